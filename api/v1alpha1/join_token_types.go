@@ -0,0 +1,108 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.status.secretName`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.expiresAt`
+// +kubebuilder:printcolumn:name="State",type=string,JSONPath=`.status.state`
+// +kubebuilder:validation:XValidation:rule="self.spec == oldSelf.spec",message="spec is immutable, create a new JoinToken instead"
+// +operator-sdk:csv:customresourcedefinitions:displayName="JoinToken"
+
+// JoinToken requests a one-time SPIRE join token used to attest a node, such as a VM, that cannot use
+// a platform node attestor. The operator creates the token through the SPIRE server API and stores it
+// in a Secret owned by the JoinToken in the operator namespace.
+type JoinToken struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              JoinTokenSpec   `json:"spec,omitempty"`
+	Status            JoinTokenStatus `json:"status,omitempty"`
+}
+
+// JoinTokenSpec defines the join token to create.
+type JoinTokenSpec struct {
+	// ttl is how long the token can be used to attest a node.
+	// Must be between 1m and 168h.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1m') && duration(self) <= duration('168h')",message="ttl must be between 1m and 168h"
+	// +kubebuilder:default:="1h"
+	TTL metav1.Duration `json:"ttl,omitempty"`
+
+	// spiffeID is an optional SPIFFE ID assigned to the node in addition to the join token
+	// SPIFFE ID. It must belong to the trust domain of the cluster.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=2048
+	// +kubebuilder:validation:Pattern=`^spiffe://.+`
+	SpiffeID string `json:"spiffeID,omitempty"`
+
+	// secretName is the name of the Secret holding the token, in the operator namespace.
+	// Defaults to the JoinToken name with a "-join-token" suffix.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// JoinTokenState describes the lifecycle of a join token
+type JoinTokenState string
+
+const (
+	// JoinTokenStatePending means the token has not been created yet
+	JoinTokenStatePending JoinTokenState = "Pending"
+	// JoinTokenStateActive means the token can be used to attest a node
+	JoinTokenStateActive JoinTokenState = "Active"
+	// JoinTokenStateUsed means a node attested with the token
+	JoinTokenStateUsed JoinTokenState = "Used"
+	// JoinTokenStateExpired means the token expired before a node attested with it
+	JoinTokenStateExpired JoinTokenState = "Expired"
+)
+
+// JoinTokenStatus defines the observed state of the join token.
+type JoinTokenStatus struct {
+	// conditions holds information about the current state of the join token.
+	ConditionalStatus `json:",inline,omitempty"`
+
+	// state is the lifecycle state of the token.
+	// +optional
+	// +kubebuilder:validation:Enum=Pending;Active;Used;Expired
+	State JoinTokenState `json:"state,omitempty"`
+
+	// secretName is the name of the Secret holding the token, in the operator namespace.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// expiresAt is when the token expires.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// agentSpiffeID is the SPIFFE ID of the agent that attested with the token.
+	// +optional
+	AgentSpiffeID string `json:"agentSpiffeID,omitempty"`
+}
+
+// GetConditionalStatus returns the conditional status of the JoinToken
+func (j *JoinToken) GetConditionalStatus() ConditionalStatus {
+	return j.Status.ConditionalStatus
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// JoinTokenList contains a list of JoinToken
+type JoinTokenList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []JoinToken `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&JoinToken{}, &JoinTokenList{})
+}
@@ -1,7 +1,9 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type ConditionalStatus struct {
@@ -24,3 +26,203 @@ type ObjectReference struct {
 	// +optional
 	Group string `json:"group,omitempty"`
 }
+
+// NodeHealthStatus summarizes the per-node health of a DaemonSet-managed operand.
+// Only nodes whose pod is not ready are listed, and the list is capped so the status
+// stays small on large clusters.
+type NodeHealthStatus struct {
+	// desiredNodes is the number of nodes that should be running the operand pod.
+	// +optional
+	DesiredNodes int32 `json:"desiredNodes,omitempty"`
+
+	// unhealthyNodeCount is the total number of nodes whose operand pod is not ready.
+	// This may be larger than the number of entries in unhealthyNodes.
+	// +optional
+	UnhealthyNodeCount int32 `json:"unhealthyNodeCount,omitempty"`
+
+	// unhealthyNodes lists nodes whose operand pod is not ready, sorted by node name.
+	// At most 20 nodes are reported.
+	// +optional
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=20
+	UnhealthyNodes []UnhealthyNode `json:"unhealthyNodes,omitempty"`
+}
+
+// UnhealthyNode describes an operand pod that is not ready on a given node.
+type UnhealthyNode struct {
+	// nodeName is the name of the node the pod is scheduled to.
+	// Empty when the pod has not been scheduled yet.
+	// +optional
+	NodeName string `json:"nodeName,omitempty"`
+
+	// podName is the name of the operand pod on the node.
+	// +required
+	PodName string `json:"podName"`
+
+	// reason is the container state reason explaining why the pod is not ready,
+	// for example CrashLoopBackOff, ImagePullBackOff or Error.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// message is the last termination message of the failing container, or the
+	// waiting message when the container never terminated. Truncated to 256 characters.
+	// +optional
+	// +kubebuilder:validation:MaxLength=256
+	Message string `json:"message,omitempty"`
+
+	// restartCount is the total number of container restarts in the pod.
+	// +optional
+	RestartCount int32 `json:"restartCount,omitempty"`
+}
+
+// DaemonSetRolloutStrategy configures how changes to a DaemonSet-managed operand are rolled out across nodes.
+type DaemonSetRolloutStrategy struct {
+	// maxUnavailable is the maximum number of nodes, or percentage of nodes, whose pod can be
+	// unavailable during the update. Must not be 0 when maxSurge is 0.
+	// Defaults to 1.
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+
+	// maxSurge is the maximum number of nodes, or percentage of nodes, that can run an updated pod
+	// alongside the old one during the update. A non-zero value keeps the Workload API available
+	// on a node while its pod is replaced.
+	// Defaults to 0.
+	// +optional
+	// +kubebuilder:validation:XIntOrString
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+
+	// canary enables a canary phase: pods on the selected nodes are updated first, and the
+	// rollout to the remaining nodes only continues once all canary pods are ready.
+	// +optional
+	Canary *CanaryRollout `json:"canary,omitempty"`
+}
+
+// CanaryRollout selects the nodes updated during the canary phase of a rollout.
+type CanaryRollout struct {
+	// nodeSelector selects the canary nodes by label.
+	// +required
+	// +kubebuilder:validation:MinProperties=1
+	// +kubebuilder:validation:MaxProperties=50
+	// +mapType=atomic
+	NodeSelector map[string]string `json:"nodeSelector"`
+}
+
+// DaemonSetRolloutStatus tracks the progress of a DaemonSet rollout driven by the operator.
+type DaemonSetRolloutStatus struct {
+	// phase is the current rollout phase.
+	// - CanaryInProgress: only pods on canary nodes are being updated.
+	// - RollingUpdate: canary pods are ready and the remaining nodes are being updated.
+	// - Complete: all pods run the current pod template.
+	// +optional
+	// +kubebuilder:validation:Enum=CanaryInProgress;RollingUpdate;Complete
+	Phase string `json:"phase,omitempty"`
+
+	// podTemplateHash identifies the pod template being rolled out.
+	// +optional
+	PodTemplateHash string `json:"podTemplateHash,omitempty"`
+
+	// desiredNodes is the number of nodes that should run the operand pod.
+	// +optional
+	DesiredNodes int32 `json:"desiredNodes,omitempty"`
+
+	// updatedNodes is the number of nodes running a pod with the current pod template.
+	// +optional
+	UpdatedNodes int32 `json:"updatedNodes,omitempty"`
+
+	// canaryNodes is the number of canary nodes running the operand pod.
+	// +optional
+	CanaryNodes int32 `json:"canaryNodes,omitempty"`
+
+	// readyCanaryNodes is the number of canary nodes running a ready pod with the current pod template.
+	// +optional
+	ReadyCanaryNodes int32 `json:"readyCanaryNodes,omitempty"`
+}
+
+// EndpointExposureType is the kind of object that publishes an endpoint outside the cluster.
+// +kubebuilder:validation:Enum=Route;Ingress;HTTPRoute;TLSRoute
+type EndpointExposureType string
+
+const (
+	// RouteExposureType publishes the endpoint through an OpenShift Route.
+	RouteExposureType EndpointExposureType = "Route"
+
+	// IngressExposureType publishes the endpoint through a networking.k8s.io Ingress.
+	IngressExposureType EndpointExposureType = "Ingress"
+
+	// HTTPRouteExposureType publishes the endpoint through a Gateway API HTTPRoute.
+	// The Gateway terminates TLS and must re-encrypt to the backend.
+	HTTPRouteExposureType EndpointExposureType = "HTTPRoute"
+
+	// TLSRouteExposureType publishes the endpoint through a Gateway API TLSRoute.
+	// The Gateway passes TLS through to the backend.
+	TLSRouteExposureType EndpointExposureType = "TLSRoute"
+)
+
+// EndpointExposure selects the object the operator creates to publish an HTTPS endpoint outside the cluster.
+// The TLS semantics of the endpoint are kept: reencrypt endpoints can use Route, Ingress or HTTPRoute,
+// and passthrough endpoints can use Route, Ingress or TLSRoute. An HTTPRoute comes with a BackendTLSPolicy
+// making the Gateway verify the service CA certificate of the backend, which requires the BackendTLSPolicy
+// CRD. An Ingress carries the TLS annotations of the OpenShift router and of ingress-nginx.
+// +kubebuilder:validation:XValidation:rule="!has(self.className) || self.type == 'Ingress'",message="className can only be set when type is Ingress"
+// +kubebuilder:validation:XValidation:rule="has(self.parentRefs) == (self.type == 'HTTPRoute' || self.type == 'TLSRoute')",message="parentRefs is required when type is HTTPRoute or TLSRoute, and not allowed otherwise"
+type EndpointExposure struct {
+	// type is the kind of object that publishes the endpoint.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:="Route"
+	Type EndpointExposureType `json:"type,omitempty"`
+
+	// className is the IngressClass of the Ingress. When empty, the cluster default class is used.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	ClassName string `json:"className,omitempty"`
+
+	// parentRefs are the Gateways the HTTPRoute or TLSRoute attaches to.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=32
+	// +listType=atomic
+	ParentRefs []GatewayParentReference `json:"parentRefs,omitempty"`
+
+	// annotations are added to the Ingress, HTTPRoute or TLSRoute, for example to configure
+	// the backend protocol, SSL passthrough or backend certificate verification of other
+	// ingress controllers.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxProperties=50
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// GatewayParentReference identifies a Gateway, or one of its listeners, that a route attaches to.
+type GatewayParentReference struct {
+	// name is the name of the Gateway.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// namespace is the namespace of the Gateway. When empty, the operator namespace is used.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	Namespace string `json:"namespace,omitempty"`
+
+	// sectionName is the name of the Gateway listener to attach to. When empty, the route
+	// attaches to every listener that allows it.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// ContainerResources overrides the resource requirements of one container of an operand pod.
+type ContainerResources struct {
+	// name is the name of the container.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// resources replace the shared resources for the container.
+	// ref: https://kubernetes.io/docs/concepts/configuration/manage-resources-containers/
+	// +kubebuilder:validation:Required
+	Resources corev1.ResourceRequirements `json:"resources"`
+}
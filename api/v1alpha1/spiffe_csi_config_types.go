@@ -42,13 +42,84 @@ type SpiffeCSIDriverSpec struct {
 	// +kubebuilder:default:="csi.spiffe.io"
 	PluginName string `json:"pluginName,omitempty"`
 
+	// rolloutStrategy configures how changes to the SPIFFE CSI driver DaemonSet are rolled out across nodes.
+	// +kubebuilder:validation:Optional
+	RolloutStrategy *DaemonSetRolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// unhealthyNodeThresholdPercent is the percentage of nodes with a non-ready SPIFFE CSI driver pod above which
+	// the NodesHealthy condition is False. Below it, the condition stays True and the nodes are only
+	// listed in status.nodeHealth, so that a few failing nodes of a large cluster do not fail readiness.
+	// Set it to 0 to report any unhealthy node as a failure.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default:=10
+	UnhealthyNodeThresholdPercent *int32 `json:"unhealthyNodeThresholdPercent,omitempty"`
+
+	// workloadInjection configures the admission webhook that adds the SPIFFE CSI volume, its mount
+	// and the SPIFFE_ENDPOINT_SOCKET environment variable to pods that opt in.
+	// +kubebuilder:validation:Optional
+	WorkloadInjection *WorkloadInjectionConfig `json:"workloadInjection,omitempty"`
+
+	// containerResources overrides the resources of individual containers of the CSI driver pods,
+	// keyed by container name. The spiffe-csi-driver and node-driver-registrar containers use the
+	// shared resources when they have no override, the set-context init container has no resources.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=3
+	// +kubebuilder:validation:XValidation:rule="self.all(c, c.name in ['spiffe-csi-driver', 'node-driver-registrar', 'set-context'])",message="containerResources names must be spiffe-csi-driver, node-driver-registrar or set-context"
+	// +listType=map
+	// +listMapKey=name
+	ContainerResources []ContainerResources `json:"containerResources,omitempty"`
+
 	CommonConfig `json:",inline"`
 }
 
+// WorkloadInjectionConfig configures the injection of the SPIFFE CSI volume into workload pods.
+// Pods opt in with the label ztwim.openshift.io/inject-spiffe-csi: "true". With an empty
+// objectSelector, the annotation of the same name is accepted as well.
+// Pods annotated with ztwim.openshift.io/inject-spiffe-helper: "true" additionally receive a
+// spiffe-helper init container and sidecar writing the X509-SVID, key and bundle to files.
+type WorkloadInjectionConfig struct {
+	// enabled turns the pod mutating webhook on or off.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="false"
+	Enabled string `json:"enabled,omitempty"`
+
+	// namespaceSelector selects the namespaces whose pods are sent to the webhook.
+	// When absent, pods of every namespace may opt in. The operator namespace and the
+	// kube-* and openshift-* namespaces are always excluded.
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// objectSelector selects the pods sent to the webhook. When absent, only pods labeled
+	// ztwim.openshift.io/inject-spiffe-csi: "true" are sent, so that the creation of other pods
+	// never depends on the webhook. An empty selector sends every pod of the selected namespaces,
+	// which is needed for pods opting in with annotations only.
+	// +kubebuilder:validation:Optional
+	ObjectSelector *metav1.LabelSelector `json:"objectSelector,omitempty"`
+
+	// failurePolicy defines how pod creation is handled when the webhook cannot be called or
+	// cannot inject the volume. Ignore admits the pod unchanged, Fail rejects it.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Ignore;Fail
+	// +kubebuilder:default:="Ignore"
+	FailurePolicy string `json:"failurePolicy,omitempty"`
+}
+
 // SpiffeCSIDriverStatus defines the observed state of the SPIFFE CSI driver reconciliation performed by the operator
 type SpiffeCSIDriverStatus struct {
 	// conditions holds information about the current state of the SPIFFE CSI driver deployment.
 	ConditionalStatus `json:",inline,omitempty"`
+
+	// nodeHealth lists the nodes whose SPIFFE CSI driver pod is not ready, along with the
+	// container state reason and restart count, to help locate failing nodes.
+	// +optional
+	NodeHealth *NodeHealthStatus `json:"nodeHealth,omitempty"`
+
+	// rollout tracks the progress of the SPIFFE CSI driver DaemonSet rollout.
+	// +optional
+	Rollout *DaemonSetRolloutStatus `json:"rollout,omitempty"`
 }
 
 // GetConditionalStatus returns the conditional status of the SpiffeCSIDriver
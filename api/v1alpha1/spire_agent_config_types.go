@@ -56,9 +56,162 @@ type SpireAgentSpec struct {
 	// +kubebuilder:validation:Optional
 	WorkloadAttestors *WorkloadAttestors `json:"workloadAttestors,omitempty"`
 
+	// rolloutStrategy configures how changes to the SPIRE agent DaemonSet are rolled out across nodes.
+	// +kubebuilder:validation:Optional
+	RolloutStrategy *DaemonSetRolloutStrategy `json:"rolloutStrategy,omitempty"`
+
+	// unhealthyNodeThresholdPercent is the percentage of nodes with a non-ready SPIRE agent pod above which
+	// the NodesHealthy condition is False. Below it, the condition stays True and the nodes are only
+	// listed in status.nodeHealth, so that a few failing nodes of a large cluster do not fail readiness.
+	// Set it to 0 to report any unhealthy node as a failure.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default:=10
+	UnhealthyNodeThresholdPercent *int32 `json:"unhealthyNodeThresholdPercent,omitempty"`
+
+	// performance tunes the SPIRE agent caching and synchronization, e.g. for nodes running thousands of pods.
+	// +kubebuilder:validation:Optional
+	Performance *SpireAgentPerformance `json:"performance,omitempty"`
+
+	// externalServer points the agents at a SPIRE server running outside the cluster instead of
+	// the SPIRE server managed by the operator. When set, no SpireServer is required and the
+	// trust domain configured in ZeroTrustWorkloadIdentityManager must match the external server.
+	// +kubebuilder:validation:Optional
+	ExternalServer *ExternalSpireServer `json:"externalServer,omitempty"`
+
 	CommonConfig `json:",inline"`
 }
 
+// ExternalSpireServer configures an off-cluster SPIRE server the agents attest to.
+type ExternalSpireServer struct {
+	// address is the DNS name or IP address of the SPIRE server.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Address string `json:"address"`
+
+	// port is the port of the SPIRE server API.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default:=8081
+	Port int32 `json:"port,omitempty"`
+
+	// trustBundle is the bootstrap bundle the agents use to authenticate the server before
+	// their first attestation. Later bundle updates are received from the server.
+	// +kubebuilder:validation:Required
+	TrustBundle ExternalServerTrustBundle `json:"trustBundle"`
+
+	// nodeAttestor selects how the agents prove the identity of their node to the server.
+	// +kubebuilder:validation:Required
+	NodeAttestor ExternalServerNodeAttestor `json:"nodeAttestor"`
+}
+
+// ExternalServerTrustBundle is the source of the bootstrap trust bundle of an external SPIRE server.
+// +kubebuilder:validation:XValidation:rule="has(self.configMap) != has(self.url)",message="exactly one of configMap or url must be set"
+type ExternalServerTrustBundle struct {
+	// configMap references a ConfigMap in the operator namespace holding the PEM encoded bundle.
+	// +kubebuilder:validation:Optional
+	ConfigMap *TrustBundleConfigMapReference `json:"configMap,omitempty"`
+
+	// url is an HTTPS URL serving the bundle, downloaded by the agents when they bootstrap.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=2048
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url,omitempty"`
+
+	// format is the format of the bundle served at url.
+	// Valid values are: pem, spiffe.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=pem;spiffe
+	// +kubebuilder:default:="pem"
+	Format string `json:"format,omitempty"`
+}
+
+// TrustBundleConfigMapReference references a key of a ConfigMap in the operator namespace.
+type TrustBundleConfigMapReference struct {
+	// name of the ConfigMap.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name"`
+
+	// key of the ConfigMap entry holding the bundle.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:default:="bundle.crt"
+	Key string `json:"key,omitempty"`
+}
+
+// ExternalServerNodeAttestor configures the node attestation performed against an external SPIRE server.
+// +kubebuilder:validation:XValidation:rule="!has(self.k8sPSAT) || self.type == 'k8sPSAT'",message="k8sPSAT can only be set when type is 'k8sPSAT'"
+type ExternalServerNodeAttestor struct {
+	// type is the node attestor used by the agents.
+	// - k8sPSAT: Kubernetes projected service account tokens. The external server must be
+	//   configured with credentials to validate the tokens of this cluster.
+	// - awsIID: AWS EC2 instance identity documents.
+	// - gcpIIT: GCP instance identity tokens.
+	// - azureMSI: Azure managed service identity tokens.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=k8sPSAT;awsIID;gcpIIT;azureMSI
+	Type string `json:"type"`
+
+	// k8sPSAT configures the k8sPSAT node attestor.
+	// +kubebuilder:validation:Optional
+	K8sPSAT *ExternalK8sPSATAttestor `json:"k8sPSAT,omitempty"`
+}
+
+// ExternalK8sPSATAttestor configures Kubernetes PSAT node attestation against an external SPIRE server.
+type ExternalK8sPSATAttestor struct {
+	// cluster is the name under which the external server knows this cluster.
+	// Defaults to the clusterName of ZeroTrustWorkloadIdentityManager.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	Cluster string `json:"cluster,omitempty"`
+
+	// audience is the audience of the projected service account token presented to the server.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:default:="spire-server"
+	Audience string `json:"audience,omitempty"`
+}
+
+// SpireAgentPerformance configures the SPIRE agent SVID cache and registration entry synchronization.
+// Unset fields keep the SPIRE defaults.
+type SpireAgentPerformance struct {
+	// x509SVIDCacheMaxSize is a soft limit on the number of X.509-SVIDs cached by the agent.
+	// SVIDs of workloads still connected to the Workload API are kept above the limit.
+	// Maps to x509_svid_cache_max_size, which defaults to 1000.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100000
+	X509SVIDCacheMaxSize *int32 `json:"x509SVIDCacheMaxSize,omitempty"`
+
+	// syncInterval is how often the agent synchronizes its registration entries and SVIDs with the server.
+	// Must be between 1s and 10m. Maps to sync_interval, which defaults to 5s.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1s') && duration(self) <= duration('10m')",message="syncInterval must be between 1s and 10m"
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+
+	// useSyncAuthorizedEntries makes the agent fetch only the entries changed since the last
+	// synchronization instead of every authorized entry. Maps to use_sync_authorized_entries.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	UseSyncAuthorizedEntries string `json:"useSyncAuthorizedEntries,omitempty"`
+
+	// availabilityTarget is the minimum remaining lifetime after which the agent rotates its SVID,
+	// keeping the agent able to serve workloads through a server outage of that length.
+	// Must be between 24h and 720h. Maps to availability_target.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('24h') && duration(self) <= duration('720h')",message="availabilityTarget must be between 24h and 720h"
+	AvailabilityTarget *metav1.Duration `json:"availabilityTarget,omitempty"`
+}
+
 // NodeAttestor defines the configuration for the Node Attestor.
 type NodeAttestor struct {
 	// k8sPSATEnabled specifies whether Kubernetes Projected Service Account Token (PSAT)
@@ -112,8 +265,10 @@ type WorkloadAttestorsVerification struct {
 	//   unless hostCertBasePath and hostCertFileName are explicitly specified.
 	// - hostCert: Use a custom CA certificate for kubelet verification. Requires hostCertBasePath
 	//   and hostCertFileName to be specified.
+	// - configMap: Copy the kubelet serving CA bundle from a cluster ConfigMap into the operator
+	//   namespace and mount it into the SPIRE agent. No host path is needed.
 	// +kubebuilder:validation:Optional
-	// +kubebuilder:validation:Enum=auto;hostCert;skip
+	// +kubebuilder:validation:Enum=auto;hostCert;skip;configMap
 	// +kubebuilder:default:="auto"
 	Type string `json:"type,omitempty"`
 
@@ -131,12 +286,49 @@ type WorkloadAttestorsVerification struct {
 	// +kubebuilder:validation:MaxLength=256
 	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9._-]+$`
 	HostCertFileName string `json:"hostCertFileName,omitempty"`
+
+	// kubeletCAConfigMap identifies the ConfigMap holding the kubelet serving CA bundle.
+	// Used when type is "configMap". Defaults to the "ca-bundle.crt" key of the
+	// "kubelet-serving-ca" ConfigMap in the "openshift-config-managed" namespace, which only
+	// exists on OpenShift. A missing ConfigMap is reported in the KubeletCAVerified condition.
+	// +kubebuilder:validation:Optional
+	KubeletCAConfigMap *KubeletCAConfigMapSource `json:"kubeletCAConfigMap,omitempty"`
+}
+
+// KubeletCAConfigMapSource references a key of a ConfigMap containing a PEM encoded CA bundle.
+type KubeletCAConfigMapSource struct {
+	// namespace of the ConfigMap.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:="openshift-config-managed"
+	// +kubebuilder:validation:MaxLength=63
+	Namespace string `json:"namespace,omitempty"`
+
+	// name of the ConfigMap.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:="kubelet-serving-ca"
+	// +kubebuilder:validation:MaxLength=253
+	Name string `json:"name,omitempty"`
+
+	// key of the ConfigMap entry holding the CA bundle.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:default:="ca-bundle.crt"
+	// +kubebuilder:validation:MaxLength=253
+	Key string `json:"key,omitempty"`
 }
 
 // SpireAgentStatus defines the observed state of the SPIRE agent reconciliation performed by the operator.
 type SpireAgentStatus struct {
 	// conditions holds information about the current state of the SPIRE agent deployment.
 	ConditionalStatus `json:",inline,omitempty"`
+
+	// nodeHealth lists the nodes whose SPIRE agent pod is not ready, along with the
+	// container state reason and restart count, to help locate failing nodes.
+	// +optional
+	NodeHealth *NodeHealthStatus `json:"nodeHealth,omitempty"`
+
+	// rollout tracks the progress of the SPIRE agent DaemonSet rollout.
+	// +optional
+	Rollout *DaemonSetRolloutStatus `json:"rollout,omitempty"`
 }
 
 // GetConditionalStatus returns the conditional status of the SpireAgent
@@ -0,0 +1,172 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Authority",type=string,JSONPath=`.spec.authority`
+// +kubebuilder:printcolumn:name="Action",type=string,JSONPath=`.spec.action`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+// +kubebuilder:validation:XValidation:rule="self.spec.authority == oldSelf.spec.authority && self.spec.action == oldSelf.spec.action && (has(self.spec.authorityID) ? has(oldSelf.spec.authorityID) && self.spec.authorityID == oldSelf.spec.authorityID : !has(oldSelf.spec.authorityID))",message="only spec.confirm can be changed, create a new SpireAuthorityOperation instead"
+// +operator-sdk:csv:customresourcedefinitions:displayName="SpireAuthorityOperation"
+
+// SpireAuthorityOperation runs a single SPIRE local authority operation, such as preparing, activating,
+// tainting or revoking an X.509 or JWT signing authority, through the SPIRE server API. The operation
+// only runs once spec.confirm is set to "true", and runs at most once.
+type SpireAuthorityOperation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              SpireAuthorityOperationSpec   `json:"spec,omitempty"`
+	Status            SpireAuthorityOperationStatus `json:"status,omitempty"`
+}
+
+// AuthorityType selects the SPIRE server signing authority
+type AuthorityType string
+
+const (
+	// AuthorityTypeX509 is the authority signing X509-SVIDs
+	AuthorityTypeX509 AuthorityType = "X509"
+	// AuthorityTypeJWT is the authority signing JWT-SVIDs
+	AuthorityTypeJWT AuthorityType = "JWT"
+)
+
+// AuthorityAction is a SPIRE local authority operation
+type AuthorityAction string
+
+const (
+	// AuthorityActionPrepare prepares a new authority that is published in the bundle but not yet used for signing
+	AuthorityActionPrepare AuthorityAction = "Prepare"
+	// AuthorityActionActivate makes the prepared authority the active signing authority
+	AuthorityActionActivate AuthorityAction = "Activate"
+	// AuthorityActionTaint marks the old authority as tainted so that workloads rotate away from it
+	AuthorityActionTaint AuthorityAction = "Taint"
+	// AuthorityActionRevoke removes the tainted old authority from the bundle
+	AuthorityActionRevoke AuthorityAction = "Revoke"
+)
+
+// SpireAuthorityOperationSpec defines the authority operation to run.
+// +kubebuilder:validation:XValidation:rule="self.action == 'Prepare' ? !has(self.authorityID) : has(self.authorityID)",message="authorityID is required for Activate, Taint and Revoke and must not be set for Prepare"
+type SpireAuthorityOperationSpec struct {
+	// authority is the signing authority the operation applies to.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=X509;JWT
+	Authority AuthorityType `json:"authority"`
+
+	// action is the local authority operation to run.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum=Prepare;Activate;Taint;Revoke
+	Action AuthorityAction `json:"action"`
+
+	// authorityID identifies the authority to activate, taint or revoke. For X.509 authorities
+	// it is the subject key ID, for JWT authorities the key ID, as reported in status.authorities.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=256
+	AuthorityID string `json:"authorityID,omitempty"`
+
+	// confirm must be set to "true" for the operation to run. Until then the operation stays
+	// Pending and status.authorities shows the current authorities to review.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="false"
+	Confirm string `json:"confirm,omitempty"`
+}
+
+// AuthorityOperationPhase describes the progress of an authority operation
+type AuthorityOperationPhase string
+
+const (
+	// AuthorityOperationPhasePending means the operation is waiting for confirmation
+	AuthorityOperationPhasePending AuthorityOperationPhase = "Pending"
+	// AuthorityOperationPhaseSucceeded means the SPIRE server completed the operation
+	AuthorityOperationPhaseSucceeded AuthorityOperationPhase = "Succeeded"
+	// AuthorityOperationPhaseFailed means the SPIRE server rejected the operation
+	AuthorityOperationPhaseFailed AuthorityOperationPhase = "Failed"
+	// AuthorityOperationPhaseRefused means the operator refused to run an unsafe operation
+	AuthorityOperationPhaseRefused AuthorityOperationPhase = "Refused"
+)
+
+// SpireAuthorityOperationStatus defines the observed state of the authority operation.
+type SpireAuthorityOperationStatus struct {
+	// conditions holds information about the current state of the operation.
+	ConditionalStatus `json:",inline,omitempty"`
+
+	// phase is the progress of the operation.
+	// +optional
+	// +kubebuilder:validation:Enum=Pending;Succeeded;Failed;Refused
+	Phase AuthorityOperationPhase `json:"phase,omitempty"`
+
+	// authorities are the authorities of the selected type, as last read from the SPIRE server.
+	// +optional
+	// +listType=atomic
+	Authorities []LocalAuthority `json:"authorities,omitempty"`
+
+	// result is the authority returned by the SPIRE server for the completed operation.
+	// +optional
+	Result *LocalAuthority `json:"result,omitempty"`
+
+	// completionTime is when the operation succeeded, failed or was refused.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// LocalAuthoritySlot is the position of an authority in the SPIRE rotation
+type LocalAuthoritySlot string
+
+const (
+	// LocalAuthoritySlotActive is the authority currently signing SVIDs
+	LocalAuthoritySlotActive LocalAuthoritySlot = "Active"
+	// LocalAuthoritySlotPrepared is the authority that becomes active on the next rotation
+	LocalAuthoritySlotPrepared LocalAuthoritySlot = "Prepared"
+	// LocalAuthoritySlotOld is the previously active authority still published in the bundle
+	LocalAuthoritySlotOld LocalAuthoritySlot = "Old"
+)
+
+// LocalAuthority describes a SPIRE server signing authority
+type LocalAuthority struct {
+	// slot is the position of the authority in the rotation.
+	// +optional
+	Slot LocalAuthoritySlot `json:"slot,omitempty"`
+
+	// authorityID is the subject key ID of an X.509 authority or the key ID of a JWT authority.
+	AuthorityID string `json:"authorityID"`
+
+	// expiresAt is when the authority expires.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// upstreamAuthoritySubjectKeyID is the subject key ID of the upstream authority that signed
+	// an X.509 authority, when an upstream authority is configured.
+	// +optional
+	UpstreamAuthoritySubjectKeyID string `json:"upstreamAuthoritySubjectKeyID,omitempty"`
+
+	// tainted reports whether the old authority is tainted. An old authority can only be revoked
+	// once it is tainted.
+	// +optional
+	Tainted bool `json:"tainted,omitempty"`
+}
+
+// GetConditionalStatus returns the conditional status of the SpireAuthorityOperation
+func (o *SpireAuthorityOperation) GetConditionalStatus() ConditionalStatus {
+	return o.Status.ConditionalStatus
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SpireAuthorityOperationList contains a list of SpireAuthorityOperation
+type SpireAuthorityOperationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SpireAuthorityOperation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SpireAuthorityOperation{}, &SpireAuthorityOperationList{})
+}
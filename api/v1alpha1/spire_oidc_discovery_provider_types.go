@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,6 +24,11 @@ type SpireOIDCDiscoveryProvider struct {
 
 // SpireOIDCDiscoveryProviderSpec defines the specifications for configuration related to the SPIRE OIDC
 // discovery provider
+// +kubebuilder:validation:XValidation:rule="!has(self.exposure) || self.exposure.type != 'TLSRoute' || has(self.acme)",message="the OIDC discovery endpoint is re-encrypted and can only be exposed through a TLSRoute with acme"
+// +kubebuilder:validation:XValidation:rule="!has(self.acme) || !has(self.exposure) || self.exposure.type != 'HTTPRoute'",message="with acme the provider terminates TLS itself, use a Route, Ingress or TLSRoute"
+// +kubebuilder:validation:XValidation:rule="!has(self.acme) || !has(self.externalSecretRef)",message="acme and externalSecretRef are mutually exclusive"
+// +kubebuilder:validation:XValidation:rule="!has(self.acme) || self.jwtIssuer.lowerAscii().startsWith('https://')",message="acme requires an https jwtIssuer"
+// +kubebuilder:validation:XValidation:rule="!has(self.previousJwtIssuer) || self.previousJwtIssuer != self.jwtIssuer",message="previousJwtIssuer must differ from jwtIssuer"
 type SpireOIDCDiscoveryProviderSpec struct {
 
 	// logLevel sets the logging level for the operand.
@@ -54,17 +60,36 @@ type SpireOIDCDiscoveryProviderSpec struct {
 	// +kubebuilder:validation:Pattern=`^(?i)https?://[^\s?#]+$`
 	JwtIssuer string `json:"jwtIssuer,omitempty"`
 
+	// previousJwtIssuer is the JWT issuer url being replaced by jwtIssuer. The provider keeps serving
+	// the discovery document and keys for it, and the managed Route, Ingress or Gateway API route keeps
+	// routing its host, so that relying parties can move to the new issuer before the SpireServer
+	// jwtIssuer is changed.
+	// To change the issuer safely, set jwtIssuer to the new url and previousJwtIssuer to the old one,
+	// update the relying parties, set the SpireServer jwtIssuer to the new url, and remove
+	// previousJwtIssuer once the JWT-SVIDs with the old issuer have expired.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=512
+	// +kubebuilder:validation:Pattern=`^(?i)https?://[^\s?#]+$`
+	PreviousJwtIssuer string `json:"previousJwtIssuer,omitempty"`
+
 	// replicaCount is the number of replicas for the OIDC provider.
-	// Must be between 1 and 5.
+	// Must be between 1 and 5. Ignored when autoscaling is set.
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=5
 	// +kubebuilder:default:=1
 	ReplicaCount int `json:"replicaCount,omitempty"`
 
-	// managedRoute controls whether the operator automatically creates an OpenShift Route
-	// for the OIDC discovery provider endpoints.
-	// "true": The operator creates and maintains an OpenShift Route automatically for OIDC discovery endpoints (*.apps.).
+	// autoscaling scales the OIDC provider with a HorizontalPodAutoscaler. When set, replicaCount
+	// is ignored and the operator leaves the Deployment replicas to the autoscaler.
+	// When the minimum number of replicas is at least 2, whether fixed or autoscaled, the operator
+	// also manages a PodDisruptionBudget that keeps at least one replica available during drains.
+	// +kubebuilder:validation:Optional
+	Autoscaling *OIDCAutoscalingConfig `json:"autoscaling,omitempty"`
+
+	// managedRoute controls whether the operator automatically exposes the OIDC discovery
+	// provider endpoints outside the cluster.
+	// "true": The operator creates and maintains the object selected by exposure, an OpenShift Route by default (*.apps.).
 	// "false": Administrators manually configure Routes or ingress, offering more control over routing behavior.
 	// +kubebuilder:default:="true"
 	// +kubebuilder:validation:Enum:="true";"false"
@@ -79,14 +104,360 @@ type SpireOIDCDiscoveryProviderSpec struct {
 	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
 	ExternalSecretRef string `json:"externalSecretRef,omitempty"`
 
+	// acme makes the provider obtain a publicly trusted certificate for the jwtIssuer host from an ACME
+	// CA, such as Let's Encrypt, and terminate TLS itself. The managed Route then passes TLS through to
+	// the provider, and issuance and renewal are reported in the CertificateReady condition.
+	// The issuer host must be publicly reachable on port 443 for the TLS-ALPN-01 challenge.
+	// The challenge must reach the replica that ordered the certificate, so acme requires a single
+	// replica without autoscaling. The account key and certificates are kept on a PersistentVolumeClaim
+	// so that restarts do not order new certificates against the rate limits of the CA.
+	// +kubebuilder:validation:Optional
+	Acme *OIDCAcmeConfig `json:"acme,omitempty"`
+
+	// exposure selects the object that publishes the OIDC discovery endpoints when managedRoute is "true".
+	// TLS is terminated at the edge with the externalSecretRef certificate and re-encrypted to the provider,
+	// so Route, Ingress and HTTPRoute are supported. With acme TLS is passed through instead, so Route,
+	// Ingress and TLSRoute are supported. When absent, an OpenShift Route is used.
+	// +kubebuilder:validation:Optional
+	Exposure *EndpointExposure `json:"exposure,omitempty"`
+
+	// additionalDomains are extra host names, such as vanity hostnames or a CDN host, for which the
+	// OIDC discovery provider serves discovery requests in addition to its service names and the jwtIssuer host.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=32
+	// +kubebuilder:validation:items:MaxLength=253
+	// +kubebuilder:validation:items:Pattern=`^[a-zA-Z0-9]([-a-zA-Z0-9.]*[a-zA-Z0-9])?$`
+	// +listType=set
+	AdditionalDomains []string `json:"additionalDomains,omitempty"`
+
+	// jwksURI overrides the jwks_uri advertised in the discovery document, for example to send
+	// relying parties to a CDN in front of the provider. An http URL also allows the insecure scheme
+	// in the discovery document.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=512
+	// +kubebuilder:validation:Pattern=`^(?i)https?://[^\s?#]+$`
+	JwksURI string `json:"jwksURI,omitempty"`
+
+	// setKeyUse sets the "use" parameter of the published keys to "sig", which some relying parties require.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	SetKeyUse string `json:"setKeyUse,omitempty"`
+
+	// serverAPI makes the provider fetch the JWT signing keys from the SPIRE Server API instead of the
+	// Workload API of the local SPIRE agent, so that it no longer needs an agent on its node. A sidecar
+	// forwards the requests to the server API proxy of the spire-server pod with a certificate that is
+	// only allowed to read the trust bundle.
+	// +kubebuilder:validation:Optional
+	ServerAPI *OIDCServerAPIConfig `json:"serverAPI,omitempty"`
+
+	// export publishes a static copy of the discovery document and JWKS for relying parties, such as
+	// cloud workload identity federation, that cannot reach the provider. The copy is refreshed whenever
+	// the JWT signing keys rotate. jwtIssuer must then be the public URL the copy is served from.
+	// +kubebuilder:validation:Optional
+	Export *OIDCDocumentExport `json:"export,omitempty"`
+
+	// endpointProbe makes the operator periodically fetch the discovery document and JWKS from the provider
+	// and report in the EndpointHealthy condition whether they serve the configured issuer and the current
+	// JWT signing keys. The signing keys are compared with the JWKS exported to export.configMapName, and
+	// are reported as unverified without it.
+	// +kubebuilder:validation:Optional
+	EndpointProbe *OIDCEndpointProbe `json:"endpointProbe,omitempty"`
+
+	// cloudFederation renders ready-to-apply artifacts federating cloud identities with the JWT-SVIDs of the
+	// given SPIFFE IDs into the spire-oidc-cloud-federation ConfigMap. They are regenerated when the issuer or
+	// the certificate it is served with changes.
+	// +kubebuilder:validation:Optional
+	CloudFederation *OIDCCloudFederation `json:"cloudFederation,omitempty"`
+
+	// identity customizes the ClusterSPIFFEID the provider pods receive their SVID from.
+	// +kubebuilder:validation:Optional
+	Identity *OIDCProviderIdentity `json:"identity,omitempty"`
+
 	CommonConfig `json:",inline"`
 }
 
+// OIDCServerAPIConfig configures how the OIDC discovery provider reads the keys from the SPIRE Server API
+type OIDCServerAPIConfig struct {
+	// pollInterval is how often the provider fetches the keys from the server. Defaults to 10s.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1s') && duration(self) <= duration('10m')",message="pollInterval must be between 1s and 10m"
+	PollInterval *metav1.Duration `json:"pollInterval,omitempty"`
+}
+
+// OIDCProviderIdentity configures the SPIFFE ID and DNS names of the OIDC discovery provider
+type OIDCProviderIdentity struct {
+	// spiffeIDTemplate is the SPIFFE ID template of the provider pods, rendered as in ClusterSPIFFEID
+	// templates. It must render a SPIFFE ID in the trust domain. Defaults to
+	// spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Pattern=`^spiffe://`
+	SPIFFEIDTemplate string `json:"spiffeIDTemplate,omitempty"`
+
+	// dnsNameTemplates are templates for DNS names added to the X509-SVIDs of the provider pods.
+	// Defaults to oidc-discovery.{{ .TrustDomain }}.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=atomic
+	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
+
+	// podSelector selects the provider pods. It must match the labels of the provider Deployment pods,
+	// including the custom labels. Defaults to the name, instance and component labels of the provider.
+	// +kubebuilder:validation:Optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+}
+
+// OIDCCloudFederation configures the cloud identity federation artifacts
+// +kubebuilder:validation:XValidation:rule="has(self.aws) || has(self.gcp) || has(self.azure)",message="at least one of aws, gcp or azure must be set"
+type OIDCCloudFederation struct {
+	// subjects are the SPIFFE IDs allowed to exchange their JWT-SVIDs for cloud credentials.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=20
+	// +kubebuilder:validation:items:Pattern=`^spiffe://[^\s]+$`
+	// +kubebuilder:validation:items:MaxLength=512
+	// +listType=set
+	Subjects []string `json:"subjects"`
+
+	// aws renders the IAM OIDC identity provider and the role trust policy.
+	// +kubebuilder:validation:Optional
+	AWS *OIDCAWSFederation `json:"aws,omitempty"`
+
+	// gcp renders the workload identity pool and its OIDC provider.
+	// +kubebuilder:validation:Optional
+	GCP *OIDCGCPFederation `json:"gcp,omitempty"`
+
+	// azure renders one federated identity credential per subject.
+	// +kubebuilder:validation:Optional
+	Azure *OIDCAzureFederation `json:"azure,omitempty"`
+}
+
+// OIDCAWSFederation configures the AWS IAM federation artifacts
+type OIDCAWSFederation struct {
+	// accountID is the AWS account the IAM OIDC identity provider is created in.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]{12}$`
+	AccountID string `json:"accountID"`
+
+	// partition is the AWS partition of the account.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="aws";"aws-cn";"aws-us-gov"
+	// +kubebuilder:default:="aws"
+	Partition string `json:"partition,omitempty"`
+
+	// audiences the JWT-SVIDs are requested for. Defaults to sts.amazonaws.com.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=10
+	// +listType=set
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+// OIDCGCPFederation configures the GCP workload identity federation artifacts
+type OIDCGCPFederation struct {
+	// projectNumber is the number of the project hosting the workload identity pool.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[0-9]{1,20}$`
+	ProjectNumber string `json:"projectNumber"`
+
+	// poolID is the ID of the workload identity pool.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9-]{4,32}$`
+	PoolID string `json:"poolID"`
+
+	// providerID is the ID of the OIDC provider in the pool.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-z0-9-]{4,32}$`
+	ProviderID string `json:"providerID"`
+
+	// audiences the JWT-SVIDs are requested for. Defaults to the full resource name of the provider.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=10
+	// +listType=set
+	Audiences []string `json:"audiences,omitempty"`
+}
+
+// OIDCAzureFederation configures the Azure federated identity credential artifacts
+type OIDCAzureFederation struct {
+	// audience the JWT-SVIDs are requested for. Defaults to api://AzureADTokenExchange.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=600
+	Audience string `json:"audience,omitempty"`
+}
+
+// OIDCEndpointProbe configures the health probe of the OIDC discovery endpoints
+type OIDCEndpointProbe struct {
+	// interval is how often the endpoints are probed. Must be between 30s and 1h. Defaults to 5m.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('30s') && duration(self) <= duration('1h')",message="interval must be between 30s and 1h"
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// probePublishedHost also probes the endpoints through the host reported in status.hostname, covering
+	// the Route, Ingress or HTTPRoute in front of the provider. The Service is always probed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="false"
+	ProbePublishedHost string `json:"probePublishedHost,omitempty"`
+}
+
+// OIDCAcmeConfig configures ACME certificate provisioning for the OIDC discovery provider
+type OIDCAcmeConfig struct {
+	// directoryUrl is the ACME directory URL.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=512
+	// +kubebuilder:validation:Pattern=`^https://.*`
+	// +kubebuilder:default:="https://acme-v02.api.letsencrypt.org/directory"
+	DirectoryUrl string `json:"directoryUrl,omitempty"`
+
+	// email for ACME account registration
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9][a-zA-Z0-9._%+-]*[a-zA-Z0-9]@[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?(\.[a-zA-Z0-9]([a-zA-Z0-9-]*[a-zA-Z0-9])?)*\.[a-zA-Z]{2,}$`
+	Email string `json:"email"`
+
+	// tosAccepted indicates acceptance of the ACME CA Terms of Service, which is required to issue certificates.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Enum:="true"
+	TosAccepted string `json:"tosAccepted"`
+
+	// storageClassName is the StorageClass of the PersistentVolumeClaim holding the ACME account key and
+	// certificates. The default StorageClass of the cluster is used when unset.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	StorageClassName string `json:"storageClassName,omitempty"`
+}
+
+// OIDCDocumentExport configures where the static discovery document and JWKS are published
+// +kubebuilder:validation:XValidation:rule="has(self.configMapName) || has(self.s3)",message="at least one of configMapName or s3 must be set"
+type OIDCDocumentExport struct {
+	// configMapName is the ConfigMap in the operator namespace that receives the discovery document
+	// under the openid-configuration key and the JWKS under the keys key.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// s3 uploads the discovery document and JWKS to an S3-compatible bucket.
+	// +kubebuilder:validation:Optional
+	S3 *OIDCS3Export `json:"s3,omitempty"`
+
+	// refreshInterval is how often the operator checks the JWT signing keys for rotation.
+	// Must be between 1m and 24h. Defaults to 5m.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1m') && duration(self) <= duration('24h')",message="refreshInterval must be between 1m and 24h"
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+}
+
+// OIDCS3Export configures the upload of the discovery document and JWKS to an S3-compatible bucket
+type OIDCS3Export struct {
+	// bucket is the name of the bucket. Objects are addressed path-style on the configured endpoint.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=3
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Pattern=`^[a-z0-9][a-z0-9.-]*[a-z0-9]$`
+	Bucket string `json:"bucket"`
+
+	// prefix is prepended to the object keys. The documents are written to
+	// <prefix>/.well-known/openid-configuration and <prefix>/keys.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=512
+	// +kubebuilder:validation:Pattern=`^[a-zA-Z0-9!_.*'()-]+(/[a-zA-Z0-9!_.*'()-]+)*$`
+	Prefix string `json:"prefix,omitempty"`
+
+	// secretRef is the name of a Secret in the operator namespace with the endpoint and region keys,
+	// and an optional caBundle key to trust a private endpoint. Static credentials are given with the
+	// accessKeyID and secretAccessKey keys, plus sessionToken for temporary ones. Alternatively, the
+	// roleARN key makes the operator assume the role through STS AssumeRoleWithWebIdentity with its
+	// projected service account token, read from the webIdentityTokenFile key or
+	// /var/run/secrets/openshift/serviceaccount/token, at the stsEndpoint key or the regional endpoint.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	SecretRef string `json:"secretRef"`
+}
+
+// OIDCAutoscalingConfig configures the HorizontalPodAutoscaler of the OIDC discovery provider
+// +kubebuilder:validation:XValidation:rule="self.minReplicas <= self.maxReplicas",message="minReplicas must not exceed maxReplicas"
+// +kubebuilder:validation:XValidation:rule="has(self.targetCPUUtilizationPercentage) || has(self.requestRate)",message="at least one of targetCPUUtilizationPercentage or requestRate must be set"
+type OIDCAutoscalingConfig struct {
+	// minReplicas is the lower limit for the number of replicas.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=20
+	// +kubebuilder:default:=2
+	MinReplicas int32 `json:"minReplicas,omitempty"`
+
+	// maxReplicas is the upper limit for the number of replicas.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=20
+	MaxReplicas int32 `json:"maxReplicas"`
+
+	// targetCPUUtilizationPercentage is the average CPU utilization, as a percentage of the
+	// requested CPU, that the autoscaler maintains. Requires resources.requests.cpu.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	TargetCPUUtilizationPercentage *int32 `json:"targetCPUUtilizationPercentage,omitempty"`
+
+	// requestRate scales on the per-pod request rate reported by a custom metrics API adapter.
+	// +kubebuilder:validation:Optional
+	RequestRate *OIDCRequestRateTarget `json:"requestRate,omitempty"`
+}
+
+// OIDCRequestRateTarget configures scaling on a per-pod request rate metric
+type OIDCRequestRateTarget struct {
+	// metricName is the name of the pods metric served through the custom metrics API,
+	// for example by prometheus-adapter, such as http_requests_per_second.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=253
+	MetricName string `json:"metricName"`
+
+	// averageValue is the target value of the metric averaged across all replicas.
+	// +kubebuilder:validation:Required
+	AverageValue resource.Quantity `json:"averageValue"`
+}
+
 // SpireOIDCDiscoveryProviderStatus defines the observed state of the SPIRE OIDC discovery provider
 // reconciliation performed by the operator
 type SpireOIDCDiscoveryProviderStatus struct {
 	// conditions holds information about the current state of the SPIRE OIDC discovery provider deployment.
 	ConditionalStatus `json:",inline,omitempty"`
+
+	// hostname is the host at which the managed Route, Ingress or HTTPRoute publishes
+	// the OIDC discovery endpoints.
+	// +optional
+	Hostname string `json:"hostname,omitempty"`
+
+	// export reports the last static export of the discovery document and JWKS.
+	// +optional
+	Export *OIDCDocumentExportStatus `json:"export,omitempty"`
+}
+
+// OIDCDocumentExportStatus reports the last static export of the discovery document and JWKS
+type OIDCDocumentExportStatus struct {
+	// lastExportTime is when the operator last published changed documents.
+	// +optional
+	LastExportTime *metav1.Time `json:"lastExportTime,omitempty"`
+
+	// keyIDs are the IDs of the JWT signing keys in the exported JWKS.
+	// +optional
+	// +listType=atomic
+	KeyIDs []string `json:"keyIDs,omitempty"`
+
+	// configMapName is the ConfigMap the documents were last written to.
+	// +optional
+	ConfigMapName string `json:"configMapName,omitempty"`
+
+	// documentHash identifies the exported documents and their destination, so that they are
+	// only uploaded again after a key rotation or a configuration change.
+	// +optional
+	DocumentHash string `json:"documentHash,omitempty"`
 }
 
 // GetConditionalStatus returns the conditional status of the SpireOIDCDiscoveryProvider
@@ -1,6 +1,7 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -113,10 +114,180 @@ type SpireServerSpec struct {
 	// +kubebuilder:validation:Optional
 	UpstreamAuthority *UpstreamAuthorityConfig `json:"upstreamAuthority,omitempty"`
 
+	// performance tunes the SPIRE server registration entry cache, together with SpireAgent performance settings.
+	// +kubebuilder:validation:Optional
+	Performance *SpireServerPerformance `json:"performance,omitempty"`
+
+	// exposure publishes the SPIRE server gRPC endpoint outside the cluster so that agents
+	// running on VMs or in other clusters can reach it. When absent, the endpoint is only
+	// reachable through the ClusterIP spire-server Service.
+	// +kubebuilder:validation:Optional
+	Exposure *SpireServerExposure `json:"exposure,omitempty"`
+
+	// agentLifecycle removes agents that are no longer active from the SPIRE datastore,
+	// such as agents of nodes deleted by the autoscaler. When absent, stale agents are kept
+	// until they are purged manually.
+	// +kubebuilder:validation:Optional
+	AgentLifecycle *SpireServerAgentLifecycle `json:"agentLifecycle,omitempty"`
+
+	// containerResources overrides the resources of individual containers of the spire-server pod,
+	// keyed by container name. Containers without an override use the shared resources.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=3
+	// +kubebuilder:validation:XValidation:rule="self.all(c, c.name in ['spire-server', 'spire-controller-manager', 'spire-server-api-proxy'])",message="containerResources names must be spire-server, spire-controller-manager or spire-server-api-proxy"
+	// +listType=map
+	// +listMapKey=name
+	ContainerResources []ContainerResources `json:"containerResources,omitempty"`
+
 	CommonConfig `json:",inline"`
 }
 
+// SpireServerExposure configures how the SPIRE server gRPC endpoint is exposed outside the cluster.
+// Agents authenticate the server by its SPIFFE ID, so TLS is always terminated by the server itself.
+// +kubebuilder:validation:XValidation:rule="has(self.route) || has(self.service)",message="at least one of route or service must be set"
+type SpireServerExposure struct {
+	// route exposes the gRPC endpoint through a passthrough OpenShift Route.
+	// +kubebuilder:validation:Optional
+	Route *SpireServerRouteExposure `json:"route,omitempty"`
+
+	// service exposes the gRPC endpoint through a dedicated LoadBalancer or NodePort Service.
+	// +kubebuilder:validation:Optional
+	Service *SpireServerServiceExposure `json:"service,omitempty"`
+
+	// dnsNames are additional names agents reach the endpoint at, such as a DNS record pointing at
+	// the load balancer. SPIRE does not put DNS names in the server SVID, agents verify its SPIFFE ID.
+	// The first name of route.host and dnsNames becomes the common name of the CA subject when
+	// caSubject.commonName is empty, and all names are reported in status.exposure.dnsNames.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=10
+	// +kubebuilder:validation:items:MaxLength=253
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +listType=set
+	DNSNames []string `json:"dnsNames,omitempty"`
+}
+
+// SpireServerRouteExposure configures the passthrough Route for the SPIRE server gRPC endpoint
+type SpireServerRouteExposure struct {
+	// host is the hostname of the Route. When empty, the router generates one.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	Host string `json:"host,omitempty"`
+}
+
+// SpireServerServiceExposure configures the Service exposing the SPIRE server gRPC endpoint
+// +kubebuilder:validation:XValidation:rule="!has(self.nodePort) || self.type == 'NodePort'",message="nodePort can only be set when type is NodePort"
+// +kubebuilder:validation:XValidation:rule="!has(self.loadBalancerSourceRanges) || self.type == 'LoadBalancer'",message="loadBalancerSourceRanges can only be set when type is LoadBalancer"
+type SpireServerServiceExposure struct {
+	// type is the type of the Service.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort
+	// +kubebuilder:default:="LoadBalancer"
+	Type corev1.ServiceType `json:"type,omitempty"`
+
+	// port is the port the Service listens on.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +kubebuilder:default:=443
+	Port int32 `json:"port,omitempty"`
+
+	// nodePort pins the node port when type is NodePort. When unset, one is allocated.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Minimum=30000
+	// +kubebuilder:validation:Maximum=32767
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// loadBalancerSourceRanges restricts the client CIDRs allowed through the load balancer.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=50
+	// +listType=set
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+
+	// annotations are added to the Service, for example to configure the cloud load balancer.
+	// +kubebuilder:validation:Optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AgentPruneMode selects what removes expired agents from the SPIRE datastore
+type AgentPruneMode string
+
+const (
+	// AgentPruneModeServer lets the SPIRE server prune expired agents itself
+	AgentPruneModeServer AgentPruneMode = "Server"
+	// AgentPruneModeOperator makes the operator purge expired agents through the SPIRE server API
+	AgentPruneModeOperator AgentPruneMode = "Operator"
+)
+
+// SpireServerAgentLifecycle configures how stale agents are removed from the SPIRE datastore.
+// +kubebuilder:validation:XValidation:rule="!has(self.purgeInterval) || self.pruneMode == 'Operator' || (has(self.banAgentsOfDeletedNodes) && self.banAgentsOfDeletedNodes == 'true')",message="purgeInterval requires pruneMode Operator or banAgentsOfDeletedNodes 'true'"
+type SpireServerAgentLifecycle struct {
+	// pruneMode selects what removes agents whose SVID expired more than expiredFor ago.
+	// Server renders the SPIRE server prune_attested_nodes_expired_for setting.
+	// Operator purges them through the SPIRE server API every purgeInterval and reports
+	// the number of purged agents in status.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum=Server;Operator
+	// +kubebuilder:default:="Server"
+	PruneMode AgentPruneMode `json:"pruneMode,omitempty"`
+
+	// expiredFor is how long an agent SVID must have been expired before the agent is removed.
+	// Must be between 1h and 8760h.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1h') && duration(self) <= duration('8760h')",message="expiredFor must be between 1h and 8760h"
+	// +kubebuilder:default:="24h"
+	ExpiredFor metav1.Duration `json:"expiredFor,omitempty"`
+
+	// purgeInterval is how often the operator checks the SPIRE datastore for agents to purge
+	// or ban. Must be between 5m and 24h. Defaults to 1h.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('5m') && duration(self) <= duration('24h')",message="purgeInterval must be between 5m and 24h"
+	PurgeInterval *metav1.Duration `json:"purgeInterval,omitempty"`
+
+	// banAgentsOfDeletedNodes bans k8s_psat agents whose Kubernetes Node no longer exists,
+	// so that their SVID cannot be renewed before it expires.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="false"
+	BanAgentsOfDeletedNodes string `json:"banAgentsOfDeletedNodes,omitempty"`
+}
+
+// SpireServerPerformance configures the SPIRE server registration entry cache.
+// Unset fields keep the SPIRE defaults.
+// +kubebuilder:validation:XValidation:rule="!has(self.pruneEventsOlderThan) || (has(self.eventsBasedCache) && self.eventsBasedCache == 'true')",message="pruneEventsOlderThan requires eventsBasedCache to be 'true'"
+type SpireServerPerformance struct {
+	// cacheReloadInterval is how often the server refreshes its registration entry cache.
+	// Must be between 1s and 10m. Maps to cache_reload_interval, which defaults to 5s.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1s') && duration(self) <= duration('10m')",message="cacheReloadInterval must be between 1s and 10m"
+	CacheReloadInterval *metav1.Duration `json:"cacheReloadInterval,omitempty"`
+
+	// eventsBasedCache makes the server apply datastore change events to its entry cache
+	// instead of reloading every entry, which reduces datastore load with many entries.
+	// Maps to events_based_cache.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	EventsBasedCache string `json:"eventsBasedCache,omitempty"`
+
+	// pruneEventsOlderThan is the age after which datastore change events are deleted.
+	// Requires eventsBasedCache. Must be between 1m and 168h.
+	// Maps to prune_events_older_than, which defaults to 12h.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('1m') && duration(self) <= duration('168h')",message="pruneEventsOlderThan must be between 1m and 168h"
+	PruneEventsOlderThan *metav1.Duration `json:"pruneEventsOlderThan,omitempty"`
+}
+
 // FederationConfig defines federation bundle endpoint and federated trust domains
+// +kubebuilder:validation:XValidation:rule="!has(self.exposure) || self.exposure.type != 'HTTPRoute' || (has(self.bundleEndpoint.httpsWeb) && has(self.bundleEndpoint.httpsWeb.servingCert))",message="HTTPRoute exposure requires the https_web profile with servingCert, other bundle endpoints use TLS passthrough"
+// +kubebuilder:validation:XValidation:rule="!has(self.exposure) || self.exposure.type != 'TLSRoute' || !has(self.bundleEndpoint.httpsWeb) || !has(self.bundleEndpoint.httpsWeb.servingCert)",message="TLSRoute exposure cannot be used with servingCert, which re-encrypts at the edge"
 type FederationConfig struct {
 	// bundleEndpoint configures this cluster's federation bundle endpoint
 	// +kubebuilder:validation:Required
@@ -128,13 +299,20 @@ type FederationConfig struct {
 	// +kubebuilder:validation:MaxItems=50
 	FederatesWith []FederatesWithConfig `json:"federatesWith,omitempty"`
 
-	// managedRoute enables or disables automatic Route creation for the federation endpoint
-	// "true": Allows automatic exposure of federation endpoint through a managed OpenShift Route.
+	// managedRoute enables or disables automatic exposure of the federation endpoint
+	// "true": Allows automatic exposure of federation endpoint through the object selected by exposure, an OpenShift Route by default.
 	// "false": Allows administrators to manually configure exposure using custom OpenShift Routes or ingress, offering more control over routing behavior.
 	// +kubebuilder:default:="true"
 	// +kubebuilder:validation:Enum:="true";"false"
 	// +kubebuilder:validation:Optional
 	ManagedRoute string `json:"managedRoute,omitempty"`
+
+	// exposure selects the object that publishes the federation endpoint when managedRoute is "true".
+	// The https_spiffe profile and ACME certificates use TLS passthrough (Route, Ingress or TLSRoute),
+	// while servingCert re-encrypts at the edge (Route, Ingress or HTTPRoute).
+	// When absent, an OpenShift Route is used.
+	// +kubebuilder:validation:Optional
+	Exposure *EndpointExposure `json:"exposure,omitempty"`
 }
 
 // BundleEndpointConfig configures how this cluster exposes its federation bundle
@@ -476,6 +654,55 @@ type SecretKeyReference struct {
 type SpireServerStatus struct {
 	// conditions holds information about the current state of the SPIRE server resources.
 	ConditionalStatus `json:",inline,omitempty"`
+
+	// exposure reports the addresses at which the SPIRE server gRPC endpoint is reachable
+	// from outside the cluster.
+	// +optional
+	Exposure *SpireServerExposureStatus `json:"exposure,omitempty"`
+
+	// agentLifecycle reports the agents removed by the operator.
+	// +optional
+	AgentLifecycle *SpireServerAgentLifecycleStatus `json:"agentLifecycle,omitempty"`
+
+	// federationHostname is the host at which the managed Route, Ingress, HTTPRoute or TLSRoute
+	// publishes the federation bundle endpoint.
+	// +optional
+	FederationHostname string `json:"federationHostname,omitempty"`
+}
+
+// SpireServerExposureStatus reports the external addresses of the SPIRE server gRPC endpoint
+type SpireServerExposureStatus struct {
+	// addresses lists the reachable endpoints as host:port, from the Route host and
+	// the load balancer ingress.
+	// +optional
+	// +listType=atomic
+	Addresses []string `json:"addresses,omitempty"`
+
+	// nodePort is the allocated node port when the endpoint is exposed through a NodePort Service.
+	// Agents connect to any node address on this port.
+	// +optional
+	NodePort int32 `json:"nodePort,omitempty"`
+
+	// dnsNames lists the names the endpoint is published under, from the Route host, the load
+	// balancer hostnames and spec.exposure.dnsNames.
+	// +optional
+	// +listType=atomic
+	DNSNames []string `json:"dnsNames,omitempty"`
+}
+
+// SpireServerAgentLifecycleStatus reports the agents purged and banned by the operator
+type SpireServerAgentLifecycleStatus struct {
+	// lastPurgeTime is when the operator last checked the SPIRE datastore for stale agents.
+	// +optional
+	LastPurgeTime *metav1.Time `json:"lastPurgeTime,omitempty"`
+
+	// purgedAgents is the total number of expired agents purged by the operator.
+	// +optional
+	PurgedAgents int64 `json:"purgedAgents,omitempty"`
+
+	// bannedAgents is the total number of agents banned because their Node was deleted.
+	// +optional
+	BannedAgents int64 `json:"bannedAgents,omitempty"`
 }
 
 // GetConditionalStatus returns the conditional status of the SpireServer
@@ -0,0 +1,102 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="SPIFFE ID",type=string,JSONPath=`.status.spiffeID`
+// +kubebuilder:printcolumn:name="Secret",type=string,JSONPath=`.status.secretName`
+// +kubebuilder:printcolumn:name="Expires",type=date,JSONPath=`.status.expiresAt`
+// +kubebuilder:printcolumn:name="Next Rotation",type=date,JSONPath=`.status.nextRotationTime`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +operator-sdk:csv:customresourcedefinitions:displayName="SVIDSecret"
+
+// SVIDSecret requests an X509-SVID written into a kubernetes.io/tls Secret of its namespace, for
+// consumers that read certificates from Secrets instead of the Workload API, such as ingress
+// controllers. The operator mints the SVID through the SPIRE server API and rotates the Secret
+// before the SVID expires. The namespace must be allowed to use SVIDSecrets by a WorkloadIdentityPolicy.
+type SVIDSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              SVIDSecretSpec   `json:"spec,omitempty"`
+	Status            SVIDSecretStatus `json:"status,omitempty"`
+}
+
+// SVIDSecretSpec defines the X509-SVID written into the Secret.
+type SVIDSecretSpec struct {
+	// spiffeIDPath is the path of the SPIFFE ID, appended to the trust domain.
+	// The path must stay under a prefix allowed for the namespace.
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Pattern=`^/.+`
+	SPIFFEIDPath string `json:"spiffeIDPath"`
+
+	// secretName is the name of the Secret in the namespace of the SVIDSecret.
+	// Defaults to the name of the SVIDSecret.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=253
+	SecretName string `json:"secretName,omitempty"`
+
+	// dnsNames are added to the X509-SVID as DNS subject alternative names.
+	// Names under <namespace>.svc and <namespace>.svc.cluster.local are always allowed, other names
+	// must be allowed for the namespace by a WorkloadIdentityPolicy.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=set
+	DNSNames []string `json:"dnsNames,omitempty"`
+
+	// ttl is the requested lifetime of the X509-SVID. When unset, the SPIRE server default is used.
+	// Must not exceed the maximum allowed for the namespace.
+	// The Secret is rotated when half of the lifetime has passed.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	// +kubebuilder:validation:XValidation:rule="duration(self) >= duration('10m')",message="ttl must be at least 10m"
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+}
+
+// SVIDSecretStatus defines the observed state of the SVIDSecret.
+type SVIDSecretStatus struct {
+	// conditions holds information about the current state of the SVIDSecret.
+	ConditionalStatus `json:",inline,omitempty"`
+
+	// spiffeID is the SPIFFE ID of the X509-SVID in the Secret.
+	// +optional
+	SPIFFEID string `json:"spiffeID,omitempty"`
+
+	// secretName is the name of the Secret holding the X509-SVID.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// expiresAt is when the X509-SVID in the Secret expires.
+	// +optional
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+
+	// nextRotationTime is when the operator replaces the X509-SVID in the Secret.
+	// +optional
+	NextRotationTime *metav1.Time `json:"nextRotationTime,omitempty"`
+}
+
+// GetConditionalStatus returns the conditional status of the SVIDSecret
+func (s *SVIDSecret) GetConditionalStatus() ConditionalStatus {
+	return s.Status.ConditionalStatus
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SVIDSecretList contains a list of SVIDSecret
+type SVIDSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SVIDSecret `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SVIDSecret{}, &SVIDSecretList{})
+}
@@ -0,0 +1,105 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +operator-sdk:csv:customresourcedefinitions:displayName="WorkloadIdentityPolicy"
+
+// WorkloadIdentityPolicy is defined by cluster administrators to control what WorkloadIdentity resources
+// in the selected namespaces may request. A namespace selected by several policies is allowed everything
+// any of them allows. Without any policy, a namespace may only request SPIFFE ID paths under
+// /ns/<namespace>/ and DNS names of its own Services, with the default TTLs of the SPIRE server and
+// no hint, federated trust domain, auto-populated DNS names or SVIDSecret resources.
+type WorkloadIdentityPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              WorkloadIdentityPolicySpec `json:"spec,omitempty"`
+}
+
+// WorkloadIdentityPolicySpec defines what the selected namespaces may request.
+type WorkloadIdentityPolicySpec struct {
+	// namespaceSelector selects the namespaces the policy applies to.
+	// An empty selector selects every namespace.
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// allowedSPIFFEIDPathPrefixes are the path prefixes the SPIFFE IDs may use, in addition
+	// to /ns/<namespace>/. The token {namespace} is replaced with the namespace name.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:Pattern=`^/.*/$`
+	// +listType=set
+	AllowedSPIFFEIDPathPrefixes []string `json:"allowedSPIFFEIDPathPrefixes,omitempty"`
+
+	// allowedDNSNames are the DNS names the SVIDs may contain, in addition to the names of the Services
+	// of the namespace under <namespace>.svc and <namespace>.svc.cluster.local. A leading "*." matches
+	// any name under the domain. The token {namespace} is replaced with the namespace name.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:MaxLength=253
+	// +kubebuilder:validation:items:Pattern=`^(\*\.)?[a-z0-9{}]([-a-z0-9{}]*[a-z0-9{}])?(\.[a-z0-9{}]([-a-z0-9{}]*[a-z0-9{}])?)*$`
+	// +listType=set
+	AllowedDNSNames []string `json:"allowedDNSNames,omitempty"`
+
+	// allowAutoPopulateDNSNames lets WorkloadIdentity resources in the selected namespaces add the
+	// DNS names of the Services selecting their pods, including the short <service> and
+	// <service>.<namespace> names, to the SVIDs.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="false"
+	AllowAutoPopulateDNSNames string `json:"allowAutoPopulateDNSNames,omitempty"`
+
+	// allowedHints are the hints the WorkloadIdentity resources may set. The token {namespace} is
+	// replaced with the namespace name.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=50
+	// +kubebuilder:validation:items:MaxLength=256
+	// +listType=set
+	AllowedHints []string `json:"allowedHints,omitempty"`
+
+	// allowedFederatesWith are the federated trust domains the workloads may receive bundles for.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=50
+	// +listType=set
+	AllowedFederatesWith []string `json:"allowedFederatesWith,omitempty"`
+
+	// maxTTL is the longest X509-SVID TTL the workloads may request. When unset, the TTL is not limited.
+	// A namespace no policy selects may not request a TTL.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	MaxTTL *metav1.Duration `json:"maxTTL,omitempty"`
+
+	// maxJWTTTL is the longest JWT-SVID TTL the workloads may request. When unset, the TTL is not limited.
+	// A namespace no policy selects may not request a TTL.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	MaxJWTTTL *metav1.Duration `json:"maxJWTTTL,omitempty"`
+
+	// allowSVIDSecrets lets SVIDSecret resources in the selected namespaces write X509-SVIDs,
+	// including their private keys, into Secrets.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="false"
+	AllowSVIDSecrets string `json:"allowSVIDSecrets,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WorkloadIdentityPolicyList contains a list of WorkloadIdentityPolicy
+type WorkloadIdentityPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkloadIdentityPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkloadIdentityPolicy{}, &WorkloadIdentityPolicyList{})
+}
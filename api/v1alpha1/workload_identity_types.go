@@ -0,0 +1,114 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced
+// +kubebuilder:printcolumn:name="SPIFFE ID",type=string,JSONPath=`.status.spiffeIDTemplate`
+// +kubebuilder:printcolumn:name="ClusterSPIFFEID",type=string,JSONPath=`.status.clusterSPIFFEIDName`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:validation:XValidation:rule="size(self.metadata.name) <= 172",message="metadata.name must be at most 172 characters"
+// +operator-sdk:csv:customresourcedefinitions:displayName="WorkloadIdentity"
+
+// WorkloadIdentity lets an application team request SPIFFE IDs for pods in its own namespace without
+// cluster-admin access. The operator translates it into a ClusterSPIFFEID whose namespace selector is
+// locked to the namespace of the WorkloadIdentity, after checking it against the WorkloadIdentityPolicy
+// resources that select the namespace.
+type WorkloadIdentity struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              WorkloadIdentitySpec   `json:"spec,omitempty"`
+	Status            WorkloadIdentityStatus `json:"status,omitempty"`
+}
+
+// WorkloadIdentitySpec defines the SPIFFE IDs issued to the selected pods.
+type WorkloadIdentitySpec struct {
+	// podSelector selects the pods of the namespace that receive the SPIFFE ID.
+	// When absent, every pod of the namespace is selected.
+	// +kubebuilder:validation:Optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// spiffeIDPath is the path template of the SPIFFE ID, appended to the trust domain.
+	// The pod and node are available to the template as in ClusterSPIFFEID templates,
+	// for example {{ .PodSpec.ServiceAccountName }}.
+	// The path must stay under a prefix allowed for the namespace.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Pattern=`^/.+`
+	// +kubebuilder:default:="/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}"
+	SPIFFEIDPath string `json:"spiffeIDPath,omitempty"`
+
+	// dnsNameTemplates are templates for DNS names added to the X509-SVIDs.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=atomic
+	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
+
+	// autoPopulateDNSNames adds the DNS names of the Services selecting the pod to the X509-SVIDs.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="false"
+	AutoPopulateDNSNames string `json:"autoPopulateDNSNames,omitempty"`
+
+	// federatesWith lists the federated trust domains whose bundles are delivered to the workloads.
+	// Each trust domain must be allowed for the namespace.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=set
+	FederatesWith []string `json:"federatesWith,omitempty"`
+
+	// ttl is the time-to-live of the X509-SVIDs. Must not exceed the maximum allowed for the namespace.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// jwtTTL is the time-to-live of the JWT-SVIDs. Must not exceed the maximum allowed for the namespace.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	JWTTTL *metav1.Duration `json:"jwtTTL,omitempty"`
+
+	// hint is an opaque string returned with the SVIDs to help workloads choose between identities.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=256
+	Hint string `json:"hint,omitempty"`
+}
+
+// WorkloadIdentityStatus defines the observed state of the WorkloadIdentity.
+type WorkloadIdentityStatus struct {
+	// conditions holds information about the current state of the WorkloadIdentity.
+	ConditionalStatus `json:",inline,omitempty"`
+
+	// clusterSPIFFEIDName is the name of the ClusterSPIFFEID created for the WorkloadIdentity.
+	// +optional
+	ClusterSPIFFEIDName string `json:"clusterSPIFFEIDName,omitempty"`
+
+	// spiffeIDTemplate is the SPIFFE ID template of the ClusterSPIFFEID.
+	// +optional
+	SPIFFEIDTemplate string `json:"spiffeIDTemplate,omitempty"`
+}
+
+// GetConditionalStatus returns the conditional status of the WorkloadIdentity
+func (w *WorkloadIdentity) GetConditionalStatus() ConditionalStatus {
+	return w.Status.ConditionalStatus
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WorkloadIdentityList contains a list of WorkloadIdentity
+type WorkloadIdentityList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WorkloadIdentity `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WorkloadIdentity{}, &WorkloadIdentityList{})
+}
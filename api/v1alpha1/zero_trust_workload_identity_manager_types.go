@@ -58,6 +58,41 @@ type ZeroTrustWorkloadIdentityManagerStatus struct {
 	// +listType=map
 	// +listMapKey=kind
 	Operands []OperandStatus `json:"operands,omitempty"`
+
+	// clusterSPIFFEIDFindings lists the problems found in the ClusterSPIFFEIDs handled by the operator's
+	// spire-controller-manager, such as several ClusterSPIFFEIDs selecting the same pods. The
+	// ClusterSPIFFEIDs are audited whenever they change and periodically for pod and namespace changes.
+	// +optional
+	// +listType=atomic
+	// +kubebuilder:validation:MaxItems=100
+	ClusterSPIFFEIDFindings []ClusterSPIFFEIDFinding `json:"clusterSPIFFEIDFindings,omitempty"`
+}
+
+// ClusterSPIFFEIDFindingType is the kind of problem found in ClusterSPIFFEIDs
+type ClusterSPIFFEIDFindingType string
+
+const (
+	// ClusterSPIFFEIDFindingOverlap means several ClusterSPIFFEIDs select the same pods
+	ClusterSPIFFEIDFindingOverlap ClusterSPIFFEIDFindingType = "Overlap"
+	// ClusterSPIFFEIDFindingTTLTooLong means the TTL exceeds what the SPIRE server CA TTL guarantees
+	ClusterSPIFFEIDFindingTTLTooLong ClusterSPIFFEIDFindingType = "TTLTooLong"
+	// ClusterSPIFFEIDFindingInvalidTemplate means a template fails to parse or renders an invalid value
+	ClusterSPIFFEIDFindingInvalidTemplate ClusterSPIFFEIDFindingType = "InvalidTemplate"
+)
+
+// ClusterSPIFFEIDFinding describes a problem found in one or more ClusterSPIFFEIDs
+type ClusterSPIFFEIDFinding struct {
+	// type is the kind of problem.
+	// +kubebuilder:validation:Enum=Overlap;TTLTooLong;InvalidTemplate
+	Type ClusterSPIFFEIDFindingType `json:"type"`
+
+	// clusterSPIFFEIDs are the names of the ClusterSPIFFEIDs involved.
+	// +listType=atomic
+	ClusterSPIFFEIDs []string `json:"clusterSPIFFEIDs"`
+
+	// message describes the problem.
+	// +kubebuilder:validation:MaxLength=1024
+	Message string `json:"message"`
 }
 
 // OperandStatus represents the status of a single managed operand CR.
@@ -144,6 +179,58 @@ type ZeroTrustWorkloadIdentityManagerSpec struct {
 	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
 	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="bundleConfigMap is immutable and cannot be changed"
 	BundleConfigMap string `json:"bundleConfigMap"`
+
+	// defaultIdentity configures the fallback SPIFFE ID issued to pods that no other ClusterSPIFFEID selects.
+	// Pods in the operator namespace never receive the default identity.
+	// +kubebuilder:validation:Optional
+	DefaultIdentity *DefaultIdentityConfig `json:"defaultIdentity,omitempty"`
+}
+
+// DefaultIdentityConfig configures the default workload identity
+type DefaultIdentityConfig struct {
+	// enabled controls whether pods receive the default identity.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Enum:="true";"false"
+	// +kubebuilder:default:="true"
+	Enabled string `json:"enabled,omitempty"`
+
+	// spiffeIDTemplate is the SPIFFE ID template of the default identity. The trust domain, cluster name,
+	// pod and node are available to the template as in ClusterSPIFFEID templates, for example
+	// spiffe://{{ .TrustDomain }}/cluster/{{ .ClusterName }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}.
+	// The template is validated before it is applied.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxLength=1024
+	// +kubebuilder:validation:Pattern=`^spiffe://`
+	// +kubebuilder:default:="spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}"
+	SPIFFEIDTemplate string `json:"spiffeIDTemplate,omitempty"`
+
+	// namespaceSelector limits the default identity to the selected namespaces.
+	// When absent, every namespace except the operator namespace is selected.
+	// +kubebuilder:validation:Optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+
+	// podSelector limits the default identity to the selected pods.
+	// When absent, every pod of the selected namespaces is selected.
+	// +kubebuilder:validation:Optional
+	PodSelector *metav1.LabelSelector `json:"podSelector,omitempty"`
+
+	// ttl is the time-to-live of the X509-SVIDs. When unset, the SPIRE server default is used.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// jwtTTL is the time-to-live of the JWT-SVIDs. When unset, the SPIRE server default is used.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:Type=string
+	// +kubebuilder:validation:Format=duration
+	JWTTTL *metav1.Duration `json:"jwtTTL,omitempty"`
+
+	// dnsNameTemplates are templates for DNS names added to the X509-SVIDs.
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:validation:MaxItems=20
+	// +listType=atomic
+	DNSNameTemplates []string `json:"dnsNameTemplates,omitempty"`
 }
 
 // CommonConfig has similar config required for all other APIs
@@ -24,6 +24,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -76,6 +77,48 @@ func (in *CASubject) DeepCopy() *CASubject {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CanaryRollout) DeepCopyInto(out *CanaryRollout) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CanaryRollout.
+func (in *CanaryRollout) DeepCopy() *CanaryRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(CanaryRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterSPIFFEIDFinding) DeepCopyInto(out *ClusterSPIFFEIDFinding) {
+	*out = *in
+	if in.ClusterSPIFFEIDs != nil {
+		in, out := &in.ClusterSPIFFEIDs, &out.ClusterSPIFFEIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSPIFFEIDFinding.
+func (in *ClusterSPIFFEIDFinding) DeepCopy() *ClusterSPIFFEIDFinding {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterSPIFFEIDFinding)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonConfig) DeepCopyInto(out *CommonConfig) {
 	*out = *in
@@ -149,305 +192,322 @@ func (in *ConditionalStatus) DeepCopy() *ConditionalStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *DataStore) DeepCopyInto(out *DataStore) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataStore.
-func (in *DataStore) DeepCopy() *DataStore {
-	if in == nil {
-		return nil
-	}
-	out := new(DataStore)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FederatesWithConfig) DeepCopyInto(out *FederatesWithConfig) {
+func (in *ContainerResources) DeepCopyInto(out *ContainerResources) {
 	*out = *in
+	in.Resources.DeepCopyInto(&out.Resources)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederatesWithConfig.
-func (in *FederatesWithConfig) DeepCopy() *FederatesWithConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ContainerResources.
+func (in *ContainerResources) DeepCopy() *ContainerResources {
 	if in == nil {
 		return nil
 	}
-	out := new(FederatesWithConfig)
+	out := new(ContainerResources)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *FederationConfig) DeepCopyInto(out *FederationConfig) {
+func (in *DaemonSetRolloutStatus) DeepCopyInto(out *DaemonSetRolloutStatus) {
 	*out = *in
-	in.BundleEndpoint.DeepCopyInto(&out.BundleEndpoint)
-	if in.FederatesWith != nil {
-		in, out := &in.FederatesWith, &out.FederatesWith
-		*out = make([]FederatesWithConfig, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationConfig.
-func (in *FederationConfig) DeepCopy() *FederationConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonSetRolloutStatus.
+func (in *DaemonSetRolloutStatus) DeepCopy() *DaemonSetRolloutStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(FederationConfig)
+	out := new(DaemonSetRolloutStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *HttpsWebConfig) DeepCopyInto(out *HttpsWebConfig) {
+func (in *DaemonSetRolloutStrategy) DeepCopyInto(out *DaemonSetRolloutStrategy) {
 	*out = *in
-	if in.Acme != nil {
-		in, out := &in.Acme, &out.Acme
-		*out = new(AcmeConfig)
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
 		**out = **in
 	}
-	if in.ServingCert != nil {
-		in, out := &in.ServingCert, &out.ServingCert
-		*out = new(ServingCertConfig)
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(intstr.IntOrString)
 		**out = **in
 	}
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(CanaryRollout)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpsWebConfig.
-func (in *HttpsWebConfig) DeepCopy() *HttpsWebConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DaemonSetRolloutStrategy.
+func (in *DaemonSetRolloutStrategy) DeepCopy() *DaemonSetRolloutStrategy {
 	if in == nil {
 		return nil
 	}
-	out := new(HttpsWebConfig)
+	out := new(DaemonSetRolloutStrategy)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeyManager) DeepCopyInto(out *KeyManager) {
+func (in *DataStore) DeepCopyInto(out *DataStore) {
 	*out = *in
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyManager.
-func (in *KeyManager) DeepCopy() *KeyManager {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DataStore.
+func (in *DataStore) DeepCopy() *DataStore {
 	if in == nil {
 		return nil
 	}
-	out := new(KeyManager)
+	out := new(DataStore)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NodeAttestor) DeepCopyInto(out *NodeAttestor) {
+func (in *DefaultIdentityConfig) DeepCopyInto(out *DefaultIdentityConfig) {
 	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.JWTTTL != nil {
+		in, out := &in.JWTTTL, &out.JWTTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.DNSNameTemplates != nil {
+		in, out := &in.DNSNameTemplates, &out.DNSNameTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAttestor.
-func (in *NodeAttestor) DeepCopy() *NodeAttestor {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DefaultIdentityConfig.
+func (in *DefaultIdentityConfig) DeepCopy() *DefaultIdentityConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(NodeAttestor)
+	out := new(DefaultIdentityConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
+func (in *EndpointExposure) DeepCopyInto(out *EndpointExposure) {
 	*out = *in
+	if in.ParentRefs != nil {
+		in, out := &in.ParentRefs, &out.ParentRefs
+		*out = make([]GatewayParentReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectReference.
-func (in *ObjectReference) DeepCopy() *ObjectReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EndpointExposure.
+func (in *EndpointExposure) DeepCopy() *EndpointExposure {
 	if in == nil {
 		return nil
 	}
-	out := new(ObjectReference)
+	out := new(EndpointExposure)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperandStatus) DeepCopyInto(out *OperandStatus) {
+func (in *ExternalK8sPSATAttestor) DeepCopyInto(out *ExternalK8sPSATAttestor) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]v1.Condition, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandStatus.
-func (in *OperandStatus) DeepCopy() *OperandStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalK8sPSATAttestor.
+func (in *ExternalK8sPSATAttestor) DeepCopy() *ExternalK8sPSATAttestor {
 	if in == nil {
 		return nil
 	}
-	out := new(OperandStatus)
+	out := new(ExternalK8sPSATAttestor)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *Persistence) DeepCopyInto(out *Persistence) {
+func (in *ExternalServerNodeAttestor) DeepCopyInto(out *ExternalServerNodeAttestor) {
 	*out = *in
+	if in.K8sPSAT != nil {
+		in, out := &in.K8sPSAT, &out.K8sPSAT
+		*out = new(ExternalK8sPSATAttestor)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Persistence.
-func (in *Persistence) DeepCopy() *Persistence {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalServerNodeAttestor.
+func (in *ExternalServerNodeAttestor) DeepCopy() *ExternalServerNodeAttestor {
 	if in == nil {
 		return nil
 	}
-	out := new(Persistence)
+	out := new(ExternalServerNodeAttestor)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+func (in *ExternalServerTrustBundle) DeepCopyInto(out *ExternalServerTrustBundle) {
 	*out = *in
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(TrustBundleConfigMapReference)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
-func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalServerTrustBundle.
+func (in *ExternalServerTrustBundle) DeepCopy() *ExternalServerTrustBundle {
 	if in == nil {
 		return nil
 	}
-	out := new(SecretKeyReference)
+	out := new(ExternalServerTrustBundle)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ServingCertConfig) DeepCopyInto(out *ServingCertConfig) {
+func (in *ExternalSpireServer) DeepCopyInto(out *ExternalSpireServer) {
 	*out = *in
+	in.TrustBundle.DeepCopyInto(&out.TrustBundle)
+	in.NodeAttestor.DeepCopyInto(&out.NodeAttestor)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServingCertConfig.
-func (in *ServingCertConfig) DeepCopy() *ServingCertConfig {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalSpireServer.
+func (in *ExternalSpireServer) DeepCopy() *ExternalSpireServer {
 	if in == nil {
 		return nil
 	}
-	out := new(ServingCertConfig)
+	out := new(ExternalSpireServer)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpiffeCSIDriver) DeepCopyInto(out *SpiffeCSIDriver) {
+func (in *FederatesWithConfig) DeepCopyInto(out *FederatesWithConfig) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriver.
-func (in *SpiffeCSIDriver) DeepCopy() *SpiffeCSIDriver {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederatesWithConfig.
+func (in *FederatesWithConfig) DeepCopy() *FederatesWithConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(SpiffeCSIDriver)
+	out := new(FederatesWithConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SpiffeCSIDriver) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpiffeCSIDriverList) DeepCopyInto(out *SpiffeCSIDriverList) {
+func (in *FederationConfig) DeepCopyInto(out *FederationConfig) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]SpiffeCSIDriver, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
-		}
+	in.BundleEndpoint.DeepCopyInto(&out.BundleEndpoint)
+	if in.FederatesWith != nil {
+		in, out := &in.FederatesWith, &out.FederatesWith
+		*out = make([]FederatesWithConfig, len(*in))
+		copy(*out, *in)
+	}
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(EndpointExposure)
+		(*in).DeepCopyInto(*out)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriverList.
-func (in *SpiffeCSIDriverList) DeepCopy() *SpiffeCSIDriverList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FederationConfig.
+func (in *FederationConfig) DeepCopy() *FederationConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(SpiffeCSIDriverList)
+	out := new(FederationConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SpiffeCSIDriverList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpiffeCSIDriverSpec) DeepCopyInto(out *SpiffeCSIDriverSpec) {
+func (in *GatewayParentReference) DeepCopyInto(out *GatewayParentReference) {
 	*out = *in
-	in.CommonConfig.DeepCopyInto(&out.CommonConfig)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriverSpec.
-func (in *SpiffeCSIDriverSpec) DeepCopy() *SpiffeCSIDriverSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayParentReference.
+func (in *GatewayParentReference) DeepCopy() *GatewayParentReference {
 	if in == nil {
 		return nil
 	}
-	out := new(SpiffeCSIDriverSpec)
+	out := new(GatewayParentReference)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpiffeCSIDriverStatus) DeepCopyInto(out *SpiffeCSIDriverStatus) {
+func (in *HttpsWebConfig) DeepCopyInto(out *HttpsWebConfig) {
 	*out = *in
-	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.Acme != nil {
+		in, out := &in.Acme, &out.Acme
+		*out = new(AcmeConfig)
+		**out = **in
+	}
+	if in.ServingCert != nil {
+		in, out := &in.ServingCert, &out.ServingCert
+		*out = new(ServingCertConfig)
+		**out = **in
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriverStatus.
-func (in *SpiffeCSIDriverStatus) DeepCopy() *SpiffeCSIDriverStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HttpsWebConfig.
+func (in *HttpsWebConfig) DeepCopy() *HttpsWebConfig {
 	if in == nil {
 		return nil
 	}
-	out := new(SpiffeCSIDriverStatus)
+	out := new(HttpsWebConfig)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpireAgent) DeepCopyInto(out *SpireAgent) {
+func (in *JoinToken) DeepCopyInto(out *JoinToken) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
+	out.Spec = in.Spec
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgent.
-func (in *SpireAgent) DeepCopy() *SpireAgent {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinToken.
+func (in *JoinToken) DeepCopy() *JoinToken {
 	if in == nil {
 		return nil
 	}
-	out := new(SpireAgent)
+	out := new(JoinToken)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SpireAgent) DeepCopyObject() runtime.Object {
+func (in *JoinToken) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -455,31 +515,31 @@ func (in *SpireAgent) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpireAgentList) DeepCopyInto(out *SpireAgentList) {
+func (in *JoinTokenList) DeepCopyInto(out *JoinTokenList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]SpireAgent, len(*in))
+		*out = make([]JoinToken, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgentList.
-func (in *SpireAgentList) DeepCopy() *SpireAgentList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinTokenList.
+func (in *JoinTokenList) DeepCopy() *JoinTokenList {
 	if in == nil {
 		return nil
 	}
-	out := new(SpireAgentList)
+	out := new(JoinTokenList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SpireAgentList) DeepCopyObject() runtime.Object {
+func (in *JoinTokenList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -487,70 +547,1003 @@ func (in *SpireAgentList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpireAgentSpec) DeepCopyInto(out *SpireAgentSpec) {
+func (in *JoinTokenSpec) DeepCopyInto(out *JoinTokenSpec) {
 	*out = *in
-	if in.NodeAttestor != nil {
-		in, out := &in.NodeAttestor, &out.NodeAttestor
-		*out = new(NodeAttestor)
-		**out = **in
-	}
-	if in.WorkloadAttestors != nil {
-		in, out := &in.WorkloadAttestors, &out.WorkloadAttestors
-		*out = new(WorkloadAttestors)
-		(*in).DeepCopyInto(*out)
-	}
-	in.CommonConfig.DeepCopyInto(&out.CommonConfig)
+	out.TTL = in.TTL
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgentSpec.
-func (in *SpireAgentSpec) DeepCopy() *SpireAgentSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinTokenSpec.
+func (in *JoinTokenSpec) DeepCopy() *JoinTokenSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(SpireAgentSpec)
+	out := new(JoinTokenSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpireAgentStatus) DeepCopyInto(out *SpireAgentStatus) {
+func (in *JoinTokenStatus) DeepCopyInto(out *JoinTokenStatus) {
 	*out = *in
 	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgentStatus.
-func (in *SpireAgentStatus) DeepCopy() *SpireAgentStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new JoinTokenStatus.
+func (in *JoinTokenStatus) DeepCopy() *JoinTokenStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(SpireAgentStatus)
+	out := new(JoinTokenStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpireOIDCDiscoveryProvider) DeepCopyInto(out *SpireOIDCDiscoveryProvider) {
+func (in *KeyManager) DeepCopyInto(out *KeyManager) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	in.Spec.DeepCopyInto(&out.Spec)
-	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireOIDCDiscoveryProvider.
-func (in *SpireOIDCDiscoveryProvider) DeepCopy() *SpireOIDCDiscoveryProvider {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyManager.
+func (in *KeyManager) DeepCopy() *KeyManager {
 	if in == nil {
 		return nil
 	}
-	out := new(SpireOIDCDiscoveryProvider)
+	out := new(KeyManager)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SpireOIDCDiscoveryProvider) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KubeletCAConfigMapSource) DeepCopyInto(out *KubeletCAConfigMapSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KubeletCAConfigMapSource.
+func (in *KubeletCAConfigMapSource) DeepCopy() *KubeletCAConfigMapSource {
+	if in == nil {
+		return nil
+	}
+	out := new(KubeletCAConfigMapSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalAuthority) DeepCopyInto(out *LocalAuthority) {
+	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalAuthority.
+func (in *LocalAuthority) DeepCopy() *LocalAuthority {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalAuthority)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeAttestor) DeepCopyInto(out *NodeAttestor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeAttestor.
+func (in *NodeAttestor) DeepCopy() *NodeAttestor {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeAttestor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeHealthStatus) DeepCopyInto(out *NodeHealthStatus) {
+	*out = *in
+	if in.UnhealthyNodes != nil {
+		in, out := &in.UnhealthyNodes, &out.UnhealthyNodes
+		*out = make([]UnhealthyNode, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeHealthStatus.
+func (in *NodeHealthStatus) DeepCopy() *NodeHealthStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeHealthStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCAWSFederation) DeepCopyInto(out *OIDCAWSFederation) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCAWSFederation.
+func (in *OIDCAWSFederation) DeepCopy() *OIDCAWSFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCAWSFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCAcmeConfig) DeepCopyInto(out *OIDCAcmeConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCAcmeConfig.
+func (in *OIDCAcmeConfig) DeepCopy() *OIDCAcmeConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCAcmeConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCAutoscalingConfig) DeepCopyInto(out *OIDCAutoscalingConfig) {
+	*out = *in
+	if in.TargetCPUUtilizationPercentage != nil {
+		in, out := &in.TargetCPUUtilizationPercentage, &out.TargetCPUUtilizationPercentage
+		*out = new(int32)
+		**out = **in
+	}
+	if in.RequestRate != nil {
+		in, out := &in.RequestRate, &out.RequestRate
+		*out = new(OIDCRequestRateTarget)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCAutoscalingConfig.
+func (in *OIDCAutoscalingConfig) DeepCopy() *OIDCAutoscalingConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCAutoscalingConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCAzureFederation) DeepCopyInto(out *OIDCAzureFederation) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCAzureFederation.
+func (in *OIDCAzureFederation) DeepCopy() *OIDCAzureFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCAzureFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCCloudFederation) DeepCopyInto(out *OIDCCloudFederation) {
+	*out = *in
+	if in.Subjects != nil {
+		in, out := &in.Subjects, &out.Subjects
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(OIDCAWSFederation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GCP != nil {
+		in, out := &in.GCP, &out.GCP
+		*out = new(OIDCGCPFederation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Azure != nil {
+		in, out := &in.Azure, &out.Azure
+		*out = new(OIDCAzureFederation)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCCloudFederation.
+func (in *OIDCCloudFederation) DeepCopy() *OIDCCloudFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCCloudFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCDocumentExport) DeepCopyInto(out *OIDCDocumentExport) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(OIDCS3Export)
+		**out = **in
+	}
+	if in.RefreshInterval != nil {
+		in, out := &in.RefreshInterval, &out.RefreshInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCDocumentExport.
+func (in *OIDCDocumentExport) DeepCopy() *OIDCDocumentExport {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCDocumentExport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCDocumentExportStatus) DeepCopyInto(out *OIDCDocumentExportStatus) {
+	*out = *in
+	if in.LastExportTime != nil {
+		in, out := &in.LastExportTime, &out.LastExportTime
+		*out = (*in).DeepCopy()
+	}
+	if in.KeyIDs != nil {
+		in, out := &in.KeyIDs, &out.KeyIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCDocumentExportStatus.
+func (in *OIDCDocumentExportStatus) DeepCopy() *OIDCDocumentExportStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCDocumentExportStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCEndpointProbe) DeepCopyInto(out *OIDCEndpointProbe) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCEndpointProbe.
+func (in *OIDCEndpointProbe) DeepCopy() *OIDCEndpointProbe {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCEndpointProbe)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCGCPFederation) DeepCopyInto(out *OIDCGCPFederation) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCGCPFederation.
+func (in *OIDCGCPFederation) DeepCopy() *OIDCGCPFederation {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCGCPFederation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCProviderIdentity) DeepCopyInto(out *OIDCProviderIdentity) {
+	*out = *in
+	if in.DNSNameTemplates != nil {
+		in, out := &in.DNSNameTemplates, &out.DNSNameTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCProviderIdentity.
+func (in *OIDCProviderIdentity) DeepCopy() *OIDCProviderIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCProviderIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCRequestRateTarget) DeepCopyInto(out *OIDCRequestRateTarget) {
+	*out = *in
+	out.AverageValue = in.AverageValue.DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCRequestRateTarget.
+func (in *OIDCRequestRateTarget) DeepCopy() *OIDCRequestRateTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCRequestRateTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCS3Export) DeepCopyInto(out *OIDCS3Export) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCS3Export.
+func (in *OIDCS3Export) DeepCopy() *OIDCS3Export {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCS3Export)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCServerAPIConfig) DeepCopyInto(out *OIDCServerAPIConfig) {
+	*out = *in
+	if in.PollInterval != nil {
+		in, out := &in.PollInterval, &out.PollInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCServerAPIConfig.
+func (in *OIDCServerAPIConfig) DeepCopy() *OIDCServerAPIConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCServerAPIConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ObjectReference) DeepCopyInto(out *ObjectReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ObjectReference.
+func (in *ObjectReference) DeepCopy() *ObjectReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ObjectReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperandStatus) DeepCopyInto(out *OperandStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperandStatus.
+func (in *OperandStatus) DeepCopy() *OperandStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OperandStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Persistence) DeepCopyInto(out *Persistence) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Persistence.
+func (in *Persistence) DeepCopy() *Persistence {
+	if in == nil {
+		return nil
+	}
+	out := new(Persistence)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SVIDSecret) DeepCopyInto(out *SVIDSecret) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SVIDSecret.
+func (in *SVIDSecret) DeepCopy() *SVIDSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(SVIDSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SVIDSecret) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SVIDSecretList) DeepCopyInto(out *SVIDSecretList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SVIDSecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SVIDSecretList.
+func (in *SVIDSecretList) DeepCopy() *SVIDSecretList {
+	if in == nil {
+		return nil
+	}
+	out := new(SVIDSecretList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SVIDSecretList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SVIDSecretSpec) DeepCopyInto(out *SVIDSecretSpec) {
+	*out = *in
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SVIDSecretSpec.
+func (in *SVIDSecretSpec) DeepCopy() *SVIDSecretSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SVIDSecretSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SVIDSecretStatus) DeepCopyInto(out *SVIDSecretStatus) {
+	*out = *in
+	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+	if in.NextRotationTime != nil {
+		in, out := &in.NextRotationTime, &out.NextRotationTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SVIDSecretStatus.
+func (in *SVIDSecretStatus) DeepCopy() *SVIDSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SVIDSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretKeyReference) DeepCopyInto(out *SecretKeyReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretKeyReference.
+func (in *SecretKeyReference) DeepCopy() *SecretKeyReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretKeyReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServingCertConfig) DeepCopyInto(out *ServingCertConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServingCertConfig.
+func (in *ServingCertConfig) DeepCopy() *ServingCertConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(ServingCertConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpiffeCSIDriver) DeepCopyInto(out *SpiffeCSIDriver) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriver.
+func (in *SpiffeCSIDriver) DeepCopy() *SpiffeCSIDriver {
+	if in == nil {
+		return nil
+	}
+	out := new(SpiffeCSIDriver)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpiffeCSIDriver) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpiffeCSIDriverList) DeepCopyInto(out *SpiffeCSIDriverList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SpiffeCSIDriver, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriverList.
+func (in *SpiffeCSIDriverList) DeepCopy() *SpiffeCSIDriverList {
+	if in == nil {
+		return nil
+	}
+	out := new(SpiffeCSIDriverList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpiffeCSIDriverList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpiffeCSIDriverSpec) DeepCopyInto(out *SpiffeCSIDriverSpec) {
+	*out = *in
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(DaemonSetRolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnhealthyNodeThresholdPercent != nil {
+		in, out := &in.UnhealthyNodeThresholdPercent, &out.UnhealthyNodeThresholdPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.WorkloadInjection != nil {
+		in, out := &in.WorkloadInjection, &out.WorkloadInjection
+		*out = new(WorkloadInjectionConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerResources != nil {
+		in, out := &in.ContainerResources, &out.ContainerResources
+		*out = make([]ContainerResources, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.CommonConfig.DeepCopyInto(&out.CommonConfig)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriverSpec.
+func (in *SpiffeCSIDriverSpec) DeepCopy() *SpiffeCSIDriverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SpiffeCSIDriverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpiffeCSIDriverStatus) DeepCopyInto(out *SpiffeCSIDriverStatus) {
+	*out = *in
+	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.NodeHealth != nil {
+		in, out := &in.NodeHealth, &out.NodeHealth
+		*out = new(NodeHealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(DaemonSetRolloutStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpiffeCSIDriverStatus.
+func (in *SpiffeCSIDriverStatus) DeepCopy() *SpiffeCSIDriverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpiffeCSIDriverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAgent) DeepCopyInto(out *SpireAgent) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgent.
+func (in *SpireAgent) DeepCopy() *SpireAgent {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAgent)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpireAgent) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAgentList) DeepCopyInto(out *SpireAgentList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SpireAgent, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgentList.
+func (in *SpireAgentList) DeepCopy() *SpireAgentList {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAgentList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpireAgentList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAgentPerformance) DeepCopyInto(out *SpireAgentPerformance) {
+	*out = *in
+	if in.X509SVIDCacheMaxSize != nil {
+		in, out := &in.X509SVIDCacheMaxSize, &out.X509SVIDCacheMaxSize
+		*out = new(int32)
+		**out = **in
+	}
+	if in.SyncInterval != nil {
+		in, out := &in.SyncInterval, &out.SyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.AvailabilityTarget != nil {
+		in, out := &in.AvailabilityTarget, &out.AvailabilityTarget
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgentPerformance.
+func (in *SpireAgentPerformance) DeepCopy() *SpireAgentPerformance {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAgentPerformance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAgentSpec) DeepCopyInto(out *SpireAgentSpec) {
+	*out = *in
+	if in.NodeAttestor != nil {
+		in, out := &in.NodeAttestor, &out.NodeAttestor
+		*out = new(NodeAttestor)
+		**out = **in
+	}
+	if in.WorkloadAttestors != nil {
+		in, out := &in.WorkloadAttestors, &out.WorkloadAttestors
+		*out = new(WorkloadAttestors)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(DaemonSetRolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnhealthyNodeThresholdPercent != nil {
+		in, out := &in.UnhealthyNodeThresholdPercent, &out.UnhealthyNodeThresholdPercent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.Performance != nil {
+		in, out := &in.Performance, &out.Performance
+		*out = new(SpireAgentPerformance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExternalServer != nil {
+		in, out := &in.ExternalServer, &out.ExternalServer
+		*out = new(ExternalSpireServer)
+		(*in).DeepCopyInto(*out)
+	}
+	in.CommonConfig.DeepCopyInto(&out.CommonConfig)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgentSpec.
+func (in *SpireAgentSpec) DeepCopy() *SpireAgentSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAgentSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAgentStatus) DeepCopyInto(out *SpireAgentStatus) {
+	*out = *in
+	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.NodeHealth != nil {
+		in, out := &in.NodeHealth, &out.NodeHealth
+		*out = new(NodeHealthStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(DaemonSetRolloutStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAgentStatus.
+func (in *SpireAgentStatus) DeepCopy() *SpireAgentStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAgentStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAuthorityOperation) DeepCopyInto(out *SpireAuthorityOperation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAuthorityOperation.
+func (in *SpireAuthorityOperation) DeepCopy() *SpireAuthorityOperation {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAuthorityOperation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpireAuthorityOperation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAuthorityOperationList) DeepCopyInto(out *SpireAuthorityOperationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SpireAuthorityOperation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAuthorityOperationList.
+func (in *SpireAuthorityOperationList) DeepCopy() *SpireAuthorityOperationList {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAuthorityOperationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpireAuthorityOperationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAuthorityOperationSpec) DeepCopyInto(out *SpireAuthorityOperationSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAuthorityOperationSpec.
+func (in *SpireAuthorityOperationSpec) DeepCopy() *SpireAuthorityOperationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAuthorityOperationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireAuthorityOperationStatus) DeepCopyInto(out *SpireAuthorityOperationStatus) {
+	*out = *in
+	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.Authorities != nil {
+		in, out := &in.Authorities, &out.Authorities
+		*out = make([]LocalAuthority, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Result != nil {
+		in, out := &in.Result, &out.Result
+		*out = new(LocalAuthority)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireAuthorityOperationStatus.
+func (in *SpireAuthorityOperationStatus) DeepCopy() *SpireAuthorityOperationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireAuthorityOperationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireOIDCDiscoveryProvider) DeepCopyInto(out *SpireOIDCDiscoveryProvider) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireOIDCDiscoveryProvider.
+func (in *SpireOIDCDiscoveryProvider) DeepCopy() *SpireOIDCDiscoveryProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireOIDCDiscoveryProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpireOIDCDiscoveryProvider) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
 	}
 	return nil
 }
@@ -590,6 +1583,51 @@ func (in *SpireOIDCDiscoveryProviderList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpireOIDCDiscoveryProviderSpec) DeepCopyInto(out *SpireOIDCDiscoveryProviderSpec) {
 	*out = *in
+	if in.Autoscaling != nil {
+		in, out := &in.Autoscaling, &out.Autoscaling
+		*out = new(OIDCAutoscalingConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Acme != nil {
+		in, out := &in.Acme, &out.Acme
+		*out = new(OIDCAcmeConfig)
+		**out = **in
+	}
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(EndpointExposure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalDomains != nil {
+		in, out := &in.AdditionalDomains, &out.AdditionalDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServerAPI != nil {
+		in, out := &in.ServerAPI, &out.ServerAPI
+		*out = new(OIDCServerAPIConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(OIDCDocumentExport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EndpointProbe != nil {
+		in, out := &in.EndpointProbe, &out.EndpointProbe
+		*out = new(OIDCEndpointProbe)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CloudFederation != nil {
+		in, out := &in.CloudFederation, &out.CloudFederation
+		*out = new(OIDCCloudFederation)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Identity != nil {
+		in, out := &in.Identity, &out.Identity
+		*out = new(OIDCProviderIdentity)
+		(*in).DeepCopyInto(*out)
+	}
 	in.CommonConfig.DeepCopyInto(&out.CommonConfig)
 }
 
@@ -607,6 +1645,11 @@ func (in *SpireOIDCDiscoveryProviderSpec) DeepCopy() *SpireOIDCDiscoveryProvider
 func (in *SpireOIDCDiscoveryProviderStatus) DeepCopyInto(out *SpireOIDCDiscoveryProviderStatus) {
 	*out = *in
 	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.Export != nil {
+		in, out := &in.Export, &out.Export
+		*out = new(OIDCDocumentExportStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireOIDCDiscoveryProviderStatus.
@@ -633,51 +1676,213 @@ func (in *SpireServer) DeepCopy() *SpireServer {
 	if in == nil {
 		return nil
 	}
-	out := new(SpireServer)
+	out := new(SpireServer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpireServer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireServerAgentLifecycle) DeepCopyInto(out *SpireServerAgentLifecycle) {
+	*out = *in
+	out.ExpiredFor = in.ExpiredFor
+	if in.PurgeInterval != nil {
+		in, out := &in.PurgeInterval, &out.PurgeInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerAgentLifecycle.
+func (in *SpireServerAgentLifecycle) DeepCopy() *SpireServerAgentLifecycle {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireServerAgentLifecycle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireServerAgentLifecycleStatus) DeepCopyInto(out *SpireServerAgentLifecycleStatus) {
+	*out = *in
+	if in.LastPurgeTime != nil {
+		in, out := &in.LastPurgeTime, &out.LastPurgeTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerAgentLifecycleStatus.
+func (in *SpireServerAgentLifecycleStatus) DeepCopy() *SpireServerAgentLifecycleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireServerAgentLifecycleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireServerExposure) DeepCopyInto(out *SpireServerExposure) {
+	*out = *in
+	if in.Route != nil {
+		in, out := &in.Route, &out.Route
+		*out = new(SpireServerRouteExposure)
+		**out = **in
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(SpireServerServiceExposure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerExposure.
+func (in *SpireServerExposure) DeepCopy() *SpireServerExposure {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireServerExposure)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireServerExposureStatus) DeepCopyInto(out *SpireServerExposureStatus) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSNames != nil {
+		in, out := &in.DNSNames, &out.DNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerExposureStatus.
+func (in *SpireServerExposureStatus) DeepCopy() *SpireServerExposureStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireServerExposureStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireServerList) DeepCopyInto(out *SpireServerList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SpireServer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerList.
+func (in *SpireServerList) DeepCopy() *SpireServerList {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireServerList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SpireServerList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireServerPerformance) DeepCopyInto(out *SpireServerPerformance) {
+	*out = *in
+	if in.CacheReloadInterval != nil {
+		in, out := &in.CacheReloadInterval, &out.CacheReloadInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.PruneEventsOlderThan != nil {
+		in, out := &in.PruneEventsOlderThan, &out.PruneEventsOlderThan
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerPerformance.
+func (in *SpireServerPerformance) DeepCopy() *SpireServerPerformance {
+	if in == nil {
+		return nil
+	}
+	out := new(SpireServerPerformance)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SpireServer) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SpireServerRouteExposure) DeepCopyInto(out *SpireServerRouteExposure) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerRouteExposure.
+func (in *SpireServerRouteExposure) DeepCopy() *SpireServerRouteExposure {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(SpireServerRouteExposure)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *SpireServerList) DeepCopyInto(out *SpireServerList) {
+func (in *SpireServerServiceExposure) DeepCopyInto(out *SpireServerServiceExposure) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
-	if in.Items != nil {
-		in, out := &in.Items, &out.Items
-		*out = make([]SpireServer, len(*in))
-		for i := range *in {
-			(*in)[i].DeepCopyInto(&(*out)[i])
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerList.
-func (in *SpireServerList) DeepCopy() *SpireServerList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerServiceExposure.
+func (in *SpireServerServiceExposure) DeepCopy() *SpireServerServiceExposure {
 	if in == nil {
 		return nil
 	}
-	out := new(SpireServerList)
+	out := new(SpireServerServiceExposure)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *SpireServerList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
-	}
-	return nil
-}
-
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SpireServerSpec) DeepCopyInto(out *SpireServerSpec) {
 	*out = *in
@@ -702,6 +1907,28 @@ func (in *SpireServerSpec) DeepCopyInto(out *SpireServerSpec) {
 		*out = new(UpstreamAuthorityConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Performance != nil {
+		in, out := &in.Performance, &out.Performance
+		*out = new(SpireServerPerformance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(SpireServerExposure)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AgentLifecycle != nil {
+		in, out := &in.AgentLifecycle, &out.AgentLifecycle
+		*out = new(SpireServerAgentLifecycle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ContainerResources != nil {
+		in, out := &in.ContainerResources, &out.ContainerResources
+		*out = make([]ContainerResources, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.CommonConfig.DeepCopyInto(&out.CommonConfig)
 }
 
@@ -719,6 +1946,16 @@ func (in *SpireServerSpec) DeepCopy() *SpireServerSpec {
 func (in *SpireServerStatus) DeepCopyInto(out *SpireServerStatus) {
 	*out = *in
 	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+	if in.Exposure != nil {
+		in, out := &in.Exposure, &out.Exposure
+		*out = new(SpireServerExposureStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AgentLifecycle != nil {
+		in, out := &in.AgentLifecycle, &out.AgentLifecycle
+		*out = new(SpireServerAgentLifecycleStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SpireServerStatus.
@@ -731,6 +1968,36 @@ func (in *SpireServerStatus) DeepCopy() *SpireServerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TrustBundleConfigMapReference) DeepCopyInto(out *TrustBundleConfigMapReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TrustBundleConfigMapReference.
+func (in *TrustBundleConfigMapReference) DeepCopy() *TrustBundleConfigMapReference {
+	if in == nil {
+		return nil
+	}
+	out := new(TrustBundleConfigMapReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnhealthyNode) DeepCopyInto(out *UnhealthyNode) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnhealthyNode.
+func (in *UnhealthyNode) DeepCopy() *UnhealthyNode {
+	if in == nil {
+		return nil
+	}
+	out := new(UnhealthyNode)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpstreamAuthorityCertManager) DeepCopyInto(out *UpstreamAuthorityCertManager) {
 	*out = *in
@@ -817,7 +2084,7 @@ func (in *WorkloadAttestors) DeepCopyInto(out *WorkloadAttestors) {
 	if in.WorkloadAttestorsVerification != nil {
 		in, out := &in.WorkloadAttestorsVerification, &out.WorkloadAttestorsVerification
 		*out = new(WorkloadAttestorsVerification)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 }
 
@@ -834,6 +2101,11 @@ func (in *WorkloadAttestors) DeepCopy() *WorkloadAttestors {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadAttestorsVerification) DeepCopyInto(out *WorkloadAttestorsVerification) {
 	*out = *in
+	if in.KubeletCAConfigMap != nil {
+		in, out := &in.KubeletCAConfigMap, &out.KubeletCAConfigMap
+		*out = new(KubeletCAConfigMapSource)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadAttestorsVerification.
@@ -846,12 +2118,260 @@ func (in *WorkloadAttestorsVerification) DeepCopy() *WorkloadAttestorsVerificati
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentity) DeepCopyInto(out *WorkloadIdentity) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentity.
+func (in *WorkloadIdentity) DeepCopy() *WorkloadIdentity {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadIdentity) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityList) DeepCopyInto(out *WorkloadIdentityList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkloadIdentity, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityList.
+func (in *WorkloadIdentityList) DeepCopy() *WorkloadIdentityList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadIdentityList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityPolicy) DeepCopyInto(out *WorkloadIdentityPolicy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityPolicy.
+func (in *WorkloadIdentityPolicy) DeepCopy() *WorkloadIdentityPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadIdentityPolicy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityPolicyList) DeepCopyInto(out *WorkloadIdentityPolicyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WorkloadIdentityPolicy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityPolicyList.
+func (in *WorkloadIdentityPolicyList) DeepCopy() *WorkloadIdentityPolicyList {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityPolicyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WorkloadIdentityPolicyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityPolicySpec) DeepCopyInto(out *WorkloadIdentityPolicySpec) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AllowedSPIFFEIDPathPrefixes != nil {
+		in, out := &in.AllowedSPIFFEIDPathPrefixes, &out.AllowedSPIFFEIDPathPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedDNSNames != nil {
+		in, out := &in.AllowedDNSNames, &out.AllowedDNSNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedHints != nil {
+		in, out := &in.AllowedHints, &out.AllowedHints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowedFederatesWith != nil {
+		in, out := &in.AllowedFederatesWith, &out.AllowedFederatesWith
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxTTL != nil {
+		in, out := &in.MaxTTL, &out.MaxTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.MaxJWTTTL != nil {
+		in, out := &in.MaxJWTTTL, &out.MaxJWTTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityPolicySpec.
+func (in *WorkloadIdentityPolicySpec) DeepCopy() *WorkloadIdentityPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentitySpec) DeepCopyInto(out *WorkloadIdentitySpec) {
+	*out = *in
+	if in.PodSelector != nil {
+		in, out := &in.PodSelector, &out.PodSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DNSNameTemplates != nil {
+		in, out := &in.DNSNameTemplates, &out.DNSNameTemplates
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.FederatesWith != nil {
+		in, out := &in.FederatesWith, &out.FederatesWith
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TTL != nil {
+		in, out := &in.TTL, &out.TTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.JWTTTL != nil {
+		in, out := &in.JWTTTL, &out.JWTTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentitySpec.
+func (in *WorkloadIdentitySpec) DeepCopy() *WorkloadIdentitySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentitySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadIdentityStatus) DeepCopyInto(out *WorkloadIdentityStatus) {
+	*out = *in
+	in.ConditionalStatus.DeepCopyInto(&out.ConditionalStatus)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadIdentityStatus.
+func (in *WorkloadIdentityStatus) DeepCopy() *WorkloadIdentityStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadIdentityStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadInjectionConfig) DeepCopyInto(out *WorkloadInjectionConfig) {
+	*out = *in
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ObjectSelector != nil {
+		in, out := &in.ObjectSelector, &out.ObjectSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadInjectionConfig.
+func (in *WorkloadInjectionConfig) DeepCopy() *WorkloadInjectionConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadInjectionConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ZeroTrustWorkloadIdentityManager) DeepCopyInto(out *ZeroTrustWorkloadIdentityManager) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -908,6 +2428,11 @@ func (in *ZeroTrustWorkloadIdentityManagerList) DeepCopyObject() runtime.Object
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ZeroTrustWorkloadIdentityManagerSpec) DeepCopyInto(out *ZeroTrustWorkloadIdentityManagerSpec) {
 	*out = *in
+	if in.DefaultIdentity != nil {
+		in, out := &in.DefaultIdentity, &out.DefaultIdentity
+		*out = new(DefaultIdentityConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZeroTrustWorkloadIdentityManagerSpec.
@@ -931,6 +2456,13 @@ func (in *ZeroTrustWorkloadIdentityManagerStatus) DeepCopyInto(out *ZeroTrustWor
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ClusterSPIFFEIDFindings != nil {
+		in, out := &in.ClusterSPIFFEIDFindings, &out.ClusterSPIFFEIDFindings
+		*out = make([]ClusterSPIFFEIDFinding, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ZeroTrustWorkloadIdentityManagerStatus.
@@ -19,7 +19,9 @@ package main
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"flag"
+	"net"
 	"os"
 	"path/filepath"
 
@@ -42,12 +44,18 @@ import (
 
 	operatoropenshiftiov1alpha1 "github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
 	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
+	clusterSPIFFEIDAuditController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/cluster-spiffeid-audit"
+	joinTokenController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/join-token"
 	spiffeCsiDriverController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/spiffe-csi-driver"
 	spireAgentController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/spire-agent"
+	spireAuthorityOperationController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/spire-authority-operation"
 	spireOIDCDiscoveryProviderController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/spire-oidc-discovery-provider"
 	spireServerController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/spire-server"
+	svidSecretController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/svid-secret"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	workloadIdentityController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/workload-identity"
 	ztwimController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/zero-trust-workload-identity-manager"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
 
 	securityv1 "github.com/openshift/api/security/v1"
 
@@ -64,6 +72,11 @@ const (
 	// at the passed `metrics-cert-dir` path.
 	metricsKeyFileName = "tls.key"
 
+	// webhookCertFileName and webhookKeyFileName are the serving certificate files,
+	// which should be present at the passed `webhook-cert-dir` path.
+	webhookCertFileName = "tls.crt"
+	webhookKeyFileName  = "tls.key"
+
 	openshiftCACertificateFile = "/var/run/secrets/kubernetes.io/serviceaccount/service-ca.crt"
 )
 
@@ -80,6 +93,16 @@ func init() {
 }
 
 func main() {
+	// The operator image also runs the SPIRE server API proxy sidecar of the spire-server pod
+	if len(os.Args) > 1 && os.Args[1] == utils.SpireServerAPIProxyCommand {
+		runServerAPIProxy(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == utils.SpireServerAPIRelayCommand {
+		runServerAPIRelay(os.Args[2:])
+		return
+	}
+
 	var (
 		metricsAddr          string
 		enableLeaderElection bool
@@ -88,6 +111,7 @@ func main() {
 		enableHTTP2          bool
 		logLevel             int
 		metricsCerts         string
+		webhookCerts         string
 		metricsTLSOpts       []func(*tls.Config)
 		webhookTLSOpts       []func(*tls.Config)
 	)
@@ -105,6 +129,9 @@ func main() {
 	flag.StringVar(&metricsCerts, "metrics-cert-dir", "",
 		"Secret name containing the certificates for the metrics server which should be present in operator namespace. "+
 			"If not provided self-signed certificates will be used")
+	flag.StringVar(&webhookCerts, "webhook-cert-dir", "",
+		"Directory containing the serving certificate of the webhook server, as tls.crt and tls.key. "+
+			"If not provided the SPIFFE CSI pod injection webhook is not served")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -133,9 +160,15 @@ func main() {
 		webhookTLSOpts = append(webhookTLSOpts, disableHTTP2)
 	}
 
-	webhookServer := webhook.NewServer(webhook.Options{
+	webhookServerOptions := webhook.Options{
 		TLSOpts: webhookTLSOpts,
-	})
+	}
+	if webhookCerts != "" {
+		webhookServerOptions.CertDir = webhookCerts
+		webhookServerOptions.CertName = webhookCertFileName
+		webhookServerOptions.KeyName = webhookKeyFileName
+	}
+	webhookServer := webhook.NewServer(webhookServerOptions)
 
 	// Metrics endpoint is enabled in 'config/default/kustomization.yaml'. The Metrics options configure the server.
 	// More info:
@@ -261,6 +294,11 @@ func main() {
 	if err = spiffeCsiDriverControllerManager.SetupWithManager(mgr); err != nil {
 		exitOnError(err, "unable to setup spiffe csi driver controller manager")
 	}
+	if webhookCerts != "" {
+		spiffeCsiDriverController.RegisterPodInjectionWebhook(mgr)
+	} else {
+		setupLog.Info("webhook certificate directory not configured, SPIFFE CSI pod injection webhook is disabled")
+	}
 
 	spireOIDCDiscoveryProviderControllerManager, err := spireOIDCDiscoveryProviderController.New(mgr)
 	if err != nil {
@@ -270,6 +308,46 @@ func main() {
 		exitOnError(err, "unable to setup spire OIDC discovery provider controller manager")
 	}
 
+	joinTokenControllerManager, err := joinTokenController.New(mgr)
+	if err != nil {
+		exitOnError(err, "unable to set up join token controller manager")
+	}
+	if err = joinTokenControllerManager.SetupWithManager(mgr); err != nil {
+		exitOnError(err, "unable to setup join token controller manager")
+	}
+
+	spireAuthorityOperationControllerManager, err := spireAuthorityOperationController.New(mgr)
+	if err != nil {
+		exitOnError(err, "unable to set up spire authority operation controller manager")
+	}
+	if err = spireAuthorityOperationControllerManager.SetupWithManager(mgr); err != nil {
+		exitOnError(err, "unable to setup spire authority operation controller manager")
+	}
+
+	workloadIdentityControllerManager, err := workloadIdentityController.New(mgr)
+	if err != nil {
+		exitOnError(err, "unable to set up workload identity controller manager")
+	}
+	if err = workloadIdentityControllerManager.SetupWithManager(mgr); err != nil {
+		exitOnError(err, "unable to setup workload identity controller manager")
+	}
+
+	clusterSPIFFEIDAuditControllerManager, err := clusterSPIFFEIDAuditController.New(mgr)
+	if err != nil {
+		exitOnError(err, "unable to set up cluster spiffeid audit controller manager")
+	}
+	if err = clusterSPIFFEIDAuditControllerManager.SetupWithManager(mgr); err != nil {
+		exitOnError(err, "unable to setup cluster spiffeid audit controller manager")
+	}
+
+	svidSecretControllerManager, err := svidSecretController.New(mgr)
+	if err != nil {
+		exitOnError(err, "unable to set up svid secret controller manager")
+	}
+	if err = svidSecretControllerManager.SetupWithManager(mgr); err != nil {
+		exitOnError(err, "unable to setup svid secret controller manager")
+	}
+
 	if err = mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		exitOnError(err, "unable to set up health check")
 	}
@@ -284,6 +362,64 @@ func main() {
 	exitOnError(err, "problem running manager")
 }
 
+// runServerAPIProxy serves the SPIRE server private API socket over mutual TLS to the operator
+func runServerAPIProxy(args []string) {
+	var (
+		listenAddress string
+		socketPath    string
+		certDir       string
+	)
+	flags := flag.NewFlagSet(utils.SpireServerAPIProxyCommand, flag.ExitOnError)
+	flags.StringVar(&listenAddress, "listen-address", ":8444", "The address the proxy listens on.")
+	flags.StringVar(&socketPath, "socket-path", spireapi.ServerAPISocketPath(), "The path of the SPIRE server private API socket.")
+	flags.StringVar(&certDir, "cert-dir", "",
+		"Directory containing the proxy certificate as tls.crt and tls.key, and the client CA as ca.crt.")
+	_ = flags.Parse(args)
+
+	ctrl.SetLogger(textlogger.NewLogger(textlogger.NewConfig()))
+	if certDir == "" {
+		exitOnError(errors.New("--cert-dir is required"), "invalid SPIRE server API proxy flags")
+	}
+
+	listener, err := net.Listen("tcp", listenAddress)
+	exitOnError(err, "unable to listen for SPIRE server API proxy connections")
+
+	setupLog.Info("starting SPIRE server API proxy", "address", listenAddress, "socket", socketPath)
+	err = spireapi.ServeProxy(ctrl.SetupSignalHandler(), listener, socketPath, spireapi.ProxyTLSConfig(certDir))
+	exitOnError(err, "problem running SPIRE server API proxy")
+}
+
+// runServerAPIRelay forwards the connections of the OIDC discovery provider to the SPIRE server API proxy
+func runServerAPIRelay(args []string) {
+	var (
+		socketPath   string
+		proxyAddress string
+		certDir      string
+	)
+	flags := flag.NewFlagSet(utils.SpireServerAPIRelayCommand, flag.ExitOnError)
+	flags.StringVar(&socketPath, "socket-path", "", "The path of the Unix socket the relay listens on.")
+	flags.StringVar(&proxyAddress, "proxy-address", "", "The address of the SPIRE server API proxy.")
+	flags.StringVar(&certDir, "cert-dir", "",
+		"Directory containing the client certificate as tls.crt and tls.key, and the proxy CA as ca.crt.")
+	_ = flags.Parse(args)
+
+	ctrl.SetLogger(textlogger.NewLogger(textlogger.NewConfig()))
+	if socketPath == "" || proxyAddress == "" || certDir == "" {
+		exitOnError(errors.New("--socket-path, --proxy-address and --cert-dir are required"), "invalid SPIRE server API relay flags")
+	}
+
+	// A socket left behind by a previous container run would make the listen fail
+	if err := os.Remove(socketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		exitOnError(err, "unable to remove stale SPIRE server API relay socket")
+	}
+	listener, err := net.Listen("unix", socketPath)
+	exitOnError(err, "unable to listen for SPIRE server API relay connections")
+
+	setupLog.Info("starting SPIRE server API relay", "socket", socketPath, "proxy", proxyAddress)
+	err = spireapi.ServeRelay(ctrl.SetupSignalHandler(), listener, proxyAddress, certDir)
+	exitOnError(err, "problem running SPIRE server API relay")
+}
+
 func exitOnError(err error, logMessage string) {
 	if err != nil {
 		setupLog.Error(err, logMessage)
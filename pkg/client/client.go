@@ -11,6 +11,7 @@ import (
 	"k8s.io/client-go/util/retry"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/types"
@@ -22,7 +23,10 @@ import (
 
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	storagev1 "k8s.io/api/storage/v1"
 
@@ -43,20 +47,36 @@ var (
 		&corev1.ServiceAccount{},
 		&corev1.Service{},
 		&corev1.ConfigMap{},
+		&corev1.Secret{},
+		&corev1.Pod{},
 		&appsv1.Deployment{},
 		&appsv1.DaemonSet{},
 		&appsv1.StatefulSet{},
 		&admissionregistrationv1.ValidatingWebhookConfiguration{},
+		&admissionregistrationv1.MutatingWebhookConfiguration{},
 		&routev1.Route{},
-		&spiffev1alpha1.ClusterSPIFFEID{},
+		&networkingv1.Ingress{},
+		&autoscalingv2.HorizontalPodAutoscaler{},
+		&policyv1.PodDisruptionBudget{},
+		&corev1.PersistentVolumeClaim{},
 	}
 
 	cacheResourceWithoutReqSelectors = []client.Object{
+		&corev1.Node{},
+		// Namespace labels select the WorkloadIdentityPolicy resources that apply
+		&corev1.Namespace{},
 		&v1alpha1.ZeroTrustWorkloadIdentityManager{},
 		&v1alpha1.SpireAgent{},
 		&v1alpha1.SpiffeCSIDriver{},
 		&v1alpha1.SpireServer{},
 		&v1alpha1.SpireOIDCDiscoveryProvider{},
+		&v1alpha1.JoinToken{},
+		&v1alpha1.SpireAuthorityOperation{},
+		&v1alpha1.WorkloadIdentity{},
+		&v1alpha1.WorkloadIdentityPolicy{},
+		&v1alpha1.SVIDSecret{},
+		// ClusterSPIFFEIDs created by users are audited along with the operator's own
+		&spiffev1alpha1.ClusterSPIFFEID{},
 		&operatorv1.OperatorCondition{},
 	}
 
@@ -69,16 +89,25 @@ var (
 		&rbacv1.ClusterRoleBinding{},
 		&storagev1.CSIDriver{},
 		&corev1.ConfigMap{},
+		&corev1.Secret{},
+		&corev1.Pod{},
+		&corev1.Node{},
+		&corev1.Namespace{},
+		&corev1.PersistentVolumeClaim{},
 		&appsv1.Deployment{},
 		&appsv1.DaemonSet{},
 		&appsv1.StatefulSet{},
 		&admissionregistrationv1.ValidatingWebhookConfiguration{},
+		&admissionregistrationv1.MutatingWebhookConfiguration{},
 		&v1alpha1.ZeroTrustWorkloadIdentityManager{},
 		&v1alpha1.SpireAgent{},
 		&v1alpha1.SpiffeCSIDriver{},
 		&v1alpha1.SpireServer{},
 		&v1alpha1.SpireOIDCDiscoveryProvider{},
 		&routev1.Route{},
+		&networkingv1.Ingress{},
+		&autoscalingv2.HorizontalPodAutoscaler{},
+		&policyv1.PodDisruptionBudget{},
 		&spiffev1alpha1.ClusterSPIFFEID{},
 		&operatorv1.OperatorCondition{},
 	}
@@ -93,6 +122,8 @@ type customCtrlClientImpl struct {
 //counterfeiter:generate -o fakes . CustomCtrlClient
 type CustomCtrlClient interface {
 	Get(context.Context, client.ObjectKey, client.Object) error
+	UncachedGet(context.Context, client.ObjectKey, client.Object) error
+	UncachedList(context.Context, client.ObjectList, ...client.ListOption) error
 	List(context.Context, client.ObjectList, ...client.ListOption) error
 	StatusUpdate(context.Context, client.Object, ...client.SubResourceUpdateOption) error
 	Update(context.Context, client.Object, ...client.UpdateOption) error
@@ -100,6 +131,7 @@ type CustomCtrlClient interface {
 	Create(context.Context, client.Object, ...client.CreateOption) error
 	Delete(context.Context, client.Object, ...client.DeleteOption) error
 	Patch(context.Context, client.Object, client.Patch, ...client.PatchOption) error
+	StatusPatch(context.Context, client.Object, client.Patch, ...client.SubResourcePatchOption) error
 	Exists(context.Context, client.ObjectKey, client.Object) (bool, error)
 	CreateOrUpdateObject(ctx context.Context, obj client.Object) error
 	StatusUpdateWithRetry(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error
@@ -123,6 +155,22 @@ func (c *customCtrlClientImpl) Get(
 	return c.Client.Get(ctx, key, obj)
 }
 
+// UncachedGet reads the object directly from the API server. It is meant for
+// resources not managed by the operator, which are excluded from the cache.
+func (c *customCtrlClientImpl) UncachedGet(
+	ctx context.Context, key client.ObjectKey, obj client.Object,
+) error {
+	return c.apiReader.Get(ctx, key, obj)
+}
+
+// UncachedList lists the objects directly from the API server. It is meant for
+// resources not managed by the operator, which are excluded from the cache.
+func (c *customCtrlClientImpl) UncachedList(
+	ctx context.Context, list client.ObjectList, opts ...client.ListOption,
+) error {
+	return c.apiReader.List(ctx, list, opts...)
+}
+
 func (c *customCtrlClientImpl) List(
 	ctx context.Context, list client.ObjectList, opts ...client.ListOption,
 ) error {
@@ -194,6 +242,14 @@ func (c *customCtrlClientImpl) Patch(
 	return c.Client.Patch(ctx, obj, patch, opts...)
 }
 
+// StatusPatch patches the status subresource, leaving the status fields not in the patch to
+// the other controllers writing them
+func (c *customCtrlClientImpl) StatusPatch(
+	ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption,
+) error {
+	return c.Client.Status().Patch(ctx, obj, patch, opts...)
+}
+
 func (c *customCtrlClientImpl) Exists(ctx context.Context, key client.ObjectKey, obj client.Object) (bool, error) {
 	if err := c.Client.Get(ctx, key, obj); err != nil {
 		if errors.IsNotFound(err) {
@@ -239,6 +295,16 @@ func NewCacheBuilder() (cache.NewCacheFunc, error) {
 		for _, resource := range cacheResourceWithoutReqSelectors {
 			customCacheObjects[resource] = cache.ByObject{}
 		}
+		// The Gateway API kinds are optional CRDs, only the kinds served at startup are cached
+		if opts.Mapper != nil {
+			for _, gvk := range utils.ServedGatewayAPIKinds(opts.Mapper) {
+				gatewayObject := &unstructured.Unstructured{}
+				gatewayObject.SetGroupVersionKind(gvk)
+				customCacheObjects[gatewayObject] = cache.ByObject{
+					Label: managedResourceLabelReqSelector,
+				}
+			}
+		}
 
 		// Merge custom cache objects with any existing ones from opts
 		if opts.ByObject == nil {
@@ -76,6 +76,16 @@ type FakeCustomCtrlClient struct {
 	getReturnsOnCall map[int]struct {
 		result1 error
 	}
+	GetClientStub        func() clienta.Client
+	getClientMutex       sync.RWMutex
+	getClientArgsForCall []struct {
+	}
+	getClientReturns struct {
+		result1 clienta.Client
+	}
+	getClientReturnsOnCall map[int]struct {
+		result1 clienta.Client
+	}
 	ListStub        func(context.Context, clienta.ObjectList, ...clienta.ListOption) error
 	listMutex       sync.RWMutex
 	listArgsForCall []struct {
@@ -103,6 +113,20 @@ type FakeCustomCtrlClient struct {
 	patchReturnsOnCall map[int]struct {
 		result1 error
 	}
+	StatusPatchStub        func(context.Context, clienta.Object, clienta.Patch, ...clienta.SubResourcePatchOption) error
+	statusPatchMutex       sync.RWMutex
+	statusPatchArgsForCall []struct {
+		arg1 context.Context
+		arg2 clienta.Object
+		arg3 clienta.Patch
+		arg4 []clienta.SubResourcePatchOption
+	}
+	statusPatchReturns struct {
+		result1 error
+	}
+	statusPatchReturnsOnCall map[int]struct {
+		result1 error
+	}
 	StatusUpdateStub        func(context.Context, clienta.Object, ...clienta.SubResourceUpdateOption) error
 	statusUpdateMutex       sync.RWMutex
 	statusUpdateArgsForCall []struct {
@@ -129,6 +153,32 @@ type FakeCustomCtrlClient struct {
 	statusUpdateWithRetryReturnsOnCall map[int]struct {
 		result1 error
 	}
+	UncachedGetStub        func(context.Context, clienta.ObjectKey, clienta.Object) error
+	uncachedGetMutex       sync.RWMutex
+	uncachedGetArgsForCall []struct {
+		arg1 context.Context
+		arg2 clienta.ObjectKey
+		arg3 clienta.Object
+	}
+	uncachedGetReturns struct {
+		result1 error
+	}
+	uncachedGetReturnsOnCall map[int]struct {
+		result1 error
+	}
+	UncachedListStub        func(context.Context, clienta.ObjectList, ...clienta.ListOption) error
+	uncachedListMutex       sync.RWMutex
+	uncachedListArgsForCall []struct {
+		arg1 context.Context
+		arg2 clienta.ObjectList
+		arg3 []clienta.ListOption
+	}
+	uncachedListReturns struct {
+		result1 error
+	}
+	uncachedListReturnsOnCall map[int]struct {
+		result1 error
+	}
 	UpdateStub        func(context.Context, clienta.Object, ...clienta.UpdateOption) error
 	updateMutex       sync.RWMutex
 	updateArgsForCall []struct {
@@ -155,16 +205,6 @@ type FakeCustomCtrlClient struct {
 	updateWithRetryReturnsOnCall map[int]struct {
 		result1 error
 	}
-	GetClientStub        func() clienta.Client
-	getClientMutex       sync.RWMutex
-	getClientArgsForCall []struct {
-	}
-	getClientReturns struct {
-		result1 clienta.Client
-	}
-	getClientReturnsOnCall map[int]struct {
-		result1 clienta.Client
-	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -486,6 +526,59 @@ func (fake *FakeCustomCtrlClient) GetReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeCustomCtrlClient) GetClient() clienta.Client {
+	fake.getClientMutex.Lock()
+	ret, specificReturn := fake.getClientReturnsOnCall[len(fake.getClientArgsForCall)]
+	fake.getClientArgsForCall = append(fake.getClientArgsForCall, struct {
+	}{})
+	stub := fake.GetClientStub
+	fakeReturns := fake.getClientReturns
+	fake.recordInvocation("GetClient", []interface{}{})
+	fake.getClientMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCustomCtrlClient) GetClientCallCount() int {
+	fake.getClientMutex.RLock()
+	defer fake.getClientMutex.RUnlock()
+	return len(fake.getClientArgsForCall)
+}
+
+func (fake *FakeCustomCtrlClient) GetClientCalls(stub func() clienta.Client) {
+	fake.getClientMutex.Lock()
+	defer fake.getClientMutex.Unlock()
+	fake.GetClientStub = stub
+}
+
+func (fake *FakeCustomCtrlClient) GetClientReturns(result1 clienta.Client) {
+	fake.getClientMutex.Lock()
+	defer fake.getClientMutex.Unlock()
+	fake.GetClientStub = nil
+	fake.getClientReturns = struct {
+		result1 clienta.Client
+	}{result1}
+}
+
+func (fake *FakeCustomCtrlClient) GetClientReturnsOnCall(i int, result1 clienta.Client) {
+	fake.getClientMutex.Lock()
+	defer fake.getClientMutex.Unlock()
+	fake.GetClientStub = nil
+	if fake.getClientReturnsOnCall == nil {
+		fake.getClientReturnsOnCall = make(map[int]struct {
+			result1 clienta.Client
+		})
+	}
+	fake.getClientReturnsOnCall[i] = struct {
+		result1 clienta.Client
+	}{result1}
+}
+
 func (fake *FakeCustomCtrlClient) List(arg1 context.Context, arg2 clienta.ObjectList, arg3 ...clienta.ListOption) error {
 	fake.listMutex.Lock()
 	ret, specificReturn := fake.listReturnsOnCall[len(fake.listArgsForCall)]
@@ -613,6 +706,70 @@ func (fake *FakeCustomCtrlClient) PatchReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *FakeCustomCtrlClient) StatusPatch(arg1 context.Context, arg2 clienta.Object, arg3 clienta.Patch, arg4 ...clienta.SubResourcePatchOption) error {
+	fake.statusPatchMutex.Lock()
+	ret, specificReturn := fake.statusPatchReturnsOnCall[len(fake.statusPatchArgsForCall)]
+	fake.statusPatchArgsForCall = append(fake.statusPatchArgsForCall, struct {
+		arg1 context.Context
+		arg2 clienta.Object
+		arg3 clienta.Patch
+		arg4 []clienta.SubResourcePatchOption
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.StatusPatchStub
+	fakeReturns := fake.statusPatchReturns
+	fake.recordInvocation("StatusPatch", []interface{}{arg1, arg2, arg3, arg4})
+	fake.statusPatchMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4...)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCustomCtrlClient) StatusPatchCallCount() int {
+	fake.statusPatchMutex.RLock()
+	defer fake.statusPatchMutex.RUnlock()
+	return len(fake.statusPatchArgsForCall)
+}
+
+func (fake *FakeCustomCtrlClient) StatusPatchCalls(stub func(context.Context, clienta.Object, clienta.Patch, ...clienta.SubResourcePatchOption) error) {
+	fake.statusPatchMutex.Lock()
+	defer fake.statusPatchMutex.Unlock()
+	fake.StatusPatchStub = stub
+}
+
+func (fake *FakeCustomCtrlClient) StatusPatchArgsForCall(i int) (context.Context, clienta.Object, clienta.Patch, []clienta.SubResourcePatchOption) {
+	fake.statusPatchMutex.RLock()
+	defer fake.statusPatchMutex.RUnlock()
+	argsForCall := fake.statusPatchArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *FakeCustomCtrlClient) StatusPatchReturns(result1 error) {
+	fake.statusPatchMutex.Lock()
+	defer fake.statusPatchMutex.Unlock()
+	fake.StatusPatchStub = nil
+	fake.statusPatchReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCustomCtrlClient) StatusPatchReturnsOnCall(i int, result1 error) {
+	fake.statusPatchMutex.Lock()
+	defer fake.statusPatchMutex.Unlock()
+	fake.StatusPatchStub = nil
+	if fake.statusPatchReturnsOnCall == nil {
+		fake.statusPatchReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.statusPatchReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeCustomCtrlClient) StatusUpdate(arg1 context.Context, arg2 clienta.Object, arg3 ...clienta.SubResourceUpdateOption) error {
 	fake.statusUpdateMutex.Lock()
 	ret, specificReturn := fake.statusUpdateReturnsOnCall[len(fake.statusUpdateArgsForCall)]
@@ -739,6 +896,132 @@ func (fake *FakeCustomCtrlClient) StatusUpdateWithRetryReturnsOnCall(i int, resu
 	}{result1}
 }
 
+func (fake *FakeCustomCtrlClient) UncachedGet(arg1 context.Context, arg2 clienta.ObjectKey, arg3 clienta.Object) error {
+	fake.uncachedGetMutex.Lock()
+	ret, specificReturn := fake.uncachedGetReturnsOnCall[len(fake.uncachedGetArgsForCall)]
+	fake.uncachedGetArgsForCall = append(fake.uncachedGetArgsForCall, struct {
+		arg1 context.Context
+		arg2 clienta.ObjectKey
+		arg3 clienta.Object
+	}{arg1, arg2, arg3})
+	stub := fake.UncachedGetStub
+	fakeReturns := fake.uncachedGetReturns
+	fake.recordInvocation("UncachedGet", []interface{}{arg1, arg2, arg3})
+	fake.uncachedGetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCustomCtrlClient) UncachedGetCallCount() int {
+	fake.uncachedGetMutex.RLock()
+	defer fake.uncachedGetMutex.RUnlock()
+	return len(fake.uncachedGetArgsForCall)
+}
+
+func (fake *FakeCustomCtrlClient) UncachedGetCalls(stub func(context.Context, clienta.ObjectKey, clienta.Object) error) {
+	fake.uncachedGetMutex.Lock()
+	defer fake.uncachedGetMutex.Unlock()
+	fake.UncachedGetStub = stub
+}
+
+func (fake *FakeCustomCtrlClient) UncachedGetArgsForCall(i int) (context.Context, clienta.ObjectKey, clienta.Object) {
+	fake.uncachedGetMutex.RLock()
+	defer fake.uncachedGetMutex.RUnlock()
+	argsForCall := fake.uncachedGetArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeCustomCtrlClient) UncachedGetReturns(result1 error) {
+	fake.uncachedGetMutex.Lock()
+	defer fake.uncachedGetMutex.Unlock()
+	fake.UncachedGetStub = nil
+	fake.uncachedGetReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCustomCtrlClient) UncachedGetReturnsOnCall(i int, result1 error) {
+	fake.uncachedGetMutex.Lock()
+	defer fake.uncachedGetMutex.Unlock()
+	fake.UncachedGetStub = nil
+	if fake.uncachedGetReturnsOnCall == nil {
+		fake.uncachedGetReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.uncachedGetReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCustomCtrlClient) UncachedList(arg1 context.Context, arg2 clienta.ObjectList, arg3 ...clienta.ListOption) error {
+	fake.uncachedListMutex.Lock()
+	ret, specificReturn := fake.uncachedListReturnsOnCall[len(fake.uncachedListArgsForCall)]
+	fake.uncachedListArgsForCall = append(fake.uncachedListArgsForCall, struct {
+		arg1 context.Context
+		arg2 clienta.ObjectList
+		arg3 []clienta.ListOption
+	}{arg1, arg2, arg3})
+	stub := fake.UncachedListStub
+	fakeReturns := fake.uncachedListReturns
+	fake.recordInvocation("UncachedList", []interface{}{arg1, arg2, arg3})
+	fake.uncachedListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3...)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeCustomCtrlClient) UncachedListCallCount() int {
+	fake.uncachedListMutex.RLock()
+	defer fake.uncachedListMutex.RUnlock()
+	return len(fake.uncachedListArgsForCall)
+}
+
+func (fake *FakeCustomCtrlClient) UncachedListCalls(stub func(context.Context, clienta.ObjectList, ...clienta.ListOption) error) {
+	fake.uncachedListMutex.Lock()
+	defer fake.uncachedListMutex.Unlock()
+	fake.UncachedListStub = stub
+}
+
+func (fake *FakeCustomCtrlClient) UncachedListArgsForCall(i int) (context.Context, clienta.ObjectList, []clienta.ListOption) {
+	fake.uncachedListMutex.RLock()
+	defer fake.uncachedListMutex.RUnlock()
+	argsForCall := fake.uncachedListArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeCustomCtrlClient) UncachedListReturns(result1 error) {
+	fake.uncachedListMutex.Lock()
+	defer fake.uncachedListMutex.Unlock()
+	fake.UncachedListStub = nil
+	fake.uncachedListReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeCustomCtrlClient) UncachedListReturnsOnCall(i int, result1 error) {
+	fake.uncachedListMutex.Lock()
+	defer fake.uncachedListMutex.Unlock()
+	fake.UncachedListStub = nil
+	if fake.uncachedListReturnsOnCall == nil {
+		fake.uncachedListReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.uncachedListReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeCustomCtrlClient) Update(arg1 context.Context, arg2 clienta.Object, arg3 ...clienta.UpdateOption) error {
 	fake.updateMutex.Lock()
 	ret, specificReturn := fake.updateReturnsOnCall[len(fake.updateArgsForCall)]
@@ -876,18 +1159,24 @@ func (fake *FakeCustomCtrlClient) Invocations() map[string][][]interface{} {
 	defer fake.deleteMutex.RUnlock()
 	fake.existsMutex.RLock()
 	defer fake.existsMutex.RUnlock()
-	fake.getClientMutex.RLock()
-	defer fake.getClientMutex.RUnlock()
 	fake.getMutex.RLock()
 	defer fake.getMutex.RUnlock()
+	fake.getClientMutex.RLock()
+	defer fake.getClientMutex.RUnlock()
 	fake.listMutex.RLock()
 	defer fake.listMutex.RUnlock()
 	fake.patchMutex.RLock()
 	defer fake.patchMutex.RUnlock()
+	fake.statusPatchMutex.RLock()
+	defer fake.statusPatchMutex.RUnlock()
 	fake.statusUpdateMutex.RLock()
 	defer fake.statusUpdateMutex.RUnlock()
 	fake.statusUpdateWithRetryMutex.RLock()
 	defer fake.statusUpdateWithRetryMutex.RUnlock()
+	fake.uncachedGetMutex.RLock()
+	defer fake.uncachedGetMutex.RUnlock()
+	fake.uncachedListMutex.RLock()
+	defer fake.uncachedListMutex.RUnlock()
 	fake.updateMutex.RLock()
 	defer fake.updateMutex.RUnlock()
 	fake.updateWithRetryMutex.RLock()
@@ -899,59 +1188,6 @@ func (fake *FakeCustomCtrlClient) Invocations() map[string][][]interface{} {
 	return copiedInvocations
 }
 
-func (fake *FakeCustomCtrlClient) GetClient() clienta.Client {
-	fake.getClientMutex.Lock()
-	ret, specificReturn := fake.getClientReturnsOnCall[len(fake.getClientArgsForCall)]
-	fake.getClientArgsForCall = append(fake.getClientArgsForCall, struct {
-	}{})
-	stub := fake.GetClientStub
-	fakeReturns := fake.getClientReturns
-	fake.recordInvocation("GetClient", []interface{}{})
-	fake.getClientMutex.Unlock()
-	if stub != nil {
-		return stub()
-	}
-	if specificReturn {
-		return ret.result1
-	}
-	return fakeReturns.result1
-}
-
-func (fake *FakeCustomCtrlClient) GetClientCallCount() int {
-	fake.getClientMutex.RLock()
-	defer fake.getClientMutex.RUnlock()
-	return len(fake.getClientArgsForCall)
-}
-
-func (fake *FakeCustomCtrlClient) GetClientCalls(stub func() clienta.Client) {
-	fake.getClientMutex.Lock()
-	defer fake.getClientMutex.Unlock()
-	fake.GetClientStub = stub
-}
-
-func (fake *FakeCustomCtrlClient) GetClientReturns(result1 clienta.Client) {
-	fake.getClientMutex.Lock()
-	defer fake.getClientMutex.Unlock()
-	fake.GetClientStub = nil
-	fake.getClientReturns = struct {
-		result1 clienta.Client
-	}{result1}
-}
-
-func (fake *FakeCustomCtrlClient) GetClientReturnsOnCall(i int, result1 clienta.Client) {
-	fake.getClientMutex.Lock()
-	defer fake.getClientMutex.Unlock()
-	fake.GetClientStub = nil
-	if fake.getClientReturnsOnCall == nil {
-		fake.getClientReturnsOnCall = make(map[int]struct {
-			result1 clienta.Client
-		})
-	}
-	fake.getClientReturnsOnCall[i] = struct {
-		result1 clienta.Client
-	}{result1}
-}
-
 func (fake *FakeCustomCtrlClient) recordInvocation(key string, args []interface{}) {
 	fake.invocationsMutex.Lock()
 	defer fake.invocationsMutex.Unlock()
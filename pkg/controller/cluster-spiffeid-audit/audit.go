@@ -0,0 +1,202 @@
+package cluster_spiffeid_audit
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	spireServerController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/spire-server"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+// maxFindings keeps the findings within the status validation limit
+const maxFindings = 100
+
+// auditEnv holds the settings spire-controller-manager renders ClusterSPIFFEIDs with
+type auditEnv struct {
+	trustDomain       string
+	clusterName       string
+	caTTL             time.Duration
+	ignoredNamespaces []*regexp.Regexp
+}
+
+// overlap counts the pods selected by the same pair of ClusterSPIFFEIDs
+type overlap struct {
+	names   [2]string
+	pods    int
+	example string
+}
+
+// auditClusterSPIFFEIDs evaluates the ClusterSPIFFEIDs against the namespaces and pods the way
+// spire-controller-manager does, and returns the problems found sorted by type and name
+func auditClusterSPIFFEIDs(clusterSPIFFEIDs []spiffev1alpha1.ClusterSPIFFEID, namespaces []corev1.Namespace, pods []corev1.Pod, nodes []corev1.Node, env auditEnv) []v1alpha1.ClusterSPIFFEIDFinding {
+	var findings []v1alpha1.ClusterSPIFFEIDFinding
+	addFinding := func(findingType v1alpha1.ClusterSPIFFEIDFindingType, message string, names ...string) {
+		findings = append(findings, v1alpha1.ClusterSPIFFEIDFinding{Type: findingType, ClusterSPIFFEIDs: names, Message: message})
+	}
+
+	type parsedClusterSPIFFEID struct {
+		name     string
+		fallback bool
+		spec     *spiffev1alpha1.ParsedClusterSPIFFEIDSpec
+		// rendered is set once the templates were checked against a selected pod
+		rendered bool
+	}
+	var parsed []*parsedClusterSPIFFEID
+	for i := range clusterSPIFFEIDs {
+		clusterSPIFFEID := &clusterSPIFFEIDs[i]
+		spec, err := spiffev1alpha1.ParseClusterSPIFFEIDSpec(&clusterSPIFFEID.Spec)
+		if err != nil {
+			addFinding(v1alpha1.ClusterSPIFFEIDFindingInvalidTemplate, fmt.Sprintf("ClusterSPIFFEID %s is invalid: %v", clusterSPIFFEID.Name, err), clusterSPIFFEID.Name)
+			continue
+		}
+		if env.caTTL > 0 {
+			maxTTL := spireServerController.MaxSVIDTTLForCATTL(env.caTTL)
+			if spec.TTL > maxTTL {
+				addFinding(v1alpha1.ClusterSPIFFEIDFindingTTLTooLong,
+					fmt.Sprintf("ClusterSPIFFEID %s requests a ttl of %s, but a CA TTL of %s only guarantees %s", clusterSPIFFEID.Name, spec.TTL, env.caTTL, maxTTL),
+					clusterSPIFFEID.Name)
+			}
+			if spec.JWTTTL > maxTTL {
+				addFinding(v1alpha1.ClusterSPIFFEIDFindingTTLTooLong,
+					fmt.Sprintf("ClusterSPIFFEID %s requests a jwtTtl of %s, but a CA TTL of %s only guarantees %s", clusterSPIFFEID.Name, spec.JWTTTL, env.caTTL, maxTTL),
+					clusterSPIFFEID.Name)
+			}
+		}
+		parsed = append(parsed, &parsedClusterSPIFFEID{name: clusterSPIFFEID.Name, fallback: clusterSPIFFEID.Spec.Fallback, spec: spec})
+	}
+
+	namespaceLabels := make(map[string]labels.Set, len(namespaces))
+	for _, namespace := range namespaces {
+		namespaceLabels[namespace.Name] = namespace.Labels
+	}
+	nodesByName := make(map[string]*corev1.Node, len(nodes))
+	for i := range nodes {
+		nodesByName[nodes[i].Name] = &nodes[i]
+	}
+
+	overlaps := map[[2]string]*overlap{}
+	for i := range pods {
+		pod := &pods[i]
+		// Unscheduled pods and pods of ignored namespaces are never registered
+		if pod.Spec.NodeName == "" || isIgnored(env.ignoredNamespaces, pod.Namespace) {
+			continue
+		}
+		nsLabels, ok := namespaceLabels[pod.Namespace]
+		if !ok {
+			continue
+		}
+
+		var selected, fallbacks []string
+		for _, p := range parsed {
+			if p.spec.NamespaceSelector != nil && !p.spec.NamespaceSelector.Matches(nsLabels) {
+				continue
+			}
+			if p.spec.PodSelector != nil && !p.spec.PodSelector.Matches(labels.Set(pod.Labels)) {
+				continue
+			}
+			if p.fallback {
+				fallbacks = append(fallbacks, p.name)
+			} else {
+				selected = append(selected, p.name)
+			}
+			if !p.rendered {
+				p.rendered = true
+				if err := renderTemplates(p.spec, pod, nodesByName[pod.Spec.NodeName], env); err != nil {
+					addFinding(v1alpha1.ClusterSPIFFEIDFindingInvalidTemplate,
+						fmt.Sprintf("ClusterSPIFFEID %s fails for pod %s/%s: %v", p.name, pod.Namespace, pod.Name, err),
+						p.name)
+				}
+			}
+		}
+
+		// Fallback ClusterSPIFFEIDs only apply when no other ClusterSPIFFEID selects the pod
+		if len(selected) == 0 {
+			selected = fallbacks
+		}
+		for a := 0; a < len(selected); a++ {
+			for b := a + 1; b < len(selected); b++ {
+				key := [2]string{selected[a], selected[b]}
+				if key[0] > key[1] {
+					key[0], key[1] = key[1], key[0]
+				}
+				o, ok := overlaps[key]
+				if !ok {
+					o = &overlap{names: key, example: pod.Namespace + "/" + pod.Name}
+					overlaps[key] = o
+				}
+				o.pods++
+			}
+		}
+	}
+	for _, o := range overlaps {
+		addFinding(v1alpha1.ClusterSPIFFEIDFindingOverlap,
+			fmt.Sprintf("ClusterSPIFFEIDs %s and %s both select %d pod(s), for example %s", o.names[0], o.names[1], o.pods, o.example),
+			o.names[0], o.names[1])
+	}
+
+	slices.SortFunc(findings, func(a, b v1alpha1.ClusterSPIFFEIDFinding) int {
+		if c := strings.Compare(string(a.Type), string(b.Type)); c != 0 {
+			return c
+		}
+		if c := slices.Compare(a.ClusterSPIFFEIDs, b.ClusterSPIFFEIDs); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Message, b.Message)
+	})
+	if len(findings) > maxFindings {
+		findings = findings[:maxFindings]
+	}
+	return findings
+}
+
+// renderTemplates renders the SPIFFE ID and DNS name templates for the pod and checks the results
+func renderTemplates(spec *spiffev1alpha1.ParsedClusterSPIFFEIDSpec, pod *corev1.Pod, node *corev1.Node, env auditEnv) error {
+	if node == nil {
+		node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: pod.Spec.NodeName}}
+	}
+	data := utils.PodTemplateData(env.trustDomain, env.clusterName, pod, node)
+
+	rendered, err := utils.RenderTemplate(spec.SPIFFEIDTemplate, data)
+	if err != nil {
+		return fmt.Errorf("failed to render SPIFFE ID: %w", err)
+	}
+	id, err := spiffeid.FromString(rendered)
+	if err != nil {
+		return fmt.Errorf("invalid SPIFFE ID %q: %w", rendered, err)
+	}
+	if id.TrustDomain().Name() != env.trustDomain {
+		return fmt.Errorf("SPIFFE ID %q is not in trust domain %q", rendered, env.trustDomain)
+	}
+
+	for _, dnsNameTemplate := range spec.DNSNameTemplates {
+		dnsName, err := utils.RenderTemplate(dnsNameTemplate, data)
+		if err != nil {
+			return fmt.Errorf("failed to render DNS name: %w", err)
+		}
+		if errs := validation.IsDNS1123Subdomain(strings.TrimPrefix(dnsName, "*.")); len(errs) > 0 {
+			return fmt.Errorf("invalid DNS name %q: %s", dnsName, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// isIgnored matches the namespace the way spire-controller-manager matches its ignoreNamespaces
+func isIgnored(ignoredNamespaces []*regexp.Regexp, namespace string) bool {
+	for _, regex := range ignoredNamespaces {
+		if regex.MatchString(namespace) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package cluster_spiffeid_audit
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+const defaultSPIFFEIDTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}"
+
+func newClusterSPIFFEID(name string, podLabels map[string]string, fallback bool) spiffev1alpha1.ClusterSPIFFEID {
+	clusterSPIFFEID := spiffev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: spiffev1alpha1.ClusterSPIFFEIDSpec{
+			SPIFFEIDTemplate: defaultSPIFFEIDTemplate,
+			ClassName:        operatorClassName,
+			Fallback:         fallback,
+		},
+	}
+	if podLabels != nil {
+		clusterSPIFFEID.Spec.PodSelector = &metav1.LabelSelector{MatchLabels: podLabels}
+	}
+	return clusterSPIFFEID
+}
+
+func newPod(namespace, name string, podLabels map[string]string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: podLabels},
+		Spec:       corev1.PodSpec{NodeName: "worker-0", ServiceAccountName: "default"},
+	}
+}
+
+func testEnv() auditEnv {
+	return auditEnv{
+		trustDomain:       "example.org",
+		clusterName:       "test-cluster",
+		caTTL:             24 * time.Hour,
+		ignoredNamespaces: []*regexp.Regexp{regexp.MustCompile("openshift-*")},
+	}
+}
+
+func TestAuditClusterSPIFFEIDs(t *testing.T) {
+	namespaces := []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "payments"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "openshift-monitoring"}},
+	}
+	nodes := []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "worker-0"}}}
+	appLabels := map[string]string{"app": "api"}
+
+	tests := []struct {
+		name             string
+		clusterSPIFFEIDs []spiffev1alpha1.ClusterSPIFFEID
+		pods             []corev1.Pod
+		wantTypes        []v1alpha1.ClusterSPIFFEIDFindingType
+		wantNames        [][]string
+	}{
+		{
+			name: "disjoint selectors have no findings",
+			clusterSPIFFEIDs: []spiffev1alpha1.ClusterSPIFFEID{
+				newClusterSPIFFEID("api", appLabels, false),
+				newClusterSPIFFEID("web", map[string]string{"app": "web"}, false),
+			},
+			pods: []corev1.Pod{newPod("payments", "api-0", appLabels)},
+		},
+		{
+			name: "pod selected by two ClusterSPIFFEIDs is an overlap",
+			clusterSPIFFEIDs: []spiffev1alpha1.ClusterSPIFFEID{
+				newClusterSPIFFEID("b-all", nil, false),
+				newClusterSPIFFEID("a-api", appLabels, false),
+			},
+			pods:      []corev1.Pod{newPod("payments", "api-0", appLabels), newPod("payments", "api-1", appLabels)},
+			wantTypes: []v1alpha1.ClusterSPIFFEIDFindingType{v1alpha1.ClusterSPIFFEIDFindingOverlap},
+			wantNames: [][]string{{"a-api", "b-all"}},
+		},
+		{
+			name: "fallback does not overlap with a matching ClusterSPIFFEID",
+			clusterSPIFFEIDs: []spiffev1alpha1.ClusterSPIFFEID{
+				newClusterSPIFFEID("api", appLabels, false),
+				newClusterSPIFFEID("default", nil, true),
+			},
+			pods: []corev1.Pod{newPod("payments", "api-0", appLabels), newPod("payments", "other", nil)},
+		},
+		{
+			name: "fallbacks overlap with each other",
+			clusterSPIFFEIDs: []spiffev1alpha1.ClusterSPIFFEID{
+				newClusterSPIFFEID("default-a", nil, true),
+				newClusterSPIFFEID("default-b", nil, true),
+			},
+			pods:      []corev1.Pod{newPod("payments", "other", nil)},
+			wantTypes: []v1alpha1.ClusterSPIFFEIDFindingType{v1alpha1.ClusterSPIFFEIDFindingOverlap},
+			wantNames: [][]string{{"default-a", "default-b"}},
+		},
+		{
+			name: "pods of ignored namespaces and unscheduled pods are skipped",
+			clusterSPIFFEIDs: []spiffev1alpha1.ClusterSPIFFEID{
+				newClusterSPIFFEID("a", nil, false),
+				newClusterSPIFFEID("b", nil, false),
+			},
+			pods: func() []corev1.Pod {
+				pending := newPod("payments", "pending", nil)
+				pending.Spec.NodeName = ""
+				return []corev1.Pod{newPod("openshift-monitoring", "prometheus-0", nil), pending}
+			}(),
+		},
+		{
+			name: "ttl above the CA TTL guarantee",
+			clusterSPIFFEIDs: func() []spiffev1alpha1.ClusterSPIFFEID {
+				clusterSPIFFEID := newClusterSPIFFEID("long", nil, false)
+				clusterSPIFFEID.Spec.TTL = metav1.Duration{Duration: 12 * time.Hour}
+				return []spiffev1alpha1.ClusterSPIFFEID{clusterSPIFFEID}
+			}(),
+			wantTypes: []v1alpha1.ClusterSPIFFEIDFindingType{v1alpha1.ClusterSPIFFEIDFindingTTLTooLong},
+			wantNames: [][]string{{"long"}},
+		},
+		{
+			name: "unparsable template",
+			clusterSPIFFEIDs: func() []spiffev1alpha1.ClusterSPIFFEID {
+				clusterSPIFFEID := newClusterSPIFFEID("broken", nil, false)
+				clusterSPIFFEID.Spec.SPIFFEIDTemplate = "spiffe://{{ .TrustDomain"
+				return []spiffev1alpha1.ClusterSPIFFEID{clusterSPIFFEID}
+			}(),
+			wantTypes: []v1alpha1.ClusterSPIFFEIDFindingType{v1alpha1.ClusterSPIFFEIDFindingInvalidTemplate},
+			wantNames: [][]string{{"broken"}},
+		},
+		{
+			name: "invalid DNS name rendered for a selected pod",
+			clusterSPIFFEIDs: func() []spiffev1alpha1.ClusterSPIFFEID {
+				clusterSPIFFEID := newClusterSPIFFEID("dns", nil, false)
+				clusterSPIFFEID.Spec.DNSNameTemplates = []string{"{{ .PodMeta.Name }}_svc.{{ .PodMeta.Namespace }}"}
+				return []spiffev1alpha1.ClusterSPIFFEID{clusterSPIFFEID}
+			}(),
+			pods:      []corev1.Pod{newPod("payments", "api-0", nil)},
+			wantTypes: []v1alpha1.ClusterSPIFFEIDFindingType{v1alpha1.ClusterSPIFFEIDFindingInvalidTemplate},
+			wantNames: [][]string{{"dns"}},
+		},
+		{
+			name: "SPIFFE ID outside the trust domain",
+			clusterSPIFFEIDs: func() []spiffev1alpha1.ClusterSPIFFEID {
+				clusterSPIFFEID := newClusterSPIFFEID("foreign", nil, false)
+				clusterSPIFFEID.Spec.SPIFFEIDTemplate = "spiffe://other.org/ns/{{ .PodMeta.Namespace }}"
+				return []spiffev1alpha1.ClusterSPIFFEID{clusterSPIFFEID}
+			}(),
+			pods:      []corev1.Pod{newPod("payments", "api-0", nil)},
+			wantTypes: []v1alpha1.ClusterSPIFFEIDFindingType{v1alpha1.ClusterSPIFFEIDFindingInvalidTemplate},
+			wantNames: [][]string{{"foreign"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := auditClusterSPIFFEIDs(tt.clusterSPIFFEIDs, namespaces, tt.pods, nodes, testEnv())
+			require.Len(t, findings, len(tt.wantTypes))
+			for i, finding := range findings {
+				assert.Equal(t, tt.wantTypes[i], finding.Type)
+				assert.Equal(t, tt.wantNames[i], finding.ClusterSPIFFEIDs)
+				assert.NotEmpty(t, finding.Message)
+			}
+		})
+	}
+}
+
+func TestAuditClusterSPIFFEIDs_NoCATTL(t *testing.T) {
+	clusterSPIFFEID := newClusterSPIFFEID("long", nil, false)
+	clusterSPIFFEID.Spec.TTL = metav1.Duration{Duration: 720 * time.Hour}
+	env := testEnv()
+	env.caTTL = 0
+
+	findings := auditClusterSPIFFEIDs([]spiffev1alpha1.ClusterSPIFFEID{clusterSPIFFEID}, nil, nil, nil, env)
+	assert.Empty(t, findings)
+}
+
+func TestContainsFinding(t *testing.T) {
+	findings := []v1alpha1.ClusterSPIFFEIDFinding{
+		{Type: v1alpha1.ClusterSPIFFEIDFindingOverlap, ClusterSPIFFEIDs: []string{"a", "b"}, Message: "1 pod(s)"},
+	}
+	assert.True(t, containsFinding(findings, v1alpha1.ClusterSPIFFEIDFinding{Type: v1alpha1.ClusterSPIFFEIDFindingOverlap, ClusterSPIFFEIDs: []string{"a", "b"}, Message: "2 pod(s)"}))
+	assert.False(t, containsFinding(findings, v1alpha1.ClusterSPIFFEIDFinding{Type: v1alpha1.ClusterSPIFFEIDFindingTTLTooLong, ClusterSPIFFEIDs: []string{"a"}}))
+}
@@ -0,0 +1,283 @@
+package cluster_spiffeid_audit
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
+	spireServerController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/spire-server"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// auditInterval is how often the ClusterSPIFFEIDs are audited again. Namespaces and pods are
+	// watched, the interval only covers nodes and missed events.
+	auditInterval = 10 * time.Minute
+
+	// minAuditInterval is the least time between two audits. Every event enqueues the same
+	// "cluster" request, so a burst of pod changes results in a single audit.
+	minAuditInterval = 30 * time.Second
+
+	operatorClassName = "zero-trust-workload-identity-manager-spire"
+)
+
+// podLister lists the pods of workloads, which the operator's cache holds only when managed by it
+type podLister interface {
+	List(context.Context, client.ObjectList, ...client.ListOption) error
+}
+
+// ClusterSPIFFEIDAuditReconciler audits the ClusterSPIFFEIDs handled by the operator's spire-controller-manager
+type ClusterSPIFFEIDAuditReconciler struct {
+	ctrlClient customClient.CustomCtrlClient
+	// podCache holds the pods of all namespaces, trimmed to what the audit reads
+	podCache      cache.Cache
+	pods          podLister
+	ctx           context.Context
+	eventRecorder record.EventRecorder
+	log           logr.Logger
+	scheme        *runtime.Scheme
+	// lastAudit is when the last audit started
+	lastAudit time.Time
+}
+
+// New returns a new Reconciler instance.
+func New(mgr ctrl.Manager) (*ClusterSPIFFEIDAuditReconciler, error) {
+	c, err := customClient.NewCustomClient(mgr)
+	if err != nil {
+		return nil, err
+	}
+	podCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+		ByObject: map[client.Object]cache.ByObject{
+			&corev1.Pod{}: {Transform: trimPod},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pod cache: %w", err)
+	}
+	return &ClusterSPIFFEIDAuditReconciler{
+		ctrlClient:    c,
+		podCache:      podCache,
+		pods:          podCache,
+		ctx:           context.Background(),
+		eventRecorder: mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerClusterSPIFFEIDAuditControllerName),
+		log:           ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerClusterSPIFFEIDAuditControllerName),
+		scheme:        mgr.GetScheme(),
+	}, nil
+}
+
+func (r *ClusterSPIFFEIDAuditReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	if wait := minAuditInterval - time.Since(r.lastAudit); wait > 0 {
+		return ctrl.Result{RequeueAfter: wait}, nil
+	}
+	r.log.Info(fmt.Sprintf("reconciling %s", utils.ZeroTrustWorkloadIdentityManagerClusterSPIFFEIDAuditControllerName))
+	var ztwim v1alpha1.ZeroTrustWorkloadIdentityManager
+	if err := r.ctrlClient.Get(ctx, req.NamespacedName, &ztwim); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.log.Info("ZeroTrustWorkloadIdentityManager resource not found. Ignoring since object must be deleted or not been created.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	r.lastAudit = time.Now()
+
+	env, err := r.auditEnv(ctx, &ztwim)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var clusterSPIFFEIDList spiffev1alpha1.ClusterSPIFFEIDList
+	if err := r.ctrlClient.List(ctx, &clusterSPIFFEIDList); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list ClusterSPIFFEIDs: %w", err)
+	}
+	var clusterSPIFFEIDs []spiffev1alpha1.ClusterSPIFFEID
+	for _, clusterSPIFFEID := range clusterSPIFFEIDList.Items {
+		if clusterSPIFFEID.Spec.ClassName == operatorClassName {
+			clusterSPIFFEIDs = append(clusterSPIFFEIDs, clusterSPIFFEID)
+		}
+	}
+
+	var namespaces corev1.NamespaceList
+	if err := r.ctrlClient.List(ctx, &namespaces); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	var pods corev1.PodList
+	if err := r.pods.List(ctx, &pods); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list pods: %w", err)
+	}
+	var nodes corev1.NodeList
+	if err := r.ctrlClient.List(ctx, &nodes); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to list nodes: %w", err)
+	}
+
+	findings := auditClusterSPIFFEIDs(clusterSPIFFEIDs, namespaces.Items, pods.Items, nodes.Items, env)
+	if err := r.reportFindings(ctx, &ztwim, findings); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: auditInterval}, nil
+}
+
+// auditEnv returns the trust domain, cluster name, CA TTL and ignored namespaces spire-controller-manager uses
+func (r *ClusterSPIFFEIDAuditReconciler) auditEnv(ctx context.Context, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) (auditEnv, error) {
+	env := auditEnv{
+		trustDomain: ztwim.Spec.TrustDomain,
+		clusterName: ztwim.Spec.ClusterName,
+	}
+	for _, expr := range spireServerController.SpireControllerManagerIgnoreNamespaces {
+		regex, err := regexp.Compile(expr)
+		if err != nil {
+			return auditEnv{}, err
+		}
+		env.ignoredNamespaces = append(env.ignoredNamespaces, regex)
+	}
+
+	// Without a SpireServer the CA TTL is unknown and TTLs are not checked
+	var server v1alpha1.SpireServer
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: "cluster"}, &server); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return auditEnv{}, err
+		}
+	} else {
+		env.caTTL = server.Spec.CAValidity.Duration
+	}
+	return env, nil
+}
+
+// trimPod keeps the pod fields the audit and the ClusterSPIFFEID templates read, so that caching
+// the pods of all namespaces stays cheap. Templates referencing annotations, containers or volumes
+// are rare and render as for a pod without them.
+func trimPod(obj interface{}) (interface{}, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return obj, nil
+	}
+	trimmed := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+			Labels:    pod.Labels,
+			UID:       pod.UID,
+		},
+		Spec: corev1.PodSpec{
+			NodeName:           pod.Spec.NodeName,
+			ServiceAccountName: pod.Spec.ServiceAccountName,
+			Hostname:           pod.Spec.Hostname,
+			Subdomain:          pod.Spec.Subdomain,
+		},
+	}
+	return trimmed, nil
+}
+
+// reportFindings stores the findings in the ZeroTrustWorkloadIdentityManager status and emits
+// an event for each new finding
+func (r *ClusterSPIFFEIDAuditReconciler) reportFindings(ctx context.Context, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, findings []v1alpha1.ClusterSPIFFEIDFinding) error {
+	previous := ztwim.Status.ClusterSPIFFEIDFindings
+	if equality.Semantic.DeepEqual(previous, findings) {
+		return nil
+	}
+
+	for _, finding := range findings {
+		if containsFinding(previous, finding) {
+			continue
+		}
+		r.log.Info("ClusterSPIFFEID audit finding", "type", finding.Type, "clusterSPIFFEIDs", finding.ClusterSPIFFEIDs, "message", finding.Message)
+		r.eventRecorder.Event(ztwim, corev1.EventTypeWarning, "ClusterSPIFFEID"+string(finding.Type), finding.Message)
+		for _, name := range finding.ClusterSPIFFEIDs {
+			clusterSPIFFEID := &spiffev1alpha1.ClusterSPIFFEID{}
+			if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: name}, clusterSPIFFEID); err == nil {
+				r.eventRecorder.Event(clusterSPIFFEID, corev1.EventTypeWarning, string(finding.Type), finding.Message)
+			}
+		}
+	}
+
+	// The ZeroTrustWorkloadIdentityManager controller owns the rest of the status, only the
+	// findings are patched
+	original := ztwim.DeepCopy()
+	ztwim.Status.ClusterSPIFFEIDFindings = findings
+	if err := r.ctrlClient.StatusPatch(ctx, ztwim, client.MergeFrom(original)); err != nil {
+		r.log.Error(err, "failed to update ClusterSPIFFEID findings")
+		return err
+	}
+	r.log.Info("Updated ClusterSPIFFEID findings", "count", len(findings))
+	return nil
+}
+
+// containsFinding reports whether the finding was already reported. Overlap messages carry pod
+// counts, so findings are compared by type and ClusterSPIFFEIDs only.
+func containsFinding(findings []v1alpha1.ClusterSPIFFEIDFinding, finding v1alpha1.ClusterSPIFFEIDFinding) bool {
+	for _, f := range findings {
+		if f.Type == finding.Type && strings.Join(f.ClusterSPIFFEIDs, ",") == strings.Join(finding.ClusterSPIFFEIDs, ",") {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ClusterSPIFFEIDAuditReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Always enqueue the "cluster" CR for reconciliation
+	mapFunc := func(ctx context.Context, _ client.Object) []reconcile.Request {
+		return []reconcile.Request{
+			{
+				NamespacedName: types.NamespacedName{
+					Name: "cluster",
+				},
+			},
+		}
+	}
+
+	if err := mgr.Add(r.podCache); err != nil {
+		return err
+	}
+
+	// Findings dropped by another status writer are restored
+	findingsChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldZTWIM, ok := e.ObjectOld.(*v1alpha1.ZeroTrustWorkloadIdentityManager)
+			newZTWIM, ok2 := e.ObjectNew.(*v1alpha1.ZeroTrustWorkloadIdentityManager)
+			return ok && ok2 && !equality.Semantic.DeepEqual(oldZTWIM.Status.ClusterSPIFFEIDFindings, newZTWIM.Status.ClusterSPIFFEIDFindings)
+		},
+	}
+
+	// Only scheduling and label changes of pods change which ClusterSPIFFEIDs select them
+	podChanged := predicate.TypedFuncs[*corev1.Pod]{
+		UpdateFunc: func(e event.TypedUpdateEvent[*corev1.Pod]) bool {
+			return e.ObjectOld.Spec.NodeName != e.ObjectNew.Spec.NodeName ||
+				!equality.Semantic.DeepEqual(e.ObjectOld.Labels, e.ObjectNew.Labels)
+		},
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.ZeroTrustWorkloadIdentityManager{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, findingsChanged))).
+		Named(utils.ZeroTrustWorkloadIdentityManagerClusterSPIFFEIDAuditControllerName).
+		Watches(&spiffev1alpha1.ClusterSPIFFEID{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&v1alpha1.SpireServer{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		WatchesRawSource(source.Kind(r.podCache, &corev1.Pod{}, handler.TypedEnqueueRequestsFromMapFunc(func(ctx context.Context, _ *corev1.Pod) []reconcile.Request {
+			return mapFunc(ctx, nil)
+		}), podChanged)).
+		Complete(r)
+}
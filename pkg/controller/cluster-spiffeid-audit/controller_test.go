@@ -0,0 +1,172 @@
+package cluster_spiffeid_audit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+)
+
+func newTestReconciler(fakeClient *fakes.FakeCustomCtrlClient) (*ClusterSPIFFEIDAuditReconciler, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = spiffev1alpha1.AddToScheme(scheme)
+	recorder := record.NewFakeRecorder(100)
+	return &ClusterSPIFFEIDAuditReconciler{
+		ctrlClient:    fakeClient,
+		pods:          fakeClient,
+		ctx:           context.Background(),
+		eventRecorder: recorder,
+		log:           logr.Discard(),
+		scheme:        scheme,
+	}, recorder
+}
+
+// stubClient serves the ZeroTrustWorkloadIdentityManager, the ClusterSPIFFEIDs and a single pod
+// selected by all of them
+func stubClient(fakeClient *fakes.FakeCustomCtrlClient, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, clusterSPIFFEIDs []spiffev1alpha1.ClusterSPIFFEID) {
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		switch o := obj.(type) {
+		case *v1alpha1.ZeroTrustWorkloadIdentityManager:
+			ztwim.DeepCopyInto(o)
+		case *v1alpha1.SpireServer:
+			o.Spec.CAValidity = metav1.Duration{Duration: 24 * time.Hour}
+		case *spiffev1alpha1.ClusterSPIFFEID:
+			for i := range clusterSPIFFEIDs {
+				if clusterSPIFFEIDs[i].Name == key.Name {
+					clusterSPIFFEIDs[i].DeepCopyInto(o)
+					return nil
+				}
+			}
+			return kerrors.NewNotFound(schema.GroupResource{Resource: "clusterspiffeids"}, key.Name)
+		}
+		return nil
+	}
+	fakeClient.ListStub = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+		switch l := list.(type) {
+		case *spiffev1alpha1.ClusterSPIFFEIDList:
+			l.Items = clusterSPIFFEIDs
+		case *corev1.NamespaceList:
+			l.Items = []corev1.Namespace{{ObjectMeta: metav1.ObjectMeta{Name: "payments"}}}
+		case *corev1.PodList:
+			l.Items = []corev1.Pod{newPod("payments", "api-0", nil)}
+		}
+		return nil
+	}
+}
+
+func TestReconcile_ZTWIMNotFound(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{Resource: "zerotrustworkloadidentitymanagers"}, "cluster"))
+	reconciler, _ := newTestReconciler(fakeClient)
+
+	result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}})
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Equal(t, 0, fakeClient.ListCallCount())
+}
+
+func TestReconcile_ReportsFindings(t *testing.T) {
+	ztwim := &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{TrustDomain: "example.org", ClusterName: "test-cluster"},
+	}
+	other := newClusterSPIFFEID("other-class", nil, false)
+	other.Spec.ClassName = "other"
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, ztwim, []spiffev1alpha1.ClusterSPIFFEID{
+		newClusterSPIFFEID("a", nil, false),
+		newClusterSPIFFEID("b", nil, false),
+		other,
+	})
+	reconciler, recorder := newTestReconciler(fakeClient)
+
+	result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}})
+	require.NoError(t, err)
+	assert.Equal(t, auditInterval, result.RequeueAfter)
+
+	require.Equal(t, 1, fakeClient.StatusPatchCallCount())
+	assert.Zero(t, fakeClient.StatusUpdateWithRetryCallCount())
+	_, obj, patch, _ := fakeClient.StatusPatchArgsForCall(0)
+	updated := obj.(*v1alpha1.ZeroTrustWorkloadIdentityManager)
+	data, err := patch.Data(updated)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"clusterSPIFFEIDFindings"`)
+	assert.NotContains(t, string(data), `"conditions"`, "only the findings are patched")
+	require.Len(t, updated.Status.ClusterSPIFFEIDFindings, 1)
+	assert.Equal(t, v1alpha1.ClusterSPIFFEIDFindingOverlap, updated.Status.ClusterSPIFFEIDFindings[0].Type)
+	assert.Equal(t, []string{"a", "b"}, updated.Status.ClusterSPIFFEIDFindings[0].ClusterSPIFFEIDs)
+	// one event on the ZeroTrustWorkloadIdentityManager and one on each ClusterSPIFFEID
+	assert.Len(t, recorder.Events, 3)
+
+	// Unchanged findings are not written again
+	ztwim.Status.ClusterSPIFFEIDFindings = updated.Status.ClusterSPIFFEIDFindings
+	reconciler.lastAudit = time.Time{}
+	_, err = reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}})
+	require.NoError(t, err)
+	assert.Equal(t, 1, fakeClient.StatusPatchCallCount())
+}
+
+func TestReconcile_DebouncesAudits(t *testing.T) {
+	ztwim := &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{TrustDomain: "example.org", ClusterName: "test-cluster"},
+	}
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, ztwim, []spiffev1alpha1.ClusterSPIFFEID{newClusterSPIFFEID("a", nil, false)})
+	reconciler, _ := newTestReconciler(fakeClient)
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: "cluster"}}
+
+	_, err := reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	audited := fakeClient.ListCallCount()
+	require.Positive(t, audited)
+
+	// Events right after an audit wait for the minimum interval instead of auditing again
+	result, err := reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, audited, fakeClient.ListCallCount())
+	assert.Positive(t, result.RequeueAfter)
+	assert.LessOrEqual(t, result.RequeueAfter, minAuditInterval)
+
+	reconciler.lastAudit = time.Now().Add(-minAuditInterval)
+	_, err = reconciler.Reconcile(context.Background(), req)
+	require.NoError(t, err)
+	assert.Greater(t, fakeClient.ListCallCount(), audited)
+}
+
+func TestTrimPod(t *testing.T) {
+	pod := newPod("payments", "api-0", map[string]string{"app": "api"})
+	pod.Spec.ServiceAccountName = "api"
+	pod.Spec.Containers = []corev1.Container{{Name: "api", Image: "registry.example.com/api"}}
+	pod.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubelet"}}
+	pod.Status.Phase = corev1.PodRunning
+	pod.UID = "api-0-uid"
+	pod.Annotations = map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"}
+	pod.OwnerReferences = []metav1.OwnerReference{{Kind: "StatefulSet", Name: "api"}}
+
+	obj, err := trimPod(&pod)
+	require.NoError(t, err)
+	trimmed := obj.(*corev1.Pod)
+	assert.Equal(t, metav1.ObjectMeta{Name: "api-0", Namespace: "payments", Labels: pod.Labels, UID: "api-0-uid"}, trimmed.ObjectMeta)
+	assert.Equal(t, pod.Spec.NodeName, trimmed.Spec.NodeName)
+	assert.Equal(t, "api", trimmed.Spec.ServiceAccountName)
+	assert.Empty(t, trimmed.Spec.Containers)
+	assert.Empty(t, trimmed.Status.Phase)
+}
@@ -0,0 +1,324 @@
+package join_token
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spiretypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/version"
+)
+
+const (
+	// Kubernetes-compliant condition names
+	ConfigurationValid = "ConfigurationValid"
+	TokenIssued        = "TokenIssued"
+
+	// JoinTokenSecretKey is the Secret key holding the token value
+	JoinTokenSecretKey = "token"
+
+	joinTokenSecretSuffix = "-join-token"
+
+	// usageCheckInterval is how often an active token is checked for use by an agent
+	usageCheckInterval = time.Minute
+)
+
+// JoinTokenReconciler reconciles a JoinToken object
+type JoinTokenReconciler struct {
+	ctrlClient    customClient.CustomCtrlClient
+	ctx           context.Context
+	eventRecorder record.EventRecorder
+	log           logr.Logger
+	scheme        *runtime.Scheme
+	dialServerAPI spireapi.DialFunc
+}
+
+// New returns a new Reconciler instance.
+func New(mgr ctrl.Manager) (*JoinTokenReconciler, error) {
+	c, err := customClient.NewCustomClient(mgr)
+	if err != nil {
+		return nil, err
+	}
+	return &JoinTokenReconciler{
+		ctrlClient:    c,
+		ctx:           context.Background(),
+		eventRecorder: mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerJoinTokenControllerName),
+		log:           ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerJoinTokenControllerName),
+		scheme:        mgr.GetScheme(),
+		dialServerAPI: utils.ServerAPIDialer(c),
+	}, nil
+}
+
+func (r *JoinTokenReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.log.Info(fmt.Sprintf("reconciling %s", utils.ZeroTrustWorkloadIdentityManagerJoinTokenControllerName), "name", req.Name)
+	var joinToken v1alpha1.JoinToken
+	if err := r.ctrlClient.Get(ctx, req.NamespacedName, &joinToken); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.log.Info("JoinToken resource not found. Ignoring since object must be deleted or not been created.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	statusMgr := status.NewManager(r.ctrlClient)
+	originalStatus := joinToken.Status.DeepCopy()
+	defer func() {
+		if !equality.Semantic.DeepEqual(originalStatus, &joinToken.Status) {
+			statusMgr.RequestStatusUpdate()
+		}
+		if err := statusMgr.ApplyStatus(ctx, &joinToken, func() *v1alpha1.ConditionalStatus {
+			return &joinToken.Status.ConditionalStatus
+		}); err != nil {
+			r.log.Error(err, "failed to update status")
+		}
+	}()
+
+	// Used and expired tokens are final, a new JoinToken is needed to onboard another node
+	if joinToken.Status.State == v1alpha1.JoinTokenStateUsed || joinToken.Status.State == v1alpha1.JoinTokenStateExpired {
+		return ctrl.Result{}, nil
+	}
+
+	var ztwim v1alpha1.ZeroTrustWorkloadIdentityManager
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: "cluster"}, &ztwim); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get ZeroTrustWorkloadIdentityManager")
+			statusMgr.AddCondition(v1alpha1.Ready, v1alpha1.ReasonFailed,
+				"Failed to retrieve ZeroTrustWorkloadIdentityManager from cluster",
+				metav1.ConditionFalse)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	trustDomain, agentID, err := validateJoinToken(&joinToken, ztwim.Spec.TrustDomain)
+	if err != nil {
+		r.log.Error(err, "invalid JoinToken configuration", "name", joinToken.Name)
+		statusMgr.AddCondition(ConfigurationValid, "InvalidJoinTokenConfiguration",
+			fmt.Sprintf("JoinToken configuration validation failed: %v", err),
+			metav1.ConditionFalse)
+		return ctrl.Result{}, nil
+	}
+	statusMgr.RemoveCondition(ConfigurationValid)
+
+	if joinToken.Status.ExpiresAt == nil {
+		if err := r.issueToken(ctx, &joinToken, agentID, statusMgr); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: nextCheck(joinToken.Status.ExpiresAt.Time)}, nil
+	}
+
+	return r.trackToken(ctx, &joinToken, trustDomain, statusMgr)
+}
+
+// issueToken creates the join token through the SPIRE server API and stores it in the Secret
+func (r *JoinTokenReconciler) issueToken(ctx context.Context, joinToken *v1alpha1.JoinToken, agentID *spiretypes.SPIFFEID, statusMgr *status.Manager) error {
+	joinToken.Status.State = v1alpha1.JoinTokenStatePending
+
+	apiClient, err := r.dialServerAPI()
+	if err != nil {
+		r.log.Error(err, "failed to connect to the SPIRE server API")
+		statusMgr.AddCondition(TokenIssued, "ServerAPIUnavailable", err.Error(), metav1.ConditionFalse)
+		return err
+	}
+	defer apiClient.Close()
+
+	token, err := apiClient.CreateJoinToken(ctx, joinToken.Spec.TTL.Duration, agentID)
+	if err != nil {
+		r.log.Error(err, "failed to create join token", "name", joinToken.Name)
+		statusMgr.AddCondition(TokenIssued, "TokenCreationFailed", err.Error(), metav1.ConditionFalse)
+		return err
+	}
+
+	secret := generateJoinTokenSecret(joinToken, token.Value)
+	if err := controllerutil.SetControllerReference(joinToken, secret, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on join token secret")
+		statusMgr.AddCondition(TokenIssued, "SecretCreationFailed",
+			fmt.Sprintf("Failed to set owner reference on Secret: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+	if err := r.storeToken(ctx, joinToken, secret, statusMgr); err != nil {
+		return err
+	}
+
+	expiresAt := metav1.NewTime(time.Unix(token.ExpiresAt, 0))
+	joinToken.Status.State = v1alpha1.JoinTokenStateActive
+	joinToken.Status.SecretName = secret.Name
+	joinToken.Status.ExpiresAt = &expiresAt
+	statusMgr.AddCondition(TokenIssued, "TokenCreated",
+		fmt.Sprintf("Join token stored in Secret %s/%s, expires at %s", secret.Namespace, secret.Name, expiresAt.UTC().Format(time.RFC3339)),
+		metav1.ConditionTrue)
+	r.eventRecorder.Eventf(joinToken, corev1.EventTypeNormal, "TokenCreated", "Join token stored in Secret %s/%s", secret.Namespace, secret.Name)
+	r.log.Info("Created join token", "name", joinToken.Name, "secret", secret.Name, "expiresAt", expiresAt)
+	return nil
+}
+
+// storeToken creates the Secret holding the token. A Secret left behind by an earlier attempt whose
+// status update was lost is overwritten, since its token was never reported as issued.
+func (r *JoinTokenReconciler) storeToken(ctx context.Context, joinToken *v1alpha1.JoinToken, secret *corev1.Secret, statusMgr *status.Manager) error {
+	err := r.ctrlClient.Create(ctx, secret)
+	if kerrors.IsAlreadyExists(err) {
+		existing := &corev1.Secret{}
+		if getErr := r.ctrlClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, existing); getErr == nil && utils.IsManagedBy(existing, joinToken) {
+			secret.ResourceVersion = existing.ResourceVersion
+			err = r.ctrlClient.Update(ctx, secret)
+		}
+	}
+	if err != nil {
+		if conflictErr := utils.HandleCreateConflict(err, secret, r.log, statusMgr, TokenIssued); conflictErr != nil {
+			return conflictErr
+		}
+		r.log.Error(err, "failed to create join token secret", "name", secret.Name)
+		statusMgr.AddCondition(TokenIssued, "SecretCreationFailed",
+			fmt.Sprintf("Failed to create Secret: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+	return nil
+}
+
+// trackToken records whether an agent attested with the token or the token expired
+func (r *JoinTokenReconciler) trackToken(ctx context.Context, joinToken *v1alpha1.JoinToken, trustDomain spiffeid.TrustDomain, statusMgr *status.Manager) (ctrl.Result, error) {
+	secret := &corev1.Secret{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: joinToken.Status.SecretName, Namespace: utils.GetOperatorNamespace()}, secret)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// The token value only lives in the Secret, so a deleted Secret cannot be restored
+		statusMgr.AddCondition(TokenIssued, "TokenSecretMissing",
+			fmt.Sprintf("Secret %s holding the join token was deleted, create a new JoinToken", joinToken.Status.SecretName),
+			metav1.ConditionFalse)
+		return r.expireIfDue(joinToken, statusMgr), nil
+	}
+
+	apiClient, err := r.dialServerAPI()
+	if err != nil {
+		r.log.Error(err, "failed to connect to the SPIRE server API")
+		return ctrl.Result{RequeueAfter: usageCheckInterval}, nil
+	}
+	defer apiClient.Close()
+
+	agentID, err := joinTokenAgentID(trustDomain, string(secret.Data[JoinTokenSecretKey]))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	agent, err := apiClient.GetAgent(ctx, agentID)
+	if err != nil {
+		r.log.Error(err, "failed to look up agent attested with join token", "name", joinToken.Name)
+		return ctrl.Result{RequeueAfter: usageCheckInterval}, nil
+	}
+	if agent != nil {
+		joinToken.Status.State = v1alpha1.JoinTokenStateUsed
+		joinToken.Status.AgentSpiffeID = fmt.Sprintf("spiffe://%s%s", agent.Id.GetTrustDomain(), agent.Id.GetPath())
+		statusMgr.AddCondition(TokenIssued, "TokenUsed",
+			fmt.Sprintf("Agent %s attested with the join token", joinToken.Status.AgentSpiffeID),
+			metav1.ConditionTrue)
+		r.eventRecorder.Eventf(joinToken, corev1.EventTypeNormal, "TokenUsed", "Agent %s attested with the join token", joinToken.Status.AgentSpiffeID)
+		return ctrl.Result{}, nil
+	}
+
+	return r.expireIfDue(joinToken, statusMgr), nil
+}
+
+// expireIfDue marks the token expired once its expiry has passed, otherwise schedules the next check
+func (r *JoinTokenReconciler) expireIfDue(joinToken *v1alpha1.JoinToken, statusMgr *status.Manager) ctrl.Result {
+	if time.Now().Before(joinToken.Status.ExpiresAt.Time) {
+		return ctrl.Result{RequeueAfter: nextCheck(joinToken.Status.ExpiresAt.Time)}
+	}
+	joinToken.Status.State = v1alpha1.JoinTokenStateExpired
+	statusMgr.AddCondition(TokenIssued, "TokenExpired",
+		fmt.Sprintf("Join token expired at %s without being used", joinToken.Status.ExpiresAt.UTC().Format(time.RFC3339)),
+		metav1.ConditionFalse)
+	return ctrl.Result{}
+}
+
+// validateJoinToken checks the JoinToken spec and returns the trust domain and the optional agent SPIFFE ID
+func validateJoinToken(joinToken *v1alpha1.JoinToken, trustDomainName string) (spiffeid.TrustDomain, *spiretypes.SPIFFEID, error) {
+	trustDomain, err := spiffeid.TrustDomainFromString(trustDomainName)
+	if err != nil {
+		return spiffeid.TrustDomain{}, nil, fmt.Errorf("invalid trust domain %q: %w", trustDomainName, err)
+	}
+	if joinToken.Spec.TTL.Duration < time.Minute {
+		return spiffeid.TrustDomain{}, nil, fmt.Errorf("ttl must be at least 1m, got %s", joinToken.Spec.TTL.Duration)
+	}
+	if joinToken.Spec.SpiffeID == "" {
+		return trustDomain, nil, nil
+	}
+
+	id, err := spiffeid.FromString(joinToken.Spec.SpiffeID)
+	if err != nil {
+		return spiffeid.TrustDomain{}, nil, fmt.Errorf("invalid spiffeID %q: %w", joinToken.Spec.SpiffeID, err)
+	}
+	if !id.MemberOf(trustDomain) {
+		return spiffeid.TrustDomain{}, nil, fmt.Errorf("spiffeID %q is not in trust domain %q", joinToken.Spec.SpiffeID, trustDomainName)
+	}
+	return trustDomain, &spiretypes.SPIFFEID{TrustDomain: id.TrustDomain().Name(), Path: id.Path()}, nil
+}
+
+// joinTokenAgentID returns the SPIFFE ID SPIRE assigns to an agent attested with the token
+func joinTokenAgentID(trustDomain spiffeid.TrustDomain, token string) (*spiretypes.SPIFFEID, error) {
+	id, err := spiffeid.FromSegments(trustDomain, "spire", "agent", "join_token", token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid join token in Secret: %w", err)
+	}
+	return &spiretypes.SPIFFEID{TrustDomain: id.TrustDomain().Name(), Path: id.Path()}, nil
+}
+
+// generateJoinTokenSecret returns the Secret holding the join token
+func generateJoinTokenSecret(joinToken *v1alpha1.JoinToken, token string) *corev1.Secret {
+	name := joinToken.Spec.SecretName
+	if name == "" {
+		name = joinToken.Name + joinTokenSecretSuffix
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.StandardizedLabels("spire-join-token", utils.ComponentControlPlane, version.SpireServerVersion, nil),
+		},
+		Type: corev1.SecretTypeOpaque,
+		Data: map[string][]byte{
+			JoinTokenSecretKey: []byte(token),
+		},
+	}
+}
+
+// nextCheck returns when to check an active token again
+func nextCheck(expiresAt time.Time) time.Duration {
+	untilExpiry := time.Until(expiresAt)
+	if untilExpiry <= 0 {
+		return time.Second
+	}
+	if untilExpiry < usageCheckInterval {
+		return untilExpiry + time.Second
+	}
+	return usageCheckInterval
+}
+
+func (r *JoinTokenReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.JoinToken{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named(utils.ZeroTrustWorkloadIdentityManagerJoinTokenControllerName).
+		Owns(&corev1.Secret{}).
+		Complete(r)
+}
@@ -0,0 +1,301 @@
+package join_token
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	spiretypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	spireapifakes "github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi/fakes"
+)
+
+func newTestReconciler(fakeClient *fakes.FakeCustomCtrlClient, api spireapi.Client) *JoinTokenReconciler {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	return &JoinTokenReconciler{
+		ctrlClient:    fakeClient,
+		ctx:           context.Background(),
+		eventRecorder: record.NewFakeRecorder(100),
+		log:           logr.Discard(),
+		scheme:        scheme,
+		dialServerAPI: func() (spireapi.Client, error) {
+			if api == nil {
+				return nil, errors.New("socket not available")
+			}
+			return api, nil
+		},
+	}
+}
+
+func newTestJoinToken(spec v1alpha1.JoinTokenSpec) *v1alpha1.JoinToken {
+	if spec.TTL.Duration == 0 {
+		spec.TTL = metav1.Duration{Duration: time.Hour}
+	}
+	return &v1alpha1.JoinToken{
+		ObjectMeta: metav1.ObjectMeta{Name: "vm-onboarding", UID: "test-uid"},
+		Spec:       spec,
+	}
+}
+
+// stubGets serves the JoinToken, the ZTWIM and optionally the token Secret from the fake client
+func stubGets(fakeClient *fakes.FakeCustomCtrlClient, joinToken *v1alpha1.JoinToken, secret *corev1.Secret) {
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		switch o := obj.(type) {
+		case *v1alpha1.JoinToken:
+			joinToken.DeepCopyInto(o)
+		case *v1alpha1.ZeroTrustWorkloadIdentityManager:
+			o.Name = "cluster"
+			o.Spec.TrustDomain = "example.com"
+		case *corev1.Secret:
+			if secret == nil {
+				return kerrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, key.Name)
+			}
+			secret.DeepCopyInto(o)
+		}
+		return nil
+	}
+}
+
+// lastStatus returns the JoinToken passed to the last status update
+func lastStatus(t *testing.T, fakeClient *fakes.FakeCustomCtrlClient) *v1alpha1.JoinToken {
+	t.Helper()
+	require.Positive(t, fakeClient.StatusUpdateWithRetryCallCount())
+	_, obj, _ := fakeClient.StatusUpdateWithRetryArgsForCall(fakeClient.StatusUpdateWithRetryCallCount() - 1)
+	joinToken, ok := obj.(*v1alpha1.JoinToken)
+	require.True(t, ok)
+	return joinToken
+}
+
+func TestReconcile_IssuesToken(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	expiresAt := time.Now().Add(time.Hour).Unix()
+	api := &spireapifakes.FakeClient{}
+	api.CreateJoinTokenReturns(&spiretypes.JoinToken{Value: "abc123", ExpiresAt: expiresAt}, nil)
+	reconciler := newTestReconciler(fakeClient, api)
+	stubGets(fakeClient, newTestJoinToken(v1alpha1.JoinTokenSpec{SpiffeID: "spiffe://example.com/vm/build-runner"}), nil)
+
+	result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, usageCheckInterval, result.RequeueAfter)
+	require.Equal(t, 1, api.CreateJoinTokenCallCount())
+	_, ttl, agentID := api.CreateJoinTokenArgsForCall(0)
+	assert.Equal(t, time.Hour, ttl)
+	require.NotNil(t, agentID)
+	assert.Equal(t, "/vm/build-runner", agentID.Path)
+
+	require.Equal(t, 1, fakeClient.CreateCallCount())
+	_, obj, _ := fakeClient.CreateArgsForCall(0)
+	secret, ok := obj.(*corev1.Secret)
+	require.True(t, ok)
+	assert.Equal(t, "vm-onboarding-join-token", secret.Name)
+	assert.Equal(t, "abc123", string(secret.Data[JoinTokenSecretKey]))
+	require.Len(t, secret.OwnerReferences, 1)
+	assert.Equal(t, "JoinToken", secret.OwnerReferences[0].Kind)
+
+	updated := lastStatus(t, fakeClient)
+	assert.Equal(t, v1alpha1.JoinTokenStateActive, updated.Status.State)
+	assert.Equal(t, "vm-onboarding-join-token", updated.Status.SecretName)
+	require.NotNil(t, updated.Status.ExpiresAt)
+	assert.Equal(t, expiresAt, updated.Status.ExpiresAt.Unix())
+	condition := apimeta.FindStatusCondition(updated.Status.Conditions, TokenIssued)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+}
+
+func TestReconcile_ServerAPIUnavailable(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient, nil)
+	stubGets(fakeClient, newTestJoinToken(v1alpha1.JoinTokenSpec{}), nil)
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+	require.Error(t, err)
+	assert.Zero(t, fakeClient.CreateCallCount())
+	updated := lastStatus(t, fakeClient)
+	assert.Equal(t, v1alpha1.JoinTokenStatePending, updated.Status.State)
+	condition := apimeta.FindStatusCondition(updated.Status.Conditions, TokenIssued)
+	require.NotNil(t, condition)
+	assert.Equal(t, "ServerAPIUnavailable", condition.Reason)
+}
+
+func TestReconcile_InvalidSpiffeID(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api := &spireapifakes.FakeClient{}
+	reconciler := newTestReconciler(fakeClient, api)
+	stubGets(fakeClient, newTestJoinToken(v1alpha1.JoinTokenSpec{SpiffeID: "spiffe://other.org/vm"}), nil)
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+	require.NoError(t, err)
+	assert.Zero(t, fakeClient.CreateCallCount())
+	condition := apimeta.FindStatusCondition(lastStatus(t, fakeClient).Status.Conditions, ConfigurationValid)
+	require.NotNil(t, condition)
+	assert.Equal(t, "InvalidJoinTokenConfiguration", condition.Reason)
+}
+
+func TestReconcile_TracksActiveToken(t *testing.T) {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "vm-onboarding-join-token"},
+		Data:       map[string][]byte{JoinTokenSecretKey: []byte("abc123")},
+	}
+	activeToken := func(expiresAt time.Time) *v1alpha1.JoinToken {
+		joinToken := newTestJoinToken(v1alpha1.JoinTokenSpec{})
+		joinToken.Status.State = v1alpha1.JoinTokenStateActive
+		joinToken.Status.SecretName = secret.Name
+		joinToken.Status.ExpiresAt = &metav1.Time{Time: expiresAt}
+		return joinToken
+	}
+
+	t.Run("agent attested with the token", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		api := &spireapifakes.FakeClient{}
+		api.GetAgentReturns(&spiretypes.Agent{Id: &spiretypes.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/join_token/abc123"}}, nil)
+		reconciler := newTestReconciler(fakeClient, api)
+		stubGets(fakeClient, activeToken(time.Now().Add(time.Hour)), secret)
+
+		result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		updated := lastStatus(t, fakeClient)
+		assert.Equal(t, v1alpha1.JoinTokenStateUsed, updated.Status.State)
+		assert.Equal(t, "spiffe://example.com/spire/agent/join_token/abc123", updated.Status.AgentSpiffeID)
+		require.Equal(t, 1, api.GetAgentCallCount())
+		_, agentID := api.GetAgentArgsForCall(0)
+		assert.Equal(t, "/spire/agent/join_token/abc123", agentID.GetPath())
+	})
+
+	t.Run("unused token is requeued until expiry", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newTestReconciler(fakeClient, &spireapifakes.FakeClient{})
+		stubGets(fakeClient, activeToken(time.Now().Add(time.Hour)), secret)
+
+		result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+		require.NoError(t, err)
+		assert.Equal(t, usageCheckInterval, result.RequeueAfter)
+		assert.Equal(t, v1alpha1.JoinTokenStateActive, lastStatus(t, fakeClient).Status.State)
+	})
+
+	t.Run("unused token expires", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newTestReconciler(fakeClient, &spireapifakes.FakeClient{})
+		stubGets(fakeClient, activeToken(time.Now().Add(-time.Minute)), secret)
+
+		result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+		require.NoError(t, err)
+		assert.Zero(t, result.RequeueAfter)
+		updated := lastStatus(t, fakeClient)
+		assert.Equal(t, v1alpha1.JoinTokenStateExpired, updated.Status.State)
+		condition := apimeta.FindStatusCondition(updated.Status.Conditions, TokenIssued)
+		require.NotNil(t, condition)
+		assert.Equal(t, "TokenExpired", condition.Reason)
+	})
+
+	t.Run("deleted secret is reported", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newTestReconciler(fakeClient, &spireapifakes.FakeClient{})
+		stubGets(fakeClient, activeToken(time.Now().Add(time.Hour)), nil)
+
+		_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+		require.NoError(t, err)
+		condition := apimeta.FindStatusCondition(lastStatus(t, fakeClient).Status.Conditions, TokenIssued)
+		require.NotNil(t, condition)
+		assert.Equal(t, "TokenSecretMissing", condition.Reason)
+	})
+}
+
+func TestReconcile_FinalStateIsNotReconciled(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	joinToken := newTestJoinToken(v1alpha1.JoinTokenSpec{})
+	joinToken.Status.State = v1alpha1.JoinTokenStateUsed
+	reconciler := newTestReconciler(fakeClient, nil)
+	stubGets(fakeClient, joinToken, nil)
+
+	result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "vm-onboarding"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, ctrl.Result{}, result)
+	assert.Equal(t, 1, fakeClient.GetCallCount())
+}
+
+func TestValidateJoinToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     v1alpha1.JoinTokenSpec
+		wantPath string
+		wantErr  string
+	}{
+		{name: "no spiffe ID", spec: v1alpha1.JoinTokenSpec{TTL: metav1.Duration{Duration: time.Hour}}},
+		{
+			name:     "spiffe ID in trust domain",
+			spec:     v1alpha1.JoinTokenSpec{TTL: metav1.Duration{Duration: time.Hour}, SpiffeID: "spiffe://example.com/vm/db"},
+			wantPath: "/vm/db",
+		},
+		{
+			name:    "spiffe ID in another trust domain",
+			spec:    v1alpha1.JoinTokenSpec{TTL: metav1.Duration{Duration: time.Hour}, SpiffeID: "spiffe://other.org/vm/db"},
+			wantErr: "not in trust domain",
+		},
+		{
+			name:    "malformed spiffe ID",
+			spec:    v1alpha1.JoinTokenSpec{TTL: metav1.Duration{Duration: time.Hour}, SpiffeID: "spiffe://example.com/vm//db"},
+			wantErr: "invalid spiffeID",
+		},
+		{
+			name:    "ttl too short",
+			spec:    v1alpha1.JoinTokenSpec{TTL: metav1.Duration{Duration: time.Second}},
+			wantErr: "ttl",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trustDomain, agentID, err := validateJoinToken(&v1alpha1.JoinToken{Spec: tt.spec}, "example.com")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, spiffeid.RequireTrustDomainFromString("example.com"), trustDomain)
+			if tt.wantPath == "" {
+				assert.Nil(t, agentID)
+				return
+			}
+			require.NotNil(t, agentID)
+			assert.Equal(t, tt.wantPath, agentID.Path)
+		})
+	}
+}
+
+func TestNextCheck(t *testing.T) {
+	assert.Equal(t, usageCheckInterval, nextCheck(time.Now().Add(time.Hour)))
+	assert.Equal(t, time.Second, nextCheck(time.Now().Add(-time.Minute)))
+	short := nextCheck(time.Now().Add(10 * time.Second))
+	assert.Greater(t, short, 9*time.Second)
+	assert.LessOrEqual(t, short, 11*time.Second)
+}
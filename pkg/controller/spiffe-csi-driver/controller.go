@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -21,6 +22,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
@@ -37,6 +39,9 @@ const (
 	SecurityContextConstraintsAvailable = "SecurityContextConstraintsAvailable"
 	ServiceAccountAvailable             = "ServiceAccountAvailable"
 	CSIDriverAvailable                  = "CSIDriverAvailable"
+	NodesHealthy                        = "NodesHealthy"
+	WorkloadInjectionAvailable          = "WorkloadInjectionAvailable"
+	CanaryNodesAvailable                = "CanaryNodesAvailable"
 )
 
 // SpiffeCsiReconciler reconciles a SpiffeCsi object
@@ -142,6 +147,11 @@ func (r *SpiffeCsiReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, err
 	}
 
+	// Reconcile the pod injection webhook
+	if err := r.reconcileWorkloadInjectionWebhook(ctx, &spiffeCSIDriver, statusMgr, createOnlyMode); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -164,9 +174,14 @@ func (r *SpiffeCsiReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&v1alpha1.SpiffeCSIDriver{}, builder.WithPredicates(utils.GenerationOrOwnerReferenceChangedPredicate)).
 		Named(utils.ZeroTrustWorkloadIdentityManagerSpiffeCsiDriverControllerName).
 		Watches(&appsv1.DaemonSet{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		// DaemonSet status only counts pods, the per-node health report follows the pods themselves
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		// Labeling a node as a canary resumes a rollout held for lack of canary nodes
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.LabelChangedPredicate{})).
 		Watches(&corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&storagev1.CSIDriver{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&securityv1.SecurityContextConstraints{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		Watches(&admissionregistrationv1.MutatingWebhookConfiguration{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&v1alpha1.ZeroTrustWorkloadIdentityManager{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(utils.ZTWIMSpecChangedPredicate)).
 		Complete(r)
 	if err != nil {
@@ -196,6 +211,22 @@ func (r *SpiffeCsiReconciler) handleCreateOnlyMode(driver *v1alpha1.SpiffeCSIDri
 
 // validateCommonConfig validates common configuration fields (affinity, tolerations, nodeSelector, resources, labels)
 func (r *SpiffeCsiReconciler) validateCommonConfig(driver *v1alpha1.SpiffeCSIDriver, statusMgr *status.Manager) error {
+	if err := utils.ValidateDaemonSetRolloutStrategy(driver.Spec.RolloutStrategy); err != nil {
+		r.log.Error(err, "rolloutStrategy validation failed", "name", driver.Name)
+		statusMgr.AddCondition(utils.ConditionTypeConfigurationValid, utils.ConditionReasonInvalidRollout,
+			fmt.Sprintf("Invalid rolloutStrategy: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := utils.ValidateContainerResources(driver.Spec.ContainerResources, "spiffe-csi-driver", "node-driver-registrar", "set-context"); err != nil {
+		r.log.Error(err, "containerResources validation failed", "name", driver.Name)
+		statusMgr.AddCondition(utils.ConditionTypeConfigurationValid, utils.ConditionReasonInvalidResources,
+			fmt.Sprintf("Container resources validation failed: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
 	return utils.ValidateAndUpdateStatus(
 		r.log,
 		statusMgr,
@@ -15,6 +15,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -339,6 +340,53 @@ func TestValidateCommonConfig_InvalidAffinity(t *testing.T) {
 	}
 }
 
+// TestValidateCommonConfig_InvalidRolloutStrategy tests that maxUnavailable and maxSurge cannot both be zero
+func TestValidateCommonConfig_InvalidRolloutStrategy(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+
+	zero := intstr.FromInt32(0)
+	driver := &v1alpha1.SpiffeCSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.SpiffeCSIDriverSpec{
+			RolloutStrategy: &v1alpha1.DaemonSetRolloutStrategy{
+				MaxUnavailable: &zero,
+				MaxSurge:       &zero,
+			},
+		},
+	}
+
+	statusMgr := status.NewManager(fakeClient)
+	err := reconciler.validateCommonConfig(driver, statusMgr)
+
+	if err == nil {
+		t.Error("Expected error for rolloutStrategy with maxUnavailable and maxSurge both zero")
+	}
+}
+
+// TestValidateCommonConfig_InvalidContainerResources tests that each container is overridden at most once
+func TestValidateCommonConfig_InvalidContainerResources(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+
+	driver := &v1alpha1.SpiffeCSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.SpiffeCSIDriverSpec{
+			ContainerResources: []v1alpha1.ContainerResources{
+				{Name: "set-context"},
+				{Name: "set-context"},
+			},
+		},
+	}
+
+	statusMgr := status.NewManager(fakeClient)
+	err := reconciler.validateCommonConfig(driver, statusMgr)
+
+	if err == nil {
+		t.Error("Expected error for duplicate container resources")
+	}
+}
+
 // TestHandleCreateOnlyMode_NotSet tests create-only mode when env var is not set
 func TestHandleCreateOnlyMode_NotSet(t *testing.T) {
 	t.Setenv("CREATE_ONLY_MODE", "")
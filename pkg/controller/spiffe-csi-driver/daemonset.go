@@ -6,6 +6,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -34,6 +35,11 @@ func (r *SpiffeCsiReconciler) reconcileDaemonSet(ctx context.Context, driver *v1
 	var existingSpiffeCsiDaemonSet appsv1.DaemonSet
 	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: spiffeCsiDaemonset.Name, Namespace: spiffeCsiDaemonset.Namespace}, &existingSpiffeCsiDaemonSet)
 	if err != nil && kerrors.IsNotFound(err) {
+		statusMgr.RemoveCondition(CanaryNodesAvailable)
+		r.reportRollout(driver, statusMgr, &v1alpha1.DaemonSetRolloutStatus{
+			Phase:           utils.RolloutPhaseComplete,
+			PodTemplateHash: spiffeCsiDaemonset.Spec.Template.Annotations[utils.PodTemplateHashAnnotationKey],
+		})
 		if err = r.ctrlClient.Create(ctx, spiffeCsiDaemonset); err != nil {
 			if conflictErr := utils.HandleCreateConflict(err, spiffeCsiDaemonset, r.log, statusMgr, DaemonSetAvailable); conflictErr != nil {
 				return conflictErr
@@ -46,8 +52,23 @@ func (r *SpiffeCsiReconciler) reconcileDaemonSet(ctx context.Context, driver *v1
 		}
 		r.log.Info("Created spiffe csi DaemonSet")
 	} else if err == nil {
+		rolloutPlan, err := utils.PlanDaemonSetRollout(ctx, r.ctrlClient, &existingSpiffeCsiDaemonSet, spiffeCsiDaemonset, driver.Spec.RolloutStrategy)
+		if err != nil {
+			r.log.Error(err, "failed to plan spiffe csi DaemonSet rollout")
+			statusMgr.AddCondition(DaemonSetAvailable, "SpiffeCSIDaemonSetRolloutFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.reportRollout(driver, statusMgr, rolloutPlan.Status)
+		r.reportCanaryNodes(driver, statusMgr, rolloutPlan)
+
 		if !needsUpdate(existingSpiffeCsiDaemonSet, *spiffeCsiDaemonset) {
+			if err := r.advanceCanaryRollout(ctx, statusMgr, rolloutPlan, createOnlyMode); err != nil {
+				return err
+			}
 			statusMgr.CheckDaemonSetHealth(ctx, spiffeCsiDaemonset.Name, spiffeCsiDaemonset.Namespace, DaemonSetAvailable)
+			r.reportNodeHealth(ctx, driver, statusMgr, spiffeCsiDaemonset)
 			return nil
 		}
 		if createOnlyMode {
@@ -63,6 +84,9 @@ func (r *SpiffeCsiReconciler) reconcileDaemonSet(ctx context.Context, driver *v1
 			}
 			r.log.Info("Updated spiffe csi DaemonSet")
 		}
+		if err := r.advanceCanaryRollout(ctx, statusMgr, rolloutPlan, createOnlyMode); err != nil {
+			return err
+		}
 	} else {
 		r.log.Error(err, "Failed to get SpiffeCsiDaemon set")
 		statusMgr.AddCondition(DaemonSetAvailable, "SpiffeCSIDaemonSetGetFailed",
@@ -73,10 +97,59 @@ func (r *SpiffeCsiReconciler) reconcileDaemonSet(ctx context.Context, driver *v1
 
 	// Check DaemonSet health/readiness
 	statusMgr.CheckDaemonSetHealth(ctx, spiffeCsiDaemonset.Name, spiffeCsiDaemonset.Namespace, DaemonSetAvailable)
+	r.reportNodeHealth(ctx, driver, statusMgr, spiffeCsiDaemonset)
+
+	return nil
+}
 
+// advanceCanaryRollout deletes the canary pods still running an old pod template so that
+// they are recreated from the current template. Nothing is deleted in create-only mode.
+func (r *SpiffeCsiReconciler) advanceCanaryRollout(ctx context.Context, statusMgr *status.Manager, plan *utils.DaemonSetRolloutPlan, createOnlyMode bool) error {
+	if createOnlyMode || len(plan.OutdatedCanaryPods) == 0 {
+		return nil
+	}
+	if err := utils.DeleteOutdatedCanaryPods(ctx, r.ctrlClient, plan); err != nil {
+		r.log.Error(err, "failed to replace spiffe csi canary pods")
+		statusMgr.AddCondition(DaemonSetAvailable, "SpiffeCSIDaemonSetRolloutFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Replaced spiffe csi canary pods", "count", len(plan.OutdatedCanaryPods))
 	return nil
 }
 
+// reportRollout records the CSI driver DaemonSet rollout progress in the SpiffeCSIDriver status
+func (r *SpiffeCsiReconciler) reportRollout(driver *v1alpha1.SpiffeCSIDriver, statusMgr *status.Manager, rollout *v1alpha1.DaemonSetRolloutStatus) {
+	if !equality.Semantic.DeepEqual(driver.Status.Rollout, rollout) {
+		driver.Status.Rollout = rollout
+		statusMgr.RequestStatusUpdate()
+	}
+}
+
+// reportCanaryNodes reports a rollout held because no node matches the canary node selector
+func (r *SpiffeCsiReconciler) reportCanaryNodes(driver *v1alpha1.SpiffeCSIDriver, statusMgr *status.Manager, plan *utils.DaemonSetRolloutPlan) {
+	if !plan.CanaryNodesNotFound {
+		statusMgr.RemoveCondition(CanaryNodesAvailable)
+		return
+	}
+	r.log.Info("Holding spiffe csi DaemonSet rollout, no node matches the canary node selector")
+	statusMgr.AddCondition(CanaryNodesAvailable, "CanaryNodesNotFound",
+		fmt.Sprintf("No node matches the canary node selector %v, the rollout is held until a node matches or the canary is removed",
+			driver.Spec.RolloutStrategy.Canary.NodeSelector),
+		metav1.ConditionFalse)
+}
+
+// reportNodeHealth records the per-node pod health of the CSI driver DaemonSet in the SpiffeCSIDriver status
+func (r *SpiffeCsiReconciler) reportNodeHealth(ctx context.Context, driver *v1alpha1.SpiffeCSIDriver, statusMgr *status.Manager, ds *appsv1.DaemonSet) {
+	nodeHealth := statusMgr.CheckDaemonSetNodeHealth(ctx, ds.Name, ds.Namespace, NodesHealthy,
+		status.UnhealthyNodeThreshold(driver.Spec.UnhealthyNodeThresholdPercent))
+	if !equality.Semantic.DeepEqual(driver.Status.NodeHealth, nodeHealth) {
+		driver.Status.NodeHealth = nodeHealth
+		statusMgr.RequestStatusUpdate()
+	}
+}
+
 // needsUpdate returns true if DaemonSet needs to be updated.
 func needsUpdate(current, desired appsv1.DaemonSet) bool {
 	return utils.ResourceNeedsUpdate(&current, &desired)
@@ -104,15 +177,7 @@ func generateSpiffeCsiDriverDaemonSet(config v1alpha1.SpiffeCSIDriverSpec) *apps
 			Selector: &metav1.LabelSelector{
 				MatchLabels: selectorLabels,
 			},
-			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
-				Type: appsv1.RollingUpdateDaemonSetStrategyType,
-				RollingUpdate: &appsv1.RollingUpdateDaemonSet{
-					MaxUnavailable: &intstr.IntOrString{
-						Type:   intstr.Int,
-						IntVal: 1,
-					},
-				},
-			},
+			UpdateStrategy: utils.DaemonSetUpdateStrategy(config.RolloutStrategy),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
@@ -136,6 +201,8 @@ func generateSpiffeCsiDriverDaemonSet(config v1alpha1.SpiffeCSIDriverSpec) *apps
 									Drop: []corev1.Capability{"all"},
 								},
 							},
+							// The init container only gets resources when overridden
+							Resources: utils.ContainerResourceRequirements("set-context", config.ContainerResources, nil),
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "spire-agent-socket-dir",
@@ -173,7 +240,7 @@ func generateSpiffeCsiDriverDaemonSet(config v1alpha1.SpiffeCSIDriverSpec) *apps
 									Drop: []corev1.Capability{"all"},
 								},
 							},
-							Resources: utils.DerefResourceRequirements(config.Resources),
+							Resources: utils.ContainerResourceRequirements("spiffe-csi-driver", config.ContainerResources, config.Resources),
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "spire-agent-socket-dir",
@@ -216,7 +283,7 @@ func generateSpiffeCsiDriverDaemonSet(config v1alpha1.SpiffeCSIDriverSpec) *apps
 									Name:          "healthz",
 								},
 							},
-							Resources: utils.DerefResourceRequirements(config.Resources),
+							Resources: utils.ContainerResourceRequirements("node-driver-registrar", config.ContainerResources, config.Resources),
 							LivenessProbe: &corev1.Probe{
 								InitialDelaySeconds: 5,
 								TimeoutSeconds:      5,
@@ -278,6 +345,8 @@ func generateSpiffeCsiDriverDaemonSet(config v1alpha1.SpiffeCSIDriverSpec) *apps
 		},
 	}
 
+	utils.SetPodTemplateHash(ds)
+
 	return ds
 }
 
@@ -14,6 +14,7 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -711,3 +712,47 @@ func createDaemonSetTestDriver() *v1alpha1.SpiffeCSIDriver {
 		},
 	}
 }
+
+func TestGenerateSpiffeCsiDriverDaemonSetWithContainerResources(t *testing.T) {
+	shared := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("50m"),
+		},
+	}
+	registrarResources := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+		},
+	}
+	config := v1alpha1.SpiffeCSIDriverSpec{
+		AgentSocketPath: "/run/spire/agent-sockets",
+		PluginName:      "csi.spiffe.io",
+		CommonConfig: v1alpha1.CommonConfig{
+			Resources: shared,
+		},
+		ContainerResources: []v1alpha1.ContainerResources{
+			{Name: "node-driver-registrar", Resources: registrarResources},
+		},
+	}
+
+	daemonSet := generateSpiffeCsiDriverDaemonSet(config)
+
+	// The init container does not inherit the shared resources
+	initContainer := daemonSet.Spec.Template.Spec.InitContainers[0]
+	if !reflect.DeepEqual(initContainer.Resources, corev1.ResourceRequirements{}) {
+		t.Errorf("Expected no set-context resources, got %+v", initContainer.Resources)
+	}
+	if !reflect.DeepEqual(daemonSet.Spec.Template.Spec.Containers[0].Resources, *shared) {
+		t.Errorf("Expected spiffe-csi-driver resources %+v, got %+v", *shared, daemonSet.Spec.Template.Spec.Containers[0].Resources)
+	}
+	if !reflect.DeepEqual(daemonSet.Spec.Template.Spec.Containers[1].Resources, registrarResources) {
+		t.Errorf("Expected node-driver-registrar resources %+v, got %+v", registrarResources, daemonSet.Spec.Template.Spec.Containers[1].Resources)
+	}
+
+	// An override applies to the init container too
+	config.ContainerResources = append(config.ContainerResources, v1alpha1.ContainerResources{Name: "set-context", Resources: registrarResources})
+	daemonSet = generateSpiffeCsiDriverDaemonSet(config)
+	if !reflect.DeepEqual(daemonSet.Spec.Template.Spec.InitContainers[0].Resources, registrarResources) {
+		t.Errorf("Expected set-context resources %+v, got %+v", registrarResources, daemonSet.Spec.Template.Spec.InitContainers[0].Resources)
+	}
+}
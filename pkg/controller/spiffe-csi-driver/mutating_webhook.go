@@ -0,0 +1,210 @@
+package spiffe_csi_driver
+
+import (
+	"context"
+	"fmt"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// PodInjectionWebhookConfigurationName is the name of the MutatingWebhookConfiguration injecting the SPIFFE CSI volume
+	PodInjectionWebhookConfigurationName = "zero-trust-workload-identity-manager-spiffe-csi-injection"
+
+	// podInjectionWebhookServiceName is the Service in front of the operator's webhook server
+	podInjectionWebhookServiceName = "zero-trust-workload-identity-manager-webhook-service"
+
+	// injectCABundleAnnotation asks the service CA operator to fill in the caBundle of the webhooks
+	injectCABundleAnnotation = "service.beta.openshift.io/inject-cabundle"
+)
+
+// reconcileWorkloadInjectionWebhook creates, updates or removes the MutatingWebhookConfiguration of the pod injection
+func (r *SpiffeCsiReconciler) reconcileWorkloadInjectionWebhook(ctx context.Context, driver *v1alpha1.SpiffeCSIDriver, statusMgr *status.Manager, createOnlyMode bool) error {
+	config := driver.Spec.WorkloadInjection
+	if config == nil || !utils.StringToBool(config.Enabled) {
+		existing := &admissionregistrationv1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: PodInjectionWebhookConfigurationName}}
+		if err := r.ctrlClient.Delete(ctx, existing); err != nil && !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to delete pod injection webhook")
+			statusMgr.AddCondition(WorkloadInjectionAvailable, "WorkloadInjectionDeletionFailed",
+				fmt.Sprintf("Failed to delete MutatingWebhookConfiguration: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		statusMgr.RemoveCondition(WorkloadInjectionAvailable)
+		return nil
+	}
+
+	// Without a serving certificate the webhook is not served, registering it would only break pod creation
+	if !podInjectionWebhookRegistered.Load() {
+		statusMgr.AddCondition(WorkloadInjectionAvailable, "WebhookServerNotConfigured",
+			"The operator webhook server has no serving certificate configured, the pod injection webhook is not served",
+			metav1.ConditionFalse)
+		return nil
+	}
+
+	desired := getPodInjectionMutatingWebhookConfiguration(config, driver.Spec.Labels)
+	if err := controllerutil.SetControllerReference(driver, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on pod injection webhook")
+		statusMgr.AddCondition(WorkloadInjectionAvailable, v1alpha1.ReasonFailed,
+			fmt.Sprintf("Failed to set owner reference on MutatingWebhookConfiguration: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	existing := &admissionregistrationv1.MutatingWebhookConfiguration{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get pod injection webhook")
+			statusMgr.AddCondition(WorkloadInjectionAvailable, v1alpha1.ReasonFailed,
+				fmt.Sprintf("Failed to get MutatingWebhookConfiguration: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, WorkloadInjectionAvailable); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create pod injection webhook")
+			statusMgr.AddCondition(WorkloadInjectionAvailable, v1alpha1.ReasonFailed,
+				fmt.Sprintf("Failed to create MutatingWebhookConfiguration: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+
+		r.log.Info("Created MutatingWebhookConfiguration", "name", desired.Name)
+		addWorkloadInjectionReadyCondition(statusMgr, config)
+		return nil
+	}
+
+	if createOnlyMode {
+		r.log.V(1).Info("MutatingWebhookConfiguration exists, skipping update due to create-only mode", "name", desired.Name)
+		addWorkloadInjectionReadyCondition(statusMgr, config)
+		return nil
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	// Preserve the caBundle injected by the service CA operator and the fields defaulted by the API server
+	for i := range desired.Webhooks {
+		if i < len(existing.Webhooks) {
+			if len(existing.Webhooks[i].ClientConfig.CABundle) > 0 {
+				desired.Webhooks[i].ClientConfig.CABundle = existing.Webhooks[i].ClientConfig.CABundle
+			}
+			if existing.Webhooks[i].MatchPolicy != nil {
+				desired.Webhooks[i].MatchPolicy = existing.Webhooks[i].MatchPolicy
+			}
+			for j := range desired.Webhooks[i].Rules {
+				if j < len(existing.Webhooks[i].Rules) && existing.Webhooks[i].Rules[j].Scope != nil {
+					desired.Webhooks[i].Rules[j].Scope = existing.Webhooks[i].Rules[j].Scope
+				}
+			}
+			if existing.Webhooks[i].ClientConfig.Service != nil && desired.Webhooks[i].ClientConfig.Service != nil &&
+				existing.Webhooks[i].ClientConfig.Service.Port != nil {
+				desired.Webhooks[i].ClientConfig.Service.Port = existing.Webhooks[i].ClientConfig.Service.Port
+			}
+		}
+	}
+
+	if !utils.ResourceNeedsUpdate(existing, desired) {
+		r.log.V(1).Info("MutatingWebhookConfiguration is up to date", "name", desired.Name)
+		addWorkloadInjectionReadyCondition(statusMgr, config)
+		return nil
+	}
+
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		r.log.Error(err, "failed to update pod injection webhook")
+		statusMgr.AddCondition(WorkloadInjectionAvailable, v1alpha1.ReasonFailed,
+			fmt.Sprintf("Failed to update MutatingWebhookConfiguration: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	r.log.Info("Updated MutatingWebhookConfiguration", "name", desired.Name)
+	addWorkloadInjectionReadyCondition(statusMgr, config)
+	return nil
+}
+
+func addWorkloadInjectionReadyCondition(statusMgr *status.Manager, config *v1alpha1.WorkloadInjectionConfig) {
+	statusMgr.AddCondition(WorkloadInjectionAvailable, v1alpha1.ReasonReady,
+		fmt.Sprintf("Pod injection webhook %s is configured with failure policy %s", PodInjectionWebhookConfigurationName, podInjectionFailurePolicy(config)),
+		metav1.ConditionTrue)
+}
+
+func podInjectionFailurePolicy(config *v1alpha1.WorkloadInjectionConfig) admissionregistrationv1.FailurePolicyType {
+	if config.FailurePolicy == string(admissionregistrationv1.Fail) {
+		return admissionregistrationv1.Fail
+	}
+	return admissionregistrationv1.Ignore
+}
+
+// getPodInjectionMutatingWebhookConfiguration returns the MutatingWebhookConfiguration sending the creations
+// of the selected pods in the selected namespaces to the operator
+func getPodInjectionMutatingWebhookConfiguration(config *v1alpha1.WorkloadInjectionConfig, customLabels map[string]string) *admissionregistrationv1.MutatingWebhookConfiguration {
+	// The operator's own pods must never depend on the webhook
+	namespaceSelector := &metav1.LabelSelector{}
+	if config.NamespaceSelector != nil {
+		namespaceSelector = config.NamespaceSelector.DeepCopy()
+	}
+	namespaceSelector.MatchExpressions = append(namespaceSelector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      "kubernetes.io/metadata.name",
+		Operator: metav1.LabelSelectorOpNotIn,
+		Values:   []string{utils.GetOperatorNamespace(), "kube-system", "kube-public", "openshift", "default"},
+	}, metav1.LabelSelectorRequirement{
+		// set on the openshift-* and kube-* run-level namespaces
+		Key:      "openshift.io/run-level",
+		Operator: metav1.LabelSelectorOpDoesNotExist,
+	})
+
+	// Only pods that opted in with the label are sent by default, other pods never depend on the webhook
+	objectSelector := &metav1.LabelSelector{MatchLabels: map[string]string{PodInjectionKey: "true"}}
+	if config.ObjectSelector != nil {
+		objectSelector = config.ObjectSelector.DeepCopy()
+	}
+
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        PodInjectionWebhookConfigurationName,
+			Labels:      utils.SpiffeCSIDriverLabels(customLabels),
+			Annotations: map[string]string{injectCABundleAnnotation: "true"},
+		},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{
+				Name:                    "spiffe-csi-injection.ztwim.openshift.io",
+				AdmissionReviewVersions: []string{"v1"},
+				ClientConfig: admissionregistrationv1.WebhookClientConfig{
+					Service: &admissionregistrationv1.ServiceReference{
+						Name:      podInjectionWebhookServiceName,
+						Namespace: utils.GetOperatorNamespace(),
+						Path:      ptr.To(PodInjectionWebhookPath),
+					},
+				},
+				Rules: []admissionregistrationv1.RuleWithOperations{
+					{
+						Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create},
+						Rule: admissionregistrationv1.Rule{
+							APIGroups:   []string{""},
+							APIVersions: []string{"v1"},
+							Resources:   []string{"pods"},
+						},
+					},
+				},
+				NamespaceSelector:  namespaceSelector,
+				ObjectSelector:     objectSelector,
+				FailurePolicy:      ptr.To(podInjectionFailurePolicy(config)),
+				SideEffects:        ptr.To(admissionregistrationv1.SideEffectClassNone),
+				TimeoutSeconds:     ptr.To(int32(10)),
+				ReinvocationPolicy: ptr.To(admissionregistrationv1.IfNeededReinvocationPolicy),
+			},
+		},
+	}
+}
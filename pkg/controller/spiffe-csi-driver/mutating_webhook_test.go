@@ -0,0 +1,151 @@
+package spiffe_csi_driver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestGetPodInjectionMutatingWebhookConfiguration(t *testing.T) {
+	t.Setenv("OPERATOR_NAMESPACE", "zero-trust-workload-identity-manager")
+	config := &v1alpha1.WorkloadInjectionConfig{
+		Enabled:           "true",
+		FailurePolicy:     "Fail",
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"spiffe": "enabled"}},
+	}
+
+	webhookConfig := getPodInjectionMutatingWebhookConfiguration(config, nil)
+
+	if webhookConfig.Annotations[injectCABundleAnnotation] != "true" {
+		t.Errorf("Expected annotation %s", injectCABundleAnnotation)
+	}
+	if len(webhookConfig.Webhooks) != 1 {
+		t.Fatalf("Expected 1 webhook, got %d", len(webhookConfig.Webhooks))
+	}
+	webhook := webhookConfig.Webhooks[0]
+	if *webhook.FailurePolicy != admissionregistrationv1.Fail {
+		t.Errorf("Expected failure policy Fail, got %s", *webhook.FailurePolicy)
+	}
+	if webhook.ClientConfig.Service.Namespace != "zero-trust-workload-identity-manager" || *webhook.ClientConfig.Service.Path != PodInjectionWebhookPath {
+		t.Errorf("Unexpected client config %+v", webhook.ClientConfig.Service)
+	}
+	if webhook.NamespaceSelector.MatchLabels["spiffe"] != "enabled" {
+		t.Errorf("Expected the configured namespace selector, got %+v", webhook.NamespaceSelector)
+	}
+	excluded := false
+	for _, requirement := range webhook.NamespaceSelector.MatchExpressions {
+		if requirement.Key == "kubernetes.io/metadata.name" && requirement.Operator == metav1.LabelSelectorOpNotIn {
+			for _, value := range requirement.Values {
+				if value == "zero-trust-workload-identity-manager" {
+					excluded = true
+				}
+			}
+		}
+	}
+	if !excluded {
+		t.Error("Expected the operator namespace to be excluded")
+	}
+	// The configured selector must not be modified
+	if len(config.NamespaceSelector.MatchExpressions) != 0 {
+		t.Error("Expected the configured namespace selector to be left unchanged")
+	}
+
+	if webhook.ObjectSelector.MatchLabels[PodInjectionKey] != "true" {
+		t.Errorf("Expected the default object selector on the opt-in label, got %+v", webhook.ObjectSelector)
+	}
+	config.ObjectSelector = &metav1.LabelSelector{}
+	if selector := getPodInjectionMutatingWebhookConfiguration(config, nil).Webhooks[0].ObjectSelector; selector == nil || len(selector.MatchLabels) != 0 {
+		t.Errorf("Expected the configured empty object selector, got %+v", selector)
+	}
+
+	config.FailurePolicy = ""
+	if policy := *getPodInjectionMutatingWebhookConfiguration(config, nil).Webhooks[0].FailurePolicy; policy != admissionregistrationv1.Ignore {
+		t.Errorf("Expected default failure policy Ignore, got %s", policy)
+	}
+}
+
+func TestReconcileWorkloadInjectionWebhook(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	notFound := kerrors.NewNotFound(schema.GroupResource{Resource: "mutatingwebhookconfigurations"}, PodInjectionWebhookConfigurationName)
+
+	t.Run("disabled deletes the webhook configuration", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.DeleteReturns(notFound)
+		reconciler := newTestReconciler(fakeClient)
+		driver := &v1alpha1.SpiffeCSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+
+		if err := reconciler.reconcileWorkloadInjectionWebhook(context.Background(), driver, status.NewManager(fakeClient), false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if fakeClient.DeleteCallCount() != 1 || fakeClient.CreateCallCount() != 0 {
+			t.Errorf("Expected 1 delete and no create, got %d and %d", fakeClient.DeleteCallCount(), fakeClient.CreateCallCount())
+		}
+	})
+
+	t.Run("enabled without webhook server is not registered", func(t *testing.T) {
+		podInjectionWebhookRegistered.Store(false)
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newTestReconciler(fakeClient)
+		driver := newInjectionDriver("true", "Ignore")
+
+		if err := reconciler.reconcileWorkloadInjectionWebhook(context.Background(), driver, status.NewManager(fakeClient), false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if fakeClient.CreateCallCount() != 0 {
+			t.Error("Expected no MutatingWebhookConfiguration without a webhook server")
+		}
+	})
+
+	t.Run("enabled creates the webhook configuration", func(t *testing.T) {
+		podInjectionWebhookRegistered.Store(true)
+		defer podInjectionWebhookRegistered.Store(false)
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetReturns(notFound)
+		reconciler := newTestReconciler(fakeClient)
+		reconciler.scheme = scheme
+		driver := newInjectionDriver("true", "Ignore")
+
+		if err := reconciler.reconcileWorkloadInjectionWebhook(context.Background(), driver, status.NewManager(fakeClient), false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if fakeClient.CreateCallCount() != 1 {
+			t.Fatalf("Expected 1 create, got %d", fakeClient.CreateCallCount())
+		}
+		_, obj, _ := fakeClient.CreateArgsForCall(0)
+		if _, ok := obj.(*admissionregistrationv1.MutatingWebhookConfiguration); !ok {
+			t.Errorf("Expected a MutatingWebhookConfiguration, got %T", obj)
+		}
+	})
+
+	t.Run("keeps the injected caBundle", func(t *testing.T) {
+		podInjectionWebhookRegistered.Store(true)
+		defer podInjectionWebhookRegistered.Store(false)
+		driver := newInjectionDriver("true", "Ignore")
+		existing := getPodInjectionMutatingWebhookConfiguration(driver.Spec.WorkloadInjection, nil)
+		existing.Webhooks[0].ClientConfig.CABundle = []byte("ca")
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetStub = func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+			existing.DeepCopyInto(obj.(*admissionregistrationv1.MutatingWebhookConfiguration))
+			return nil
+		}
+		reconciler := newTestReconciler(fakeClient)
+		reconciler.scheme = scheme
+
+		if err := reconciler.reconcileWorkloadInjectionWebhook(context.Background(), driver, status.NewManager(fakeClient), false); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if fakeClient.UpdateCallCount() != 0 {
+			t.Errorf("Expected no update when only the caBundle differs, got %d", fakeClient.UpdateCallCount())
+		}
+	})
+}
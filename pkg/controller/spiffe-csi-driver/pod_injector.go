@@ -0,0 +1,214 @@
+package spiffe_csi_driver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// PodInjectionKey is the label or annotation pods set to "true" to receive the SPIFFE CSI volume
+	PodInjectionKey = "ztwim.openshift.io/inject-spiffe-csi"
+
+	// PodInjectionStatusAnnotation is set on pods the volume was injected into
+	PodInjectionStatusAnnotation = "ztwim.openshift.io/spiffe-csi-injection-status"
+
+	// PodInjectionWebhookPath is the path the webhook server serves the pod injection on
+	PodInjectionWebhookPath = "/mutate-spiffe-csi-volume"
+
+	podInjectionStatusInjected = "injected"
+
+	workloadAPIVolumeName = "spiffe-workload-api"
+	workloadAPIMountPath  = "/spiffe-workload-api"
+	// workloadAPISocketName is the socket file the SPIRE agent creates in its socket directory
+	workloadAPISocketName   = "spire-agent.sock"
+	spiffeEndpointSocketEnv = "SPIFFE_ENDPOINT_SOCKET"
+)
+
+// podInjectionWebhookRegistered records whether the webhook server serves the pod injection,
+// which requires a serving certificate
+var podInjectionWebhookRegistered atomic.Bool
+
+// PodInjector adds the SPIFFE CSI volume to pods that opt in
+type PodInjector struct {
+	client  client.Reader
+	decoder admission.Decoder
+	log     logr.Logger
+}
+
+// RegisterPodInjectionWebhook registers the pod injection webhook on the manager's webhook server
+func RegisterPodInjectionWebhook(mgr ctrl.Manager) {
+	mgr.GetWebhookServer().Register(PodInjectionWebhookPath, &webhook.Admission{
+		Handler: &PodInjector{
+			client:  mgr.GetClient(),
+			decoder: admission.NewDecoder(mgr.GetScheme()),
+			log:     ctrl.Log.WithName("spiffe-csi-pod-injector"),
+		},
+	})
+	podInjectionWebhookRegistered.Store(true)
+}
+
+// Handle injects the SPIFFE CSI volume, the volume mounts and the SPIFFE_ENDPOINT_SOCKET
+// environment variable into pods that opt in
+func (p *PodInjector) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pod := &corev1.Pod{}
+	if err := p.decoder.Decode(req, pod); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !wantsInjection(pod) {
+		return admission.Allowed("pod did not opt in to SPIFFE CSI volume injection")
+	}
+	if pod.Annotations[PodInjectionStatusAnnotation] == podInjectionStatusInjected {
+		return admission.Allowed("SPIFFE CSI volume already injected")
+	}
+
+	var driver v1alpha1.SpiffeCSIDriver
+	if err := p.client.Get(ctx, types.NamespacedName{Name: "cluster"}, &driver); err != nil {
+		if kerrors.IsNotFound(err) {
+			return admission.Allowed("SpiffeCSIDriver not found, SPIFFE CSI volume not injected")
+		}
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	config := driver.Spec.WorkloadInjection
+	if config == nil || !utils.StringToBool(config.Enabled) {
+		return admission.Allowed("SPIFFE CSI volume injection is disabled")
+	}
+
+	if err := p.checkAgentSocket(ctx, &driver); err != nil {
+		return p.notInjected(req, pod, config, err)
+	}
+
+	var helper *spiffeHelperConfig
+	helperImage := utils.GetSpiffeHelperImage()
+	if wantsSpiffeHelper(pod) {
+		var err error
+		if helper, err = spiffeHelperConfigFromAnnotations(pod.Annotations); err != nil {
+			return p.notInjected(req, pod, config, err)
+		}
+		if helperImage == "" {
+			return p.notInjected(req, pod, config, fmt.Errorf("the spiffe-helper image is not configured"))
+		}
+	}
+
+	volumeName := injectWorkloadAPIVolume(pod, driver.Spec.PluginName)
+	if helper != nil {
+		injectSpiffeHelper(pod, helper, helperImage, volumeName)
+	}
+	marshaled, err := json.Marshal(pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// notInjected admits the pod unchanged with a warning, or rejects it when the failure policy is Fail
+func (p *PodInjector) notInjected(req admission.Request, pod *corev1.Pod, config *v1alpha1.WorkloadInjectionConfig, err error) admission.Response {
+	p.log.Error(err, "cannot inject SPIFFE CSI volume", "namespace", req.Namespace, "name", pod.GenerateName+pod.Name)
+	if config.FailurePolicy == "Fail" {
+		return admission.Denied(fmt.Sprintf("cannot inject SPIFFE CSI volume: %v", err))
+	}
+	return admission.Allowed("SPIFFE CSI volume not injected").WithWarnings(fmt.Sprintf("cannot inject SPIFFE CSI volume: %v", err))
+}
+
+// checkAgentSocket verifies that the CSI driver mounts the directory the SPIRE agent serves its socket in
+func (p *PodInjector) checkAgentSocket(ctx context.Context, driver *v1alpha1.SpiffeCSIDriver) error {
+	var agent v1alpha1.SpireAgent
+	if err := p.client.Get(ctx, types.NamespacedName{Name: "cluster"}, &agent); err != nil {
+		if kerrors.IsNotFound(err) {
+			return fmt.Errorf("SpireAgent not found")
+		}
+		return err
+	}
+	if agent.Spec.SocketPath != driver.Spec.AgentSocketPath {
+		return fmt.Errorf("SpireAgent socketPath %q does not match SpiffeCSIDriver agentSocketPath %q", agent.Spec.SocketPath, driver.Spec.AgentSocketPath)
+	}
+	return nil
+}
+
+// wantsInjection reports whether the pod opted in through the label or the annotation, or asked for
+// a spiffe-helper sidecar
+func wantsInjection(pod *corev1.Pod) bool {
+	return pod.Labels[PodInjectionKey] == "true" || pod.Annotations[PodInjectionKey] == "true" || wantsSpiffeHelper(pod)
+}
+
+// injectWorkloadAPIVolume adds the CSI volume and mounts it into every container, and returns the
+// name of the volume. Volumes, mounts and environment variables the pod already defines are kept.
+func injectWorkloadAPIVolume(pod *corev1.Pod, pluginName string) string {
+	volumeName := workloadAPIVolumeName
+	found := false
+	for _, volume := range pod.Spec.Volumes {
+		if volume.CSI != nil && volume.CSI.Driver == pluginName {
+			volumeName = volume.Name
+			found = true
+			break
+		}
+	}
+	if !found {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				CSI: &corev1.CSIVolumeSource{
+					Driver:   pluginName,
+					ReadOnly: ptr.To(true),
+				},
+			},
+		})
+	}
+
+	for i := range pod.Spec.InitContainers {
+		injectContainer(&pod.Spec.InitContainers[i], volumeName)
+	}
+	for i := range pod.Spec.Containers {
+		injectContainer(&pod.Spec.Containers[i], volumeName)
+	}
+
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[PodInjectionStatusAnnotation] = podInjectionStatusInjected
+	return volumeName
+}
+
+func injectContainer(container *corev1.Container, volumeName string) {
+	mountPath := workloadAPIMountPath
+	mounted := false
+	for _, mount := range container.VolumeMounts {
+		if mount.Name == volumeName {
+			mountPath = mount.MountPath
+			mounted = true
+			break
+		}
+	}
+	if !mounted {
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volumeName,
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+	}
+
+	for _, env := range container.Env {
+		if env.Name == spiffeEndpointSocketEnv {
+			return
+		}
+	}
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name:  spiffeEndpointSocketEnv,
+		Value: "unix://" + mountPath + "/" + workloadAPISocketName,
+	})
+}
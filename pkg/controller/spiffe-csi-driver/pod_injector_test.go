@@ -0,0 +1,277 @@
+package spiffe_csi_driver
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// stubReader serves the SpiffeCSIDriver and SpireAgent to the pod injector
+type stubReader struct {
+	driver *v1alpha1.SpiffeCSIDriver
+	agent  *v1alpha1.SpireAgent
+}
+
+func (s *stubReader) Get(_ context.Context, key client.ObjectKey, obj client.Object, _ ...client.GetOption) error {
+	switch o := obj.(type) {
+	case *v1alpha1.SpiffeCSIDriver:
+		if s.driver == nil {
+			return kerrors.NewNotFound(schema.GroupResource{Resource: "spiffecsidrivers"}, key.Name)
+		}
+		s.driver.DeepCopyInto(o)
+	case *v1alpha1.SpireAgent:
+		if s.agent == nil {
+			return kerrors.NewNotFound(schema.GroupResource{Resource: "spireagents"}, key.Name)
+		}
+		s.agent.DeepCopyInto(o)
+	}
+	return nil
+}
+
+func (s *stubReader) List(context.Context, client.ObjectList, ...client.ListOption) error {
+	return nil
+}
+
+func newTestPodInjector(reader client.Reader) *PodInjector {
+	return &PodInjector{
+		client:  reader,
+		decoder: admission.NewDecoder(runtime.NewScheme()),
+		log:     logr.Discard(),
+	}
+}
+
+func newInjectionDriver(enabled, failurePolicy string) *v1alpha1.SpiffeCSIDriver {
+	return &v1alpha1.SpiffeCSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.SpiffeCSIDriverSpec{
+			PluginName:      "csi.spiffe.io",
+			AgentSocketPath: "/run/spire/agent-sockets",
+			WorkloadInjection: &v1alpha1.WorkloadInjectionConfig{
+				Enabled:       enabled,
+				FailurePolicy: failurePolicy,
+			},
+		},
+	}
+}
+
+func newInjectionAgent(socketPath string) *v1alpha1.SpireAgent {
+	return &v1alpha1.SpireAgent{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.SpireAgentSpec{SocketPath: socketPath},
+	}
+}
+
+func newPodRequest(t *testing.T, pod *corev1.Pod) admission.Request {
+	t.Helper()
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("failed to marshal pod: %v", err)
+	}
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Namespace: pod.Namespace,
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+}
+
+func newOptedInPod() *corev1.Pod {
+	return &corev1.Pod{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "payments",
+			Labels:    map[string]string{PodInjectionKey: "true"},
+		},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "app"}},
+		},
+	}
+}
+
+func TestPodInjector_Handle(t *testing.T) {
+	tests := []struct {
+		name        string
+		pod         func() *corev1.Pod
+		driver      *v1alpha1.SpiffeCSIDriver
+		agent       *v1alpha1.SpireAgent
+		helperImage string
+		wantAllowed bool
+		wantPatches bool
+	}{
+		{
+			name:        "pod opted in with label is injected",
+			pod:         newOptedInPod,
+			driver:      newInjectionDriver("true", "Ignore"),
+			agent:       newInjectionAgent("/run/spire/agent-sockets"),
+			wantAllowed: true,
+			wantPatches: true,
+		},
+		{
+			name: "pod opted in with annotation is injected",
+			pod: func() *corev1.Pod {
+				pod := newOptedInPod()
+				pod.Labels = nil
+				pod.Annotations = map[string]string{PodInjectionKey: "true"}
+				return pod
+			},
+			driver:      newInjectionDriver("true", "Ignore"),
+			agent:       newInjectionAgent("/run/spire/agent-sockets"),
+			wantAllowed: true,
+			wantPatches: true,
+		},
+		{
+			name: "pod without opt-in is not changed",
+			pod: func() *corev1.Pod {
+				pod := newOptedInPod()
+				pod.Labels = nil
+				return pod
+			},
+			driver:      newInjectionDriver("true", "Ignore"),
+			agent:       newInjectionAgent("/run/spire/agent-sockets"),
+			wantAllowed: true,
+		},
+		{
+			name:        "injection disabled",
+			pod:         newOptedInPod,
+			driver:      newInjectionDriver("false", "Ignore"),
+			agent:       newInjectionAgent("/run/spire/agent-sockets"),
+			wantAllowed: true,
+		},
+		{
+			name:        "socket path mismatch with Ignore admits the pod unchanged",
+			pod:         newOptedInPod,
+			driver:      newInjectionDriver("true", "Ignore"),
+			agent:       newInjectionAgent("/var/run/spire"),
+			wantAllowed: true,
+		},
+		{
+			name:        "socket path mismatch with Fail rejects the pod",
+			pod:         newOptedInPod,
+			driver:      newInjectionDriver("true", "Fail"),
+			agent:       newInjectionAgent("/var/run/spire"),
+			wantAllowed: false,
+		},
+		{
+			name: "pod asking for spiffe-helper is injected",
+			pod: func() *corev1.Pod {
+				pod := newOptedInPod()
+				pod.Labels = nil
+				pod.Annotations = map[string]string{SpiffeHelperInjectionAnnotation: "true"}
+				return pod
+			},
+			helperImage: "ghcr.io/spiffe/spiffe-helper:test",
+			driver:      newInjectionDriver("true", "Ignore"),
+			agent:       newInjectionAgent("/run/spire/agent-sockets"),
+			wantAllowed: true,
+			wantPatches: true,
+		},
+		{
+			name: "spiffe-helper without image with Fail rejects the pod",
+			pod: func() *corev1.Pod {
+				pod := newOptedInPod()
+				pod.Annotations = map[string]string{SpiffeHelperInjectionAnnotation: "true"}
+				return pod
+			},
+			driver:      newInjectionDriver("true", "Fail"),
+			agent:       newInjectionAgent("/run/spire/agent-sockets"),
+			wantAllowed: false,
+		},
+		{
+			name: "invalid spiffe-helper annotation with Ignore admits the pod unchanged",
+			pod: func() *corev1.Pod {
+				pod := newOptedInPod()
+				pod.Annotations = map[string]string{SpiffeHelperInjectionAnnotation: "true", SpiffeHelperCertDirAnnotation: "certs"}
+				return pod
+			},
+			helperImage: "ghcr.io/spiffe/spiffe-helper:test",
+			driver:      newInjectionDriver("true", "Ignore"),
+			agent:       newInjectionAgent("/run/spire/agent-sockets"),
+			wantAllowed: true,
+		},
+		{
+			name:        "missing SpireAgent with Fail rejects the pod",
+			pod:         newOptedInPod,
+			driver:      newInjectionDriver("true", "Fail"),
+			wantAllowed: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(utils.SpiffeHelperImageEnv, tt.helperImage)
+			injector := newTestPodInjector(&stubReader{driver: tt.driver, agent: tt.agent})
+			resp := injector.Handle(context.Background(), newPodRequest(t, tt.pod()))
+			if resp.Allowed != tt.wantAllowed {
+				t.Errorf("Expected allowed %v, got %v (%v)", tt.wantAllowed, resp.Allowed, resp.Result)
+			}
+			if (len(resp.Patches) > 0) != tt.wantPatches {
+				t.Errorf("Expected patches %v, got %v", tt.wantPatches, resp.Patches)
+			}
+		})
+	}
+}
+
+func TestInjectWorkloadAPIVolume(t *testing.T) {
+	t.Run("adds volume, mounts and env to every container", func(t *testing.T) {
+		pod := newOptedInPod()
+		injectWorkloadAPIVolume(pod, "csi.example.com")
+
+		if len(pod.Spec.Volumes) != 1 {
+			t.Fatalf("Expected 1 volume, got %d", len(pod.Spec.Volumes))
+		}
+		volume := pod.Spec.Volumes[0]
+		if volume.Name != workloadAPIVolumeName || volume.CSI == nil || volume.CSI.Driver != "csi.example.com" {
+			t.Errorf("Unexpected volume %+v", volume)
+		}
+		if volume.CSI.ReadOnly == nil || !*volume.CSI.ReadOnly {
+			t.Error("Expected the CSI volume to be read-only")
+		}
+		for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+			if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != workloadAPIMountPath {
+				t.Errorf("Expected container %s to mount %s, got %+v", container.Name, workloadAPIMountPath, container.VolumeMounts)
+			}
+			if len(container.Env) != 1 || container.Env[0].Value != "unix:///spiffe-workload-api/spire-agent.sock" {
+				t.Errorf("Expected container %s to have %s, got %+v", container.Name, spiffeEndpointSocketEnv, container.Env)
+			}
+		}
+		if pod.Annotations[PodInjectionStatusAnnotation] != podInjectionStatusInjected {
+			t.Errorf("Expected annotation %s to be set", PodInjectionStatusAnnotation)
+		}
+	})
+
+	t.Run("keeps existing volume, mount and env", func(t *testing.T) {
+		pod := newOptedInPod()
+		pod.Spec.Volumes = []corev1.Volume{{
+			Name:         "spiffe",
+			VolumeSource: corev1.VolumeSource{CSI: &corev1.CSIVolumeSource{Driver: "csi.spiffe.io"}},
+		}}
+		pod.Spec.Containers[0].VolumeMounts = []corev1.VolumeMount{{Name: "spiffe", MountPath: "/run/spiffe"}}
+		pod.Spec.Containers[0].Env = []corev1.EnvVar{{Name: spiffeEndpointSocketEnv, Value: "unix:///custom.sock"}}
+		injectWorkloadAPIVolume(pod, "csi.spiffe.io")
+
+		if len(pod.Spec.Volumes) != 1 {
+			t.Errorf("Expected the existing volume to be reused, got %+v", pod.Spec.Volumes)
+		}
+		app := pod.Spec.Containers[0]
+		if len(app.VolumeMounts) != 1 || len(app.Env) != 1 || app.Env[0].Value != "unix:///custom.sock" {
+			t.Errorf("Expected existing mount and env to be kept, got %+v %+v", app.VolumeMounts, app.Env)
+		}
+		init := pod.Spec.InitContainers[0]
+		if len(init.VolumeMounts) != 1 || init.VolumeMounts[0].Name != "spiffe" {
+			t.Errorf("Expected init container to mount the existing volume, got %+v", init.VolumeMounts)
+		}
+	})
+}
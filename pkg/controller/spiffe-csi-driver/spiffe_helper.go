@@ -0,0 +1,196 @@
+package spiffe_csi_driver
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// SpiffeHelperInjectionAnnotation is the annotation pods set to "true" to receive a spiffe-helper
+	// sidecar writing the SVID to files. It implies the SPIFFE CSI volume injection.
+	SpiffeHelperInjectionAnnotation = "ztwim.openshift.io/inject-spiffe-helper"
+
+	// SpiffeHelperCertDirAnnotation is the directory the SVID files are written to, in every container
+	SpiffeHelperCertDirAnnotation = "ztwim.openshift.io/spiffe-helper-cert-dir"
+	// SpiffeHelperSVIDFileAnnotation is the file name of the X509-SVID certificate
+	SpiffeHelperSVIDFileAnnotation = "ztwim.openshift.io/spiffe-helper-svid-file"
+	// SpiffeHelperSVIDKeyFileAnnotation is the file name of the X509-SVID private key
+	SpiffeHelperSVIDKeyFileAnnotation = "ztwim.openshift.io/spiffe-helper-svid-key-file"
+	// SpiffeHelperSVIDBundleFileAnnotation is the file name of the trust bundle
+	SpiffeHelperSVIDBundleFileAnnotation = "ztwim.openshift.io/spiffe-helper-svid-bundle-file"
+	// SpiffeHelperReloadSignalAnnotation is the signal sent to the application when the SVID is renewed
+	SpiffeHelperReloadSignalAnnotation = "ztwim.openshift.io/spiffe-helper-reload-signal"
+	// SpiffeHelperPIDFileAnnotation is the file holding the PID of the process receiving the reload signal
+	SpiffeHelperPIDFileAnnotation = "ztwim.openshift.io/spiffe-helper-pid-file"
+
+	// SpiffeHelperConfigAnnotation holds the rendered spiffe-helper configuration, mounted into the
+	// helper containers through the downward API
+	SpiffeHelperConfigAnnotation = "ztwim.openshift.io/spiffe-helper-config"
+
+	spiffeHelperContainerName     = "spiffe-helper"
+	spiffeHelperInitContainerName = "spiffe-helper-init"
+	spiffeHelperCertsVolumeName   = "spiffe-helper-certs"
+	spiffeHelperConfigVolumeName  = "spiffe-helper-config"
+	spiffeHelperConfigDir         = "/etc/spiffe-helper"
+	spiffeHelperConfigFile        = "helper.conf"
+
+	defaultSpiffeHelperCertDir        = "/run/spiffe/certs"
+	defaultSpiffeHelperSVIDFile       = "svid.pem"
+	defaultSpiffeHelperSVIDKeyFile    = "svid_key.pem"
+	defaultSpiffeHelperSVIDBundleFile = "svid_bundle.pem"
+)
+
+// spiffeHelperReloadSignals are the signals spiffe-helper may send to the application
+var spiffeHelperReloadSignals = []string{"SIGHUP", "SIGINT", "SIGQUIT", "SIGTERM", "SIGUSR1", "SIGUSR2"}
+
+// spiffeHelperConfig is the spiffe-helper configuration read from the pod annotations
+type spiffeHelperConfig struct {
+	certDir        string
+	svidFile       string
+	svidKeyFile    string
+	svidBundleFile string
+	reloadSignal   string
+	pidFile        string
+}
+
+// wantsSpiffeHelper reports whether the pod asked for a spiffe-helper sidecar
+func wantsSpiffeHelper(pod *corev1.Pod) bool {
+	return pod.Annotations[SpiffeHelperInjectionAnnotation] == "true"
+}
+
+// spiffeHelperConfigFromAnnotations reads and validates the spiffe-helper settings of the pod
+func spiffeHelperConfigFromAnnotations(annotations map[string]string) (*spiffeHelperConfig, error) {
+	valueOr := func(key, defaultValue string) string {
+		if value, ok := annotations[key]; ok && value != "" {
+			return value
+		}
+		return defaultValue
+	}
+	config := &spiffeHelperConfig{
+		certDir:        path.Clean(valueOr(SpiffeHelperCertDirAnnotation, defaultSpiffeHelperCertDir)),
+		svidFile:       valueOr(SpiffeHelperSVIDFileAnnotation, defaultSpiffeHelperSVIDFile),
+		svidKeyFile:    valueOr(SpiffeHelperSVIDKeyFileAnnotation, defaultSpiffeHelperSVIDKeyFile),
+		svidBundleFile: valueOr(SpiffeHelperSVIDBundleFileAnnotation, defaultSpiffeHelperSVIDBundleFile),
+		reloadSignal:   annotations[SpiffeHelperReloadSignalAnnotation],
+		pidFile:        annotations[SpiffeHelperPIDFileAnnotation],
+	}
+
+	if !path.IsAbs(config.certDir) || config.certDir == "/" {
+		return nil, fmt.Errorf("%s must be an absolute directory, got %q", SpiffeHelperCertDirAnnotation, config.certDir)
+	}
+	for key, name := range map[string]string{
+		SpiffeHelperSVIDFileAnnotation:       config.svidFile,
+		SpiffeHelperSVIDKeyFileAnnotation:    config.svidKeyFile,
+		SpiffeHelperSVIDBundleFileAnnotation: config.svidBundleFile,
+	} {
+		if strings.Contains(name, "/") || name == "." || name == ".." {
+			return nil, fmt.Errorf("%s must be a file name, got %q", key, name)
+		}
+	}
+	if config.reloadSignal != "" {
+		valid := false
+		for _, signal := range spiffeHelperReloadSignals {
+			if config.reloadSignal == signal {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%s must be one of %s, got %q", SpiffeHelperReloadSignalAnnotation, strings.Join(spiffeHelperReloadSignals, ", "), config.reloadSignal)
+		}
+		if config.pidFile == "" {
+			return nil, fmt.Errorf("%s is required with %s", SpiffeHelperPIDFileAnnotation, SpiffeHelperReloadSignalAnnotation)
+		}
+	}
+	if config.pidFile != "" && !path.IsAbs(config.pidFile) {
+		return nil, fmt.Errorf("%s must be an absolute path, got %q", SpiffeHelperPIDFileAnnotation, config.pidFile)
+	}
+	return config, nil
+}
+
+// render returns the spiffe-helper configuration file for the agent socket
+func (c *spiffeHelperConfig) render(agentAddress string) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "agent_address = %s\n", strconv.Quote(agentAddress))
+	fmt.Fprintf(&sb, "cert_dir = %s\n", strconv.Quote(c.certDir))
+	fmt.Fprintf(&sb, "svid_file_name = %s\n", strconv.Quote(c.svidFile))
+	fmt.Fprintf(&sb, "svid_key_file_name = %s\n", strconv.Quote(c.svidKeyFile))
+	fmt.Fprintf(&sb, "svid_bundle_file_name = %s\n", strconv.Quote(c.svidBundleFile))
+	if c.reloadSignal != "" {
+		fmt.Fprintf(&sb, "renew_signal = %s\n", strconv.Quote(c.reloadSignal))
+		fmt.Fprintf(&sb, "pid_file_name = %s\n", strconv.Quote(c.pidFile))
+	}
+	return sb.String()
+}
+
+// injectSpiffeHelper adds an init container writing the SVID files before the application starts
+// and a sidecar keeping them renewed. The files are shared with the application containers through
+// an in-memory emptyDir mounted at the configured directory.
+func injectSpiffeHelper(pod *corev1.Pod, config *spiffeHelperConfig, image, workloadAPIVolume string) {
+	pod.Annotations[SpiffeHelperConfigAnnotation] = config.render("/spiffe-workload-api/" + workloadAPISocketName)
+
+	pod.Spec.Volumes = append(pod.Spec.Volumes,
+		corev1.Volume{
+			Name: spiffeHelperCertsVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				EmptyDir: &corev1.EmptyDirVolumeSource{Medium: corev1.StorageMediumMemory},
+			},
+		},
+		corev1.Volume{
+			Name: spiffeHelperConfigVolumeName,
+			VolumeSource: corev1.VolumeSource{
+				DownwardAPI: &corev1.DownwardAPIVolumeSource{
+					Items: []corev1.DownwardAPIVolumeFile{
+						{
+							Path:     spiffeHelperConfigFile,
+							FieldRef: &corev1.ObjectFieldSelector{FieldPath: fmt.Sprintf("metadata.annotations['%s']", SpiffeHelperConfigAnnotation)},
+						},
+					},
+				},
+			},
+		},
+	)
+
+	certsMount := corev1.VolumeMount{Name: spiffeHelperCertsVolumeName, MountPath: config.certDir}
+	for i := range pod.Spec.InitContainers {
+		pod.Spec.InitContainers[i].VolumeMounts = append(pod.Spec.InitContainers[i].VolumeMounts, certsMount)
+	}
+	for i := range pod.Spec.Containers {
+		pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts, certsMount)
+	}
+
+	helper := func(name string, daemonMode bool) corev1.Container {
+		return corev1.Container{
+			Name:  name,
+			Image: image,
+			Args: []string{
+				"-config", spiffeHelperConfigDir + "/" + spiffeHelperConfigFile,
+				"-daemon-mode=" + strconv.FormatBool(daemonMode),
+			},
+			VolumeMounts: []corev1.VolumeMount{
+				{Name: workloadAPIVolume, MountPath: "/spiffe-workload-api", ReadOnly: true},
+				{Name: spiffeHelperConfigVolumeName, MountPath: spiffeHelperConfigDir, ReadOnly: true},
+				certsMount,
+			},
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: ptr.To(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				ReadOnlyRootFilesystem:   ptr.To(true),
+				RunAsNonRoot:             ptr.To(true),
+			},
+		}
+	}
+	// The init container runs first so that the files exist when the application starts
+	pod.Spec.InitContainers = append([]corev1.Container{helper(spiffeHelperInitContainerName, false)}, pod.Spec.InitContainers...)
+	pod.Spec.Containers = append(pod.Spec.Containers, helper(spiffeHelperContainerName, true))
+
+	// The sidecar can only signal the application when they share the process namespace
+	if config.reloadSignal != "" {
+		pod.Spec.ShareProcessNamespace = ptr.To(true)
+	}
+}
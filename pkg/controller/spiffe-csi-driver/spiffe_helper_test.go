@@ -0,0 +1,158 @@
+package spiffe_csi_driver
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestSpiffeHelperConfigFromAnnotations(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     bool
+		want        spiffeHelperConfig
+	}{
+		{
+			name:        "defaults",
+			annotations: map[string]string{SpiffeHelperInjectionAnnotation: "true"},
+			want: spiffeHelperConfig{
+				certDir:        defaultSpiffeHelperCertDir,
+				svidFile:       defaultSpiffeHelperSVIDFile,
+				svidKeyFile:    defaultSpiffeHelperSVIDKeyFile,
+				svidBundleFile: defaultSpiffeHelperSVIDBundleFile,
+			},
+		},
+		{
+			name: "custom paths and reload signal",
+			annotations: map[string]string{
+				SpiffeHelperCertDirAnnotation:        "/etc/nginx/certs/",
+				SpiffeHelperSVIDFileAnnotation:       "tls.crt",
+				SpiffeHelperSVIDKeyFileAnnotation:    "tls.key",
+				SpiffeHelperSVIDBundleFileAnnotation: "ca.crt",
+				SpiffeHelperReloadSignalAnnotation:   "SIGHUP",
+				SpiffeHelperPIDFileAnnotation:        "/etc/nginx/certs/nginx.pid",
+			},
+			want: spiffeHelperConfig{
+				certDir:        "/etc/nginx/certs",
+				svidFile:       "tls.crt",
+				svidKeyFile:    "tls.key",
+				svidBundleFile: "ca.crt",
+				reloadSignal:   "SIGHUP",
+				pidFile:        "/etc/nginx/certs/nginx.pid",
+			},
+		},
+		{
+			name:        "relative cert dir",
+			annotations: map[string]string{SpiffeHelperCertDirAnnotation: "certs"},
+			wantErr:     true,
+		},
+		{
+			name:        "file name with a path",
+			annotations: map[string]string{SpiffeHelperSVIDKeyFileAnnotation: "../key.pem"},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown signal",
+			annotations: map[string]string{SpiffeHelperReloadSignalAnnotation: "SIGKILL", SpiffeHelperPIDFileAnnotation: "/run/app.pid"},
+			wantErr:     true,
+		},
+		{
+			name:        "reload signal without pid file",
+			annotations: map[string]string{SpiffeHelperReloadSignalAnnotation: "SIGHUP"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := spiffeHelperConfigFromAnnotations(tt.annotations)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error, got config %+v", config)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if *config != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, *config)
+			}
+		})
+	}
+}
+
+func TestSpiffeHelperConfigRender(t *testing.T) {
+	config := &spiffeHelperConfig{
+		certDir:        "/run/spiffe/certs",
+		svidFile:       "svid.pem",
+		svidKeyFile:    "svid_key.pem",
+		svidBundleFile: "svid_bundle.pem",
+		reloadSignal:   "SIGHUP",
+		pidFile:        "/run/spiffe/certs/nginx.pid",
+	}
+
+	rendered := config.render("/spiffe-workload-api/spire-agent.sock")
+
+	for _, line := range []string{
+		`agent_address = "/spiffe-workload-api/spire-agent.sock"`,
+		`cert_dir = "/run/spiffe/certs"`,
+		`svid_key_file_name = "svid_key.pem"`,
+		`renew_signal = "SIGHUP"`,
+		`pid_file_name = "/run/spiffe/certs/nginx.pid"`,
+	} {
+		if !strings.Contains(rendered, line) {
+			t.Errorf("Expected rendered config to contain %q, got:\n%s", line, rendered)
+		}
+	}
+}
+
+func TestInjectSpiffeHelper(t *testing.T) {
+	pod := newOptedInPod()
+	config, err := spiffeHelperConfigFromAnnotations(map[string]string{
+		SpiffeHelperReloadSignalAnnotation: "SIGHUP",
+		SpiffeHelperPIDFileAnnotation:      "/run/spiffe/certs/app.pid",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	volumeName := injectWorkloadAPIVolume(pod, "csi.spiffe.io")
+	injectSpiffeHelper(pod, config, "ghcr.io/spiffe/spiffe-helper:test", volumeName)
+
+	if pod.Spec.InitContainers[0].Name != spiffeHelperInitContainerName {
+		t.Errorf("Expected the helper init container to run first, got %s", pod.Spec.InitContainers[0].Name)
+	}
+	sidecar := pod.Spec.Containers[len(pod.Spec.Containers)-1]
+	if sidecar.Name != spiffeHelperContainerName || sidecar.Image != "ghcr.io/spiffe/spiffe-helper:test" {
+		t.Errorf("Unexpected sidecar %s with image %s", sidecar.Name, sidecar.Image)
+	}
+	if pod.Spec.ShareProcessNamespace == nil || !*pod.Spec.ShareProcessNamespace {
+		t.Error("Expected the process namespace to be shared for the reload signal")
+	}
+	if !strings.Contains(pod.Annotations[SpiffeHelperConfigAnnotation], "renew_signal") {
+		t.Errorf("Expected the rendered config annotation, got %q", pod.Annotations[SpiffeHelperConfigAnnotation])
+	}
+
+	app := pod.Spec.Containers[0]
+	hasCerts := false
+	for _, mount := range app.VolumeMounts {
+		if mount.Name == spiffeHelperCertsVolumeName && mount.MountPath == defaultSpiffeHelperCertDir {
+			hasCerts = true
+		}
+	}
+	if !hasCerts {
+		t.Errorf("Expected the application to mount the certificates, got %+v", app.VolumeMounts)
+	}
+
+	var certsVolume *corev1.Volume
+	for i := range pod.Spec.Volumes {
+		if pod.Spec.Volumes[i].Name == spiffeHelperCertsVolumeName {
+			certsVolume = &pod.Spec.Volumes[i]
+		}
+	}
+	if certsVolume == nil || certsVolume.EmptyDir == nil || certsVolume.EmptyDir.Medium != corev1.StorageMediumMemory {
+		t.Errorf("Expected an in-memory emptyDir for the certificates, got %+v", certsVolume)
+	}
+}
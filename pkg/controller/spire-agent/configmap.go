@@ -84,7 +84,7 @@ func (r *SpireAgentReconciler) reconcileConfigMap(ctx context.Context, agent *v1
 }
 
 func generateAgentConfig(cfg *v1alpha1.SpireAgent, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) map[string]interface{} {
-	spireServerAddress := "spire-server." + utils.GetOperatorNamespace()
+	spireServerAddress, spireServerPort := spireServerEndpoint(cfg.Spec)
 	agentConf := map[string]interface{}{
 		"agent": map[string]interface{}{
 			"data_dir":          "/var/lib/spire",
@@ -92,9 +92,8 @@ func generateAgentConfig(cfg *v1alpha1.SpireAgent, ztwim *v1alpha1.ZeroTrustWork
 			"log_format":        utils.GetLogFormatFromString(cfg.Spec.LogFormat),
 			"rebootstrap_mode":  "auto",
 			"server_address":    spireServerAddress,
-			"server_port":       "443",
+			"server_port":       spireServerPort,
 			"socket_path":       "/tmp/spire-agent/public/spire-agent.sock",
-			"trust_bundle_path": "/run/spire/bundle/bundle.crt",
 			"trust_domain":      ztwim.Spec.TrustDomain,
 			// SDS settings required for Envoy/Istio integration.
 			// default_bundle_name "null" prevents the local-only handler intercepting "ROOTCA".
@@ -126,16 +125,11 @@ func generateAgentConfig(cfg *v1alpha1.SpireAgent, ztwim *v1alpha1.ZeroTrustWork
 		},
 	}
 
-	if cfg.Spec.NodeAttestor != nil && cfg.Spec.NodeAttestor.K8sPSATEnabled == "true" {
-		agentConf["plugins"].(map[string]interface{})["NodeAttestor"] = []map[string]interface{}{
-			{
-				"k8s_psat": map[string]interface{}{
-					"plugin_data": map[string]interface{}{
-						"cluster": ztwim.Spec.ClusterName,
-					},
-				},
-			},
-		}
+	configureTrustBundle(agentConf["agent"].(map[string]interface{}), cfg.Spec)
+	configureAgentPerformance(agentConf["agent"].(map[string]interface{}), cfg.Spec.Performance)
+
+	if nodeAttestor := nodeAttestorPlugin(cfg.Spec, ztwim); nodeAttestor != nil {
+		agentConf["plugins"].(map[string]interface{})["NodeAttestor"] = nodeAttestor
 	}
 
 	if cfg.Spec.WorkloadAttestors != nil && cfg.Spec.WorkloadAttestors.K8sEnabled == "true" {
@@ -157,6 +151,32 @@ func generateAgentConfig(cfg *v1alpha1.SpireAgent, ztwim *v1alpha1.ZeroTrustWork
 	return agentConf
 }
 
+// configureAgentPerformance maps the performance settings to the SPIRE agent configuration.
+// Unset settings are omitted so that SPIRE applies its defaults.
+func configureAgentPerformance(agent map[string]interface{}, performance *v1alpha1.SpireAgentPerformance) {
+	if performance == nil {
+		return
+	}
+
+	if performance.X509SVIDCacheMaxSize != nil {
+		agent["x509_svid_cache_max_size"] = *performance.X509SVIDCacheMaxSize
+	}
+	if performance.AvailabilityTarget != nil {
+		agent["availability_target"] = performance.AvailabilityTarget.Duration.String()
+	}
+
+	experimental := map[string]interface{}{}
+	if performance.SyncInterval != nil {
+		experimental["sync_interval"] = performance.SyncInterval.Duration.String()
+	}
+	if performance.UseSyncAuthorizedEntries != "" {
+		experimental["use_sync_authorized_entries"] = utils.StringToBool(performance.UseSyncAuthorizedEntries)
+	}
+	if len(experimental) > 0 {
+		agent["experimental"] = experimental
+	}
+}
+
 // configureKubeletVerification configures the kubelet TLS verification settings
 // based on the WorkloadAttestorsVerification configuration.
 // This maps to SPIRE's skip_kubelet_verification and kubelet_ca_path options.
@@ -182,6 +202,11 @@ func configureKubeletVerification(plugin map[string]interface{}, verification *v
 			plugin["kubelet_ca_path"] = path.Join(utils.DefaultKubeletCABasePath, utils.DefaultKubeletCAFileName)
 		}
 
+	case utils.WorkloadAttestorVerificationTypeConfigMap:
+		// configMap: the operator copies the CA bundle into a ConfigMap mounted into the agent
+		plugin["skip_kubelet_verification"] = false
+		plugin["kubelet_ca_path"] = path.Join(utils.SpireAgentKubeletCAMountPath, utils.SpireAgentKubeletCAKey)
+
 	default:
 		// Unknown type, default to skip
 		plugin["skip_kubelet_verification"] = true
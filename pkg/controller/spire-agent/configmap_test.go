@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
@@ -774,6 +775,18 @@ func TestConfigureKubeletVerification(t *testing.T) {
 				"kubelet_ca_path":           "/custom/path/custom-ca.crt",
 			},
 		},
+		{
+			name: "configMap type uses the CA copied by the operator",
+			verification: &v1alpha1.WorkloadAttestorsVerification{
+				Type:             utils.WorkloadAttestorVerificationTypeConfigMap,
+				HostCertBasePath: "/etc/kubernetes",
+				HostCertFileName: "kubelet-ca.crt",
+			},
+			expected: map[string]interface{}{
+				"skip_kubelet_verification": false,
+				"kubelet_ca_path":           "/run/spire/kubelet-ca/ca.crt",
+			},
+		},
 		{
 			name: "unknown type defaults to skip",
 			verification: &v1alpha1.WorkloadAttestorsVerification{
@@ -1014,3 +1027,51 @@ func TestGenerateAgentConfigWithVerification(t *testing.T) {
 		})
 	}
 }
+
+func TestConfigureAgentPerformance(t *testing.T) {
+	cacheSize := int32(5000)
+	tests := []struct {
+		name        string
+		performance *v1alpha1.SpireAgentPerformance
+		expected    map[string]interface{}
+	}{
+		{
+			name:     "no performance settings",
+			expected: map[string]interface{}{},
+		},
+		{
+			name: "all performance settings",
+			performance: &v1alpha1.SpireAgentPerformance{
+				X509SVIDCacheMaxSize:     &cacheSize,
+				SyncInterval:             &metav1.Duration{Duration: 30 * time.Second},
+				UseSyncAuthorizedEntries: "true",
+				AvailabilityTarget:       &metav1.Duration{Duration: 48 * time.Hour},
+			},
+			expected: map[string]interface{}{
+				"x509_svid_cache_max_size": int32(5000),
+				"availability_target":      "48h0m0s",
+				"experimental": map[string]interface{}{
+					"sync_interval":               "30s",
+					"use_sync_authorized_entries": true,
+				},
+			},
+		},
+		{
+			name: "only cache size",
+			performance: &v1alpha1.SpireAgentPerformance{
+				X509SVIDCacheMaxSize: &cacheSize,
+			},
+			expected: map[string]interface{}{
+				"x509_svid_cache_max_size": int32(5000),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := map[string]interface{}{}
+			configureAgentPerformance(agent, tt.performance)
+			assert.Equal(t, tt.expected, agent)
+		})
+	}
+}
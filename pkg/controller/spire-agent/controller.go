@@ -24,6 +24,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
@@ -41,6 +42,9 @@ const (
 	ServiceAvailable                    = "ServiceAvailable"
 	RBACAvailable                       = "RBACAvailable"
 	ConfigurationValid                  = "ConfigurationValid"
+	NodesHealthy                        = "NodesHealthy"
+	KubeletCAVerified                   = "KubeletCAVerified"
+	CanaryNodesAvailable                = "CanaryNodesAvailable"
 )
 
 const spireAgentDaemonSetSpireAgentConfigHashAnnotationKey = "ztwim.openshift.io/spire-agent-config-hash"
@@ -52,6 +56,10 @@ type SpireAgentReconciler struct {
 	eventRecorder record.EventRecorder
 	log           logr.Logger
 	scheme        *runtime.Scheme
+	// kubeletCACheck verifies the kubelet CA bundle in the background
+	kubeletCACheck utils.BackgroundCheck[kubeletCAVerification]
+	// runInBackground starts the background checks, on a new goroutine unless set by tests
+	runInBackground func(func())
 }
 
 // New returns a new Reconciler instance.
@@ -153,12 +161,32 @@ func (r *SpireAgentReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Reconcile the kubelet CA bundle used for configMap verification
+	kubeletCAHash, nextKubeletCACheck, err := r.reconcileKubeletCA(ctx, &agent, statusMgr, createOnlyMode)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if kubeletCAHash != "" {
+		// The agent only loads the kubelet CA at startup, so restart it when the bundle changes
+		configHash = utils.GenerateConfigHash([]byte(configHash + kubeletCAHash))
+	}
+
 	// Reconcile DaemonSet
 	if err := r.reconcileDaemonSet(ctx, &agent, statusMgr, &ztwim, createOnlyMode, configHash); err != nil {
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// The source ConfigMap is not watched, resync periodically to pick up CA rotations
+	return ctrl.Result{RequeueAfter: nextKubeletCACheck}, nil
+}
+
+// startBackground runs f with runInBackground, or on a new goroutine when it is not set
+func (r *SpireAgentReconciler) startBackground(f func()) {
+	if r.runInBackground != nil {
+		r.runInBackground(f)
+		return
+	}
+	go f()
 }
 
 func (r *SpireAgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -180,6 +208,10 @@ func (r *SpireAgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&v1alpha1.SpireAgent{}, builder.WithPredicates(utils.GenerationOrOwnerReferenceChangedPredicate)).
 		Named(utils.ZeroTrustWorkloadIdentityManagerSpireAgentControllerName).
 		Watches(&appsv1.DaemonSet{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		// DaemonSet status only counts pods, the per-node health report follows the pods themselves
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		// Labeling a node as a canary resumes a rollout held for lack of canary nodes
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.LabelChangedPredicate{})).
 		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
@@ -220,6 +252,30 @@ func (r *SpireAgentReconciler) validateConfiguration(ctx context.Context, agent
 		return err
 	}
 
+	if err := utils.ValidateDaemonSetRolloutStrategy(agent.Spec.RolloutStrategy); err != nil {
+		r.log.Error(err, "rolloutStrategy validation failed", "name", agent.Name)
+		statusMgr.AddCondition(ConfigurationValid, utils.ConditionReasonInvalidRollout,
+			fmt.Sprintf("Invalid rolloutStrategy: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := validateExternalServer(agent.Spec.ExternalServer); err != nil {
+		r.log.Error(err, "externalServer validation failed", "name", agent.Name)
+		statusMgr.AddCondition(ConfigurationValid, "InvalidExternalServerConfiguration",
+			fmt.Sprintf("Invalid externalServer configuration: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := utils.ValidateSpireAgentPerformance(agent.Spec.Performance); err != nil {
+		r.log.Error(err, "performance validation failed", "name", agent.Name)
+		statusMgr.AddCondition(ConfigurationValid, utils.ConditionReasonInvalidPerformance,
+			fmt.Sprintf("Invalid performance configuration: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
 	return utils.ValidateAndUpdateStatus(
 		r.log,
 		statusMgr,
@@ -657,6 +657,7 @@ func TestDaemonSetConditionConstants(t *testing.T) {
 		{"ServiceAvailable", ServiceAvailable, "ServiceAvailable"},
 		{"RBACAvailable", RBACAvailable, "RBACAvailable"},
 		{"ConfigurationValid", ConfigurationValid, "ConfigurationValid"},
+		{"NodesHealthy", NodesHealthy, "NodesHealthy"},
 	}
 
 	for _, tt := range tests {
@@ -668,6 +669,64 @@ func TestDaemonSetConditionConstants(t *testing.T) {
 	}
 }
 
+// TestReportNodeHealth tests that the per-node health report is stored in the SpireAgent status
+func TestReportNodeHealth(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+
+	ds := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "spire-agent", Namespace: utils.GetOperatorNamespace()},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "spire-agent"}},
+		},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 2},
+	}
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		if d, ok := obj.(*appsv1.DaemonSet); ok {
+			*d = *ds
+		}
+		return nil
+	}
+	fakeClient.ListStub = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+		if l, ok := list.(*corev1.PodList); ok {
+			l.Items = []corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "spire-agent-abc"},
+					Spec:       corev1.PodSpec{NodeName: "worker-1"},
+					Status: corev1.PodStatus{
+						ContainerStatuses: []corev1.ContainerStatus{
+							{
+								Name:         "spire-agent",
+								RestartCount: 5,
+								State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+								LastTerminationState: corev1.ContainerState{
+									Terminated: &corev1.ContainerStateTerminated{Reason: "Error", Message: "node attestation failed"},
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+		return nil
+	}
+
+	agent := &v1alpha1.SpireAgent{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	statusMgr := status.NewManager(fakeClient)
+	reconciler.reportNodeHealth(context.Background(), agent, statusMgr, ds)
+
+	if agent.Status.NodeHealth == nil {
+		t.Fatal("Expected NodeHealth to be set")
+	}
+	if agent.Status.NodeHealth.UnhealthyNodeCount != 1 || len(agent.Status.NodeHealth.UnhealthyNodes) != 1 {
+		t.Fatalf("Expected one unhealthy node, got %+v", agent.Status.NodeHealth)
+	}
+	node := agent.Status.NodeHealth.UnhealthyNodes[0]
+	if node.NodeName != "worker-1" || node.Reason != "CrashLoopBackOff" || node.Message != "node attestation failed" || node.RestartCount != 5 {
+		t.Errorf("Unexpected unhealthy node entry: %+v", node)
+	}
+}
+
 // TestReconcile_ErrorScenarios tests various error scenarios with table-driven tests
 func TestReconcile_ErrorScenarios(t *testing.T) {
 	tests := []struct {
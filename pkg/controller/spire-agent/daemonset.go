@@ -6,6 +6,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/ptr"
@@ -33,6 +34,11 @@ func (r *SpireAgentReconciler) reconcileDaemonSet(ctx context.Context, agent *v1
 	var existingSpireAgentDaemonSet appsv1.DaemonSet
 	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: spireAgentDaemonset.Name, Namespace: spireAgentDaemonset.Namespace}, &existingSpireAgentDaemonSet)
 	if err != nil && kerrors.IsNotFound(err) {
+		statusMgr.RemoveCondition(CanaryNodesAvailable)
+		r.reportRollout(agent, statusMgr, &v1alpha1.DaemonSetRolloutStatus{
+			Phase:           utils.RolloutPhaseComplete,
+			PodTemplateHash: spireAgentDaemonset.Spec.Template.Annotations[utils.PodTemplateHashAnnotationKey],
+		})
 		if err = r.ctrlClient.Create(ctx, spireAgentDaemonset); err != nil {
 			if conflictErr := utils.HandleCreateConflict(err, spireAgentDaemonset, r.log, statusMgr, DaemonSetAvailable); conflictErr != nil {
 				return conflictErr
@@ -45,8 +51,23 @@ func (r *SpireAgentReconciler) reconcileDaemonSet(ctx context.Context, agent *v1
 		}
 		r.log.Info("Created spire agent DaemonSet")
 	} else if err == nil {
+		rolloutPlan, err := utils.PlanDaemonSetRollout(ctx, r.ctrlClient, &existingSpireAgentDaemonSet, spireAgentDaemonset, agent.Spec.RolloutStrategy)
+		if err != nil {
+			r.log.Error(err, "failed to plan spire agent DaemonSet rollout")
+			statusMgr.AddCondition(DaemonSetAvailable, "SpireAgentDaemonSetRolloutFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.reportRollout(agent, statusMgr, rolloutPlan.Status)
+		r.reportCanaryNodes(agent, statusMgr, rolloutPlan)
+
 		if !needsUpdate(existingSpireAgentDaemonSet, *spireAgentDaemonset) {
+			if err := r.advanceCanaryRollout(ctx, statusMgr, rolloutPlan, createOnlyMode); err != nil {
+				return err
+			}
 			statusMgr.CheckDaemonSetHealth(ctx, spireAgentDaemonset.Name, spireAgentDaemonset.Namespace, DaemonSetAvailable)
+			r.reportNodeHealth(ctx, agent, statusMgr, spireAgentDaemonset)
 			return nil
 		}
 		if createOnlyMode {
@@ -62,6 +83,9 @@ func (r *SpireAgentReconciler) reconcileDaemonSet(ctx context.Context, agent *v1
 			}
 			r.log.Info("Updated spire agent DaemonSet")
 		}
+		if err := r.advanceCanaryRollout(ctx, statusMgr, rolloutPlan, createOnlyMode); err != nil {
+			return err
+		}
 	} else {
 		r.log.Error(err, "failed to get spire-agent daemonset")
 		statusMgr.AddCondition(DaemonSetAvailable, "SpireAgentDaemonSetGetFailed",
@@ -72,10 +96,59 @@ func (r *SpireAgentReconciler) reconcileDaemonSet(ctx context.Context, agent *v1
 
 	// Check DaemonSet health/readiness
 	statusMgr.CheckDaemonSetHealth(ctx, spireAgentDaemonset.Name, spireAgentDaemonset.Namespace, DaemonSetAvailable)
+	r.reportNodeHealth(ctx, agent, statusMgr, spireAgentDaemonset)
 
 	return nil
 }
 
+// advanceCanaryRollout deletes the canary pods still running an old pod template so that
+// they are recreated from the current template. Nothing is deleted in create-only mode.
+func (r *SpireAgentReconciler) advanceCanaryRollout(ctx context.Context, statusMgr *status.Manager, plan *utils.DaemonSetRolloutPlan, createOnlyMode bool) error {
+	if createOnlyMode || len(plan.OutdatedCanaryPods) == 0 {
+		return nil
+	}
+	if err := utils.DeleteOutdatedCanaryPods(ctx, r.ctrlClient, plan); err != nil {
+		r.log.Error(err, "failed to replace spire agent canary pods")
+		statusMgr.AddCondition(DaemonSetAvailable, "SpireAgentDaemonSetRolloutFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Replaced spire agent canary pods", "count", len(plan.OutdatedCanaryPods))
+	return nil
+}
+
+// reportRollout records the spire-agent DaemonSet rollout progress in the SpireAgent status
+func (r *SpireAgentReconciler) reportRollout(agent *v1alpha1.SpireAgent, statusMgr *status.Manager, rollout *v1alpha1.DaemonSetRolloutStatus) {
+	if !equality.Semantic.DeepEqual(agent.Status.Rollout, rollout) {
+		agent.Status.Rollout = rollout
+		statusMgr.RequestStatusUpdate()
+	}
+}
+
+// reportCanaryNodes reports a rollout held because no node matches the canary node selector
+func (r *SpireAgentReconciler) reportCanaryNodes(agent *v1alpha1.SpireAgent, statusMgr *status.Manager, plan *utils.DaemonSetRolloutPlan) {
+	if !plan.CanaryNodesNotFound {
+		statusMgr.RemoveCondition(CanaryNodesAvailable)
+		return
+	}
+	r.log.Info("Holding spire agent DaemonSet rollout, no node matches the canary node selector")
+	statusMgr.AddCondition(CanaryNodesAvailable, "CanaryNodesNotFound",
+		fmt.Sprintf("No node matches the canary node selector %v, the rollout is held until a node matches or the canary is removed",
+			agent.Spec.RolloutStrategy.Canary.NodeSelector),
+		metav1.ConditionFalse)
+}
+
+// reportNodeHealth records the per-node pod health of the spire-agent DaemonSet in the SpireAgent status
+func (r *SpireAgentReconciler) reportNodeHealth(ctx context.Context, agent *v1alpha1.SpireAgent, statusMgr *status.Manager, ds *appsv1.DaemonSet) {
+	nodeHealth := statusMgr.CheckDaemonSetNodeHealth(ctx, ds.Name, ds.Namespace, NodesHealthy,
+		status.UnhealthyNodeThreshold(agent.Spec.UnhealthyNodeThresholdPercent))
+	if !equality.Semantic.DeepEqual(agent.Status.NodeHealth, nodeHealth) {
+		agent.Status.NodeHealth = nodeHealth
+		statusMgr.RequestStatusUpdate()
+	}
+}
+
 func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, spireAgentConfigHash string) *appsv1.DaemonSet {
 
 	// Generate standardized labels once and reuse them
@@ -91,7 +164,6 @@ func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1
 	volumeMounts := []corev1.VolumeMount{
 		{Name: "spire-config", MountPath: "/opt/spire/conf/agent", ReadOnly: true},
 		{Name: "spire-agent-persistence", MountPath: "/var/lib/spire"},
-		{Name: "spire-bundle", MountPath: "/run/spire/bundle", ReadOnly: true},
 		{Name: "spire-agent-socket-dir", MountPath: "/tmp/spire-agent/public"},
 		{Name: "spire-token", MountPath: "/var/run/secrets/tokens"},
 	}
@@ -105,12 +177,6 @@ func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1
 		},
 		{Name: "spire-agent-admin-socket-dir", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
 		{Name: "spire-agent-persistence", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
-		{
-			Name: "spire-bundle",
-			VolumeSource: corev1.VolumeSource{
-				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: ztwim.Spec.BundleConfigMap}},
-			},
-		},
 		{
 			Name: "spire-token",
 			VolumeSource: corev1.VolumeSource{
@@ -120,7 +186,7 @@ func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1
 							ServiceAccountToken: &corev1.ServiceAccountTokenProjection{
 								Path:              "spire-agent",
 								ExpirationSeconds: ptr.To(int64(7200)),
-								Audience:          "spire-server",
+								Audience:          k8sPSATAudience(config),
 							},
 						},
 					},
@@ -138,6 +204,12 @@ func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1
 		},
 	}
 
+	// Mount the bootstrap trust bundle unless the agent downloads it from a URL
+	if bundleVolume := spireBundleVolume(config, ztwim); bundleVolume != nil {
+		volumes = append(volumes, *bundleVolume)
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: bundleVolume.Name, MountPath: spireBundleMountPath, ReadOnly: true})
+	}
+
 	// Conditionally add kubelet CA hostPath mount for hostCert verification mode
 	if hostCertPath := getHostCertMountPath(config.WorkloadAttestors); hostCertPath != "" {
 		volumes = append(volumes, corev1.Volume{
@@ -156,6 +228,23 @@ func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1
 		})
 	}
 
+	// Mount the kubelet CA bundle copied by the operator for configMap verification mode
+	if usesKubeletCAConfigMap(config.WorkloadAttestors) {
+		volumes = append(volumes, corev1.Volume{
+			Name: "kubelet-ca",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: utils.SpireAgentKubeletCAConfigMapName},
+				},
+			},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      "kubelet-ca",
+			MountPath: utils.SpireAgentKubeletCAMountPath,
+			ReadOnly:  true,
+		})
+	}
+
 	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "spire-agent",
@@ -166,12 +255,7 @@ func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1
 			Selector: &metav1.LabelSelector{
 				MatchLabels: selectorLabels,
 			},
-			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
-				Type: appsv1.RollingUpdateDaemonSetStrategyType,
-				RollingUpdate: &appsv1.RollingUpdateDaemonSet{
-					MaxUnavailable: &intstr.IntOrString{IntVal: 1},
-				},
-			},
+			UpdateStrategy: utils.DaemonSetUpdateStrategy(config.RolloutStrategy),
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Annotations: map[string]string{
@@ -252,6 +336,9 @@ func generateSpireAgentDaemonSet(config v1alpha1.SpireAgentSpec, ztwim *v1alpha1
 	// The internal service names are added to NO_PROXY to ensure internal traffic bypasses the proxy.
 	utils.AddProxyConfigToPodWithInternalNoProxy(&ds.Spec.Template.Spec)
 
+	// Record the pod template hash last so that it covers the complete template
+	utils.SetPodTemplateHash(ds)
+
 	return ds
 }
 
@@ -7,7 +7,10 @@ import (
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
 )
 
 func TestGetHostCertMountPath(t *testing.T) {
@@ -130,6 +133,16 @@ func TestGetHostCertMountPath(t *testing.T) {
 			},
 			expected: "",
 		},
+		{
+			name: "configMap type - no host mount",
+			workloadAttestors: &v1alpha1.WorkloadAttestors{
+				K8sEnabled: "true",
+				WorkloadAttestorsVerification: &v1alpha1.WorkloadAttestorsVerification{
+					Type: utils.WorkloadAttestorVerificationTypeConfigMap,
+				},
+			},
+			expected: "",
+		},
 		{
 			name: "custom path with hostCert",
 			workloadAttestors: &v1alpha1.WorkloadAttestors{
@@ -222,3 +235,43 @@ func TestGenerateSpireAgentDaemonSet_SCCSecurityHardening(t *testing.T) {
 		assertSpireAgentContainerHardening(t, &ds.Spec.Template.Spec.Containers[0])
 	})
 }
+
+func TestGenerateSpireAgentDaemonSet_RolloutStrategy(t *testing.T) {
+	ztwim := &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{
+			TrustDomain:     "example.org",
+			BundleConfigMap: "spire-bundle",
+		},
+	}
+
+	t.Run("defaults to one unavailable node without surge", func(t *testing.T) {
+		ds := generateSpireAgentDaemonSet(v1alpha1.SpireAgentSpec{SocketPath: "/run/spire/agent-sockets"}, ztwim, "hash")
+		require.NotNil(t, ds.Spec.UpdateStrategy.RollingUpdate)
+		assert.Equal(t, appsv1.RollingUpdateDaemonSetStrategyType, ds.Spec.UpdateStrategy.Type)
+		assert.Equal(t, intstr.FromInt32(1), *ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable)
+		assert.Equal(t, intstr.FromInt32(0), *ds.Spec.UpdateStrategy.RollingUpdate.MaxSurge)
+		assert.NotEmpty(t, ds.Spec.Template.Annotations[utils.PodTemplateHashAnnotationKey])
+	})
+
+	t.Run("uses configured maxUnavailable and maxSurge", func(t *testing.T) {
+		spec := v1alpha1.SpireAgentSpec{
+			SocketPath: "/run/spire/agent-sockets",
+			RolloutStrategy: &v1alpha1.DaemonSetRolloutStrategy{
+				MaxUnavailable: ptr.To(intstr.FromInt32(0)),
+				MaxSurge:       ptr.To(intstr.FromString("10%")),
+			},
+		}
+		ds := generateSpireAgentDaemonSet(spec, ztwim, "hash")
+		assert.Equal(t, intstr.FromInt32(0), *ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable)
+		assert.Equal(t, intstr.FromString("10%"), *ds.Spec.UpdateStrategy.RollingUpdate.MaxSurge)
+	})
+
+	t.Run("pod template hash follows the config hash", func(t *testing.T) {
+		spec := v1alpha1.SpireAgentSpec{SocketPath: "/run/spire/agent-sockets"}
+		first := generateSpireAgentDaemonSet(spec, ztwim, "hash-1")
+		second := generateSpireAgentDaemonSet(spec, ztwim, "hash-2")
+		assert.NotEqual(t,
+			first.Spec.Template.Annotations[utils.PodTemplateHashAnnotationKey],
+			second.Spec.Template.Annotations[utils.PodTemplateHashAnnotationKey])
+	})
+}
@@ -0,0 +1,176 @@
+package spire_agent
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// Node attestor types supported with an external SPIRE server
+	externalNodeAttestorK8sPSAT  = "k8sPSAT"
+	externalNodeAttestorAWSIID   = "awsIID"
+	externalNodeAttestorGCPIIT   = "gcpIIT"
+	externalNodeAttestorAzureMSI = "azureMSI"
+
+	defaultExternalServerPort = 8081
+	defaultTrustBundleKey     = "bundle.crt"
+	defaultTrustBundleFormat  = "pem"
+	defaultK8sPSATAudience    = "spire-server"
+	spireBundleMountPath      = "/run/spire/bundle"
+	spireBundleFileName       = "bundle.crt"
+	localSpireServerPort      = "443"
+)
+
+// spireServerEndpoint returns the address and port the agents connect to
+func spireServerEndpoint(spec v1alpha1.SpireAgentSpec) (string, string) {
+	if spec.ExternalServer == nil {
+		return "spire-server." + utils.GetOperatorNamespace(), localSpireServerPort
+	}
+	port := spec.ExternalServer.Port
+	if port == 0 {
+		port = defaultExternalServerPort
+	}
+	return spec.ExternalServer.Address, strconv.Itoa(int(port))
+}
+
+// configureTrustBundle sets the bootstrap trust bundle source of the agent.
+// The bundle is read from the mounted bundle ConfigMap unless an external server bundle URL is configured.
+func configureTrustBundle(agent map[string]interface{}, spec v1alpha1.SpireAgentSpec) {
+	if spec.ExternalServer != nil && spec.ExternalServer.TrustBundle.URL != "" {
+		format := spec.ExternalServer.TrustBundle.Format
+		if format == "" {
+			format = defaultTrustBundleFormat
+		}
+		agent["trust_bundle_url"] = spec.ExternalServer.TrustBundle.URL
+		agent["trust_bundle_format"] = format
+		return
+	}
+	agent["trust_bundle_path"] = path.Join(spireBundleMountPath, spireBundleFileName)
+}
+
+// spireBundleVolume returns the volume holding the bootstrap trust bundle, or nil when the
+// bundle is downloaded from a URL. With a local server the bundle ConfigMap is maintained
+// by the SPIRE server notifier, with an external server it is provided by the administrator.
+func spireBundleVolume(spec v1alpha1.SpireAgentSpec, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) *corev1.Volume {
+	if spec.ExternalServer == nil {
+		return &corev1.Volume{
+			Name: "spire-bundle",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: ztwim.Spec.BundleConfigMap}},
+			},
+		}
+	}
+
+	bundleConfigMap := spec.ExternalServer.TrustBundle.ConfigMap
+	if bundleConfigMap == nil {
+		return nil
+	}
+	key := bundleConfigMap.Key
+	if key == "" {
+		key = defaultTrustBundleKey
+	}
+	return &corev1.Volume{
+		Name: "spire-bundle",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: bundleConfigMap.Name},
+				Items:                []corev1.KeyToPath{{Key: key, Path: spireBundleFileName}},
+			},
+		},
+	}
+}
+
+// nodeAttestorPlugin returns the NodeAttestor plugin configuration of the agent, or nil when none is enabled
+func nodeAttestorPlugin(spec v1alpha1.SpireAgentSpec, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) []map[string]interface{} {
+	if spec.ExternalServer == nil {
+		if spec.NodeAttestor == nil || spec.NodeAttestor.K8sPSATEnabled != "true" {
+			return nil
+		}
+		return k8sPSATPlugin(ztwim.Spec.ClusterName)
+	}
+
+	attestor := spec.ExternalServer.NodeAttestor
+	switch attestor.Type {
+	case externalNodeAttestorK8sPSAT:
+		cluster := ztwim.Spec.ClusterName
+		if attestor.K8sPSAT != nil && attestor.K8sPSAT.Cluster != "" {
+			cluster = attestor.K8sPSAT.Cluster
+		}
+		return k8sPSATPlugin(cluster)
+	case externalNodeAttestorAWSIID:
+		return []map[string]interface{}{{"aws_iid": map[string]interface{}{"plugin_data": map[string]interface{}{}}}}
+	case externalNodeAttestorGCPIIT:
+		return []map[string]interface{}{{"gcp_iit": map[string]interface{}{"plugin_data": map[string]interface{}{}}}}
+	case externalNodeAttestorAzureMSI:
+		return []map[string]interface{}{{"azure_msi": map[string]interface{}{"plugin_data": map[string]interface{}{}}}}
+	default:
+		return nil
+	}
+}
+
+func k8sPSATPlugin(cluster string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"k8s_psat": map[string]interface{}{
+				"plugin_data": map[string]interface{}{
+					"cluster": cluster,
+				},
+			},
+		},
+	}
+}
+
+// k8sPSATAudience returns the audience of the projected service account token used for node attestation
+func k8sPSATAudience(spec v1alpha1.SpireAgentSpec) string {
+	if spec.ExternalServer != nil && spec.ExternalServer.NodeAttestor.K8sPSAT != nil && spec.ExternalServer.NodeAttestor.K8sPSAT.Audience != "" {
+		return spec.ExternalServer.NodeAttestor.K8sPSAT.Audience
+	}
+	return defaultK8sPSATAudience
+}
+
+// validateExternalServer checks the external SPIRE server configuration
+func validateExternalServer(external *v1alpha1.ExternalSpireServer) error {
+	if external == nil {
+		return nil
+	}
+	if external.Address == "" {
+		return fmt.Errorf("externalServer.address is required")
+	}
+	if net.ParseIP(external.Address) == nil && len(validation.IsDNS1123Subdomain(external.Address)) > 0 {
+		return fmt.Errorf("externalServer.address must be a DNS name or IP address without scheme or port, got %q", external.Address)
+	}
+
+	bundle := external.TrustBundle
+	if (bundle.ConfigMap == nil) == (bundle.URL == "") {
+		return fmt.Errorf("exactly one of externalServer.trustBundle.configMap or externalServer.trustBundle.url must be set")
+	}
+	if bundle.ConfigMap != nil && bundle.ConfigMap.Name == "" {
+		return fmt.Errorf("externalServer.trustBundle.configMap.name is required")
+	}
+	if bundle.URL != "" {
+		u, err := url.Parse(bundle.URL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("externalServer.trustBundle.url must be a valid https URL")
+		}
+	}
+
+	attestor := external.NodeAttestor
+	switch attestor.Type {
+	case externalNodeAttestorK8sPSAT, externalNodeAttestorAWSIID, externalNodeAttestorGCPIIT, externalNodeAttestorAzureMSI:
+	default:
+		return fmt.Errorf("unsupported externalServer.nodeAttestor.type %q", attestor.Type)
+	}
+	if attestor.K8sPSAT != nil && attestor.Type != externalNodeAttestorK8sPSAT {
+		return fmt.Errorf("externalServer.nodeAttestor.k8sPSAT can only be set when type is %s", externalNodeAttestorK8sPSAT)
+	}
+	return nil
+}
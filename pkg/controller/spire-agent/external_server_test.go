@@ -0,0 +1,181 @@
+package spire_agent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func newExternalServer() *v1alpha1.ExternalSpireServer {
+	return &v1alpha1.ExternalSpireServer{
+		Address: "spire.example.com",
+		Port:    8443,
+		TrustBundle: v1alpha1.ExternalServerTrustBundle{
+			ConfigMap: &v1alpha1.TrustBundleConfigMapReference{Name: "external-bundle", Key: "ca.pem"},
+		},
+		NodeAttestor: v1alpha1.ExternalServerNodeAttestor{Type: "k8sPSAT"},
+	}
+}
+
+func newExternalTestZTWIM() *v1alpha1.ZeroTrustWorkloadIdentityManager {
+	return &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{
+			TrustDomain:     "example.org",
+			ClusterName:     "local-cluster",
+			BundleConfigMap: "spire-bundle",
+		},
+	}
+}
+
+func TestSpireServerEndpoint(t *testing.T) {
+	address, port := spireServerEndpoint(v1alpha1.SpireAgentSpec{})
+	assert.Equal(t, "spire-server."+utils.GetOperatorNamespace(), address)
+	assert.Equal(t, "443", port)
+
+	address, port = spireServerEndpoint(v1alpha1.SpireAgentSpec{ExternalServer: newExternalServer()})
+	assert.Equal(t, "spire.example.com", address)
+	assert.Equal(t, "8443", port)
+
+	external := newExternalServer()
+	external.Port = 0
+	_, port = spireServerEndpoint(v1alpha1.SpireAgentSpec{ExternalServer: external})
+	assert.Equal(t, "8081", port)
+}
+
+func TestConfigureTrustBundle(t *testing.T) {
+	agent := map[string]interface{}{}
+	configureTrustBundle(agent, v1alpha1.SpireAgentSpec{ExternalServer: newExternalServer()})
+	assert.Equal(t, "/run/spire/bundle/bundle.crt", agent["trust_bundle_path"])
+	assert.NotContains(t, agent, "trust_bundle_url")
+
+	external := newExternalServer()
+	external.TrustBundle = v1alpha1.ExternalServerTrustBundle{URL: "https://bundle.example.com/bundle", Format: "spiffe"}
+	agent = map[string]interface{}{}
+	configureTrustBundle(agent, v1alpha1.SpireAgentSpec{ExternalServer: external})
+	assert.Equal(t, "https://bundle.example.com/bundle", agent["trust_bundle_url"])
+	assert.Equal(t, "spiffe", agent["trust_bundle_format"])
+	assert.NotContains(t, agent, "trust_bundle_path")
+}
+
+func TestSpireBundleVolume(t *testing.T) {
+	ztwim := newExternalTestZTWIM()
+
+	volume := spireBundleVolume(v1alpha1.SpireAgentSpec{}, ztwim)
+	require.NotNil(t, volume)
+	assert.Equal(t, "spire-bundle", volume.ConfigMap.Name)
+	assert.Empty(t, volume.ConfigMap.Items)
+
+	volume = spireBundleVolume(v1alpha1.SpireAgentSpec{ExternalServer: newExternalServer()}, ztwim)
+	require.NotNil(t, volume)
+	assert.Equal(t, "external-bundle", volume.ConfigMap.Name)
+	require.Len(t, volume.ConfigMap.Items, 1)
+	assert.Equal(t, "ca.pem", volume.ConfigMap.Items[0].Key)
+	assert.Equal(t, "bundle.crt", volume.ConfigMap.Items[0].Path)
+
+	external := newExternalServer()
+	external.TrustBundle = v1alpha1.ExternalServerTrustBundle{URL: "https://bundle.example.com/bundle"}
+	assert.Nil(t, spireBundleVolume(v1alpha1.SpireAgentSpec{ExternalServer: external}, ztwim))
+}
+
+func TestNodeAttestorPlugin(t *testing.T) {
+	ztwim := newExternalTestZTWIM()
+
+	assert.Nil(t, nodeAttestorPlugin(v1alpha1.SpireAgentSpec{}, ztwim))
+	assert.Equal(t, k8sPSATPlugin("local-cluster"), nodeAttestorPlugin(v1alpha1.SpireAgentSpec{
+		NodeAttestor: &v1alpha1.NodeAttestor{K8sPSATEnabled: "true"},
+	}, ztwim))
+
+	external := newExternalServer()
+	assert.Equal(t, k8sPSATPlugin("local-cluster"), nodeAttestorPlugin(v1alpha1.SpireAgentSpec{ExternalServer: external}, ztwim))
+
+	external.NodeAttestor.K8sPSAT = &v1alpha1.ExternalK8sPSATAttestor{Cluster: "edge-cluster"}
+	assert.Equal(t, k8sPSATPlugin("edge-cluster"), nodeAttestorPlugin(v1alpha1.SpireAgentSpec{ExternalServer: external}, ztwim))
+
+	external = newExternalServer()
+	external.NodeAttestor.Type = "awsIID"
+	plugin := nodeAttestorPlugin(v1alpha1.SpireAgentSpec{ExternalServer: external}, ztwim)
+	require.Len(t, plugin, 1)
+	assert.Contains(t, plugin[0], "aws_iid")
+}
+
+func TestK8sPSATAudience(t *testing.T) {
+	assert.Equal(t, "spire-server", k8sPSATAudience(v1alpha1.SpireAgentSpec{}))
+
+	external := newExternalServer()
+	external.NodeAttestor.K8sPSAT = &v1alpha1.ExternalK8sPSATAttestor{Audience: "spire-edge"}
+	assert.Equal(t, "spire-edge", k8sPSATAudience(v1alpha1.SpireAgentSpec{ExternalServer: external}))
+}
+
+func TestValidateExternalServer(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*v1alpha1.ExternalSpireServer)
+		wantErr string
+	}{
+		{name: "valid configMap bundle"},
+		{
+			name: "valid url bundle with IP address",
+			mutate: func(e *v1alpha1.ExternalSpireServer) {
+				e.Address = "10.0.0.10"
+				e.TrustBundle = v1alpha1.ExternalServerTrustBundle{URL: "https://bundle.example.com/bundle"}
+			},
+		},
+		{
+			name:    "address with scheme",
+			mutate:  func(e *v1alpha1.ExternalSpireServer) { e.Address = "https://spire.example.com" },
+			wantErr: "externalServer.address",
+		},
+		{
+			name:    "both bundle sources",
+			mutate:  func(e *v1alpha1.ExternalSpireServer) { e.TrustBundle.URL = "https://bundle.example.com" },
+			wantErr: "exactly one",
+		},
+		{
+			name:    "no bundle source",
+			mutate:  func(e *v1alpha1.ExternalSpireServer) { e.TrustBundle.ConfigMap = nil },
+			wantErr: "exactly one",
+		},
+		{
+			name: "plain http bundle url",
+			mutate: func(e *v1alpha1.ExternalSpireServer) {
+				e.TrustBundle = v1alpha1.ExternalServerTrustBundle{URL: "http://bundle.example.com"}
+			},
+			wantErr: "https",
+		},
+		{
+			name:    "unsupported attestor",
+			mutate:  func(e *v1alpha1.ExternalSpireServer) { e.NodeAttestor.Type = "joinToken" },
+			wantErr: "unsupported",
+		},
+		{
+			name: "k8sPSAT settings with other attestor",
+			mutate: func(e *v1alpha1.ExternalSpireServer) {
+				e.NodeAttestor.Type = "gcpIIT"
+				e.NodeAttestor.K8sPSAT = &v1alpha1.ExternalK8sPSATAttestor{}
+			},
+			wantErr: "k8sPSAT",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			external := newExternalServer()
+			if tt.mutate != nil {
+				tt.mutate(external)
+			}
+			err := validateExternalServer(external)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	assert.NoError(t, validateExternalServer(nil))
+}
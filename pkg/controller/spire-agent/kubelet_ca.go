@@ -0,0 +1,320 @@
+package spire_agent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// kubeletDialTimeout bounds the TLS handshake used to verify the kubelet CA bundle
+	kubeletDialTimeout = 5 * time.Second
+
+	// defaultKubeletPort is used when a node does not report its kubelet endpoint
+	defaultKubeletPort = 10250
+
+	// maxKubeletProbeNodes is the number of nodes tried when verifying the kubelet CA bundle
+	maxKubeletProbeNodes = 3
+
+	// kubeletCAResyncInterval is how often the kubelet CA bundle is copied again from its source
+	kubeletCAResyncInterval = 10 * time.Minute
+
+	// kubeletCACheckTimeout bounds a background verification of the kubelet CA bundle
+	kubeletCACheckTimeout = 30 * time.Second
+)
+
+// kubeletCAVerification is the outcome of verifying the kubelet CA bundle, as a KubeletCAVerified condition
+type kubeletCAVerification struct {
+	status  metav1.ConditionStatus
+	reason  string
+	message string
+}
+
+// fetchKubeletServingCertificates returns the certificate chain presented by the kubelet
+// listening on address. The chain is verified by the caller against the configured CA bundle.
+var fetchKubeletServingCertificates = func(ctx context.Context, address string) ([]*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: kubeletDialTimeout},
+		Config:    &tls.Config{InsecureSkipVerify: true},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState().PeerCertificates, nil
+}
+
+// reconcileKubeletCA copies the kubelet serving CA bundle from the configured cluster ConfigMap
+// into the operator namespace and checks in the background that it verifies a kubelet serving
+// certificate. It returns a hash of the bundle, or an empty string when configMap verification is
+// not used or the bundle is not available, and when to reconcile again.
+func (r *SpireAgentReconciler) reconcileKubeletCA(ctx context.Context, agent *v1alpha1.SpireAgent, statusMgr *status.Manager, createOnlyMode bool) (string, time.Duration, error) {
+	if !usesKubeletCAConfigMap(agent.Spec.WorkloadAttestors) {
+		statusMgr.RemoveCondition(KubeletCAVerified)
+		r.kubeletCACheck.Reset()
+		return "", 0, nil
+	}
+
+	verification := agent.Spec.WorkloadAttestors.WorkloadAttestorsVerification
+	source := kubeletCASource(verification)
+	var sourceConfigMap corev1.ConfigMap
+	if err := r.ctrlClient.UncachedGet(ctx, types.NamespacedName{Name: source.Name, Namespace: source.Namespace}, &sourceConfigMap); err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get kubelet CA ConfigMap", "namespace", source.Namespace, "name", source.Name)
+			statusMgr.AddCondition(KubeletCAVerified, "KubeletCASourceUnavailable",
+				fmt.Sprintf("Failed to get kubelet CA ConfigMap %s/%s: %v", source.Namespace, source.Name, err),
+				metav1.ConditionFalse)
+			return "", 0, err
+		}
+		// A missing ConfigMap is a configuration problem, look for it again at the next resync
+		message := fmt.Sprintf("Kubelet CA ConfigMap %s/%s not found", source.Namespace, source.Name)
+		if source.Namespace == utils.DefaultKubeletCAConfigMapNamespace && source.Name == utils.DefaultKubeletCAConfigMapName {
+			message += ", it is only published on OpenShift: set workloadAttestorsVerification.kubeletCAConfigMap to the ConfigMap holding the kubelet serving CA"
+		}
+		r.log.Info("Kubelet CA ConfigMap not found", "namespace", source.Namespace, "name", source.Name)
+		statusMgr.AddCondition(KubeletCAVerified, "KubeletCASourceNotFound", message, metav1.ConditionFalse)
+		return "", kubeletCAResyncInterval, nil
+	}
+
+	caBundle := sourceConfigMap.Data[source.Key]
+	roots, err := parseKubeletCABundle([]byte(caBundle))
+	if err != nil {
+		err = fmt.Errorf("invalid kubelet CA bundle in key %q of ConfigMap %s/%s: %w", source.Key, source.Namespace, source.Name, err)
+		r.log.Error(err, "failed to parse kubelet CA bundle")
+		statusMgr.AddCondition(KubeletCAVerified, "KubeletCAInvalid", err.Error(), metav1.ConditionFalse)
+		return "", 0, err
+	}
+
+	kubeletCAConfigMap := generateKubeletCAConfigMap(agent, caBundle)
+	if err := controllerutil.SetControllerReference(agent, kubeletCAConfigMap, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference")
+		statusMgr.AddCondition(ConfigMapAvailable, "SpireAgentKubeletCAConfigMapGenerationFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", 0, err
+	}
+
+	var existing corev1.ConfigMap
+	err = r.ctrlClient.Get(ctx, types.NamespacedName{Name: kubeletCAConfigMap.Name, Namespace: kubeletCAConfigMap.Namespace}, &existing)
+	if err != nil && kerrors.IsNotFound(err) {
+		if err = r.ctrlClient.Create(ctx, kubeletCAConfigMap); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, kubeletCAConfigMap, r.log, statusMgr, ConfigMapAvailable); conflictErr != nil {
+				return "", 0, conflictErr
+			}
+			r.log.Error(err, "failed to create spire-agent kubelet CA config map")
+			statusMgr.AddCondition(ConfigMapAvailable, "SpireAgentKubeletCAConfigMapGenerationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", 0, fmt.Errorf("failed to create kubelet CA ConfigMap: %w", err)
+		}
+		r.log.Info("Created spire agent kubelet CA ConfigMap")
+	} else if err == nil {
+		if !equality.Semantic.DeepEqual(existing.Data, kubeletCAConfigMap.Data) ||
+			!equality.Semantic.DeepEqual(existing.Labels, kubeletCAConfigMap.Labels) {
+			if createOnlyMode {
+				r.log.Info("Skipping kubelet CA ConfigMap update due to create-only mode")
+			} else {
+				kubeletCAConfigMap.ResourceVersion = existing.ResourceVersion
+				if err = r.ctrlClient.Update(ctx, kubeletCAConfigMap); err != nil {
+					r.log.Error(err, "failed to update spire-agent kubelet CA config map")
+					statusMgr.AddCondition(ConfigMapAvailable, "SpireAgentKubeletCAConfigMapGenerationFailed",
+						err.Error(),
+						metav1.ConditionFalse)
+					return "", 0, fmt.Errorf("failed to update kubelet CA ConfigMap: %w", err)
+				}
+				r.log.Info("Updated spire agent kubelet CA ConfigMap")
+			}
+		}
+	} else {
+		statusMgr.AddCondition(ConfigMapAvailable, "SpireAgentKubeletCAConfigMapGenerationFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", 0, err
+	}
+
+	hash := utils.GenerateConfigHash([]byte(caBundle))
+	result := r.kubeletCACheck.Poll(hash, kubeletCAResyncInterval, r.startBackground, func() (kubeletCAVerification, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), kubeletCACheckTimeout)
+		defer cancel()
+		return r.verifyKubeletCA(ctx, roots), nil
+	})
+	if result == nil {
+		statusMgr.AddCondition(KubeletCAVerified, "KubeletCAVerificationPending",
+			"Verifying the kubelet CA bundle against a kubelet serving certificate",
+			metav1.ConditionUnknown)
+		return hash, utils.BackgroundCheckPollInterval, nil
+	}
+	statusMgr.AddCondition(KubeletCAVerified, result.Value.reason, result.Value.message, result.Value.status)
+	return hash, kubeletCAResyncInterval, nil
+}
+
+// verifyKubeletCA checks that the CA bundle verifies the serving certificate of a kubelet. The
+// result is Unknown when no kubelet could be reached, since the bundle itself may still be valid.
+func (r *SpireAgentReconciler) verifyKubeletCA(ctx context.Context, roots *x509.CertPool) kubeletCAVerification {
+	var nodes corev1.NodeList
+	if err := r.ctrlClient.List(ctx, &nodes); err != nil {
+		return kubeletCAVerification{metav1.ConditionUnknown, "KubeletUnreachable",
+			fmt.Sprintf("Failed to list nodes to verify the kubelet CA bundle: %v", err)}
+	}
+
+	addresses := kubeletAddresses(nodes.Items, maxKubeletProbeNodes)
+	if len(addresses) == 0 {
+		return kubeletCAVerification{metav1.ConditionUnknown, "KubeletUnreachable",
+			"No ready node with an internal address found to verify the kubelet CA bundle"}
+	}
+
+	var dialErrors []string
+	for _, address := range addresses {
+		certs, err := fetchKubeletServingCertificates(ctx, address)
+		if err != nil {
+			dialErrors = append(dialErrors, fmt.Sprintf("%s: %v", address, err))
+			continue
+		}
+		if err := verifyKubeletServingCertificate(certs, roots); err != nil {
+			return kubeletCAVerification{metav1.ConditionFalse, "KubeletCAVerificationFailed",
+				fmt.Sprintf("Kubelet CA bundle does not verify the serving certificate of kubelet %s: %v", address, err)}
+		}
+		return kubeletCAVerification{metav1.ConditionTrue, "KubeletCAVerified",
+			fmt.Sprintf("Kubelet CA bundle verifies the serving certificate of kubelet %s", address)}
+	}
+
+	return kubeletCAVerification{metav1.ConditionUnknown, "KubeletUnreachable",
+		fmt.Sprintf("Failed to connect to a kubelet to verify the CA bundle: %s", strings.Join(dialErrors, "; "))}
+}
+
+// usesKubeletCAConfigMap returns true if kubelet verification uses the CA bundle copied by the operator
+func usesKubeletCAConfigMap(workloadAttestors *v1alpha1.WorkloadAttestors) bool {
+	return workloadAttestors != nil && workloadAttestors.WorkloadAttestorsVerification != nil &&
+		workloadAttestors.WorkloadAttestorsVerification.Type == utils.WorkloadAttestorVerificationTypeConfigMap
+}
+
+// kubeletCASource returns the ConfigMap key holding the kubelet serving CA bundle, with defaults applied
+func kubeletCASource(verification *v1alpha1.WorkloadAttestorsVerification) v1alpha1.KubeletCAConfigMapSource {
+	source := v1alpha1.KubeletCAConfigMapSource{}
+	if verification != nil && verification.KubeletCAConfigMap != nil {
+		source = *verification.KubeletCAConfigMap
+	}
+	if source.Namespace == "" {
+		source.Namespace = utils.DefaultKubeletCAConfigMapNamespace
+	}
+	if source.Name == "" {
+		source.Name = utils.DefaultKubeletCAConfigMapName
+	}
+	if source.Key == "" {
+		source.Key = utils.DefaultKubeletCAConfigMapKey
+	}
+	return source
+}
+
+// generateKubeletCAConfigMap returns the copy of the kubelet CA bundle mounted into the SPIRE agent
+func generateKubeletCAConfigMap(agent *v1alpha1.SpireAgent, caBundle string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.SpireAgentKubeletCAConfigMapName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireAgentLabels(agent.Spec.Labels),
+		},
+		Data: map[string]string{
+			utils.SpireAgentKubeletCAKey: caBundle,
+		},
+	}
+}
+
+// parseKubeletCABundle parses a PEM encoded CA bundle into a certificate pool
+func parseKubeletCABundle(data []byte) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	found := false
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		pool.AddCert(cert)
+		found = true
+	}
+	if !found {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	return pool, nil
+}
+
+// verifyKubeletServingCertificate verifies a kubelet serving certificate chain against the CA bundle.
+// Host names are not checked since the SPIRE agent reaches the kubelet through different addresses.
+func verifyKubeletServingCertificate(certs []*x509.Certificate, roots *x509.CertPool) error {
+	if len(certs) == 0 {
+		return fmt.Errorf("kubelet presented no certificate")
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	return err
+}
+
+// kubeletAddresses returns the kubelet endpoints of up to limit ready nodes, sorted by node name
+func kubeletAddresses(nodes []corev1.Node, limit int) []string {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	var addresses []string
+	for _, node := range nodes {
+		if len(addresses) == limit {
+			break
+		}
+		if !isNodeReady(&node) {
+			continue
+		}
+		port := int(node.Status.DaemonEndpoints.KubeletEndpoint.Port)
+		if port == 0 {
+			port = defaultKubeletPort
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP {
+				addresses = append(addresses, net.JoinHostPort(address.Address, strconv.Itoa(port)))
+				break
+			}
+		}
+	}
+	return addresses
+}
+
+// isNodeReady returns true if the node has a Ready condition set to True
+func isNodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,424 @@
+package spire_agent
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+// testCertificate creates a certificate signed by parent, or self-signed when parent is nil
+func testCertificate(t *testing.T, cn string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func encodeCertificate(cert *x509.Certificate) string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+}
+
+func readyNode(name, internalIP string, port int32) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions:      []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			Addresses:       []corev1.NodeAddress{{Type: corev1.NodeHostName, Address: name}, {Type: corev1.NodeInternalIP, Address: internalIP}},
+			DaemonEndpoints: corev1.NodeDaemonEndpoints{KubeletEndpoint: corev1.DaemonEndpoint{Port: port}},
+		},
+	}
+}
+
+func TestKubeletCASource(t *testing.T) {
+	defaults := v1alpha1.KubeletCAConfigMapSource{
+		Namespace: utils.DefaultKubeletCAConfigMapNamespace,
+		Name:      utils.DefaultKubeletCAConfigMapName,
+		Key:       utils.DefaultKubeletCAConfigMapKey,
+	}
+	assert.Equal(t, defaults, kubeletCASource(nil))
+	assert.Equal(t, defaults, kubeletCASource(&v1alpha1.WorkloadAttestorsVerification{Type: "configMap"}))
+
+	custom := kubeletCASource(&v1alpha1.WorkloadAttestorsVerification{
+		Type:               "configMap",
+		KubeletCAConfigMap: &v1alpha1.KubeletCAConfigMapSource{Namespace: "kube-system", Name: "kubelet-ca"},
+	})
+	assert.Equal(t, v1alpha1.KubeletCAConfigMapSource{Namespace: "kube-system", Name: "kubelet-ca", Key: utils.DefaultKubeletCAConfigMapKey}, custom)
+}
+
+func TestParseKubeletCABundle(t *testing.T) {
+	ca, _ := testCertificate(t, "kubelet-ca", true, nil, nil)
+
+	_, err := parseKubeletCABundle([]byte(encodeCertificate(ca)))
+	assert.NoError(t, err)
+
+	_, err = parseKubeletCABundle([]byte(""))
+	assert.Error(t, err)
+
+	_, err = parseKubeletCABundle([]byte("-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydA==\n-----END CERTIFICATE-----\n"))
+	assert.Error(t, err)
+}
+
+func TestVerifyKubeletServingCertificate(t *testing.T) {
+	ca, caKey := testCertificate(t, "kubelet-ca", true, nil, nil)
+	intermediate, intermediateKey := testCertificate(t, "kubelet-intermediate", true, ca, caKey)
+	leaf, _ := testCertificate(t, "system:node:node-a", false, intermediate, intermediateKey)
+	otherCA, _ := testCertificate(t, "other-ca", true, nil, nil)
+
+	roots, err := parseKubeletCABundle([]byte(encodeCertificate(ca)))
+	require.NoError(t, err)
+	otherRoots, err := parseKubeletCABundle([]byte(encodeCertificate(otherCA)))
+	require.NoError(t, err)
+
+	assert.NoError(t, verifyKubeletServingCertificate([]*x509.Certificate{leaf, intermediate}, roots))
+	assert.Error(t, verifyKubeletServingCertificate([]*x509.Certificate{leaf, intermediate}, otherRoots))
+	assert.Error(t, verifyKubeletServingCertificate([]*x509.Certificate{leaf}, roots), "missing intermediate")
+	assert.Error(t, verifyKubeletServingCertificate(nil, roots))
+}
+
+func TestKubeletAddresses(t *testing.T) {
+	notReady := readyNode("node-b", "10.0.0.2", 10250)
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+	noInternalIP := readyNode("node-c", "10.0.0.3", 10250)
+	noInternalIP.Status.Addresses = noInternalIP.Status.Addresses[:1]
+
+	nodes := []corev1.Node{
+		readyNode("node-e", "10.0.0.5", 10250),
+		readyNode("node-d", "fd00::4", 0),
+		noInternalIP,
+		notReady,
+		readyNode("node-a", "10.0.0.1", 11250),
+	}
+
+	assert.Equal(t, []string{"10.0.0.1:11250", "[fd00::4]:10250"}, kubeletAddresses(nodes, 2))
+	assert.Equal(t, []string{"10.0.0.1:11250", "[fd00::4]:10250", "10.0.0.5:10250"}, kubeletAddresses(nodes, 3))
+}
+
+func TestReconcileKubeletCA(t *testing.T) {
+	ca, caKey := testCertificate(t, "kubelet-ca", true, nil, nil)
+	leaf, _ := testCertificate(t, "system:node:node-a", false, ca, caKey)
+	otherCA, otherKey := testCertificate(t, "other-ca", true, nil, nil)
+	otherLeaf, _ := testCertificate(t, "system:node:node-a", false, otherCA, otherKey)
+
+	configMapAgent := func() *v1alpha1.SpireAgent {
+		return &v1alpha1.SpireAgent{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "uid"},
+			Spec: v1alpha1.SpireAgentSpec{
+				WorkloadAttestors: &v1alpha1.WorkloadAttestors{
+					K8sEnabled:                    "true",
+					WorkloadAttestorsVerification: &v1alpha1.WorkloadAttestorsVerification{Type: utils.WorkloadAttestorVerificationTypeConfigMap},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		agent          *v1alpha1.SpireAgent
+		sourceErr      error
+		caBundle       string
+		existingCopy   *corev1.ConfigMap
+		servingCerts   []*x509.Certificate
+		fetchErr       error
+		expectErr      bool
+		expectHash     bool
+		expectCreate   int
+		expectUpdate   int
+		expectStatus   metav1.ConditionStatus
+		expectReason   string
+		expectMessage  string
+		expectRequeue  time.Duration
+		expectRemoved  bool
+		expectNoSource bool
+	}{
+		{
+			name:           "verification type is not configMap",
+			agent:          &v1alpha1.SpireAgent{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}},
+			expectRemoved:  true,
+			expectNoSource: true,
+		},
+		{
+			name:          "default source ConfigMap not found outside OpenShift",
+			agent:         configMapAgent(),
+			sourceErr:     kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, utils.DefaultKubeletCAConfigMapName),
+			expectStatus:  metav1.ConditionFalse,
+			expectReason:  "KubeletCASourceNotFound",
+			expectMessage: "only published on OpenShift",
+			expectRequeue: kubeletCAResyncInterval,
+		},
+		{
+			name:         "source ConfigMap unavailable",
+			agent:        configMapAgent(),
+			sourceErr:    errors.New("forbidden"),
+			expectErr:    true,
+			expectStatus: metav1.ConditionFalse,
+			expectReason: "KubeletCASourceUnavailable",
+		},
+		{
+			name:         "source ConfigMap without certificate",
+			agent:        configMapAgent(),
+			caBundle:     "not a certificate",
+			expectErr:    true,
+			expectStatus: metav1.ConditionFalse,
+			expectReason: "KubeletCAInvalid",
+		},
+		{
+			name:          "copy created and CA verifies kubelet certificate",
+			agent:         configMapAgent(),
+			caBundle:      encodeCertificate(ca),
+			servingCerts:  []*x509.Certificate{leaf},
+			expectHash:    true,
+			expectCreate:  1,
+			expectStatus:  metav1.ConditionTrue,
+			expectReason:  "KubeletCAVerified",
+			expectRequeue: kubeletCAResyncInterval,
+		},
+		{
+			name:     "outdated copy updated and CA does not verify kubelet certificate",
+			agent:    configMapAgent(),
+			caBundle: encodeCertificate(ca),
+			existingCopy: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: utils.SpireAgentKubeletCAConfigMapName, Labels: utils.SpireAgentLabels(nil)},
+				Data:       map[string]string{utils.SpireAgentKubeletCAKey: "old"},
+			},
+			servingCerts: []*x509.Certificate{otherLeaf},
+			expectHash:   true,
+			expectUpdate: 1,
+			expectStatus: metav1.ConditionFalse,
+			expectReason: "KubeletCAVerificationFailed",
+		},
+		{
+			name:         "kubelet unreachable",
+			agent:        configMapAgent(),
+			caBundle:     encodeCertificate(ca),
+			fetchErr:     errors.New("connection refused"),
+			expectHash:   true,
+			expectCreate: 1,
+			expectStatus: metav1.ConditionUnknown,
+			expectReason: "KubeletUnreachable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			originalFetch := fetchKubeletServingCertificates
+			defer func() { fetchKubeletServingCertificates = originalFetch }()
+			fetchKubeletServingCertificates = func(ctx context.Context, address string) ([]*x509.Certificate, error) {
+				return tt.servingCerts, tt.fetchErr
+			}
+
+			fakeClient := &fakes.FakeCustomCtrlClient{}
+			fakeClient.UncachedGetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				if tt.sourceErr != nil {
+					return tt.sourceErr
+				}
+				obj.(*corev1.ConfigMap).Data = map[string]string{utils.DefaultKubeletCAConfigMapKey: tt.caBundle}
+				return nil
+			}
+			fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+				if tt.existingCopy == nil {
+					return kerrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, key.Name)
+				}
+				tt.existingCopy.DeepCopyInto(obj.(*corev1.ConfigMap))
+				return nil
+			}
+			fakeClient.ListStub = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+				list.(*corev1.NodeList).Items = []corev1.Node{readyNode("node-a", "10.0.0.1", 10250)}
+				return nil
+			}
+
+			scheme := runtime.NewScheme()
+			_ = v1alpha1.AddToScheme(scheme)
+			reconciler := &SpireAgentReconciler{
+				ctrlClient:    fakeClient,
+				ctx:           context.Background(),
+				log:           logr.Discard(),
+				scheme:        scheme,
+				eventRecorder: record.NewFakeRecorder(100),
+				// Verify synchronously
+				runInBackground: func(f func()) { f() },
+			}
+			statusMgr := status.NewManager(fakeClient)
+
+			hash, next, err := reconciler.reconcileKubeletCA(context.Background(), tt.agent, statusMgr, false)
+
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectHash, hash != "")
+			if tt.expectRequeue != 0 {
+				assert.Equal(t, tt.expectRequeue, next)
+			}
+			assert.Equal(t, tt.expectCreate, fakeClient.CreateCallCount())
+			assert.Equal(t, tt.expectUpdate, fakeClient.UpdateCallCount())
+			if tt.expectNoSource {
+				assert.Equal(t, 0, fakeClient.UncachedGetCallCount())
+			}
+			if tt.expectCreate > 0 {
+				_, obj, _ := fakeClient.CreateArgsForCall(0)
+				assert.Equal(t, tt.caBundle, obj.(*corev1.ConfigMap).Data[utils.SpireAgentKubeletCAKey])
+			}
+
+			// Apply the status to observe the recorded conditions
+			agent := tt.agent.DeepCopy()
+			agent.Status.Conditions = []metav1.Condition{{Type: KubeletCAVerified, Status: metav1.ConditionTrue, Reason: "KubeletCAVerified"}}
+			require.NoError(t, statusMgr.ApplyStatus(context.Background(), agent, func() *v1alpha1.ConditionalStatus {
+				return &agent.Status.ConditionalStatus
+			}))
+			var cond *metav1.Condition
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == KubeletCAVerified {
+					cond = &agent.Status.Conditions[i]
+				}
+			}
+			if tt.expectRemoved {
+				assert.Nil(t, cond)
+				return
+			}
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.expectStatus, cond.Status)
+			assert.Equal(t, tt.expectReason, cond.Reason)
+			assert.Contains(t, cond.Message, tt.expectMessage)
+		})
+	}
+}
+
+func TestReconcileKubeletCAVerifiesInBackground(t *testing.T) {
+	ca, caKey := testCertificate(t, "kubelet-ca", true, nil, nil)
+	leaf, _ := testCertificate(t, "system:node:node-a", false, ca, caKey)
+	originalFetch := fetchKubeletServingCertificates
+	defer func() { fetchKubeletServingCertificates = originalFetch }()
+	dials := 0
+	fetchKubeletServingCertificates = func(ctx context.Context, address string) ([]*x509.Certificate, error) {
+		dials++
+		return []*x509.Certificate{leaf}, nil
+	}
+
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	fakeClient.UncachedGetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		obj.(*corev1.ConfigMap).Data = map[string]string{utils.DefaultKubeletCAConfigMapKey: encodeCertificate(ca)}
+		return nil
+	}
+	fakeClient.ListStub = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+		list.(*corev1.NodeList).Items = []corev1.Node{readyNode("node-a", "10.0.0.1", 10250)}
+		return nil
+	}
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	var run func()
+	reconciler := &SpireAgentReconciler{
+		ctrlClient:      fakeClient,
+		log:             logr.Discard(),
+		scheme:          scheme,
+		runInBackground: func(f func()) { run = f },
+	}
+	agent := &v1alpha1.SpireAgent{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "uid"},
+		Spec: v1alpha1.SpireAgentSpec{
+			WorkloadAttestors: &v1alpha1.WorkloadAttestors{
+				WorkloadAttestorsVerification: &v1alpha1.WorkloadAttestorsVerification{Type: utils.WorkloadAttestorVerificationTypeConfigMap},
+			},
+		},
+	}
+	conditionReason := func(statusMgr *status.Manager) string {
+		observed := agent.DeepCopy()
+		require.NoError(t, statusMgr.ApplyStatus(context.Background(), observed, func() *v1alpha1.ConditionalStatus {
+			return &observed.Status.ConditionalStatus
+		}))
+		return apimeta.FindStatusCondition(observed.Status.Conditions, KubeletCAVerified).Reason
+	}
+
+	statusMgr := status.NewManager(fakeClient)
+	hash, next, err := reconciler.reconcileKubeletCA(context.Background(), agent, statusMgr, false)
+	require.NoError(t, err)
+	assert.NotEmpty(t, hash)
+	assert.Equal(t, utils.BackgroundCheckPollInterval, next)
+	assert.Equal(t, "KubeletCAVerificationPending", conditionReason(statusMgr))
+	assert.Zero(t, dials, "kubelets must not be dialed during Reconcile")
+
+	require.NotNil(t, run)
+	run()
+	statusMgr = status.NewManager(fakeClient)
+	_, next, err = reconciler.reconcileKubeletCA(context.Background(), agent, statusMgr, false)
+	require.NoError(t, err)
+	assert.Equal(t, kubeletCAResyncInterval, next)
+	assert.Equal(t, "KubeletCAVerified", conditionReason(statusMgr))
+	assert.Equal(t, 1, dials)
+}
+
+func TestGenerateSpireAgentDaemonSet_KubeletCAConfigMap(t *testing.T) {
+	ztwim := &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{TrustDomain: "example.org", BundleConfigMap: "spire-bundle"},
+	}
+	spec := v1alpha1.SpireAgentSpec{
+		SocketPath: "/tmp/spire-agent/public",
+		WorkloadAttestors: &v1alpha1.WorkloadAttestors{
+			K8sEnabled:                    "true",
+			WorkloadAttestorsVerification: &v1alpha1.WorkloadAttestorsVerification{Type: utils.WorkloadAttestorVerificationTypeConfigMap},
+		},
+	}
+
+	ds := generateSpireAgentDaemonSet(spec, ztwim, "hash")
+
+	var volume *corev1.Volume
+	for i := range ds.Spec.Template.Spec.Volumes {
+		if ds.Spec.Template.Spec.Volumes[i].Name == "kubelet-ca" {
+			volume = &ds.Spec.Template.Spec.Volumes[i]
+		}
+	}
+	require.NotNil(t, volume, "kubelet-ca volume must be present")
+	require.NotNil(t, volume.ConfigMap, "kubelet-ca volume must come from a ConfigMap")
+	assert.Equal(t, utils.SpireAgentKubeletCAConfigMapName, volume.ConfigMap.Name)
+	assert.Nil(t, volume.HostPath)
+
+	var mountPath string
+	for _, mount := range ds.Spec.Template.Spec.Containers[0].VolumeMounts {
+		if mount.Name == "kubelet-ca" {
+			mountPath = mount.MountPath
+		}
+	}
+	assert.Equal(t, utils.SpireAgentKubeletCAMountPath, mountPath)
+}
@@ -0,0 +1,274 @@
+package spire_authority_operation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	localauthorityv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/localauthority/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+)
+
+const (
+	// Kubernetes-compliant condition names
+	OperationSucceeded = "OperationSucceeded"
+
+	// serverAPIRetryInterval is how often an unreachable SPIRE server API is retried
+	serverAPIRetryInterval = time.Minute
+	// authoritiesRefreshInterval is how often status.authorities is refreshed while the operation awaits
+	// confirmation, so that it is reviewed against the current state of the server
+	authoritiesRefreshInterval = time.Minute
+)
+
+// SpireAuthorityOperationReconciler reconciles a SpireAuthorityOperation object
+type SpireAuthorityOperationReconciler struct {
+	ctrlClient    customClient.CustomCtrlClient
+	ctx           context.Context
+	eventRecorder record.EventRecorder
+	log           logr.Logger
+	scheme        *runtime.Scheme
+	dialServerAPI spireapi.DialFunc
+}
+
+// New returns a new Reconciler instance.
+func New(mgr ctrl.Manager) (*SpireAuthorityOperationReconciler, error) {
+	c, err := customClient.NewCustomClient(mgr)
+	if err != nil {
+		return nil, err
+	}
+	return &SpireAuthorityOperationReconciler{
+		ctrlClient:    c,
+		ctx:           context.Background(),
+		eventRecorder: mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerSpireAuthorityOperationControllerName),
+		log:           ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerSpireAuthorityOperationControllerName),
+		scheme:        mgr.GetScheme(),
+		dialServerAPI: utils.ServerAPIDialer(c),
+	}, nil
+}
+
+func (r *SpireAuthorityOperationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.log.Info(fmt.Sprintf("reconciling %s", utils.ZeroTrustWorkloadIdentityManagerSpireAuthorityOperationControllerName), "name", req.Name)
+	var operation v1alpha1.SpireAuthorityOperation
+	if err := r.ctrlClient.Get(ctx, req.NamespacedName, &operation); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.log.Info("SpireAuthorityOperation resource not found. Ignoring since object must be deleted or not been created.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	statusMgr := status.NewManager(r.ctrlClient)
+	originalStatus := operation.Status.DeepCopy()
+	defer func() {
+		if !equality.Semantic.DeepEqual(originalStatus, &operation.Status) {
+			statusMgr.RequestStatusUpdate()
+		}
+		if err := statusMgr.ApplyStatus(ctx, &operation, func() *v1alpha1.ConditionalStatus {
+			return &operation.Status.ConditionalStatus
+		}); err != nil {
+			r.log.Error(err, "failed to update status")
+		}
+	}()
+
+	// An operation runs at most once, a new SpireAuthorityOperation is needed to run it again
+	if isCompleted(operation.Status.Phase) {
+		return ctrl.Result{}, nil
+	}
+	operation.Status.Phase = v1alpha1.AuthorityOperationPhasePending
+
+	apiClient, err := r.dialServerAPI()
+	if err != nil {
+		r.log.Error(err, "failed to connect to the SPIRE server API")
+		statusMgr.AddCondition(OperationSucceeded, "ServerAPIUnavailable", err.Error(), metav1.ConditionFalse)
+		return ctrl.Result{RequeueAfter: serverAPIRetryInterval}, nil
+	}
+	defer apiClient.Close()
+
+	authority := spireapi.Authority(operation.Spec.Authority)
+	states, err := apiClient.GetAuthorityState(ctx, authority)
+	if err != nil {
+		r.log.Error(err, "failed to read the local authority state", "authority", authority)
+		statusMgr.AddCondition(OperationSucceeded, "ServerAPIUnavailable", err.Error(), metav1.ConditionFalse)
+		return ctrl.Result{RequeueAfter: serverAPIRetryInterval}, nil
+	}
+	operation.Status.Authorities = localAuthorities(states)
+
+	if !utils.StringToBool(operation.Spec.Confirm) {
+		statusMgr.AddCondition(OperationSucceeded, "AwaitingConfirmation",
+			fmt.Sprintf("Review status.authorities and set spec.confirm to \"true\" to %s", describeOperation(&operation.Spec)),
+			metav1.ConditionFalse)
+		return ctrl.Result{RequeueAfter: authoritiesRefreshInterval}, nil
+	}
+
+	if err := checkOperation(&operation.Spec, states); err != nil {
+		r.complete(&operation, v1alpha1.AuthorityOperationPhaseRefused)
+		r.log.Info("Refused unsafe authority operation", "name", operation.Name, "reason", err.Error())
+		statusMgr.AddCondition(OperationSucceeded, "UnsafeOperation", fmt.Sprintf("Refused to %s: %v", describeOperation(&operation.Spec), err), metav1.ConditionFalse)
+		r.eventRecorder.Eventf(&operation, corev1.EventTypeWarning, "AuthorityOperationRefused", "Refused to %s: %v", describeOperation(&operation.Spec), err)
+		return ctrl.Result{}, nil
+	}
+
+	result, err := runOperation(ctx, apiClient, &operation.Spec)
+	if err != nil {
+		r.complete(&operation, v1alpha1.AuthorityOperationPhaseFailed)
+		r.log.Error(err, "authority operation failed", "name", operation.Name)
+		statusMgr.AddCondition(OperationSucceeded, "OperationFailed", err.Error(), metav1.ConditionFalse)
+		r.eventRecorder.Eventf(&operation, corev1.EventTypeWarning, "AuthorityOperationFailed", "Failed to %s: %v", describeOperation(&operation.Spec), err)
+		return ctrl.Result{}, nil
+	}
+
+	r.complete(&operation, v1alpha1.AuthorityOperationPhaseSucceeded)
+	if result != nil {
+		operation.Status.Result = localAuthority("", result)
+	}
+	// Refresh the authorities so that status reflects the rotation, the operation already succeeded
+	if states, err := apiClient.GetAuthorityState(ctx, authority); err == nil {
+		operation.Status.Authorities = localAuthorities(states)
+	}
+	message := fmt.Sprintf("Completed: %s", describeOperation(&operation.Spec))
+	if operation.Status.Result != nil {
+		message = fmt.Sprintf("%s, authority %s", message, operation.Status.Result.AuthorityID)
+	}
+	statusMgr.AddCondition(OperationSucceeded, v1alpha1.ReasonReady, message, metav1.ConditionTrue)
+	r.eventRecorder.Event(&operation, corev1.EventTypeNormal, "AuthorityOperationSucceeded", message)
+	r.log.Info("Completed authority operation", "name", operation.Name, "authority", operation.Spec.Authority, "action", operation.Spec.Action)
+	return ctrl.Result{}, nil
+}
+
+// complete records the final phase of the operation
+func (r *SpireAuthorityOperationReconciler) complete(operation *v1alpha1.SpireAuthorityOperation, phase v1alpha1.AuthorityOperationPhase) {
+	now := metav1.Now()
+	operation.Status.Phase = phase
+	operation.Status.CompletionTime = &now
+}
+
+// isCompleted reports whether the operation already ran or was refused
+func isCompleted(phase v1alpha1.AuthorityOperationPhase) bool {
+	switch phase {
+	case v1alpha1.AuthorityOperationPhaseSucceeded, v1alpha1.AuthorityOperationPhaseFailed, v1alpha1.AuthorityOperationPhaseRefused:
+		return true
+	}
+	return false
+}
+
+// checkOperation refuses operations that would leave the trust domain without a usable authority or that
+// target an authority which is not in the expected position of the rotation
+func checkOperation(spec *v1alpha1.SpireAuthorityOperationSpec, states *spireapi.AuthorityStates) error {
+	if spec.Action != v1alpha1.AuthorityActionPrepare && states.Active.GetAuthorityId() == spec.AuthorityID {
+		return fmt.Errorf("authority %s is the active authority", spec.AuthorityID)
+	}
+
+	switch spec.Action {
+	case v1alpha1.AuthorityActionPrepare:
+		if states.Prepared != nil {
+			return fmt.Errorf("authority %s is already prepared, activate it first", states.Prepared.AuthorityId)
+		}
+	case v1alpha1.AuthorityActionActivate:
+		if states.Prepared == nil {
+			return fmt.Errorf("there is no prepared authority, prepare one first")
+		}
+		if states.Prepared.AuthorityId != spec.AuthorityID {
+			return fmt.Errorf("authority %s is not the prepared authority %s", spec.AuthorityID, states.Prepared.AuthorityId)
+		}
+	case v1alpha1.AuthorityActionTaint, v1alpha1.AuthorityActionRevoke:
+		if states.Active == nil {
+			return fmt.Errorf("there is no active authority to replace authority %s", spec.AuthorityID)
+		}
+		if states.Old == nil {
+			return fmt.Errorf("there is no old authority, activate a prepared authority first")
+		}
+		if states.Old.AuthorityId != spec.AuthorityID {
+			return fmt.Errorf("authority %s is not the old authority %s", spec.AuthorityID, states.Old.AuthorityId)
+		}
+		// Revoking an authority that is still trusted would break workloads that were not rotated yet
+		if spec.Action == v1alpha1.AuthorityActionRevoke && !states.OldTainted {
+			return fmt.Errorf("authority %s is not tainted, taint it first", spec.AuthorityID)
+		}
+		if spec.Action == v1alpha1.AuthorityActionTaint && states.OldTainted {
+			return fmt.Errorf("authority %s is already tainted", spec.AuthorityID)
+		}
+	default:
+		return fmt.Errorf("unsupported action %q", spec.Action)
+	}
+	return nil
+}
+
+// runOperation calls the SPIRE server local authority API for the requested action
+func runOperation(ctx context.Context, apiClient spireapi.Client, spec *v1alpha1.SpireAuthorityOperationSpec) (*localauthorityv1.AuthorityState, error) {
+	authority := spireapi.Authority(spec.Authority)
+	switch spec.Action {
+	case v1alpha1.AuthorityActionPrepare:
+		return apiClient.PrepareAuthority(ctx, authority)
+	case v1alpha1.AuthorityActionActivate:
+		return apiClient.ActivateAuthority(ctx, authority, spec.AuthorityID)
+	case v1alpha1.AuthorityActionTaint:
+		return apiClient.TaintAuthority(ctx, authority, spec.AuthorityID)
+	case v1alpha1.AuthorityActionRevoke:
+		return apiClient.RevokeAuthority(ctx, authority, spec.AuthorityID)
+	}
+	return nil, fmt.Errorf("unsupported action %q", spec.Action)
+}
+
+// describeOperation returns a human readable description of the operation for conditions and events
+func describeOperation(spec *v1alpha1.SpireAuthorityOperationSpec) string {
+	if spec.Action == v1alpha1.AuthorityActionPrepare {
+		return fmt.Sprintf("prepare a new %s authority", spec.Authority)
+	}
+	return fmt.Sprintf("%s %s authority %s", strings.ToLower(string(spec.Action)), spec.Authority, spec.AuthorityID)
+}
+
+// localAuthorities converts the SPIRE authority state to the status representation
+func localAuthorities(states *spireapi.AuthorityStates) []v1alpha1.LocalAuthority {
+	var authorities []v1alpha1.LocalAuthority
+	for _, s := range []struct {
+		slot  v1alpha1.LocalAuthoritySlot
+		state *localauthorityv1.AuthorityState
+	}{
+		{v1alpha1.LocalAuthoritySlotActive, states.Active},
+		{v1alpha1.LocalAuthoritySlotPrepared, states.Prepared},
+		{v1alpha1.LocalAuthoritySlotOld, states.Old},
+	} {
+		if s.state != nil {
+			authority := localAuthority(s.slot, s.state)
+			authority.Tainted = s.slot == v1alpha1.LocalAuthoritySlotOld && states.OldTainted
+			authorities = append(authorities, *authority)
+		}
+	}
+	return authorities
+}
+
+func localAuthority(slot v1alpha1.LocalAuthoritySlot, state *localauthorityv1.AuthorityState) *v1alpha1.LocalAuthority {
+	authority := &v1alpha1.LocalAuthority{
+		Slot:                          slot,
+		AuthorityID:                   state.AuthorityId,
+		UpstreamAuthoritySubjectKeyID: state.UpstreamAuthoritySubjectKeyId,
+	}
+	if state.ExpiresAt != 0 {
+		expiresAt := metav1.NewTime(time.Unix(state.ExpiresAt, 0))
+		authority.ExpiresAt = &expiresAt
+	}
+	return authority
+}
+
+func (r *SpireAuthorityOperationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.SpireAuthorityOperation{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named(utils.ZeroTrustWorkloadIdentityManagerSpireAuthorityOperationControllerName).
+		Complete(r)
+}
@@ -0,0 +1,310 @@
+package spire_authority_operation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	localauthorityv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/localauthority/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	spireapifakes "github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi/fakes"
+)
+
+// newLocalAuthorityAPI returns a fake SPIRE server API serving the authority states, whose
+// authority changes return err
+func newLocalAuthorityAPI(states *spireapi.AuthorityStates, err error) *spireapifakes.FakeClient {
+	api := &spireapifakes.FakeClient{}
+	api.GetAuthorityStateReturns(states, nil)
+	api.PrepareAuthorityReturns(&localauthorityv1.AuthorityState{AuthorityId: "new", ExpiresAt: time.Now().Add(24 * time.Hour).Unix()}, err)
+	changed := func(_ context.Context, _ spireapi.Authority, id string) (*localauthorityv1.AuthorityState, error) {
+		return &localauthorityv1.AuthorityState{AuthorityId: id}, err
+	}
+	api.ActivateAuthorityStub = changed
+	api.TaintAuthorityStub = changed
+	api.RevokeAuthorityStub = changed
+	return api
+}
+
+// authorityChanges lists the authority changes requested from the fake SPIRE server API
+func authorityChanges(api *spireapifakes.FakeClient) []string {
+	var changes []string
+	for i := 0; i < api.PrepareAuthorityCallCount(); i++ {
+		_, authority := api.PrepareAuthorityArgsForCall(i)
+		changes = append(changes, "prepare "+string(authority))
+	}
+	for _, change := range []struct {
+		name        string
+		count       int
+		argsForCall func(int) (context.Context, spireapi.Authority, string)
+	}{
+		{"activate", api.ActivateAuthorityCallCount(), api.ActivateAuthorityArgsForCall},
+		{"taint", api.TaintAuthorityCallCount(), api.TaintAuthorityArgsForCall},
+		{"revoke", api.RevokeAuthorityCallCount(), api.RevokeAuthorityArgsForCall},
+	} {
+		for i := 0; i < change.count; i++ {
+			_, authority, id := change.argsForCall(i)
+			changes = append(changes, change.name+" "+string(authority)+" "+id)
+		}
+	}
+	return changes
+}
+
+func newTestReconciler(fakeClient *fakes.FakeCustomCtrlClient, api spireapi.Client) (*SpireAuthorityOperationReconciler, *record.FakeRecorder) {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	recorder := record.NewFakeRecorder(100)
+	return &SpireAuthorityOperationReconciler{
+		ctrlClient:    fakeClient,
+		ctx:           context.Background(),
+		eventRecorder: recorder,
+		log:           logr.Discard(),
+		scheme:        scheme,
+		dialServerAPI: func() (spireapi.Client, error) {
+			if api == nil {
+				return nil, errors.New("socket not available")
+			}
+			return api, nil
+		},
+	}, recorder
+}
+
+func stubOperation(fakeClient *fakes.FakeCustomCtrlClient, operation *v1alpha1.SpireAuthorityOperation) {
+	fakeClient.GetStub = func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+		if o, ok := obj.(*v1alpha1.SpireAuthorityOperation); ok {
+			operation.DeepCopyInto(o)
+		}
+		return nil
+	}
+}
+
+func newOperation(action v1alpha1.AuthorityAction, authorityID, confirm string) *v1alpha1.SpireAuthorityOperation {
+	return &v1alpha1.SpireAuthorityOperation{
+		ObjectMeta: metav1.ObjectMeta{Name: "rotate"},
+		Spec: v1alpha1.SpireAuthorityOperationSpec{
+			Authority:   v1alpha1.AuthorityTypeX509,
+			Action:      action,
+			AuthorityID: authorityID,
+			Confirm:     confirm,
+		},
+	}
+}
+
+func rotationStates() *spireapi.AuthorityStates {
+	return &spireapi.AuthorityStates{
+		Active:   &localauthorityv1.AuthorityState{AuthorityId: "active"},
+		Prepared: &localauthorityv1.AuthorityState{AuthorityId: "prepared"},
+		Old:      &localauthorityv1.AuthorityState{AuthorityId: "old"},
+	}
+}
+
+// taintedRotationStates returns rotationStates with the old authority tainted
+func taintedRotationStates() *spireapi.AuthorityStates {
+	states := rotationStates()
+	states.OldTainted = true
+	return states
+}
+
+func reconcileOperation(t *testing.T, fakeClient *fakes.FakeCustomCtrlClient, reconciler *SpireAuthorityOperationReconciler) (ctrl.Result, *v1alpha1.SpireAuthorityOperation) {
+	t.Helper()
+	result, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "rotate"}})
+	require.NoError(t, err)
+	require.Positive(t, fakeClient.StatusUpdateWithRetryCallCount())
+	_, obj, _ := fakeClient.StatusUpdateWithRetryArgsForCall(fakeClient.StatusUpdateWithRetryCallCount() - 1)
+	operation, ok := obj.(*v1alpha1.SpireAuthorityOperation)
+	require.True(t, ok)
+	return result, operation
+}
+
+func TestReconcile_AwaitsConfirmation(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api := newLocalAuthorityAPI(rotationStates(), nil)
+	reconciler, _ := newTestReconciler(fakeClient, api)
+	stubOperation(fakeClient, newOperation(v1alpha1.AuthorityActionActivate, "prepared", "false"))
+
+	result, operation := reconcileOperation(t, fakeClient, reconciler)
+
+	assert.Empty(t, authorityChanges(api))
+	// status.authorities keeps being refreshed while the operation is reviewed
+	assert.Equal(t, authoritiesRefreshInterval, result.RequeueAfter)
+	assert.Equal(t, v1alpha1.AuthorityOperationPhasePending, operation.Status.Phase)
+	require.Len(t, operation.Status.Authorities, 3)
+	assert.Equal(t, v1alpha1.LocalAuthoritySlotActive, operation.Status.Authorities[0].Slot)
+	assert.Equal(t, "active", operation.Status.Authorities[0].AuthorityID)
+	assert.False(t, operation.Status.Authorities[2].Tainted)
+	condition := apimeta.FindStatusCondition(operation.Status.Conditions, OperationSucceeded)
+	require.NotNil(t, condition)
+	assert.Equal(t, "AwaitingConfirmation", condition.Reason)
+}
+
+func TestReconcile_RunsConfirmedOperation(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api := newLocalAuthorityAPI(rotationStates(), nil)
+	reconciler, recorder := newTestReconciler(fakeClient, api)
+	stubOperation(fakeClient, newOperation(v1alpha1.AuthorityActionActivate, "prepared", "true"))
+
+	_, operation := reconcileOperation(t, fakeClient, reconciler)
+
+	assert.Equal(t, []string{"activate X509 prepared"}, authorityChanges(api))
+	assert.Equal(t, v1alpha1.AuthorityOperationPhaseSucceeded, operation.Status.Phase)
+	require.NotNil(t, operation.Status.Result)
+	assert.Equal(t, "prepared", operation.Status.Result.AuthorityID)
+	assert.NotNil(t, operation.Status.CompletionTime)
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "AuthorityOperationSucceeded")
+}
+
+func TestReconcile_RefusesUnsafeOperation(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api := newLocalAuthorityAPI(rotationStates(), nil)
+	reconciler, recorder := newTestReconciler(fakeClient, api)
+	stubOperation(fakeClient, newOperation(v1alpha1.AuthorityActionTaint, "active", "true"))
+
+	_, operation := reconcileOperation(t, fakeClient, reconciler)
+
+	assert.Empty(t, authorityChanges(api))
+	assert.Equal(t, v1alpha1.AuthorityOperationPhaseRefused, operation.Status.Phase)
+	condition := apimeta.FindStatusCondition(operation.Status.Conditions, OperationSucceeded)
+	require.NotNil(t, condition)
+	assert.Equal(t, "UnsafeOperation", condition.Reason)
+	assert.Contains(t, condition.Message, "active authority")
+	require.Len(t, recorder.Events, 1)
+	assert.Contains(t, <-recorder.Events, "Warning AuthorityOperationRefused")
+}
+
+func TestReconcile_RecordsServerFailure(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api := newLocalAuthorityAPI(taintedRotationStates(), errors.New("datastore failure"))
+	reconciler, _ := newTestReconciler(fakeClient, api)
+	stubOperation(fakeClient, newOperation(v1alpha1.AuthorityActionRevoke, "old", "true"))
+
+	_, operation := reconcileOperation(t, fakeClient, reconciler)
+
+	assert.Equal(t, []string{"revoke X509 old"}, authorityChanges(api))
+	assert.Equal(t, v1alpha1.AuthorityOperationPhaseFailed, operation.Status.Phase)
+	assert.Nil(t, operation.Status.Result)
+}
+
+func TestReconcile_ServerAPIUnavailable(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler, _ := newTestReconciler(fakeClient, nil)
+	stubOperation(fakeClient, newOperation(v1alpha1.AuthorityActionPrepare, "", "true"))
+
+	result, operation := reconcileOperation(t, fakeClient, reconciler)
+
+	assert.Equal(t, serverAPIRetryInterval, result.RequeueAfter)
+	assert.Equal(t, v1alpha1.AuthorityOperationPhasePending, operation.Status.Phase)
+}
+
+func TestReconcile_CompletedOperationIsNotRunAgain(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api := newLocalAuthorityAPI(rotationStates(), nil)
+	reconciler, _ := newTestReconciler(fakeClient, api)
+	operation := newOperation(v1alpha1.AuthorityActionPrepare, "", "true")
+	operation.Status.Phase = v1alpha1.AuthorityOperationPhaseSucceeded
+	stubOperation(fakeClient, operation)
+
+	_, err := reconciler.Reconcile(context.Background(), ctrl.Request{NamespacedName: types.NamespacedName{Name: "rotate"}})
+
+	require.NoError(t, err)
+	assert.Empty(t, authorityChanges(api))
+}
+
+func TestCheckOperation(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  v1alpha1.AuthorityAction
+		id      string
+		states  *spireapi.AuthorityStates
+		wantErr string
+	}{
+		{
+			name:   "prepare without prepared authority",
+			action: v1alpha1.AuthorityActionPrepare,
+			states: &spireapi.AuthorityStates{Active: &localauthorityv1.AuthorityState{AuthorityId: "active"}},
+		},
+		{
+			name:    "prepare with prepared authority",
+			action:  v1alpha1.AuthorityActionPrepare,
+			states:  rotationStates(),
+			wantErr: "already prepared",
+		},
+		{name: "activate prepared authority", action: v1alpha1.AuthorityActionActivate, id: "prepared", states: rotationStates()},
+		{
+			name:    "activate unknown authority",
+			action:  v1alpha1.AuthorityActionActivate,
+			id:      "other",
+			states:  rotationStates(),
+			wantErr: "not the prepared authority",
+		},
+		{
+			name:    "activate without prepared authority",
+			action:  v1alpha1.AuthorityActionActivate,
+			id:      "other",
+			states:  &spireapi.AuthorityStates{Active: &localauthorityv1.AuthorityState{AuthorityId: "active"}},
+			wantErr: "no prepared authority",
+		},
+		{name: "taint old authority", action: v1alpha1.AuthorityActionTaint, id: "old", states: rotationStates()},
+		{
+			name:    "taint tainted authority",
+			action:  v1alpha1.AuthorityActionTaint,
+			id:      "old",
+			states:  taintedRotationStates(),
+			wantErr: "already tainted",
+		},
+		{
+			name:    "taint active authority",
+			action:  v1alpha1.AuthorityActionTaint,
+			id:      "active",
+			states:  rotationStates(),
+			wantErr: "is the active authority",
+		},
+		{
+			name:    "taint without old authority",
+			action:  v1alpha1.AuthorityActionTaint,
+			id:      "prepared",
+			states:  &spireapi.AuthorityStates{Active: &localauthorityv1.AuthorityState{AuthorityId: "active"}},
+			wantErr: "no old authority",
+		},
+		{
+			name:    "revoke without active authority",
+			action:  v1alpha1.AuthorityActionRevoke,
+			id:      "old",
+			states:  &spireapi.AuthorityStates{Old: &localauthorityv1.AuthorityState{AuthorityId: "old"}},
+			wantErr: "no active authority",
+		},
+		{
+			name:    "revoke untainted authority",
+			action:  v1alpha1.AuthorityActionRevoke,
+			id:      "old",
+			states:  rotationStates(),
+			wantErr: "is not tainted",
+		},
+		{name: "revoke tainted old authority", action: v1alpha1.AuthorityActionRevoke, id: "old", states: taintedRotationStates()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOperation(&v1alpha1.SpireAuthorityOperationSpec{Action: tt.action, AuthorityID: tt.id}, tt.states)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
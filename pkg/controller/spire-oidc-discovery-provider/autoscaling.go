@@ -0,0 +1,297 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"fmt"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// HorizontalPodAutoscalerAvailable reports whether the OIDC discovery provider autoscaler is reconciled
+	HorizontalPodAutoscalerAvailable = "HorizontalPodAutoscalerAvailable"
+	// PodDisruptionBudgetAvailable reports whether the OIDC discovery provider PodDisruptionBudget is reconciled
+	PodDisruptionBudgetAvailable = "PodDisruptionBudgetAvailable"
+
+	oidcDeploymentName        = "spire-spiffe-oidc-discovery-provider"
+	defaultAutoscalingMinimum = 2
+)
+
+// autoscalingMinReplicas returns the minimum replicas of the autoscaler, applying the API default
+func autoscalingMinReplicas(autoscaling *v1alpha1.OIDCAutoscalingConfig) int32 {
+	if autoscaling.MinReplicas > 0 {
+		return autoscaling.MinReplicas
+	}
+	return defaultAutoscalingMinimum
+}
+
+// minimumReplicas returns the number of replicas the OIDC discovery provider never goes below
+func minimumReplicas(oidc *v1alpha1.SpireOIDCDiscoveryProvider) int32 {
+	if oidc.Spec.Autoscaling != nil {
+		return autoscalingMinReplicas(oidc.Spec.Autoscaling)
+	}
+	if oidc.Spec.ReplicaCount > 0 {
+		return int32(oidc.Spec.ReplicaCount)
+	}
+	return 1
+}
+
+// validateAutoscaling validates the autoscaling configuration
+func validateAutoscaling(oidc *v1alpha1.SpireOIDCDiscoveryProvider) error {
+	autoscaling := oidc.Spec.Autoscaling
+	if autoscaling == nil {
+		return nil
+	}
+	if autoscalingMinReplicas(autoscaling) > autoscaling.MaxReplicas {
+		return fmt.Errorf("autoscaling.minReplicas %d exceeds autoscaling.maxReplicas %d", autoscalingMinReplicas(autoscaling), autoscaling.MaxReplicas)
+	}
+	if autoscaling.TargetCPUUtilizationPercentage == nil && autoscaling.RequestRate == nil {
+		return fmt.Errorf("autoscaling requires targetCPUUtilizationPercentage or requestRate")
+	}
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		if oidc.Spec.Resources == nil || oidc.Spec.Resources.Requests.Cpu().IsZero() {
+			return fmt.Errorf("autoscaling.targetCPUUtilizationPercentage requires resources.requests.cpu to be set")
+		}
+	}
+	if autoscaling.RequestRate != nil && autoscaling.RequestRate.AverageValue.Sign() <= 0 {
+		return fmt.Errorf("autoscaling.requestRate.averageValue must be positive")
+	}
+	return nil
+}
+
+// generateHorizontalPodAutoscaler creates the HorizontalPodAutoscaler for the OIDC discovery provider Deployment
+func generateHorizontalPodAutoscaler(oidc *v1alpha1.SpireOIDCDiscoveryProvider) *autoscalingv2.HorizontalPodAutoscaler {
+	autoscaling := oidc.Spec.Autoscaling
+
+	var metrics []autoscalingv2.MetricSpec
+	if autoscaling.TargetCPUUtilizationPercentage != nil {
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.ResourceMetricSourceType,
+			Resource: &autoscalingv2.ResourceMetricSource{
+				Name: corev1.ResourceCPU,
+				Target: autoscalingv2.MetricTarget{
+					Type:               autoscalingv2.UtilizationMetricType,
+					AverageUtilization: ptr.To(*autoscaling.TargetCPUUtilizationPercentage),
+				},
+			},
+		})
+	}
+	if autoscaling.RequestRate != nil {
+		metrics = append(metrics, autoscalingv2.MetricSpec{
+			Type: autoscalingv2.PodsMetricSourceType,
+			Pods: &autoscalingv2.PodsMetricSource{
+				Metric: autoscalingv2.MetricIdentifier{Name: autoscaling.RequestRate.MetricName},
+				Target: autoscalingv2.MetricTarget{
+					Type:         autoscalingv2.AverageValueMetricType,
+					AverageValue: ptr.To(autoscaling.RequestRate.AverageValue.DeepCopy()),
+				},
+			},
+		})
+	}
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oidcDeploymentName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels),
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       oidcDeploymentName,
+			},
+			MinReplicas: ptr.To(autoscalingMinReplicas(autoscaling)),
+			MaxReplicas: autoscaling.MaxReplicas,
+			Metrics:     metrics,
+		},
+	}
+}
+
+// generatePodDisruptionBudget creates the PodDisruptionBudget that keeps the OIDC issuer online during drains
+func generatePodDisruptionBudget(oidc *v1alpha1.SpireOIDCDiscoveryProvider) *policyv1.PodDisruptionBudget {
+	labels := utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels)
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oidcDeploymentName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"app.kubernetes.io/name":      labels["app.kubernetes.io/name"],
+					"app.kubernetes.io/instance":  labels["app.kubernetes.io/instance"],
+					"app.kubernetes.io/component": labels["app.kubernetes.io/component"],
+				},
+			},
+		},
+	}
+}
+
+// reconcileHorizontalPodAutoscaler creates, updates or removes the HorizontalPodAutoscaler
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileHorizontalPodAutoscaler(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) error {
+	if oidc.Spec.Autoscaling == nil {
+		statusMgr.RemoveCondition(HorizontalPodAutoscalerAvailable)
+		return r.deleteScalingResource(ctx, &autoscalingv2.HorizontalPodAutoscaler{}, HorizontalPodAutoscalerAvailable, statusMgr)
+	}
+
+	desired := generateHorizontalPodAutoscaler(oidc)
+	if err := controllerutil.SetControllerReference(oidc, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on HorizontalPodAutoscaler")
+		statusMgr.AddCondition(HorizontalPodAutoscalerAvailable, "HorizontalPodAutoscalerCreationFailed",
+			fmt.Sprintf("Failed to set owner reference on HorizontalPodAutoscaler: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	existing := &autoscalingv2.HorizontalPodAutoscaler{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get HorizontalPodAutoscaler")
+			statusMgr.AddCondition(HorizontalPodAutoscalerAvailable, "HorizontalPodAutoscalerRetrievalFailed",
+				fmt.Sprintf("Failed to get HorizontalPodAutoscaler: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, HorizontalPodAutoscalerAvailable); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create HorizontalPodAutoscaler")
+			statusMgr.AddCondition(HorizontalPodAutoscalerAvailable, "HorizontalPodAutoscalerCreationFailed",
+				fmt.Sprintf("Failed to create HorizontalPodAutoscaler: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.log.Info("Created HorizontalPodAutoscaler", "name", desired.Name, "namespace", desired.Namespace)
+	} else if !equality.Semantic.DeepEqual(existing.Spec.ScaleTargetRef, desired.Spec.ScaleTargetRef) ||
+		!equality.Semantic.DeepEqual(existing.Spec.MinReplicas, desired.Spec.MinReplicas) ||
+		existing.Spec.MaxReplicas != desired.Spec.MaxReplicas ||
+		!equality.Semantic.DeepEqual(existing.Spec.Metrics, desired.Spec.Metrics) ||
+		!utils.LabelsMatch(existing.Labels, desired.Labels) {
+		if createOnlyMode {
+			r.log.V(1).Info("HorizontalPodAutoscaler exists, skipping update due to create-only mode", "name", desired.Name)
+		} else {
+			desired.ResourceVersion = existing.ResourceVersion
+			desired.Status = existing.Status
+			if err := r.ctrlClient.Update(ctx, desired); err != nil {
+				r.log.Error(err, "failed to update HorizontalPodAutoscaler")
+				statusMgr.AddCondition(HorizontalPodAutoscalerAvailable, "HorizontalPodAutoscalerUpdateFailed",
+					fmt.Sprintf("Failed to update HorizontalPodAutoscaler: %v", err),
+					metav1.ConditionFalse)
+				return err
+			}
+			r.log.Info("Updated HorizontalPodAutoscaler", "name", desired.Name, "namespace", desired.Namespace)
+		}
+	}
+
+	statusMgr.AddCondition(HorizontalPodAutoscalerAvailable, v1alpha1.ReasonReady,
+		fmt.Sprintf("Scaling between %d and %d replicas", autoscalingMinReplicas(oidc.Spec.Autoscaling), oidc.Spec.Autoscaling.MaxReplicas),
+		metav1.ConditionTrue)
+	return nil
+}
+
+// reconcilePodDisruptionBudget creates or updates the PodDisruptionBudget when at least two replicas
+// are guaranteed, and removes it otherwise since a single replica could never be drained
+func (r *SpireOidcDiscoveryProviderReconciler) reconcilePodDisruptionBudget(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) error {
+	if minimumReplicas(oidc) < 2 {
+		statusMgr.RemoveCondition(PodDisruptionBudgetAvailable)
+		return r.deleteScalingResource(ctx, &policyv1.PodDisruptionBudget{}, PodDisruptionBudgetAvailable, statusMgr)
+	}
+
+	desired := generatePodDisruptionBudget(oidc)
+	if err := controllerutil.SetControllerReference(oidc, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on PodDisruptionBudget")
+		statusMgr.AddCondition(PodDisruptionBudgetAvailable, "PodDisruptionBudgetCreationFailed",
+			fmt.Sprintf("Failed to set owner reference on PodDisruptionBudget: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	existing := &policyv1.PodDisruptionBudget{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get PodDisruptionBudget")
+			statusMgr.AddCondition(PodDisruptionBudgetAvailable, "PodDisruptionBudgetRetrievalFailed",
+				fmt.Sprintf("Failed to get PodDisruptionBudget: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, PodDisruptionBudgetAvailable); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create PodDisruptionBudget")
+			statusMgr.AddCondition(PodDisruptionBudgetAvailable, "PodDisruptionBudgetCreationFailed",
+				fmt.Sprintf("Failed to create PodDisruptionBudget: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.log.Info("Created PodDisruptionBudget", "name", desired.Name, "namespace", desired.Namespace)
+	} else if !equality.Semantic.DeepEqual(existing.Spec.MaxUnavailable, desired.Spec.MaxUnavailable) ||
+		!equality.Semantic.DeepEqual(existing.Spec.MinAvailable, desired.Spec.MinAvailable) ||
+		!equality.Semantic.DeepEqual(existing.Spec.Selector, desired.Spec.Selector) ||
+		!utils.LabelsMatch(existing.Labels, desired.Labels) {
+		if createOnlyMode {
+			r.log.V(1).Info("PodDisruptionBudget exists, skipping update due to create-only mode", "name", desired.Name)
+		} else {
+			desired.ResourceVersion = existing.ResourceVersion
+			desired.Status = existing.Status
+			if err := r.ctrlClient.Update(ctx, desired); err != nil {
+				r.log.Error(err, "failed to update PodDisruptionBudget")
+				statusMgr.AddCondition(PodDisruptionBudgetAvailable, "PodDisruptionBudgetUpdateFailed",
+					fmt.Sprintf("Failed to update PodDisruptionBudget: %v", err),
+					metav1.ConditionFalse)
+				return err
+			}
+			r.log.Info("Updated PodDisruptionBudget", "name", desired.Name, "namespace", desired.Namespace)
+		}
+	}
+
+	statusMgr.AddCondition(PodDisruptionBudgetAvailable, v1alpha1.ReasonReady,
+		"At most one OIDC discovery provider replica can be disrupted at a time",
+		metav1.ConditionTrue)
+	return nil
+}
+
+// deleteScalingResource removes an operator-managed HorizontalPodAutoscaler or PodDisruptionBudget that is no longer needed
+func (r *SpireOidcDiscoveryProviderReconciler) deleteScalingResource(ctx context.Context, obj client.Object, conditionType string, statusMgr *status.Manager) error {
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: oidcDeploymentName, Namespace: utils.GetOperatorNamespace()}, obj)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		if utils.CheckResourceConflict(obj) != nil {
+			// Not created by the operator, leave it alone
+			return nil
+		}
+		err = r.ctrlClient.Delete(ctx, obj)
+	}
+	if err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "failed to delete scaling resource", "condition", conditionType)
+		statusMgr.AddCondition(conditionType, "CleanupFailed",
+			fmt.Sprintf("Failed to delete %s: %v", oidcDeploymentName, err),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Deleted scaling resource", "condition", conditionType, "name", oidcDeploymentName)
+	return nil
+}
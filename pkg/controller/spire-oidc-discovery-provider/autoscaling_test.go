@@ -0,0 +1,273 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func createAutoscalingTestOIDC(autoscaling *v1alpha1.OIDCAutoscalingConfig) *v1alpha1.SpireOIDCDiscoveryProvider {
+	oidc := createDeploymentTestOIDCCR()
+	oidc.Spec.Autoscaling = autoscaling
+	oidc.Spec.Resources = &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}
+	return oidc
+}
+
+func TestValidateAutoscaling(t *testing.T) {
+	tests := []struct {
+		name        string
+		autoscaling *v1alpha1.OIDCAutoscalingConfig
+		noResources bool
+		wantErr     string
+	}{
+		{name: "autoscaling disabled"},
+		{name: "cpu target", autoscaling: &v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 4, TargetCPUUtilizationPercentage: ptr.To(int32(70))}},
+		{
+			name: "request rate target without cpu requests",
+			autoscaling: &v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 4, RequestRate: &v1alpha1.OIDCRequestRateTarget{
+				MetricName: "http_requests_per_second", AverageValue: resource.MustParse("50"),
+			}},
+			noResources: true,
+		},
+		{
+			name:        "defaulted minimum above maximum",
+			autoscaling: &v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 1, TargetCPUUtilizationPercentage: ptr.To(int32(70))},
+			wantErr:     "exceeds autoscaling.maxReplicas",
+		},
+		{
+			name:        "no target",
+			autoscaling: &v1alpha1.OIDCAutoscalingConfig{MinReplicas: 2, MaxReplicas: 4},
+			wantErr:     "requires targetCPUUtilizationPercentage or requestRate",
+		},
+		{
+			name:        "cpu target without cpu requests",
+			autoscaling: &v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 4, TargetCPUUtilizationPercentage: ptr.To(int32(70))},
+			noResources: true,
+			wantErr:     "requires resources.requests.cpu",
+		},
+		{
+			name: "zero request rate",
+			autoscaling: &v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 4, RequestRate: &v1alpha1.OIDCRequestRateTarget{
+				MetricName: "http_requests_per_second",
+			}},
+			wantErr: "must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oidc := createAutoscalingTestOIDC(tt.autoscaling)
+			if tt.noResources {
+				oidc.Spec.Resources = nil
+			}
+			err := validateAutoscaling(oidc)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGenerateHorizontalPodAutoscaler(t *testing.T) {
+	oidc := createAutoscalingTestOIDC(&v1alpha1.OIDCAutoscalingConfig{
+		MaxReplicas:                    6,
+		TargetCPUUtilizationPercentage: ptr.To(int32(75)),
+		RequestRate: &v1alpha1.OIDCRequestRateTarget{
+			MetricName:   "http_requests_per_second",
+			AverageValue: resource.MustParse("100"),
+		},
+	})
+
+	hpa := generateHorizontalPodAutoscaler(oidc)
+
+	assert.Equal(t, "spire-spiffe-oidc-discovery-provider", hpa.Name)
+	assert.Equal(t, utils.GetOperatorNamespace(), hpa.Namespace)
+	assert.Equal(t, autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "spire-spiffe-oidc-discovery-provider"}, hpa.Spec.ScaleTargetRef)
+	assert.Equal(t, ptr.To(int32(2)), hpa.Spec.MinReplicas)
+	assert.Equal(t, int32(6), hpa.Spec.MaxReplicas)
+	require.Len(t, hpa.Spec.Metrics, 2)
+	assert.Equal(t, corev1.ResourceCPU, hpa.Spec.Metrics[0].Resource.Name)
+	assert.Equal(t, ptr.To(int32(75)), hpa.Spec.Metrics[0].Resource.Target.AverageUtilization)
+	assert.Equal(t, "http_requests_per_second", hpa.Spec.Metrics[1].Pods.Metric.Name)
+	assert.Equal(t, "100", hpa.Spec.Metrics[1].Pods.Target.AverageValue.String())
+}
+
+func TestGeneratePodDisruptionBudget(t *testing.T) {
+	oidc := createAutoscalingTestOIDC(nil)
+	oidc.Spec.Labels = map[string]string{"team": "identity"}
+
+	pdb := generatePodDisruptionBudget(oidc)
+
+	assert.Equal(t, "spire-spiffe-oidc-discovery-provider", pdb.Name)
+	assert.Equal(t, "identity", pdb.Labels["team"])
+	assert.Equal(t, 1, pdb.Spec.MaxUnavailable.IntValue())
+	assert.Nil(t, pdb.Spec.MinAvailable)
+	assert.NotContains(t, pdb.Spec.Selector.MatchLabels, "team")
+	podLabels := generateDeployment(oidc, "test-hash").Spec.Template.Labels
+	for key, value := range pdb.Spec.Selector.MatchLabels {
+		assert.Equal(t, value, podLabels[key], "selector label %s must match the pod template", key)
+	}
+}
+
+func TestReconcileHorizontalPodAutoscaler(t *testing.T) {
+	t.Run("creates autoscaler", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newDeploymentTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createAutoscalingTestOIDC(&v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 4, TargetCPUUtilizationPercentage: ptr.To(int32(70))})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileHorizontalPodAutoscaler(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		hpa, ok := created.(*autoscalingv2.HorizontalPodAutoscaler)
+		require.True(t, ok, "expected a HorizontalPodAutoscaler, got %T", created)
+		require.Len(t, hpa.OwnerReferences, 1)
+	})
+
+	t.Run("leaves an up to date autoscaler alone", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newDeploymentTestReconciler(fakeClient)
+		oidc := createAutoscalingTestOIDC(&v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 4, TargetCPUUtilizationPercentage: ptr.To(int32(70))})
+		fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			generateHorizontalPodAutoscaler(oidc).DeepCopyInto(obj.(*autoscalingv2.HorizontalPodAutoscaler))
+			return nil
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileHorizontalPodAutoscaler(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Zero(t, fakeClient.CreateCallCount())
+		assert.Zero(t, fakeClient.UpdateCallCount())
+	})
+
+	t.Run("removing autoscaling deletes the managed autoscaler", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newDeploymentTestReconciler(fakeClient)
+		fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			obj.SetName(key.Name)
+			obj.SetNamespace(key.Namespace)
+			obj.SetLabels(utils.SpireOIDCDiscoveryProviderLabels(nil))
+			return nil
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileHorizontalPodAutoscaler(context.Background(), createAutoscalingTestOIDC(nil), statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.DeleteCallCount())
+		_, deleted, _ := fakeClient.DeleteArgsForCall(0)
+		assert.IsType(t, &autoscalingv2.HorizontalPodAutoscaler{}, deleted)
+	})
+
+	t.Run("does not delete a foreign autoscaler", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newDeploymentTestReconciler(fakeClient)
+		fakeClient.GetReturns(nil)
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileHorizontalPodAutoscaler(context.Background(), createAutoscalingTestOIDC(nil), statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Zero(t, fakeClient.DeleteCallCount())
+	})
+}
+
+func TestReconcilePodDisruptionBudget(t *testing.T) {
+	t.Run("creates budget for two replicas", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newDeploymentTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createAutoscalingTestOIDC(nil)
+		oidc.Spec.ReplicaCount = 2
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcilePodDisruptionBudget(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		assert.IsType(t, &policyv1.PodDisruptionBudget{}, created)
+	})
+
+	t.Run("creates budget for the default autoscaling minimum", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newDeploymentTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createAutoscalingTestOIDC(&v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 4, TargetCPUUtilizationPercentage: ptr.To(int32(70))})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcilePodDisruptionBudget(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, fakeClient.CreateCallCount())
+	})
+
+	t.Run("single replica deletes the managed budget", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newDeploymentTestReconciler(fakeClient)
+		fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			obj.SetName(key.Name)
+			obj.SetNamespace(key.Namespace)
+			obj.SetLabels(utils.SpireOIDCDiscoveryProviderLabels(nil))
+			return nil
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcilePodDisruptionBudget(context.Background(), createAutoscalingTestOIDC(nil), statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Zero(t, fakeClient.CreateCallCount())
+		require.Equal(t, 1, fakeClient.DeleteCallCount())
+		_, deleted, _ := fakeClient.DeleteArgsForCall(0)
+		assert.IsType(t, &policyv1.PodDisruptionBudget{}, deleted)
+	})
+}
+
+func TestReconcileDeployment_AutoscalingKeepsReplicas(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newDeploymentTestReconciler(fakeClient)
+
+	oidc := createAutoscalingTestOIDC(&v1alpha1.OIDCAutoscalingConfig{MaxReplicas: 6, TargetCPUUtilizationPercentage: ptr.To(int32(70))})
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		existing := generateDeployment(oidc, "old-hash")
+		existing.Spec.Replicas = ptr.To(int32(5))
+		existing.DeepCopyInto(obj.(*appsv1.Deployment))
+		return nil
+	}
+	statusMgr := status.NewManager(fakeClient)
+
+	err := reconciler.reconcileDeployment(context.Background(), oidc, statusMgr, false, "new-hash")
+
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.UpdateCallCount())
+	_, updated, _ := fakeClient.UpdateArgsForCall(0)
+	assert.Equal(t, ptr.To(int32(5)), updated.(*appsv1.Deployment).Spec.Replicas)
+}
@@ -0,0 +1,260 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// CertificateReady reports whether the provider serves a valid ACME certificate for the issuer host
+	CertificateReady = "CertificateReady"
+
+	// defaultAcmeDirectoryURL is the Let's Encrypt production directory, matching the API default
+	defaultAcmeDirectoryURL = "https://acme-v02.api.letsencrypt.org/directory"
+	// acmeCacheDir holds the ACME account key and certificates of the provider
+	acmeCacheDir = "/run/spire/oidc/acme"
+	// acmeCacheClaimName is the PersistentVolumeClaim mounted at acmeCacheDir, which keeps the
+	// certificate across restarts instead of ordering a new one each time
+	acmeCacheClaimName = "spire-spiffe-oidc-discovery-provider-acme"
+	acmeCacheSize      = "1Gi"
+	// acmeHTTPSPort is the port the provider serves on with ACME, required by the TLS-ALPN-01 challenge
+	acmeHTTPSPort = 443
+	// servingCertHTTPSPort is the port the provider serves on with the service CA certificate
+	servingCertHTTPSPort = 8443
+
+	certificateDialTimeout = 30 * time.Second
+	// certificateCheckTimeout bounds a background check, including the TLS handshake
+	certificateCheckTimeout = 45 * time.Second
+	// certificatePendingRetry is how soon the certificate is checked again while it is not issued
+	certificatePendingRetry = time.Minute
+	// certificateCheckInterval is how often an issued certificate is checked for renewal
+	certificateCheckInterval = time.Hour
+	// certificateRenewalDeadline is the remaining lifetime under which a certificate that was not
+	// renewed is reported. The provider renews certificates 30 days before they expire.
+	certificateRenewalDeadline = 10 * 24 * time.Hour
+)
+
+// CertificateCheckFunc returns the publicly trusted certificate served at addr for serverName
+type CertificateCheckFunc func(ctx context.Context, addr, serverName string) (*x509.Certificate, error)
+
+// isACMEEnabled reports whether the provider obtains its certificate from an ACME CA
+func isACMEEnabled(oidc *v1alpha1.SpireOIDCDiscoveryProvider) bool {
+	return oidc.Spec.Acme != nil
+}
+
+// validateACME validates the ACME configuration of the provider
+func validateACME(oidc *v1alpha1.SpireOIDCDiscoveryProvider) error {
+	acme := oidc.Spec.Acme
+	if acme == nil {
+		return nil
+	}
+	if acme.DirectoryUrl != "" && !strings.HasPrefix(acme.DirectoryUrl, "https://") {
+		return fmt.Errorf("acme.directoryUrl must use https://, got %s", acme.DirectoryUrl)
+	}
+	if acme.Email == "" {
+		return fmt.Errorf("acme.email is required")
+	}
+	if !utils.StringToBool(acme.TosAccepted) {
+		return fmt.Errorf("acme.tosAccepted must be true to use ACME")
+	}
+	if oidc.Spec.ExternalSecretRef != "" {
+		return fmt.Errorf("acme and externalSecretRef are mutually exclusive")
+	}
+	if !strings.HasPrefix(strings.ToLower(oidc.Spec.JwtIssuer), "https://") {
+		return fmt.Errorf("acme requires an https jwtIssuer, got %s", oidc.Spec.JwtIssuer)
+	}
+	// Each replica would order its own certificate, and the TLS-ALPN-01 challenge passed through the
+	// Route could reach a replica other than the one that ordered it
+	if oidc.Spec.Autoscaling != nil {
+		return fmt.Errorf("acme and autoscaling are mutually exclusive, the provider must run a single replica")
+	}
+	if oidc.Spec.ReplicaCount > 1 {
+		return fmt.Errorf("acme requires replicaCount 1, got %d", oidc.Spec.ReplicaCount)
+	}
+	return nil
+}
+
+// fetchTrustedCertificate connects to addr and verifies the certificate served for serverName against roots,
+// or the system roots when roots is nil
+func fetchTrustedCertificate(ctx context.Context, addr, serverName string, roots *x509.CertPool) (*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: certificateDialTimeout},
+		Config: &tls.Config{
+			ServerName: serverName,
+			MinVersion: tls.VersionTLS12,
+			// The chain is verified below so that an untrusted certificate is reported with its issuer
+			InsecureSkipVerify: true, //nolint:gosec
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s served no certificate", addr)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         roots,
+		Intermediates: intermediates,
+	}); err != nil {
+		return nil, fmt.Errorf("certificate issued by %q for %s is not publicly trusted: %w", chain[0].Issuer.CommonName, serverName, err)
+	}
+	return chain[0], nil
+}
+
+// checkPublicCertificate returns the publicly trusted certificate served at addr for serverName
+func checkPublicCertificate(ctx context.Context, addr, serverName string) (*x509.Certificate, error) {
+	return fetchTrustedCertificate(ctx, addr, serverName, nil)
+}
+
+// generateACMECacheClaim returns the PersistentVolumeClaim holding the ACME account key and certificates
+func generateACMECacheClaim(oidc *v1alpha1.SpireOIDCDiscoveryProvider) *corev1.PersistentVolumeClaim {
+	claim := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      acmeCacheClaimName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(acmeCacheSize)},
+			},
+		},
+	}
+	if oidc.Spec.Acme.StorageClassName != "" {
+		claim.Spec.StorageClassName = &oidc.Spec.Acme.StorageClassName
+	}
+	return claim
+}
+
+// reconcileACMECache creates the PersistentVolumeClaim of the ACME cache, and removes it once ACME is
+// disabled. The claim is never updated since its spec is immutable once bound.
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileACMECache(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) error {
+	key := types.NamespacedName{Name: acmeCacheClaimName, Namespace: utils.GetOperatorNamespace()}
+	existing := &corev1.PersistentVolumeClaim{}
+	err := r.ctrlClient.Get(ctx, key, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "failed to get ACME cache PersistentVolumeClaim")
+		statusMgr.AddCondition(CertificateReady, "ACMECacheRetrievalFailed",
+			fmt.Sprintf("Failed to get PersistentVolumeClaim %s: %v", acmeCacheClaimName, err),
+			metav1.ConditionFalse)
+		return err
+	}
+	found := err == nil
+
+	if !isACMEEnabled(oidc) {
+		if !found || utils.CheckResourceConflict(existing) != nil {
+			return nil
+		}
+		if err := r.ctrlClient.Delete(ctx, existing); err != nil && !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to delete ACME cache PersistentVolumeClaim")
+			return fmt.Errorf("failed to delete PersistentVolumeClaim %s: %w", acmeCacheClaimName, err)
+		}
+		r.log.Info("Deleted ACME cache PersistentVolumeClaim", "name", acmeCacheClaimName)
+		return nil
+	}
+	if found {
+		return nil
+	}
+
+	desired := generateACMECacheClaim(oidc)
+	if err := controllerutil.SetControllerReference(oidc, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on ACME cache PersistentVolumeClaim")
+		statusMgr.AddCondition(CertificateReady, "ACMECacheCreationFailed", err.Error(), metav1.ConditionFalse)
+		return err
+	}
+	if err := r.ctrlClient.Create(ctx, desired); err != nil {
+		if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, CertificateReady); conflictErr != nil {
+			return conflictErr
+		}
+		r.log.Error(err, "failed to create ACME cache PersistentVolumeClaim")
+		statusMgr.AddCondition(CertificateReady, "ACMECacheCreationFailed",
+			fmt.Sprintf("Failed to create PersistentVolumeClaim %s: %v", acmeCacheClaimName, err),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Created ACME cache PersistentVolumeClaim", "name", acmeCacheClaimName)
+	return nil
+}
+
+// reconcileCertificate reports the issuance and renewal of the ACME certificate in the CertificateReady
+// condition. The certificate is fetched in the background so that an unreachable provider does not
+// hold up Reconcile. It returns when the certificate has to be checked again.
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileCertificate(oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) time.Duration {
+	if !isACMEEnabled(oidc) {
+		statusMgr.RemoveCondition(CertificateReady)
+		r.certificateCheck.Reset()
+		return 0
+	}
+
+	host, err := utils.StripProtocolFromJWTIssuer(oidc.Spec.JwtIssuer)
+	if err != nil {
+		statusMgr.AddCondition(CertificateReady, "InvalidIssuer", err.Error(), metav1.ConditionFalse)
+		return 0
+	}
+	// The certificate is for the issuer host, without the issuer path
+	host, _, _ = strings.Cut(host, "/")
+
+	// Connecting through the Service with the issuer host as SNI also makes the provider request
+	// the certificate if it has none yet
+	addr := fmt.Sprintf("%s.%s.svc:%d", oidcServiceName, utils.GetOperatorNamespace(), oidcServicePort)
+	result := r.certificateCheck.Poll(addr+"/"+host, certificatePendingRetry, r.startBackground, func() (*x509.Certificate, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), certificateCheckTimeout)
+		defer cancel()
+		return r.checkCertificate(ctx, addr, host)
+	})
+	if result == nil {
+		statusMgr.AddCondition(CertificateReady, "CertificateCheckPending",
+			fmt.Sprintf("Checking the ACME certificate for %s", host),
+			metav1.ConditionUnknown)
+		return utils.BackgroundCheckPollInterval
+	}
+	cert, err := result.Value, result.Err
+	if err != nil {
+		r.log.Info("ACME certificate is not ready", "host", host, "reason", err.Error())
+		statusMgr.AddCondition(CertificateReady, "CertificatePending",
+			fmt.Sprintf("Waiting for the ACME certificate for %s: %v", host, err),
+			metav1.ConditionFalse)
+		return certificatePendingRetry
+	}
+
+	remaining := time.Until(cert.NotAfter)
+	if remaining < certificateRenewalDeadline {
+		statusMgr.AddCondition(CertificateReady, "CertificateRenewalOverdue",
+			fmt.Sprintf("Certificate for %s issued by %s expires at %s and has not been renewed",
+				host, cert.Issuer.CommonName, cert.NotAfter.UTC().Format(time.RFC3339)),
+			metav1.ConditionFalse)
+		return certificatePendingRetry
+	}
+
+	statusMgr.AddCondition(CertificateReady, "CertificateIssued",
+		fmt.Sprintf("Certificate for %s issued by %s is valid until %s",
+			host, cert.Issuer.CommonName, cert.NotAfter.UTC().Format(time.RFC3339)),
+		metav1.ConditionTrue)
+	return min(certificateCheckInterval, remaining-certificateRenewalDeadline)
+}
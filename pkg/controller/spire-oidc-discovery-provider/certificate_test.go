@@ -0,0 +1,284 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func createAcmeTestOIDC() *v1alpha1.SpireOIDCDiscoveryProvider {
+	return &v1alpha1.SpireOIDCDiscoveryProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+			JwtIssuer: "https://oidc.example.com/tenant",
+			Acme:      &v1alpha1.OIDCAcmeConfig{Email: "admin@example.com", TosAccepted: "true"},
+		},
+	}
+}
+
+func certificateReadyCondition(t *testing.T, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) *metav1.Condition {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), oidc, func() *v1alpha1.ConditionalStatus {
+		return &oidc.Status.ConditionalStatus
+	}))
+	return apimeta.FindStatusCondition(oidc.Status.Conditions, CertificateReady)
+}
+
+func TestValidateACME(t *testing.T) {
+	tests := []struct {
+		name    string
+		mutate  func(*v1alpha1.SpireOIDCDiscoveryProvider)
+		wantErr string
+	}{
+		{name: "valid", mutate: func(*v1alpha1.SpireOIDCDiscoveryProvider) {}},
+		{name: "disabled", mutate: func(oidc *v1alpha1.SpireOIDCDiscoveryProvider) { oidc.Spec.Acme = nil }},
+		{
+			name:    "terms of service not accepted",
+			mutate:  func(oidc *v1alpha1.SpireOIDCDiscoveryProvider) { oidc.Spec.Acme.TosAccepted = "false" },
+			wantErr: "tosAccepted must be true",
+		},
+		{
+			name: "http directory",
+			mutate: func(oidc *v1alpha1.SpireOIDCDiscoveryProvider) {
+				oidc.Spec.Acme.DirectoryUrl = "http://acme.example.com/directory"
+			},
+			wantErr: "directoryUrl must use https://",
+		},
+		{
+			name:    "external certificate",
+			mutate:  func(oidc *v1alpha1.SpireOIDCDiscoveryProvider) { oidc.Spec.ExternalSecretRef = "oidc-tls" },
+			wantErr: "mutually exclusive",
+		},
+		{
+			name:    "http issuer",
+			mutate:  func(oidc *v1alpha1.SpireOIDCDiscoveryProvider) { oidc.Spec.JwtIssuer = "http://oidc.example.com" },
+			wantErr: "requires an https jwtIssuer",
+		},
+		{
+			name:    "several replicas",
+			mutate:  func(oidc *v1alpha1.SpireOIDCDiscoveryProvider) { oidc.Spec.ReplicaCount = 2 },
+			wantErr: "requires replicaCount 1",
+		},
+		{
+			name: "autoscaling",
+			mutate: func(oidc *v1alpha1.SpireOIDCDiscoveryProvider) {
+				oidc.Spec.Autoscaling = &v1alpha1.OIDCAutoscalingConfig{MinReplicas: 1, MaxReplicas: 3}
+			},
+			wantErr: "acme and autoscaling are mutually exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oidc := createAcmeTestOIDC()
+			tt.mutate(oidc)
+			err := validateACME(oidc)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestFetchTrustedCertificate(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	t.Cleanup(server.Close)
+	addr := server.Listener.Addr().String()
+
+	trusted := x509.NewCertPool()
+	trusted.AddCert(server.Certificate())
+
+	cert, err := fetchTrustedCertificate(context.Background(), addr, "example.com", trusted)
+	require.NoError(t, err)
+	assert.Equal(t, server.Certificate().Raw, cert.Raw)
+
+	_, err = fetchTrustedCertificate(context.Background(), addr, "example.com", x509.NewCertPool())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not publicly trusted")
+
+	_, err = fetchTrustedCertificate(context.Background(), addr, "oidc.example.org", trusted)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "oidc.example.org")
+}
+
+func TestReconcileCertificate(t *testing.T) {
+	issued := func(validFor time.Duration) CertificateCheckFunc {
+		return func(context.Context, string, string) (*x509.Certificate, error) {
+			return &x509.Certificate{
+				Issuer:   pkix.Name{CommonName: "R11"},
+				NotAfter: time.Now().Add(validFor),
+			}, nil
+		}
+	}
+
+	t.Run("reports the issued certificate", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		var gotAddr, gotServerName string
+		reconciler.checkCertificate = func(ctx context.Context, addr, serverName string) (*x509.Certificate, error) {
+			gotAddr, gotServerName = addr, serverName
+			return issued(60*24*time.Hour)(ctx, addr, serverName)
+		}
+		oidc := createAcmeTestOIDC()
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCertificate(oidc, statusMgr)
+
+		assert.Equal(t, certificateCheckInterval, next)
+		assert.Equal(t, "spire-spiffe-oidc-discovery-provider."+utils.GetOperatorNamespace()+".svc:443", gotAddr)
+		assert.Equal(t, "oidc.example.com", gotServerName)
+		condition := certificateReadyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, "CertificateIssued", condition.Reason)
+		assert.Contains(t, condition.Message, "issued by R11")
+	})
+
+	t.Run("waits for issuance", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		reconciler.checkCertificate = func(context.Context, string, string) (*x509.Certificate, error) {
+			return nil, errors.New("connection refused")
+		}
+		oidc := createAcmeTestOIDC()
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCertificate(oidc, statusMgr)
+
+		assert.Equal(t, certificatePendingRetry, next)
+		condition := certificateReadyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "CertificatePending", condition.Reason)
+		assert.Contains(t, condition.Message, "connection refused")
+	})
+
+	t.Run("reports a certificate that was not renewed", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		reconciler.checkCertificate = issued(3 * 24 * time.Hour)
+		oidc := createAcmeTestOIDC()
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCertificate(oidc, statusMgr)
+
+		assert.Equal(t, certificatePendingRetry, next)
+		condition := certificateReadyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "CertificateRenewalOverdue", condition.Reason)
+	})
+
+	t.Run("reports a pending check without blocking", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		var run func()
+		reconciler.runInBackground = func(f func()) { run = f }
+		reconciler.checkCertificate = issued(60 * 24 * time.Hour)
+		oidc := createAcmeTestOIDC()
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCertificate(oidc, statusMgr)
+
+		assert.Equal(t, utils.BackgroundCheckPollInterval, next)
+		condition := certificateReadyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+		assert.Equal(t, "CertificateCheckPending", condition.Reason)
+
+		require.NotNil(t, run)
+		run()
+		statusMgr = status.NewManager(fakeClient)
+		assert.Equal(t, certificateCheckInterval, reconciler.reconcileCertificate(oidc, statusMgr))
+		assert.Equal(t, "CertificateIssued", certificateReadyCondition(t, oidc, statusMgr).Reason)
+	})
+
+	t.Run("removes the condition without acme", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		oidc := createAcmeTestOIDC()
+		oidc.Spec.Acme = nil
+		oidc.Status.Conditions = []metav1.Condition{{Type: CertificateReady, Status: metav1.ConditionTrue, Reason: "CertificateIssued"}}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCertificate(oidc, statusMgr)
+
+		assert.Zero(t, next)
+		assert.Nil(t, certificateReadyCondition(t, oidc, statusMgr))
+	})
+}
+
+func TestReconcileACMECache(t *testing.T) {
+	t.Run("creates the claim", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{Resource: "persistentvolumeclaims"}, acmeCacheClaimName))
+		reconciler := newRouteTestReconciler(fakeClient)
+		oidc := createAcmeTestOIDC()
+		oidc.Spec.Acme.StorageClassName = "gp3-csi"
+
+		require.NoError(t, reconciler.reconcileACMECache(context.Background(), oidc, status.NewManager(fakeClient)))
+
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, obj, _ := fakeClient.CreateArgsForCall(0)
+		claim := obj.(*corev1.PersistentVolumeClaim)
+		assert.Equal(t, acmeCacheClaimName, claim.Name)
+		assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, claim.Spec.AccessModes)
+		assert.Equal(t, "gp3-csi", *claim.Spec.StorageClassName)
+		assert.Equal(t, utils.AppManagedByLabelValue, claim.Labels[utils.AppManagedByLabelKey])
+		require.Len(t, claim.OwnerReferences, 1)
+	})
+
+	t.Run("keeps an existing claim", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+
+		require.NoError(t, reconciler.reconcileACMECache(context.Background(), createAcmeTestOIDC(), status.NewManager(fakeClient)))
+
+		assert.Zero(t, fakeClient.CreateCallCount())
+		assert.Zero(t, fakeClient.UpdateCallCount())
+	})
+
+	t.Run("deletes the claim without acme", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			obj.SetLabels(map[string]string{utils.AppManagedByLabelKey: utils.AppManagedByLabelValue})
+			return nil
+		}
+		reconciler := newRouteTestReconciler(fakeClient)
+		oidc := createAcmeTestOIDC()
+		oidc.Spec.Acme = nil
+
+		require.NoError(t, reconciler.reconcileACMECache(context.Background(), oidc, status.NewManager(fakeClient)))
+
+		assert.Equal(t, 1, fakeClient.DeleteCallCount())
+	})
+}
+
+func TestEarliestRequeue(t *testing.T) {
+	assert.Zero(t, earliestRequeue())
+	assert.Zero(t, earliestRequeue(0, 0))
+	assert.Equal(t, time.Minute, earliestRequeue(0, time.Hour, time.Minute))
+	assert.Equal(t, 5*time.Minute, earliestRequeue(5*time.Minute, 0))
+}
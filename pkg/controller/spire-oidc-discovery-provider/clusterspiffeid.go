@@ -3,10 +3,13 @@ package spire_oidc_discovery_provider
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
@@ -15,10 +18,55 @@ import (
 	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 )
 
-// reconcileClusterSpiffeIDs reconciles the ClusterSpiffeID resources
+const (
+	defaultOIDCSPIFFEIDTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}"
+	defaultOIDCDNSNameTemplate  = "oidc-discovery.{{ .TrustDomain }}"
+	oidcServiceAccountName      = "spire-spiffe-oidc-discovery-provider"
+)
+
+// validateIdentity parses the ClusterSPIFFEID templates of the provider the way spire-controller-manager does,
+// renders them for a provider pod and checks the pod selector still matches the provider pods
+func validateIdentity(oidc *v1alpha1.SpireOIDCDiscoveryProvider, trustDomain, clusterName string) error {
+	if oidc.Spec.Identity == nil {
+		return nil
+	}
+	spec := generateSpireIODCDiscoveryProviderSpiffeID(oidc).Spec
+	parsed, err := spiffev1alpha1.ParseClusterSPIFFEIDSpec(&spec)
+	if err != nil {
+		return fmt.Errorf("invalid identity: %w", err)
+	}
+
+	data := utils.SampleTemplateData(trustDomain, clusterName, utils.GetOperatorNamespace(), oidcServiceAccountName)
+	if _, err := utils.RenderSampleSPIFFEID(parsed.SPIFFEIDTemplate, data); err != nil {
+		return fmt.Errorf("identity.spiffeIDTemplate %w", err)
+	}
+	for _, dnsNameTemplate := range parsed.DNSNameTemplates {
+		dnsName, err := utils.RenderTemplate(dnsNameTemplate, data)
+		if err != nil {
+			return fmt.Errorf("failed to render identity.dnsNameTemplates: %w", err)
+		}
+		validate := validation.IsDNS1123Subdomain
+		if strings.HasPrefix(dnsName, "*.") {
+			validate = validation.IsWildcardDNS1123Subdomain
+		}
+		if errs := validate(dnsName); len(errs) > 0 {
+			return fmt.Errorf("identity.dnsNameTemplates renders an invalid DNS name %q: %s", dnsName, strings.Join(errs, ", "))
+		}
+	}
+
+	// The selector is matched against the pod template labels of the provider Deployment
+	podLabels := labels.Set(utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels))
+	if !parsed.PodSelector.Matches(podLabels) {
+		return fmt.Errorf("identity.podSelector %q does not match the provider pod labels %q", parsed.PodSelector.String(), podLabels.String())
+	}
+	return nil
+}
+
+// reconcileClusterSpiffeIDs reconciles the OIDC discovery provider ClusterSpiffeID. The default
+// fallback ClusterSPIFFEID is managed from the ZeroTrustWorkloadIdentityManager.
 func (r *SpireOidcDiscoveryProviderReconciler) reconcileClusterSpiffeIDs(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) error {
 	// Reconcile OIDC Discovery Provider ClusterSPIFFEID
-	desiredOIDC := generateSpireIODCDiscoveryProviderSpiffeID(oidc.Spec.Labels)
+	desiredOIDC := generateSpireIODCDiscoveryProviderSpiffeID(oidc)
 	if err := controllerutil.SetControllerReference(oidc, desiredOIDC, r.scheme); err != nil {
 		r.log.Error(err, "failed to set controller reference for OIDC ClusterSPIFFEID")
 		statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "SpireClusterSpiffeIDGenerationFailed",
@@ -53,6 +101,9 @@ func (r *SpireOidcDiscoveryProviderReconciler) reconcileClusterSpiffeIDs(ctx con
 			return err
 		}
 		r.log.Info("Created OIDC ClusterSPIFFEID", "name", desiredOIDC.Name)
+		statusMgr.AddCondition(SVIDAvailable, "EntriesPending",
+			"Waiting for spire-controller-manager to register the provider pods",
+			metav1.ConditionFalse)
 	} else {
 		// Resource exists, check if we need to update
 		if utils.ResourceNeedsUpdate(existingOIDC, desiredOIDC) {
@@ -74,94 +125,79 @@ func (r *SpireOidcDiscoveryProviderReconciler) reconcileClusterSpiffeIDs(ctx con
 		} else {
 			r.log.V(1).Info("OIDC ClusterSPIFFEID is up to date", "name", desiredOIDC.Name)
 		}
+		reportSVIDAvailability(existingOIDC, statusMgr)
 	}
 
-	// Reconcile Default Fallback ClusterSPIFFEID
-	desiredDefault := generateDefaultFallbackClusterSPIFFEID(oidc.Spec.Labels)
-	if err = controllerutil.SetControllerReference(oidc, desiredDefault, r.scheme); err != nil {
-		r.log.Error(err, "failed to set controller reference for default ClusterSPIFFEID")
-		statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "SpireClusterSpiffeIDGenerationFailed",
-			err.Error(),
+	statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "SpireClusterSpiffeIDResourcesReady",
+		"Spire OIDC ClusterSpiffeID resource is ready",
+		metav1.ConditionTrue)
+	return nil
+}
+
+// reportSVIDAvailability reports in the SVIDAvailable condition whether spire-controller-manager registered
+// the provider pods, from the statistics of its last reconciliation of the ClusterSPIFFEID
+func reportSVIDAvailability(clusterSpiffeID *spiffev1alpha1.ClusterSPIFFEID, statusMgr *status.Manager) {
+	stats := clusterSpiffeID.Status.Stats
+	switch {
+	case stats.PodsSelected == 0:
+		statusMgr.AddCondition(SVIDAvailable, "NoPodsSelected",
+			fmt.Sprintf("ClusterSPIFFEID %s selects no provider pods", clusterSpiffeID.Name),
 			metav1.ConditionFalse)
-		return err
+	case stats.PodEntryRenderFailures > 0:
+		statusMgr.AddCondition(SVIDAvailable, "EntryRenderFailed",
+			fmt.Sprintf("ClusterSPIFFEID %s failed to render the entries of %d provider pods", clusterSpiffeID.Name, stats.PodEntryRenderFailures),
+			metav1.ConditionFalse)
+	case stats.EntriesMasked > 0:
+		statusMgr.AddCondition(SVIDAvailable, "EntryMasked",
+			fmt.Sprintf("Entries of %d provider pods are masked by another ClusterSPIFFEID", stats.EntriesMasked),
+			metav1.ConditionFalse)
+	case stats.EntryFailures > 0:
+		statusMgr.AddCondition(SVIDAvailable, "EntryRegistrationFailed",
+			fmt.Sprintf("SPIRE server failed to register the entries of %d provider pods", stats.EntryFailures),
+			metav1.ConditionFalse)
+	default:
+		statusMgr.AddCondition(SVIDAvailable, "EntriesRegistered",
+			fmt.Sprintf("Registration entries issue SVIDs to %d provider pods", stats.EntriesToSet),
+			metav1.ConditionTrue)
 	}
+}
 
-	// Get existing Default ClusterSPIFFEID (from cache)
-	existingDefault := &spiffev1alpha1.ClusterSPIFFEID{}
-	err = r.ctrlClient.Get(ctx, types.NamespacedName{Name: desiredDefault.Name}, existingDefault)
-
-	if err != nil {
-		if !kerrors.IsNotFound(err) {
-			// Unexpected error
-			r.log.Error(err, "failed to get Default ClusterSPIFFEID")
-			statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "SpireClusterSpiffeIDGetFailed",
-				fmt.Sprintf("Failed to get Default ClusterSPIFFEID: %v", err),
-				metav1.ConditionFalse)
-			return err
+// generateSpireIODCDiscoveryProviderSpiffeID generates the ClusterSPIFFEID of the provider pods, customized by
+// the identity of the SpireOIDCDiscoveryProvider
+func generateSpireIODCDiscoveryProviderSpiffeID(oidc *v1alpha1.SpireOIDCDiscoveryProvider) *spiffev1alpha1.ClusterSPIFFEID {
+	spiffeIDTemplate := defaultOIDCSPIFFEIDTemplate
+	dnsNameTemplates := []string{defaultOIDCDNSNameTemplate}
+	podSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			"app.kubernetes.io/name":      "spiffe-oidc-discovery-provider",
+			"app.kubernetes.io/instance":  "cluster-zero-trust-workload-identity-manager",
+			"app.kubernetes.io/component": "discovery",
+		},
+	}
+	if identity := oidc.Spec.Identity; identity != nil {
+		if identity.SPIFFEIDTemplate != "" {
+			spiffeIDTemplate = identity.SPIFFEIDTemplate
 		}
-
-		// Resource doesn't exist, create it
-		if err := r.ctrlClient.Create(ctx, desiredDefault); err != nil {
-			if conflictErr := utils.HandleCreateConflict(err, desiredDefault, r.log, statusMgr, ClusterSPIFFEIDAvailable); conflictErr != nil {
-				return conflictErr
-			}
-			r.log.Error(err, "Failed to create DefaultFallbackClusterSPIFFEID")
-			statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "SpireClusterSpiffeIDCreationFailed",
-				err.Error(),
-				metav1.ConditionFalse)
-			return err
+		if len(identity.DNSNameTemplates) > 0 {
+			dnsNameTemplates = identity.DNSNameTemplates
 		}
-		r.log.Info("Created Default ClusterSPIFFEID", "name", desiredDefault.Name)
-	} else {
-		// Resource exists, check if we need to update
-		if utils.ResourceNeedsUpdate(existingDefault, desiredDefault) {
-			if createOnlyMode {
-				// Skip update in create-only mode
-				r.log.Info("Skipping Default ClusterSPIFFEID update due to create-only mode", "name", desiredDefault.Name)
-			} else {
-				// Update the resource
-				desiredDefault.ResourceVersion = existingDefault.ResourceVersion
-				if err := r.ctrlClient.Update(ctx, desiredDefault); err != nil {
-					r.log.Error(err, "Failed to update Default ClusterSPIFFEID")
-					statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "SpireClusterSpiffeIDUpdateFailed",
-						fmt.Sprintf("Failed to update Default ClusterSPIFFEID: %v", err),
-						metav1.ConditionFalse)
-					return err
-				}
-				r.log.Info("Updated Default ClusterSPIFFEID", "name", desiredDefault.Name)
-			}
-		} else {
-			r.log.V(1).Info("Default ClusterSPIFFEID is up to date", "name", desiredDefault.Name)
+		if identity.PodSelector != nil {
+			podSelector = identity.PodSelector.DeepCopy()
 		}
 	}
 
-	statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "SpireClusterSpiffeIDResourcesReady",
-		"Spire OIDC and default ClusterSpiffeID resources are ready",
-		metav1.ConditionTrue)
-	return nil
-}
-
-func generateSpireIODCDiscoveryProviderSpiffeID(customLabels map[string]string) *spiffev1alpha1.ClusterSPIFFEID {
 	clusterSpiffeID := &spiffev1alpha1.ClusterSPIFFEID{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:   "zero-trust-workload-identity-manager-spire-oidc-discovery-provider",
-			Labels: utils.SpireOIDCDiscoveryProviderLabels(customLabels),
+			Labels: utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels),
 		},
 		Spec: spiffev1alpha1.ClusterSPIFFEIDSpec{
-			ClassName:        "zero-trust-workload-identity-manager-spire",
-			Hint:             "oidc-discovery-provider",
-			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
-			DNSNameTemplates: []string{
-				"oidc-discovery.{{ .TrustDomain }}",
-			},
+			ClassName:            "zero-trust-workload-identity-manager-spire",
+			Hint:                 "oidc-discovery-provider",
+			SPIFFEIDTemplate:     spiffeIDTemplate,
+			DNSNameTemplates:     dnsNameTemplates,
 			AutoPopulateDNSNames: true,
-			PodSelector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app.kubernetes.io/name":      "spiffe-oidc-discovery-provider",
-					"app.kubernetes.io/instance":  "cluster-zero-trust-workload-identity-manager",
-					"app.kubernetes.io/component": "discovery",
-				},
-			},
+			PodSelector:          podSelector,
 			NamespaceSelector: &metav1.LabelSelector{
 				MatchExpressions: []metav1.LabelSelectorRequirement{
 					{
@@ -177,30 +213,3 @@ func generateSpireIODCDiscoveryProviderSpiffeID(customLabels map[string]string)
 	}
 	return clusterSpiffeID
 }
-
-func generateDefaultFallbackClusterSPIFFEID(customLabels map[string]string) *spiffev1alpha1.ClusterSPIFFEID {
-	clusterSpiffeID := &spiffev1alpha1.ClusterSPIFFEID{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:   "zero-trust-workload-identity-manager-spire-default",
-			Labels: utils.SpireOIDCDiscoveryProviderLabels(customLabels),
-		},
-		Spec: spiffev1alpha1.ClusterSPIFFEIDSpec{
-			ClassName:        "zero-trust-workload-identity-manager-spire",
-			Hint:             "default",
-			SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
-			Fallback:         true,
-			NamespaceSelector: &metav1.LabelSelector{
-				MatchExpressions: []metav1.LabelSelectorRequirement{
-					{
-						Key:      "kubernetes.io/metadata.name",
-						Operator: metav1.LabelSelectorOpNotIn,
-						Values: []string{
-							utils.GetOperatorNamespace(),
-						},
-					},
-				},
-			},
-		},
-	}
-	return clusterSpiffeID
-}
@@ -11,7 +11,10 @@ import (
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
 	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -35,7 +38,7 @@ func TestReconcileClusterSpiffeIDs(t *testing.T) {
 			name:         "create success",
 			notFound:     true,
 			expectError:  false,
-			expectCreate: 2,
+			expectCreate: 1,
 		},
 		{
 			name:        "create error for oidc",
@@ -132,7 +135,7 @@ func TestReconcileClusterSpiffeIDs(t *testing.T) {
 func TestGenerateClusterSPIFFEIDs(t *testing.T) {
 	tests := []struct {
 		name         string
-		genFunc      func(map[string]string) *spiffev1alpha1.ClusterSPIFFEID
+		genFunc      func(*v1alpha1.SpireOIDCDiscoveryProvider) *spiffev1alpha1.ClusterSPIFFEID
 		expectedName string
 		customLabels map[string]string
 		checkCustom  bool
@@ -149,23 +152,13 @@ func TestGenerateClusterSPIFFEIDs(t *testing.T) {
 			customLabels: map[string]string{"custom": "label"},
 			checkCustom:  true,
 		},
-		{
-			name:         "Default ClusterSPIFFEID basic",
-			genFunc:      generateDefaultFallbackClusterSPIFFEID,
-			expectedName: "zero-trust-workload-identity-manager-spire-default",
-		},
-		{
-			name:         "Default ClusterSPIFFEID with labels",
-			genFunc:      generateDefaultFallbackClusterSPIFFEID,
-			expectedName: "zero-trust-workload-identity-manager-spire-default",
-			customLabels: map[string]string{"custom": "label"},
-			checkCustom:  true,
-		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			csid := tt.genFunc(tt.customLabels)
+			oidc := createClusterSpiffeIDTestOIDCCR()
+			oidc.Spec.Labels = tt.customLabels
+			csid := tt.genFunc(oidc)
 			if csid == nil {
 				t.Fatal("Expected non-nil ClusterSPIFFEID")
 			}
@@ -203,3 +196,142 @@ func createClusterSpiffeIDTestOIDCCR() *v1alpha1.SpireOIDCDiscoveryProvider {
 		},
 	}
 }
+
+func TestGenerateClusterSPIFFEID_Identity(t *testing.T) {
+	oidc := createClusterSpiffeIDTestOIDCCR()
+
+	csid := generateSpireIODCDiscoveryProviderSpiffeID(oidc)
+	assert.Equal(t, defaultOIDCSPIFFEIDTemplate, csid.Spec.SPIFFEIDTemplate)
+	assert.Equal(t, []string{defaultOIDCDNSNameTemplate}, csid.Spec.DNSNameTemplates)
+	assert.Equal(t, "spiffe-oidc-discovery-provider", csid.Spec.PodSelector.MatchLabels["app.kubernetes.io/name"])
+
+	oidc.Spec.Identity = &v1alpha1.OIDCProviderIdentity{
+		SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }}/infra/oidc",
+		DNSNameTemplates: []string{"oidc.{{ .TrustDomain }}", "oidc.internal"},
+		PodSelector:      &metav1.LabelSelector{MatchLabels: map[string]string{"team": "infra"}},
+	}
+	csid = generateSpireIODCDiscoveryProviderSpiffeID(oidc)
+	assert.Equal(t, "spiffe://{{ .TrustDomain }}/infra/oidc", csid.Spec.SPIFFEIDTemplate)
+	assert.Equal(t, []string{"oidc.{{ .TrustDomain }}", "oidc.internal"}, csid.Spec.DNSNameTemplates)
+	assert.Equal(t, map[string]string{"team": "infra"}, csid.Spec.PodSelector.MatchLabels)
+	assert.True(t, csid.Spec.AutoPopulateDNSNames)
+}
+
+func TestValidateIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		identity *v1alpha1.OIDCProviderIdentity
+		labels   map[string]string
+		wantErr  string
+	}{
+		{name: "default identity"},
+		{
+			name:     "fixed SPIFFE ID",
+			identity: &v1alpha1.OIDCProviderIdentity{SPIFFEIDTemplate: "spiffe://example.org/infra/oidc"},
+		},
+		{
+			name: "selector on a custom label",
+			identity: &v1alpha1.OIDCProviderIdentity{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "infra"}},
+			},
+			labels: map[string]string{"team": "infra"},
+		},
+		{
+			name:     "SPIFFE ID outside the trust domain",
+			identity: &v1alpha1.OIDCProviderIdentity{SPIFFEIDTemplate: "spiffe://other.org/infra/oidc"},
+			wantErr:  `must render a SPIFFE ID starting with "spiffe://example.org/"`,
+		},
+		{
+			name:     "unparsable template",
+			identity: &v1alpha1.OIDCProviderIdentity{SPIFFEIDTemplate: "spiffe://{{ .TrustDomain }/oidc"},
+			wantErr:  "invalid identity",
+		},
+		{
+			name:     "invalid DNS name",
+			identity: &v1alpha1.OIDCProviderIdentity{DNSNameTemplates: []string{"oidc_{{ .PodMeta.Name }}!"}},
+			wantErr:  "invalid DNS name",
+		},
+		{
+			name: "selector not matching the provider pods",
+			identity: &v1alpha1.OIDCProviderIdentity{
+				PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "infra"}},
+			},
+			wantErr: "does not match the provider pod labels",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oidc := createClusterSpiffeIDTestOIDCCR()
+			oidc.Spec.Identity = tt.identity
+			oidc.Spec.Labels = tt.labels
+
+			err := validateIdentity(oidc, "example.org", "test-cluster")
+
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReportSVIDAvailability(t *testing.T) {
+	tests := []struct {
+		name       string
+		stats      spiffev1alpha1.ClusterSPIFFEIDStats
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name:       "entries registered",
+			stats:      spiffev1alpha1.ClusterSPIFFEIDStats{NamespacesSelected: 1, PodsSelected: 2, EntriesToSet: 2},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: "EntriesRegistered",
+		},
+		{
+			name:       "no pods selected",
+			stats:      spiffev1alpha1.ClusterSPIFFEIDStats{NamespacesSelected: 1},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "NoPodsSelected",
+		},
+		{
+			name:       "render failure",
+			stats:      spiffev1alpha1.ClusterSPIFFEIDStats{PodsSelected: 1, PodEntryRenderFailures: 1},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "EntryRenderFailed",
+		},
+		{
+			name:       "masked by another ClusterSPIFFEID",
+			stats:      spiffev1alpha1.ClusterSPIFFEIDStats{PodsSelected: 1, EntriesMasked: 1},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "EntryMasked",
+		},
+		{
+			name:       "registration failure",
+			stats:      spiffev1alpha1.ClusterSPIFFEIDStats{PodsSelected: 1, EntriesToSet: 1, EntryFailures: 1},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "EntryRegistrationFailed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			oidc := createClusterSpiffeIDTestOIDCCR()
+			statusMgr := status.NewManager(&fakes.FakeCustomCtrlClient{})
+			csid := generateSpireIODCDiscoveryProviderSpiffeID(oidc)
+			csid.Status.Stats = tt.stats
+
+			reportSVIDAvailability(csid, statusMgr)
+
+			require.NoError(t, statusMgr.ApplyStatus(context.Background(), oidc, func() *v1alpha1.ConditionalStatus {
+				return &oidc.Status.ConditionalStatus
+			}))
+			condition := apimeta.FindStatusCondition(oidc.Status.Conditions, SVIDAvailable)
+			require.NotNil(t, condition)
+			assert.Equal(t, tt.wantStatus, condition.Status)
+			assert.Equal(t, tt.wantReason, condition.Reason)
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"k8s.io/apimachinery/pkg/api/equality"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -105,13 +107,29 @@ func generateOIDCConfigMapFromCR(dp *v1alpha1.SpireOIDCDiscoveryProvider, ztwim
 	// OIDC config map data
 	oidcDefaultDomain := "spire-spiffe-oidc-discovery-provider." + utils.GetOperatorNamespace()
 	oidcSVCDomain := "spire-spiffe-oidc-discovery-provider." + utils.GetOperatorNamespace() + ".svc.cluster.local"
+	domains := []string{
+		"spire-spiffe-oidc-discovery-provider",
+		oidcDefaultDomain,
+		oidcSVCDomain,
+		jwtIssuer,
+	}
+	// The previous issuer stays discoverable while relying parties move to the new one
+	if dp.Spec.PreviousJwtIssuer != "" {
+		previousIssuer, err := utils.StripProtocolFromJWTIssuer(dp.Spec.PreviousJwtIssuer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid previous JWT issuer URL: %w", err)
+		}
+		if !slices.Contains(domains, previousIssuer) {
+			domains = append(domains, previousIssuer)
+		}
+	}
+	for _, domain := range dp.Spec.AdditionalDomains {
+		if !slices.Contains(domains, domain) {
+			domains = append(domains, domain)
+		}
+	}
 	oidcConfig := map[string]interface{}{
-		"domains": []string{
-			"spire-spiffe-oidc-discovery-provider",
-			oidcDefaultDomain,
-			oidcSVCDomain,
-			jwtIssuer,
-		},
+		"domains": domains,
 		"health_checks": map[string]string{
 			"bind_port":  "8008",
 			"live_path":  "/live",
@@ -119,15 +137,50 @@ func generateOIDCConfigMapFromCR(dp *v1alpha1.SpireOIDCDiscoveryProvider, ztwim
 		},
 		"log_level":  utils.GetLogLevelFromString(dp.Spec.LogLevel),
 		"log_format": utils.GetLogFormatFromString(dp.Spec.LogFormat),
-		"serving_cert_file": map[string]string{
-			"addr":           ":8443",
+	}
+
+	// With ACME the provider serves on port 443 with a certificate it obtains for the domains itself
+	if dp.Spec.Acme != nil {
+		directoryURL := dp.Spec.Acme.DirectoryUrl
+		if directoryURL == "" {
+			directoryURL = defaultAcmeDirectoryURL
+		}
+		oidcConfig["acme"] = map[string]interface{}{
+			"cache_dir":     acmeCacheDir,
+			"directory_url": directoryURL,
+			"email":         dp.Spec.Acme.Email,
+			"tos_accepted":  true,
+		}
+	} else {
+		oidcConfig["serving_cert_file"] = map[string]string{
+			"addr":           fmt.Sprintf(":%d", servingCertHTTPSPort),
 			"cert_file_path": "/etc/oidc/tls/tls.crt",
 			"key_file_path":  "/etc/oidc/tls/tls.key",
-		},
-		"workload_api": map[string]string{
+		}
+	}
+
+	if dp.Spec.ServerAPI != nil {
+		oidcConfig["server_api"] = serverAPIConfig(dp.Spec.ServerAPI)
+	} else {
+		oidcConfig["workload_api"] = map[string]string{
 			"socket_path":  "/spiffe-workload-api/" + agentSocketName,
 			"trust_domain": trustDomain,
-		},
+		}
+	}
+
+	if dp.Spec.JwksURI != "" {
+		jwksURI, err := utils.NormalizeURL(dp.Spec.JwksURI)
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS URI: %w", err)
+		}
+		oidcConfig["jwks_uri"] = jwksURI
+		if strings.HasPrefix(jwksURI, "http://") {
+			oidcConfig["allow_insecure_scheme"] = true
+		}
+	}
+
+	if utils.StringToBool(dp.Spec.SetKeyUse) {
+		oidcConfig["set_key_use"] = true
 	}
 
 	oidcJSON, err := json.MarshalIndent(oidcConfig, "", "  ")
@@ -148,3 +201,30 @@ func generateOIDCConfigMapFromCR(dp *v1alpha1.SpireOIDCDiscoveryProvider, ztwim
 
 	return configMap, nil
 }
+
+// validateDiscoveryOptions validates the issuers, domains and JWKS URI of the discovery document
+func validateDiscoveryOptions(oidc *v1alpha1.SpireOIDCDiscoveryProvider) error {
+	if oidc.Spec.PreviousJwtIssuer != "" {
+		previousIssuer, err := utils.NormalizeURL(oidc.Spec.PreviousJwtIssuer)
+		if err != nil {
+			return fmt.Errorf("invalid previous JWT issuer URL: %w", err)
+		}
+		if jwtIssuer, _ := utils.NormalizeURL(oidc.Spec.JwtIssuer); previousIssuer == jwtIssuer {
+			return fmt.Errorf("previousJwtIssuer must differ from jwtIssuer %s", jwtIssuer)
+		}
+	}
+
+	for _, domain := range oidc.Spec.AdditionalDomains {
+		if err := utils.IsValidDomain(domain); err != nil {
+			return fmt.Errorf("invalid additional domain %q: %w", domain, err)
+		}
+	}
+
+	if oidc.Spec.JwksURI != "" {
+		if err := utils.IsValidURL(oidc.Spec.JwksURI); err != nil {
+			return fmt.Errorf("invalid JWKS URI: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
@@ -480,3 +481,146 @@ func TestOIDCConfigJSONFormatting(t *testing.T) {
 	err = json.Unmarshal([]byte(oidcJSON), &temp)
 	assert.NoError(t, err)
 }
+
+func TestGenerateOIDCConfigMapFromCR_DiscoveryOptions(t *testing.T) {
+	parseConfig := func(t *testing.T, cr *v1alpha1.SpireOIDCDiscoveryProvider) map[string]interface{} {
+		t.Helper()
+		result, err := generateOIDCConfigMapFromCR(cr, createOIDCTestZTWIM())
+		require.NoError(t, err)
+		var oidcConfig map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(result.Data["oidc-discovery-provider.conf"]), &oidcConfig))
+		return oidcConfig
+	}
+
+	t.Run("defaults leave the optional settings unset", func(t *testing.T) {
+		oidcConfig := parseConfig(t, &v1alpha1.SpireOIDCDiscoveryProvider{
+			Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{JwtIssuer: "https://oidc.example.org"},
+		})
+
+		assert.NotContains(t, oidcConfig, "jwks_uri")
+		assert.NotContains(t, oidcConfig, "allow_insecure_scheme")
+		assert.NotContains(t, oidcConfig, "set_key_use")
+		assert.NotContains(t, oidcConfig, "server_api")
+	})
+
+	t.Run("additional domains, jwks uri and key use", func(t *testing.T) {
+		oidcConfig := parseConfig(t, &v1alpha1.SpireOIDCDiscoveryProvider{
+			Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+				JwtIssuer:         "https://oidc.example.org",
+				AdditionalDomains: []string{"oidc.example.org", "identity.example.com", "cdn.example.net"},
+				JwksURI:           "https://CDN.example.net/keys/",
+				SetKeyUse:         "true",
+			},
+		})
+
+		domains := oidcConfig["domains"].([]interface{})
+		assert.Len(t, domains, 6, "the jwtIssuer host must not be duplicated")
+		assert.Equal(t, "identity.example.com", domains[4])
+		assert.Equal(t, "cdn.example.net", domains[5])
+		assert.Equal(t, "https://cdn.example.net/keys", oidcConfig["jwks_uri"])
+		assert.NotContains(t, oidcConfig, "allow_insecure_scheme")
+		assert.Equal(t, true, oidcConfig["set_key_use"])
+	})
+
+	t.Run("http jwks uri allows the insecure scheme", func(t *testing.T) {
+		oidcConfig := parseConfig(t, &v1alpha1.SpireOIDCDiscoveryProvider{
+			Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+				JwtIssuer: "https://oidc.example.org",
+				JwksURI:   "http://keys.internal.example.org",
+				SetKeyUse: "false",
+			},
+		})
+
+		assert.Equal(t, "http://keys.internal.example.org", oidcConfig["jwks_uri"])
+		assert.Equal(t, true, oidcConfig["allow_insecure_scheme"])
+		assert.NotContains(t, oidcConfig, "set_key_use")
+	})
+
+	t.Run("server api replaces the workload api", func(t *testing.T) {
+		oidcConfig := parseConfig(t, &v1alpha1.SpireOIDCDiscoveryProvider{
+			Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+				JwtIssuer: "https://oidc.example.org",
+				ServerAPI: &v1alpha1.OIDCServerAPIConfig{PollInterval: &metav1.Duration{Duration: 30 * time.Second}},
+			},
+		})
+
+		assert.NotContains(t, oidcConfig, "workload_api")
+		assert.Equal(t, map[string]interface{}{
+			"address":       "unix:///run/spire/oidc-sockets/server-api.sock",
+			"poll_interval": "30s",
+		}, oidcConfig["server_api"])
+	})
+
+	t.Run("previous issuer stays in the domains", func(t *testing.T) {
+		oidcConfig := parseConfig(t, &v1alpha1.SpireOIDCDiscoveryProvider{
+			Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+				JwtIssuer:         "https://new.example.org",
+				PreviousJwtIssuer: "https://old.example.org",
+			},
+		})
+
+		assert.Contains(t, oidcConfig["domains"], "new.example.org")
+		assert.Contains(t, oidcConfig["domains"], "old.example.org")
+	})
+
+	t.Run("acme replaces the serving certificate", func(t *testing.T) {
+		oidcConfig := parseConfig(t, &v1alpha1.SpireOIDCDiscoveryProvider{
+			Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+				JwtIssuer: "https://oidc.example.org",
+				Acme:      &v1alpha1.OIDCAcmeConfig{Email: "admin@example.org", TosAccepted: "true"},
+			},
+		})
+
+		assert.NotContains(t, oidcConfig, "serving_cert_file")
+		assert.Equal(t, map[string]interface{}{
+			"cache_dir":     "/run/spire/oidc/acme",
+			"directory_url": "https://acme-v02.api.letsencrypt.org/directory",
+			"email":         "admin@example.org",
+			"tos_accepted":  true,
+		}, oidcConfig["acme"])
+	})
+}
+
+func TestValidateDiscoveryOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    v1alpha1.SpireOIDCDiscoveryProviderSpec
+		wantErr string
+	}{
+		{name: "no options"},
+		{
+			name: "valid options",
+			spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+				AdditionalDomains: []string{"oidc.example.com"},
+				JwksURI:           "https://cdn.example.com/keys",
+			},
+		},
+		{
+			name:    "domain with port",
+			spec:    v1alpha1.SpireOIDCDiscoveryProviderSpec{AdditionalDomains: []string{"oidc.example.com:443"}},
+			wantErr: `invalid additional domain "oidc.example.com:443"`,
+		},
+		{
+			name:    "jwks uri with query",
+			spec:    v1alpha1.SpireOIDCDiscoveryProviderSpec{JwksURI: "https://cdn.example.com/keys?v=1"},
+			wantErr: "query parameters are not allowed",
+		},
+		{
+			name:    "previous issuer equal to the issuer",
+			spec:    v1alpha1.SpireOIDCDiscoveryProviderSpec{JwtIssuer: "https://oidc.example.com", PreviousJwtIssuer: "https://OIDC.example.com/"},
+			wantErr: "previousJwtIssuer must differ from jwtIssuer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDiscoveryOptions(&v1alpha1.SpireOIDCDiscoveryProvider{Spec: tt.spec})
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
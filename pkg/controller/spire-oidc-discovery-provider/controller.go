@@ -2,21 +2,28 @@ package spire_oidc_discovery_provider
 
 import (
 	"context"
+	"crypto/x509"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 
 	"k8s.io/client-go/tools/record"
@@ -25,6 +32,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	routev1 "github.com/openshift/api/route/v1"
@@ -32,6 +40,7 @@ import (
 	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
 )
 
 const spireOidcDeploymentSpireOidcConfigHashAnnotationKey = "ztwim.openshift.io/spire-oidc-discovery-provider-config-hash"
@@ -40,6 +49,7 @@ const (
 	DeploymentAvailable      = "DeploymentAvailable"
 	ConfigMapAvailable       = "ConfigMapAvailable"
 	ClusterSPIFFEIDAvailable = "ClusterSPIFFEIDAvailable"
+	SVIDAvailable            = "SVIDAvailable"
 	RouteAvailable           = "RouteAvailable"
 	RBACAvailable            = "RBACAvailable"
 	ConfigurationValid       = "ConfigurationValid"
@@ -54,6 +64,21 @@ type SpireOidcDiscoveryProviderReconciler struct {
 	eventRecorder record.EventRecorder
 	log           logr.Logger
 	scheme        *runtime.Scheme
+	dialServerAPI spireapi.DialFunc
+	// checkCertificate returns the certificate the provider serves with ACME
+	checkCertificate CertificateCheckFunc
+	// certificateCheck runs checkCertificate in the background
+	certificateCheck utils.BackgroundCheck[*x509.Certificate]
+	// endpointProbeCheck probes the discovery endpoints in the background
+	endpointProbeCheck utils.BackgroundCheck[[]endpointProbeResult]
+	// runInBackground starts the background checks, on a new goroutine unless set by tests
+	runInBackground func(func())
+	// newProbeClient returns the HTTP client probing the discovery endpoints
+	newProbeClient ProbeClientFunc
+	// fetchCertificateChain returns the certificate chain the JWT issuer is served with
+	fetchCertificateChain CertificateChainFunc
+	// gatewayAPIKinds are the Gateway API kinds served at startup, which are cached and watched
+	gatewayAPIKinds []schema.GroupVersionKind
 }
 
 // New returns a new Reconciler instance.
@@ -63,11 +88,17 @@ func New(mgr ctrl.Manager) (*SpireOidcDiscoveryProviderReconciler, error) {
 		return nil, err
 	}
 	return &SpireOidcDiscoveryProviderReconciler{
-		ctrlClient:    c,
-		ctx:           context.Background(),
-		eventRecorder: mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerSpireOIDCDiscoveryProviderControllerName),
-		log:           ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerSpireOIDCDiscoveryProviderControllerName),
-		scheme:        mgr.GetScheme(),
+		ctrlClient:       c,
+		ctx:              context.Background(),
+		eventRecorder:    mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerSpireOIDCDiscoveryProviderControllerName),
+		log:              ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerSpireOIDCDiscoveryProviderControllerName),
+		scheme:           mgr.GetScheme(),
+		dialServerAPI:    utils.ServerAPIDialer(c),
+		checkCertificate: checkPublicCertificate,
+		newProbeClient:   newProbeHTTPClient,
+
+		fetchCertificateChain: fetchServedCertificateChain,
+		gatewayAPIKinds:       utils.ServedGatewayAPIKinds(mgr.GetRESTMapper()),
 	}, nil
 }
 
@@ -128,10 +159,13 @@ func (r *SpireOidcDiscoveryProviderReconciler) Reconcile(ctx context.Context, re
 	createOnlyMode := r.handleCreateOnlyMode(&oidcDiscoveryProviderConfig, statusMgr)
 
 	// Validate configuration
-	if err := r.validateConfiguration(ctx, &oidcDiscoveryProviderConfig, statusMgr); err != nil {
+	if err := r.validateConfiguration(ctx, &oidcDiscoveryProviderConfig, statusMgr, &ztwim); err != nil {
 		return ctrl.Result{}, nil
 	}
 
+	// Compare the issuer with the one SPIRE Server signs JWT-SVIDs with
+	r.reconcileJWTIssuerConsistency(ctx, &oidcDiscoveryProviderConfig, statusMgr)
+
 	// Reconcile static resources (ServiceAccount, Service)
 	if err := r.reconcileServiceAccount(ctx, &oidcDiscoveryProviderConfig, statusMgr, createOnlyMode); err != nil {
 		return ctrl.Result{}, err
@@ -152,11 +186,25 @@ func (r *SpireOidcDiscoveryProviderReconciler) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, err
 	}
 
+	// Reconcile the ACME cache before the Deployment mounting it
+	if err := r.reconcileACMECache(ctx, &oidcDiscoveryProviderConfig, statusMgr); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Reconcile Deployment
 	if err := r.reconcileDeployment(ctx, &oidcDiscoveryProviderConfig, statusMgr, createOnlyMode, configHash); err != nil {
 		return ctrl.Result{}, err
 	}
 
+	// Reconcile HorizontalPodAutoscaler and PodDisruptionBudget
+	if err := r.reconcileHorizontalPodAutoscaler(ctx, &oidcDiscoveryProviderConfig, statusMgr, createOnlyMode); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcilePodDisruptionBudget(ctx, &oidcDiscoveryProviderConfig, statusMgr, createOnlyMode); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Reconcile RBAC for external certificate access BEFORE Route (if externalSecretRef is configured)
 	// This ensures the router serviceaccount has permissions before the Route is created/updated
 	if err := r.reconcileExternalCertRBAC(ctx, &oidcDiscoveryProviderConfig, statusMgr, createOnlyMode); err != nil {
@@ -168,7 +216,39 @@ func (r *SpireOidcDiscoveryProviderReconciler) Reconcile(ctx context.Context, re
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Publish the static discovery document and JWKS, and check again for key rotation later
+	nextExport := r.reconcileExport(ctx, &oidcDiscoveryProviderConfig, statusMgr, createOnlyMode)
+
+	// Track issuance and renewal of the ACME certificate (if enabled)
+	nextCertificateCheck := r.reconcileCertificate(&oidcDiscoveryProviderConfig, statusMgr)
+
+	// Render the cloud federation artifacts (if enabled)
+	nextFederationCheck := r.reconcileCloudFederation(ctx, &oidcDiscoveryProviderConfig, statusMgr, createOnlyMode)
+
+	// Check what the provider actually serves (if enabled)
+	nextProbe := r.reconcileEndpointProbe(ctx, &oidcDiscoveryProviderConfig, statusMgr)
+
+	return ctrl.Result{RequeueAfter: earliestRequeue(nextExport, nextCertificateCheck, nextFederationCheck, nextProbe)}, nil
+}
+
+// startBackground runs f with runInBackground, or on a new goroutine when it is not set
+func (r *SpireOidcDiscoveryProviderReconciler) startBackground(f func()) {
+	if r.runInBackground != nil {
+		r.runInBackground(f)
+		return
+	}
+	go f()
+}
+
+// earliestRequeue returns the shortest non-zero requeue interval, or zero when none is set
+func earliestRequeue(intervals ...time.Duration) time.Duration {
+	var earliest time.Duration
+	for _, interval := range intervals {
+		if interval > 0 && (earliest == 0 || interval < earliest) {
+			earliest = interval
+		}
+	}
+	return earliest
 }
 
 func (r *SpireOidcDiscoveryProviderReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -186,19 +266,30 @@ func (r *SpireOidcDiscoveryProviderReconciler) SetupWithManager(mgr ctrl.Manager
 	// Use component-specific predicate to only reconcile for discovery component resources
 	controllerManagedResourcePredicates := builder.WithPredicates(utils.ControllerManagedResourcesForComponent(utils.ComponentDiscovery))
 
-	err := ctrl.NewControllerManagedBy(mgr).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.SpireOIDCDiscoveryProvider{}, builder.WithPredicates(utils.GenerationOrOwnerReferenceChangedPredicate)).
 		Named(utils.ZeroTrustWorkloadIdentityManagerSpireOIDCDiscoveryProviderControllerName).
 		Watches(&appsv1.Deployment{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		Watches(&autoscalingv2.HorizontalPodAutoscaler{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		Watches(&policyv1.PodDisruptionBudget{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		Watches(&corev1.PersistentVolumeClaim{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&routev1.Route{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		Watches(&networkingv1.Ingress{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&rbacv1.Role{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&rbacv1.RoleBinding{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&spiffev1alpha1.ClusterSPIFFEID{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&v1alpha1.ZeroTrustWorkloadIdentityManager{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(utils.ZTWIMSpecChangedPredicate)).
-		Complete(r)
+		Watches(&v1alpha1.SpireServer{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	// The Gateway API kinds are optional, only those served at startup are watched
+	for _, gvk := range r.gatewayAPIKinds {
+		gatewayObject := &unstructured.Unstructured{}
+		gatewayObject.SetGroupVersionKind(gvk)
+		controllerBuilder = controllerBuilder.Watches(gatewayObject, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates)
+	}
+	err := controllerBuilder.Complete(r)
 	if err != nil {
 		return err
 	}
@@ -225,7 +316,7 @@ func (r *SpireOidcDiscoveryProviderReconciler) handleCreateOnlyMode(oidc *v1alph
 }
 
 // validateConfiguration validates the SpireOIDCDiscoveryProvider configuration
-func (r *SpireOidcDiscoveryProviderReconciler) validateConfiguration(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) error {
+func (r *SpireOidcDiscoveryProviderReconciler) validateConfiguration(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) error {
 	// Validate common configuration
 	if err := r.validateCommonConfig(oidc, statusMgr); err != nil {
 		return err
@@ -245,6 +336,46 @@ func (r *SpireOidcDiscoveryProviderReconciler) validateConfiguration(ctx context
 		return err
 	}
 
+	if err := validateDiscoveryOptions(oidc); err != nil {
+		r.log.Error(err, "Invalid discovery options in SpireOIDCDiscoveryProvider configuration")
+		statusMgr.AddCondition(ConfigurationValid, "InvalidDiscoveryOptions",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := validateServerAPI(oidc); err != nil {
+		r.log.Error(err, "Invalid server API configuration in SpireOIDCDiscoveryProvider configuration")
+		statusMgr.AddCondition(ConfigurationValid, "InvalidServerAPI",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := validateACME(oidc); err != nil {
+		r.log.Error(err, "Invalid ACME configuration in SpireOIDCDiscoveryProvider configuration")
+		statusMgr.AddCondition(ConfigurationValid, "InvalidAcme",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := validateIdentity(oidc, ztwim.Spec.TrustDomain, ztwim.Spec.ClusterName); err != nil {
+		r.log.Error(err, "Invalid identity in SpireOIDCDiscoveryProvider configuration")
+		statusMgr.AddCondition(ConfigurationValid, "InvalidIdentity",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := validateAutoscaling(oidc); err != nil {
+		r.log.Error(err, "Invalid autoscaling in SpireOIDCDiscoveryProvider configuration")
+		statusMgr.AddCondition(ConfigurationValid, "InvalidAutoscaling",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+
 	// Only set to true if the condition previously existed as false
 	existingCondition := apimeta.FindStatusCondition(oidc.Status.ConditionalStatus.Conditions, ConfigurationValid)
 	if existingCondition != nil && existingCondition.Status == metav1.ConditionFalse {
@@ -287,5 +418,8 @@ func needsUpdate(current, desired appsv1.Deployment) bool {
 	if current.Spec.Template.Annotations[spireOidcDeploymentSpireOidcConfigHashAnnotationKey] != desired.Spec.Template.Annotations[spireOidcDeploymentSpireOidcConfigHashAnnotationKey] {
 		return true
 	}
+	if current.Spec.Strategy.Type != desired.Spec.Strategy.Type {
+		return true
+	}
 	return utils.ResourceNeedsUpdate(&current, &desired)
 }
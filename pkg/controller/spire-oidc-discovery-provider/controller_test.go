@@ -353,7 +353,7 @@ func TestValidateConfiguration_ValidConfig(t *testing.T) {
 	}
 
 	statusMgr := status.NewManager(fakeClient)
-	err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr)
+	err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr, &v1alpha1.ZeroTrustWorkloadIdentityManager{})
 
 	// Assert: validation should pass with valid configuration
 	if err != nil {
@@ -374,7 +374,7 @@ func TestValidateConfiguration_InvalidJWTIssuer(t *testing.T) {
 	}
 
 	statusMgr := status.NewManager(fakeClient)
-	err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr)
+	err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr, &v1alpha1.ZeroTrustWorkloadIdentityManager{})
 
 	// Assert: validation should fail with invalid JWT issuer
 	if err == nil {
@@ -543,7 +543,7 @@ func TestValidateConfiguration_ConditionUpdate(t *testing.T) {
 				}
 			}
 
-			err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr)
+			err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr, &v1alpha1.ZeroTrustWorkloadIdentityManager{})
 			// validateConfiguration should succeed regardless of existing condition state
 			if err != nil {
 				t.Fatalf("Unexpected error: %v", err)
@@ -728,7 +728,7 @@ func TestValidateConfiguration_ConfigurationValidPreviouslyFalse(t *testing.T) {
 	}
 
 	statusMgr := status.NewManager(fakeClient)
-	err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr)
+	err := reconciler.validateConfiguration(context.Background(), oidc, statusMgr, &v1alpha1.ZeroTrustWorkloadIdentityManager{})
 
 	if err != nil {
 		t.Errorf("Expected no error for valid configuration, got: %v", err)
@@ -1003,6 +1003,19 @@ func TestValidateConfiguration_AllScenarios(t *testing.T) {
 			},
 			expectError: true,
 		},
+		{
+			name: "identity selector not matching the provider pods",
+			oidc: &v1alpha1.SpireOIDCDiscoveryProvider{
+				ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+				Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+					JwtIssuer: "https://example.com",
+					Identity: &v1alpha1.OIDCProviderIdentity{
+						PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "infra"}},
+					},
+				},
+			},
+			expectError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -1010,8 +1023,11 @@ func TestValidateConfiguration_AllScenarios(t *testing.T) {
 			fakeClient := &fakes.FakeCustomCtrlClient{}
 			reconciler := newTestReconciler(fakeClient)
 			statusMgr := status.NewManager(fakeClient)
+			ztwim := &v1alpha1.ZeroTrustWorkloadIdentityManager{
+				Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{TrustDomain: "example.org"},
+			}
 
-			err := reconciler.validateConfiguration(context.Background(), tt.oidc, statusMgr)
+			err := reconciler.validateConfiguration(context.Background(), tt.oidc, statusMgr, ztwim)
 
 			if tt.expectError && err == nil {
 				t.Fatal("Expected error but got nil")
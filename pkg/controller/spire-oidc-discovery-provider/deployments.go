@@ -45,6 +45,10 @@ func (r *SpireOidcDiscoveryProviderReconciler) reconcileDeployment(ctx context.C
 		}
 		r.log.Info("Created spire oidc discovery provider deployment")
 	} else if err == nil {
+		// The HorizontalPodAutoscaler owns the replica count while autoscaling is enabled
+		if oidc.Spec.Autoscaling != nil {
+			deployment.Spec.Replicas = existingSpireOidcDeployment.Spec.Replicas
+		}
 		if needsUpdate(existingSpireOidcDeployment, *deployment) {
 			if createOnlyMode {
 				r.log.Info("Skipping Deployment update due to create-only mode")
@@ -87,10 +91,8 @@ func generateDeployment(config *v1alpha1.SpireOIDCDiscoveryProvider, spireOidcCo
 		"app.kubernetes.io/component": labels["app.kubernetes.io/component"],
 	}
 
-	replicas := int32(1)
-	if config.Spec.ReplicaCount > 0 {
-		replicas = int32(config.Spec.ReplicaCount)
-	}
+	// With autoscaling, the Deployment starts at the minimum and the autoscaler takes over
+	replicas := minimumReplicas(config)
 
 	// Apply default CSI driver name if not specified
 	csiDriverName := config.Spec.CSIDriverName
@@ -203,8 +205,55 @@ func generateDeployment(config *v1alpha1.SpireOIDCDiscoveryProvider, spireOidcCo
 		},
 	}
 
+	// The ACME cache volume can only be attached to one pod, so the old pod has to go first
+	deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+	if config.Spec.Acme != nil {
+		addACMEToPod(&deployment.Spec.Template.Spec)
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+
+	if config.Spec.ServerAPI != nil {
+		addServerAPIRelayToPod(&deployment.Spec.Template.Spec, config)
+	}
+
 	// Add proxy configuration if enabled
 	utils.AddProxyConfigToPod(&deployment.Spec.Template.Spec)
 
 	return deployment
 }
+
+// addACMEToPod serves the provider on port 443 for the TLS-ALPN-01 challenge and mounts the persistent
+// cache for the ACME account key and certificates in place of the service CA certificate
+func addACMEToPod(podSpec *corev1.PodSpec) {
+	// The provider runs as a non-root user, which may only bind port 443 with unprivileged ports lowered
+	if podSpec.SecurityContext == nil {
+		podSpec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	podSpec.SecurityContext.Sysctls = append(podSpec.SecurityContext.Sysctls, corev1.Sysctl{
+		Name:  "net.ipv4.ip_unprivileged_port_start",
+		Value: "0",
+	})
+
+	for i := range podSpec.Volumes {
+		if podSpec.Volumes[i].Name == "tls-certs" {
+			podSpec.Volumes[i] = corev1.Volume{
+				Name: "acme-cache",
+				VolumeSource: corev1.VolumeSource{
+					PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: acmeCacheClaimName},
+				},
+			}
+		}
+	}
+
+	container := &podSpec.Containers[0]
+	for i := range container.Ports {
+		if container.Ports[i].Name == oidcServicePortName {
+			container.Ports[i].ContainerPort = acmeHTTPSPort
+		}
+	}
+	for i := range container.VolumeMounts {
+		if container.VolumeMounts[i].Name == "tls-certs" {
+			container.VolumeMounts[i] = corev1.VolumeMount{Name: "acme-cache", MountPath: acmeCacheDir}
+		}
+	}
+}
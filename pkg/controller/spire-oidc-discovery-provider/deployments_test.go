@@ -330,6 +330,39 @@ func TestBuildDeployment(t *testing.T) {
 	}
 }
 
+func TestBuildDeployment_Acme(t *testing.T) {
+	cr := createDeploymentTestOIDCCR()
+	cr.Spec.Acme = &v1alpha1.OIDCAcmeConfig{Email: "admin@example.org", TosAccepted: "true"}
+
+	deployment := generateDeployment(cr, "hash")
+
+	podSpec := deployment.Spec.Template.Spec
+	require.NotNil(t, podSpec.SecurityContext)
+	assert.Contains(t, podSpec.SecurityContext.Sysctls, corev1.Sysctl{Name: "net.ipv4.ip_unprivileged_port_start", Value: "0"})
+
+	container := podSpec.Containers[0]
+	assert.Contains(t, container.Ports, corev1.ContainerPort{Name: "https", ContainerPort: 443, Protocol: corev1.ProtocolTCP})
+	assert.Contains(t, container.VolumeMounts, corev1.VolumeMount{Name: "acme-cache", MountPath: "/run/spire/oidc/acme"})
+	for _, mount := range container.VolumeMounts {
+		assert.NotEqual(t, "tls-certs", mount.Name)
+	}
+
+	volumeNames := []string{}
+	for _, volume := range podSpec.Volumes {
+		volumeNames = append(volumeNames, volume.Name)
+	}
+	assert.Contains(t, volumeNames, "acme-cache")
+	assert.NotContains(t, volumeNames, "tls-certs")
+	for _, volume := range podSpec.Volumes {
+		if volume.Name == "acme-cache" {
+			require.NotNil(t, volume.PersistentVolumeClaim)
+			assert.Equal(t, acmeCacheClaimName, volume.PersistentVolumeClaim.ClaimName)
+		}
+	}
+	assert.Equal(t, appsv1.RecreateDeploymentStrategyType, deployment.Spec.Strategy.Type)
+	assert.Equal(t, appsv1.RollingUpdateDeploymentStrategyType, generateDeployment(createDeploymentTestOIDCCR(), "hash").Spec.Strategy.Type)
+}
+
 // TestReconcileDeployment tests the reconcileDeployment function
 func TestReconcileDeployment(t *testing.T) {
 	t.Run("create success", func(t *testing.T) {
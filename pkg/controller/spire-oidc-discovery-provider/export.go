@@ -0,0 +1,361 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	jose "github.com/go-jose/go-jose/v4"
+	spiretypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/objectstorage"
+)
+
+const (
+	// DocumentExported reports whether the static discovery document and JWKS are published
+	DocumentExported = "DocumentExported"
+
+	// ExportDiscoveryDocumentKey is the ConfigMap key holding the exported discovery document
+	ExportDiscoveryDocumentKey = "openid-configuration"
+	// ExportKeysKey is the ConfigMap key holding the exported JWKS
+	ExportKeysKey = "keys"
+
+	defaultExportRefreshInterval = 5 * time.Minute
+	discoveryDocumentObjectKey   = ".well-known/openid-configuration"
+	jsonContentType              = "application/json"
+
+	// Keys of the Secret referenced by the S3 export
+	s3EndpointKey        = "endpoint"
+	s3RegionKey          = "region"
+	s3AccessKeyIDKey     = "accessKeyID"
+	s3SecretAccessKeyKey = "secretAccessKey"
+	s3CABundleKey        = "caBundle"
+	s3SessionTokenKey    = "sessionToken"
+	// A role ARN replaces the access keys with temporary credentials obtained with the service
+	// account token of the operator
+	s3RoleARNKey              = "roleARN"
+	s3WebIdentityTokenFileKey = "webIdentityTokenFile"
+	s3STSEndpointKey          = "stsEndpoint"
+)
+
+// discoveryDocument is the OpenID Connect discovery document, with the same content the
+// SPIRE OIDC discovery provider serves
+type discoveryDocument struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint"`
+	ResponseTypesSupported           []string `json:"response_types_supported"`
+	SubjectTypesSupported            []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported"`
+}
+
+// exportRefreshInterval returns how often the exported documents are checked for key rotation
+func exportRefreshInterval(export *v1alpha1.OIDCDocumentExport) time.Duration {
+	if export.RefreshInterval == nil {
+		return defaultExportRefreshInterval
+	}
+	return export.RefreshInterval.Duration
+}
+
+// generateDiscoveryDocuments builds the discovery document and the JWKS of the JWT authorities in the bundle.
+// Tainted authorities are left out so that relying parties stop trusting them.
+func generateDiscoveryDocuments(oidc *v1alpha1.SpireOIDCDiscoveryProvider, bundle *spiretypes.Bundle) ([]byte, []byte, []string, error) {
+	issuer, err := utils.NormalizeURL(oidc.Spec.JwtIssuer)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("invalid JWT issuer URL: %w", err)
+	}
+	jwksURI := issuer + "/keys"
+	if oidc.Spec.JwksURI != "" {
+		if jwksURI, err = utils.NormalizeURL(oidc.Spec.JwksURI); err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid JWKS URI: %w", err)
+		}
+	}
+
+	discovery, err := json.MarshalIndent(discoveryDocument{
+		Issuer:                           issuer,
+		JWKSURI:                          jwksURI,
+		AuthorizationEndpoint:            "",
+		ResponseTypesSupported:           []string{"id_token"},
+		SubjectTypesSupported:            []string{"public"},
+		IDTokenSigningAlgValuesSupported: []string{"RS256", "ES256", "ES384"},
+	}, "", "  ")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal discovery document: %w", err)
+	}
+
+	keySet := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{}}
+	var keyIDs []string
+	for _, authority := range bundle.GetJwtAuthorities() {
+		if authority.Tainted {
+			continue
+		}
+		publicKey, err := x509.ParsePKIXPublicKey(authority.PublicKey)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid public key for JWT authority %s: %w", authority.KeyId, err)
+		}
+		key := jose.JSONWebKey{Key: publicKey, KeyID: authority.KeyId}
+		if utils.StringToBool(oidc.Spec.SetKeyUse) {
+			key.Use = "sig"
+		}
+		keySet.Keys = append(keySet.Keys, key)
+		keyIDs = append(keyIDs, authority.KeyId)
+	}
+	if len(keyIDs) == 0 {
+		return nil, nil, nil, fmt.Errorf("the trust bundle has no usable JWT authorities")
+	}
+	keys, err := json.MarshalIndent(keySet, "", "  ")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to marshal JWKS: %w", err)
+	}
+
+	return discovery, keys, keyIDs, nil
+}
+
+// generateExportConfigMap creates the ConfigMap holding the exported documents
+func generateExportConfigMap(oidc *v1alpha1.SpireOIDCDiscoveryProvider, discovery, keys []byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      oidc.Spec.Export.ConfigMapName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels),
+		},
+		Data: map[string]string{
+			ExportDiscoveryDocumentKey: string(discovery),
+			ExportKeysKey:              string(keys),
+		},
+	}
+}
+
+// reconcileExport publishes the discovery document and JWKS to the configured ConfigMap and bucket.
+// It returns when the signing keys have to be checked again for rotation.
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileExport(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) time.Duration {
+	export := oidc.Spec.Export
+	if export == nil {
+		statusMgr.RemoveCondition(DocumentExported)
+		if oidc.Status.Export != nil {
+			if err := r.deleteOwnedConfigMap(ctx, oidc.Status.Export.ConfigMapName); err != nil {
+				statusMgr.AddCondition(DocumentExported, "CleanupFailed", err.Error(), metav1.ConditionFalse)
+				return 0
+			}
+			oidc.Status.Export = nil
+			statusMgr.RequestStatusUpdate()
+		}
+		return 0
+	}
+
+	interval := exportRefreshInterval(export)
+	apiClient, err := r.dialServerAPI()
+	if err != nil {
+		r.log.Error(err, "failed to connect to the SPIRE server API")
+		statusMgr.AddCondition(DocumentExported, "ServerAPIUnavailable", err.Error(), metav1.ConditionFalse)
+		return interval
+	}
+	defer apiClient.Close()
+
+	bundle, err := apiClient.GetBundle(ctx)
+	if err != nil {
+		r.log.Error(err, "failed to get trust bundle")
+		statusMgr.AddCondition(DocumentExported, "BundleUnavailable", err.Error(), metav1.ConditionFalse)
+		return interval
+	}
+	discovery, keys, keyIDs, err := generateDiscoveryDocuments(oidc, bundle)
+	if err != nil {
+		r.log.Error(err, "failed to generate the discovery documents")
+		statusMgr.AddCondition(DocumentExported, "DocumentGenerationFailed", err.Error(), metav1.ConditionFalse)
+		return interval
+	}
+
+	if oidc.Status.Export == nil {
+		oidc.Status.Export = &v1alpha1.OIDCDocumentExportStatus{}
+	}
+	exportStatus := oidc.Status.Export
+
+	if export.ConfigMapName != "" {
+		if err := r.reconcileOwnedConfigMap(ctx, oidc, generateExportConfigMap(oidc, discovery, keys), statusMgr, DocumentExported, createOnlyMode); err != nil {
+			return interval
+		}
+	}
+	if exportStatus.ConfigMapName != export.ConfigMapName {
+		if err := r.deleteOwnedConfigMap(ctx, exportStatus.ConfigMapName); err != nil {
+			statusMgr.AddCondition(DocumentExported, "CleanupFailed", err.Error(), metav1.ConditionFalse)
+			return interval
+		}
+		exportStatus.ConfigMapName = export.ConfigMapName
+		statusMgr.RequestStatusUpdate()
+	}
+
+	hashInput := map[string]string{
+		ExportDiscoveryDocumentKey: string(discovery),
+		ExportKeysKey:              string(keys),
+		"configMapName":            export.ConfigMapName,
+	}
+	var s3Config objectstorage.S3Config
+	if export.S3 != nil {
+		if s3Config, err = r.exportS3Config(ctx, export.S3); err != nil {
+			r.log.Error(err, "invalid S3 export configuration", "secret", export.S3.SecretRef)
+			statusMgr.AddCondition(DocumentExported, "InvalidS3Configuration", err.Error(), metav1.ConditionFalse)
+			return interval
+		}
+		hashInput["s3"] = strings.Join([]string{s3Config.Endpoint, s3Config.Region, export.S3.Bucket, export.S3.Prefix}, "|")
+	}
+	documentHash := utils.GenerateMapHash(hashInput)
+
+	if documentHash != exportStatus.DocumentHash {
+		if export.S3 != nil {
+			if err := uploadExport(ctx, s3Config, export.S3.Prefix, discovery, keys); err != nil {
+				r.log.Error(err, "failed to upload the discovery documents", "bucket", export.S3.Bucket)
+				statusMgr.AddCondition(DocumentExported, "UploadFailed", err.Error(), metav1.ConditionFalse)
+				return interval
+			}
+		}
+		now := metav1.Now()
+		exportStatus.LastExportTime = &now
+		exportStatus.KeyIDs = keyIDs
+		exportStatus.DocumentHash = documentHash
+		statusMgr.RequestStatusUpdate()
+		r.eventRecorder.Eventf(oidc, corev1.EventTypeNormal, "DocumentsExported",
+			"Exported the OIDC discovery document with signing keys %s", strings.Join(keyIDs, ", "))
+		r.log.Info("Exported OIDC discovery documents", "keyIDs", keyIDs)
+	}
+
+	statusMgr.AddCondition(DocumentExported, v1alpha1.ReasonReady,
+		fmt.Sprintf("Discovery document exported with %d signing keys", len(keyIDs)),
+		metav1.ConditionTrue)
+	return interval
+}
+
+// reconcileOwnedConfigMap creates or updates a ConfigMap of generated documents owned by the provider,
+// reporting failures in conditionType
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileOwnedConfigMap(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, desired *corev1.ConfigMap, statusMgr *status.Manager, conditionType string, createOnlyMode bool) error {
+	if err := controllerutil.SetControllerReference(oidc, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on ConfigMap", "name", desired.Name)
+		statusMgr.AddCondition(conditionType, "ConfigMapUpdateFailed", err.Error(), metav1.ConditionFalse)
+		return err
+	}
+
+	existing := &corev1.ConfigMap{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if kerrors.IsNotFound(err) {
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, conditionType); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create ConfigMap", "name", desired.Name)
+			statusMgr.AddCondition(conditionType, "ConfigMapUpdateFailed", err.Error(), metav1.ConditionFalse)
+			return err
+		}
+		r.log.Info("Created ConfigMap", "name", desired.Name, "namespace", desired.Namespace)
+		return nil
+	}
+	if err != nil {
+		r.log.Error(err, "failed to get ConfigMap", "name", desired.Name)
+		statusMgr.AddCondition(conditionType, "ConfigMapUpdateFailed", err.Error(), metav1.ConditionFalse)
+		return err
+	}
+
+	if utils.GenerateMapHash(existing.Data) == utils.GenerateMapHash(desired.Data) && utils.LabelsMatch(existing.Labels, desired.Labels) {
+		return nil
+	}
+	if createOnlyMode {
+		r.log.V(1).Info("ConfigMap exists, skipping update due to create-only mode", "name", desired.Name)
+		return nil
+	}
+	desired.ResourceVersion = existing.ResourceVersion
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		r.log.Error(err, "failed to update ConfigMap", "name", desired.Name)
+		statusMgr.AddCondition(conditionType, "ConfigMapUpdateFailed", err.Error(), metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Updated ConfigMap", "name", desired.Name, "namespace", desired.Namespace)
+	return nil
+}
+
+// deleteOwnedConfigMap removes a ConfigMap of generated documents that is no longer configured
+func (r *SpireOidcDiscoveryProviderReconciler) deleteOwnedConfigMap(ctx context.Context, name string) error {
+	if name == "" {
+		return nil
+	}
+	existing := &corev1.ConfigMap{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: name, Namespace: utils.GetOperatorNamespace()}, existing)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		if utils.CheckResourceConflict(existing) != nil {
+			// Not created by the operator, leave it alone
+			return nil
+		}
+		err = r.ctrlClient.Delete(ctx, existing)
+	}
+	if err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "failed to delete ConfigMap", "name", name)
+		return fmt.Errorf("failed to delete ConfigMap %s: %w", name, err)
+	}
+	r.log.Info("Deleted ConfigMap", "name", name)
+	return nil
+}
+
+// exportS3Config reads the endpoint and credentials of the S3 export from its Secret
+func (r *SpireOidcDiscoveryProviderReconciler) exportS3Config(ctx context.Context, s3 *v1alpha1.OIDCS3Export) (objectstorage.S3Config, error) {
+	// The Secret is provided by the administrator and is not in the operator cache
+	secret := &corev1.Secret{}
+	if err := r.ctrlClient.UncachedGet(ctx, types.NamespacedName{Name: s3.SecretRef, Namespace: utils.GetOperatorNamespace()}, secret); err != nil {
+		return objectstorage.S3Config{}, fmt.Errorf("failed to get S3 secret %s: %w", s3.SecretRef, err)
+	}
+	value := func(key string) string {
+		return strings.TrimSpace(string(secret.Data[key]))
+	}
+
+	required := []string{s3EndpointKey, s3AccessKeyIDKey, s3SecretAccessKeyKey}
+	if value(s3RoleARNKey) != "" {
+		required = []string{s3EndpointKey}
+	}
+	for _, key := range required {
+		if value(key) == "" {
+			return objectstorage.S3Config{}, fmt.Errorf("S3 secret %s has no %s key", s3.SecretRef, key)
+		}
+	}
+
+	config := objectstorage.S3Config{
+		Endpoint:        value(s3EndpointKey),
+		Region:          value(s3RegionKey),
+		Bucket:          s3.Bucket,
+		AccessKeyID:     value(s3AccessKeyIDKey),
+		SecretAccessKey: value(s3SecretAccessKeyKey),
+		SessionToken:    value(s3SessionTokenKey),
+		CABundle:        secret.Data[s3CABundleKey],
+	}
+	if roleARN := value(s3RoleARNKey); roleARN != "" {
+		config.WebIdentity = &objectstorage.WebIdentity{
+			RoleARN:     roleARN,
+			TokenFile:   value(s3WebIdentityTokenFileKey),
+			STSEndpoint: value(s3STSEndpointKey),
+		}
+	}
+	return config, nil
+}
+
+// uploadExport writes the JWKS and then the discovery document to the bucket, so that the
+// document never references keys that are not published yet
+func uploadExport(ctx context.Context, config objectstorage.S3Config, prefix string, discovery, keys []byte) error {
+	client, err := objectstorage.NewS3Client(config)
+	if err != nil {
+		return err
+	}
+	if err := client.PutObject(ctx, path.Join(prefix, ExportKeysKey), jsonContentType, keys); err != nil {
+		return err
+	}
+	return client.PutObject(ctx, path.Join(prefix, discoveryDocumentObjectKey), jsonContentType, discovery)
+}
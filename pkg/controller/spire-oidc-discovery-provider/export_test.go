@@ -0,0 +1,320 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	spiretypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/objectstorage"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	spireapifakes "github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi/fakes"
+)
+
+// newBundleAPI returns a fake SPIRE server API serving a trust bundle with the JWT authorities
+func newBundleAPI(authorities ...*spiretypes.JWTKey) *spireapifakes.FakeClient {
+	api := &spireapifakes.FakeClient{}
+	api.GetBundleReturns(&spiretypes.Bundle{JwtAuthorities: authorities}, nil)
+	return api
+}
+
+// fakeS3 is a local S3 stand-in that records the uploaded objects
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	puts    int
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	fake := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		if r.Method != http.MethodPut || r.Header.Get("Authorization") == "" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		fake.objects[r.URL.Path] = body
+		fake.puts++
+	}))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func jwtAuthority(t *testing.T, keyID string) *spiretypes.JWTKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	require.NoError(t, err)
+	return &spiretypes.JWTKey{PublicKey: der, KeyId: keyID}
+}
+
+func newExportTestReconciler(fakeClient *fakes.FakeCustomCtrlClient, api *spireapifakes.FakeClient) *SpireOidcDiscoveryProviderReconciler {
+	reconciler := newDeploymentTestReconciler(fakeClient)
+	reconciler.dialServerAPI = func() (spireapi.Client, error) {
+		if api == nil {
+			return nil, errors.New("socket not found")
+		}
+		return api, nil
+	}
+	return reconciler
+}
+
+func createExportTestOIDC(export *v1alpha1.OIDCDocumentExport) *v1alpha1.SpireOIDCDiscoveryProvider {
+	oidc := createDeploymentTestOIDCCR()
+	oidc.Spec.JwtIssuer = "https://storage.example.com/oidc/cluster-a"
+	oidc.Spec.Export = export
+	return oidc
+}
+
+func documentExportedCondition(t *testing.T, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) *metav1.Condition {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), oidc, func() *v1alpha1.ConditionalStatus {
+		return &oidc.Status.ConditionalStatus
+	}))
+	condition := apimeta.FindStatusCondition(oidc.Status.Conditions, DocumentExported)
+	require.NotNil(t, condition)
+	return condition
+}
+
+func TestGenerateDiscoveryDocuments(t *testing.T) {
+	tainted := jwtAuthority(t, "tainted")
+	tainted.Tainted = true
+	bundle := &spiretypes.Bundle{JwtAuthorities: []*spiretypes.JWTKey{jwtAuthority(t, "active"), tainted}}
+
+	t.Run("defaults", func(t *testing.T) {
+		discovery, keys, keyIDs, err := generateDiscoveryDocuments(createExportTestOIDC(nil), bundle)
+
+		require.NoError(t, err)
+		var document map[string]interface{}
+		require.NoError(t, json.Unmarshal(discovery, &document))
+		assert.Equal(t, "https://storage.example.com/oidc/cluster-a", document["issuer"])
+		assert.Equal(t, "https://storage.example.com/oidc/cluster-a/keys", document["jwks_uri"])
+		assert.Equal(t, []interface{}{"id_token"}, document["response_types_supported"])
+
+		var keySet struct {
+			Keys []map[string]interface{} `json:"keys"`
+		}
+		require.NoError(t, json.Unmarshal(keys, &keySet))
+		require.Len(t, keySet.Keys, 1, "tainted authorities must not be exported")
+		assert.Equal(t, "active", keySet.Keys[0]["kid"])
+		assert.Equal(t, "EC", keySet.Keys[0]["kty"])
+		assert.Equal(t, "P-256", keySet.Keys[0]["crv"])
+		assert.NotContains(t, keySet.Keys[0], "use")
+		assert.Equal(t, []string{"active"}, keyIDs)
+	})
+
+	t.Run("jwks uri and key use", func(t *testing.T) {
+		oidc := createExportTestOIDC(nil)
+		oidc.Spec.JwksURI = "https://cdn.example.com/keys/"
+		oidc.Spec.SetKeyUse = "true"
+
+		discovery, keys, _, err := generateDiscoveryDocuments(oidc, bundle)
+
+		require.NoError(t, err)
+		assert.Contains(t, string(discovery), `"jwks_uri": "https://cdn.example.com/keys"`)
+		assert.Contains(t, string(keys), `"use": "sig"`)
+	})
+
+	t.Run("no usable keys", func(t *testing.T) {
+		_, _, _, err := generateDiscoveryDocuments(createExportTestOIDC(nil), &spiretypes.Bundle{JwtAuthorities: []*spiretypes.JWTKey{tainted}})
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "no usable JWT authorities")
+	})
+}
+
+func TestReconcileExport(t *testing.T) {
+	t.Run("writes the documents into the ConfigMap", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		api := newBundleAPI(jwtAuthority(t, "key-1"))
+		reconciler := newExportTestReconciler(fakeClient, api)
+
+		oidc := createExportTestOIDC(&v1alpha1.OIDCDocumentExport{ConfigMapName: "oidc-export"})
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileExport(context.Background(), oidc, statusMgr, false)
+
+		assert.Equal(t, defaultExportRefreshInterval, next)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		cm, ok := created.(*corev1.ConfigMap)
+		require.True(t, ok, "expected a ConfigMap, got %T", created)
+		assert.Equal(t, "oidc-export", cm.Name)
+		assert.Contains(t, cm.Data[ExportDiscoveryDocumentKey], `"issuer": "https://storage.example.com/oidc/cluster-a"`)
+		assert.Contains(t, cm.Data[ExportKeysKey], `"kid": "key-1"`)
+		require.Len(t, cm.OwnerReferences, 1)
+		require.NotNil(t, oidc.Status.Export)
+		assert.Equal(t, []string{"key-1"}, oidc.Status.Export.KeyIDs)
+		assert.Equal(t, "oidc-export", oidc.Status.Export.ConfigMapName)
+		assert.NotNil(t, oidc.Status.Export.LastExportTime)
+		assert.Equal(t, metav1.ConditionTrue, documentExportedCondition(t, oidc, statusMgr).Status)
+	})
+
+	t.Run("uploads to S3 only when the keys rotate", func(t *testing.T) {
+		fake, server := newFakeS3(t)
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.UncachedGetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			obj.(*corev1.Secret).Data = map[string][]byte{
+				"endpoint":        []byte(server.URL),
+				"accessKeyID":     []byte("id"),
+				"secretAccessKey": []byte("secret"),
+			}
+			return nil
+		}
+		key1 := jwtAuthority(t, "key-1")
+		api := newBundleAPI(key1)
+		reconciler := newExportTestReconciler(fakeClient, api)
+
+		oidc := createExportTestOIDC(&v1alpha1.OIDCDocumentExport{
+			S3: &v1alpha1.OIDCS3Export{Bucket: "oidc", Prefix: "cluster-a", SecretRef: "oidc-bucket"},
+		})
+
+		reconciler.reconcileExport(context.Background(), oidc, status.NewManager(fakeClient), false)
+
+		assert.Equal(t, 2, fake.puts)
+		assert.Contains(t, string(fake.objects["/oidc/cluster-a/.well-known/openid-configuration"]), `"jwks_uri": "https://storage.example.com/oidc/cluster-a/keys"`)
+		assert.Contains(t, string(fake.objects["/oidc/cluster-a/keys"]), `"kid": "key-1"`)
+		_, secretKey, _ := fakeClient.UncachedGetArgsForCall(0)
+		assert.Equal(t, "oidc-bucket", secretKey.Name)
+
+		reconciler.reconcileExport(context.Background(), oidc, status.NewManager(fakeClient), false)
+		assert.Equal(t, 2, fake.puts, "unchanged documents must not be uploaded again")
+
+		api.GetBundleReturns(&spiretypes.Bundle{JwtAuthorities: []*spiretypes.JWTKey{key1, jwtAuthority(t, "key-2")}}, nil)
+		reconciler.reconcileExport(context.Background(), oidc, status.NewManager(fakeClient), false)
+		assert.Equal(t, 4, fake.puts)
+		assert.Contains(t, string(fake.objects["/oidc/cluster-a/keys"]), `"kid": "key-2"`)
+		assert.Equal(t, []string{"key-1", "key-2"}, oidc.Status.Export.KeyIDs)
+	})
+
+	t.Run("reports an incomplete S3 secret", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.UncachedGetReturns(nil)
+		api := newBundleAPI(jwtAuthority(t, "key-1"))
+		reconciler := newExportTestReconciler(fakeClient, api)
+
+		oidc := createExportTestOIDC(&v1alpha1.OIDCDocumentExport{
+			S3: &v1alpha1.OIDCS3Export{Bucket: "oidc", SecretRef: "oidc-bucket"},
+		})
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileExport(context.Background(), oidc, statusMgr, false)
+
+		condition := documentExportedCondition(t, oidc, statusMgr)
+		assert.Equal(t, "InvalidS3Configuration", condition.Reason)
+		assert.Contains(t, condition.Message, "has no endpoint key")
+		assert.Empty(t, oidc.Status.Export.DocumentHash)
+	})
+
+	t.Run("reports an unreachable server API", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newExportTestReconciler(fakeClient, nil)
+
+		oidc := createExportTestOIDC(&v1alpha1.OIDCDocumentExport{ConfigMapName: "oidc-export"})
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileExport(context.Background(), oidc, statusMgr, false)
+
+		assert.Equal(t, "ServerAPIUnavailable", documentExportedCondition(t, oidc, statusMgr).Reason)
+		assert.Zero(t, fakeClient.CreateCallCount())
+	})
+
+	t.Run("disabling the export deletes the managed ConfigMap", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			obj.SetName(key.Name)
+			obj.SetNamespace(key.Namespace)
+			obj.SetLabels(utils.SpireOIDCDiscoveryProviderLabels(nil))
+			return nil
+		}
+		reconciler := newExportTestReconciler(fakeClient, nil)
+
+		oidc := createExportTestOIDC(nil)
+		oidc.Status.Export = &v1alpha1.OIDCDocumentExportStatus{ConfigMapName: "oidc-export"}
+
+		next := reconciler.reconcileExport(context.Background(), oidc, status.NewManager(fakeClient), false)
+
+		assert.Zero(t, next)
+		require.Equal(t, 1, fakeClient.DeleteCallCount())
+		_, deleted, _ := fakeClient.DeleteArgsForCall(0)
+		assert.Equal(t, "oidc-export", deleted.GetName())
+		assert.Nil(t, oidc.Status.Export)
+	})
+}
+
+func TestExportS3Config(t *testing.T) {
+	exportS3Config := func(t *testing.T, data map[string]string) (objectstorage.S3Config, error) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.UncachedGetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			secret := obj.(*corev1.Secret)
+			secret.Data = map[string][]byte{}
+			for k, v := range data {
+				secret.Data[k] = []byte(v)
+			}
+			return nil
+		}
+		reconciler := newExportTestReconciler(fakeClient, newBundleAPI())
+		return reconciler.exportS3Config(context.Background(), &v1alpha1.OIDCS3Export{Bucket: "oidc", SecretRef: "oidc-bucket"})
+	}
+
+	t.Run("temporary access keys", func(t *testing.T) {
+		config, err := exportS3Config(t, map[string]string{
+			"endpoint":        "https://s3.example.com",
+			"accessKeyID":     "ASIAEXAMPLE",
+			"secretAccessKey": "secret",
+			"sessionToken":    " token\n",
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "token", config.SessionToken)
+		assert.Nil(t, config.WebIdentity)
+	})
+
+	t.Run("role ARN replaces the access keys", func(t *testing.T) {
+		config, err := exportS3Config(t, map[string]string{
+			"endpoint":    "https://s3.example.com",
+			"region":      "eu-west-1",
+			"roleARN":     "arn:aws:iam::123456789012:role/oidc",
+			"stsEndpoint": "https://sts.eu-west-1.amazonaws.com",
+		})
+
+		require.NoError(t, err)
+		assert.Empty(t, config.AccessKeyID)
+		require.NotNil(t, config.WebIdentity)
+		assert.Equal(t, "arn:aws:iam::123456789012:role/oidc", config.WebIdentity.RoleARN)
+		assert.Equal(t, "https://sts.eu-west-1.amazonaws.com", config.WebIdentity.STSEndpoint)
+		assert.Empty(t, config.WebIdentity.TokenFile)
+	})
+
+	t.Run("access keys are required without a role ARN", func(t *testing.T) {
+		_, err := exportS3Config(t, map[string]string{"endpoint": "https://s3.example.com"})
+
+		assert.ErrorContains(t, err, "has no accessKeyID key")
+	})
+}
@@ -0,0 +1,325 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"fmt"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	oidcExposureName    = "spire-oidc-discovery-provider"
+	oidcServiceName     = "spire-spiffe-oidc-discovery-provider"
+	oidcServicePortName = "https"
+	oidcServicePort     = 443
+)
+
+// generateOIDCExposedEndpoint describes the OIDC discovery endpoint published by an Ingress or Gateway API route.
+// The endpoint is re-encrypted, unless the provider terminates TLS itself with an ACME certificate.
+func generateOIDCExposedEndpoint(oidc *v1alpha1.SpireOIDCDiscoveryProvider) (utils.ExposedEndpoint, error) {
+	host, err := utils.StripProtocolFromJWTIssuer(oidc.Spec.JwtIssuer)
+	if err != nil {
+		return utils.ExposedEndpoint{}, fmt.Errorf("invalid JWT issuer URL: %w", err)
+	}
+	endpoint := utils.ExposedEndpoint{
+		Name:            oidcExposureName,
+		Host:            host,
+		Labels:          utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels),
+		ServiceName:     oidcServiceName,
+		ServicePortName: oidcServicePortName,
+		ServicePort:     oidcServicePort,
+		Passthrough:     isACMEEnabled(oidc),
+	}
+	if !endpoint.Passthrough {
+		endpoint.TLSSecretName = oidc.Spec.ExternalSecretRef
+	}
+	// The previous issuer host is routed alongside while relying parties move to the new issuer
+	if oidc.Spec.PreviousJwtIssuer != "" {
+		previousHost, err := utils.StripProtocolFromJWTIssuer(oidc.Spec.PreviousJwtIssuer)
+		if err != nil {
+			return utils.ExposedEndpoint{}, fmt.Errorf("invalid previous JWT issuer URL: %w", err)
+		}
+		if previousHost != host {
+			endpoint.AdditionalHosts = []string{previousHost}
+		}
+	}
+	return endpoint, nil
+}
+
+// reconcileExposureIngress reconciles the Ingress exposing the OIDC Discovery Provider and returns its host
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileExposureIngress(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) (string, error) {
+	endpoint, err := generateOIDCExposedEndpoint(oidc)
+	if err != nil {
+		r.log.Error(err, "Failed to generate OIDC discovery provider ingress")
+		statusMgr.AddCondition(RouteAvailable, "ManagedIngressCreationFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	desired := utils.GenerateExposureIngress(endpoint, oidc.Spec.Exposure)
+	if err := controllerutil.SetControllerReference(oidc, desired, r.scheme); err != nil {
+		r.log.Error(err, "Failed to set controller reference on ingress")
+		statusMgr.AddCondition(RouteAvailable, "ManagedIngressCreationFailed",
+			fmt.Sprintf("Failed to set owner reference on Ingress: %v", err),
+			metav1.ConditionFalse)
+		return "", err
+	}
+
+	existing := &networkingv1.Ingress{}
+	err = r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "Failed to get existing ingress")
+			statusMgr.AddCondition(RouteAvailable, "ManagedIngressRetrievalFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, RouteAvailable); conflictErr != nil {
+				return "", conflictErr
+			}
+			r.log.Error(err, "Failed to create ingress")
+			statusMgr.AddCondition(RouteAvailable, "ManagedIngressCreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		statusMgr.AddCondition(RouteAvailable, "ManagedIngressCreated",
+			"Spire OIDC Managed Ingress created",
+			metav1.ConditionTrue)
+		r.log.Info("Created ingress", "Namespace", desired.Namespace, "Name", desired.Name)
+		return endpoint.Host, nil
+	}
+
+	if !utils.IngressNeedsUpdate(existing, desired) {
+		r.markExposureReady(oidc, statusMgr, "ManagedIngressReady", "Spire OIDC Managed Ingress is ready")
+		return utils.IngressHost(existing), nil
+	}
+	if createOnlyMode {
+		r.log.Info("Skipping Ingress update due to create-only mode", "Namespace", desired.Namespace, "Name", desired.Name)
+		return utils.IngressHost(existing), nil
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	desired.Status = existing.Status
+	// Keep annotations added by the ingress controller alongside the configured ones
+	for k, v := range existing.Annotations {
+		if _, ok := desired.Annotations[k]; !ok {
+			desired.Annotations[k] = v
+		}
+	}
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		statusMgr.AddCondition(RouteAvailable, "ManagedIngressUpdateFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	statusMgr.AddCondition(RouteAvailable, "ManagedIngressUpdated",
+		"Spire OIDC Managed Ingress updated",
+		metav1.ConditionTrue)
+	r.log.Info("Updated ingress", "Namespace", desired.Namespace, "Name", desired.Name)
+	return endpoint.Host, nil
+}
+
+// reconcileExposureGatewayRoute reconciles the Gateway API route exposing the OIDC Discovery Provider and
+// returns its host. With an HTTPRoute the Gateway must re-encrypt to the provider, which serves the service
+// CA certificate; with ACME a TLSRoute passes TLS through to the provider.
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileExposureGatewayRoute(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) (string, error) {
+	kind := string(utils.EndpointExposureType(oidc.Spec.Exposure))
+	endpoint, err := generateOIDCExposedEndpoint(oidc)
+	if err != nil {
+		r.log.Error(err, "Failed to generate OIDC discovery provider gateway route", "Kind", kind)
+		statusMgr.AddCondition(RouteAvailable, "Managed"+kind+"CreationFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	desired := utils.GenerateExposureGatewayRoute(endpoint, oidc.Spec.Exposure)
+	if err := controllerutil.SetControllerReference(oidc, desired, r.scheme); err != nil {
+		r.log.Error(err, "Failed to set controller reference on gateway route", "Kind", kind)
+		statusMgr.AddCondition(RouteAvailable, "Managed"+kind+"CreationFailed",
+			fmt.Sprintf("Failed to set owner reference on %s: %v", kind, err),
+			metav1.ConditionFalse)
+		return "", err
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(desired.GroupVersionKind())
+	err = utils.GetGatewayObject(ctx, r.ctrlClient, r.gatewayAPIKinds, types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, existing)
+	if err != nil {
+		if apimeta.IsNoMatchError(err) {
+			err = fmt.Errorf("the Gateway API %s kind is not installed in the cluster", desired.GetKind())
+			r.log.Error(err, "Failed to get existing gateway route", "Kind", kind)
+			statusMgr.AddCondition(RouteAvailable, "GatewayAPINotInstalled",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "Failed to get existing gateway route", "Kind", kind)
+			statusMgr.AddCondition(RouteAvailable, "Managed"+kind+"RetrievalFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, RouteAvailable); conflictErr != nil {
+				return "", conflictErr
+			}
+			r.log.Error(err, "Failed to create gateway route", "Kind", kind)
+			statusMgr.AddCondition(RouteAvailable, "Managed"+kind+"CreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		statusMgr.AddCondition(RouteAvailable, "Managed"+kind+"Created",
+			fmt.Sprintf("Spire OIDC Managed %s created", kind),
+			metav1.ConditionTrue)
+		r.log.Info("Created gateway route", "Kind", kind, "Namespace", desired.GetNamespace(), "Name", desired.GetName())
+		return endpoint.Host, nil
+	}
+
+	if err := utils.CheckResourceConflict(existing); err != nil {
+		r.log.Error(err, "resource conflict detected")
+		statusMgr.AddCondition(RouteAvailable, "ResourceConflict",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	if !utils.GatewayObjectNeedsUpdate(existing, desired) {
+		r.markExposureReady(oidc, statusMgr, "Managed"+kind+"Ready", fmt.Sprintf("Spire OIDC Managed %s is ready", kind))
+		return utils.GatewayRouteHost(existing), nil
+	}
+	if createOnlyMode {
+		r.log.Info("Skipping gateway route update due to create-only mode", "Kind", kind, "Namespace", desired.GetNamespace(), "Name", desired.GetName())
+		return utils.GatewayRouteHost(existing), nil
+	}
+
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	if existingStatus, ok := existing.Object["status"]; ok {
+		desired.Object["status"] = existingStatus
+	}
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		statusMgr.AddCondition(RouteAvailable, "Managed"+kind+"UpdateFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	statusMgr.AddCondition(RouteAvailable, "Managed"+kind+"Updated",
+		fmt.Sprintf("Spire OIDC Managed %s updated", kind),
+		metav1.ConditionTrue)
+	r.log.Info("Updated gateway route", "Kind", kind, "Namespace", desired.GetNamespace(), "Name", desired.GetName())
+	return endpoint.Host, nil
+}
+
+// reconcileExposureBackendTLS reconciles the BackendTLSPolicy of the HTTPRoute, without which the Gateway
+// would not connect to the provider with TLS
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileExposureBackendTLS(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) error {
+	endpoint, err := generateOIDCExposedEndpoint(oidc)
+	if err == nil {
+		err = utils.ReconcileExposureBackendTLS(ctx, r.ctrlClient, r.scheme, oidc, endpoint, r.gatewayAPIKinds, createOnlyMode)
+	}
+	if err != nil {
+		if apimeta.IsNoMatchError(err) {
+			err = fmt.Errorf("the Gateway API BackendTLSPolicy kind is not installed in the cluster, the Gateway cannot re-encrypt to the provider")
+		}
+		r.log.Error(err, "Failed to reconcile the BackendTLSPolicy of the HTTPRoute")
+		statusMgr.AddCondition(RouteAvailable, "BackendTLSPolicyFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+	return nil
+}
+
+// markExposureReady sets RouteAvailable when the exposure is up to date, without
+// overwriting the reason of an already ready condition
+func (r *SpireOidcDiscoveryProviderReconciler) markExposureReady(oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, reason, message string) {
+	existingCondition := apimeta.FindStatusCondition(oidc.Status.ConditionalStatus.Conditions, RouteAvailable)
+	if existingCondition == nil || existingCondition.Status != metav1.ConditionTrue {
+		statusMgr.AddCondition(RouteAvailable, reason, message, metav1.ConditionTrue)
+	}
+}
+
+// deleteStaleExposure removes the Route, Ingress, HTTPRoute or TLSRoute left over from a previous exposure type
+func (r *SpireOidcDiscoveryProviderReconciler) deleteStaleExposure(ctx context.Context, exposureType v1alpha1.EndpointExposureType, statusMgr *status.Manager) error {
+	if exposureType != v1alpha1.RouteExposureType {
+		if err := r.deleteExposureObject(ctx, &routev1.Route{}, "Route", oidcExposureName, statusMgr); err != nil {
+			return err
+		}
+	}
+	if exposureType != v1alpha1.IngressExposureType {
+		if err := r.deleteExposureObject(ctx, &networkingv1.Ingress{}, "Ingress", oidcExposureName, statusMgr); err != nil {
+			return err
+		}
+	}
+	for _, gvk := range []schema.GroupVersionKind{utils.HTTPRouteGVK, utils.TLSRouteGVK} {
+		if string(exposureType) == gvk.Kind {
+			continue
+		}
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(gvk)
+		if err := r.deleteExposureObject(ctx, route, gvk.Kind, oidcExposureName, statusMgr); err != nil {
+			return err
+		}
+	}
+	if exposureType != v1alpha1.HTTPRouteExposureType {
+		for _, gvk := range utils.BackendTLSPolicyGVKs {
+			policy := &unstructured.Unstructured{}
+			policy.SetGroupVersionKind(gvk)
+			if err := r.deleteExposureObject(ctx, policy, gvk.Kind, oidcExposureName, statusMgr); err != nil {
+				return err
+			}
+		}
+		if err := r.deleteExposureObject(ctx, &corev1.ConfigMap{}, "ConfigMap", utils.BackendCAConfigMapName(oidcExposureName), statusMgr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExposureObject deletes the operator-managed exposure object of the given kind and name, if any.
+// Gateway API kinds are treated as absent when their CRD is not installed.
+func (r *SpireOidcDiscoveryProviderReconciler) deleteExposureObject(ctx context.Context, obj client.Object, kind, name string, statusMgr *status.Manager) error {
+	key := types.NamespacedName{Name: name, Namespace: utils.GetOperatorNamespace()}
+	var err error
+	if gatewayObject, ok := obj.(*unstructured.Unstructured); ok {
+		err = utils.GetGatewayObject(ctx, r.ctrlClient, r.gatewayAPIKinds, key, gatewayObject)
+	} else {
+		err = r.ctrlClient.Get(ctx, key, obj)
+	}
+	if kerrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+		return nil
+	}
+	if err == nil {
+		if utils.CheckResourceConflict(obj) != nil {
+			// Not created by the operator, leave it alone
+			return nil
+		}
+		err = r.ctrlClient.Delete(ctx, obj)
+	}
+	if err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "Failed to delete stale exposure", "Kind", kind, "Name", name)
+		statusMgr.AddCondition(RouteAvailable, "ExposureCleanupFailed",
+			fmt.Sprintf("Failed to delete previous %s %s: %v", kind, name, err),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Deleted stale exposure", "Kind", kind, "Name", name)
+	return nil
+}
@@ -0,0 +1,317 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func createExposureTestOIDC(exposure *v1alpha1.EndpointExposure) *v1alpha1.SpireOIDCDiscoveryProvider {
+	return &v1alpha1.SpireOIDCDiscoveryProvider{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+			UID:  "test-uid",
+		},
+		Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+			ManagedRoute:      "true",
+			JwtIssuer:         "https://oidc.example.com",
+			ExternalSecretRef: "oidc-tls",
+			Exposure:          exposure,
+		},
+	}
+}
+
+func routeAvailableCondition(t *testing.T, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) *metav1.Condition {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), oidc, func() *v1alpha1.ConditionalStatus {
+		return &oidc.Status.ConditionalStatus
+	}))
+	condition := apimeta.FindStatusCondition(oidc.Status.Conditions, RouteAvailable)
+	require.NotNil(t, condition)
+	return condition
+}
+
+// useTestServiceCA points the service CA bundle to a temporary file for the duration of the test
+func useTestServiceCA(t *testing.T) {
+	t.Helper()
+	serviceCAFile := filepath.Join(t.TempDir(), "service-ca.crt")
+	require.NoError(t, os.WriteFile(serviceCAFile, []byte("test service CA"), 0o600))
+	previous := utils.ServiceCAFile
+	utils.ServiceCAFile = serviceCAFile
+	t.Cleanup(func() { utils.ServiceCAFile = previous })
+}
+
+func TestReconcileRoute_Exposure(t *testing.T) {
+	t.Run("creates ingress and reports its host", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType, ClassName: "nginx"})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		ingress, ok := created.(*networkingv1.Ingress)
+		require.True(t, ok, "expected an Ingress, got %T", created)
+		assert.Equal(t, "oidc.example.com", ingress.Spec.Rules[0].Host)
+		assert.Equal(t, "spire-spiffe-oidc-discovery-provider", ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name)
+		require.Len(t, ingress.Spec.TLS, 1)
+		assert.Equal(t, "oidc-tls", ingress.Spec.TLS[0].SecretName)
+		assert.Equal(t, "reencrypt", ingress.Annotations[utils.RouteTerminationAnnotation])
+		require.Len(t, ingress.OwnerReferences, 1)
+		assert.Equal(t, "oidc.example.com", oidc.Status.Hostname)
+		assert.Zero(t, fakeClient.DeleteCallCount())
+	})
+
+	t.Run("creates HTTPRoute attached to the gateway", func(t *testing.T) {
+		useTestServiceCA(t)
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{
+			Type:       v1alpha1.HTTPRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public", Namespace: "gateways"}},
+		})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 3, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		route, ok := created.(*unstructured.Unstructured)
+		require.True(t, ok, "expected an unstructured route, got %T", created)
+		assert.Equal(t, utils.HTTPRouteGVK, route.GroupVersionKind())
+		assert.Equal(t, "oidc.example.com", utils.GatewayRouteHost(route))
+
+		// The Gateway verifies the provider with the service CA
+		_, created, _ = fakeClient.CreateArgsForCall(1)
+		configMap, ok := created.(*corev1.ConfigMap)
+		require.True(t, ok, "expected the backend CA ConfigMap, got %T", created)
+		assert.Equal(t, "test service CA", configMap.Data["ca.crt"])
+		_, created, _ = fakeClient.CreateArgsForCall(2)
+		policy, ok := created.(*unstructured.Unstructured)
+		require.True(t, ok, "expected a BackendTLSPolicy, got %T", created)
+		assert.Equal(t, utils.BackendTLSPolicyGVKs[0], policy.GroupVersionKind())
+		require.Len(t, policy.GetOwnerReferences(), 1)
+
+		assert.Equal(t, "oidc.example.com", oidc.Status.Hostname)
+		assert.Equal(t, "ManagedHTTPRouteCreated", routeAvailableCondition(t, oidc, statusMgr).Reason)
+	})
+
+	t.Run("reports missing BackendTLSPolicy", func(t *testing.T) {
+		useTestServiceCA(t)
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			if obj.GetObjectKind().GroupVersionKind().Kind == "BackendTLSPolicy" {
+				return &apimeta.NoKindMatchError{GroupKind: utils.BackendTLSPolicyGVKs[0].GroupKind()}
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		}
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{
+			Type:       v1alpha1.HTTPRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+		})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.Error(t, err)
+		condition := routeAvailableCondition(t, oidc, statusMgr)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "BackendTLSPolicyFailed", condition.Reason)
+		assert.Contains(t, condition.Message, "BackendTLSPolicy kind is not installed")
+	})
+
+	t.Run("reports missing Gateway API", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(&apimeta.NoKindMatchError{GroupKind: utils.HTTPRouteGVK.GroupKind()})
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{
+			Type:       v1alpha1.HTTPRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+		})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.Error(t, err)
+		assert.Zero(t, fakeClient.CreateCallCount())
+		condition := routeAvailableCondition(t, oidc, statusMgr)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "GatewayAPINotInstalled", condition.Reason)
+	})
+
+	t.Run("leaves a foreign HTTPRoute untouched", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(nil)
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{
+			Type:       v1alpha1.HTTPRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+		})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.Error(t, err)
+		assert.Zero(t, fakeClient.UpdateCallCount())
+		assert.Equal(t, "ResourceConflict", routeAvailableCondition(t, oidc, statusMgr).Reason)
+	})
+
+	t.Run("switching to ingress deletes the managed route", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			if route, ok := obj.(*routev1.Route); ok && key.Name == oidcExposureName {
+				route.Name = key.Name
+				route.Namespace = key.Namespace
+				route.Labels = utils.SpireOIDCDiscoveryProviderLabels(nil)
+				return nil
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		}
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.DeleteCallCount())
+		_, deleted, _ := fakeClient.DeleteArgsForCall(0)
+		assert.IsType(t, &routev1.Route{}, deleted)
+	})
+
+	t.Run("rejects TLSRoute for the reencrypt endpoint", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{
+			Type:       v1alpha1.TLSRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+		})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Zero(t, fakeClient.CreateCallCount())
+		assert.Equal(t, "InvalidExposure", routeAvailableCondition(t, oidc, statusMgr).Reason)
+	})
+
+	t.Run("creates TLSRoute when the provider terminates TLS with acme", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{
+			Type:       v1alpha1.TLSRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+		})
+		oidc.Spec.ExternalSecretRef = ""
+		oidc.Spec.Acme = &v1alpha1.OIDCAcmeConfig{Email: "admin@example.com", TosAccepted: "true"}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		route, ok := created.(*unstructured.Unstructured)
+		require.True(t, ok, "expected an unstructured route, got %T", created)
+		assert.Equal(t, utils.TLSRouteGVK, route.GroupVersionKind())
+		assert.Equal(t, "oidc.example.com", utils.GatewayRouteHost(route))
+		assert.Equal(t, "ManagedTLSRouteCreated", routeAvailableCondition(t, oidc, statusMgr).Reason)
+	})
+
+	t.Run("rejects HTTPRoute when the provider terminates TLS with acme", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{
+			Type:       v1alpha1.HTTPRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+		})
+		oidc.Spec.ExternalSecretRef = ""
+		oidc.Spec.Acme = &v1alpha1.OIDCAcmeConfig{Email: "admin@example.com", TosAccepted: "true"}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Zero(t, fakeClient.CreateCallCount())
+		assert.Equal(t, "InvalidExposure", routeAvailableCondition(t, oidc, statusMgr).Reason)
+	})
+
+	t.Run("ingress passes TLS through with acme", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		oidc := createExposureTestOIDC(&v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType})
+		oidc.Spec.ExternalSecretRef = ""
+		oidc.Spec.Acme = &v1alpha1.OIDCAcmeConfig{Email: "admin@example.com", TosAccepted: "true"}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		ingress, ok := created.(*networkingv1.Ingress)
+		require.True(t, ok, "expected an Ingress, got %T", created)
+		assert.Empty(t, ingress.Spec.TLS)
+		assert.Equal(t, "passthrough", ingress.Annotations[utils.RouteTerminationAnnotation])
+	})
+
+	t.Run("disabling the managed route clears the hostname", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+
+		oidc := createExposureTestOIDC(nil)
+		oidc.Spec.ManagedRoute = "false"
+		oidc.Status.Hostname = "oidc.example.com"
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Empty(t, oidc.Status.Hostname)
+	})
+}
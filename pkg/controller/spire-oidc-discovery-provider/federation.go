@@ -0,0 +1,346 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec // AWS identifies OIDC provider CAs by their SHA-1 thumbprint
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// CloudFederationReady reports whether the cloud federation artifacts are rendered
+	CloudFederationReady = "CloudFederationReady"
+
+	cloudFederationConfigMapName = "spire-oidc-cloud-federation"
+
+	// Keys of the cloud federation ConfigMap
+	AWSOIDCProviderKey                 = "aws-oidc-provider.json"
+	AWSTrustPolicyKey                  = "aws-trust-policy.json"
+	GCPWorkloadIdentityPoolKey         = "gcp-workload-identity-pool.json"
+	GCPWorkloadIdentityPoolProviderKey = "gcp-workload-identity-pool-provider.json"
+	AzureFederatedCredentialsKey       = "azure-federated-credentials.json"
+	CAThumbprintsKey                   = "ca-thumbprints"
+
+	defaultAWSPartition  = "aws"
+	defaultAWSAudience   = "sts.amazonaws.com"
+	defaultAzureAudience = "api://AzureADTokenExchange"
+
+	// cloudFederationCheckInterval is how often the certificate of the issuer is checked for changes
+	cloudFederationCheckInterval = time.Hour
+	// azureCredentialNameMinLength and azureCredentialNameMaxLength bound the name Azure accepts for a
+	// federated identity credential
+	azureCredentialNameMinLength = 3
+	azureCredentialNameMaxLength = 120
+)
+
+var azureCredentialNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// CertificateChainFunc returns the verified certificate chain served at addr for serverName
+type CertificateChainFunc func(ctx context.Context, addr, serverName string) ([]*x509.Certificate, error)
+
+// fetchServedCertificateChain connects to addr and returns the certificate chain served for serverName,
+// once verified against the system roots as cloud providers do
+func fetchServedCertificateChain(ctx context.Context, addr, serverName string) ([]*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: certificateDialTimeout},
+		Config: &tls.Config{
+			ServerName: serverName,
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer conn.Close()
+	return conn.(*tls.Conn).ConnectionState().PeerCertificates, nil
+}
+
+// caThumbprint returns the SHA-1 thumbprint of the top CA certificate of the chain, as registered in an
+// AWS IAM OIDC identity provider
+func caThumbprint(chain []*x509.Certificate) string {
+	sum := sha1.Sum(chain[len(chain)-1].Raw) //nolint:gosec
+	return hex.EncodeToString(sum[:])
+}
+
+type awsOIDCProvider struct {
+	URL            string   `json:"Url"`
+	ClientIDList   []string `json:"ClientIDList"`
+	ThumbprintList []string `json:"ThumbprintList,omitempty"`
+}
+
+type awsTrustPolicy struct {
+	Version   string                 `json:"Version"`
+	Statement []awsTrustPolicyClause `json:"Statement"`
+}
+
+type awsTrustPolicyClause struct {
+	Effect    string                         `json:"Effect"`
+	Principal map[string]string              `json:"Principal"`
+	Action    string                         `json:"Action"`
+	Condition map[string]map[string][]string `json:"Condition"`
+}
+
+type gcpWorkloadIdentityPool struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+}
+
+type gcpWorkloadIdentityPoolProvider struct {
+	Name               string            `json:"name"`
+	DisplayName        string            `json:"displayName"`
+	AttributeMapping   map[string]string `json:"attributeMapping"`
+	AttributeCondition string            `json:"attributeCondition"`
+	OIDC               gcpOIDC           `json:"oidc"`
+}
+
+type gcpOIDC struct {
+	IssuerURI        string   `json:"issuerUri"`
+	AllowedAudiences []string `json:"allowedAudiences"`
+}
+
+type azureFederatedCredential struct {
+	Name        string   `json:"name"`
+	Issuer      string   `json:"issuer"`
+	Subject     string   `json:"subject"`
+	Description string   `json:"description"`
+	Audiences   []string `json:"audiences"`
+}
+
+// azureCredentialName derives a federated identity credential name from a SPIFFE ID. Names Azure would
+// reject for their length, or already used, get a hash of the SPIFFE ID appended.
+func azureCredentialName(subject string, used map[string]bool) string {
+	name := strings.Trim(azureCredentialNameInvalidChars.ReplaceAllString(strings.TrimPrefix(subject, "spiffe://"), "-"), "-")
+	if len(name) < azureCredentialNameMinLength || len(name) > azureCredentialNameMaxLength || used[name] {
+		sum := sha256.Sum256([]byte(subject))
+		hash := hex.EncodeToString(sum[:4])
+		name = name[:min(len(name), azureCredentialNameMaxLength-len(hash)-1)]
+		if name == "" {
+			name = hash
+		} else {
+			name += "-" + hash
+		}
+	}
+	used[name] = true
+	return name
+}
+
+// marshalArtifact renders an artifact as indented JSON
+func marshalArtifact(artifact interface{}) (string, error) {
+	data, err := json.MarshalIndent(artifact, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// generateCloudFederationArtifacts renders the cloud federation artifacts for the issuer of the provider,
+// registering the CA thumbprints of the certificate it is served with
+func generateCloudFederationArtifacts(oidc *v1alpha1.SpireOIDCDiscoveryProvider, thumbprints []string) (map[string]string, error) {
+	federation := oidc.Spec.CloudFederation
+	issuer, err := utils.NormalizeURL(oidc.Spec.JwtIssuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT issuer URL: %w", err)
+	}
+	// Cloud providers identify the issuer by its host and path
+	issuerID, err := utils.StripProtocolFromJWTIssuer(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT issuer URL: %w", err)
+	}
+
+	artifacts := map[string]string{}
+	if len(thumbprints) > 0 {
+		artifacts[CAThumbprintsKey] = strings.Join(thumbprints, "\n") + "\n"
+	}
+
+	if aws := federation.AWS; aws != nil {
+		partition := aws.Partition
+		if partition == "" {
+			partition = defaultAWSPartition
+		}
+		audiences := aws.Audiences
+		if len(audiences) == 0 {
+			audiences = []string{defaultAWSAudience}
+		}
+		if artifacts[AWSOIDCProviderKey], err = marshalArtifact(awsOIDCProvider{
+			URL:            issuer,
+			ClientIDList:   audiences,
+			ThumbprintList: thumbprints,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to render AWS OIDC provider: %w", err)
+		}
+
+		if artifacts[AWSTrustPolicyKey], err = marshalArtifact(awsTrustPolicy{
+			Version: "2012-10-17",
+			Statement: []awsTrustPolicyClause{{
+				Effect: "Allow",
+				Principal: map[string]string{
+					"Federated": fmt.Sprintf("arn:%s:iam::%s:oidc-provider/%s", partition, aws.AccountID, issuerID),
+				},
+				Action: "sts:AssumeRoleWithWebIdentity",
+				Condition: map[string]map[string][]string{
+					"StringEquals": {
+						issuerID + ":aud": audiences,
+						issuerID + ":sub": federation.Subjects,
+					},
+				},
+			}},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to render AWS trust policy: %w", err)
+		}
+	}
+
+	if gcp := federation.GCP; gcp != nil {
+		poolName := fmt.Sprintf("projects/%s/locations/global/workloadIdentityPools/%s", gcp.ProjectNumber, gcp.PoolID)
+		providerName := fmt.Sprintf("%s/providers/%s", poolName, gcp.ProviderID)
+		audiences := gcp.Audiences
+		if len(audiences) == 0 {
+			audiences = []string{"//iam.googleapis.com/" + providerName}
+		}
+		if artifacts[GCPWorkloadIdentityPoolKey], err = marshalArtifact(gcpWorkloadIdentityPool{
+			Name:        poolName,
+			DisplayName: gcp.PoolID,
+			Description: "SPIFFE workloads with JWT-SVIDs issued by " + issuer,
+		}); err != nil {
+			return nil, fmt.Errorf("failed to render GCP workload identity pool: %w", err)
+		}
+
+		quoted := make([]string, 0, len(federation.Subjects))
+		for _, subject := range federation.Subjects {
+			quoted = append(quoted, strconv.Quote(subject))
+		}
+		if artifacts[GCPWorkloadIdentityPoolProviderKey], err = marshalArtifact(gcpWorkloadIdentityPoolProvider{
+			Name:               providerName,
+			DisplayName:        gcp.ProviderID,
+			AttributeMapping:   map[string]string{"google.subject": "assertion.sub"},
+			AttributeCondition: fmt.Sprintf("assertion.sub in [%s]", strings.Join(quoted, ", ")),
+			OIDC:               gcpOIDC{IssuerURI: issuer, AllowedAudiences: audiences},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to render GCP workload identity pool provider: %w", err)
+		}
+	}
+
+	if azure := federation.Azure; azure != nil {
+		audience := azure.Audience
+		if audience == "" {
+			audience = defaultAzureAudience
+		}
+		// Azure matches a single subject exactly per federated identity credential
+		used := map[string]bool{}
+		credentials := make([]azureFederatedCredential, 0, len(federation.Subjects))
+		for _, subject := range federation.Subjects {
+			credentials = append(credentials, azureFederatedCredential{
+				Name:        azureCredentialName(subject, used),
+				Issuer:      issuer,
+				Subject:     subject,
+				Description: "JWT-SVIDs of " + subject,
+				Audiences:   []string{audience},
+			})
+		}
+		if artifacts[AzureFederatedCredentialsKey], err = marshalArtifact(credentials); err != nil {
+			return nil, fmt.Errorf("failed to render Azure federated credentials: %w", err)
+		}
+	}
+
+	return artifacts, nil
+}
+
+// issuerThumbprints returns the CA thumbprint of the certificate the issuer is served with
+func (r *SpireOidcDiscoveryProviderReconciler) issuerThumbprints(ctx context.Context, issuer string) ([]string, error) {
+	issuerURL, err := url.Parse(issuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT issuer URL: %w", err)
+	}
+	if issuerURL.Scheme != "https" {
+		return nil, fmt.Errorf("issuer %s is not served over https", issuer)
+	}
+	port := issuerURL.Port()
+	if port == "" {
+		port = "443"
+	}
+	chain, err := r.fetchCertificateChain(ctx, net.JoinHostPort(issuerURL.Hostname(), port), issuerURL.Hostname())
+	if err != nil {
+		return nil, err
+	}
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("%s served no certificate", issuerURL.Host)
+	}
+	return []string{caThumbprint(chain)}, nil
+}
+
+// reconcileCloudFederation renders the cloud federation artifacts into their ConfigMap and reports the
+// result in the CloudFederationReady condition. It returns when the issuer certificate has to be checked again.
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileCloudFederation(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) time.Duration {
+	if oidc.Spec.CloudFederation == nil {
+		// Only clean up after a previous configuration, reported by the condition
+		if apimeta.FindStatusCondition(oidc.Status.Conditions, CloudFederationReady) != nil {
+			if err := r.deleteOwnedConfigMap(ctx, cloudFederationConfigMapName); err != nil {
+				statusMgr.AddCondition(CloudFederationReady, "CleanupFailed", err.Error(), metav1.ConditionFalse)
+				return 0
+			}
+			statusMgr.RemoveCondition(CloudFederationReady)
+		}
+		return 0
+	}
+
+	issuer, err := utils.NormalizeURL(oidc.Spec.JwtIssuer)
+	if err != nil {
+		statusMgr.AddCondition(CloudFederationReady, "InvalidIssuer", err.Error(), metav1.ConditionFalse)
+		return 0
+	}
+	// The artifacts are still rendered without thumbprint, which recent cloud providers do not require
+	thumbprints, thumbprintErr := r.issuerThumbprints(ctx, issuer)
+	if thumbprintErr != nil {
+		r.log.Info("Unable to compute the CA thumbprint of the JWT issuer", "issuer", issuer, "reason", thumbprintErr.Error())
+	}
+
+	artifacts, err := generateCloudFederationArtifacts(oidc, thumbprints)
+	if err != nil {
+		r.log.Error(err, "failed to render the cloud federation artifacts")
+		statusMgr.AddCondition(CloudFederationReady, "ArtifactGenerationFailed", err.Error(), metav1.ConditionFalse)
+		return 0
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cloudFederationConfigMapName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireOIDCDiscoveryProviderLabels(oidc.Spec.Labels),
+		},
+		Data: artifacts,
+	}
+	if err := r.reconcileOwnedConfigMap(ctx, oidc, configMap, statusMgr, CloudFederationReady, createOnlyMode); err != nil {
+		return certificatePendingRetry
+	}
+
+	// Unknown rather than True: the artifacts are usable with recent cloud providers, but older ones
+	// still need the thumbprint, so the condition must not read as fully ready
+	if thumbprintErr != nil {
+		statusMgr.AddCondition(CloudFederationReady, "ThumbprintUnavailable",
+			fmt.Sprintf("Artifacts rendered into ConfigMap %s without CA thumbprint: %v", cloudFederationConfigMapName, thumbprintErr),
+			metav1.ConditionUnknown)
+		return certificatePendingRetry
+	}
+	statusMgr.AddCondition(CloudFederationReady, v1alpha1.ReasonReady,
+		fmt.Sprintf("Artifacts for %d subjects rendered into ConfigMap %s", len(oidc.Spec.CloudFederation.Subjects), cloudFederationConfigMapName),
+		metav1.ConditionTrue)
+	return cloudFederationCheckInterval
+}
@@ -0,0 +1,267 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/sha1" //nolint:gosec
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func createFederationTestOIDC(federation *v1alpha1.OIDCCloudFederation) *v1alpha1.SpireOIDCDiscoveryProvider {
+	oidc := createDeploymentTestOIDCCR()
+	oidc.Spec.JwtIssuer = "https://oidc.example.com/cluster-a/"
+	oidc.Spec.CloudFederation = federation
+	return oidc
+}
+
+func cloudFederationReadyCondition(t *testing.T, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) *metav1.Condition {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), oidc, func() *v1alpha1.ConditionalStatus {
+		return &oidc.Status.ConditionalStatus
+	}))
+	return apimeta.FindStatusCondition(oidc.Status.Conditions, CloudFederationReady)
+}
+
+func TestGenerateCloudFederationArtifacts(t *testing.T) {
+	subjects := []string{"spiffe://example.org/ns/app/sa/api", "spiffe://example.org/ns/app/sa/worker"}
+
+	t.Run("AWS", func(t *testing.T) {
+		oidc := createFederationTestOIDC(&v1alpha1.OIDCCloudFederation{
+			Subjects: subjects,
+			AWS:      &v1alpha1.OIDCAWSFederation{AccountID: "123456789012"},
+		})
+
+		artifacts, err := generateCloudFederationArtifacts(oidc, []string{"abcdef"})
+
+		require.NoError(t, err)
+		assert.Equal(t, "abcdef\n", artifacts[CAThumbprintsKey])
+		var provider map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(artifacts[AWSOIDCProviderKey]), &provider))
+		assert.Equal(t, "https://oidc.example.com/cluster-a", provider["Url"])
+		assert.Equal(t, []interface{}{"sts.amazonaws.com"}, provider["ClientIDList"])
+		assert.Equal(t, []interface{}{"abcdef"}, provider["ThumbprintList"])
+
+		var policy awsTrustPolicy
+		require.NoError(t, json.Unmarshal([]byte(artifacts[AWSTrustPolicyKey]), &policy))
+		require.Len(t, policy.Statement, 1)
+		statement := policy.Statement[0]
+		assert.Equal(t, "arn:aws:iam::123456789012:oidc-provider/oidc.example.com/cluster-a", statement.Principal["Federated"])
+		assert.Equal(t, "sts:AssumeRoleWithWebIdentity", statement.Action)
+		assert.Equal(t, []string{"sts.amazonaws.com"}, statement.Condition["StringEquals"]["oidc.example.com/cluster-a:aud"])
+		assert.Equal(t, subjects, statement.Condition["StringEquals"]["oidc.example.com/cluster-a:sub"])
+		assert.NotContains(t, artifacts, GCPWorkloadIdentityPoolKey)
+		assert.NotContains(t, artifacts, AzureFederatedCredentialsKey)
+	})
+
+	t.Run("AWS GovCloud without thumbprint", func(t *testing.T) {
+		oidc := createFederationTestOIDC(&v1alpha1.OIDCCloudFederation{
+			Subjects: subjects,
+			AWS:      &v1alpha1.OIDCAWSFederation{AccountID: "123456789012", Partition: "aws-us-gov", Audiences: []string{"spire"}},
+		})
+
+		artifacts, err := generateCloudFederationArtifacts(oidc, nil)
+
+		require.NoError(t, err)
+		assert.NotContains(t, artifacts, CAThumbprintsKey)
+		assert.NotContains(t, artifacts[AWSOIDCProviderKey], "ThumbprintList")
+		assert.Contains(t, artifacts[AWSTrustPolicyKey], `"arn:aws-us-gov:iam::123456789012:oidc-provider/oidc.example.com/cluster-a"`)
+		assert.Contains(t, artifacts[AWSOIDCProviderKey], `"spire"`)
+	})
+
+	t.Run("GCP", func(t *testing.T) {
+		oidc := createFederationTestOIDC(&v1alpha1.OIDCCloudFederation{
+			Subjects: subjects,
+			GCP:      &v1alpha1.OIDCGCPFederation{ProjectNumber: "1234567890", PoolID: "spire-pool", ProviderID: "cluster-a"},
+		})
+
+		artifacts, err := generateCloudFederationArtifacts(oidc, nil)
+
+		require.NoError(t, err)
+		var pool gcpWorkloadIdentityPool
+		require.NoError(t, json.Unmarshal([]byte(artifacts[GCPWorkloadIdentityPoolKey]), &pool))
+		assert.Equal(t, "projects/1234567890/locations/global/workloadIdentityPools/spire-pool", pool.Name)
+
+		var provider gcpWorkloadIdentityPoolProvider
+		require.NoError(t, json.Unmarshal([]byte(artifacts[GCPWorkloadIdentityPoolProviderKey]), &provider))
+		assert.Equal(t, pool.Name+"/providers/cluster-a", provider.Name)
+		assert.Equal(t, "assertion.sub", provider.AttributeMapping["google.subject"])
+		assert.Equal(t, `assertion.sub in ["spiffe://example.org/ns/app/sa/api", "spiffe://example.org/ns/app/sa/worker"]`, provider.AttributeCondition)
+		assert.Equal(t, "https://oidc.example.com/cluster-a", provider.OIDC.IssuerURI)
+		assert.Equal(t, []string{"//iam.googleapis.com/" + provider.Name}, provider.OIDC.AllowedAudiences)
+	})
+
+	t.Run("Azure", func(t *testing.T) {
+		oidc := createFederationTestOIDC(&v1alpha1.OIDCCloudFederation{
+			Subjects: subjects,
+			Azure:    &v1alpha1.OIDCAzureFederation{},
+		})
+
+		artifacts, err := generateCloudFederationArtifacts(oidc, nil)
+
+		require.NoError(t, err)
+		var credentials []azureFederatedCredential
+		require.NoError(t, json.Unmarshal([]byte(artifacts[AzureFederatedCredentialsKey]), &credentials))
+		require.Len(t, credentials, 2)
+		assert.Equal(t, "example-org-ns-app-sa-api", credentials[0].Name)
+		assert.Equal(t, "https://oidc.example.com/cluster-a", credentials[0].Issuer)
+		assert.Equal(t, subjects[0], credentials[0].Subject)
+		assert.Equal(t, []string{"api://AzureADTokenExchange"}, credentials[0].Audiences)
+		assert.Equal(t, subjects[1], credentials[1].Subject)
+	})
+}
+
+func TestAzureCredentialName(t *testing.T) {
+	used := map[string]bool{}
+
+	assert.Equal(t, "example-org-ns-app", azureCredentialName("spiffe://example.org/ns/app", used))
+
+	duplicate := azureCredentialName("spiffe://example.org/ns.app", used)
+	assert.True(t, strings.HasPrefix(duplicate, "example-org-ns-app-"), duplicate)
+	assert.Len(t, duplicate, len("example-org-ns-app-")+8)
+
+	long := azureCredentialName("spiffe://example.org/"+strings.Repeat("a", 200), used)
+	assert.Len(t, long, azureCredentialNameMaxLength)
+
+	short := azureCredentialName("spiffe://a", used)
+	assert.True(t, strings.HasPrefix(short, "a-"), short)
+	assert.Len(t, short, len("a-")+8)
+
+	empty := azureCredentialName("spiffe://", used)
+	assert.Len(t, empty, 8)
+}
+
+func TestReconcileCloudFederation(t *testing.T) {
+	ca := &x509.Certificate{Raw: []byte("ca certificate")}
+	caSum := sha1.Sum(ca.Raw) //nolint:gosec
+	federation := &v1alpha1.OIDCCloudFederation{
+		Subjects: []string{"spiffe://example.org/ns/app/sa/api"},
+		AWS:      &v1alpha1.OIDCAWSFederation{AccountID: "123456789012"},
+	}
+
+	t.Run("renders the artifacts with the CA thumbprint", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, cloudFederationConfigMapName))
+		reconciler := newExportTestReconciler(fakeClient, nil)
+		var dialed, serverName string
+		reconciler.fetchCertificateChain = func(_ context.Context, addr, name string) ([]*x509.Certificate, error) {
+			dialed, serverName = addr, name
+			return []*x509.Certificate{{Raw: []byte("leaf")}, ca}, nil
+		}
+		oidc := createFederationTestOIDC(federation)
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCloudFederation(context.Background(), oidc, statusMgr, false)
+
+		assert.Equal(t, cloudFederationCheckInterval, next)
+		assert.Equal(t, "oidc.example.com:443", dialed)
+		assert.Equal(t, "oidc.example.com", serverName)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		configMap, ok := created.(*corev1.ConfigMap)
+		require.True(t, ok, "expected a ConfigMap, got %T", created)
+		assert.Equal(t, cloudFederationConfigMapName, configMap.Name)
+		assert.Equal(t, hex.EncodeToString(caSum[:])+"\n", configMap.Data[CAThumbprintsKey])
+		assert.Contains(t, configMap.Data, AWSTrustPolicyKey)
+		condition := cloudFederationReadyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, v1alpha1.ReasonReady, condition.Reason)
+	})
+
+	t.Run("renders the artifacts without thumbprint when the issuer is unreachable", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, cloudFederationConfigMapName))
+		reconciler := newExportTestReconciler(fakeClient, nil)
+		reconciler.fetchCertificateChain = func(context.Context, string, string) ([]*x509.Certificate, error) {
+			return nil, errors.New("certificate signed by unknown authority")
+		}
+		oidc := createFederationTestOIDC(federation)
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCloudFederation(context.Background(), oidc, statusMgr, false)
+
+		assert.Equal(t, certificatePendingRetry, next)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		assert.NotContains(t, created.(*corev1.ConfigMap).Data, CAThumbprintsKey)
+		condition := cloudFederationReadyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+		assert.Equal(t, "ThumbprintUnavailable", condition.Reason)
+		assert.Contains(t, condition.Message, "unknown authority")
+	})
+
+	t.Run("unchanged artifacts are not updated", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newExportTestReconciler(fakeClient, nil)
+		reconciler.fetchCertificateChain = func(context.Context, string, string) ([]*x509.Certificate, error) {
+			return []*x509.Certificate{ca}, nil
+		}
+		oidc := createFederationTestOIDC(federation)
+		artifacts, err := generateCloudFederationArtifacts(oidc, []string{hex.EncodeToString(caSum[:])})
+		require.NoError(t, err)
+		fakeClient.GetStub = func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			configMap := obj.(*corev1.ConfigMap)
+			configMap.Name = key.Name
+			configMap.Namespace = key.Namespace
+			configMap.Labels = utils.SpireOIDCDiscoveryProviderLabels(nil)
+			configMap.Data = artifacts
+			return nil
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileCloudFederation(context.Background(), oidc, statusMgr, false)
+
+		assert.Zero(t, fakeClient.CreateCallCount())
+		assert.Zero(t, fakeClient.UpdateCallCount())
+	})
+
+	t.Run("removing the configuration deletes the ConfigMap", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.GetStub = func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			obj.SetName(key.Name)
+			obj.SetNamespace(key.Namespace)
+			obj.SetLabels(utils.SpireOIDCDiscoveryProviderLabels(nil))
+			return nil
+		}
+		reconciler := newExportTestReconciler(fakeClient, nil)
+		oidc := createFederationTestOIDC(nil)
+		oidc.Status.Conditions = []metav1.Condition{{Type: CloudFederationReady, Status: metav1.ConditionTrue, Reason: v1alpha1.ReasonReady}}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileCloudFederation(context.Background(), oidc, statusMgr, false)
+
+		assert.Zero(t, next)
+		require.Equal(t, 1, fakeClient.DeleteCallCount())
+		_, deleted, _ := fakeClient.DeleteArgsForCall(0)
+		assert.Equal(t, cloudFederationConfigMapName, deleted.GetName())
+		assert.Nil(t, cloudFederationReadyCondition(t, oidc, statusMgr))
+	})
+
+	t.Run("never configured", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newExportTestReconciler(fakeClient, nil)
+
+		reconciler.reconcileCloudFederation(context.Background(), createFederationTestOIDC(nil), status.NewManager(fakeClient), false)
+
+		assert.Zero(t, fakeClient.GetCallCount())
+	})
+}
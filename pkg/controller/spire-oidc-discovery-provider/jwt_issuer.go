@@ -0,0 +1,123 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"fmt"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+// previousIssuerRouteName is the Route keeping the previous JWT issuer host reachable during an issuer change
+const previousIssuerRouteName = "spire-oidc-discovery-provider-previous"
+
+// reconcileJWTIssuerConsistency reports in the JWTIssuerConsistent condition whether the SpireServer signs
+// JWT-SVIDs with an issuer the provider serves
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileJWTIssuerConsistency(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) {
+	var server v1alpha1.SpireServer
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: "cluster"}, &server); err != nil {
+		if kerrors.IsNotFound(err) {
+			// Agents attached to an external SPIRE server have no SpireServer to compare with
+			statusMgr.RemoveCondition(utils.JWTIssuerConsistentConditionType)
+			return
+		}
+		r.log.Error(err, "failed to get SpireServer to compare JWT issuers")
+		return
+	}
+
+	conditionStatus, reason, message := utils.CheckJWTIssuerConsistency(&server, oidc)
+	if conditionStatus != metav1.ConditionTrue && utils.JWTIssuerConditionChanged(oidc.Status.Conditions, conditionStatus, reason, message) {
+		r.log.Info("JWT issuer mismatch", "message", message)
+		r.eventRecorder.Event(oidc, corev1.EventTypeWarning, reason, message)
+	}
+	statusMgr.AddCondition(utils.JWTIssuerConsistentConditionType, reason, message, conditionStatus)
+}
+
+// generatePreviousIssuerRoute creates the Route for the previous JWT issuer host, a copy of the managed Route
+func generatePreviousIssuerRoute(config *v1alpha1.SpireOIDCDiscoveryProvider) (*routev1.Route, error) {
+	previousIssuer, err := utils.StripProtocolFromJWTIssuer(config.Spec.PreviousJwtIssuer)
+	if err != nil {
+		return nil, fmt.Errorf("invalid previous JWT issuer URL: %w", err)
+	}
+	route, err := generateOIDCDiscoveryProviderRoute(config)
+	if err != nil {
+		return nil, err
+	}
+	route.Name = previousIssuerRouteName
+	route.Spec.Host = previousIssuer
+	return route, nil
+}
+
+// reconcilePreviousIssuerRoute keeps the previous JWT issuer host routed to the provider while previousJwtIssuer
+// is set and the endpoints are exposed through a Route, and deletes the Route otherwise. An Ingress or Gateway
+// API route carries the previous issuer host itself.
+func (r *SpireOidcDiscoveryProviderReconciler) reconcilePreviousIssuerRoute(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) error {
+	if oidc.Spec.PreviousJwtIssuer == "" || utils.EndpointExposureType(oidc.Spec.Exposure) != v1alpha1.RouteExposureType {
+		return r.deleteExposureObject(ctx, &routev1.Route{}, "Route", previousIssuerRouteName, statusMgr)
+	}
+
+	route, err := generatePreviousIssuerRoute(oidc)
+	if err != nil {
+		r.log.Error(err, "Failed to generate previous JWT issuer route")
+		statusMgr.AddCondition(RouteAvailable, "PreviousIssuerRouteCreationFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	var existingRoute routev1.Route
+	err = r.ctrlClient.Get(ctx, types.NamespacedName{Name: route.Name, Namespace: route.Namespace}, &existingRoute)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "Failed to get previous JWT issuer route")
+			statusMgr.AddCondition(RouteAvailable, "PreviousIssuerRouteRetrievalFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return err
+		}
+		if err := r.ctrlClient.Create(ctx, route); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, route, r.log, statusMgr, RouteAvailable); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "Failed to create previous JWT issuer route")
+			statusMgr.AddCondition(RouteAvailable, "PreviousIssuerRouteCreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.log.Info("Created previous JWT issuer route", "Namespace", route.Namespace, "Name", route.Name, "Host", route.Spec.Host)
+		return nil
+	}
+
+	if err := utils.CheckResourceConflict(&existingRoute); err != nil {
+		r.log.Error(err, "Previous JWT issuer route is not managed by the operator")
+		statusMgr.AddCondition(RouteAvailable, "ResourceConflict",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+	if !checkRouteConflict(&existingRoute, route) {
+		return nil
+	}
+	if createOnlyMode {
+		r.log.Info("Skipping previous JWT issuer Route update due to create-only mode", "Namespace", route.Namespace, "Name", route.Name)
+		return nil
+	}
+
+	route.ResourceVersion = existingRoute.ResourceVersion
+	if err := r.ctrlClient.Update(ctx, route); err != nil {
+		statusMgr.AddCondition(RouteAvailable, "PreviousIssuerRouteUpdateFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Updated previous JWT issuer route", "Namespace", route.Namespace, "Name", route.Name, "Host", route.Spec.Host)
+	return nil
+}
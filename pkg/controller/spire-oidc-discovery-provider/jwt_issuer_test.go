@@ -0,0 +1,187 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func createIssuerTestOIDC(jwtIssuer, previousJwtIssuer string) *v1alpha1.SpireOIDCDiscoveryProvider {
+	return &v1alpha1.SpireOIDCDiscoveryProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+			ManagedRoute:      "true",
+			JwtIssuer:         jwtIssuer,
+			PreviousJwtIssuer: previousJwtIssuer,
+		},
+	}
+}
+
+func jwtIssuerConsistentCondition(t *testing.T, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) *metav1.Condition {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), oidc, func() *v1alpha1.ConditionalStatus {
+		return &oidc.Status.ConditionalStatus
+	}))
+	return apimeta.FindStatusCondition(oidc.Status.Conditions, utils.JWTIssuerConsistentConditionType)
+}
+
+func TestReconcileJWTIssuerConsistency(t *testing.T) {
+	serverWithIssuer := func(issuer string) func(context.Context, client.ObjectKey, client.Object) error {
+		return func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+			if server, ok := obj.(*v1alpha1.SpireServer); ok {
+				server.Spec.JwtIssuer = issuer
+				return nil
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, "not-found")
+		}
+	}
+
+	t.Run("matching issuers", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetStub = serverWithIssuer("https://oidc.example.com/")
+		oidc := createIssuerTestOIDC("https://oidc.example.com", "")
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), oidc, statusMgr)
+
+		condition := jwtIssuerConsistentCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, utils.JWTIssuerMatch, condition.Reason)
+	})
+
+	t.Run("server still on the previous issuer", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetStub = serverWithIssuer("https://old.example.com")
+		oidc := createIssuerTestOIDC("https://new.example.com", "https://old.example.com")
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), oidc, statusMgr)
+
+		condition := jwtIssuerConsistentCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Equal(t, utils.JWTIssuerTransition, condition.Reason)
+	})
+
+	t.Run("mismatched issuers", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetStub = serverWithIssuer("https://server.example.com")
+		oidc := createIssuerTestOIDC("https://oidc.example.com", "")
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), oidc, statusMgr)
+
+		condition := jwtIssuerConsistentCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, utils.JWTIssuerMismatch, condition.Reason)
+		assert.Equal(t, metav1.ConditionFalse, apimeta.FindStatusCondition(oidc.Status.Conditions, v1alpha1.Ready).Status)
+		recorder := reconciler.eventRecorder.(*record.FakeRecorder)
+		require.Len(t, recorder.Events, 1)
+		assert.Contains(t, <-recorder.Events, "Warning JWTIssuerMismatch")
+
+		// The mismatch is only recorded again when the condition changes
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), oidc, status.NewManager(fakeClient))
+		assert.Empty(t, recorder.Events)
+	})
+
+	t.Run("no SpireServer removes the condition", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "cluster"))
+		oidc := createIssuerTestOIDC("https://oidc.example.com", "")
+		oidc.Status.Conditions = []metav1.Condition{{Type: utils.JWTIssuerConsistentConditionType, Status: metav1.ConditionFalse, Reason: utils.JWTIssuerMismatch}}
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), oidc, statusMgr)
+
+		assert.Nil(t, jwtIssuerConsistentCondition(t, oidc, statusMgr))
+	})
+}
+
+func TestReconcileRoute_PreviousIssuer(t *testing.T) {
+	t.Run("creates a route for the previous issuer host", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		oidc := createIssuerTestOIDC("https://new.example.com", "https://old.example.com")
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 2, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(1)
+		route, ok := created.(*routev1.Route)
+		require.True(t, ok, "expected a Route, got %T", created)
+		assert.Equal(t, previousIssuerRouteName, route.Name)
+		assert.Equal(t, "old.example.com", route.Spec.Host)
+		assert.Equal(t, "spire-spiffe-oidc-discovery-provider", route.Spec.To.Name)
+		assert.Equal(t, "new.example.com", oidc.Status.Hostname)
+	})
+
+	t.Run("routes the previous issuer host through the Ingress", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		oidc := createIssuerTestOIDC("https://new.example.com", "https://old.example.com")
+		oidc.Spec.Exposure = &v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		ingress, ok := created.(*networkingv1.Ingress)
+		require.True(t, ok, "expected an Ingress, got %T", created)
+		require.Len(t, ingress.Spec.Rules, 2)
+		assert.Equal(t, "new.example.com", ingress.Spec.Rules[0].Host)
+		assert.Equal(t, "old.example.com", ingress.Spec.Rules[1].Host)
+	})
+
+	t.Run("deletes the previous issuer route once the transition is over", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetStub = func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+			if route, ok := obj.(*routev1.Route); ok && key.Name == previousIssuerRouteName {
+				route.Name = key.Name
+				route.Namespace = key.Namespace
+				route.Labels = utils.SpireOIDCDiscoveryProviderLabels(nil)
+				return nil
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		}
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		oidc := createIssuerTestOIDC("https://new.example.com", "")
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), oidc, statusMgr, false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.DeleteCallCount())
+		_, deleted, _ := fakeClient.DeleteArgsForCall(0)
+		assert.Equal(t, previousIssuerRouteName, deleted.GetName())
+	})
+}
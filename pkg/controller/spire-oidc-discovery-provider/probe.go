@@ -0,0 +1,281 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// EndpointHealthy reports whether the provider serves the configured issuer and the current JWT signing keys
+	EndpointHealthy = "EndpointHealthy"
+
+	defaultEndpointProbeInterval = 5 * time.Minute
+	endpointProbeTimeout         = 10 * time.Second
+	// endpointProbeCheckTimeout bounds a background probe of all the targets
+	endpointProbeCheckTimeout = 45 * time.Second
+	// endpointProbeMaxResponseSize bounds the documents read from the provider
+	endpointProbeMaxResponseSize = 1 << 20
+
+	discoveryDocumentPath = "/.well-known/openid-configuration"
+	keysPath              = "/keys"
+)
+
+// ProbeClientFunc returns the HTTP client used to probe the provider, verifying the certificate for serverName
+type ProbeClientFunc func(serverName string) *http.Client
+
+// endpointProbeTarget is a base URL the discovery endpoints are probed at
+type endpointProbeTarget struct {
+	name    string
+	baseURL string
+	// host is sent as the Host header, the provider only answers for its configured domains
+	host string
+	// serverName is the name the served certificate is verified for, empty for the host
+	serverName string
+}
+
+// endpointProbeResult is the outcome of probing one target
+type endpointProbeResult struct {
+	target string
+	// keyIDs are the IDs of the keys in the served JWKS
+	keyIDs []string
+	err    error
+}
+
+// newProbeHTTPClient returns an HTTP client trusting the system roots and the service CA
+func newProbeHTTPClient(serverName string) *http.Client {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	if serviceCA, err := os.ReadFile(utils.ServiceCAFile); err == nil {
+		roots.AppendCertsFromPEM(serviceCA)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableKeepAlives = true
+	transport.TLSClientConfig = &tls.Config{
+		RootCAs:    roots,
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS12,
+	}
+	return &http.Client{Transport: transport, Timeout: endpointProbeTimeout}
+}
+
+// endpointProbeInterval returns how often the endpoints are probed
+func endpointProbeInterval(probe *v1alpha1.OIDCEndpointProbe) time.Duration {
+	if probe.Interval == nil {
+		return defaultEndpointProbeInterval
+	}
+	return probe.Interval.Duration
+}
+
+// endpointProbeTargets returns the in-cluster Service and, if requested and known, the published host
+func (r *SpireOidcDiscoveryProviderReconciler) endpointProbeTargets(oidc *v1alpha1.SpireOIDCDiscoveryProvider, issuerHost string) []endpointProbeTarget {
+	serviceHost := fmt.Sprintf("%s.%s.svc", oidcServiceName, utils.GetOperatorNamespace())
+	// The service CA certificate is issued for the Service, an ACME certificate for the issuer host
+	serverName := serviceHost
+	if isACMEEnabled(oidc) {
+		serverName = issuerHost
+	}
+	targets := []endpointProbeTarget{{
+		name:       "Service " + serviceHost,
+		baseURL:    fmt.Sprintf("https://%s:%d", serviceHost, oidcServicePort),
+		host:       issuerHost,
+		serverName: serverName,
+	}}
+
+	if utils.StringToBool(oidc.Spec.EndpointProbe.ProbePublishedHost) && oidc.Status.Hostname != "" {
+		targets = append(targets, endpointProbeTarget{
+			name:    "host " + oidc.Status.Hostname,
+			baseURL: "https://" + oidc.Status.Hostname,
+			host:    oidc.Status.Hostname,
+		})
+	}
+	return targets
+}
+
+// fetchEndpointDocument fetches path from the target and decodes the JSON response into out
+func fetchEndpointDocument(ctx context.Context, httpClient *http.Client, target endpointProbeTarget, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Host = target.host
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP %d", path, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, endpointProbeMaxResponseSize))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("%s is not valid JSON: %w", path, err)
+	}
+	return nil
+}
+
+// probeEndpoint checks that the target serves the discovery document for issuer and a non-empty JWKS,
+// and returns the IDs of the served keys
+func probeEndpoint(ctx context.Context, httpClient *http.Client, target endpointProbeTarget, issuer string) ([]string, error) {
+	var discovery discoveryDocument
+	if err := fetchEndpointDocument(ctx, httpClient, target, discoveryDocumentPath, &discovery); err != nil {
+		return nil, err
+	}
+	servedIssuer, err := utils.NormalizeURL(discovery.Issuer)
+	if err != nil || servedIssuer != issuer {
+		return nil, fmt.Errorf("discovery document has issuer %q instead of %s", discovery.Issuer, issuer)
+	}
+
+	var keySet jwksKeyIDs
+	if err := fetchEndpointDocument(ctx, httpClient, target, keysPath, &keySet); err != nil {
+		return nil, err
+	}
+	if len(keySet.Keys) == 0 {
+		return nil, fmt.Errorf("JWKS is empty")
+	}
+	return keySet.ids(), nil
+}
+
+// jwksKeyIDs decodes the key IDs of a JWKS
+type jwksKeyIDs struct {
+	Keys []struct {
+		KeyID string `json:"kid"`
+	} `json:"keys"`
+}
+
+func (s jwksKeyIDs) ids() []string {
+	keyIDs := make([]string, 0, len(s.Keys))
+	for _, key := range s.Keys {
+		keyIDs = append(keyIDs, key.KeyID)
+	}
+	return keyIDs
+}
+
+// probeEndpoints probes every target, one after the other
+func (r *SpireOidcDiscoveryProviderReconciler) probeEndpoints(targets []endpointProbeTarget, issuer string) []endpointProbeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), endpointProbeCheckTimeout)
+	defer cancel()
+	results := make([]endpointProbeResult, 0, len(targets))
+	for _, target := range targets {
+		keyIDs, err := probeEndpoint(ctx, r.newProbeClient(target.serverName), target, issuer)
+		results = append(results, endpointProbeResult{target: target.name, keyIDs: keyIDs, err: err})
+	}
+	return results
+}
+
+// currentJWTKeyIDs returns the IDs of the signing keys in the JWKS exported to the ConfigMap, which
+// is the bundle of SPIRE Server without the tainted JWT authorities
+func (r *SpireOidcDiscoveryProviderReconciler) currentJWTKeyIDs(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider) ([]string, error) {
+	if oidc.Spec.Export == nil || oidc.Spec.Export.ConfigMapName == "" {
+		return nil, fmt.Errorf("no exported JWKS to compare with, set spec.export.configMapName")
+	}
+	configMap := &corev1.ConfigMap{}
+	key := types.NamespacedName{Name: oidc.Spec.Export.ConfigMapName, Namespace: utils.GetOperatorNamespace()}
+	if err := r.ctrlClient.Get(ctx, key, configMap); err != nil {
+		return nil, fmt.Errorf("failed to get the exported JWKS from ConfigMap %s: %w", key.Name, err)
+	}
+	var keySet jwksKeyIDs
+	if err := json.Unmarshal([]byte(configMap.Data[ExportKeysKey]), &keySet); err != nil {
+		return nil, fmt.Errorf("ConfigMap %s has no valid JWKS: %w", key.Name, err)
+	}
+	return keySet.ids(), nil
+}
+
+// reconcileEndpointProbe fetches the discovery document and JWKS through the Service, and optionally the
+// published host, and reports the result in the EndpointHealthy condition. The endpoints are probed in the
+// background, and the served keys compared with the exported JWKS. It returns when to look again.
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileEndpointProbe(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) time.Duration {
+	probe := oidc.Spec.EndpointProbe
+	if probe == nil {
+		statusMgr.RemoveCondition(EndpointHealthy)
+		r.endpointProbeCheck.Reset()
+		return 0
+	}
+
+	interval := endpointProbeInterval(probe)
+	issuer, err := utils.NormalizeURL(oidc.Spec.JwtIssuer)
+	if err != nil {
+		statusMgr.AddCondition(EndpointHealthy, "InvalidIssuer", err.Error(), metav1.ConditionFalse)
+		return 0
+	}
+	issuerHost, _ := utils.StripProtocolFromJWTIssuer(issuer)
+	issuerHost, _, _ = strings.Cut(issuerHost, "/")
+
+	targets := r.endpointProbeTargets(oidc, issuerHost)
+	checkKey := issuer
+	for _, target := range targets {
+		checkKey += "|" + target.baseURL + "|" + target.serverName
+	}
+	result := r.endpointProbeCheck.Poll(checkKey, interval, r.startBackground, func() ([]endpointProbeResult, error) {
+		return r.probeEndpoints(targets, issuer), nil
+	})
+	if result == nil {
+		statusMgr.AddCondition(EndpointHealthy, "EndpointProbePending",
+			fmt.Sprintf("Probing the OIDC discovery endpoints of %s", issuer),
+			metav1.ConditionUnknown)
+		return utils.BackgroundCheckPollInterval
+	}
+	// Look again when the next probe is due
+	next := max(interval-time.Since(result.CheckedAt), utils.BackgroundCheckPollInterval)
+
+	currentKeyIDs, keysErr := r.currentJWTKeyIDs(ctx, oidc)
+
+	var probed []string
+	for _, probeResult := range result.Value {
+		err := probeResult.err
+		if err == nil && keysErr == nil {
+			var missing []string
+			for _, keyID := range currentKeyIDs {
+				if !slices.Contains(probeResult.keyIDs, keyID) {
+					missing = append(missing, keyID)
+				}
+			}
+			if len(missing) > 0 {
+				err = fmt.Errorf("JWKS is missing the current signing keys %s", strings.Join(missing, ", "))
+			}
+		}
+		if err != nil {
+			r.log.Info("OIDC discovery endpoint is unhealthy", "target", probeResult.target, "reason", err.Error())
+			statusMgr.AddCondition(EndpointHealthy, "EndpointUnhealthy",
+				fmt.Sprintf("%s: %v", probeResult.target, err),
+				metav1.ConditionFalse)
+			return next
+		}
+		probed = append(probed, probeResult.target)
+	}
+
+	if keysErr != nil {
+		// The endpoints answer, but whether they serve the current keys is unknown
+		statusMgr.AddCondition(EndpointHealthy, "SigningKeysUnverified",
+			fmt.Sprintf("Discovery document served by %s, signing keys not verified: %v", strings.Join(probed, " and "), keysErr),
+			metav1.ConditionUnknown)
+		return next
+	}
+
+	statusMgr.AddCondition(EndpointHealthy, v1alpha1.ReasonReady,
+		fmt.Sprintf("Discovery document and JWKS with the %d current signing keys served by %s",
+			len(currentKeyIDs), strings.Join(probed, " and ")),
+		metav1.ConditionTrue)
+	return next
+}
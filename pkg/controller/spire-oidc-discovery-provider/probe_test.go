@@ -0,0 +1,274 @@
+package spire_oidc_discovery_provider
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+// fakeProvider serves a discovery document and JWKS and records the requests it receives
+type fakeProvider struct {
+	mu          sync.Mutex
+	issuer      string
+	keyIDs      []string
+	hosts       []string
+	serverNames []string
+}
+
+func newFakeProvider(t *testing.T, issuer string, keyIDs ...string) (*fakeProvider, *httptest.Server) {
+	provider := &fakeProvider{issuer: issuer, keyIDs: keyIDs}
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider.mu.Lock()
+		defer provider.mu.Unlock()
+		provider.hosts = append(provider.hosts, r.Host)
+		switch r.URL.Path {
+		case discoveryDocumentPath:
+			_ = json.NewEncoder(w).Encode(map[string]string{"issuer": provider.issuer, "jwks_uri": provider.issuer + "/keys"})
+		case keysPath:
+			keys := []map[string]string{}
+			for _, keyID := range provider.keyIDs {
+				keys = append(keys, map[string]string{"kid": keyID, "kty": "EC"})
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return provider, server
+}
+
+// probeClient sends every request to the fake provider, whatever the URL
+func (p *fakeProvider) probeClient(server *httptest.Server) ProbeClientFunc {
+	return func(serverName string) *http.Client {
+		p.mu.Lock()
+		p.serverNames = append(p.serverNames, serverName)
+		p.mu.Unlock()
+		return &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, network, server.Listener.Addr().String())
+				},
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, //nolint:gosec
+			},
+		}
+	}
+}
+
+func createProbeTestOIDC(probe *v1alpha1.OIDCEndpointProbe) *v1alpha1.SpireOIDCDiscoveryProvider {
+	oidc := createDeploymentTestOIDCCR()
+	oidc.Spec.JwtIssuer = "https://oidc.example.com"
+	oidc.Spec.EndpointProbe = probe
+	oidc.Spec.Export = &v1alpha1.OIDCDocumentExport{ConfigMapName: "oidc-documents"}
+	return oidc
+}
+
+// serveExportedKeys serves the export ConfigMap with a JWKS holding keyIDs
+func serveExportedKeys(fakeClient *fakes.FakeCustomCtrlClient, keyIDs ...string) {
+	keys := []map[string]string{}
+	for _, keyID := range keyIDs {
+		keys = append(keys, map[string]string{"kid": keyID, "kty": "EC"})
+	}
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": keys})
+	fakeClient.GetStub = func(_ context.Context, key client.ObjectKey, obj client.Object) error {
+		if configMap, ok := obj.(*corev1.ConfigMap); ok && key.Name == "oidc-documents" {
+			configMap.Data = map[string]string{ExportKeysKey: string(jwks)}
+		}
+		return nil
+	}
+}
+
+func newProbeTestReconciler(fakeClient *fakes.FakeCustomCtrlClient, server *httptest.Server, provider *fakeProvider) *SpireOidcDiscoveryProviderReconciler {
+	reconciler := newRouteTestReconciler(fakeClient)
+	reconciler.newProbeClient = provider.probeClient(server)
+	return reconciler
+}
+
+func endpointHealthyCondition(t *testing.T, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager) *metav1.Condition {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), oidc, func() *v1alpha1.ConditionalStatus {
+		return &oidc.Status.ConditionalStatus
+	}))
+	return apimeta.FindStatusCondition(oidc.Status.Conditions, EndpointHealthy)
+}
+
+func TestReconcileEndpointProbe(t *testing.T) {
+	t.Run("healthy endpoint", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		serveExportedKeys(fakeClient, "key-1")
+		provider, server := newFakeProvider(t, "https://oidc.example.com/", "key-1", "key-0")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{})
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		assert.InDelta(t, defaultEndpointProbeInterval, next, float64(time.Second))
+		condition := endpointHealthyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "Service spire-spiffe-oidc-discovery-provider.")
+		// The provider only answers for the issuer host, and serves the service CA certificate
+		assert.Equal(t, []string{"oidc.example.com", "oidc.example.com"}, provider.hosts)
+		assert.Equal(t, []string{"spire-spiffe-oidc-discovery-provider." + utils.GetOperatorNamespace() + ".svc"}, provider.serverNames)
+	})
+
+	t.Run("reuses the last probe until the interval elapsed", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		serveExportedKeys(fakeClient, "key-1")
+		provider, server := newFakeProvider(t, "https://oidc.example.com", "key-1")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{})
+
+		reconciler.reconcileEndpointProbe(context.Background(), oidc, status.NewManager(fakeClient))
+		reconciler.reconcileEndpointProbe(context.Background(), oidc, status.NewManager(fakeClient))
+
+		assert.Len(t, provider.hosts, 2)
+	})
+
+	t.Run("reports a pending probe without blocking", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		serveExportedKeys(fakeClient, "key-1")
+		provider, server := newFakeProvider(t, "https://oidc.example.com", "key-1")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		var run func()
+		reconciler.runInBackground = func(f func()) { run = f }
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{})
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		assert.Equal(t, utils.BackgroundCheckPollInterval, next)
+		condition := endpointHealthyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+		assert.Equal(t, "EndpointProbePending", condition.Reason)
+		assert.Empty(t, provider.hosts)
+
+		require.NotNil(t, run)
+		run()
+		statusMgr = status.NewManager(fakeClient)
+		reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+		assert.Equal(t, v1alpha1.ReasonReady, endpointHealthyCondition(t, oidc, statusMgr).Reason)
+	})
+
+	t.Run("wrong issuer", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		serveExportedKeys(fakeClient, "key-1")
+		provider, server := newFakeProvider(t, "https://spire-spiffe-oidc-discovery-provider.svc", "key-1")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{Interval: &metav1.Duration{Duration: time.Minute}})
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		assert.InDelta(t, time.Minute, next, float64(time.Second))
+		condition := endpointHealthyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Equal(t, "EndpointUnhealthy", condition.Reason)
+		assert.Contains(t, condition.Message, `issuer "https://spire-spiffe-oidc-discovery-provider.svc" instead of https://oidc.example.com`)
+		assert.Equal(t, metav1.ConditionFalse, apimeta.FindStatusCondition(oidc.Status.Conditions, v1alpha1.Ready).Status)
+	})
+
+	t.Run("empty JWKS", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		serveExportedKeys(fakeClient, "key-1")
+		provider, server := newFakeProvider(t, "https://oidc.example.com")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{})
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		condition := endpointHealthyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Contains(t, condition.Message, "JWKS is empty")
+	})
+
+	t.Run("JWKS without the current signing key", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		serveExportedKeys(fakeClient, "key-1")
+		provider, server := newFakeProvider(t, "https://oidc.example.com", "key-0")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{})
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		condition := endpointHealthyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+		assert.Contains(t, condition.Message, "missing the current signing keys key-1")
+	})
+
+	t.Run("without an exported JWKS", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		provider, server := newFakeProvider(t, "https://oidc.example.com", "key-1")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{})
+		oidc.Spec.Export = nil
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		condition := endpointHealthyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+		assert.Equal(t, "SigningKeysUnverified", condition.Reason)
+		assert.Contains(t, condition.Message, "spec.export.configMapName")
+	})
+
+	t.Run("published host with ACME", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		serveExportedKeys(fakeClient, "key-1")
+		provider, server := newFakeProvider(t, "https://oidc.example.com", "key-1")
+		reconciler := newProbeTestReconciler(fakeClient, server, provider)
+		oidc := createProbeTestOIDC(&v1alpha1.OIDCEndpointProbe{ProbePublishedHost: "true"})
+		oidc.Spec.Acme = &v1alpha1.OIDCAcmeConfig{Email: "admin@example.com", TosAccepted: "true"}
+		oidc.Status.Hostname = "oidc.example.com"
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		condition := endpointHealthyCondition(t, oidc, statusMgr)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+		assert.Contains(t, condition.Message, "and host oidc.example.com")
+		assert.Equal(t, []string{"oidc.example.com", ""}, provider.serverNames)
+		assert.Len(t, provider.hosts, 4)
+	})
+
+	t.Run("disabled removes the condition", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		oidc := createProbeTestOIDC(nil)
+		oidc.Status.Conditions = []metav1.Condition{{Type: EndpointHealthy, Status: metav1.ConditionFalse, Reason: "EndpointUnhealthy"}}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileEndpointProbe(context.Background(), oidc, statusMgr)
+
+		assert.Zero(t, next)
+		assert.Nil(t, endpointHealthyCondition(t, oidc, statusMgr))
+	})
+}
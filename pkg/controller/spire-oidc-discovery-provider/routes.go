@@ -18,90 +18,146 @@ import (
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
 )
 
-// reconcileRoute reconciles the OIDC Discovery Provider Route
+// reconcileRoute reconciles the object exposing the OIDC Discovery Provider, an OpenShift Route by default,
+// and reports the published hostname in status
 func (r *SpireOidcDiscoveryProviderReconciler) reconcileRoute(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) error {
-	if utils.StringToBool(oidc.Spec.ManagedRoute) {
-		// Create Route for OIDC Discovery Provider
-		route, err := generateOIDCDiscoveryProviderRoute(oidc)
-		if err != nil {
-			r.log.Error(err, "Failed to generate OIDC discovery provider route")
+	if !utils.StringToBool(oidc.Spec.ManagedRoute) {
+		// Only update status if it's currently enabled
+		statusMgr.AddCondition(RouteAvailable, "ManagedRouteDisabled",
+			"Spire OIDC Managed Route disabled",
+			metav1.ConditionFalse)
+		r.setHostname(oidc, "", statusMgr)
+		return nil
+	}
+
+	exposureType := utils.EndpointExposureType(oidc.Spec.Exposure)
+	if err := utils.ValidateEndpointExposure(oidc.Spec.Exposure, isACMEEnabled(oidc)); err != nil {
+		r.log.Error(err, "Invalid OIDC discovery provider exposure")
+		statusMgr.AddCondition(RouteAvailable, "InvalidExposure",
+			err.Error(),
+			metav1.ConditionFalse)
+		return nil
+	}
+
+	var hostname string
+	var err error
+	switch exposureType {
+	case v1alpha1.IngressExposureType:
+		hostname, err = r.reconcileExposureIngress(ctx, oidc, statusMgr, createOnlyMode)
+	case v1alpha1.HTTPRouteExposureType:
+		hostname, err = r.reconcileExposureGatewayRoute(ctx, oidc, statusMgr, createOnlyMode)
+		if err == nil {
+			err = r.reconcileExposureBackendTLS(ctx, oidc, statusMgr, createOnlyMode)
+		}
+	case v1alpha1.TLSRouteExposureType:
+		hostname, err = r.reconcileExposureGatewayRoute(ctx, oidc, statusMgr, createOnlyMode)
+	default:
+		hostname, err = r.reconcileManagedRoute(ctx, oidc, statusMgr, createOnlyMode)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := r.deleteStaleExposure(ctx, exposureType, statusMgr); err != nil {
+		return err
+	}
+
+	if err := r.reconcilePreviousIssuerRoute(ctx, oidc, statusMgr, createOnlyMode); err != nil {
+		return err
+	}
+
+	r.setHostname(oidc, hostname, statusMgr)
+	return nil
+}
+
+// setHostname records the published hostname in status
+func (r *SpireOidcDiscoveryProviderReconciler) setHostname(oidc *v1alpha1.SpireOIDCDiscoveryProvider, hostname string, statusMgr *status.Manager) {
+	if oidc.Status.Hostname != hostname {
+		oidc.Status.Hostname = hostname
+		statusMgr.RequestStatusUpdate()
+	}
+}
+
+// reconcileManagedRoute reconciles the OIDC Discovery Provider Route and returns its host
+func (r *SpireOidcDiscoveryProviderReconciler) reconcileManagedRoute(ctx context.Context, oidc *v1alpha1.SpireOIDCDiscoveryProvider, statusMgr *status.Manager, createOnlyMode bool) (string, error) {
+	// Create Route for OIDC Discovery Provider
+	route, err := generateOIDCDiscoveryProviderRoute(oidc)
+	if err != nil {
+		r.log.Error(err, "Failed to generate OIDC discovery provider route")
+		statusMgr.AddCondition(RouteAvailable, "ManagedRouteCreationFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+
+	var existingRoute routev1.Route
+	err = r.ctrlClient.Get(ctx, types.NamespacedName{
+		Name:      route.Name,
+		Namespace: route.Namespace,
+	}, &existingRoute)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "Failed to get existing route")
+			statusMgr.AddCondition(RouteAvailable, "ManagedRouteRetrievalFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if err = r.ctrlClient.Create(ctx, route); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, route, r.log, statusMgr, RouteAvailable); conflictErr != nil {
+				return "", conflictErr
+			}
+			r.log.Error(err, "Failed to create route")
 			statusMgr.AddCondition(RouteAvailable, "ManagedRouteCreationFailed",
 				err.Error(),
 				metav1.ConditionFalse)
-			return err
+			return "", err
 		}
 
-		var existingRoute routev1.Route
-		err = r.ctrlClient.Get(ctx, types.NamespacedName{
-			Name:      route.Name,
-			Namespace: route.Namespace,
-		}, &existingRoute)
-		if err != nil {
-			if kerrors.IsNotFound(err) {
-				if err = r.ctrlClient.Create(ctx, route); err != nil {
-					if conflictErr := utils.HandleCreateConflict(err, route, r.log, statusMgr, RouteAvailable); conflictErr != nil {
-						return conflictErr
-					}
-					r.log.Error(err, "Failed to create route")
-					statusMgr.AddCondition(RouteAvailable, "ManagedRouteCreationFailed",
-						err.Error(),
-						metav1.ConditionFalse)
-					return err
-				}
-
-				// Set status when route is actually created
-				statusMgr.AddCondition(RouteAvailable, "ManagedRouteCreated",
-					"Spire OIDC Managed Route created",
-					metav1.ConditionTrue)
-
-				r.log.Info("Created route", "Namespace", route.Namespace, "Name", route.Name)
-			} else {
-				r.log.Error(err, "Failed to get existing route")
-				statusMgr.AddCondition(RouteAvailable, "ManagedRouteRetrievalFailed",
-					err.Error(),
-					metav1.ConditionFalse)
-				return err
-			}
-		} else if checkRouteConflict(&existingRoute, route) {
-			r.log.Info("Found conflict in routes, updating route")
-			route.ResourceVersion = existingRoute.ResourceVersion
-
-			if createOnlyMode {
-				r.log.Info("Skipping Route update due to create-only mode", "Namespace", route.Namespace, "Name", route.Name)
-			} else {
-				err = r.ctrlClient.Update(ctx, route)
-				if err != nil {
-					statusMgr.AddCondition(RouteAvailable, "ManagedRouteUpdateFailed",
-						err.Error(),
-						metav1.ConditionFalse)
-					return err
-				}
-
-				// Set status when route is actually updated
-				statusMgr.AddCondition(RouteAvailable, "ManagedRouteUpdated",
-					"Spire OIDC Managed Route updated",
-					metav1.ConditionTrue)
-
-				r.log.Info("Updated route", "Namespace", route.Namespace, "Name", route.Name)
-			}
-		} else {
-			// Route exists and is up to date - only update status if it's currently not ready
-			existingCondition := apimeta.FindStatusCondition(oidc.Status.ConditionalStatus.Conditions, RouteAvailable)
-			if existingCondition == nil || existingCondition.Status != metav1.ConditionTrue {
-				statusMgr.AddCondition(RouteAvailable, "ManagedRouteReady",
-					"Spire OIDC Managed Route is ready",
-					metav1.ConditionTrue)
-			}
-			// If route is already ready, don't update the status to avoid overwriting the reason
+		// Set status when route is actually created
+		statusMgr.AddCondition(RouteAvailable, "ManagedRouteCreated",
+			"Spire OIDC Managed Route created",
+			metav1.ConditionTrue)
+
+		r.log.Info("Created route", "Namespace", route.Namespace, "Name", route.Name)
+		return route.Spec.Host, nil
+	}
+
+	if !checkRouteConflict(&existingRoute, route) {
+		// Route exists and is up to date - only update status if it's currently not ready
+		existingCondition := apimeta.FindStatusCondition(oidc.Status.ConditionalStatus.Conditions, RouteAvailable)
+		if existingCondition == nil || existingCondition.Status != metav1.ConditionTrue {
+			statusMgr.AddCondition(RouteAvailable, "ManagedRouteReady",
+				"Spire OIDC Managed Route is ready",
+				metav1.ConditionTrue)
 		}
-	} else {
-		// Only update status if it's currently enabled
-		statusMgr.AddCondition(RouteAvailable, "ManagedRouteDisabled",
-			"Spire OIDC Managed Route disabled",
+		// If route is already ready, don't update the status to avoid overwriting the reason
+		return existingRoute.Spec.Host, nil
+	}
+
+	r.log.Info("Found conflict in routes, updating route")
+	route.ResourceVersion = existingRoute.ResourceVersion
+
+	if createOnlyMode {
+		r.log.Info("Skipping Route update due to create-only mode", "Namespace", route.Namespace, "Name", route.Name)
+		return existingRoute.Spec.Host, nil
+	}
+
+	err = r.ctrlClient.Update(ctx, route)
+	if err != nil {
+		statusMgr.AddCondition(RouteAvailable, "ManagedRouteUpdateFailed",
+			err.Error(),
 			metav1.ConditionFalse)
+		return "", err
 	}
 
-	return nil
+	// Set status when route is actually updated
+	statusMgr.AddCondition(RouteAvailable, "ManagedRouteUpdated",
+		"Spire OIDC Managed Route updated",
+		metav1.ConditionTrue)
+
+	r.log.Info("Updated route", "Namespace", route.Namespace, "Name", route.Name)
+	return route.Spec.Host, nil
 }
 
 // checkRouteConflict returns true if desired & current routes has conflicts else return false
@@ -143,7 +199,10 @@ func generateOIDCDiscoveryProviderRoute(config *v1alpha1.SpireOIDCDiscoveryProvi
 		},
 	}
 
-	if config.Spec.ExternalSecretRef != "" {
+	// The provider serves its ACME certificate itself, so the router passes TLS through
+	if isACMEEnabled(config) {
+		route.Spec.TLS.Termination = routev1.TLSTerminationPassthrough
+	} else if config.Spec.ExternalSecretRef != "" {
 		route.Spec.TLS.ExternalCertificate = &routev1.LocalObjectReference{
 			Name: config.Spec.ExternalSecretRef,
 		}
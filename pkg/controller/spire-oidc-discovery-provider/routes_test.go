@@ -800,6 +800,8 @@ func newRouteTestReconciler(fakeClient *fakes.FakeCustomCtrlClient) *SpireOidcDi
 		log:           logr.Discard(),
 		scheme:        scheme,
 		eventRecorder: record.NewFakeRecorder(100),
+		// Background checks complete before the reconcile step returns
+		runInBackground: func(f func()) { f() },
 	}
 }
 
@@ -1096,3 +1098,21 @@ func TestReconcileRoute_ExternalSecretRef(t *testing.T) {
 		t.Errorf("Expected ExternalCertificate.Name 'custom-tls-secret', got %s", createdRoute.Spec.TLS.ExternalCertificate.Name)
 	}
 }
+
+func TestGenerateOIDCDiscoveryProviderRoute_Acme(t *testing.T) {
+	config := &v1alpha1.SpireOIDCDiscoveryProvider{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+			JwtIssuer: "https://oidc.example.com",
+			Acme:      &v1alpha1.OIDCAcmeConfig{Email: "admin@example.com", TosAccepted: "true"},
+		},
+	}
+
+	route, err := generateOIDCDiscoveryProviderRoute(config)
+
+	require.NoError(t, err)
+	assert.Equal(t, "oidc.example.com", route.Spec.Host)
+	require.NotNil(t, route.Spec.TLS)
+	assert.Equal(t, routev1.TLSTerminationPassthrough, route.Spec.TLS.Termination)
+	assert.Nil(t, route.Spec.TLS.ExternalCertificate)
+}
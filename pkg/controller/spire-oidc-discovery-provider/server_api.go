@@ -0,0 +1,78 @@
+package spire_oidc_discovery_provider
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// serverAPIRelaySocketPath is where the relay sidecar listens for the provider, in the sockets volume
+	// shared by both containers
+	serverAPIRelaySocketPath = "/run/spire/oidc-sockets/server-api.sock"
+	serverAPIRelayVolumeName = "server-api-relay-tls"
+)
+
+// serverAPIConfig returns the server_api section of the provider configuration, which points the provider
+// at the relay sidecar
+func serverAPIConfig(config *v1alpha1.OIDCServerAPIConfig) map[string]string {
+	serverAPI := map[string]string{
+		"address": "unix://" + serverAPIRelaySocketPath,
+	}
+	if config.PollInterval != nil {
+		serverAPI["poll_interval"] = config.PollInterval.Duration.String()
+	}
+	return serverAPI
+}
+
+// addServerAPIRelayToPod adds the sidecar forwarding the provider's server API requests to the proxy of
+// the spire-server pod with the bundle reader credentials. The provider no longer reads the Workload API,
+// so the SPIFFE CSI volume is dropped and the pod can run on nodes without an agent.
+func addServerAPIRelayToPod(podSpec *corev1.PodSpec, config *v1alpha1.SpireOIDCDiscoveryProvider) {
+	podSpec.Volumes = slices.DeleteFunc(podSpec.Volumes, func(volume corev1.Volume) bool {
+		return volume.Name == "spiffe-workload-api"
+	})
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: serverAPIRelayVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: utils.SpireServerAPIBundleReaderSecretName},
+		},
+	})
+	provider := &podSpec.Containers[0]
+	provider.VolumeMounts = slices.DeleteFunc(provider.VolumeMounts, func(mount corev1.VolumeMount) bool {
+		return mount.Name == "spiffe-workload-api"
+	})
+
+	podSpec.Containers = append(podSpec.Containers, corev1.Container{
+		SecurityContext: &corev1.SecurityContext{
+			ReadOnlyRootFilesystem: ptr.To(true),
+		},
+		Name:            utils.SpireServerAPIRelayContainerName,
+		Image:           utils.GetOperatorImage(),
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Args: []string{
+			utils.SpireServerAPIRelayCommand,
+			"--socket-path=" + serverAPIRelaySocketPath,
+			"--proxy-address=" + utils.ServerAPIProxyAddress(),
+			"--cert-dir=" + utils.SpireServerAPIRelayCertMountPath,
+		},
+		Resources: utils.DerefResourceRequirements(config.Spec.Resources),
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "spire-oidc-sockets", MountPath: "/run/spire/oidc-sockets"},
+			{Name: serverAPIRelayVolumeName, MountPath: utils.SpireServerAPIRelayCertMountPath, ReadOnly: true},
+		},
+	})
+}
+
+// validateServerAPI makes sure the relay sidecar can run, as it uses the operator's own image
+func validateServerAPI(oidc *v1alpha1.SpireOIDCDiscoveryProvider) error {
+	if oidc.Spec.ServerAPI != nil && utils.GetOperatorImage() == "" {
+		return fmt.Errorf("serverAPI requires the %s environment variable to run the server API relay", utils.OperatorImageEnv)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package spire_oidc_discovery_provider
+
+import (
+	"testing"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestBuildDeployment_ServerAPI(t *testing.T) {
+	t.Setenv("OPERATOR_NAMESPACE", "ztwim")
+	t.Setenv(utils.OperatorImageEnv, "operator:latest")
+	cr := createDeploymentTestOIDCCR()
+	cr.Spec.ServerAPI = &v1alpha1.OIDCServerAPIConfig{}
+
+	podSpec := generateDeployment(cr, "hash").Spec.Template.Spec
+
+	require.Len(t, podSpec.Containers, 2)
+	for _, mount := range podSpec.Containers[0].VolumeMounts {
+		assert.NotEqual(t, "spiffe-workload-api", mount.Name, "the provider must not depend on the local agent")
+	}
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range podSpec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	assert.NotContains(t, volumes, "spiffe-workload-api")
+	require.Contains(t, volumes, serverAPIRelayVolumeName)
+	require.NotNil(t, volumes[serverAPIRelayVolumeName].Secret)
+	assert.Equal(t, utils.SpireServerAPIBundleReaderSecretName, volumes[serverAPIRelayVolumeName].Secret.SecretName)
+
+	relay := podSpec.Containers[1]
+	assert.Equal(t, utils.SpireServerAPIRelayContainerName, relay.Name)
+	assert.Equal(t, "operator:latest", relay.Image)
+	assert.Equal(t, []string{
+		utils.SpireServerAPIRelayCommand,
+		"--socket-path=/run/spire/oidc-sockets/server-api.sock",
+		"--proxy-address=spire-server-api.ztwim.svc:8444",
+		"--cert-dir=" + utils.SpireServerAPIRelayCertMountPath,
+	}, relay.Args)
+	assert.Contains(t, relay.VolumeMounts, corev1.VolumeMount{Name: "spire-oidc-sockets", MountPath: "/run/spire/oidc-sockets"})
+	assert.Contains(t, relay.VolumeMounts, corev1.VolumeMount{Name: serverAPIRelayVolumeName, MountPath: utils.SpireServerAPIRelayCertMountPath, ReadOnly: true})
+
+	// Without serverAPI the provider reads the Workload API and runs alone
+	podSpec = generateDeployment(createDeploymentTestOIDCCR(), "hash").Spec.Template.Spec
+	assert.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "spiffe-workload-api", podSpec.Volumes[0].Name)
+}
+
+func TestValidateServerAPI(t *testing.T) {
+	cr := createDeploymentTestOIDCCR()
+	t.Setenv(utils.OperatorImageEnv, "")
+	assert.NoError(t, validateServerAPI(cr))
+
+	cr.Spec.ServerAPI = &v1alpha1.OIDCServerAPIConfig{}
+	assert.ErrorContains(t, validateServerAPI(cr), "serverAPI requires the "+utils.OperatorImageEnv)
+
+	t.Setenv(utils.OperatorImageEnv, "operator:latest")
+	assert.NoError(t, validateServerAPI(cr))
+}
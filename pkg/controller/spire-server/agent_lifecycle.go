@@ -0,0 +1,227 @@
+package spire_server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	spiretypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+)
+
+const (
+	// AgentLifecycleHealthy reports whether the last purge of stale agents succeeded
+	AgentLifecycleHealthy = "AgentLifecycleHealthy"
+
+	defaultAgentExpiredFor    = 24 * time.Hour
+	defaultAgentPurgeInterval = time.Hour
+	// agentPurgeRetryInterval is how soon a failed purge is retried
+	agentPurgeRetryInterval = time.Minute
+
+	k8sPSATAttestationType = "k8s_psat"
+	agentNodeNamePrefix    = "agent_node_name:"
+)
+
+// agentExpiredFor returns how long an agent SVID must have been expired before the agent is removed
+func agentExpiredFor(lifecycle *v1alpha1.SpireServerAgentLifecycle) time.Duration {
+	if lifecycle.ExpiredFor.Duration == 0 {
+		return defaultAgentExpiredFor
+	}
+	return lifecycle.ExpiredFor.Duration
+}
+
+// agentPurgeInterval returns how often the operator checks for agents to purge or ban
+func agentPurgeInterval(lifecycle *v1alpha1.SpireServerAgentLifecycle) time.Duration {
+	if lifecycle.PurgeInterval == nil {
+		return defaultAgentPurgeInterval
+	}
+	return lifecycle.PurgeInterval.Duration
+}
+
+// operatorManagesAgents reports whether the operator has to act on agents through the server API
+func operatorManagesAgents(lifecycle *v1alpha1.SpireServerAgentLifecycle) bool {
+	return lifecycle != nil &&
+		(lifecycle.PruneMode == v1alpha1.AgentPruneModeOperator || utils.StringToBool(lifecycle.BanAgentsOfDeletedNodes))
+}
+
+// reconcileAgentLifecycle purges expired agents and bans agents of deleted nodes when the policy asks the
+// operator to do so. It returns when the next check is due.
+func (r *SpireServerReconciler) reconcileAgentLifecycle(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager) time.Duration {
+	lifecycle := server.Spec.AgentLifecycle
+	if !operatorManagesAgents(lifecycle) {
+		statusMgr.RemoveCondition(AgentLifecycleHealthy)
+		if server.Status.AgentLifecycle != nil {
+			server.Status.AgentLifecycle = nil
+			statusMgr.RequestStatusUpdate()
+		}
+		return 0
+	}
+
+	interval := agentPurgeInterval(lifecycle)
+	if server.Status.AgentLifecycle == nil {
+		server.Status.AgentLifecycle = &v1alpha1.SpireServerAgentLifecycleStatus{}
+	}
+	lifecycleStatus := server.Status.AgentLifecycle
+	if lifecycleStatus.LastPurgeTime != nil {
+		if elapsed := time.Since(lifecycleStatus.LastPurgeTime.Time); elapsed < interval {
+			return interval - elapsed
+		}
+	}
+
+	now := metav1.Now()
+	retry := min(interval, agentPurgeRetryInterval)
+	apiClient, err := r.dialServerAPI()
+	if err != nil {
+		r.reportServerAPIUnavailable(statusMgr, err)
+		return retry
+	}
+	defer apiClient.Close()
+
+	var purged, banned int64
+	if lifecycle.PruneMode == v1alpha1.AgentPruneModeOperator {
+		purged, err = purgeExpiredAgents(ctx, apiClient, now.Add(-agentExpiredFor(lifecycle)))
+		if purged > 0 {
+			lifecycleStatus.PurgedAgents += purged
+			statusMgr.RequestStatusUpdate()
+		}
+		if err != nil {
+			r.reportAgentLifecycleFailure(statusMgr, "AgentPurgeFailed", "failed to purge expired agents", err)
+			return retry
+		}
+	}
+
+	if utils.StringToBool(lifecycle.BanAgentsOfDeletedNodes) {
+		banned, err = r.banAgentsOfDeletedNodes(ctx, apiClient)
+		if banned > 0 {
+			lifecycleStatus.BannedAgents += banned
+			statusMgr.RequestStatusUpdate()
+		}
+		if err != nil {
+			r.reportAgentLifecycleFailure(statusMgr, "AgentBanFailed", "failed to ban agents of deleted nodes", err)
+			return retry
+		}
+	}
+
+	if purged > 0 || banned > 0 {
+		r.log.Info("Removed stale agents", "purged", purged, "banned", banned)
+	}
+	// The purge time is only recorded once every step succeeded, so that failures are retried
+	lifecycleStatus.LastPurgeTime = &now
+	statusMgr.RequestStatusUpdate()
+	statusMgr.AddCondition(AgentLifecycleHealthy, v1alpha1.ReasonReady,
+		fmt.Sprintf("Purged %d expired agents and banned %d agents of deleted nodes", purged, banned),
+		metav1.ConditionTrue)
+	return interval
+}
+
+// reportAgentLifecycleFailure records a failed purge, telling an unreachable server API apart from
+// a failure of the purge itself
+func (r *SpireServerReconciler) reportAgentLifecycleFailure(statusMgr *status.Manager, reason, message string, err error) {
+	if spireapi.IsUnavailable(err) {
+		r.reportServerAPIUnavailable(statusMgr, err)
+		return
+	}
+	r.log.Error(err, message)
+	statusMgr.AddCondition(AgentLifecycleHealthy, reason, err.Error(), metav1.ConditionFalse)
+}
+
+// reportServerAPIUnavailable records that the purge could not run. The server API is unreachable while
+// the spire-server pod restarts, which the StatefulSet condition already reports, so the state of the
+// agents is unknown rather than unhealthy.
+func (r *SpireServerReconciler) reportServerAPIUnavailable(statusMgr *status.Manager, err error) {
+	r.log.Info("SPIRE server API unavailable, retrying agent purge", "error", err.Error())
+	statusMgr.AddCondition(AgentLifecycleHealthy, "ServerAPIUnavailable", err.Error(), metav1.ConditionUnknown)
+}
+
+// purgeExpiredAgents deletes the agents whose SVID expired before the given time
+func purgeExpiredAgents(ctx context.Context, apiClient spireapi.Client, expiredBefore time.Time) (int64, error) {
+	agents, err := apiClient.ListAgents(ctx, &agentv1.ListAgentsRequest_Filter{
+		ByExpiresBefore: expiredBefore.Format(spireapi.ExpiresBeforeLayout),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, agent := range agents {
+		if err := apiClient.DeleteAgent(ctx, agent.Id); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// banAgentsOfDeletedNodes bans the k8s_psat agents whose Node no longer exists
+func (r *SpireServerReconciler) banAgentsOfDeletedNodes(ctx context.Context, apiClient spireapi.Client) (int64, error) {
+	agents, err := apiClient.ListAgents(ctx, &agentv1.ListAgentsRequest_Filter{
+		ByAttestationType: k8sPSATAttestationType,
+		ByBanned:          wrapperspb.Bool(false),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var banned int64
+	for _, agent := range agents {
+		nodeName := agentNodeName(agent)
+		if nodeName == "" {
+			continue
+		}
+		deleted, err := r.nodeDeleted(ctx, nodeName)
+		if err != nil {
+			return banned, err
+		}
+		if !deleted {
+			continue
+		}
+		if err := apiClient.BanAgent(ctx, agent.Id); err != nil {
+			return banned, err
+		}
+		r.log.Info("Banned agent of deleted node", "agent", agent.Id.GetPath(), "node", nodeName)
+		banned++
+	}
+	return banned, nil
+}
+
+// nodeDeleted looks the Node up in the cache. A ban cannot be undone by the agent, so a Node missing
+// from the cache, which may not have synced a new Node yet, is confirmed with a live lookup.
+func (r *SpireServerReconciler) nodeDeleted(ctx context.Context, nodeName string) (bool, error) {
+	key := types.NamespacedName{Name: nodeName}
+	err := r.ctrlClient.Get(ctx, key, &corev1.Node{})
+	if err == nil {
+		return false, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	err = r.ctrlClient.UncachedGet(ctx, key, &corev1.Node{})
+	if err == nil {
+		return false, nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return false, fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	return true, nil
+}
+
+// agentNodeName returns the name of the Node a k8s_psat agent attested from
+func agentNodeName(agent *spiretypes.Agent) string {
+	for _, selector := range agent.Selectors {
+		if selector.Type == k8sPSATAttestationType && strings.HasPrefix(selector.Value, agentNodeNamePrefix) {
+			return strings.TrimPrefix(selector.Value, agentNodeNamePrefix)
+		}
+	}
+	return ""
+}
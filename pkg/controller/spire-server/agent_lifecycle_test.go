@@ -0,0 +1,254 @@
+package spire_server
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	spiretypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	spireapifakes "github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi/fakes"
+)
+
+// listAgentsBy serves the expired agents for the expiry filter and the PSAT agents otherwise
+func listAgentsBy(expired, psat []*spiretypes.Agent) func(context.Context, *agentv1.ListAgentsRequest_Filter) ([]*spiretypes.Agent, error) {
+	return func(_ context.Context, filter *agentv1.ListAgentsRequest_Filter) ([]*spiretypes.Agent, error) {
+		if filter.ByExpiresBefore != "" {
+			return expired, nil
+		}
+		return psat, nil
+	}
+}
+
+// agentPaths returns the paths of the agent IDs passed to the recorded calls
+func agentPaths(count int, argsForCall func(int) (context.Context, *spiretypes.SPIFFEID)) []string {
+	var paths []string
+	for i := 0; i < count; i++ {
+		_, id := argsForCall(i)
+		paths = append(paths, id.GetPath())
+	}
+	return paths
+}
+
+func psatAgent(path, nodeName string) *spiretypes.Agent {
+	return &spiretypes.Agent{
+		Id: &spiretypes.SPIFFEID{TrustDomain: "example.org", Path: path},
+		Selectors: []*spiretypes.Selector{
+			{Type: "k8s_psat", Value: "cluster:test"},
+			{Type: "k8s_psat", Value: "agent_node_name:" + nodeName},
+		},
+	}
+}
+
+func newAgentLifecycleTestReconciler(fakeClient *fakes.FakeCustomCtrlClient, api spireapi.Client) *SpireServerReconciler {
+	reconciler := newRouteTestReconciler(fakeClient)
+	reconciler.dialServerAPI = func() (spireapi.Client, error) {
+		if api == nil {
+			return nil, errors.New("socket not available")
+		}
+		return api, nil
+	}
+	return reconciler
+}
+
+func applyTestStatus(t *testing.T, statusMgr *status.Manager, server *v1alpha1.SpireServer) {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), server, func() *v1alpha1.ConditionalStatus {
+		return &server.Status.ConditionalStatus
+	}))
+}
+
+func TestReconcileAgentLifecycle(t *testing.T) {
+	t.Run("server pruning does not use the server API", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newAgentLifecycleTestReconciler(fakeClient, nil)
+		server := &v1alpha1.SpireServer{Spec: v1alpha1.SpireServerSpec{
+			AgentLifecycle: &v1alpha1.SpireServerAgentLifecycle{PruneMode: v1alpha1.AgentPruneModeServer},
+		}}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileAgentLifecycle(context.Background(), server, statusMgr)
+
+		assert.Zero(t, next)
+		assert.Nil(t, server.Status.AgentLifecycle)
+	})
+
+	t.Run("operator purges expired agents and bans agents of deleted nodes", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		nodeNotFound := func(_ context.Context, key client.ObjectKey, _ client.Object) error {
+			if key.Name == "deleted-node" || key.Name == "new-node" {
+				return kerrors.NewNotFound(schema.GroupResource{Resource: "nodes"}, key.Name)
+			}
+			return nil
+		}
+		// new-node is not in the cache yet but exists on the API server
+		fakeClient.GetStub = nodeNotFound
+		fakeClient.UncachedGetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			if key.Name == "new-node" {
+				return nil
+			}
+			return nodeNotFound(ctx, key, obj)
+		}
+		api := &spireapifakes.FakeClient{}
+		api.ListAgentsStub = listAgentsBy(
+			[]*spiretypes.Agent{{Id: &spiretypes.SPIFFEID{TrustDomain: "example.org", Path: "/spire/agent/k8s_psat/test/expired"}}},
+			[]*spiretypes.Agent{
+				psatAgent("/spire/agent/k8s_psat/test/live", "live-node"),
+				psatAgent("/spire/agent/k8s_psat/test/gone", "deleted-node"),
+				psatAgent("/spire/agent/k8s_psat/test/new", "new-node"),
+			},
+		)
+		reconciler := newAgentLifecycleTestReconciler(fakeClient, api)
+		server := &v1alpha1.SpireServer{
+			Spec: v1alpha1.SpireServerSpec{
+				AgentLifecycle: &v1alpha1.SpireServerAgentLifecycle{
+					PruneMode:               v1alpha1.AgentPruneModeOperator,
+					ExpiredFor:              metav1.Duration{Duration: 2 * time.Hour},
+					PurgeInterval:           &metav1.Duration{Duration: 10 * time.Minute},
+					BanAgentsOfDeletedNodes: "true",
+				},
+			},
+			Status: v1alpha1.SpireServerStatus{
+				AgentLifecycle: &v1alpha1.SpireServerAgentLifecycleStatus{PurgedAgents: 3},
+			},
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileAgentLifecycle(context.Background(), server, statusMgr)
+
+		assert.Equal(t, 10*time.Minute, next)
+		assert.Equal(t, []string{"/spire/agent/k8s_psat/test/expired"}, agentPaths(api.DeleteAgentCallCount(), api.DeleteAgentArgsForCall))
+		assert.Equal(t, []string{"/spire/agent/k8s_psat/test/gone"}, agentPaths(api.BanAgentCallCount(), api.BanAgentArgsForCall))
+		require.Equal(t, 2, api.ListAgentsCallCount())
+		_, expiredFilter := api.ListAgentsArgsForCall(0)
+		expiredBefore, err := time.Parse(spireapi.ExpiresBeforeLayout, expiredFilter.ByExpiresBefore)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(-2*time.Hour), expiredBefore, time.Minute)
+		_, psatFilter := api.ListAgentsArgsForCall(1)
+		assert.Equal(t, "k8s_psat", psatFilter.ByAttestationType)
+		assert.False(t, psatFilter.ByBanned.GetValue())
+
+		require.NotNil(t, server.Status.AgentLifecycle)
+		assert.Equal(t, int64(4), server.Status.AgentLifecycle.PurgedAgents)
+		assert.Equal(t, int64(1), server.Status.AgentLifecycle.BannedAgents)
+		assert.NotNil(t, server.Status.AgentLifecycle.LastPurgeTime)
+
+		applyTestStatus(t, statusMgr, server)
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, AgentLifecycleHealthy)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	})
+
+	t.Run("purge is skipped until the interval elapses", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		api := &spireapifakes.FakeClient{}
+		reconciler := newAgentLifecycleTestReconciler(fakeClient, api)
+		lastPurge := metav1.NewTime(time.Now().Add(-20 * time.Minute))
+		server := &v1alpha1.SpireServer{
+			Spec: v1alpha1.SpireServerSpec{
+				AgentLifecycle: &v1alpha1.SpireServerAgentLifecycle{PruneMode: v1alpha1.AgentPruneModeOperator},
+			},
+			Status: v1alpha1.SpireServerStatus{
+				AgentLifecycle: &v1alpha1.SpireServerAgentLifecycleStatus{LastPurgeTime: &lastPurge},
+			},
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileAgentLifecycle(context.Background(), server, statusMgr)
+
+		assert.InDelta(t, float64(40*time.Minute), float64(next), float64(time.Minute))
+		assert.Zero(t, api.ListAgentsCallCount())
+	})
+
+	t.Run("unavailable server API is retried without failing the server", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newAgentLifecycleTestReconciler(fakeClient, nil)
+		server := &v1alpha1.SpireServer{Spec: v1alpha1.SpireServerSpec{
+			AgentLifecycle: &v1alpha1.SpireServerAgentLifecycle{PruneMode: v1alpha1.AgentPruneModeOperator},
+		}}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileAgentLifecycle(context.Background(), server, statusMgr)
+
+		assert.Equal(t, agentPurgeRetryInterval, next)
+		assert.Nil(t, server.Status.AgentLifecycle.LastPurgeTime)
+		applyTestStatus(t, statusMgr, server)
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, AgentLifecycleHealthy)
+		require.NotNil(t, condition)
+		assert.Equal(t, "ServerAPIUnavailable", condition.Reason)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	})
+
+	t.Run("failed purge is retried and does not record the purge time", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		api := &spireapifakes.FakeClient{}
+		api.ListAgentsReturns(nil, errors.New("datastore failure"))
+		reconciler := newAgentLifecycleTestReconciler(fakeClient, api)
+		server := &v1alpha1.SpireServer{Spec: v1alpha1.SpireServerSpec{
+			AgentLifecycle: &v1alpha1.SpireServerAgentLifecycle{PruneMode: v1alpha1.AgentPruneModeOperator},
+		}}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileAgentLifecycle(context.Background(), server, statusMgr)
+
+		assert.Equal(t, agentPurgeRetryInterval, next)
+		assert.Nil(t, server.Status.AgentLifecycle.LastPurgeTime)
+		applyTestStatus(t, statusMgr, server)
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, AgentLifecycleHealthy)
+		require.NotNil(t, condition)
+		assert.Equal(t, "AgentPurgeFailed", condition.Reason)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	})
+
+	t.Run("server API becoming unavailable mid-purge is not reported as a failure", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		api := &spireapifakes.FakeClient{}
+		api.ListAgentsReturns(nil, grpcstatus.Error(codes.Unavailable, "connection refused"))
+		reconciler := newAgentLifecycleTestReconciler(fakeClient, api)
+		server := &v1alpha1.SpireServer{Spec: v1alpha1.SpireServerSpec{
+			AgentLifecycle: &v1alpha1.SpireServerAgentLifecycle{PruneMode: v1alpha1.AgentPruneModeOperator},
+		}}
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileAgentLifecycle(context.Background(), server, statusMgr)
+
+		applyTestStatus(t, statusMgr, server)
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, AgentLifecycleHealthy)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+	})
+
+	t.Run("removing the policy clears the status", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newAgentLifecycleTestReconciler(fakeClient, nil)
+		server := &v1alpha1.SpireServer{Status: v1alpha1.SpireServerStatus{
+			AgentLifecycle: &v1alpha1.SpireServerAgentLifecycleStatus{PurgedAgents: 2},
+		}}
+		statusMgr := status.NewManager(fakeClient)
+
+		next := reconciler.reconcileAgentLifecycle(context.Background(), server, statusMgr)
+
+		assert.Zero(t, next)
+		assert.Nil(t, server.Status.AgentLifecycle)
+	})
+}
+
+func TestAgentNodeName(t *testing.T) {
+	assert.Equal(t, "worker-1", agentNodeName(psatAgent("/spire/agent/k8s_psat/test/a", "worker-1")))
+	assert.Empty(t, agentNodeName(&spiretypes.Agent{Selectors: []*spiretypes.Selector{{Type: "join_token", Value: "abc"}}}))
+}
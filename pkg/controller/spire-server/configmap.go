@@ -252,6 +252,12 @@ func generateSpireServerConfigMap(config *v1alpha1.SpireServerSpec, ztwim *v1alp
 
 // generateServerConfMap builds the server.conf structure as a Go map
 func generateServerConfMap(config *v1alpha1.SpireServerSpec, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) map[string]interface{} {
+	// The CA is named after the external endpoint unless a common name is configured
+	caCommonName := config.CASubject.CommonName
+	if caCommonName == "" {
+		caCommonName = exposureCommonName(config.Exposure)
+	}
+
 	// Build the server config
 	serverConfig := map[string]interface{}{
 		"audit_log_enabled": false,
@@ -260,7 +266,7 @@ func generateServerConfMap(config *v1alpha1.SpireServerSpec, ztwim *v1alpha1.Zer
 		"ca_key_type":       getCAKeyType(config.CAKeyType),
 		"ca_subject": []map[string]interface{}{
 			{
-				"common_name":  config.CASubject.CommonName,
+				"common_name":  caCommonName,
 				"country":      []string{config.CASubject.Country},
 				"organization": []string{config.CASubject.Organization},
 			},
@@ -351,6 +357,9 @@ func generateServerConfMap(config *v1alpha1.SpireServerSpec, ztwim *v1alpha1.Zer
 		serverSection["federation"] = generateFederationConfig(config.Federation)
 	}
 
+	configureServerPerformance(serverConfig, config.Performance)
+	configureAgentPruning(serverConfig, config.AgentLifecycle)
+
 	if config.UpstreamAuthority != nil {
 		if uaPlugin := buildUpstreamAuthorityPlugin(config.UpstreamAuthority); uaPlugin != nil {
 			plugins := configMap["plugins"].(map[string]interface{})
@@ -361,6 +370,36 @@ func generateServerConfMap(config *v1alpha1.SpireServerSpec, ztwim *v1alpha1.Zer
 	return configMap
 }
 
+// configureServerPerformance maps the performance settings to the experimental section of the
+// SPIRE server configuration. Unset settings are omitted so that SPIRE applies its defaults.
+func configureServerPerformance(serverConfig map[string]interface{}, performance *v1alpha1.SpireServerPerformance) {
+	if performance == nil {
+		return
+	}
+
+	experimental := map[string]interface{}{}
+	if performance.CacheReloadInterval != nil {
+		experimental["cache_reload_interval"] = performance.CacheReloadInterval.Duration.String()
+	}
+	if performance.EventsBasedCache != "" {
+		experimental["events_based_cache"] = utils.StringToBool(performance.EventsBasedCache)
+	}
+	if performance.PruneEventsOlderThan != nil {
+		experimental["prune_events_older_than"] = performance.PruneEventsOlderThan.Duration.String()
+	}
+	if len(experimental) > 0 {
+		serverConfig["experimental"] = experimental
+	}
+}
+
+// configureAgentPruning enables the SPIRE server pruning of expired agents unless the operator purges them
+func configureAgentPruning(serverConfig map[string]interface{}, lifecycle *v1alpha1.SpireServerAgentLifecycle) {
+	if lifecycle == nil || lifecycle.PruneMode == v1alpha1.AgentPruneModeOperator {
+		return
+	}
+	serverConfig["prune_attested_nodes_expired_for"] = agentExpiredFor(lifecycle).String()
+}
+
 func buildUpstreamAuthorityPlugin(ua *v1alpha1.UpstreamAuthorityConfig) []map[string]interface{} {
 	if ua.CertManager != nil {
 		return []map[string]interface{}{
@@ -586,6 +625,15 @@ func buildDataStorePluginData(datastore v1alpha1.DataStore) map[string]interface
 	return pluginData
 }
 
+// SpireControllerManagerIgnoreNamespaces are regular expressions matching the namespaces whose pods
+// spire-controller-manager never registers
+var SpireControllerManagerIgnoreNamespaces = []string{
+	"kube-system",
+	"kube-public",
+	"local-path-storage",
+	"openshift-*",
+}
+
 func generateControllerManagerConfig(config *v1alpha1.SpireServerSpec, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) (*ControllerManagerConfigYAML, error) {
 	if ztwim.Spec.TrustDomain == "" {
 		return nil, errors.New("trust_domain is empty")
@@ -623,12 +671,7 @@ func generateControllerManagerConfig(config *v1alpha1.SpireServerSpec, ztwim *v1
 			},
 			ValidatingWebhookConfigurationName: "spire-controller-manager-webhook",
 			SPIREServerSocketPath:              "/tmp/spire-server/private/api.sock",
-			IgnoreNamespaces: []string{
-				"kube-system",
-				"kube-public",
-				"local-path-storage",
-				"openshift-*",
-			},
+			IgnoreNamespaces:                   SpireControllerManagerIgnoreNamespaces,
 		},
 	}, nil
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -336,6 +337,129 @@ func TestGenerateServerConfMapTTLFields(t *testing.T) {
 	}
 }
 
+func TestGenerateServerConfMapPerformance(t *testing.T) {
+	validZTWIM := &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{
+			TrustDomain:     "example.org",
+			BundleConfigMap: "spire-bundle",
+		},
+	}
+
+	tests := []struct {
+		name        string
+		performance *v1alpha1.SpireServerPerformance
+		expected    map[string]interface{}
+	}{
+		{
+			name:        "no performance settings",
+			performance: nil,
+			expected:    nil,
+		},
+		{
+			name:        "empty performance settings",
+			performance: &v1alpha1.SpireServerPerformance{},
+			expected:    nil,
+		},
+		{
+			name: "all performance settings",
+			performance: &v1alpha1.SpireServerPerformance{
+				CacheReloadInterval:  &metav1.Duration{Duration: 30 * time.Second},
+				EventsBasedCache:     "true",
+				PruneEventsOlderThan: &metav1.Duration{Duration: 6 * time.Hour},
+			},
+			expected: map[string]interface{}{
+				"cache_reload_interval":   "30s",
+				"events_based_cache":      true,
+				"prune_events_older_than": "6h0m0s",
+			},
+		},
+		{
+			name: "events based cache disabled",
+			performance: &v1alpha1.SpireServerPerformance{
+				EventsBasedCache: "false",
+			},
+			expected: map[string]interface{}{
+				"events_based_cache": false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createValidConfig()
+			config.Performance = tt.performance
+
+			confMap := generateServerConfMap(config, validZTWIM)
+
+			server := confMap["server"].(map[string]interface{})
+			experimental, ok := server["experimental"]
+			if tt.expected == nil {
+				if ok {
+					t.Errorf("Expected no experimental section, got %v", experimental)
+				}
+				return
+			}
+			if !reflect.DeepEqual(experimental, tt.expected) {
+				t.Errorf("Expected experimental section %v, got %v", tt.expected, experimental)
+			}
+		})
+	}
+}
+
+func TestGenerateServerConfMapAgentPruning(t *testing.T) {
+	validZTWIM := &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{
+			TrustDomain:     "example.org",
+			BundleConfigMap: "spire-bundle",
+		},
+	}
+
+	tests := []struct {
+		name      string
+		lifecycle *v1alpha1.SpireServerAgentLifecycle
+		expected  interface{}
+	}{
+		{
+			name:      "no agent lifecycle policy",
+			lifecycle: nil,
+		},
+		{
+			name:      "server pruning with default expiry",
+			lifecycle: &v1alpha1.SpireServerAgentLifecycle{PruneMode: v1alpha1.AgentPruneModeServer},
+			expected:  "24h0m0s",
+		},
+		{
+			name: "server pruning with custom expiry",
+			lifecycle: &v1alpha1.SpireServerAgentLifecycle{
+				PruneMode:  v1alpha1.AgentPruneModeServer,
+				ExpiredFor: metav1.Duration{Duration: 2 * time.Hour},
+			},
+			expected: "2h0m0s",
+		},
+		{
+			name: "operator purging is not rendered",
+			lifecycle: &v1alpha1.SpireServerAgentLifecycle{
+				PruneMode:  v1alpha1.AgentPruneModeOperator,
+				ExpiredFor: metav1.Duration{Duration: 2 * time.Hour},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := createValidConfig()
+			config.AgentLifecycle = tt.lifecycle
+
+			confMap := generateServerConfMap(config, validZTWIM)
+
+			server := confMap["server"].(map[string]interface{})
+			if got := server["prune_attested_nodes_expired_for"]; !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected prune_attested_nodes_expired_for %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
 func TestGenerateSpireServerConfigMapWithTTLFields(t *testing.T) {
 	// Test that the new TTL fields are properly included in the generated ConfigMap
 	config := createValidConfig()
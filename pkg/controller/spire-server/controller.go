@@ -7,13 +7,16 @@ import (
 
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -23,6 +26,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
@@ -32,6 +36,7 @@ import (
 	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
 )
 
 const (
@@ -56,6 +61,9 @@ type SpireServerReconciler struct {
 	eventRecorder record.EventRecorder
 	log           logr.Logger
 	scheme        *runtime.Scheme
+	dialServerAPI spireapi.DialFunc
+	// gatewayAPIKinds are the Gateway API kinds served at startup, which are cached and watched
+	gatewayAPIKinds []schema.GroupVersionKind
 }
 
 // New returns a new Reconciler instance.
@@ -70,6 +78,9 @@ func New(mgr ctrl.Manager) (*SpireServerReconciler, error) {
 		eventRecorder: mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerSpireServerControllerName),
 		log:           ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerSpireServerControllerName),
 		scheme:        mgr.GetScheme(),
+		dialServerAPI: utils.ServerAPIDialer(c),
+
+		gatewayAPIKinds: utils.ServedGatewayAPIKinds(mgr.GetRESTMapper()),
 	}, nil
 }
 
@@ -138,6 +149,9 @@ func (r *SpireServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, nil
 	}
 
+	// Compare the issuer with the one the OIDC discovery provider serves
+	r.reconcileJWTIssuerConsistency(ctx, &server, statusMgr)
+
 	// Reconcile ServiceAccount
 	if err := r.reconcileServiceAccount(ctx, &server, statusMgr, createOnlyMode); err != nil {
 		return ctrl.Result{}, err
@@ -175,6 +189,11 @@ func (r *SpireServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	// Reconcile the credentials of the server API proxy, which the StatefulSet mounts
+	if err := r.reconcileServerAPICredentials(ctx, &server, statusMgr); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// Reconcile StatefulSet
 	if err := r.reconcileStatefulSet(ctx, &server, statusMgr, createOnlyMode, spireServerConfigMapHash, spireControllerManagerConfigMapHash); err != nil {
 		return ctrl.Result{}, err
@@ -185,7 +204,15 @@ func (r *SpireServerReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	// Reconcile external exposure of the gRPC endpoint
+	if err := r.reconcileExposure(ctx, &server, statusMgr, createOnlyMode); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// Purge and ban stale agents when the agent lifecycle policy asks the operator to
+	nextAgentPurge := r.reconcileAgentLifecycle(ctx, &server, statusMgr)
+
+	return ctrl.Result{RequeueAfter: nextAgentPurge}, nil
 }
 
 func (r *SpireServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -203,21 +230,30 @@ func (r *SpireServerReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Use component-specific predicate to only reconcile for control-plane component resources
 	controllerManagedResourcePredicates := builder.WithPredicates(utils.ControllerManagedResourcesForComponent(utils.ComponentControlPlane))
 
-	err := ctrl.NewControllerManagedBy(mgr).
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.SpireServer{}, builder.WithPredicates(utils.GenerationOrOwnerReferenceChangedPredicate)).
 		Named(utils.ZeroTrustWorkloadIdentityManagerSpireServerControllerName).
 		Watches(&appsv1.StatefulSet{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.ServiceAccount{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&corev1.Service{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&rbacv1.ClusterRole{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&rbacv1.ClusterRoleBinding{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&rbacv1.Role{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&rbacv1.RoleBinding{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&admissionregistrationv1.ValidatingWebhookConfiguration{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
 		Watches(&v1alpha1.ZeroTrustWorkloadIdentityManager{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(utils.ZTWIMSpecChangedPredicate)).
+		Watches(&v1alpha1.SpireOIDCDiscoveryProvider{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Watches(&routev1.Route{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates).
-		Complete(r)
+		Watches(&networkingv1.Ingress{}, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates)
+	// The Gateway API kinds are optional, only those served at startup are watched
+	for _, gvk := range r.gatewayAPIKinds {
+		gatewayObject := &unstructured.Unstructured{}
+		gatewayObject.SetGroupVersionKind(gvk)
+		controllerBuilder = controllerBuilder.Watches(gatewayObject, handler.EnqueueRequestsFromMapFunc(mapFunc), controllerManagedResourcePredicates)
+	}
+	err := controllerBuilder.Complete(r)
 	if err != nil {
 		return err
 	}
@@ -284,6 +320,22 @@ func (r *SpireServerReconciler) validateConfiguration(ctx context.Context, serve
 		}
 	}
 
+	if err := validateExposure(server.Spec.Exposure); err != nil {
+		r.log.Error(err, "Invalid exposure configuration")
+		statusMgr.AddCondition(ConfigurationValid, "InvalidExposureConfiguration",
+			fmt.Sprintf("Exposure configuration validation failed: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	if err := utils.ValidateSpireServerPerformance(server.Spec.Performance); err != nil {
+		r.log.Error(err, "Invalid performance configuration")
+		statusMgr.AddCondition(ConfigurationValid, utils.ConditionReasonInvalidPerformance,
+			fmt.Sprintf("Performance configuration validation failed: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
 	// Only set to true if the condition previously existed as false
 	existingCondition := apimeta.FindStatusCondition(server.Status.ConditionalStatus.Conditions, ConfigurationValid)
 	if existingCondition != nil && existingCondition.Status == metav1.ConditionFalse {
@@ -296,6 +348,14 @@ func (r *SpireServerReconciler) validateConfiguration(ctx context.Context, serve
 
 // validateCommonConfig validates common configuration fields (affinity, tolerations, nodeSelector, resources, labels)
 func (r *SpireServerReconciler) validateCommonConfig(server *v1alpha1.SpireServer, statusMgr *status.Manager) error {
+	if err := utils.ValidateContainerResources(server.Spec.ContainerResources, "spire-server", "spire-controller-manager", utils.SpireServerAPIProxyContainerName); err != nil {
+		r.log.Error(err, "containerResources validation failed", "name", server.Name)
+		statusMgr.AddCondition(utils.ConditionTypeConfigurationValid, utils.ConditionReasonInvalidResources,
+			fmt.Sprintf("Container resources validation failed: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
 	return utils.ValidateAndUpdateStatus(
 		r.log,
 		statusMgr,
@@ -367,3 +427,25 @@ func (r *SpireServerReconciler) handleTTLValidation(ctx context.Context, server
 
 	return nil
 }
+
+// reconcileJWTIssuerConsistency reports in the JWTIssuerConsistent condition whether the SpireOIDCDiscoveryProvider
+// serves the issuer the server signs JWT-SVIDs with
+func (r *SpireServerReconciler) reconcileJWTIssuerConsistency(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager) {
+	var oidc v1alpha1.SpireOIDCDiscoveryProvider
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: "cluster"}, &oidc); err != nil {
+		if kerrors.IsNotFound(err) {
+			// Without a discovery provider, relying parties are configured with the issuer directly
+			statusMgr.RemoveCondition(utils.JWTIssuerConsistentConditionType)
+			return
+		}
+		r.log.Error(err, "failed to get SpireOIDCDiscoveryProvider to compare JWT issuers")
+		return
+	}
+
+	conditionStatus, reason, message := utils.CheckJWTIssuerConsistency(server, &oidc)
+	if conditionStatus != metav1.ConditionTrue && utils.JWTIssuerConditionChanged(server.Status.Conditions, conditionStatus, reason, message) {
+		r.log.Info("JWT issuer mismatch", "message", message)
+		r.eventRecorder.Event(server, corev1.EventTypeWarning, reason, message)
+	}
+	statusMgr.AddCondition(utils.JWTIssuerConsistentConditionType, reason, message, conditionStatus)
+}
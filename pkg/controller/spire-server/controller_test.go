@@ -3,6 +3,7 @@ package spire_server
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,9 +11,11 @@ import (
 	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -549,6 +552,28 @@ func TestValidateCommonConfig_InvalidAffinity(t *testing.T) {
 	}
 }
 
+// TestValidateCommonConfig_InvalidContainerResources tests that overrides must name a spire-server pod container
+func TestValidateCommonConfig_InvalidContainerResources(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+
+	server := &v1alpha1.SpireServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: v1alpha1.SpireServerSpec{
+			ContainerResources: []v1alpha1.ContainerResources{
+				{Name: "spiffe-csi-driver"},
+			},
+		},
+	}
+
+	statusMgr := status.NewManager(fakeClient)
+	err := reconciler.validateCommonConfig(server, statusMgr)
+
+	if err == nil {
+		t.Error("Expected error for container resources of an unknown container")
+	}
+}
+
 // TestValidateProxyConfiguration_AllScenarios tests proxy validation scenarios
 func TestValidateProxyConfiguration_AllScenarios(t *testing.T) {
 	tests := []struct {
@@ -2230,3 +2255,65 @@ func TestReconcile_ReconciliationStepErrors_MutationKillers(t *testing.T) {
 		})
 	}
 }
+
+// TestReconcileJWTIssuerConsistency tests the JWTIssuerConsistent condition and the mismatch event
+func TestReconcileJWTIssuerConsistency(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+	recorder := record.NewFakeRecorder(10)
+	reconciler.eventRecorder = recorder
+	fakeClient.GetStub = func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+		if oidc, ok := obj.(*v1alpha1.SpireOIDCDiscoveryProvider); ok {
+			oidc.Spec.JwtIssuer = "https://oidc.example.com"
+			return nil
+		}
+		return kerrors.NewNotFound(schema.GroupResource{}, "cluster")
+	}
+	server := &v1alpha1.SpireServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.SpireServerSpec{JwtIssuer: "https://server.example.com"},
+	}
+	statusMgr := status.NewManager(fakeClient)
+
+	reconciler.reconcileJWTIssuerConsistency(context.Background(), server, statusMgr)
+
+	if err := statusMgr.ApplyStatus(context.Background(), server, func() *v1alpha1.ConditionalStatus {
+		return &server.Status.ConditionalStatus
+	}); err != nil {
+		t.Fatalf("failed to apply status: %v", err)
+	}
+	condition := apimeta.FindStatusCondition(server.Status.Conditions, utils.JWTIssuerConsistentConditionType)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != utils.JWTIssuerMismatch {
+		t.Fatalf("Expected a False %s condition, got %+v", utils.JWTIssuerMismatch, condition)
+	}
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, "Warning JWTIssuerMismatch") {
+			t.Errorf("Expected a JWTIssuerMismatch warning event, got %q", event)
+		}
+	default:
+		t.Error("Expected a JWTIssuerMismatch warning event")
+	}
+
+	// The mismatch is only recorded again when the condition changes
+	reconciler.reconcileJWTIssuerConsistency(context.Background(), server, status.NewManager(fakeClient))
+	if len(recorder.Events) != 0 {
+		t.Errorf("Expected no event for an unchanged condition, got %q", <-recorder.Events)
+	}
+
+	// Without a SpireOIDCDiscoveryProvider there is nothing to compare
+	fakeClient.GetStub = nil
+	fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "cluster"))
+	statusMgr = status.NewManager(fakeClient)
+
+	reconciler.reconcileJWTIssuerConsistency(context.Background(), server, statusMgr)
+
+	if err := statusMgr.ApplyStatus(context.Background(), server, func() *v1alpha1.ConditionalStatus {
+		return &server.Status.ConditionalStatus
+	}); err != nil {
+		t.Fatalf("failed to apply status: %v", err)
+	}
+	if condition := apimeta.FindStatusCondition(server.Status.Conditions, utils.JWTIssuerConsistentConditionType); condition != nil {
+		t.Errorf("Expected the condition to be removed, got %+v", condition)
+	}
+}
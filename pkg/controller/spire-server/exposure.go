@@ -0,0 +1,430 @@
+package spire_server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// ExposureAvailable reports whether the SPIRE server gRPC endpoint is reachable from outside the cluster
+	ExposureAvailable = "ExposureAvailable"
+
+	spireServerGRPCRouteName       = "spire-server-grpc"
+	spireServerExternalServiceName = "spire-server-external"
+	defaultExposureServicePort     = 443
+	routePort                      = 443
+)
+
+// generateGRPCRoute creates a passthrough Route for the SPIRE server gRPC endpoint
+func generateGRPCRoute(server *v1alpha1.SpireServer) *routev1.Route {
+	return &routev1.Route{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      spireServerGRPCRouteName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireServerLabels(server.Spec.Labels),
+		},
+		Spec: routev1.RouteSpec{
+			Host: server.Spec.Exposure.Route.Host,
+			To: routev1.RouteTargetReference{
+				Kind:   "Service",
+				Name:   "spire-server",
+				Weight: ptr.To(int32(100)),
+			},
+			Port: &routev1.RoutePort{
+				TargetPort: intstr.FromString("grpc"),
+			},
+			TLS: &routev1.TLSConfig{
+				Termination:                   routev1.TLSTerminationPassthrough,
+				InsecureEdgeTerminationPolicy: routev1.InsecureEdgeTerminationPolicyNone,
+			},
+			WildcardPolicy: routev1.WildcardPolicyNone,
+		},
+	}
+}
+
+// generateExternalService creates the LoadBalancer or NodePort Service for the SPIRE server gRPC endpoint
+func generateExternalService(server *v1alpha1.SpireServer) *corev1.Service {
+	exposure := server.Spec.Exposure.Service
+
+	serviceType := exposure.Type
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeLoadBalancer
+	}
+	port := exposure.Port
+	if port == 0 {
+		port = defaultExposureServicePort
+	}
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        spireServerExternalServiceName,
+			Namespace:   utils.GetOperatorNamespace(),
+			Labels:      utils.SpireServerLabels(server.Spec.Labels),
+			Annotations: exposure.Annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: serviceType,
+			Selector: map[string]string{
+				"app.kubernetes.io/name":     "spire-server",
+				"app.kubernetes.io/instance": utils.StandardInstance,
+			},
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "grpc",
+					Port:       port,
+					TargetPort: intstr.FromString("grpc"),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+	if serviceType == corev1.ServiceTypeNodePort {
+		svc.Spec.Ports[0].NodePort = exposure.NodePort
+	}
+	if serviceType == corev1.ServiceTypeLoadBalancer {
+		svc.Spec.LoadBalancerSourceRanges = exposure.LoadBalancerSourceRanges
+	}
+	return svc
+}
+
+// exposureCommonName returns the name used as common name of the CA subject when none is configured,
+// the Route host or else the first of the additional DNS names. Names only known once the Route is
+// admitted or the load balancer provisioned are left out, so that the server configuration is stable.
+func exposureCommonName(exposure *v1alpha1.SpireServerExposure) string {
+	if exposure == nil {
+		return ""
+	}
+	if exposure.Route != nil && exposure.Route.Host != "" {
+		return exposure.Route.Host
+	}
+	if len(exposure.DNSNames) > 0 {
+		return exposure.DNSNames[0]
+	}
+	return ""
+}
+
+// validateExposure validates the SPIRE server exposure configuration
+func validateExposure(exposure *v1alpha1.SpireServerExposure) error {
+	if exposure == nil {
+		return nil
+	}
+	if exposure.Route == nil && exposure.Service == nil {
+		return fmt.Errorf("at least one of exposure.route or exposure.service must be set")
+	}
+	if exposure.Route != nil && exposure.Route.Host != "" {
+		if errs := validation.IsDNS1123Subdomain(exposure.Route.Host); len(errs) > 0 {
+			return fmt.Errorf("exposure.route.host %q is not a valid DNS name: %s", exposure.Route.Host, strings.Join(errs, ", "))
+		}
+	}
+	for _, name := range exposure.DNSNames {
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return fmt.Errorf("exposure.dnsNames entry %q is not a valid DNS name: %s", name, strings.Join(errs, ", "))
+		}
+	}
+	if svc := exposure.Service; svc != nil {
+		serviceType := svc.Type
+		if serviceType == "" {
+			serviceType = corev1.ServiceTypeLoadBalancer
+		}
+		if serviceType != corev1.ServiceTypeLoadBalancer && serviceType != corev1.ServiceTypeNodePort {
+			return fmt.Errorf("exposure.service.type must be LoadBalancer or NodePort, got %s", svc.Type)
+		}
+		if svc.NodePort != 0 && serviceType != corev1.ServiceTypeNodePort {
+			return fmt.Errorf("exposure.service.nodePort can only be set when type is NodePort")
+		}
+		if len(svc.LoadBalancerSourceRanges) > 0 && serviceType != corev1.ServiceTypeLoadBalancer {
+			return fmt.Errorf("exposure.service.loadBalancerSourceRanges can only be set when type is LoadBalancer")
+		}
+		for _, cidr := range svc.LoadBalancerSourceRanges {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("exposure.service.loadBalancerSourceRanges contains invalid CIDR %q", cidr)
+			}
+		}
+	}
+	return nil
+}
+
+// reconcileExposure creates, updates or removes the Route and Service exposing the SPIRE server
+// gRPC endpoint and reports the resulting external addresses in status
+func (r *SpireServerReconciler) reconcileExposure(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager, createOnlyMode bool) error {
+	exposure := server.Spec.Exposure
+	exposureStatus := &v1alpha1.SpireServerExposureStatus{}
+
+	if exposure != nil && exposure.Route != nil {
+		route, err := r.reconcileGRPCRoute(ctx, server, statusMgr, createOnlyMode)
+		if err != nil {
+			return err
+		}
+		if host := routeHost(route); host != "" {
+			exposureStatus.Addresses = append(exposureStatus.Addresses, net.JoinHostPort(host, strconv.Itoa(routePort)))
+			exposureStatus.DNSNames = append(exposureStatus.DNSNames, host)
+		}
+	} else if err := r.deleteExposureResource(ctx, &routev1.Route{}, spireServerGRPCRouteName, statusMgr); err != nil {
+		return err
+	}
+
+	if exposure != nil && exposure.Service != nil {
+		svc, err := r.reconcileExternalService(ctx, server, statusMgr, createOnlyMode)
+		if err != nil {
+			return err
+		}
+		addresses, nodePort := serviceExternalAddresses(svc)
+		exposureStatus.Addresses = append(exposureStatus.Addresses, addresses...)
+		exposureStatus.NodePort = nodePort
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.Hostname != "" && !slices.Contains(exposureStatus.DNSNames, ingress.Hostname) {
+				exposureStatus.DNSNames = append(exposureStatus.DNSNames, ingress.Hostname)
+			}
+		}
+	} else if err := r.deleteExposureResource(ctx, &corev1.Service{}, spireServerExternalServiceName, statusMgr); err != nil {
+		return err
+	}
+
+	if exposure != nil {
+		for _, name := range exposure.DNSNames {
+			if !slices.Contains(exposureStatus.DNSNames, name) {
+				exposureStatus.DNSNames = append(exposureStatus.DNSNames, name)
+			}
+		}
+	}
+
+	if exposure == nil {
+		statusMgr.RemoveCondition(ExposureAvailable)
+		exposureStatus = nil
+	} else if len(exposureStatus.Addresses) == 0 && exposureStatus.NodePort == 0 {
+		statusMgr.AddCondition(ExposureAvailable, "ExternalAddressPending",
+			"Waiting for the Route or load balancer to be assigned an external address",
+			metav1.ConditionUnknown)
+	} else {
+		message := fmt.Sprintf("SPIRE server gRPC endpoint exposed at %s", strings.Join(exposureStatus.Addresses, ", "))
+		if exposureStatus.NodePort != 0 {
+			message = fmt.Sprintf("SPIRE server gRPC endpoint exposed on node port %d", exposureStatus.NodePort)
+			if len(exposureStatus.Addresses) > 0 {
+				message += fmt.Sprintf(" and at %s", strings.Join(exposureStatus.Addresses, ", "))
+			}
+		}
+		statusMgr.AddCondition(ExposureAvailable, v1alpha1.ReasonReady, message, metav1.ConditionTrue)
+	}
+
+	if !equality.Semantic.DeepEqual(server.Status.Exposure, exposureStatus) {
+		server.Status.Exposure = exposureStatus
+		statusMgr.RequestStatusUpdate()
+	}
+	return nil
+}
+
+// reconcileGRPCRoute reconciles the passthrough Route and returns the Route as stored in the cluster
+func (r *SpireServerReconciler) reconcileGRPCRoute(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager, createOnlyMode bool) (*routev1.Route, error) {
+	desired := generateGRPCRoute(server)
+	if err := controllerutil.SetControllerReference(server, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on gRPC route")
+		statusMgr.AddCondition(ExposureAvailable, "GRPCRouteCreationFailed",
+			fmt.Sprintf("Failed to set owner reference on Route: %v", err),
+			metav1.ConditionFalse)
+		return nil, err
+	}
+
+	existing := &routev1.Route{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get gRPC route")
+			statusMgr.AddCondition(ExposureAvailable, "GRPCRouteRetrievalFailed",
+				fmt.Sprintf("Failed to get Route: %v", err),
+				metav1.ConditionFalse)
+			return nil, err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, ExposureAvailable); conflictErr != nil {
+				return nil, conflictErr
+			}
+			r.log.Error(err, "failed to create gRPC route")
+			statusMgr.AddCondition(ExposureAvailable, "GRPCRouteCreationFailed",
+				fmt.Sprintf("Failed to create Route: %v", err),
+				metav1.ConditionFalse)
+			return nil, err
+		}
+		r.log.Info("Created gRPC route", "name", desired.Name, "namespace", desired.Namespace)
+		return desired, nil
+	}
+
+	if !routeNeedsUpdate(existing, desired) {
+		return existing, nil
+	}
+	if createOnlyMode {
+		r.log.V(1).Info("gRPC route exists, skipping update due to create-only mode", "name", desired.Name)
+		return existing, nil
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	desired.Status = existing.Status
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		r.log.Error(err, "failed to update gRPC route")
+		statusMgr.AddCondition(ExposureAvailable, "GRPCRouteUpdateFailed",
+			fmt.Sprintf("Failed to update Route: %v", err),
+			metav1.ConditionFalse)
+		return nil, err
+	}
+	r.log.Info("Updated gRPC route", "name", desired.Name, "namespace", desired.Namespace)
+	return desired, nil
+}
+
+// reconcileExternalService reconciles the external Service and returns the Service as stored in the cluster
+func (r *SpireServerReconciler) reconcileExternalService(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager, createOnlyMode bool) (*corev1.Service, error) {
+	desired := generateExternalService(server)
+	if err := controllerutil.SetControllerReference(server, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on external service")
+		statusMgr.AddCondition(ExposureAvailable, "ExternalServiceCreationFailed",
+			fmt.Sprintf("Failed to set owner reference on Service: %v", err),
+			metav1.ConditionFalse)
+		return nil, err
+	}
+
+	existing := &corev1.Service{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get external service")
+			statusMgr.AddCondition(ExposureAvailable, "ExternalServiceRetrievalFailed",
+				fmt.Sprintf("Failed to get Service: %v", err),
+				metav1.ConditionFalse)
+			return nil, err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, ExposureAvailable); conflictErr != nil {
+				return nil, conflictErr
+			}
+			r.log.Error(err, "failed to create external service")
+			statusMgr.AddCondition(ExposureAvailable, "ExternalServiceCreationFailed",
+				fmt.Sprintf("Failed to create Service: %v", err),
+				metav1.ConditionFalse)
+			return nil, err
+		}
+		r.log.Info("Created Service", "name", desired.Name, "namespace", desired.Namespace)
+		return desired, nil
+	}
+
+	if createOnlyMode {
+		r.log.V(1).Info("Service exists, skipping update due to create-only mode", "name", desired.Name)
+		return existing, nil
+	}
+
+	// Preserve Kubernetes-managed fields from existing resource BEFORE comparison
+	desired.ResourceVersion = existing.ResourceVersion
+	desired.Spec.ClusterIP = existing.Spec.ClusterIP
+	desired.Spec.ClusterIPs = existing.Spec.ClusterIPs
+	desired.Spec.IPFamilies = existing.Spec.IPFamilies
+	desired.Spec.IPFamilyPolicy = existing.Spec.IPFamilyPolicy
+	desired.Spec.InternalTrafficPolicy = existing.Spec.InternalTrafficPolicy
+	desired.Spec.SessionAffinity = existing.Spec.SessionAffinity
+	desired.Spec.ExternalTrafficPolicy = existing.Spec.ExternalTrafficPolicy
+	desired.Spec.AllocateLoadBalancerNodePorts = existing.Spec.AllocateLoadBalancerNodePorts
+	if existing.Spec.HealthCheckNodePort != 0 {
+		desired.Spec.HealthCheckNodePort = existing.Spec.HealthCheckNodePort
+	}
+	// Keep the allocated node port unless one is pinned
+	if desired.Spec.Ports[0].NodePort == 0 && len(existing.Spec.Ports) > 0 && existing.Spec.Type == desired.Spec.Type {
+		desired.Spec.Ports[0].NodePort = existing.Spec.Ports[0].NodePort
+	}
+	desired.Status = existing.Status
+
+	needsUpdate := utils.ResourceNeedsUpdate(existing, desired) ||
+		!equality.Semantic.DeepEqual(existing.Spec.LoadBalancerSourceRanges, desired.Spec.LoadBalancerSourceRanges)
+	if !needsUpdate {
+		r.log.V(1).Info("Service is up to date", "name", desired.Name)
+		return existing, nil
+	}
+
+	// Keep annotations added by cloud providers alongside the configured ones
+	annotations := make(map[string]string, len(existing.Annotations)+len(desired.Annotations))
+	for k, v := range existing.Annotations {
+		annotations[k] = v
+	}
+	for k, v := range desired.Annotations {
+		annotations[k] = v
+	}
+	desired.Annotations = annotations
+
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		r.log.Error(err, "failed to update external service")
+		statusMgr.AddCondition(ExposureAvailable, "ExternalServiceUpdateFailed",
+			fmt.Sprintf("Failed to update Service: %v", err),
+			metav1.ConditionFalse)
+		return nil, err
+	}
+	r.log.Info("Updated Service", "name", desired.Name, "namespace", desired.Namespace)
+	return desired, nil
+}
+
+// deleteExposureResource removes an exposure Route or Service left over from a previous configuration
+func (r *SpireServerReconciler) deleteExposureResource(ctx context.Context, obj client.Object, name string, statusMgr *status.Manager) error {
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: name, Namespace: utils.GetOperatorNamespace()}, obj)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	if err == nil {
+		err = r.ctrlClient.Delete(ctx, obj)
+	}
+	if err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "failed to delete exposure resource", "name", name)
+		statusMgr.AddCondition(ExposureAvailable, "ExposureCleanupFailed",
+			fmt.Sprintf("Failed to delete %s: %v", name, err),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Deleted exposure resource", "name", name)
+	return nil
+}
+
+// routeHost returns the admitted host of the Route, falling back to the requested host
+func routeHost(route *routev1.Route) string {
+	for _, ingress := range route.Status.Ingress {
+		if ingress.Host != "" {
+			return ingress.Host
+		}
+	}
+	return route.Spec.Host
+}
+
+// serviceExternalAddresses returns the load balancer addresses and the node port of the external Service
+func serviceExternalAddresses(svc *corev1.Service) ([]string, int32) {
+	if len(svc.Spec.Ports) == 0 {
+		return nil, 0
+	}
+	port := svc.Spec.Ports[0]
+	if svc.Spec.Type == corev1.ServiceTypeNodePort {
+		return nil, port.NodePort
+	}
+
+	var addresses []string
+	for _, ingress := range svc.Status.LoadBalancer.Ingress {
+		host := ingress.Hostname
+		if host == "" {
+			host = ingress.IP
+		}
+		if host != "" {
+			addresses = append(addresses, net.JoinHostPort(host, strconv.Itoa(int(port.Port))))
+		}
+	}
+	return addresses, 0
+}
@@ -0,0 +1,285 @@
+package spire_server
+
+import (
+	"context"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+)
+
+func createExposureTestServer(exposure *v1alpha1.SpireServerExposure) *v1alpha1.SpireServer {
+	return &v1alpha1.SpireServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+			UID:  "test-uid",
+		},
+		Spec: v1alpha1.SpireServerSpec{
+			Exposure: exposure,
+		},
+	}
+}
+
+func TestGenerateGRPCRoute(t *testing.T) {
+	server := createExposureTestServer(&v1alpha1.SpireServerExposure{
+		Route: &v1alpha1.SpireServerRouteExposure{Host: "spire.apps.example.com"},
+	})
+
+	route := generateGRPCRoute(server)
+
+	assert.Equal(t, "spire-server-grpc", route.Name)
+	assert.Equal(t, "spire.apps.example.com", route.Spec.Host)
+	assert.Equal(t, "spire-server", route.Spec.To.Name)
+	assert.Equal(t, "grpc", route.Spec.Port.TargetPort.StrVal)
+	require.NotNil(t, route.Spec.TLS)
+	assert.Equal(t, routev1.TLSTerminationPassthrough, route.Spec.TLS.Termination)
+}
+
+func TestGenerateExternalService(t *testing.T) {
+	t.Run("defaults to LoadBalancer on port 443", func(t *testing.T) {
+		server := createExposureTestServer(&v1alpha1.SpireServerExposure{
+			Service: &v1alpha1.SpireServerServiceExposure{
+				LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+				Annotations:              map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+			},
+		})
+
+		svc := generateExternalService(server)
+
+		assert.Equal(t, "spire-server-external", svc.Name)
+		assert.Equal(t, corev1.ServiceTypeLoadBalancer, svc.Spec.Type)
+		require.Len(t, svc.Spec.Ports, 1)
+		assert.Equal(t, int32(443), svc.Spec.Ports[0].Port)
+		assert.Equal(t, "grpc", svc.Spec.Ports[0].TargetPort.StrVal)
+		assert.Equal(t, []string{"10.0.0.0/8"}, svc.Spec.LoadBalancerSourceRanges)
+		assert.Equal(t, "nlb", svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-type"])
+		assert.Equal(t, "spire-server", svc.Spec.Selector["app.kubernetes.io/name"])
+	})
+
+	t.Run("NodePort with pinned port", func(t *testing.T) {
+		server := createExposureTestServer(&v1alpha1.SpireServerExposure{
+			Service: &v1alpha1.SpireServerServiceExposure{Type: corev1.ServiceTypeNodePort, Port: 8081, NodePort: 30081},
+		})
+
+		svc := generateExternalService(server)
+
+		assert.Equal(t, corev1.ServiceTypeNodePort, svc.Spec.Type)
+		assert.Equal(t, int32(8081), svc.Spec.Ports[0].Port)
+		assert.Equal(t, int32(30081), svc.Spec.Ports[0].NodePort)
+		assert.Empty(t, svc.Spec.LoadBalancerSourceRanges)
+	})
+}
+
+func TestValidateExposure(t *testing.T) {
+	tests := []struct {
+		name     string
+		exposure *v1alpha1.SpireServerExposure
+		wantErr  string
+	}{
+		{name: "nil exposure"},
+		{
+			name:     "route and load balancer",
+			exposure: &v1alpha1.SpireServerExposure{Route: &v1alpha1.SpireServerRouteExposure{}, Service: &v1alpha1.SpireServerServiceExposure{}},
+		},
+		{
+			name:     "empty exposure",
+			exposure: &v1alpha1.SpireServerExposure{},
+			wantErr:  "at least one",
+		},
+		{
+			name:     "invalid route host",
+			exposure: &v1alpha1.SpireServerExposure{Route: &v1alpha1.SpireServerRouteExposure{Host: "Spire_Server"}},
+			wantErr:  "exposure.route.host",
+		},
+		{
+			name:     "invalid DNS name",
+			exposure: &v1alpha1.SpireServerExposure{Service: &v1alpha1.SpireServerServiceExposure{}, DNSNames: []string{"spire..example.com"}},
+			wantErr:  "exposure.dnsNames",
+		},
+		{
+			name: "node port with load balancer",
+			exposure: &v1alpha1.SpireServerExposure{
+				Service: &v1alpha1.SpireServerServiceExposure{Type: corev1.ServiceTypeLoadBalancer, NodePort: 30081},
+			},
+			wantErr: "nodePort",
+		},
+		{
+			name: "source ranges with node port",
+			exposure: &v1alpha1.SpireServerExposure{
+				Service: &v1alpha1.SpireServerServiceExposure{Type: corev1.ServiceTypeNodePort, LoadBalancerSourceRanges: []string{"10.0.0.0/8"}},
+			},
+			wantErr: "loadBalancerSourceRanges",
+		},
+		{
+			name: "invalid CIDR",
+			exposure: &v1alpha1.SpireServerExposure{
+				Service: &v1alpha1.SpireServerServiceExposure{LoadBalancerSourceRanges: []string{"10.0.0.0"}},
+			},
+			wantErr: "invalid CIDR",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateExposure(tt.exposure)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestExposureCommonName(t *testing.T) {
+	assert.Empty(t, exposureCommonName(nil))
+	assert.Empty(t, exposureCommonName(&v1alpha1.SpireServerExposure{Route: &v1alpha1.SpireServerRouteExposure{}}))
+	assert.Equal(t, "spire.example.com", exposureCommonName(&v1alpha1.SpireServerExposure{
+		Route:    &v1alpha1.SpireServerRouteExposure{},
+		DNSNames: []string{"spire.example.com"},
+	}))
+	assert.Equal(t, "spire.apps.example.com", exposureCommonName(&v1alpha1.SpireServerExposure{
+		Route:    &v1alpha1.SpireServerRouteExposure{Host: "spire.apps.example.com"},
+		DNSNames: []string{"spire.example.com"},
+	}))
+
+	t.Run("names the CA unless a common name is configured", func(t *testing.T) {
+		spec := &v1alpha1.SpireServerSpec{
+			Exposure: &v1alpha1.SpireServerExposure{Route: &v1alpha1.SpireServerRouteExposure{Host: "spire.apps.example.com"}},
+		}
+		ztwim := &v1alpha1.ZeroTrustWorkloadIdentityManager{Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{TrustDomain: "example.org"}}
+		caSubject := func() map[string]interface{} {
+			server := generateServerConfMap(spec, ztwim)["server"].(map[string]interface{})
+			return server["ca_subject"].([]map[string]interface{})[0]
+		}
+
+		assert.Equal(t, "spire.apps.example.com", caSubject()["common_name"])
+		spec.CASubject.CommonName = "Example CA"
+		assert.Equal(t, "Example CA", caSubject()["common_name"])
+	})
+}
+
+func TestServiceExternalAddresses(t *testing.T) {
+	lb := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Port: 443, NodePort: 31000}},
+		},
+		Status: corev1.ServiceStatus{
+			LoadBalancer: corev1.LoadBalancerStatus{
+				Ingress: []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}, {Hostname: "lb.example.com"}},
+			},
+		},
+	}
+	addresses, nodePort := serviceExternalAddresses(lb)
+	assert.Equal(t, []string{"203.0.113.10:443", "lb.example.com:443"}, addresses)
+	assert.Zero(t, nodePort)
+
+	np := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{{Port: 443, NodePort: 30081}},
+		},
+	}
+	addresses, nodePort = serviceExternalAddresses(np)
+	assert.Empty(t, addresses)
+	assert.Equal(t, int32(30081), nodePort)
+}
+
+func TestReconcileExposure(t *testing.T) {
+	t.Run("creates route and reports its admitted host", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.CreateStub = func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+			if route, ok := obj.(*routev1.Route); ok {
+				route.Status.Ingress = []routev1.RouteIngress{{Host: "spire-server-grpc.apps.example.com"}}
+			}
+			return nil
+		}
+
+		server := createExposureTestServer(&v1alpha1.SpireServerExposure{Route: &v1alpha1.SpireServerRouteExposure{}})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileExposure(context.Background(), server, statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		assert.IsType(t, &routev1.Route{}, created)
+		require.NotNil(t, server.Status.Exposure)
+		assert.Equal(t, []string{"spire-server-grpc.apps.example.com:443"}, server.Status.Exposure.Addresses)
+		assert.Equal(t, []string{"spire-server-grpc.apps.example.com"}, server.Status.Exposure.DNSNames)
+		assert.Zero(t, fakeClient.DeleteCallCount())
+	})
+
+	t.Run("reports the load balancer hostname and the additional DNS names", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.CreateStub = func(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+			if svc, ok := obj.(*corev1.Service); ok {
+				svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}, {Hostname: "lb.example.com"}}
+			}
+			return nil
+		}
+
+		server := createExposureTestServer(&v1alpha1.SpireServerExposure{
+			Service:  &v1alpha1.SpireServerServiceExposure{},
+			DNSNames: []string{"spire.example.com", "lb.example.com"},
+		})
+
+		require.NoError(t, reconciler.reconcileExposure(context.Background(), server, status.NewManager(fakeClient), false))
+
+		require.NotNil(t, server.Status.Exposure)
+		assert.Equal(t, []string{"lb.example.com", "spire.example.com"}, server.Status.Exposure.DNSNames)
+	})
+
+	t.Run("pending load balancer reports unknown availability", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		server := createExposureTestServer(&v1alpha1.SpireServerExposure{Service: &v1alpha1.SpireServerServiceExposure{}})
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileExposure(context.Background(), server, statusMgr, false)
+		require.NoError(t, err)
+
+		require.NoError(t, statusMgr.ApplyStatus(context.Background(), server, func() *v1alpha1.ConditionalStatus {
+			return &server.Status.ConditionalStatus
+		}))
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, ExposureAvailable)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionUnknown, condition.Status)
+		assert.Equal(t, "ExternalAddressPending", condition.Reason)
+	})
+
+	t.Run("removing exposure deletes managed resources", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(nil)
+
+		server := createExposureTestServer(nil)
+		server.Status.Exposure = &v1alpha1.SpireServerExposureStatus{Addresses: []string{"old.example.com:443"}}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileExposure(context.Background(), server, statusMgr, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, fakeClient.DeleteCallCount())
+		assert.Nil(t, server.Status.Exposure)
+	})
+}
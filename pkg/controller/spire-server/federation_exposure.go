@@ -0,0 +1,293 @@
+package spire_server
+
+import (
+	"context"
+	"fmt"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	federationExposureName        = "spire-server-federation"
+	federationServicePortName     = "federation"
+	federationServicePort         = 8443
+	federationExposureServiceName = "spire-server"
+)
+
+// isFederationPassthrough returns true when the federation endpoint terminates TLS itself,
+// which is the case for the https_spiffe profile and for ACME certificates
+func isFederationPassthrough(server *v1alpha1.SpireServer) bool {
+	httpsWeb := server.Spec.Federation.BundleEndpoint.HttpsWeb
+	return server.Spec.Federation.BundleEndpoint.Profile == v1alpha1.HttpsSpiffeProfile ||
+		(httpsWeb != nil && httpsWeb.Acme != nil)
+}
+
+// generateFederationExposedEndpoint describes the federation endpoint published by an Ingress or Gateway API route
+func generateFederationExposedEndpoint(server *v1alpha1.SpireServer, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager) utils.ExposedEndpoint {
+	endpoint := utils.ExposedEndpoint{
+		Name:            federationExposureName,
+		Host:            "federation." + ztwim.Spec.TrustDomain,
+		Labels:          utils.SpireServerLabels(server.Spec.Labels),
+		ServiceName:     federationExposureServiceName,
+		ServicePortName: federationServicePortName,
+		ServicePort:     federationServicePort,
+		Passthrough:     isFederationPassthrough(server),
+	}
+	if httpsWeb := server.Spec.Federation.BundleEndpoint.HttpsWeb; !endpoint.Passthrough && httpsWeb != nil && httpsWeb.ServingCert != nil {
+		endpoint.TLSSecretName = httpsWeb.ServingCert.ExternalSecretRef
+	}
+	return endpoint
+}
+
+// reconcileFederationIngress reconciles the Ingress exposing the federation endpoint and returns its host
+func (r *SpireServerReconciler) reconcileFederationIngress(ctx context.Context, server *v1alpha1.SpireServer, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, statusMgr *status.Manager, createOnlyMode bool) (string, error) {
+	endpoint := generateFederationExposedEndpoint(server, ztwim)
+	desired := utils.GenerateExposureIngress(endpoint, server.Spec.Federation.Exposure)
+	if err := controllerutil.SetControllerReference(server, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on federation ingress")
+		statusMgr.AddCondition(RouteAvailable, "FederationIngressCreationFailed",
+			fmt.Sprintf("Failed to set owner reference on Ingress: %v", err),
+			metav1.ConditionFalse)
+		return "", err
+	}
+
+	existing := &networkingv1.Ingress{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "Failed to get existing federation ingress")
+			statusMgr.AddCondition(RouteAvailable, "FederationIngressRetrievalFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, RouteAvailable); conflictErr != nil {
+				return "", conflictErr
+			}
+			r.log.Error(err, "Failed to create federation ingress")
+			statusMgr.AddCondition(RouteAvailable, "FederationIngressCreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		statusMgr.AddCondition(RouteAvailable, "FederationIngressCreated",
+			"Federation ingress created",
+			metav1.ConditionTrue)
+		r.log.Info("Created federation ingress", "Namespace", desired.Namespace, "Name", desired.Name)
+		return endpoint.Host, nil
+	}
+
+	if !utils.IngressNeedsUpdate(existing, desired) {
+		r.markFederationExposureReady(server, statusMgr, "FederationIngressReady", "Federation ingress is ready")
+		return utils.IngressHost(existing), nil
+	}
+	if createOnlyMode {
+		r.log.Info("Skipping federation ingress update due to create-only mode")
+		return utils.IngressHost(existing), nil
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	desired.Status = existing.Status
+	// Keep annotations added by the ingress controller alongside the configured ones
+	for k, v := range existing.Annotations {
+		if _, ok := desired.Annotations[k]; !ok {
+			desired.Annotations[k] = v
+		}
+	}
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		statusMgr.AddCondition(RouteAvailable, "FederationIngressUpdateFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	statusMgr.AddCondition(RouteAvailable, "FederationIngressUpdated",
+		"Federation ingress updated",
+		metav1.ConditionTrue)
+	r.log.Info("Updated federation ingress", "Namespace", desired.Namespace, "Name", desired.Name)
+	return endpoint.Host, nil
+}
+
+// reconcileFederationGatewayRoute reconciles the Gateway API route exposing the federation endpoint and
+// returns its host. Passthrough endpoints use a TLSRoute; servingCert endpoints use an HTTPRoute and the
+// Gateway must re-encrypt to the SPIRE server.
+func (r *SpireServerReconciler) reconcileFederationGatewayRoute(ctx context.Context, server *v1alpha1.SpireServer, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, statusMgr *status.Manager, createOnlyMode bool) (string, error) {
+	endpoint := generateFederationExposedEndpoint(server, ztwim)
+	desired := utils.GenerateExposureGatewayRoute(endpoint, server.Spec.Federation.Exposure)
+	kind := desired.GetKind()
+	if err := controllerutil.SetControllerReference(server, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on federation gateway route", "Kind", kind)
+		statusMgr.AddCondition(RouteAvailable, "Federation"+kind+"CreationFailed",
+			fmt.Sprintf("Failed to set owner reference on %s: %v", kind, err),
+			metav1.ConditionFalse)
+		return "", err
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(desired.GroupVersionKind())
+	err := utils.GetGatewayObject(ctx, r.ctrlClient, r.gatewayAPIKinds, types.NamespacedName{Name: desired.GetName(), Namespace: desired.GetNamespace()}, existing)
+	if err != nil {
+		if apimeta.IsNoMatchError(err) {
+			err = fmt.Errorf("the Gateway API %s kind is not installed in the cluster", kind)
+			r.log.Error(err, "Failed to get existing federation gateway route")
+			statusMgr.AddCondition(RouteAvailable, "GatewayAPINotInstalled",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "Failed to get existing federation gateway route", "Kind", kind)
+			statusMgr.AddCondition(RouteAvailable, "Federation"+kind+"RetrievalFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, RouteAvailable); conflictErr != nil {
+				return "", conflictErr
+			}
+			r.log.Error(err, "Failed to create federation gateway route", "Kind", kind)
+			statusMgr.AddCondition(RouteAvailable, "Federation"+kind+"CreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		statusMgr.AddCondition(RouteAvailable, "Federation"+kind+"Created",
+			fmt.Sprintf("Federation %s created", kind),
+			metav1.ConditionTrue)
+		r.log.Info("Created federation gateway route", "Kind", kind, "Namespace", desired.GetNamespace(), "Name", desired.GetName())
+		return endpoint.Host, nil
+	}
+
+	if err := utils.CheckResourceConflict(existing); err != nil {
+		r.log.Error(err, "resource conflict detected")
+		statusMgr.AddCondition(RouteAvailable, "ResourceConflict",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	if !utils.GatewayObjectNeedsUpdate(existing, desired) {
+		r.markFederationExposureReady(server, statusMgr, "Federation"+kind+"Ready", fmt.Sprintf("Federation %s is ready", kind))
+		return utils.GatewayRouteHost(existing), nil
+	}
+	if createOnlyMode {
+		r.log.Info("Skipping federation gateway route update due to create-only mode", "Kind", kind)
+		return utils.GatewayRouteHost(existing), nil
+	}
+
+	desired.SetResourceVersion(existing.GetResourceVersion())
+	if existingStatus, ok := existing.Object["status"]; ok {
+		desired.Object["status"] = existingStatus
+	}
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		statusMgr.AddCondition(RouteAvailable, "Federation"+kind+"UpdateFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return "", err
+	}
+	statusMgr.AddCondition(RouteAvailable, "Federation"+kind+"Updated",
+		fmt.Sprintf("Federation %s updated", kind),
+		metav1.ConditionTrue)
+	r.log.Info("Updated federation gateway route", "Kind", kind, "Namespace", desired.GetNamespace(), "Name", desired.GetName())
+	return endpoint.Host, nil
+}
+
+// reconcileFederationBackendTLS reconciles the BackendTLSPolicy of the federation HTTPRoute, without which
+// the Gateway would not connect to the SPIRE server with TLS
+func (r *SpireServerReconciler) reconcileFederationBackendTLS(ctx context.Context, server *v1alpha1.SpireServer, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, statusMgr *status.Manager, createOnlyMode bool) error {
+	endpoint := generateFederationExposedEndpoint(server, ztwim)
+	if err := utils.ReconcileExposureBackendTLS(ctx, r.ctrlClient, r.scheme, server, endpoint, r.gatewayAPIKinds, createOnlyMode); err != nil {
+		if apimeta.IsNoMatchError(err) {
+			err = fmt.Errorf("the Gateway API BackendTLSPolicy kind is not installed in the cluster, the Gateway cannot re-encrypt to the SPIRE server")
+		}
+		r.log.Error(err, "Failed to reconcile the BackendTLSPolicy of the federation HTTPRoute")
+		statusMgr.AddCondition(RouteAvailable, "FederationBackendTLSPolicyFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+	return nil
+}
+
+// deleteStaleFederationExposure removes the Route, Ingress, HTTPRoute or TLSRoute left over from a
+// previous federation exposure type
+func (r *SpireServerReconciler) deleteStaleFederationExposure(ctx context.Context, exposureType v1alpha1.EndpointExposureType, statusMgr *status.Manager) error {
+	if exposureType != v1alpha1.RouteExposureType {
+		if err := r.deleteFederationExposureObject(ctx, &routev1.Route{}, "Route", federationExposureName, statusMgr); err != nil {
+			return err
+		}
+	}
+	if exposureType != v1alpha1.IngressExposureType {
+		if err := r.deleteFederationExposureObject(ctx, &networkingv1.Ingress{}, "Ingress", federationExposureName, statusMgr); err != nil {
+			return err
+		}
+	}
+	for _, gvk := range []schema.GroupVersionKind{utils.HTTPRouteGVK, utils.TLSRouteGVK} {
+		if string(exposureType) == gvk.Kind {
+			continue
+		}
+		route := &unstructured.Unstructured{}
+		route.SetGroupVersionKind(gvk)
+		if err := r.deleteFederationExposureObject(ctx, route, gvk.Kind, federationExposureName, statusMgr); err != nil {
+			return err
+		}
+	}
+	if exposureType != v1alpha1.HTTPRouteExposureType {
+		for _, gvk := range utils.BackendTLSPolicyGVKs {
+			policy := &unstructured.Unstructured{}
+			policy.SetGroupVersionKind(gvk)
+			if err := r.deleteFederationExposureObject(ctx, policy, gvk.Kind, federationExposureName, statusMgr); err != nil {
+				return err
+			}
+		}
+		if err := r.deleteFederationExposureObject(ctx, &corev1.ConfigMap{}, "ConfigMap", utils.BackendCAConfigMapName(federationExposureName), statusMgr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteFederationExposureObject deletes the operator-managed federation exposure object of the given kind and
+// name, if any. Gateway API kinds are treated as absent when their CRD is not installed.
+func (r *SpireServerReconciler) deleteFederationExposureObject(ctx context.Context, obj client.Object, kind, name string, statusMgr *status.Manager) error {
+	key := types.NamespacedName{Name: name, Namespace: utils.GetOperatorNamespace()}
+	var err error
+	if gatewayObject, ok := obj.(*unstructured.Unstructured); ok {
+		err = utils.GetGatewayObject(ctx, r.ctrlClient, r.gatewayAPIKinds, key, gatewayObject)
+	} else {
+		err = r.ctrlClient.Get(ctx, key, obj)
+	}
+	if kerrors.IsNotFound(err) || apimeta.IsNoMatchError(err) {
+		return nil
+	}
+	if err == nil {
+		if utils.CheckResourceConflict(obj) != nil {
+			// Not created by the operator, leave it alone
+			return nil
+		}
+		err = r.ctrlClient.Delete(ctx, obj)
+	}
+	if err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "Failed to delete stale federation exposure", "Kind", kind, "Name", name)
+		statusMgr.AddCondition(RouteAvailable, "FederationExposureCleanupFailed",
+			fmt.Sprintf("Failed to delete previous federation %s: %v", kind, err),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Deleted stale federation exposure", "Kind", kind, "Name", name)
+	return nil
+}
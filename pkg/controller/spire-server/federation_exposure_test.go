@@ -0,0 +1,159 @@
+package spire_server
+
+import (
+	"context"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func servingCertFederation(server *v1alpha1.SpireServer) *v1alpha1.SpireServer {
+	server.Spec.Federation.BundleEndpoint.Profile = v1alpha1.HttpsWebProfile
+	server.Spec.Federation.BundleEndpoint.HttpsWeb = &v1alpha1.HttpsWebConfig{
+		ServingCert: &v1alpha1.ServingCertConfig{ExternalSecretRef: "federation-tls"},
+	}
+	return server
+}
+
+func TestIsFederationPassthrough(t *testing.T) {
+	server := createRouteTestServer()
+	assert.True(t, isFederationPassthrough(server))
+
+	server.Spec.Federation.BundleEndpoint.Profile = v1alpha1.HttpsWebProfile
+	server.Spec.Federation.BundleEndpoint.HttpsWeb = &v1alpha1.HttpsWebConfig{
+		Acme: &v1alpha1.AcmeConfig{DomainName: "federation.example.org"},
+	}
+	assert.True(t, isFederationPassthrough(server))
+
+	assert.False(t, isFederationPassthrough(servingCertFederation(createRouteTestServer())))
+}
+
+func TestReconcileRoute_FederationExposure(t *testing.T) {
+	t.Run("https_spiffe creates TLSRoute and reports its host", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+
+		server := createRouteTestServer()
+		server.Spec.Federation.Exposure = &v1alpha1.EndpointExposure{
+			Type:       v1alpha1.TLSRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public", SectionName: "tls-passthrough"}},
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), server, statusMgr, createRouteTestZTWIM(), false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		route, ok := created.(*unstructured.Unstructured)
+		require.True(t, ok, "expected an unstructured route, got %T", created)
+		assert.Equal(t, utils.TLSRouteGVK, route.GroupVersionKind())
+		assert.Equal(t, "spire-server-federation", route.GetName())
+		assert.Equal(t, "federation.example.org", utils.GatewayRouteHost(route))
+		require.Len(t, route.GetOwnerReferences(), 1)
+		assert.Equal(t, "federation.example.org", server.Status.FederationHostname)
+	})
+
+	t.Run("servingCert creates reencrypt ingress", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{}, "not-found"))
+		fakeClient.UncachedGetReturns(&apimeta.NoKindMatchError{GroupKind: utils.HTTPRouteGVK.GroupKind()})
+
+		server := servingCertFederation(createRouteTestServer())
+		server.Spec.Federation.Exposure = &v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), server, statusMgr, createRouteTestZTWIM(), false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		ingress, ok := created.(*networkingv1.Ingress)
+		require.True(t, ok, "expected an Ingress, got %T", created)
+		assert.Equal(t, "reencrypt", ingress.Annotations[utils.RouteTerminationAnnotation])
+		require.Len(t, ingress.Spec.TLS, 1)
+		assert.Equal(t, "federation-tls", ingress.Spec.TLS[0].SecretName)
+		assert.Equal(t, networkingv1.ServiceBackendPort{Name: "federation"}, ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Port)
+		assert.Equal(t, "federation.example.org", server.Status.FederationHostname)
+	})
+
+	t.Run("rejects HTTPRoute for the passthrough endpoint", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+
+		server := createRouteTestServer()
+		server.Spec.Federation.Exposure = &v1alpha1.EndpointExposure{
+			Type:       v1alpha1.HTTPRouteExposureType,
+			ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+		}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), server, statusMgr, createRouteTestZTWIM(), false)
+
+		require.NoError(t, err)
+		assert.Zero(t, fakeClient.CreateCallCount())
+		require.NoError(t, statusMgr.ApplyStatus(context.Background(), server, func() *v1alpha1.ConditionalStatus {
+			return &server.Status.ConditionalStatus
+		}))
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, RouteAvailable)
+		require.NotNil(t, condition)
+		assert.Equal(t, "InvalidFederationExposure", condition.Reason)
+	})
+
+	t.Run("switching back to route deletes the managed ingress and TLSRoute", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newRouteTestReconciler(fakeClient)
+		managed := func(obj client.Object, key client.ObjectKey) {
+			obj.SetName(key.Name)
+			obj.SetNamespace(key.Namespace)
+			obj.SetLabels(utils.SpireServerLabels(nil))
+		}
+		fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			if _, ok := obj.(*networkingv1.Ingress); ok {
+				managed(obj, key)
+				return nil
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		}
+		fakeClient.UncachedGetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+			if obj.GetObjectKind().GroupVersionKind() == utils.TLSRouteGVK {
+				managed(obj, key)
+				return nil
+			}
+			return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+		}
+
+		server := createRouteTestServer()
+		server.Status.FederationHostname = "federation.example.org"
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileRoute(context.Background(), server, statusMgr, createRouteTestZTWIM(), false)
+
+		require.NoError(t, err)
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		assert.IsType(t, &routev1.Route{}, created)
+		require.Equal(t, 2, fakeClient.DeleteCallCount())
+		_, deletedIngress, _ := fakeClient.DeleteArgsForCall(0)
+		assert.IsType(t, &networkingv1.Ingress{}, deletedIngress)
+		_, deletedRoute, _ := fakeClient.DeleteArgsForCall(1)
+		assert.Equal(t, utils.TLSRouteGVK, deletedRoute.GetObjectKind().GroupVersionKind())
+		assert.Equal(t, "federation.example.org", server.Status.FederationHostname)
+	})
+}
@@ -83,92 +83,148 @@ func generateFederationRoute(server *v1alpha1.SpireServer, ztwim *v1alpha1.ZeroT
 	return route
 }
 
-// checkFederationRouteConflict returns true if desired & current routes have conflicts
-func checkFederationRouteConflict(current, desired *routev1.Route) bool {
+// routeNeedsUpdate returns true if the spec or labels of the current Route differ from the desired ones
+func routeNeedsUpdate(current, desired *routev1.Route) bool {
 	return !equality.Semantic.DeepEqual(current.Spec, desired.Spec) || !equality.Semantic.DeepEqual(current.Labels, desired.Labels)
 }
 
-// reconcileRoute creates/updates route when managedRoute is enabled else sets status to disabled
+// reconcileRoute creates/updates the object exposing the federation endpoint, an OpenShift Route by default,
+// when managedRoute is enabled else sets status to disabled
 func (r *SpireServerReconciler) reconcileRoute(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, createOnlyMode bool) error {
 	// Check if federation is configured
 	if server.Spec.Federation == nil {
 		// No federation configured - don't manage route, don't set status
+		r.setFederationHostname(server, "", statusMgr)
 		return nil
 	}
 
-	if utils.StringToBool(server.Spec.Federation.ManagedRoute) {
-		// Create Route for federation endpoint
-		route := generateFederationRoute(server, ztwim)
-
-		var existingRoute routev1.Route
-		err := r.ctrlClient.Get(ctx, types.NamespacedName{
-			Name:      route.Name,
-			Namespace: route.Namespace,
-		}, &existingRoute)
-		if err != nil {
-			if kerrors.IsNotFound(err) {
-				if err = r.ctrlClient.Create(ctx, route); err != nil {
-					if conflictErr := utils.HandleCreateConflict(err, route, r.log, statusMgr, RouteAvailable); conflictErr != nil {
-						return conflictErr
-					}
-					r.log.Error(err, "Failed to create federation route")
-					statusMgr.AddCondition(RouteAvailable, "FederationRouteCreationFailed",
-						err.Error(),
-						metav1.ConditionFalse)
-					return err
-				}
+	if !utils.StringToBool(server.Spec.Federation.ManagedRoute) {
+		// Only update status to disabled
+		statusMgr.AddCondition(RouteAvailable, "FederationRouteDisabled",
+			"Federation managed route disabled",
+			metav1.ConditionFalse)
+		r.setFederationHostname(server, "", statusMgr)
+		return nil
+	}
 
-				// Set status when route is actually created
-				statusMgr.AddCondition(RouteAvailable, "FederationRouteCreated",
-					"Federation route created",
-					metav1.ConditionTrue)
-
-				r.log.Info("Created federation route", "Namespace", route.Namespace, "Name", route.Name)
-			} else {
-				r.log.Error(err, "Failed to get existing federation route")
-				statusMgr.AddCondition(RouteAvailable, "FederationRouteRetrievalFailed",
-					err.Error(),
-					metav1.ConditionFalse)
-				return err
-			}
-		} else if checkFederationRouteConflict(&existingRoute, route) {
-			if createOnlyMode {
-				r.log.Info("Skipping federation route update due to create-only mode")
-			} else {
-				r.log.Info("Found conflict in federation routes, updating route")
-				route.ResourceVersion = existingRoute.ResourceVersion
-
-				err = r.ctrlClient.Update(ctx, route)
-				if err != nil {
-					statusMgr.AddCondition(RouteAvailable, "FederationRouteUpdateFailed",
-						err.Error(),
-						metav1.ConditionFalse)
-					return err
-				}
+	exposure := server.Spec.Federation.Exposure
+	exposureType := utils.EndpointExposureType(exposure)
+	if err := utils.ValidateEndpointExposure(exposure, isFederationPassthrough(server)); err != nil {
+		r.log.Error(err, "Invalid federation exposure")
+		statusMgr.AddCondition(RouteAvailable, "InvalidFederationExposure",
+			err.Error(),
+			metav1.ConditionFalse)
+		return nil
+	}
 
-				// Set status when route is actually updated
-				statusMgr.AddCondition(RouteAvailable, "FederationRouteUpdated",
-					"Federation route updated",
-					metav1.ConditionTrue)
+	var hostname string
+	var err error
+	switch exposureType {
+	case v1alpha1.IngressExposureType:
+		hostname, err = r.reconcileFederationIngress(ctx, server, ztwim, statusMgr, createOnlyMode)
+	case v1alpha1.HTTPRouteExposureType:
+		hostname, err = r.reconcileFederationGatewayRoute(ctx, server, ztwim, statusMgr, createOnlyMode)
+		if err == nil {
+			err = r.reconcileFederationBackendTLS(ctx, server, ztwim, statusMgr, createOnlyMode)
+		}
+	case v1alpha1.TLSRouteExposureType:
+		hostname, err = r.reconcileFederationGatewayRoute(ctx, server, ztwim, statusMgr, createOnlyMode)
+	default:
+		hostname, err = r.reconcileFederationRoute(ctx, server, statusMgr, ztwim, createOnlyMode)
+	}
+	if err != nil {
+		return err
+	}
 
-				r.log.Info("Updated federation route", "Namespace", route.Namespace, "Name", route.Name)
-			}
-		} else {
-			// Route exists and is up to date - only update status if it's currently not ready
-			existingCondition := apimeta.FindStatusCondition(server.Status.ConditionalStatus.Conditions, RouteAvailable)
-			if existingCondition == nil || existingCondition.Status != metav1.ConditionTrue {
-				statusMgr.AddCondition(RouteAvailable, "RouteAvailable",
-					"Federation route is ready",
-					metav1.ConditionTrue)
+	if err := r.deleteStaleFederationExposure(ctx, exposureType, statusMgr); err != nil {
+		return err
+	}
+
+	r.setFederationHostname(server, hostname, statusMgr)
+	return nil
+}
+
+// setFederationHostname records the published federation hostname in status
+func (r *SpireServerReconciler) setFederationHostname(server *v1alpha1.SpireServer, hostname string, statusMgr *status.Manager) {
+	if server.Status.FederationHostname != hostname {
+		server.Status.FederationHostname = hostname
+		statusMgr.RequestStatusUpdate()
+	}
+}
+
+// reconcileFederationRoute reconciles the federation Route and returns its host
+func (r *SpireServerReconciler) reconcileFederationRoute(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager, ztwim *v1alpha1.ZeroTrustWorkloadIdentityManager, createOnlyMode bool) (string, error) {
+	// Create Route for federation endpoint
+	route := generateFederationRoute(server, ztwim)
+
+	var existingRoute routev1.Route
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{
+		Name:      route.Name,
+		Namespace: route.Namespace,
+	}, &existingRoute)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "Failed to get existing federation route")
+			statusMgr.AddCondition(RouteAvailable, "FederationRouteRetrievalFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
+		}
+		if err = r.ctrlClient.Create(ctx, route); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, route, r.log, statusMgr, RouteAvailable); conflictErr != nil {
+				return "", conflictErr
 			}
-			// If route is already ready, don't update the status to avoid overwriting the reason
+			r.log.Error(err, "Failed to create federation route")
+			statusMgr.AddCondition(RouteAvailable, "FederationRouteCreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return "", err
 		}
-	} else {
-		// Only update status to disabled
-		statusMgr.AddCondition(RouteAvailable, "FederationRouteDisabled",
-			"Federation managed route disabled",
+
+		// Set status when route is actually created
+		statusMgr.AddCondition(RouteAvailable, "FederationRouteCreated",
+			"Federation route created",
+			metav1.ConditionTrue)
+
+		r.log.Info("Created federation route", "Namespace", route.Namespace, "Name", route.Name)
+		return route.Spec.Host, nil
+	}
+
+	if !routeNeedsUpdate(&existingRoute, route) {
+		// Route exists and is up to date - only update status if it's currently not ready
+		r.markFederationExposureReady(server, statusMgr, "RouteAvailable", "Federation route is ready")
+		return routeHost(&existingRoute), nil
+	}
+	if createOnlyMode {
+		r.log.Info("Skipping federation route update due to create-only mode")
+		return routeHost(&existingRoute), nil
+	}
+
+	r.log.Info("Found conflict in federation routes, updating route")
+	route.ResourceVersion = existingRoute.ResourceVersion
+
+	err = r.ctrlClient.Update(ctx, route)
+	if err != nil {
+		statusMgr.AddCondition(RouteAvailable, "FederationRouteUpdateFailed",
+			err.Error(),
 			metav1.ConditionFalse)
+		return "", err
 	}
 
-	return nil
+	// Set status when route is actually updated
+	statusMgr.AddCondition(RouteAvailable, "FederationRouteUpdated",
+		"Federation route updated",
+		metav1.ConditionTrue)
+
+	r.log.Info("Updated federation route", "Namespace", route.Namespace, "Name", route.Name)
+	return route.Spec.Host, nil
+}
+
+// markFederationExposureReady sets RouteAvailable when the federation exposure is up to date,
+// without overwriting the reason of an already ready condition
+func (r *SpireServerReconciler) markFederationExposureReady(server *v1alpha1.SpireServer, statusMgr *status.Manager, reason, message string) {
+	existingCondition := apimeta.FindStatusCondition(server.Status.ConditionalStatus.Conditions, RouteAvailable)
+	if existingCondition == nil || existingCondition.Status != metav1.ConditionTrue {
+		statusMgr.AddCondition(RouteAvailable, reason, message, metav1.ConditionTrue)
+	}
 }
@@ -175,7 +175,7 @@ func TestGenerateFederationRoute(t *testing.T) {
 	}
 }
 
-func TestCheckFederationRouteConflict(t *testing.T) {
+func TestRouteNeedsUpdate(t *testing.T) {
 	baseRoute := &routev1.Route{
 		Spec: routev1.RouteSpec{
 			Host: "federation.example.org",
@@ -252,7 +252,7 @@ func TestCheckFederationRouteConflict(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			hasConflict := checkFederationRouteConflict(tt.current, tt.desired)
+			hasConflict := routeNeedsUpdate(tt.current, tt.desired)
 			if hasConflict != tt.expectConflict {
 				t.Errorf("Expected conflict=%v, got %v", tt.expectConflict, hasConflict)
 			}
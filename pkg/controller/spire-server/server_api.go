@@ -0,0 +1,291 @@
+package spire_server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+)
+
+const (
+	// ServerAPICredentialsAvailable reports whether the credentials securing the server API proxy are in place
+	ServerAPICredentialsAvailable = "ServerAPICredentialsAvailable"
+
+	serverAPIProxyPortName    = "server-api"
+	serverAPIProxyVolumeName  = "server-api-proxy-tls"
+	serverAPICredentialsValid = 365 * 24 * time.Hour
+	// serverAPICredentialsRenewBefore leaves the proxy time to pick up the rotated Secret before the
+	// previous credentials expire
+	serverAPICredentialsRenewBefore = 30 * 24 * time.Hour
+)
+
+// serverAPIProxyServerName is the DNS name the proxy certificate is issued for
+func serverAPIProxyServerName() string {
+	return fmt.Sprintf("%s.%s.svc", utils.SpireServerAPIServiceName, utils.GetOperatorNamespace())
+}
+
+// addServerAPIProxyToStatefulSet adds the sidecar exposing the private API socket to the operator over
+// mutual TLS. Without the operator image configured, the operator cannot reach the server API and the
+// features depending on it report the API as unavailable.
+func addServerAPIProxyToStatefulSet(sts *appsv1.StatefulSet, config *v1alpha1.SpireServerSpec) {
+	image := utils.GetOperatorImage()
+	if image == "" {
+		return
+	}
+
+	podSpec := &sts.Spec.Template.Spec
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: serverAPIProxyVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: utils.SpireServerAPIProxySecretName},
+		},
+	})
+	podSpec.Containers = append(podSpec.Containers, corev1.Container{
+		SecurityContext: &corev1.SecurityContext{
+			ReadOnlyRootFilesystem: ptr.To(true),
+		},
+		Name:            utils.SpireServerAPIProxyContainerName,
+		Image:           image,
+		ImagePullPolicy: corev1.PullIfNotPresent,
+		Args: []string{
+			utils.SpireServerAPIProxyCommand,
+			fmt.Sprintf("--listen-address=:%d", utils.SpireServerAPIProxyPort),
+			"--socket-path=" + spireapi.DefaultServerAPISocketPath,
+			"--cert-dir=" + utils.SpireServerAPIProxyCertMountPath,
+		},
+		Ports: []corev1.ContainerPort{
+			{Name: serverAPIProxyPortName, ContainerPort: utils.SpireServerAPIProxyPort, Protocol: corev1.ProtocolTCP},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler:  corev1.ProbeHandler{TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString(serverAPIProxyPortName)}},
+			PeriodSeconds: 10,
+		},
+		Resources: utils.ContainerResourceRequirements(utils.SpireServerAPIProxyContainerName, config.ContainerResources, config.Resources),
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "spire-server-socket", MountPath: "/tmp/spire-server/private", ReadOnly: true},
+			{Name: serverAPIProxyVolumeName, MountPath: utils.SpireServerAPIProxyCertMountPath, ReadOnly: true},
+		},
+	})
+}
+
+// getSpireServerAPIService returns the Service in front of the server API proxy sidecar
+func getSpireServerAPIService(customLabels map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      utils.SpireServerAPIServiceName,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireServerLabels(customLabels),
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+			Selector: map[string]string{
+				"app.kubernetes.io/name":     "spire-server",
+				"app.kubernetes.io/instance": utils.StandardInstance,
+			},
+			Ports: []corev1.ServicePort{{
+				Name:       serverAPIProxyPortName,
+				Port:       utils.SpireServerAPIProxyPort,
+				TargetPort: intstr.FromString(serverAPIProxyPortName),
+				Protocol:   corev1.ProtocolTCP,
+			}},
+		},
+	}
+}
+
+// reconcileSpireServerAPIService reconciles the Service in front of the server API proxy sidecar
+func (r *SpireServerReconciler) reconcileSpireServerAPIService(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager, createOnlyMode bool) error {
+	desired := getSpireServerAPIService(server.Spec.Labels)
+
+	if err := controllerutil.SetControllerReference(server, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on server API service")
+		statusMgr.AddCondition(ServiceAvailable, v1alpha1.ReasonFailed,
+			fmt.Sprintf("Failed to set owner reference on Server API Service: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	existing := &corev1.Service{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get server API service")
+			statusMgr.AddCondition(ServiceAvailable, v1alpha1.ReasonFailed,
+				fmt.Sprintf("Failed to get Server API Service: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, ServiceAvailable); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create server API service")
+			statusMgr.AddCondition(ServiceAvailable, v1alpha1.ReasonFailed,
+				fmt.Sprintf("Failed to create Server API Service: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.log.Info("Created Service", "name", desired.Name, "namespace", desired.Namespace)
+		return nil
+	}
+
+	if createOnlyMode {
+		r.log.V(1).Info("Service exists, skipping update due to create-only mode", "name", desired.Name)
+		return nil
+	}
+
+	// Preserve Kubernetes-managed fields from existing resource BEFORE comparison
+	desired.ResourceVersion = existing.ResourceVersion
+	desired.Spec.ClusterIP = existing.Spec.ClusterIP
+	desired.Spec.ClusterIPs = existing.Spec.ClusterIPs
+	desired.Spec.IPFamilies = existing.Spec.IPFamilies
+	desired.Spec.IPFamilyPolicy = existing.Spec.IPFamilyPolicy
+	desired.Spec.InternalTrafficPolicy = existing.Spec.InternalTrafficPolicy
+	desired.Spec.SessionAffinity = existing.Spec.SessionAffinity
+
+	if !utils.ResourceNeedsUpdate(existing, desired) {
+		r.log.V(1).Info("Service is up to date", "name", desired.Name)
+		return nil
+	}
+
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		r.log.Error(err, "failed to update server API service")
+		statusMgr.AddCondition(ServiceAvailable, v1alpha1.ReasonFailed,
+			fmt.Sprintf("Failed to update Server API Service: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+	r.log.Info("Updated Service", "name", desired.Name, "namespace", desired.Namespace)
+	return nil
+}
+
+// reconcileServerAPICredentials makes sure the proxy, the operator and the bundle reader hold matching,
+// unexpired credentials. They are internal to the operator, so they are rotated in create-only mode as
+// well: the operator would otherwise lose access to the server API once they expire.
+func (r *SpireServerReconciler) reconcileServerAPICredentials(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager) error {
+	names := []string{
+		utils.SpireServerAPIProxySecretName,
+		utils.SpireServerAPIClientSecretName,
+		utils.SpireServerAPIBundleReaderSecretName,
+	}
+	existing := make([]*corev1.Secret, len(names))
+	for i, name := range names {
+		secret := &corev1.Secret{}
+		exists, err := r.ctrlClient.Exists(ctx, types.NamespacedName{Namespace: utils.GetOperatorNamespace(), Name: name}, secret)
+		if err != nil {
+			return r.serverAPICredentialsFailed(statusMgr, fmt.Sprintf("failed to get server API Secret %s", name), err)
+		}
+		if exists {
+			existing[i] = secret
+		}
+	}
+
+	if reason := serverAPICredentialsRotationReason(existing...); reason != "" {
+		r.log.Info("Issuing SPIRE server API proxy credentials", "reason", reason)
+		credentials, err := spireapi.GenerateProxyCredentials(serverAPIProxyServerName(), utils.SpireServerAPIProxyClientCommonName, serverAPICredentialsValid)
+		if err != nil {
+			return r.serverAPICredentialsFailed(statusMgr, "failed to generate server API proxy credentials", err)
+		}
+		// The proxy is updated first so that it trusts the new client certificates as soon as possible
+		desired := []*corev1.Secret{
+			r.serverAPISecret(server, names[0], credentials.ServerCert, credentials.ServerKey, credentials.CACert),
+			r.serverAPISecret(server, names[1], credentials.ClientCert, credentials.ClientKey, credentials.CACert),
+			r.serverAPISecret(server, names[2], credentials.BundleReaderCert, credentials.BundleReaderKey, credentials.CACert),
+		}
+		for i := range desired {
+			if err := r.writeServerAPISecret(ctx, server, existing[i], desired[i]); err != nil {
+				return r.serverAPICredentialsFailed(statusMgr, fmt.Sprintf("failed to write server API Secret %s", desired[i].Name), err)
+			}
+		}
+	}
+
+	statusMgr.AddCondition(ServerAPICredentialsAvailable, v1alpha1.ReasonReady,
+		"Server API proxy credentials available",
+		metav1.ConditionTrue)
+	return nil
+}
+
+// serverAPICredentialsRotationReason returns why new credentials are needed, or an empty string. The first
+// Secret is the proxy's, and a nil Secret was not found.
+func serverAPICredentialsRotationReason(secrets ...*corev1.Secret) string {
+	for _, secret := range secrets {
+		if secret == nil {
+			return "credentials not found"
+		}
+	}
+	for _, secret := range secrets[1:] {
+		if !bytes.Equal(secrets[0].Data[spireapi.ProxyCACertFileName], secret.Data[spireapi.ProxyCACertFileName]) {
+			return fmt.Sprintf("proxy and %s credentials are issued by different CAs", secret.Name)
+		}
+	}
+	for _, secret := range secrets {
+		notAfter, err := spireapi.CertificateNotAfter(secret.Data[corev1.TLSCertKey])
+		if err != nil {
+			return fmt.Sprintf("invalid certificate in %s: %v", secret.Name, err)
+		}
+		if time.Until(notAfter) < serverAPICredentialsRenewBefore {
+			return fmt.Sprintf("certificate in %s expires at %s", secret.Name, notAfter.Format(time.RFC3339))
+		}
+	}
+	return ""
+}
+
+func (r *SpireServerReconciler) serverAPISecret(server *v1alpha1.SpireServer, name string, cert, key, caCert []byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: utils.GetOperatorNamespace(),
+			Labels:    utils.SpireServerLabels(server.Spec.Labels),
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:            cert,
+			corev1.TLSPrivateKeyKey:      key,
+			spireapi.ProxyCACertFileName: caCert,
+		},
+	}
+}
+
+// writeServerAPISecret creates the Secret when existing is nil, and updates it otherwise
+func (r *SpireServerReconciler) writeServerAPISecret(ctx context.Context, server *v1alpha1.SpireServer, existing, desired *corev1.Secret) error {
+	if err := controllerutil.SetControllerReference(server, desired, r.scheme); err != nil {
+		return err
+	}
+	if existing == nil {
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			return err
+		}
+		r.log.Info("Created Secret", "name", desired.Name, "namespace", desired.Namespace)
+		return nil
+	}
+	if !utils.IsManagedBy(existing, server) {
+		return fmt.Errorf("secret %s exists and is not managed by the operator", existing.Name)
+	}
+	desired.ResourceVersion = existing.ResourceVersion
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		return err
+	}
+	r.log.Info("Rotated Secret", "name", desired.Name, "namespace", desired.Namespace)
+	return nil
+}
+
+func (r *SpireServerReconciler) serverAPICredentialsFailed(statusMgr *status.Manager, message string, err error) error {
+	r.log.Error(err, message)
+	statusMgr.AddCondition(ServerAPICredentialsAvailable, v1alpha1.ReasonFailed,
+		fmt.Sprintf("%s: %v", message, err),
+		metav1.ConditionFalse)
+	return err
+}
@@ -0,0 +1,179 @@
+package spire_server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+)
+
+// serverAPITestSecrets returns the proxy, client and bundle reader Secrets of one set of credentials
+func serverAPITestSecrets(t *testing.T, validity time.Duration) []*corev1.Secret {
+	t.Helper()
+	credentials, err := spireapi.GenerateProxyCredentials("spire-server-api.test.svc", "operator", validity)
+	require.NoError(t, err)
+	reconciler := &SpireServerReconciler{}
+	server := &v1alpha1.SpireServer{}
+	return []*corev1.Secret{
+		reconciler.serverAPISecret(server, utils.SpireServerAPIProxySecretName, credentials.ServerCert, credentials.ServerKey, credentials.CACert),
+		reconciler.serverAPISecret(server, utils.SpireServerAPIClientSecretName, credentials.ClientCert, credentials.ClientKey, credentials.CACert),
+		reconciler.serverAPISecret(server, utils.SpireServerAPIBundleReaderSecretName, credentials.BundleReaderCert, credentials.BundleReaderKey, credentials.CACert),
+	}
+}
+
+// existingSecrets returns an Exists stub finding copies of the given Secrets by name
+func existingSecrets(secrets []*corev1.Secret) func(context.Context, client.ObjectKey, client.Object) (bool, error) {
+	return func(_ context.Context, key client.ObjectKey, obj client.Object) (bool, error) {
+		for _, secret := range secrets {
+			if secret.Name == key.Name {
+				secret.DeepCopyInto(obj.(*corev1.Secret))
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+}
+
+func TestServerAPICredentialsRotationReason(t *testing.T) {
+	secrets := serverAPITestSecrets(t, serverAPICredentialsValid)
+	assert.Empty(t, serverAPICredentialsRotationReason(secrets...))
+	assert.Equal(t, "credentials not found", serverAPICredentialsRotationReason(nil, secrets[1], secrets[2]))
+	assert.Equal(t, "credentials not found", serverAPICredentialsRotationReason(secrets[0], secrets[1], nil))
+
+	other := serverAPITestSecrets(t, serverAPICredentialsValid)
+	assert.Contains(t, serverAPICredentialsRotationReason(other[0], secrets[1], secrets[2]), "different CAs")
+	assert.Contains(t, serverAPICredentialsRotationReason(secrets[0], secrets[1], other[2]), "different CAs")
+
+	expiring := serverAPITestSecrets(t, 24*time.Hour)
+	assert.Contains(t, serverAPICredentialsRotationReason(expiring...), "expires at")
+
+	corrupted := secrets[2].DeepCopy()
+	corrupted.Data[corev1.TLSCertKey] = []byte("garbage")
+	assert.Contains(t, serverAPICredentialsRotationReason(secrets[0], secrets[1], corrupted), "invalid certificate")
+}
+
+func TestReconcileServerAPICredentials(t *testing.T) {
+	t.Run("issues all Secrets when missing", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newServiceTestReconciler(fakeClient)
+		statusMgr := status.NewManager(fakeClient)
+
+		require.NoError(t, reconciler.reconcileServerAPICredentials(context.Background(), &v1alpha1.SpireServer{}, statusMgr))
+
+		require.Equal(t, 3, fakeClient.CreateCallCount())
+		var created []*corev1.Secret
+		for i := range 3 {
+			_, obj, _ := fakeClient.CreateArgsForCall(i)
+			created = append(created, obj.(*corev1.Secret))
+		}
+		assert.Equal(t, utils.SpireServerAPIProxySecretName, created[0].Name)
+		assert.Equal(t, utils.SpireServerAPIClientSecretName, created[1].Name)
+		assert.Equal(t, utils.SpireServerAPIBundleReaderSecretName, created[2].Name)
+		assert.Empty(t, serverAPICredentialsRotationReason(created...))
+	})
+
+	t.Run("issues all Secrets when the bundle reader is missing", func(t *testing.T) {
+		secrets := serverAPITestSecrets(t, serverAPICredentialsValid)
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.ExistsStub = existingSecrets(secrets[:2])
+		reconciler := newServiceTestReconciler(fakeClient)
+		server := &v1alpha1.SpireServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "server-uid"}}
+		for _, secret := range secrets[:2] {
+			require.NoError(t, controllerutil.SetControllerReference(server, secret, reconciler.scheme))
+		}
+
+		require.NoError(t, reconciler.reconcileServerAPICredentials(context.Background(), server, status.NewManager(fakeClient)))
+		assert.Equal(t, 2, fakeClient.UpdateCallCount())
+		require.Equal(t, 1, fakeClient.CreateCallCount())
+		_, created, _ := fakeClient.CreateArgsForCall(0)
+		assert.Equal(t, utils.SpireServerAPIBundleReaderSecretName, created.GetName())
+	})
+
+	t.Run("keeps valid credentials", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.ExistsStub = existingSecrets(serverAPITestSecrets(t, serverAPICredentialsValid))
+		reconciler := newServiceTestReconciler(fakeClient)
+		server := &v1alpha1.SpireServer{}
+		statusMgr := status.NewManager(fakeClient)
+
+		require.NoError(t, reconciler.reconcileServerAPICredentials(context.Background(), server, statusMgr))
+		assert.Zero(t, fakeClient.CreateCallCount())
+		assert.Zero(t, fakeClient.UpdateCallCount())
+
+		applyTestStatus(t, statusMgr, server)
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, ServerAPICredentialsAvailable)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	})
+
+	t.Run("rotates expiring credentials", func(t *testing.T) {
+		secrets := serverAPITestSecrets(t, 24*time.Hour)
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.ExistsStub = existingSecrets(secrets)
+		reconciler := newServiceTestReconciler(fakeClient)
+		server := &v1alpha1.SpireServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "server-uid"}}
+		for _, secret := range secrets {
+			require.NoError(t, controllerutil.SetControllerReference(server, secret, reconciler.scheme))
+		}
+
+		require.NoError(t, reconciler.reconcileServerAPICredentials(context.Background(), server, status.NewManager(fakeClient)))
+		assert.Zero(t, fakeClient.CreateCallCount())
+		assert.Equal(t, 3, fakeClient.UpdateCallCount())
+	})
+
+	t.Run("does not overwrite Secrets it did not create", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		fakeClient.ExistsStub = existingSecrets(serverAPITestSecrets(t, 24*time.Hour))
+		reconciler := newServiceTestReconciler(fakeClient)
+		server := &v1alpha1.SpireServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "server-uid"}}
+		statusMgr := status.NewManager(fakeClient)
+
+		err := reconciler.reconcileServerAPICredentials(context.Background(), server, statusMgr)
+		require.ErrorContains(t, err, "not managed by the operator")
+		assert.Zero(t, fakeClient.UpdateCallCount())
+
+		applyTestStatus(t, statusMgr, server)
+		condition := apimeta.FindStatusCondition(server.Status.Conditions, ServerAPICredentialsAvailable)
+		require.NotNil(t, condition)
+		assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	})
+}
+
+func TestAddServerAPIProxyToStatefulSet(t *testing.T) {
+	t.Run("without operator image", func(t *testing.T) {
+		t.Setenv(utils.OperatorImageEnv, "")
+		sts := &appsv1.StatefulSet{}
+		addServerAPIProxyToStatefulSet(sts, &v1alpha1.SpireServerSpec{})
+		assert.Empty(t, sts.Spec.Template.Spec.Containers)
+		assert.Empty(t, sts.Spec.Template.Spec.Volumes)
+	})
+
+	t.Run("with operator image", func(t *testing.T) {
+		t.Setenv(utils.OperatorImageEnv, "operator:test")
+		sts := &appsv1.StatefulSet{}
+		addServerAPIProxyToStatefulSet(sts, &v1alpha1.SpireServerSpec{})
+
+		require.Len(t, sts.Spec.Template.Spec.Containers, 1)
+		container := sts.Spec.Template.Spec.Containers[0]
+		assert.Equal(t, utils.SpireServerAPIProxyContainerName, container.Name)
+		assert.Equal(t, "operator:test", container.Image)
+		assert.Equal(t, utils.SpireServerAPIProxyCommand, container.Args[0])
+		assert.Contains(t, container.Args, "--socket-path="+spireapi.DefaultServerAPISocketPath)
+		require.Len(t, sts.Spec.Template.Spec.Volumes, 1)
+		assert.Equal(t, utils.SpireServerAPIProxySecretName, sts.Spec.Template.Spec.Volumes[0].Secret.SecretName)
+	})
+}
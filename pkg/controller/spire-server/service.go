@@ -17,7 +17,7 @@ import (
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/operator/assets"
 )
 
-// reconcileService reconciles all Services (spire-server and controller-manager)
+// reconcileService reconciles all Services (spire-server, controller-manager and server API proxy)
 func (r *SpireServerReconciler) reconcileService(ctx context.Context, server *v1alpha1.SpireServer, statusMgr *status.Manager, createOnlyMode bool) error {
 	// Spire Server Service
 	if err := r.reconcileSpireServerService(ctx, server, statusMgr, createOnlyMode); err != nil {
@@ -29,6 +29,11 @@ func (r *SpireServerReconciler) reconcileService(ctx context.Context, server *v1
 		return err
 	}
 
+	// Server API proxy Service
+	if err := r.reconcileSpireServerAPIService(ctx, server, statusMgr, createOnlyMode); err != nil {
+		return err
+	}
+
 	statusMgr.AddCondition(ServiceAvailable, v1alpha1.ReasonReady,
 		"All Service resources available",
 		metav1.ConditionTrue)
@@ -197,7 +197,7 @@ func GenerateSpireServerStatefulSet(config *v1alpha1.SpireServerSpec,
 								InitialDelaySeconds: 5,
 								PeriodSeconds:       5,
 							},
-							Resources:    utils.DerefResourceRequirements(config.Resources),
+							Resources:    utils.ContainerResourceRequirements("spire-server", config.ContainerResources, config.Resources),
 							VolumeMounts: spireServerVolumeMounts,
 						},
 						{
@@ -226,7 +226,7 @@ func GenerateSpireServerStatefulSet(config *v1alpha1.SpireServerSpec,
 								{Name: "controller-manager-config", MountPath: "/controller-manager-config.yaml", SubPath: "controller-manager-config.yaml", ReadOnly: true},
 								{Name: "spire-controller-manager-tmp", MountPath: "/tmp", SubPath: "spire-controller-manager"},
 							},
-							Resources: utils.DerefResourceRequirements(config.Resources),
+							Resources: utils.ContainerResourceRequirements("spire-controller-manager", config.ContainerResources, config.Resources),
 						},
 					},
 					Volumes:      volumes,
@@ -264,6 +264,8 @@ func GenerateSpireServerStatefulSet(config *v1alpha1.SpireServerSpec,
 		addUpstreamAuthorityToStatefulSet(sts, config.UpstreamAuthority)
 	}
 
+	addServerAPIProxyToStatefulSet(sts, config)
+
 	return sts
 }
 
@@ -1132,3 +1132,41 @@ func TestGenerateStatefulSet_NoUpstreamAuthority(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateStatefulSet_ContainerResources(t *testing.T) {
+	shared := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("100m"),
+		},
+	}
+	serverResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	config := &v1alpha1.SpireServerSpec{
+		Persistence: v1alpha1.Persistence{
+			Size:       "1Gi",
+			AccessMode: "ReadWriteOnce",
+		},
+		CommonConfig: v1alpha1.CommonConfig{
+			Resources: shared,
+		},
+		ContainerResources: []v1alpha1.ContainerResources{
+			{Name: "spire-server", Resources: serverResources},
+		},
+	}
+
+	sts := GenerateSpireServerStatefulSet(config, "hash1", "hash2")
+
+	for _, container := range sts.Spec.Template.Spec.Containers {
+		expected := *shared
+		if container.Name == "spire-server" {
+			expected = serverResources
+		}
+		if !reflect.DeepEqual(container.Resources, expected) {
+			t.Errorf("Expected %s resources %+v, got %+v", container.Name, expected, container.Resources)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// MaxReportedUnhealthyNodes caps the number of nodes listed in NodeHealthStatus
+	MaxReportedUnhealthyNodes = 20
+
+	// DefaultUnhealthyNodeThresholdPercent is the percentage of desired nodes with a non-ready
+	// pod above which the node health condition is set to False, unless the CR sets another one
+	DefaultUnhealthyNodeThresholdPercent = 10
+
+	// maxUnhealthyNodeMessageLength matches the MaxLength validation of UnhealthyNode.Message
+	maxUnhealthyNodeMessageLength = 256
+
+	// Node health condition reasons
+	ReasonNodesHealthy                   = "NodesHealthy"
+	ReasonUnhealthyNodesBelowThreshold   = "UnhealthyNodesBelowThreshold"
+	ReasonUnhealthyNodeThresholdExceeded = "UnhealthyNodeThresholdExceeded"
+)
+
+// CheckDaemonSetNodeHealth inspects the pods of a DaemonSet, adds a condition reflecting
+// the ratio of nodes with a non-ready pod against thresholdPercent, and returns a per-node report.
+// It returns nil when the DaemonSet or its pods cannot be read, or when no pods are desired.
+func (m *Manager) CheckDaemonSetNodeHealth(ctx context.Context, name, namespace, conditionType string, thresholdPercent int32) *v1alpha1.NodeHealthStatus {
+	var ds appsv1.DaemonSet
+	if err := m.customClient.Get(ctx, client.ObjectKey{Name: name, Namespace: namespace}, &ds); err != nil {
+		return nil
+	}
+	if ds.Spec.Selector == nil || ds.Status.DesiredNumberScheduled == 0 {
+		return nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+	if err != nil {
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := m.customClient.List(ctx, &pods, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		m.AddCondition(conditionType, "PodListFailed",
+			fmt.Sprintf("Failed to list pods of DaemonSet %s/%s: %v", namespace, name, err),
+			metav1.ConditionUnknown)
+		return nil
+	}
+
+	report := BuildNodeHealthStatus(ds.Status.DesiredNumberScheduled, pods.Items)
+
+	switch {
+	case report.UnhealthyNodeCount == 0:
+		m.AddCondition(conditionType, ReasonNodesHealthy,
+			fmt.Sprintf("Pods of DaemonSet %s are ready on all %d nodes", name, report.DesiredNodes),
+			metav1.ConditionTrue)
+	case report.UnhealthyNodeCount*100 > report.DesiredNodes*thresholdPercent:
+		m.AddCondition(conditionType, ReasonUnhealthyNodeThresholdExceeded,
+			fmt.Sprintf("Pods of DaemonSet %s are not ready on %d/%d nodes, above the %d%% threshold",
+				name, report.UnhealthyNodeCount, report.DesiredNodes, thresholdPercent),
+			metav1.ConditionFalse)
+	default:
+		m.AddCondition(conditionType, ReasonUnhealthyNodesBelowThreshold,
+			fmt.Sprintf("Pods of DaemonSet %s are not ready on %d/%d nodes",
+				name, report.UnhealthyNodeCount, report.DesiredNodes),
+			metav1.ConditionTrue)
+	}
+
+	return report
+}
+
+// UnhealthyNodeThreshold returns the threshold set on the CR, or the default one
+func UnhealthyNodeThreshold(thresholdPercent *int32) int32 {
+	if thresholdPercent == nil {
+		return DefaultUnhealthyNodeThresholdPercent
+	}
+	return *thresholdPercent
+}
+
+// BuildNodeHealthStatus builds a NodeHealthStatus from the pods of a DaemonSet.
+// Unhealthy nodes are sorted by node name and capped at MaxReportedUnhealthyNodes.
+func BuildNodeHealthStatus(desiredNodes int32, pods []corev1.Pod) *v1alpha1.NodeHealthStatus {
+	report := &v1alpha1.NodeHealthStatus{DesiredNodes: desiredNodes}
+
+	var unhealthy []v1alpha1.UnhealthyNode
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || utils.IsPodReady(pod) {
+			continue
+		}
+		unhealthy = append(unhealthy, describeUnhealthyPod(pod))
+	}
+
+	sort.Slice(unhealthy, func(i, j int) bool {
+		if unhealthy[i].NodeName != unhealthy[j].NodeName {
+			return unhealthy[i].NodeName < unhealthy[j].NodeName
+		}
+		return unhealthy[i].PodName < unhealthy[j].PodName
+	})
+
+	report.UnhealthyNodeCount = int32(len(unhealthy))
+	if len(unhealthy) > MaxReportedUnhealthyNodes {
+		unhealthy = unhealthy[:MaxReportedUnhealthyNodes]
+	}
+	report.UnhealthyNodes = unhealthy
+
+	return report
+}
+
+// describeUnhealthyPod extracts the most relevant failure reason from a non-ready pod.
+// Init containers are considered first since the main containers cannot start before them.
+func describeUnhealthyPod(pod *corev1.Pod) v1alpha1.UnhealthyNode {
+	node := v1alpha1.UnhealthyNode{
+		NodeName: pod.Spec.NodeName,
+		PodName:  pod.Name,
+	}
+
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		node.RestartCount += cs.RestartCount
+		if node.Reason != "" {
+			continue
+		}
+		reason, message := containerFailure(cs)
+		if reason != "" {
+			node.Reason = reason
+			node.Message = truncateMessage(message)
+		}
+	}
+
+	if node.Reason == "" {
+		node.Reason = string(pod.Status.Phase)
+		if pod.Status.Reason != "" {
+			node.Reason = pod.Status.Reason
+		}
+		node.Message = truncateMessage(pod.Status.Message)
+	}
+
+	return node
+}
+
+// containerFailure returns the reason and message describing why a container is not ready.
+// The last termination message is preferred since it usually carries the actual error,
+// e.g. an attestation failure logged before the agent exited.
+func containerFailure(cs corev1.ContainerStatus) (string, string) {
+	if cs.Ready {
+		return "", ""
+	}
+
+	lastMessage := ""
+	if cs.LastTerminationState.Terminated != nil {
+		lastMessage = cs.LastTerminationState.Terminated.Message
+		if lastMessage == "" {
+			lastMessage = cs.LastTerminationState.Terminated.Reason
+		}
+	}
+
+	switch {
+	case cs.State.Waiting != nil:
+		if lastMessage == "" {
+			lastMessage = cs.State.Waiting.Message
+		}
+		return cs.State.Waiting.Reason, lastMessage
+	case cs.State.Terminated != nil:
+		if cs.State.Terminated.Message != "" {
+			lastMessage = cs.State.Terminated.Message
+		}
+		return cs.State.Terminated.Reason, lastMessage
+	default:
+		return "ContainerNotReady", lastMessage
+	}
+}
+
+// truncateMessage shortens a message to the maximum length allowed in status
+func truncateMessage(message string) string {
+	if len(message) <= maxUnhealthyNodeMessageLength {
+		return message
+	}
+	return message[:maxUnhealthyNodeMessageLength-3] + "..."
+}
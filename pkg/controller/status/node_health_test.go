@@ -0,0 +1,253 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func readyPod(name, node string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "spire-agent", Ready: true, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}},
+			},
+		},
+	}
+}
+
+func crashLoopPod(name, node, lastMessage string, restarts int32) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"},
+		Spec:       corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionFalse}},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{
+					Name:         "spire-agent",
+					RestartCount: restarts,
+					State: corev1.ContainerState{
+						Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off restarting failed container"},
+					},
+					LastTerminationState: corev1.ContainerState{
+						Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1, Message: lastMessage},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestBuildNodeHealthStatus(t *testing.T) {
+	pendingPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "agent-pending", Namespace: "ns"},
+		Status:     corev1.PodStatus{Phase: corev1.PodPending},
+	}
+	initFailingPod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "csi-init", Namespace: "ns"},
+		Spec:       corev1.PodSpec{NodeName: "node-d"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodPending,
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "set-context", RestartCount: 2, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "image not found"}}},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "spiffe-csi-driver", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}},
+			},
+		},
+	}
+
+	pods := []corev1.Pod{
+		readyPod("agent-a", "node-a"),
+		crashLoopPod("agent-c", "node-c", "failed to attest: join token does not exist", 7),
+		crashLoopPod("agent-b", "node-b", "", 3),
+		pendingPod,
+		initFailingPod,
+	}
+
+	report := BuildNodeHealthStatus(5, pods)
+
+	if report.DesiredNodes != 5 {
+		t.Errorf("Expected DesiredNodes 5, got %d", report.DesiredNodes)
+	}
+	if report.UnhealthyNodeCount != 4 {
+		t.Fatalf("Expected 4 unhealthy nodes, got %d", report.UnhealthyNodeCount)
+	}
+
+	expected := []struct {
+		node, pod, reason, message string
+		restarts                   int32
+	}{
+		{"", "agent-pending", "Pending", "", 0},
+		{"node-b", "agent-b", "CrashLoopBackOff", "Error", 3},
+		{"node-c", "agent-c", "CrashLoopBackOff", "failed to attest: join token does not exist", 7},
+		{"node-d", "csi-init", "ImagePullBackOff", "image not found", 2},
+	}
+	for i, exp := range expected {
+		got := report.UnhealthyNodes[i]
+		if got.NodeName != exp.node || got.PodName != exp.pod || got.Reason != exp.reason || got.Message != exp.message || got.RestartCount != exp.restarts {
+			t.Errorf("Entry %d mismatch: got %+v, expected %+v", i, got, exp)
+		}
+	}
+}
+
+func TestBuildNodeHealthStatusCapsList(t *testing.T) {
+	var pods []corev1.Pod
+	for i := 0; i < MaxReportedUnhealthyNodes+5; i++ {
+		pods = append(pods, crashLoopPod(fmt.Sprintf("agent-%03d", i), fmt.Sprintf("node-%03d", i), strings.Repeat("x", 1000), 1))
+	}
+
+	report := BuildNodeHealthStatus(int32(len(pods)), pods)
+
+	if report.UnhealthyNodeCount != int32(len(pods)) {
+		t.Errorf("Expected unhealthy count %d, got %d", len(pods), report.UnhealthyNodeCount)
+	}
+	if len(report.UnhealthyNodes) != MaxReportedUnhealthyNodes {
+		t.Errorf("Expected %d reported nodes, got %d", MaxReportedUnhealthyNodes, len(report.UnhealthyNodes))
+	}
+	if len(report.UnhealthyNodes[0].Message) != maxUnhealthyNodeMessageLength {
+		t.Errorf("Expected message truncated to %d characters, got %d", maxUnhealthyNodeMessageLength, len(report.UnhealthyNodes[0].Message))
+	}
+}
+
+func TestCheckDaemonSetNodeHealth(t *testing.T) {
+	tests := []struct {
+		name           string
+		getError       error
+		listError      error
+		desired        int32
+		threshold      *int32
+		pods           []corev1.Pod
+		expectReport   bool
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{name: "daemonset not found", getError: errors.New("not found")},
+		{name: "no pods desired", desired: 0},
+		{
+			name:           "pod list fails",
+			desired:        3,
+			listError:      errors.New("forbidden"),
+			expectedStatus: metav1.ConditionUnknown,
+			expectedReason: "PodListFailed",
+		},
+		{
+			name:           "all nodes healthy",
+			desired:        2,
+			pods:           []corev1.Pod{readyPod("a", "node-a"), readyPod("b", "node-b")},
+			expectReport:   true,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: ReasonNodesHealthy,
+		},
+		{
+			name:    "unhealthy nodes below threshold",
+			desired: 20,
+			pods: func() []corev1.Pod {
+				pods := []corev1.Pod{crashLoopPod("bad", "node-bad", "", 1)}
+				for i := 0; i < 19; i++ {
+					pods = append(pods, readyPod(fmt.Sprintf("ok-%d", i), fmt.Sprintf("node-%d", i)))
+				}
+				return pods
+			}(),
+			expectReport:   true,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: ReasonUnhealthyNodesBelowThreshold,
+		},
+		{
+			name:           "unhealthy nodes above threshold",
+			desired:        3,
+			pods:           []corev1.Pod{readyPod("a", "node-a"), crashLoopPod("b", "node-b", "", 4), readyPod("c", "node-c")},
+			expectReport:   true,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: ReasonUnhealthyNodeThresholdExceeded,
+		},
+		{
+			name:           "unhealthy nodes below a raised threshold",
+			desired:        3,
+			threshold:      ptr.To[int32](50),
+			pods:           []corev1.Pod{readyPod("a", "node-a"), crashLoopPod("b", "node-b", "", 4), readyPod("c", "node-c")},
+			expectReport:   true,
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: ReasonUnhealthyNodesBelowThreshold,
+		},
+		{
+			name:      "any unhealthy node with a zero threshold",
+			desired:   20,
+			threshold: ptr.To[int32](0),
+			pods: func() []corev1.Pod {
+				pods := []corev1.Pod{crashLoopPod("bad", "node-bad", "", 1)}
+				for i := 0; i < 19; i++ {
+					pods = append(pods, readyPod(fmt.Sprintf("ok-%d", i), fmt.Sprintf("node-%d", i)))
+				}
+				return pods
+			}(),
+			expectReport:   true,
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: ReasonUnhealthyNodeThresholdExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := &fakes.FakeCustomCtrlClient{}
+			mgr := NewManager(fakeClient)
+
+			if tt.getError != nil {
+				fakeClient.GetReturns(tt.getError)
+			} else {
+				ds := &appsv1.DaemonSet{
+					ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+					Spec: appsv1.DaemonSetSpec{
+						Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test"}},
+					},
+					Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: tt.desired},
+				}
+				fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+					if d, ok := obj.(*appsv1.DaemonSet); ok {
+						*d = *ds
+					}
+					return nil
+				}
+			}
+			fakeClient.ListStub = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+				if tt.listError != nil {
+					return tt.listError
+				}
+				if l, ok := list.(*corev1.PodList); ok {
+					l.Items = tt.pods
+				}
+				return nil
+			}
+
+			report := mgr.CheckDaemonSetNodeHealth(context.Background(), "test", "ns", "NodesHealthy", UnhealthyNodeThreshold(tt.threshold))
+
+			if tt.expectReport != (report != nil) {
+				t.Fatalf("Expected report present=%v, got %+v", tt.expectReport, report)
+			}
+			cond, exists := mgr.conditions["NodesHealthy"]
+			if tt.expectedReason == "" {
+				if exists {
+					t.Errorf("Expected no condition, got %+v", cond)
+				}
+				return
+			}
+			if cond.Status != tt.expectedStatus || cond.Reason != tt.expectedReason {
+				t.Errorf("Expected %v/%s, got %v/%s", tt.expectedStatus, tt.expectedReason, cond.Status, cond.Reason)
+			}
+		})
+	}
+}
@@ -27,6 +27,8 @@ type Condition struct {
 type Manager struct {
 	customClient customClient.CustomCtrlClient
 	conditions   map[string]Condition
+	removed      map[string]bool
+	forceUpdate  bool
 }
 
 // NewManager creates a new status manager
@@ -34,11 +36,13 @@ func NewManager(customClient customClient.CustomCtrlClient) *Manager {
 	return &Manager{
 		customClient: customClient,
 		conditions:   make(map[string]Condition),
+		removed:      make(map[string]bool),
 	}
 }
 
 // AddCondition adds or updates a condition
 func (m *Manager) AddCondition(conditionType, reason, message string, status metav1.ConditionStatus) {
+	delete(m.removed, conditionType)
 	m.conditions[conditionType] = Condition{
 		Type:    conditionType,
 		Status:  status,
@@ -47,6 +51,22 @@ func (m *Manager) AddCondition(conditionType, reason, message string, status met
 	}
 }
 
+// RemoveCondition drops a condition from the resource status, for conditions that
+// no longer apply to the current configuration
+func (m *Manager) RemoveCondition(conditionType string) {
+	delete(m.conditions, conditionType)
+	if m.removed == nil {
+		m.removed = make(map[string]bool)
+	}
+	m.removed[conditionType] = true
+}
+
+// RequestStatusUpdate makes ApplyStatus persist the status even when no condition changed.
+// Controllers call it after modifying status fields other than conditions.
+func (m *Manager) RequestStatusUpdate() {
+	m.forceUpdate = true
+}
+
 // SetReadyCondition sets the Ready condition based on all other conditions
 // Distinguishes between "Progressing" (normal startup/rollout) and "Failed" (actual errors)
 func (m *Manager) SetReadyCondition() {
@@ -128,9 +148,14 @@ func (m *Manager) ApplyStatus(ctx context.Context, obj client.Object, getStatus
 		}
 		apimeta.SetStatusCondition(&status.Conditions, newCondition)
 	}
+	for conditionType := range m.removed {
+		if _, readded := m.conditions[conditionType]; !readded {
+			apimeta.RemoveStatusCondition(&status.Conditions, conditionType)
+		}
+	}
 
 	// Only update if status has changed
-	if !equality.Semantic.DeepEqual(originalStatus, status) {
+	if m.forceUpdate || !equality.Semantic.DeepEqual(originalStatus, status) {
 		if err := m.customClient.StatusUpdateWithRetry(ctx, obj); err != nil {
 			return fmt.Errorf("failed to update status: %w", err)
 		}
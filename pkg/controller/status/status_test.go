@@ -9,6 +9,7 @@ import (
 	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
 	appsv1 "k8s.io/api/apps/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -414,6 +415,74 @@ func TestApplyStatus(t *testing.T) {
 	}
 }
 
+func TestApplyStatusRequestStatusUpdate(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestUpdate  bool
+		expectedUpdate int
+	}{
+		{name: "unchanged conditions skip update", requestUpdate: false, expectedUpdate: 0},
+		{name: "requested update is persisted", requestUpdate: true, expectedUpdate: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClient := &fakes.FakeCustomCtrlClient{}
+			obj := &v1alpha1.SpireAgent{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+			obj.Status.Conditions = []metav1.Condition{
+				{Type: v1alpha1.Ready, Status: metav1.ConditionTrue, Reason: v1alpha1.ReasonReady, Message: "All components are ready"},
+			}
+
+			mgr := NewManager(fakeClient)
+			if tt.requestUpdate {
+				obj.Status.NodeHealth = &v1alpha1.NodeHealthStatus{DesiredNodes: 3}
+				mgr.RequestStatusUpdate()
+			}
+
+			if err := mgr.ApplyStatus(context.Background(), obj, func() *v1alpha1.ConditionalStatus {
+				return &obj.Status.ConditionalStatus
+			}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if fakeClient.StatusUpdateWithRetryCallCount() != tt.expectedUpdate {
+				t.Errorf("Expected %d status updates, got %d", tt.expectedUpdate, fakeClient.StatusUpdateWithRetryCallCount())
+			}
+		})
+	}
+}
+
+func TestApplyStatusRemoveCondition(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	obj := &v1alpha1.SpireAgent{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	obj.Status.Conditions = []metav1.Condition{
+		{Type: v1alpha1.Ready, Status: metav1.ConditionTrue, Reason: v1alpha1.ReasonReady, Message: "All components are ready"},
+		{Type: "Stale", Status: metav1.ConditionFalse, Reason: "Old", Message: "no longer applies"},
+		{Type: "Readded", Status: metav1.ConditionTrue, Reason: "Old", Message: "still applies"},
+	}
+
+	mgr := NewManager(fakeClient)
+	mgr.RemoveCondition("Stale")
+	mgr.RemoveCondition("Readded")
+	mgr.AddCondition("Readded", "New", "still applies", metav1.ConditionTrue)
+
+	if err := mgr.ApplyStatus(context.Background(), obj, func() *v1alpha1.ConditionalStatus {
+		return &obj.Status.ConditionalStatus
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if cond := apimeta.FindStatusCondition(obj.Status.Conditions, "Stale"); cond != nil {
+		t.Errorf("Expected Stale condition to be removed, got %+v", cond)
+	}
+	if cond := apimeta.FindStatusCondition(obj.Status.Conditions, "Readded"); cond == nil || cond.Reason != "New" {
+		t.Errorf("Expected Readded condition with reason New, got %+v", cond)
+	}
+	if fakeClient.StatusUpdateWithRetryCallCount() != 1 {
+		t.Errorf("Expected 1 status update, got %d", fakeClient.StatusUpdateWithRetryCallCount())
+	}
+}
+
 func TestCheckStatefulSetHealth(t *testing.T) {
 	tests := []struct {
 		name           string
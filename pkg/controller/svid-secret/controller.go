@@ -0,0 +1,450 @@
+package svid_secret
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	workloadIdentityController "github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/workload-identity"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/version"
+)
+
+const (
+	// Kubernetes-compliant condition names
+	PolicyCompliant = "PolicyCompliant"
+	SVIDIssued      = "SVIDIssued"
+
+	// SVIDSecretGenerationAnnotation records the SVIDSecret generation the SVID in the Secret was minted for
+	SVIDSecretGenerationAnnotation = "operator.openshift.io/svid-secret-generation"
+)
+
+// SVIDSecretReconciler reconciles a SVIDSecret object
+type SVIDSecretReconciler struct {
+	ctrlClient    customClient.CustomCtrlClient
+	ctx           context.Context
+	eventRecorder record.EventRecorder
+	log           logr.Logger
+	scheme        *runtime.Scheme
+	dialServerAPI spireapi.DialFunc
+}
+
+// New returns a new Reconciler instance.
+func New(mgr ctrl.Manager) (*SVIDSecretReconciler, error) {
+	c, err := customClient.NewCustomClient(mgr)
+	if err != nil {
+		return nil, err
+	}
+	return &SVIDSecretReconciler{
+		ctrlClient:    c,
+		ctx:           context.Background(),
+		eventRecorder: mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerSVIDSecretControllerName),
+		log:           ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerSVIDSecretControllerName),
+		scheme:        mgr.GetScheme(),
+		dialServerAPI: utils.ServerAPIDialer(c),
+	}, nil
+}
+
+func (r *SVIDSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.log.Info(fmt.Sprintf("reconciling %s", utils.ZeroTrustWorkloadIdentityManagerSVIDSecretControllerName), "namespace", req.Namespace, "name", req.Name)
+	var svidSecret v1alpha1.SVIDSecret
+	if err := r.ctrlClient.Get(ctx, req.NamespacedName, &svidSecret); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.log.Info("SVIDSecret resource not found. Ignoring since object must be deleted or not been created.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	statusMgr := status.NewManager(r.ctrlClient)
+	originalStatus := svidSecret.Status.DeepCopy()
+	defer func() {
+		if !equality.Semantic.DeepEqual(originalStatus, &svidSecret.Status) {
+			statusMgr.RequestStatusUpdate()
+		}
+		if err := statusMgr.ApplyStatus(ctx, &svidSecret, func() *v1alpha1.ConditionalStatus {
+			return &svidSecret.Status.ConditionalStatus
+		}); err != nil {
+			r.log.Error(err, "failed to update status")
+		}
+	}()
+
+	var ztwim v1alpha1.ZeroTrustWorkloadIdentityManager
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: "cluster"}, &ztwim); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get ZeroTrustWorkloadIdentityManager")
+			statusMgr.AddCondition(v1alpha1.Ready, v1alpha1.ReasonFailed,
+				"Failed to retrieve ZeroTrustWorkloadIdentityManager from cluster",
+				metav1.ConditionFalse)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	namespaceLabels, policies, err := r.policyInputs(ctx, svidSecret.Namespace)
+	if err != nil {
+		r.log.Error(err, "failed to evaluate WorkloadIdentityPolicy resources", "namespace", svidSecret.Namespace)
+		statusMgr.AddCondition(PolicyCompliant, "PolicyEvaluationFailed", err.Error(), metav1.ConditionFalse)
+		return ctrl.Result{}, err
+	}
+	if err := workloadIdentityController.ValidateSVIDSecret(&svidSecret, namespaceLabels, policies); err != nil {
+		r.log.Info("SVIDSecret violates policy", "namespace", svidSecret.Namespace, "name", svidSecret.Name, "reason", err.Error())
+		statusMgr.AddCondition(PolicyCompliant, "PolicyViolation", err.Error(), metav1.ConditionFalse)
+		r.eventRecorder.Event(&svidSecret, corev1.EventTypeWarning, "PolicyViolation", err.Error())
+		// The private key must not stay available once the policy no longer allows it
+		if err := r.deleteSecret(ctx, &svidSecret, svidSecret.Status.SecretName); err != nil {
+			return ctrl.Result{}, err
+		}
+		statusMgr.RemoveCondition(SVIDIssued)
+		svidSecret.Status.SPIFFEID = ""
+		svidSecret.Status.SecretName = ""
+		svidSecret.Status.ExpiresAt = nil
+		svidSecret.Status.NextRotationTime = nil
+		return ctrl.Result{}, nil
+	}
+	statusMgr.AddCondition(PolicyCompliant, "PolicyAllowed",
+		"SVIDSecret is allowed by the policies of the namespace",
+		metav1.ConditionTrue)
+
+	id, err := svidSecretSPIFFEID(&svidSecret, ztwim.Spec.TrustDomain)
+	if err != nil {
+		r.log.Error(err, "invalid SVIDSecret configuration", "namespace", svidSecret.Namespace, "name", svidSecret.Name)
+		statusMgr.AddCondition(SVIDIssued, "InvalidSVIDSecretConfiguration", err.Error(), metav1.ConditionFalse)
+		return ctrl.Result{}, nil
+	}
+
+	// A renamed Secret must not leave the previous SVID behind
+	secretName := svidSecretName(&svidSecret)
+	if previous := svidSecret.Status.SecretName; previous != "" && previous != secretName {
+		if err := r.deleteSecret(ctx, &svidSecret, previous); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	existing := &corev1.Secret{}
+	err = r.ctrlClient.Get(ctx, types.NamespacedName{Name: secretName, Namespace: svidSecret.Namespace}, existing)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if err == nil {
+		if !utils.IsManagedBy(existing, &svidSecret) {
+			statusMgr.AddCondition(SVIDIssued, "SecretConflict",
+				fmt.Sprintf("Secret %s exists and is not managed by this SVIDSecret", secretName),
+				metav1.ConditionFalse)
+			return ctrl.Result{}, nil
+		}
+		if cert, ok := currentSVID(existing, &svidSecret); ok {
+			rotateAt := rotationTime(cert)
+			if time.Now().Before(rotateAt) {
+				setIssuedStatus(&svidSecret, id, secretName, cert, statusMgr)
+				return ctrl.Result{RequeueAfter: time.Until(rotateAt)}, nil
+			}
+		}
+	} else {
+		existing = nil
+	}
+
+	cert, err := r.issueSVID(ctx, &svidSecret, id, secretName, existing, statusMgr)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	setIssuedStatus(&svidSecret, id, secretName, cert, statusMgr)
+	return ctrl.Result{RequeueAfter: time.Until(rotationTime(cert))}, nil
+}
+
+// policyInputs returns the labels of the namespace and the WorkloadIdentityPolicy resources
+func (r *SVIDSecretReconciler) policyInputs(ctx context.Context, namespace string) (map[string]string, []v1alpha1.WorkloadIdentityPolicy, error) {
+	ns := &corev1.Namespace{}
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, nil, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	var policies v1alpha1.WorkloadIdentityPolicyList
+	if err := r.ctrlClient.List(ctx, &policies); err != nil {
+		return nil, nil, fmt.Errorf("failed to list WorkloadIdentityPolicy resources: %w", err)
+	}
+	return ns.Labels, policies.Items, nil
+}
+
+// issueSVID mints a new X509-SVID through the SPIRE server API and writes it into the Secret
+func (r *SVIDSecretReconciler) issueSVID(ctx context.Context, svidSecret *v1alpha1.SVIDSecret, id spiffeid.ID, secretName string, existing *corev1.Secret, statusMgr *status.Manager) (*x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		statusMgr.AddCondition(SVIDIssued, "KeyGenerationFailed", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		URIs:     []*url.URL{id.URL()},
+		DNSNames: svidSecret.Spec.DNSNames,
+	}, key)
+	if err != nil {
+		statusMgr.AddCondition(SVIDIssued, "KeyGenerationFailed", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+
+	apiClient, err := r.dialServerAPI()
+	if err != nil {
+		r.log.Error(err, "failed to connect to the SPIRE server API")
+		statusMgr.AddCondition(SVIDIssued, "ServerAPIUnavailable", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+	defer apiClient.Close()
+
+	var ttl time.Duration
+	if svidSecret.Spec.TTL != nil {
+		ttl = svidSecret.Spec.TTL.Duration
+	}
+	svid, err := apiClient.MintX509SVID(ctx, csr, ttl)
+	if err != nil {
+		r.log.Error(err, "failed to mint X509-SVID", "namespace", svidSecret.Namespace, "name", svidSecret.Name)
+		statusMgr.AddCondition(SVIDIssued, "SVIDMintFailed", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+	if len(svid.CertChain) == 0 {
+		err := fmt.Errorf("SPIRE server returned an X509-SVID without certificates")
+		statusMgr.AddCondition(SVIDIssued, "SVIDMintFailed", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(svid.CertChain[0])
+	if err != nil {
+		err = fmt.Errorf("SPIRE server returned an invalid X509-SVID: %w", err)
+		statusMgr.AddCondition(SVIDIssued, "SVIDMintFailed", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+	bundle, err := apiClient.GetBundle(ctx)
+	if err != nil {
+		r.log.Error(err, "failed to get trust bundle")
+		statusMgr.AddCondition(SVIDIssued, "BundleUnavailable", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		statusMgr.AddCondition(SVIDIssued, "KeyGenerationFailed", err.Error(), metav1.ConditionFalse)
+		return nil, err
+	}
+	authorities := make([][]byte, 0, len(bundle.X509Authorities))
+	for _, authority := range bundle.X509Authorities {
+		authorities = append(authorities, authority.Asn1)
+	}
+
+	desired := generateSVIDSecret(svidSecret, secretName, svid.CertChain, keyDER, authorities)
+	if err := controllerutil.SetControllerReference(svidSecret, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference on SVID secret")
+		statusMgr.AddCondition(SVIDIssued, "SecretUpdateFailed",
+			fmt.Sprintf("Failed to set owner reference on Secret: %v", err),
+			metav1.ConditionFalse)
+		return nil, err
+	}
+	if err := r.storeSVID(ctx, desired, existing, statusMgr); err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		r.eventRecorder.Eventf(svidSecret, corev1.EventTypeNormal, "SVIDIssued", "X509-SVID for %s written to Secret %s", id, secretName)
+	} else {
+		r.eventRecorder.Eventf(svidSecret, corev1.EventTypeNormal, "SVIDRotated", "X509-SVID for %s rotated in Secret %s", id, secretName)
+	}
+	r.log.Info("Wrote X509-SVID to Secret", "namespace", svidSecret.Namespace, "secret", secretName, "spiffeID", id.String(), "expiresAt", cert.NotAfter)
+	return cert, nil
+}
+
+// storeSVID creates the Secret, or replaces the data of the Secret owned by the SVIDSecret
+func (r *SVIDSecretReconciler) storeSVID(ctx context.Context, desired, existing *corev1.Secret, statusMgr *status.Manager) error {
+	if existing == nil {
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, SVIDIssued); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create SVID secret", "namespace", desired.Namespace, "name", desired.Name)
+			statusMgr.AddCondition(SVIDIssued, "SecretCreationFailed",
+				fmt.Sprintf("Failed to create Secret: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		return nil
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	if err := r.ctrlClient.Update(ctx, desired); err != nil {
+		r.log.Error(err, "failed to update SVID secret", "namespace", desired.Namespace, "name", desired.Name)
+		statusMgr.AddCondition(SVIDIssued, "SecretUpdateFailed",
+			fmt.Sprintf("Failed to update Secret: %v", err),
+			metav1.ConditionFalse)
+		return err
+	}
+	return nil
+}
+
+// deleteSecret removes the Secret when it was created for the SVIDSecret
+func (r *SVIDSecretReconciler) deleteSecret(ctx context.Context, svidSecret *v1alpha1.SVIDSecret, name string) error {
+	if name == "" {
+		return nil
+	}
+	secret := &corev1.Secret{}
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: name, Namespace: svidSecret.Namespace}, secret); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if !utils.IsManagedBy(secret, svidSecret) {
+		r.log.Info("Leaving Secret not created for the SVIDSecret in place", "namespace", secret.Namespace, "name", secret.Name)
+		return nil
+	}
+	if err := r.ctrlClient.Delete(ctx, secret); err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "failed to delete SVID secret", "namespace", secret.Namespace, "name", secret.Name)
+		return err
+	}
+	r.log.Info("Deleted SVID secret", "namespace", secret.Namespace, "name", secret.Name)
+	return nil
+}
+
+func setIssuedStatus(svidSecret *v1alpha1.SVIDSecret, id spiffeid.ID, secretName string, cert *x509.Certificate, statusMgr *status.Manager) {
+	expiresAt := metav1.NewTime(cert.NotAfter)
+	rotateAt := metav1.NewTime(rotationTime(cert))
+	svidSecret.Status.SPIFFEID = id.String()
+	svidSecret.Status.SecretName = secretName
+	svidSecret.Status.ExpiresAt = &expiresAt
+	svidSecret.Status.NextRotationTime = &rotateAt
+	statusMgr.AddCondition(SVIDIssued, "SVIDIssued",
+		fmt.Sprintf("X509-SVID stored in Secret %s, rotated at %s", secretName, rotateAt.UTC().Format(time.RFC3339)),
+		metav1.ConditionTrue)
+}
+
+// currentSVID returns the certificate in the Secret when it was minted for the current SVIDSecret spec
+func currentSVID(secret *corev1.Secret, svidSecret *v1alpha1.SVIDSecret) (*x509.Certificate, bool) {
+	if secret.Annotations[SVIDSecretGenerationAnnotation] != strconv.FormatInt(svidSecret.Generation, 10) {
+		return nil, false
+	}
+	if len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 || len(secret.Data[corev1.ServiceAccountRootCAKey]) == 0 {
+		return nil, false
+	}
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, false
+	}
+	return cert, true
+}
+
+// rotationTime is when half of the lifetime of the certificate has passed
+func rotationTime(cert *x509.Certificate) time.Time {
+	return cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) / 2)
+}
+
+// svidSecretSPIFFEID returns the SPIFFE ID requested by the SVIDSecret in the trust domain
+func svidSecretSPIFFEID(svidSecret *v1alpha1.SVIDSecret, trustDomainName string) (spiffeid.ID, error) {
+	trustDomain, err := spiffeid.TrustDomainFromString(trustDomainName)
+	if err != nil {
+		return spiffeid.ID{}, fmt.Errorf("invalid trust domain %q: %w", trustDomainName, err)
+	}
+	id, err := spiffeid.FromPath(trustDomain, svidSecret.Spec.SPIFFEIDPath)
+	if err != nil {
+		return spiffeid.ID{}, fmt.Errorf("invalid spiffeIDPath %q: %w", svidSecret.Spec.SPIFFEIDPath, err)
+	}
+	return id, nil
+}
+
+func svidSecretName(svidSecret *v1alpha1.SVIDSecret) string {
+	if svidSecret.Spec.SecretName != "" {
+		return svidSecret.Spec.SecretName
+	}
+	return svidSecret.Name
+}
+
+// generateSVIDSecret returns the kubernetes.io/tls Secret holding the X509-SVID, its key and the trust bundle
+func generateSVIDSecret(svidSecret *v1alpha1.SVIDSecret, name string, certChain [][]byte, keyDER []byte, authorities [][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: svidSecret.Namespace,
+			Labels:    utils.StandardizedLabels("svid-secret", utils.ComponentControlPlane, version.SpireServerVersion, nil),
+			Annotations: map[string]string{
+				SVIDSecretGenerationAnnotation: strconv.FormatInt(svidSecret.Generation, 10),
+			},
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:              encodePEM("CERTIFICATE", certChain...),
+			corev1.TLSPrivateKeyKey:        encodePEM("PRIVATE KEY", keyDER),
+			corev1.ServiceAccountRootCAKey: encodePEM("CERTIFICATE", authorities...),
+		},
+	}
+}
+
+func encodePEM(blockType string, ders ...[]byte) []byte {
+	var out []byte
+	for _, der := range ders {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})...)
+	}
+	return out
+}
+
+func (r *SVIDSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Policies can change what any namespace may request, so every SVIDSecret is checked again
+	policyMapFunc := func(ctx context.Context, _ client.Object) []reconcile.Request {
+		var svidSecrets v1alpha1.SVIDSecretList
+		if err := r.ctrlClient.List(ctx, &svidSecrets); err != nil {
+			r.log.Error(err, "failed to list SVIDSecret resources")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(svidSecrets.Items))
+		for _, svidSecret := range svidSecrets.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&svidSecret)})
+		}
+		return requests
+	}
+
+	// Relabeling a namespace can change which policies select it
+	namespaceMapFunc := func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var svidSecrets v1alpha1.SVIDSecretList
+		if err := r.ctrlClient.List(ctx, &svidSecrets, client.InNamespace(obj.GetName())); err != nil {
+			r.log.Error(err, "failed to list SVIDSecret resources", "namespace", obj.GetName())
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(svidSecrets.Items))
+		for _, svidSecret := range svidSecrets.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&svidSecret)})
+		}
+		return requests
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.SVIDSecret{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named(utils.ZeroTrustWorkloadIdentityManagerSVIDSecretControllerName).
+		Owns(&corev1.Secret{}).
+		Watches(&v1alpha1.WorkloadIdentityPolicy{}, handler.EnqueueRequestsFromMapFunc(policyMapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(namespaceMapFunc), builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Complete(r)
+}
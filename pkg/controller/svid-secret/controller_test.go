@@ -0,0 +1,453 @@
+package svid_secret
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	spiretypes "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	spireapifakes "github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi/fakes"
+)
+
+// newFakeServerAPI returns a fake SPIRE server API signing CSRs with an in-memory CA, and the CA certificate
+func newFakeServerAPI(t *testing.T) (*spireapifakes.FakeClient, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{Organization: []string{"SPIFFE"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	api := &spireapifakes.FakeClient{}
+	serial := int64(1)
+	api.MintX509SVIDStub = func(_ context.Context, csrDER []byte, ttl time.Duration) (*spiretypes.X509SVID, error) {
+		csr, err := x509.ParseCertificateRequest(csrDER)
+		if err != nil {
+			return nil, err
+		}
+		if ttl == 0 {
+			ttl = time.Hour
+		}
+		serial++
+		now := time.Now().Add(-time.Second)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			URIs:         csr.URIs,
+			DNSNames:     csr.DNSNames,
+			NotBefore:    now,
+			NotAfter:     now.Add(ttl),
+		}, caCert, csr.PublicKey, key)
+		if err != nil {
+			return nil, err
+		}
+		return &spiretypes.X509SVID{CertChain: [][]byte{der}, ExpiresAt: now.Add(ttl).Unix()}, nil
+	}
+	api.GetBundleReturns(&spiretypes.Bundle{X509Authorities: []*spiretypes.X509Certificate{{Asn1: caCert.Raw}}}, nil)
+	return api, caCert
+}
+
+func newTestReconciler(fakeClient *fakes.FakeCustomCtrlClient, api spireapi.Client) *SVIDSecretReconciler {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+	return &SVIDSecretReconciler{
+		ctrlClient:    fakeClient,
+		ctx:           context.Background(),
+		eventRecorder: record.NewFakeRecorder(100),
+		log:           logr.Discard(),
+		scheme:        scheme,
+		dialServerAPI: func() (spireapi.Client, error) {
+			if api == nil {
+				return nil, errors.New("socket not available")
+			}
+			return api, nil
+		},
+	}
+}
+
+func newTestSVIDSecret(spec v1alpha1.SVIDSecretSpec) *v1alpha1.SVIDSecret {
+	if spec.SPIFFEIDPath == "" {
+		spec.SPIFFEIDPath = "/ns/payments/ingress"
+	}
+	return &v1alpha1.SVIDSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "payments", UID: "test-uid", Generation: 1},
+		Spec:       spec,
+	}
+}
+
+var managedLabels = map[string]string{utils.AppManagedByLabelKey: utils.AppManagedByLabelValue}
+
+var allowingPolicy = v1alpha1.WorkloadIdentityPolicy{
+	ObjectMeta: metav1.ObjectMeta{Name: "all"},
+	Spec:       v1alpha1.WorkloadIdentityPolicySpec{AllowSVIDSecrets: "true"},
+}
+
+// stubClient serves the SVIDSecret, the ZTWIM, the namespace, the policies and optionally the Secret
+func stubClient(fakeClient *fakes.FakeCustomCtrlClient, svidSecret *v1alpha1.SVIDSecret, secret *corev1.Secret, policies ...v1alpha1.WorkloadIdentityPolicy) {
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		switch o := obj.(type) {
+		case *v1alpha1.SVIDSecret:
+			svidSecret.DeepCopyInto(o)
+		case *v1alpha1.ZeroTrustWorkloadIdentityManager:
+			o.Name = "cluster"
+			o.Spec.TrustDomain = "example.com"
+		case *corev1.Secret:
+			if secret == nil {
+				return kerrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, key.Name)
+			}
+			secret.DeepCopyInto(o)
+		case *corev1.Namespace:
+			o.Name = key.Name
+		}
+		return nil
+	}
+	fakeClient.ListStub = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+		if l, ok := list.(*v1alpha1.WorkloadIdentityPolicyList); ok {
+			l.Items = policies
+		}
+		return nil
+	}
+}
+
+// lastStatus returns the SVIDSecret passed to the last status update
+func lastStatus(t *testing.T, fakeClient *fakes.FakeCustomCtrlClient) *v1alpha1.SVIDSecret {
+	t.Helper()
+	require.Positive(t, fakeClient.StatusUpdateWithRetryCallCount())
+	_, obj, _ := fakeClient.StatusUpdateWithRetryArgsForCall(fakeClient.StatusUpdateWithRetryCallCount() - 1)
+	svidSecret, ok := obj.(*v1alpha1.SVIDSecret)
+	require.True(t, ok)
+	return svidSecret
+}
+
+func request() ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "payments", Name: "ingress"}}
+}
+
+func TestReconcile_WritesSecret(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api, caCert := newFakeServerAPI(t)
+	reconciler := newTestReconciler(fakeClient, api)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{
+		SecretName: "ingress-tls",
+		DNSNames:   []string{"gateway.payments.svc"},
+		TTL:        &metav1.Duration{Duration: 2 * time.Hour},
+	})
+	stubClient(fakeClient, svidSecret, nil, allowingPolicy)
+
+	result, err := reconciler.Reconcile(context.Background(), request())
+
+	require.NoError(t, err)
+	assert.InDelta(t, time.Hour, result.RequeueAfter, float64(time.Minute))
+	require.Equal(t, 1, api.MintX509SVIDCallCount())
+	_, _, ttl := api.MintX509SVIDArgsForCall(0)
+	assert.Equal(t, 2*time.Hour, ttl)
+
+	require.Equal(t, 1, fakeClient.CreateCallCount())
+	_, obj, _ := fakeClient.CreateArgsForCall(0)
+	secret := obj.(*corev1.Secret)
+	assert.Equal(t, "ingress-tls", secret.Name)
+	assert.Equal(t, "payments", secret.Namespace)
+	assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+	assert.True(t, metav1.IsControlledBy(secret, svidSecret))
+
+	block, _ := pem.Decode(secret.Data[corev1.TLSCertKey])
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	require.Len(t, cert.URIs, 1)
+	assert.Equal(t, "spiffe://example.com/ns/payments/ingress", cert.URIs[0].String())
+	assert.Equal(t, []string{"gateway.payments.svc"}, cert.DNSNames)
+
+	keyBlock, _ := pem.Decode(secret.Data[corev1.TLSPrivateKeyKey])
+	require.NotNil(t, keyBlock)
+	key, err := x509.ParsePKCS8PrivateKey(keyBlock.Bytes)
+	require.NoError(t, err)
+	assert.True(t, key.(*ecdsa.PrivateKey).PublicKey.Equal(cert.PublicKey))
+
+	caBlock, _ := pem.Decode(secret.Data[corev1.ServiceAccountRootCAKey])
+	require.NotNil(t, caBlock)
+	assert.Equal(t, caCert.Raw, caBlock.Bytes)
+
+	status := lastStatus(t, fakeClient)
+	assert.Equal(t, "spiffe://example.com/ns/payments/ingress", status.Status.SPIFFEID)
+	assert.Equal(t, "ingress-tls", status.Status.SecretName)
+	require.NotNil(t, status.Status.ExpiresAt)
+	require.NotNil(t, status.Status.NextRotationTime)
+	assert.True(t, status.Status.NextRotationTime.Before(status.Status.ExpiresAt))
+	condition := apimeta.FindStatusCondition(status.Status.Conditions, SVIDIssued)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+}
+
+// issuedSecret returns the Secret written for the SVIDSecret by a first reconcile
+func issuedSecret(t *testing.T, api *spireapifakes.FakeClient, svidSecret *v1alpha1.SVIDSecret) *corev1.Secret {
+	t.Helper()
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, svidSecret, nil, allowingPolicy)
+	_, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.CreateCallCount())
+	_, obj, _ := fakeClient.CreateArgsForCall(0)
+	return obj.(*corev1.Secret)
+}
+
+func TestReconcile_KeepsCurrentSVID(t *testing.T) {
+	api, _ := newFakeServerAPI(t)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+	secret := issuedSecret(t, api, svidSecret)
+
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, svidSecret, secret, allowingPolicy)
+	result, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, api.MintX509SVIDCallCount())
+	assert.Zero(t, fakeClient.UpdateCallCount())
+	assert.InDelta(t, 30*time.Minute, result.RequeueAfter, float64(time.Minute))
+}
+
+func TestReconcile_RotatesSVID(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(*v1alpha1.SVIDSecret, *corev1.Secret)
+	}{
+		{
+			name: "spec changed",
+			mutate: func(svidSecret *v1alpha1.SVIDSecret, _ *corev1.Secret) {
+				svidSecret.Generation = 2
+				svidSecret.Spec.DNSNames = []string{"api.payments.svc"}
+			},
+		},
+		{
+			name: "rotation time passed",
+			mutate: func(_ *v1alpha1.SVIDSecret, secret *corev1.Secret) {
+				now := time.Now()
+				der, _ := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+					SerialNumber: big.NewInt(10),
+					NotBefore:    now.Add(-time.Hour),
+					NotAfter:     now.Add(10 * time.Minute),
+				}, &x509.Certificate{SerialNumber: big.NewInt(11)}, &secretKey(secret).PublicKey, secretKey(secret))
+				secret.Data[corev1.TLSCertKey] = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+			},
+		},
+		{
+			name: "certificate missing",
+			mutate: func(_ *v1alpha1.SVIDSecret, secret *corev1.Secret) {
+				delete(secret.Data, corev1.TLSCertKey)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, _ := newFakeServerAPI(t)
+			svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+			secret := issuedSecret(t, api, svidSecret)
+			secret.ResourceVersion = "7"
+			tt.mutate(svidSecret, secret)
+
+			fakeClient := &fakes.FakeCustomCtrlClient{}
+			stubClient(fakeClient, svidSecret, secret, allowingPolicy)
+			_, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+
+			require.NoError(t, err)
+			assert.Equal(t, 2, api.MintX509SVIDCallCount())
+			require.Equal(t, 1, fakeClient.UpdateCallCount())
+			_, obj, _ := fakeClient.UpdateArgsForCall(0)
+			updated := obj.(*corev1.Secret)
+			assert.Equal(t, "7", updated.ResourceVersion)
+			assert.Equal(t, strconv.FormatInt(svidSecret.Generation, 10), updated.Annotations[SVIDSecretGenerationAnnotation])
+		})
+	}
+}
+
+func secretKey(secret *corev1.Secret) *ecdsa.PrivateKey {
+	block, _ := pem.Decode(secret.Data[corev1.TLSPrivateKeyKey])
+	key, _ := x509.ParsePKCS8PrivateKey(block.Bytes)
+	return key.(*ecdsa.PrivateKey)
+}
+
+func TestReconcile_PolicyViolationDeletesSecret(t *testing.T) {
+	api, _ := newFakeServerAPI(t)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+	secret := issuedSecret(t, api, svidSecret)
+	svidSecret.Status.SecretName = secret.Name
+
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, svidSecret, secret)
+	_, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, api.MintX509SVIDCallCount())
+	require.Equal(t, 1, fakeClient.DeleteCallCount())
+	_, obj, _ := fakeClient.DeleteArgsForCall(0)
+	assert.Equal(t, secret.Name, obj.GetName())
+
+	status := lastStatus(t, fakeClient)
+	assert.Empty(t, status.Status.SecretName)
+	condition := apimeta.FindStatusCondition(status.Status.Conditions, PolicyCompliant)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Contains(t, condition.Message, "no WorkloadIdentityPolicy allows SVIDSecrets")
+}
+
+func TestReconcile_DoesNotOverwriteForeignSecret(t *testing.T) {
+	api, _ := newFakeServerAPI(t)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+	foreign := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "payments"}}
+
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, svidSecret, foreign, allowingPolicy)
+	_, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+
+	require.NoError(t, err)
+	assert.Zero(t, api.MintX509SVIDCallCount())
+	assert.Zero(t, fakeClient.UpdateCallCount())
+	condition := apimeta.FindStatusCondition(lastStatus(t, fakeClient).Status.Conditions, SVIDIssued)
+	require.NotNil(t, condition)
+	assert.Equal(t, "SecretConflict", condition.Reason)
+}
+
+func TestReconcile_DoesNotDeleteAdoptedSecret(t *testing.T) {
+	api, _ := newFakeServerAPI(t)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+	svidSecret.Status.SecretName = "ingress"
+	// An existing Secret a user pointed at the SVIDSecret is not one the operator created
+	adopted := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "payments"}}
+	require.NoError(t, controllerutil.SetControllerReference(svidSecret, adopted, newTestReconciler(nil, nil).scheme))
+
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, svidSecret, adopted)
+	_, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+
+	require.NoError(t, err)
+	assert.Zero(t, fakeClient.DeleteCallCount())
+}
+
+func TestReconcile_DoesNotOverwriteAdoptedSecret(t *testing.T) {
+	api, _ := newFakeServerAPI(t)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+	adopted := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "payments"}}
+	require.NoError(t, controllerutil.SetControllerReference(svidSecret, adopted, newTestReconciler(nil, nil).scheme))
+
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, svidSecret, adopted, allowingPolicy)
+	_, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+
+	require.NoError(t, err)
+	assert.Zero(t, api.MintX509SVIDCallCount())
+	assert.Zero(t, fakeClient.UpdateCallCount())
+	condition := apimeta.FindStatusCondition(lastStatus(t, fakeClient).Status.Conditions, SVIDIssued)
+	require.NotNil(t, condition)
+	assert.Equal(t, "SecretConflict", condition.Reason)
+}
+
+func TestReconcile_DeletesRenamedSecret(t *testing.T) {
+	api, _ := newFakeServerAPI(t)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+	previous := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "old-tls", Namespace: "payments", Labels: managedLabels}}
+	require.NoError(t, controllerutil.SetControllerReference(svidSecret, previous, newTestReconciler(nil, nil).scheme))
+	svidSecret.Status.SecretName = "old-tls"
+
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	stubClient(fakeClient, svidSecret, nil, allowingPolicy)
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		switch o := obj.(type) {
+		case *v1alpha1.SVIDSecret:
+			svidSecret.DeepCopyInto(o)
+		case *v1alpha1.ZeroTrustWorkloadIdentityManager:
+			o.Spec.TrustDomain = "example.com"
+		case *corev1.Secret:
+			if key.Name != "old-tls" {
+				return kerrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, key.Name)
+			}
+			previous.DeepCopyInto(o)
+		}
+		return nil
+	}
+	_, err := newTestReconciler(fakeClient, api).Reconcile(context.Background(), request())
+
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.DeleteCallCount())
+	_, obj, _ := fakeClient.DeleteArgsForCall(0)
+	assert.Equal(t, "old-tls", obj.GetName())
+	require.Equal(t, 1, fakeClient.CreateCallCount())
+	_, obj, _ = fakeClient.CreateArgsForCall(0)
+	assert.Equal(t, "ingress", obj.GetName())
+}
+
+func TestReconcile_ServerAPIUnavailable(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient, nil)
+	stubClient(fakeClient, newTestSVIDSecret(v1alpha1.SVIDSecretSpec{}), nil, allowingPolicy)
+
+	_, err := reconciler.Reconcile(context.Background(), request())
+
+	require.Error(t, err)
+	assert.Zero(t, fakeClient.CreateCallCount())
+	condition := apimeta.FindStatusCondition(lastStatus(t, fakeClient).Status.Conditions, SVIDIssued)
+	require.NotNil(t, condition)
+	assert.Equal(t, "ServerAPIUnavailable", condition.Reason)
+}
+
+func TestReconcile_PolicyEvaluationFailureKeepsSecret(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	api, _ := newFakeServerAPI(t)
+	reconciler := newTestReconciler(fakeClient, api)
+	svidSecret := newTestSVIDSecret(v1alpha1.SVIDSecretSpec{})
+	svidSecret.Status.SecretName = "ingress"
+	stubClient(fakeClient, svidSecret, nil, allowingPolicy)
+	getStub := fakeClient.GetStub
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		if _, ok := obj.(*corev1.Namespace); ok {
+			return errors.New("apiserver unavailable")
+		}
+		return getStub(ctx, key, obj)
+	}
+
+	_, err := reconciler.Reconcile(context.Background(), request())
+
+	require.Error(t, err)
+	assert.Zero(t, fakeClient.DeleteCallCount())
+	condition := apimeta.FindStatusCondition(lastStatus(t, fakeClient).Status.Conditions, PolicyCompliant)
+	require.NotNil(t, condition)
+	assert.Equal(t, "PolicyEvaluationFailed", condition.Reason)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// ExposureClient is the part of the operator client reconciling the objects publishing an endpoint
+type ExposureClient interface {
+	Get(context.Context, client.ObjectKey, client.Object) error
+	UncachedGet(context.Context, client.ObjectKey, client.Object) error
+	Create(context.Context, client.Object, ...client.CreateOption) error
+	Update(context.Context, client.Object, ...client.UpdateOption) error
+}
+
+// GetGatewayObject reads a Gateway API object from the cache when its kind was served at startup,
+// and from the API server otherwise, which reports a kind that is not installed as a no match error
+func GetGatewayObject(ctx context.Context, c ExposureClient, servedKinds []schema.GroupVersionKind, key client.ObjectKey, obj *unstructured.Unstructured) error {
+	if slices.Contains(servedKinds, obj.GroupVersionKind()) {
+		return c.Get(ctx, key, obj)
+	}
+	return c.UncachedGet(ctx, key, obj)
+}
+
+// ReconcileExposureBackendTLS creates or updates the ConfigMap holding the service CA and the
+// BackendTLSPolicy of a reencrypt HTTPRoute, so that the Gateway connects to the backend with TLS
+// and verifies its certificate
+func ReconcileExposureBackendTLS(ctx context.Context, c ExposureClient, scheme *runtime.Scheme, owner client.Object, endpoint ExposedEndpoint, servedKinds []schema.GroupVersionKind, createOnlyMode bool) error {
+	serviceCA, err := os.ReadFile(ServiceCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the service CA bundle: %w", err)
+	}
+
+	desiredConfigMap := GenerateExposureBackendCAConfigMap(endpoint, serviceCA)
+	if err := controllerutil.SetControllerReference(owner, desiredConfigMap, scheme); err != nil {
+		return fmt.Errorf("failed to set owner reference on ConfigMap: %w", err)
+	}
+	existingConfigMap := &corev1.ConfigMap{}
+	err = c.Get(ctx, types.NamespacedName{Name: desiredConfigMap.Name, Namespace: desiredConfigMap.Namespace}, existingConfigMap)
+	switch {
+	case kerrors.IsNotFound(err):
+		if err := c.Create(ctx, desiredConfigMap); err != nil {
+			return fmt.Errorf("failed to create ConfigMap %s: %w", desiredConfigMap.Name, err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to get ConfigMap %s: %w", desiredConfigMap.Name, err)
+	default:
+		if err := CheckResourceConflict(existingConfigMap); err != nil {
+			return err
+		}
+		if !createOnlyMode && (!equality.Semantic.DeepEqual(existingConfigMap.Data, desiredConfigMap.Data) ||
+			!equality.Semantic.DeepEqual(existingConfigMap.Labels, desiredConfigMap.Labels)) {
+			desiredConfigMap.ResourceVersion = existingConfigMap.ResourceVersion
+			if err := c.Update(ctx, desiredConfigMap); err != nil {
+				return fmt.Errorf("failed to update ConfigMap %s: %w", desiredConfigMap.Name, err)
+			}
+		}
+	}
+
+	desiredPolicy := GenerateExposureBackendTLSPolicy(endpoint, BackendTLSPolicyGVK(servedKinds))
+	if err := controllerutil.SetControllerReference(owner, desiredPolicy, scheme); err != nil {
+		return fmt.Errorf("failed to set owner reference on BackendTLSPolicy: %w", err)
+	}
+	existingPolicy := &unstructured.Unstructured{}
+	existingPolicy.SetGroupVersionKind(desiredPolicy.GroupVersionKind())
+	err = GetGatewayObject(ctx, c, servedKinds, types.NamespacedName{Name: desiredPolicy.GetName(), Namespace: desiredPolicy.GetNamespace()}, existingPolicy)
+	switch {
+	case kerrors.IsNotFound(err):
+		if err := c.Create(ctx, desiredPolicy); err != nil {
+			return fmt.Errorf("failed to create BackendTLSPolicy %s: %w", desiredPolicy.GetName(), err)
+		}
+	case err != nil:
+		return err
+	default:
+		if err := CheckResourceConflict(existingPolicy); err != nil {
+			return err
+		}
+		if !createOnlyMode && GatewayObjectNeedsUpdate(existingPolicy, desiredPolicy) {
+			desiredPolicy.SetResourceVersion(existingPolicy.GetResourceVersion())
+			if existingStatus, ok := existingPolicy.Object["status"]; ok {
+				desiredPolicy.Object["status"] = existingStatus
+			}
+			if err := c.Update(ctx, desiredPolicy); err != nil {
+				return fmt.Errorf("failed to update BackendTLSPolicy %s: %w", desiredPolicy.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+// fakeExposureClient serves objects from memory, keyed by kind and name, and records the
+// cached and uncached reads
+type fakeExposureClient struct {
+	objects      map[string]client.Object
+	created      []client.Object
+	updated      []client.Object
+	cachedGets   int
+	uncachedGets int
+}
+
+func exposureObjectKey(obj client.Object, name string) string {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.GetKind() + "/" + name
+	}
+	return "ConfigMap/" + name
+}
+
+func (f *fakeExposureClient) get(key client.ObjectKey, obj client.Object) error {
+	existing, ok := f.objects[exposureObjectKey(obj, key.Name)]
+	if !ok {
+		return kerrors.NewNotFound(schema.GroupResource{}, key.Name)
+	}
+	switch o := obj.(type) {
+	case *unstructured.Unstructured:
+		existing.(*unstructured.Unstructured).DeepCopyInto(o)
+	case *corev1.ConfigMap:
+		existing.(*corev1.ConfigMap).DeepCopyInto(o)
+	}
+	return nil
+}
+
+func (f *fakeExposureClient) Get(_ context.Context, key client.ObjectKey, obj client.Object) error {
+	f.cachedGets++
+	return f.get(key, obj)
+}
+
+func (f *fakeExposureClient) UncachedGet(_ context.Context, key client.ObjectKey, obj client.Object) error {
+	f.uncachedGets++
+	return f.get(key, obj)
+}
+
+func (f *fakeExposureClient) Create(_ context.Context, obj client.Object, _ ...client.CreateOption) error {
+	f.created = append(f.created, obj)
+	f.objects[exposureObjectKey(obj, obj.GetName())] = obj
+	return nil
+}
+
+func (f *fakeExposureClient) Update(_ context.Context, obj client.Object, _ ...client.UpdateOption) error {
+	f.updated = append(f.updated, obj)
+	f.objects[exposureObjectKey(obj, obj.GetName())] = obj
+	return nil
+}
+
+func TestReconcileExposureBackendTLS(t *testing.T) {
+	serviceCAFile := filepath.Join(t.TempDir(), "service-ca.crt")
+	previous := ServiceCAFile
+	ServiceCAFile = serviceCAFile
+	t.Cleanup(func() { ServiceCAFile = previous })
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, v1alpha1.AddToScheme(scheme))
+	owner := &v1alpha1.SpireServer{ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "server-uid"}}
+	endpoint := testExposedEndpoint(false)
+	ctx := context.Background()
+
+	t.Run("fails without the service CA", func(t *testing.T) {
+		c := &fakeExposureClient{objects: map[string]client.Object{}}
+		err := ReconcileExposureBackendTLS(ctx, c, scheme, owner, endpoint, nil, false)
+		require.ErrorContains(t, err, "failed to read the service CA bundle")
+	})
+
+	require.NoError(t, os.WriteFile(serviceCAFile, []byte("first CA"), 0o600))
+	c := &fakeExposureClient{objects: map[string]client.Object{}}
+	servedKinds := []schema.GroupVersionKind{HTTPRouteGVK, BackendTLSPolicyGVKs[1]}
+
+	t.Run("creates the CA ConfigMap and the policy", func(t *testing.T) {
+		require.NoError(t, ReconcileExposureBackendTLS(ctx, c, scheme, owner, endpoint, servedKinds, false))
+
+		require.Len(t, c.created, 2)
+		assert.Equal(t, "first CA", c.created[0].(*corev1.ConfigMap).Data["ca.crt"])
+		policy := c.created[1].(*unstructured.Unstructured)
+		assert.Equal(t, BackendTLSPolicyGVKs[1], policy.GroupVersionKind(), "the served version must be used")
+		require.Len(t, policy.GetOwnerReferences(), 1)
+		assert.Zero(t, c.uncachedGets, "served kinds must be read from the cache")
+	})
+
+	t.Run("updates the ConfigMap when the service CA rotates", func(t *testing.T) {
+		require.NoError(t, ReconcileExposureBackendTLS(ctx, c, scheme, owner, endpoint, servedKinds, false))
+		assert.Empty(t, c.updated)
+
+		require.NoError(t, os.WriteFile(serviceCAFile, []byte("second CA"), 0o600))
+		require.NoError(t, ReconcileExposureBackendTLS(ctx, c, scheme, owner, endpoint, servedKinds, false))
+		require.Len(t, c.updated, 1)
+		assert.Equal(t, "second CA", c.updated[0].(*corev1.ConfigMap).Data["ca.crt"])
+	})
+
+	t.Run("leaves a foreign policy alone", func(t *testing.T) {
+		foreign := GenerateExposureBackendTLSPolicy(endpoint, BackendTLSPolicyGVKs[1])
+		foreign.SetLabels(nil)
+		c.objects[exposureObjectKey(foreign, foreign.GetName())] = foreign
+		c.updated = nil
+
+		require.Error(t, ReconcileExposureBackendTLS(ctx, c, scheme, owner, endpoint, servedKinds, false))
+		assert.Empty(t, c.updated)
+	})
+}
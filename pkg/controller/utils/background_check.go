@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"sync"
+	"time"
+)
+
+// BackgroundCheckPollInterval is how soon Reconcile looks again for the result of a running check
+const BackgroundCheckPollInterval = 5 * time.Second
+
+// CheckResult is the outcome of a completed background check
+type CheckResult[T any] struct {
+	Value T
+	Err   error
+	// CheckedAt is when the check completed
+	CheckedAt time.Time
+}
+
+// BackgroundCheck runs a network check outside of Reconcile, which must not block
+// on slow or unreachable endpoints, and keeps the result of the last completed run
+type BackgroundCheck[T any] struct {
+	mu      sync.Mutex
+	running bool
+	// key identifies the inputs of the last result, a result for other inputs is never returned
+	key  string
+	last *CheckResult[T]
+}
+
+// Poll returns the last result of the check for key, or nil until a run for key completed. A new run
+// is started with start when none is running and there is no result for key younger than maxAge.
+func (c *BackgroundCheck[T]) Poll(key string, maxAge time.Duration, start func(func()), run func() (T, error)) *CheckResult[T] {
+	c.mu.Lock()
+	if c.key != key {
+		c.key, c.last = key, nil
+	}
+	startRun := !c.running && (c.last == nil || time.Since(c.last.CheckedAt) >= maxAge)
+	if startRun {
+		c.running = true
+	}
+	c.mu.Unlock()
+
+	if startRun {
+		start(func() {
+			value, err := run()
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.running = false
+			if c.key == key {
+				c.last = &CheckResult[T]{Value: value, Err: err, CheckedAt: time.Now()}
+			}
+		})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.key != key || c.last == nil {
+		return nil
+	}
+	result := *c.last
+	return &result
+}
+
+// Reset forgets the last result, so that the next poll starts a new run
+func (c *BackgroundCheck[T]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.key, c.last = "", nil
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackgroundCheck(t *testing.T) {
+	var check BackgroundCheck[string]
+	var pending []func()
+	start := func(f func()) { pending = append(pending, f) }
+	runs := 0
+	run := func() (string, error) {
+		runs++
+		return "ok", nil
+	}
+
+	assert.Nil(t, check.Poll("a", time.Hour, start, run), "no result before the first run completes")
+	assert.Nil(t, check.Poll("a", time.Hour, start, run))
+	require.Len(t, pending, 1, "a second run must not start while one is running")
+
+	pending[0]()
+	result := check.Poll("a", time.Hour, start, run)
+	require.NotNil(t, result)
+	assert.Equal(t, "ok", result.Value)
+	assert.Len(t, pending, 1, "a fresh result must not be checked again")
+
+	assert.Nil(t, check.Poll("b", time.Hour, start, func() (string, error) { return "", errors.New("refused") }),
+		"the result for other inputs must not be returned")
+	require.Len(t, pending, 2)
+	pending[1]()
+	result = check.Poll("b", time.Hour, start, run)
+	require.NotNil(t, result)
+	assert.EqualError(t, result.Err, "refused")
+
+	assert.NotNil(t, check.Poll("b", 0, start, run), "the last result is returned while a new run is started")
+	assert.Len(t, pending, 3)
+
+	check.Reset()
+	assert.Nil(t, check.Poll("b", time.Hour, start, run))
+	assert.Equal(t, 1, runs)
+}
+
+func TestBackgroundCheckDiscardsStaleRun(t *testing.T) {
+	var check BackgroundCheck[string]
+	var pending []func()
+	start := func(f func()) { pending = append(pending, f) }
+
+	check.Poll("a", time.Hour, start, func() (string, error) { return "a", nil })
+	check.Poll("b", time.Hour, start, func() (string, error) { return "b", nil })
+	require.Len(t, pending, 1, "a new run waits for the running one")
+
+	pending[0]()
+	assert.Nil(t, check.Poll("b", time.Hour, start, func() (string, error) { return "b", nil }), "the run for a must not be reported for b")
+	require.Len(t, pending, 2)
+	pending[1]()
+	assert.Equal(t, "b", check.Poll("b", time.Hour, start, nil).Value)
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// clusterDomain is the cluster domain spire-controller-manager renders ClusterSPIFFEID templates with
+const clusterDomain = "cluster.local"
+
+// sampleUID is the UID of the sample pod and node templates are checked against
+const sampleUID = "00000000-0000-0000-0000-000000000000"
+
+// TemplateData mirrors the data spire-controller-manager renders ClusterSPIFFEID templates with
+type TemplateData struct {
+	TrustDomain   string
+	ClusterName   string
+	ClusterDomain string
+	PodMeta       *metav1.ObjectMeta
+	PodSpec       *corev1.PodSpec
+	NodeMeta      *metav1.ObjectMeta
+	NodeSpec      *corev1.NodeSpec
+}
+
+// PodTemplateData returns the data spire-controller-manager renders the templates with for the pod on the node
+func PodTemplateData(trustDomain, clusterName string, pod *corev1.Pod, node *corev1.Node) *TemplateData {
+	return &TemplateData{
+		TrustDomain:   trustDomain,
+		ClusterName:   clusterName,
+		ClusterDomain: clusterDomain,
+		PodMeta:       &pod.ObjectMeta,
+		PodSpec:       &pod.Spec,
+		NodeMeta:      &node.ObjectMeta,
+		NodeSpec:      &node.Spec,
+	}
+}
+
+// SampleTemplateData returns the data for a sample pod running as the service account in the namespace,
+// used to check templates before any pod they select exists
+func SampleTemplateData(trustDomain, clusterName, namespace, serviceAccountName string) *TemplateData {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "sample", Namespace: namespace, UID: sampleUID},
+		Spec:       corev1.PodSpec{ServiceAccountName: serviceAccountName, NodeName: "sample"},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "sample", UID: sampleUID}}
+	return PodTemplateData(trustDomain, clusterName, pod, node)
+}
+
+// RenderTemplate executes a parsed ClusterSPIFFEID template with the data
+func RenderTemplate(tmpl *template.Template, data *TemplateData) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// RenderSampleSPIFFEID renders the SPIFFE ID template for the sample data and checks it stays in the trust domain
+func RenderSampleSPIFFEID(tmpl *template.Template, data *TemplateData) (string, error) {
+	rendered, err := RenderTemplate(tmpl, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to render: %w", err)
+	}
+	if prefix := "spiffe://" + data.TrustDomain + "/"; !strings.HasPrefix(rendered, prefix) {
+		return "", fmt.Errorf("must render a SPIFFE ID starting with %q, got %q", prefix, rendered)
+	}
+	return rendered, nil
+}
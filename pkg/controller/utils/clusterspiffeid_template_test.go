@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderSampleSPIFFEID(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "pod identity",
+			template: "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+			want:     "spiffe://example.org/ns/demo/sa/workload",
+		},
+		{
+			name:     "node and cluster data",
+			template: "spiffe://{{ .TrustDomain }}/{{ .ClusterName }}/{{ .ClusterDomain }}/{{ .NodeMeta.Name }}/{{ .PodMeta.UID }}",
+			want:     "spiffe://example.org/cluster/cluster.local/sample/00000000-0000-0000-0000-000000000000",
+		},
+		{
+			name:     "other trust domain",
+			template: "spiffe://other.org/ns/{{ .PodMeta.Namespace }}",
+			wantErr:  `must render a SPIFFE ID starting with "spiffe://example.org/", got "spiffe://other.org/ns/demo"`,
+		},
+		{
+			name:     "missing field",
+			template: "spiffe://{{ .TrustDomain }}/{{ .PodMeta.Missing }}",
+			wantErr:  "failed to render",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := template.Must(template.New("spiffeID").Parse(tt.template))
+			got, err := RenderSampleSPIFFEID(tmpl, SampleTemplateData("example.org", "cluster", "demo", "workload"))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
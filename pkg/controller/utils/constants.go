@@ -8,6 +8,11 @@ const (
 	ZeroTrustWorkloadIdentityManagerSpireAgentControllerName                 = "zero-trust-workload-identity-manager-spire-agent-controller"
 	ZeroTrustWorkloadIdentityManagerSpiffeCsiDriverControllerName            = "zero-trust-workload-identity-manager-spiffe-csi-driver-controller"
 	ZeroTrustWorkloadIdentityManagerSpireOIDCDiscoveryProviderControllerName = "zero-trust-workload-identity-manager-spire-oidc-discovery-provider-controller"
+	ZeroTrustWorkloadIdentityManagerJoinTokenControllerName                  = "zero-trust-workload-identity-manager-join-token-controller"
+	ZeroTrustWorkloadIdentityManagerSpireAuthorityOperationControllerName    = "zero-trust-workload-identity-manager-spire-authority-operation-controller"
+	ZeroTrustWorkloadIdentityManagerWorkloadIdentityControllerName           = "zero-trust-workload-identity-manager-workload-identity-controller"
+	ZeroTrustWorkloadIdentityManagerClusterSPIFFEIDAuditControllerName       = "zero-trust-workload-identity-manager-cluster-spiffeid-audit-controller"
+	ZeroTrustWorkloadIdentityManagerSVIDSecretControllerName                 = "zero-trust-workload-identity-manager-svid-secret-controller"
 
 	OperatorNamespace = "zero-trust-workload-identity-manager"
 
@@ -48,6 +53,24 @@ const (
 	// Validating Webhook Configurations
 	SpireControllerManagerValidatingWebhookConfigurationAssetName = "spire-controller-manager/spire-controller-manager-webhook-validating-webhook.yaml"
 
+	// SPIRE server API proxy. The sidecar in the spire-server pod exposes the private API socket over mutual
+	// TLS to the operator, which holds the client credentials.
+	SpireServerAPIServiceName           = "spire-server-api"
+	SpireServerAPIProxyContainerName    = "spire-server-api-proxy"
+	SpireServerAPIProxyPort             = 8444
+	SpireServerAPIProxySecretName       = "spire-server-api-proxy-tls"
+	SpireServerAPIClientSecretName      = "spire-server-api-client-tls"
+	SpireServerAPIProxyCommand          = "server-api-proxy"
+	SpireServerAPIProxyCertMountPath    = "/run/spire/server-api-proxy"
+	SpireServerAPIProxyClientCommonName = "zero-trust-workload-identity-manager"
+
+	// SPIRE server API relay. The sidecar in the OIDC discovery provider pod forwards the provider's
+	// requests to the proxy with the bundle reader credentials, which only allow reading the trust bundle.
+	SpireServerAPIBundleReaderSecretName = "spire-server-api-bundle-reader-tls"
+	SpireServerAPIRelayContainerName     = "spire-server-api-relay"
+	SpireServerAPIRelayCommand           = "server-api-relay"
+	SpireServerAPIRelayCertMountPath     = "/run/spire/server-api-relay"
+
 	// Service CA Certificate
 	ServiceCAAnnotationKey     = "service.beta.openshift.io/serving-cert-secret-name"
 	SpireServerServingCertName = "spire-server-serving-cert"
@@ -60,6 +83,9 @@ const (
 	SpireControllerManagerImageEnv     = "RELATED_IMAGE_SPIRE_CONTROLLER_MANAGER"
 	NodeDriverRegistrarImageEnv        = "RELATED_IMAGE_NODE_DRIVER_REGISTRAR"
 	SpiffeCSIInitContainerImageEnv     = "RELATED_IMAGE_SPIFFE_CSI_INIT_CONTAINER"
+	SpiffeHelperImageEnv               = "RELATED_IMAGE_SPIFFE_HELPER"
+	// OperatorImageEnv is the operator's own image, which also runs the SPIRE server API proxy sidecar
+	OperatorImageEnv = "RELATED_IMAGE_ZERO_TRUST_WORKLOAD_IDENTITY_MANAGER"
 
 	// Resource Kinds - used for validation and logging
 	ResourceKindSpireServer                = "SpireServer"
@@ -77,11 +103,14 @@ const (
 	ConditionReasonInvalidNodeSelector = "InvalidNodeSelector"
 	ConditionReasonInvalidResources    = "InvalidResources"
 	ConditionReasonInvalidLabels       = "InvalidLabels"
+	ConditionReasonInvalidRollout      = "InvalidRolloutStrategy"
+	ConditionReasonInvalidPerformance  = "InvalidPerformanceConfiguration"
 
 	// Workload Attestor Verification Types
-	WorkloadAttestorVerificationTypeSkip     = "skip"
-	WorkloadAttestorVerificationTypeAuto     = "auto"
-	WorkloadAttestorVerificationTypeHostCert = "hostCert"
+	WorkloadAttestorVerificationTypeSkip      = "skip"
+	WorkloadAttestorVerificationTypeAuto      = "auto"
+	WorkloadAttestorVerificationTypeHostCert  = "hostCert"
+	WorkloadAttestorVerificationTypeConfigMap = "configMap"
 
 	// ConfigMap Data Keys
 	SpireAgentConfigKey             = "agent.conf"
@@ -93,6 +122,16 @@ const (
 	DefaultKubeletCABasePath = "/etc/kubernetes"
 	DefaultKubeletCAFileName = "kubelet-ca.crt"
 
+	// Default source of the kubelet serving CA bundle for 'configMap' mode
+	DefaultKubeletCAConfigMapNamespace = "openshift-config-managed"
+	DefaultKubeletCAConfigMapName      = "kubelet-serving-ca"
+	DefaultKubeletCAConfigMapKey       = "ca-bundle.crt"
+
+	// Copy of the kubelet serving CA bundle mounted into the SPIRE agent in 'configMap' mode
+	SpireAgentKubeletCAConfigMapName = "spire-agent-kubelet-ca"
+	SpireAgentKubeletCAKey           = "ca.crt"
+	SpireAgentKubeletCAMountPath     = "/run/spire/kubelet-ca"
+
 	// External Certificate RBAC Resource Names
 	SpireOIDCExternalCertRoleName          = "spire-oidc-external-cert-reader"
 	SpireOIDCExternalCertRoleBindingName   = "spire-oidc-external-cert-reader"
@@ -0,0 +1,400 @@
+package utils
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+const (
+	// RouteTerminationAnnotation sets the TLS termination of the Route that OpenShift
+	// creates from an Ingress
+	RouteTerminationAnnotation = "route.openshift.io/termination"
+
+	// ingress-nginx annotations keeping the TLS semantics of an endpoint. Other ingress
+	// controllers ignore them and are configured through the exposure annotations.
+	ingressNginxBackendProtocolAnnotation = "nginx.ingress.kubernetes.io/backend-protocol"
+	ingressNginxSSLPassthroughAnnotation  = "nginx.ingress.kubernetes.io/ssl-passthrough"
+
+	// backendCACertificateKey is the ConfigMap key BackendTLSPolicy reads CA certificates from
+	backendCACertificateKey = "ca.crt"
+)
+
+var (
+	// HTTPRouteGVK is the Gateway API HTTPRoute kind used for reencrypt endpoints
+	HTTPRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+
+	// TLSRouteGVK is the Gateway API TLSRoute kind used for passthrough endpoints
+	TLSRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Kind: "TLSRoute"}
+
+	// BackendTLSPolicyGVKs are the versions of the BackendTLSPolicy kind making the Gateway verify
+	// the backend of a reencrypt HTTPRoute, newest first
+	BackendTLSPolicyGVKs = []schema.GroupVersionKind{
+		{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "BackendTLSPolicy"},
+		{Group: "gateway.networking.k8s.io", Version: "v1alpha3", Kind: "BackendTLSPolicy"},
+	}
+
+	// ServiceCAFile is the service CA bundle OpenShift mounts in every pod, which signs the
+	// serving certificates of the operands
+	ServiceCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/service-ca.crt"
+)
+
+// ExposedEndpoint describes an HTTPS endpoint of an operand published outside the cluster
+type ExposedEndpoint struct {
+	// Name is the name of the Ingress, HTTPRoute or TLSRoute
+	Name string
+	// Host is the external hostname of the endpoint
+	Host string
+	// AdditionalHosts are other hostnames routed to the same backend
+	AdditionalHosts []string
+	// Labels are set on the generated object
+	Labels map[string]string
+	// ServiceName is the backend Service
+	ServiceName string
+	// ServicePortName and ServicePort identify the HTTPS port of the backend Service
+	ServicePortName string
+	ServicePort     int32
+	// Passthrough is true when TLS is terminated by the operand, false when it is re-encrypted at the edge
+	Passthrough bool
+	// TLSSecretName is the edge certificate of a reencrypt endpoint. When empty, the default certificate is used.
+	TLSSecretName string
+}
+
+// endpointHosts returns the hostname of the endpoint followed by the additional ones
+func endpointHosts(endpoint ExposedEndpoint) []string {
+	return append([]string{endpoint.Host}, endpoint.AdditionalHosts...)
+}
+
+// EndpointExposureType returns the configured exposure type, defaulting to Route
+func EndpointExposureType(exposure *v1alpha1.EndpointExposure) v1alpha1.EndpointExposureType {
+	if exposure == nil || exposure.Type == "" {
+		return v1alpha1.RouteExposureType
+	}
+	return exposure.Type
+}
+
+// ValidateEndpointExposure verifies that the exposure type keeps the TLS semantics of the endpoint
+func ValidateEndpointExposure(exposure *v1alpha1.EndpointExposure, passthrough bool) error {
+	switch exposureType := EndpointExposureType(exposure); exposureType {
+	case v1alpha1.RouteExposureType, v1alpha1.IngressExposureType:
+	case v1alpha1.HTTPRouteExposureType:
+		if passthrough {
+			return fmt.Errorf("exposure type HTTPRoute terminates TLS at the Gateway and cannot be used for a passthrough endpoint, use TLSRoute instead")
+		}
+	case v1alpha1.TLSRouteExposureType:
+		if !passthrough {
+			return fmt.Errorf("exposure type TLSRoute passes TLS through and cannot be used for a reencrypt endpoint, use HTTPRoute instead")
+		}
+	default:
+		return fmt.Errorf("unsupported exposure type %q", exposureType)
+	}
+	if exposure == nil {
+		return nil
+	}
+	if exposure.ClassName != "" && exposure.Type != v1alpha1.IngressExposureType {
+		return fmt.Errorf("exposure.className can only be set when type is Ingress")
+	}
+	isGatewayRoute := exposure.Type == v1alpha1.HTTPRouteExposureType || exposure.Type == v1alpha1.TLSRouteExposureType
+	if isGatewayRoute && len(exposure.ParentRefs) == 0 {
+		return fmt.Errorf("exposure.parentRefs is required when type is %s", exposure.Type)
+	}
+	if !isGatewayRoute && len(exposure.ParentRefs) > 0 {
+		return fmt.Errorf("exposure.parentRefs can only be set when type is HTTPRoute or TLSRoute")
+	}
+	return nil
+}
+
+// GenerateExposureIngress creates the Ingress publishing the endpoint. The termination annotation
+// makes OpenShift create a Route with the same TLS semantics, and the ingress-nginx annotations do
+// the same for ingress-nginx. Other ingress controllers, and the verification of the backend
+// certificate by ingress-nginx, are configured through the exposure annotations.
+func GenerateExposureIngress(endpoint ExposedEndpoint, exposure *v1alpha1.EndpointExposure) *networkingv1.Ingress {
+	annotations := map[string]string{}
+	for k, v := range exposure.Annotations {
+		annotations[k] = v
+	}
+	if endpoint.Passthrough {
+		annotations[RouteTerminationAnnotation] = "passthrough"
+		annotations[ingressNginxSSLPassthroughAnnotation] = "true"
+	} else {
+		annotations[RouteTerminationAnnotation] = "reencrypt"
+		annotations[ingressNginxBackendProtocolAnnotation] = "HTTPS"
+	}
+
+	hosts := endpointHosts(endpoint)
+	rules := make([]networkingv1.IngressRule, 0, len(hosts))
+	for _, host := range hosts {
+		rules = append(rules, networkingv1.IngressRule{
+			Host: host,
+			IngressRuleValue: networkingv1.IngressRuleValue{
+				HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{
+						{
+							Path:     "/",
+							PathType: ptr.To(networkingv1.PathTypePrefix),
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: endpoint.ServiceName,
+									Port: networkingv1.ServiceBackendPort{Name: endpoint.ServicePortName},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        endpoint.Name,
+			Namespace:   GetOperatorNamespace(),
+			Labels:      endpoint.Labels,
+			Annotations: annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: rules,
+		},
+	}
+	if exposure.ClassName != "" {
+		ingress.Spec.IngressClassName = ptr.To(exposure.ClassName)
+	}
+	if !endpoint.Passthrough {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{
+			{
+				Hosts:      hosts,
+				SecretName: endpoint.TLSSecretName,
+			},
+		}
+	}
+	return ingress
+}
+
+// GenerateExposureGatewayRoute creates the Gateway API HTTPRoute or TLSRoute publishing the endpoint
+func GenerateExposureGatewayRoute(endpoint ExposedEndpoint, exposure *v1alpha1.EndpointExposure) *unstructured.Unstructured {
+	gvk := HTTPRouteGVK
+	if exposure.Type == v1alpha1.TLSRouteExposureType {
+		gvk = TLSRouteGVK
+	}
+
+	parentRefs := make([]interface{}, 0, len(exposure.ParentRefs))
+	for _, ref := range exposure.ParentRefs {
+		namespace := ref.Namespace
+		if namespace == "" {
+			namespace = GetOperatorNamespace()
+		}
+		parentRef := map[string]interface{}{
+			"group":     gvk.Group,
+			"kind":      "Gateway",
+			"name":      ref.Name,
+			"namespace": namespace,
+		}
+		if ref.SectionName != "" {
+			parentRef["sectionName"] = ref.SectionName
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(gvk)
+	route.SetName(endpoint.Name)
+	route.SetNamespace(GetOperatorNamespace())
+	route.SetLabels(endpoint.Labels)
+	if len(exposure.Annotations) > 0 {
+		route.SetAnnotations(exposure.Annotations)
+	}
+	hostnames := []interface{}{}
+	for _, host := range endpointHosts(endpoint) {
+		hostnames = append(hostnames, host)
+	}
+	route.Object["spec"] = map[string]interface{}{
+		"parentRefs": parentRefs,
+		"hostnames":  hostnames,
+		"rules": []interface{}{
+			map[string]interface{}{
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"name": endpoint.ServiceName,
+						"port": int64(endpoint.ServicePort),
+					},
+				},
+			},
+		},
+	}
+	return route
+}
+
+// BackendCAConfigMapName returns the name of the ConfigMap holding the CA of the backend of the
+// endpoint published under exposureName
+func BackendCAConfigMapName(exposureName string) string {
+	return exposureName + "-backend-ca"
+}
+
+// GenerateExposureBackendCAConfigMap creates the ConfigMap holding the service CA bundle that signs
+// the certificate of the endpoint's backend, under the key BackendTLSPolicy reads
+func GenerateExposureBackendCAConfigMap(endpoint ExposedEndpoint, serviceCA []byte) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BackendCAConfigMapName(endpoint.Name),
+			Namespace: GetOperatorNamespace(),
+			Labels:    endpoint.Labels,
+		},
+		Data: map[string]string{backendCACertificateKey: string(serviceCA)},
+	}
+}
+
+// GenerateExposureBackendTLSPolicy creates the BackendTLSPolicy making the Gateway of a reencrypt
+// HTTPRoute connect to the backend Service with TLS and verify its service CA certificate
+func GenerateExposureBackendTLSPolicy(endpoint ExposedEndpoint, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	policy := &unstructured.Unstructured{}
+	policy.SetGroupVersionKind(gvk)
+	policy.SetName(endpoint.Name)
+	policy.SetNamespace(GetOperatorNamespace())
+	policy.SetLabels(endpoint.Labels)
+	policy.Object["spec"] = map[string]interface{}{
+		"targetRefs": []interface{}{
+			map[string]interface{}{
+				"group":       "",
+				"kind":        "Service",
+				"name":        endpoint.ServiceName,
+				"sectionName": endpoint.ServicePortName,
+			},
+		},
+		"validation": map[string]interface{}{
+			"caCertificateRefs": []interface{}{
+				map[string]interface{}{
+					"group": "",
+					"kind":  "ConfigMap",
+					"name":  BackendCAConfigMapName(endpoint.Name),
+				},
+			},
+			// The service CA signs the service DNS names
+			"hostname": fmt.Sprintf("%s.%s.svc", endpoint.ServiceName, GetOperatorNamespace()),
+		},
+	}
+	return policy
+}
+
+// ServedGatewayAPIKinds returns the Gateway API kinds used to publish endpoints that the cluster
+// serves. BackendTLSPolicy is returned in its newest served version.
+func ServedGatewayAPIKinds(mapper apimeta.RESTMapper) []schema.GroupVersionKind {
+	served := func(gvk schema.GroupVersionKind) bool {
+		_, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		return err == nil
+	}
+	var kinds []schema.GroupVersionKind
+	for _, gvk := range []schema.GroupVersionKind{HTTPRouteGVK, TLSRouteGVK} {
+		if served(gvk) {
+			kinds = append(kinds, gvk)
+		}
+	}
+	for _, gvk := range BackendTLSPolicyGVKs {
+		if served(gvk) {
+			kinds = append(kinds, gvk)
+			break
+		}
+	}
+	return kinds
+}
+
+// BackendTLSPolicyGVK returns the BackendTLSPolicy version among the served kinds, or the newest
+// version when the kind is not served
+func BackendTLSPolicyGVK(servedKinds []schema.GroupVersionKind) schema.GroupVersionKind {
+	for _, gvk := range servedKinds {
+		if gvk.GroupKind() == BackendTLSPolicyGVKs[0].GroupKind() {
+			return gvk
+		}
+	}
+	return BackendTLSPolicyGVKs[0]
+}
+
+// GatewayObjectNeedsUpdate returns true if the spec, labels or configured annotations of an
+// HTTPRoute, TLSRoute or BackendTLSPolicy differ from the desired object. Fields defaulted by
+// the Gateway API CRDs, such as backendRefs kind and weight, are not compared.
+func GatewayObjectNeedsUpdate(current, desired *unstructured.Unstructured) bool {
+	if !containsFields(current.Object["spec"], desired.Object["spec"]) ||
+		!equality.Semantic.DeepEqual(current.GetLabels(), desired.GetLabels()) {
+		return true
+	}
+	currentAnnotations := current.GetAnnotations()
+	for k, v := range desired.GetAnnotations() {
+		if currentAnnotations[k] != v {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFields returns true if every field set in desired has the same value in current.
+// Lists must have the same length and are compared element by element.
+func containsFields(current, desired interface{}) bool {
+	switch d := desired.(type) {
+	case map[string]interface{}:
+		c, ok := current.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range d {
+			if !containsFields(c[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		c, ok := current.([]interface{})
+		if !ok || len(c) != len(d) {
+			return false
+		}
+		for i := range d {
+			if !containsFields(c[i], d[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equality.Semantic.DeepEqual(current, desired)
+	}
+}
+
+// IngressNeedsUpdate returns true if the spec, labels or configured annotations of the Ingress
+// differ from the desired Ingress
+func IngressNeedsUpdate(current, desired *networkingv1.Ingress) bool {
+	if !equality.Semantic.DeepEqual(current.Spec, desired.Spec) ||
+		!equality.Semantic.DeepEqual(current.Labels, desired.Labels) {
+		return true
+	}
+	for k, v := range desired.Annotations {
+		if current.Annotations[k] != v {
+			return true
+		}
+	}
+	return false
+}
+
+// IngressHost returns the host published by the Ingress
+func IngressHost(ingress *networkingv1.Ingress) string {
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			return rule.Host
+		}
+	}
+	return ""
+}
+
+// GatewayRouteHost returns the first hostname of an HTTPRoute or TLSRoute
+func GatewayRouteHost(route *unstructured.Unstructured) string {
+	hostnames, _, _ := unstructured.NestedStringSlice(route.Object, "spec", "hostnames")
+	if len(hostnames) > 0 {
+		return hostnames[0]
+	}
+	return ""
+}
@@ -0,0 +1,230 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func testExposedEndpoint(passthrough bool) ExposedEndpoint {
+	return ExposedEndpoint{
+		Name:            "spire-server-federation",
+		Host:            "federation.example.org",
+		Labels:          map[string]string{AppManagedByLabelKey: AppManagedByLabelValue},
+		ServiceName:     "spire-server",
+		ServicePortName: "federation",
+		ServicePort:     8443,
+		Passthrough:     passthrough,
+		TLSSecretName:   "federation-tls",
+	}
+}
+
+func TestValidateEndpointExposure(t *testing.T) {
+	gatewayRefs := []v1alpha1.GatewayParentReference{{Name: "public"}}
+	tests := []struct {
+		name        string
+		exposure    *v1alpha1.EndpointExposure
+		passthrough bool
+		wantErr     string
+	}{
+		{name: "nil exposure defaults to route"},
+		{name: "ingress with class", exposure: &v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType, ClassName: "nginx"}},
+		{name: "httproute for reencrypt", exposure: &v1alpha1.EndpointExposure{Type: v1alpha1.HTTPRouteExposureType, ParentRefs: gatewayRefs}},
+		{name: "tlsroute for passthrough", exposure: &v1alpha1.EndpointExposure{Type: v1alpha1.TLSRouteExposureType, ParentRefs: gatewayRefs}, passthrough: true},
+		{
+			name:        "httproute for passthrough",
+			exposure:    &v1alpha1.EndpointExposure{Type: v1alpha1.HTTPRouteExposureType, ParentRefs: gatewayRefs},
+			passthrough: true,
+			wantErr:     "use TLSRoute",
+		},
+		{
+			name:     "tlsroute for reencrypt",
+			exposure: &v1alpha1.EndpointExposure{Type: v1alpha1.TLSRouteExposureType, ParentRefs: gatewayRefs},
+			wantErr:  "use HTTPRoute",
+		},
+		{
+			name:     "gateway route without parents",
+			exposure: &v1alpha1.EndpointExposure{Type: v1alpha1.HTTPRouteExposureType},
+			wantErr:  "parentRefs is required",
+		},
+		{
+			name:     "parents on ingress",
+			exposure: &v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType, ParentRefs: gatewayRefs},
+			wantErr:  "parentRefs can only be set",
+		},
+		{
+			name:     "class on route",
+			exposure: &v1alpha1.EndpointExposure{Type: v1alpha1.RouteExposureType, ClassName: "nginx"},
+			wantErr:  "className",
+		},
+		{
+			name:     "unknown type",
+			exposure: &v1alpha1.EndpointExposure{Type: "LoadBalancer"},
+			wantErr:  "unsupported exposure type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEndpointExposure(tt.exposure, tt.passthrough)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGenerateExposureIngress(t *testing.T) {
+	t.Run("reencrypt endpoint terminates TLS with the configured secret", func(t *testing.T) {
+		exposure := &v1alpha1.EndpointExposure{
+			Type:        v1alpha1.IngressExposureType,
+			ClassName:   "openshift-default",
+			Annotations: map[string]string{"example.com/backend-protocol": "HTTPS"},
+		}
+
+		ingress := GenerateExposureIngress(testExposedEndpoint(false), exposure)
+
+		assert.Equal(t, "spire-server-federation", ingress.Name)
+		assert.Equal(t, GetOperatorNamespace(), ingress.Namespace)
+		require.NotNil(t, ingress.Spec.IngressClassName)
+		assert.Equal(t, "openshift-default", *ingress.Spec.IngressClassName)
+		assert.Equal(t, "reencrypt", ingress.Annotations[RouteTerminationAnnotation])
+		assert.Equal(t, "HTTPS", ingress.Annotations[ingressNginxBackendProtocolAnnotation])
+		assert.Equal(t, "HTTPS", ingress.Annotations["example.com/backend-protocol"])
+		assert.NotContains(t, exposure.Annotations, RouteTerminationAnnotation, "exposure annotations must not be mutated")
+		require.Len(t, ingress.Spec.TLS, 1)
+		assert.Equal(t, []string{"federation.example.org"}, ingress.Spec.TLS[0].Hosts)
+		assert.Equal(t, "federation-tls", ingress.Spec.TLS[0].SecretName)
+		assert.Equal(t, "federation.example.org", IngressHost(ingress))
+		backend := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+		assert.Equal(t, "spire-server", backend.Name)
+		assert.Equal(t, networkingv1.ServiceBackendPort{Name: "federation"}, backend.Port)
+	})
+
+	t.Run("additional hosts are routed to the same backend", func(t *testing.T) {
+		endpoint := testExposedEndpoint(false)
+		endpoint.AdditionalHosts = []string{"old-federation.example.org"}
+
+		ingress := GenerateExposureIngress(endpoint, &v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType})
+
+		require.Len(t, ingress.Spec.Rules, 2)
+		assert.Equal(t, "old-federation.example.org", ingress.Spec.Rules[1].Host)
+		assert.Equal(t, ingress.Spec.Rules[0].HTTP, ingress.Spec.Rules[1].HTTP)
+		assert.Equal(t, []string{"federation.example.org", "old-federation.example.org"}, ingress.Spec.TLS[0].Hosts)
+		assert.Equal(t, "federation.example.org", IngressHost(ingress))
+	})
+
+	t.Run("passthrough endpoint has no TLS section", func(t *testing.T) {
+		ingress := GenerateExposureIngress(testExposedEndpoint(true), &v1alpha1.EndpointExposure{Type: v1alpha1.IngressExposureType})
+
+		assert.Nil(t, ingress.Spec.IngressClassName)
+		assert.Empty(t, ingress.Spec.TLS)
+		assert.Equal(t, "passthrough", ingress.Annotations[RouteTerminationAnnotation])
+		assert.Equal(t, "true", ingress.Annotations[ingressNginxSSLPassthroughAnnotation])
+		assert.NotContains(t, ingress.Annotations, ingressNginxBackendProtocolAnnotation)
+	})
+}
+
+func TestGenerateExposureGatewayRoute(t *testing.T) {
+	exposure := &v1alpha1.EndpointExposure{
+		Type: v1alpha1.TLSRouteExposureType,
+		ParentRefs: []v1alpha1.GatewayParentReference{
+			{Name: "public", Namespace: "gateways", SectionName: "tls"},
+			{Name: "internal"},
+		},
+	}
+
+	route := GenerateExposureGatewayRoute(testExposedEndpoint(true), exposure)
+
+	assert.Equal(t, TLSRouteGVK, route.GroupVersionKind())
+	assert.Equal(t, "spire-server-federation", route.GetName())
+	assert.Equal(t, "federation.example.org", GatewayRouteHost(route))
+	parentRefs, _, _ := unstructured.NestedSlice(route.Object, "spec", "parentRefs")
+	require.Len(t, parentRefs, 2)
+	assert.Equal(t, map[string]interface{}{
+		"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "public", "namespace": "gateways", "sectionName": "tls",
+	}, parentRefs[0])
+	assert.Equal(t, GetOperatorNamespace(), parentRefs[1].(map[string]interface{})["namespace"])
+	rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+	require.Len(t, rules, 1)
+	backendRefs := rules[0].(map[string]interface{})["backendRefs"].([]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "spire-server", "port": int64(8443)}, backendRefs[0])
+
+	exposure.Type = v1alpha1.HTTPRouteExposureType
+	endpoint := testExposedEndpoint(false)
+	endpoint.AdditionalHosts = []string{"old-federation.example.org"}
+	httpRoute := GenerateExposureGatewayRoute(endpoint, exposure)
+	assert.Equal(t, HTTPRouteGVK, httpRoute.GroupVersionKind())
+	hostnames, _, _ := unstructured.NestedStringSlice(httpRoute.Object, "spec", "hostnames")
+	assert.Equal(t, []string{"federation.example.org", "old-federation.example.org"}, hostnames)
+	assert.Equal(t, "federation.example.org", GatewayRouteHost(httpRoute))
+}
+
+func TestGenerateExposureBackendTLSPolicy(t *testing.T) {
+	endpoint := testExposedEndpoint(false)
+
+	configMap := GenerateExposureBackendCAConfigMap(endpoint, []byte("service CA"))
+	assert.Equal(t, "spire-server-federation-backend-ca", configMap.Name)
+	assert.Equal(t, map[string]string{"ca.crt": "service CA"}, configMap.Data)
+
+	policy := GenerateExposureBackendTLSPolicy(endpoint, BackendTLSPolicyGVKs[1])
+	assert.Equal(t, BackendTLSPolicyGVKs[1], policy.GroupVersionKind())
+	assert.Equal(t, "spire-server-federation", policy.GetName())
+	targetRefs, _, _ := unstructured.NestedSlice(policy.Object, "spec", "targetRefs")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"group": "", "kind": "Service", "name": "spire-server", "sectionName": "federation",
+	}}, targetRefs)
+	caRefs, _, _ := unstructured.NestedSlice(policy.Object, "spec", "validation", "caCertificateRefs")
+	assert.Equal(t, []interface{}{map[string]interface{}{
+		"group": "", "kind": "ConfigMap", "name": configMap.Name,
+	}}, caRefs)
+	hostname, _, _ := unstructured.NestedString(policy.Object, "spec", "validation", "hostname")
+	assert.Equal(t, "spire-server."+GetOperatorNamespace()+".svc", hostname)
+}
+
+func TestServedGatewayAPIKinds(t *testing.T) {
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	assert.Empty(t, ServedGatewayAPIKinds(mapper))
+	assert.Equal(t, BackendTLSPolicyGVKs[0], BackendTLSPolicyGVK(nil))
+
+	mapper.Add(HTTPRouteGVK, apimeta.RESTScopeNamespace)
+	mapper.Add(BackendTLSPolicyGVKs[1], apimeta.RESTScopeNamespace)
+	kinds := ServedGatewayAPIKinds(mapper)
+	assert.Equal(t, []schema.GroupVersionKind{HTTPRouteGVK, BackendTLSPolicyGVKs[1]}, kinds)
+	assert.Equal(t, BackendTLSPolicyGVKs[1], BackendTLSPolicyGVK(kinds))
+}
+
+func TestGatewayObjectNeedsUpdate(t *testing.T) {
+	exposure := &v1alpha1.EndpointExposure{
+		Type:       v1alpha1.HTTPRouteExposureType,
+		ParentRefs: []v1alpha1.GatewayParentReference{{Name: "public"}},
+	}
+	desired := GenerateExposureGatewayRoute(testExposedEndpoint(false), exposure)
+
+	// Simulate the fields defaulted by the Gateway API CRDs
+	current := desired.DeepCopy()
+	rules, _, _ := unstructured.NestedSlice(current.Object, "spec", "rules")
+	rule := rules[0].(map[string]interface{})
+	backendRef := rule["backendRefs"].([]interface{})[0].(map[string]interface{})
+	backendRef["group"] = ""
+	backendRef["kind"] = "Service"
+	backendRef["weight"] = int64(1)
+	rule["matches"] = []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/"}}}
+	require.NoError(t, unstructured.SetNestedSlice(current.Object, rules, "spec", "rules"))
+	current.SetAnnotations(map[string]string{"gateway.example.com/observed": "true"})
+	current.Object["status"] = map[string]interface{}{"parents": []interface{}{}}
+
+	assert.False(t, GatewayObjectNeedsUpdate(current, desired))
+
+	require.NoError(t, unstructured.SetNestedStringSlice(current.Object, []string{"old.example.org"}, "spec", "hostnames"))
+	assert.True(t, GatewayObjectNeedsUpdate(current, desired))
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+const (
+	// JWTIssuerConsistentConditionType reports whether SPIRE Server signs JWT-SVIDs with an issuer the
+	// OIDC discovery provider serves
+	JWTIssuerConsistentConditionType = "JWTIssuerConsistent"
+
+	JWTIssuerMatch      = "JWTIssuerMatch"
+	JWTIssuerTransition = "JWTIssuerTransition"
+	JWTIssuerMismatch   = "JWTIssuerMismatch"
+)
+
+// CheckJWTIssuerConsistency compares the issuer SPIRE Server puts in JWT-SVIDs with the issuers the OIDC
+// discovery provider serves, after normalizing both. A server still using the previous issuer of the
+// provider is consistent, since the provider keeps serving it while relying parties move to the new one.
+func CheckJWTIssuerConsistency(server *v1alpha1.SpireServer, oidc *v1alpha1.SpireOIDCDiscoveryProvider) (metav1.ConditionStatus, string, string) {
+	serverIssuer, err := NormalizeURL(server.Spec.JwtIssuer)
+	if err != nil {
+		return metav1.ConditionFalse, JWTIssuerMismatch, fmt.Sprintf("SpireServer JWT issuer is invalid: %v", err)
+	}
+	oidcIssuer, err := NormalizeURL(oidc.Spec.JwtIssuer)
+	if err != nil {
+		return metav1.ConditionFalse, JWTIssuerMismatch, fmt.Sprintf("SpireOIDCDiscoveryProvider JWT issuer is invalid: %v", err)
+	}
+	var previousIssuer string
+	if oidc.Spec.PreviousJwtIssuer != "" {
+		previousIssuer, _ = NormalizeURL(oidc.Spec.PreviousJwtIssuer)
+	}
+
+	switch serverIssuer {
+	case oidcIssuer:
+		if previousIssuer != "" {
+			return metav1.ConditionTrue, JWTIssuerMatch, fmt.Sprintf(
+				"SpireServer and SpireOIDCDiscoveryProvider use JWT issuer %s, the previous issuer %s is still served until previousJwtIssuer is removed",
+				serverIssuer, previousIssuer)
+		}
+		return metav1.ConditionTrue, JWTIssuerMatch, fmt.Sprintf(
+			"SpireServer and SpireOIDCDiscoveryProvider use JWT issuer %s", serverIssuer)
+	case previousIssuer:
+		return metav1.ConditionTrue, JWTIssuerTransition, fmt.Sprintf(
+			"SpireServer still uses the previous JWT issuer %s, which SpireOIDCDiscoveryProvider keeps serving; set the SpireServer jwtIssuer to %s to complete the transition",
+			serverIssuer, oidcIssuer)
+	default:
+		return metav1.ConditionFalse, JWTIssuerMismatch, fmt.Sprintf(
+			"SpireServer uses JWT issuer %s but SpireOIDCDiscoveryProvider serves %s, relying parties will reject the JWT-SVIDs",
+			serverIssuer, oidcIssuer)
+	}
+}
+
+// JWTIssuerConditionChanged reports whether the JWTIssuerConsistent condition in conditions differs from the
+// result of CheckJWTIssuerConsistency, so that a mismatch is only recorded as an event when it first appears
+func JWTIssuerConditionChanged(conditions []metav1.Condition, status metav1.ConditionStatus, reason, message string) bool {
+	existing := apimeta.FindStatusCondition(conditions, JWTIssuerConsistentConditionType)
+	return existing == nil || existing.Status != status || existing.Reason != reason || existing.Message != message
+}
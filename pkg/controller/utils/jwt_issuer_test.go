@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+func TestCheckJWTIssuerConsistency(t *testing.T) {
+	tests := []struct {
+		name           string
+		serverIssuer   string
+		oidcIssuer     string
+		previousIssuer string
+		wantStatus     metav1.ConditionStatus
+		wantReason     string
+		wantMessage    string
+	}{
+		{
+			name:         "same issuer",
+			serverIssuer: "https://oidc.example.com",
+			oidcIssuer:   "https://oidc.example.com",
+			wantStatus:   metav1.ConditionTrue,
+			wantReason:   JWTIssuerMatch,
+		},
+		{
+			name:         "same issuer after normalization",
+			serverIssuer: "HTTPS://OIDC.example.com/",
+			oidcIssuer:   "https://oidc.example.com",
+			wantStatus:   metav1.ConditionTrue,
+			wantReason:   JWTIssuerMatch,
+		},
+		{
+			name:           "transition completed while the previous issuer is still served",
+			serverIssuer:   "https://new.example.com",
+			oidcIssuer:     "https://new.example.com",
+			previousIssuer: "https://old.example.com",
+			wantStatus:     metav1.ConditionTrue,
+			wantReason:     JWTIssuerMatch,
+			wantMessage:    "previous issuer https://old.example.com is still served",
+		},
+		{
+			name:           "server still on the previous issuer",
+			serverIssuer:   "https://old.example.com",
+			oidcIssuer:     "https://new.example.com",
+			previousIssuer: "https://old.example.com/",
+			wantStatus:     metav1.ConditionTrue,
+			wantReason:     JWTIssuerTransition,
+			wantMessage:    "set the SpireServer jwtIssuer to https://new.example.com",
+		},
+		{
+			name:         "different issuers",
+			serverIssuer: "https://server.example.com",
+			oidcIssuer:   "https://oidc.example.com",
+			wantStatus:   metav1.ConditionFalse,
+			wantReason:   JWTIssuerMismatch,
+			wantMessage:  "SpireServer uses JWT issuer https://server.example.com but SpireOIDCDiscoveryProvider serves https://oidc.example.com",
+		},
+		{
+			name:           "server ahead of the provider",
+			serverIssuer:   "https://new.example.com",
+			oidcIssuer:     "https://old.example.com",
+			previousIssuer: "https://older.example.com",
+			wantStatus:     metav1.ConditionFalse,
+			wantReason:     JWTIssuerMismatch,
+		},
+		{
+			name:         "different paths",
+			serverIssuer: "https://oidc.example.com/a",
+			oidcIssuer:   "https://oidc.example.com/b",
+			wantStatus:   metav1.ConditionFalse,
+			wantReason:   JWTIssuerMismatch,
+		},
+		{
+			name:         "invalid server issuer",
+			serverIssuer: "oidc.example.com",
+			oidcIssuer:   "https://oidc.example.com",
+			wantStatus:   metav1.ConditionFalse,
+			wantReason:   JWTIssuerMismatch,
+			wantMessage:  "SpireServer JWT issuer is invalid",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := &v1alpha1.SpireServer{Spec: v1alpha1.SpireServerSpec{JwtIssuer: tt.serverIssuer}}
+			oidc := &v1alpha1.SpireOIDCDiscoveryProvider{Spec: v1alpha1.SpireOIDCDiscoveryProviderSpec{
+				JwtIssuer:         tt.oidcIssuer,
+				PreviousJwtIssuer: tt.previousIssuer,
+			}}
+
+			status, reason, message := CheckJWTIssuerConsistency(server, oidc)
+
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantReason, reason)
+			assert.Contains(t, message, tt.wantMessage)
+		})
+	}
+}
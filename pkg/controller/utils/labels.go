@@ -1,6 +1,8 @@
 package utils
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/version"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
@@ -77,6 +79,14 @@ func hasControllerManagedLabelWithComponent(obj client.Object, component string)
 		hasComponent && componentVal == component
 }
 
+// IsManagedBy reports whether the operator created the object for the owner: it must carry the
+// managed-by label and a controller reference to the owner. Objects the operator holds broad write
+// access to, such as Secrets, are only updated or deleted when this holds, so that existing objects
+// are never adopted.
+func IsManagedBy(obj, owner metav1.Object) bool {
+	return obj.GetLabels()[AppManagedByLabelKey] == AppManagedByLabelValue && metav1.IsControlledBy(obj, owner)
+}
+
 // ControllerManagedResourcesForComponent creates a predicate that filters resources by both
 // the managed-by label and the component label
 func ControllerManagedResourcesForComponent(component string) predicate.Funcs {
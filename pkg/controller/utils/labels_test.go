@@ -5,6 +5,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
@@ -175,3 +176,48 @@ func TestControllerManagedResourcesForComponent(t *testing.T) {
 		})
 	}
 }
+
+func TestIsManagedBy(t *testing.T) {
+	owner := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "owner", UID: "owner-uid"}}
+	controllerRef := func(uid types.UID) []metav1.OwnerReference {
+		controller := true
+		return []metav1.OwnerReference{{Kind: "ConfigMap", Name: "owner", UID: uid, Controller: &controller}}
+	}
+	managed := map[string]string{AppManagedByLabelKey: AppManagedByLabelValue}
+
+	tests := []struct {
+		name     string
+		meta     metav1.ObjectMeta
+		expected bool
+	}{
+		{
+			name:     "label and controller reference",
+			meta:     metav1.ObjectMeta{Labels: managed, OwnerReferences: controllerRef("owner-uid")},
+			expected: true,
+		},
+		{
+			name:     "controller reference without label",
+			meta:     metav1.ObjectMeta{OwnerReferences: controllerRef("owner-uid")},
+			expected: false,
+		},
+		{
+			name:     "label without controller reference",
+			meta:     metav1.ObjectMeta{Labels: managed},
+			expected: false,
+		},
+		{
+			name:     "controlled by another owner",
+			meta:     metav1.ObjectMeta{Labels: managed, OwnerReferences: controllerRef("other-uid")},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &corev1.Secret{ObjectMeta: tt.meta}
+			if got := IsManagedBy(obj, owner); got != tt.expected {
+				t.Errorf("IsManagedBy() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+// Bounds of the performance settings, mirroring the CRD validation
+const (
+	MinX509SVIDCacheMaxSize = 1
+	MaxX509SVIDCacheMaxSize = 100000
+
+	MinSyncInterval         = time.Second
+	MaxSyncInterval         = 10 * time.Minute
+	MinAvailabilityTarget   = 24 * time.Hour
+	MaxAvailabilityTarget   = 720 * time.Hour
+	MinCacheReloadInterval  = time.Second
+	MaxCacheReloadInterval  = 10 * time.Minute
+	MinPruneEventsOlderThan = time.Minute
+	MaxPruneEventsOlderThan = 168 * time.Hour
+)
+
+// ValidateSpireAgentPerformance checks that the SPIRE agent performance settings are within bounds
+func ValidateSpireAgentPerformance(performance *v1alpha1.SpireAgentPerformance) error {
+	if performance == nil {
+		return nil
+	}
+	if size := performance.X509SVIDCacheMaxSize; size != nil && (*size < MinX509SVIDCacheMaxSize || *size > MaxX509SVIDCacheMaxSize) {
+		return fmt.Errorf("x509SVIDCacheMaxSize must be between %d and %d, got %d", MinX509SVIDCacheMaxSize, MaxX509SVIDCacheMaxSize, *size)
+	}
+	if err := validateDurationRange("syncInterval", performance.SyncInterval, MinSyncInterval, MaxSyncInterval); err != nil {
+		return err
+	}
+	return validateDurationRange("availabilityTarget", performance.AvailabilityTarget, MinAvailabilityTarget, MaxAvailabilityTarget)
+}
+
+// ValidateSpireServerPerformance checks that the SPIRE server performance settings are within bounds
+func ValidateSpireServerPerformance(performance *v1alpha1.SpireServerPerformance) error {
+	if performance == nil {
+		return nil
+	}
+	if err := validateDurationRange("cacheReloadInterval", performance.CacheReloadInterval, MinCacheReloadInterval, MaxCacheReloadInterval); err != nil {
+		return err
+	}
+	if performance.PruneEventsOlderThan != nil && !StringToBool(performance.EventsBasedCache) {
+		return fmt.Errorf("pruneEventsOlderThan requires eventsBasedCache to be true")
+	}
+	return validateDurationRange("pruneEventsOlderThan", performance.PruneEventsOlderThan, MinPruneEventsOlderThan, MaxPruneEventsOlderThan)
+}
+
+// validateDurationRange returns an error if the duration is set and outside [min, max]
+func validateDurationRange(field string, d *metav1.Duration, min, max time.Duration) error {
+	if d == nil {
+		return nil
+	}
+	if d.Duration < min || d.Duration > max {
+		return fmt.Errorf("%s must be between %s and %s, got %s", field, min, max, d.Duration)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+func TestValidateSpireAgentPerformance(t *testing.T) {
+	size := func(v int32) *int32 { return &v }
+	duration := func(d time.Duration) *metav1.Duration { return &metav1.Duration{Duration: d} }
+
+	tests := []struct {
+		name        string
+		performance *v1alpha1.SpireAgentPerformance
+		expectError bool
+	}{
+		{name: "nil", performance: nil},
+		{name: "empty", performance: &v1alpha1.SpireAgentPerformance{}},
+		{
+			name: "valid settings",
+			performance: &v1alpha1.SpireAgentPerformance{
+				X509SVIDCacheMaxSize: size(5000),
+				SyncInterval:         duration(30 * time.Second),
+				AvailabilityTarget:   duration(48 * time.Hour),
+			},
+		},
+		{name: "cache size too small", performance: &v1alpha1.SpireAgentPerformance{X509SVIDCacheMaxSize: size(0)}, expectError: true},
+		{name: "cache size too large", performance: &v1alpha1.SpireAgentPerformance{X509SVIDCacheMaxSize: size(MaxX509SVIDCacheMaxSize + 1)}, expectError: true},
+		{name: "sync interval too short", performance: &v1alpha1.SpireAgentPerformance{SyncInterval: duration(500 * time.Millisecond)}, expectError: true},
+		{name: "sync interval too long", performance: &v1alpha1.SpireAgentPerformance{SyncInterval: duration(time.Hour)}, expectError: true},
+		{name: "availability target too short", performance: &v1alpha1.SpireAgentPerformance{AvailabilityTarget: duration(time.Hour)}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSpireAgentPerformance(tt.performance)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error=%v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
+
+func TestValidateSpireServerPerformance(t *testing.T) {
+	duration := func(d time.Duration) *metav1.Duration { return &metav1.Duration{Duration: d} }
+
+	tests := []struct {
+		name        string
+		performance *v1alpha1.SpireServerPerformance
+		expectError bool
+	}{
+		{name: "nil", performance: nil},
+		{
+			name: "valid settings",
+			performance: &v1alpha1.SpireServerPerformance{
+				CacheReloadInterval:  duration(10 * time.Second),
+				EventsBasedCache:     "true",
+				PruneEventsOlderThan: duration(24 * time.Hour),
+			},
+		},
+		{name: "cache reload interval too long", performance: &v1alpha1.SpireServerPerformance{CacheReloadInterval: duration(time.Hour)}, expectError: true},
+		{
+			name:        "prune events without events based cache",
+			performance: &v1alpha1.SpireServerPerformance{PruneEventsOlderThan: duration(time.Hour)},
+			expectError: true,
+		},
+		{
+			name:        "prune events too old",
+			performance: &v1alpha1.SpireServerPerformance{EventsBasedCache: "true", PruneEventsOlderThan: duration(30 * 24 * time.Hour)},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSpireServerPerformance(tt.performance)
+			if (err != nil) != tt.expectError {
+				t.Errorf("Expected error=%v, got %v", tt.expectError, err)
+			}
+		})
+	}
+}
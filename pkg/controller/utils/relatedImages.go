@@ -57,3 +57,15 @@ func GetSpiffeCsiInitContainerImage() string {
 	}
 	return containerImage
 }
+
+func GetSpiffeHelperImage() string {
+	spiffeHelperImage := os.Getenv(SpiffeHelperImageEnv)
+	if spiffeHelperImage == "" {
+		return ""
+	}
+	return spiffeHelperImage
+}
+
+func GetOperatorImage() string {
+	return os.Getenv(OperatorImageEnv)
+}
@@ -54,6 +54,8 @@ func ResourceNeedsUpdate(existing, desired client.Object) bool {
 		typeSpecificResult = CSIDriverNeedsUpdate(existingTyped, desired.(*storagev1.CSIDriver))
 	case *admissionregistrationv1.ValidatingWebhookConfiguration:
 		typeSpecificResult = ValidatingWebhookConfigurationNeedsUpdate(existingTyped, desired.(*admissionregistrationv1.ValidatingWebhookConfiguration))
+	case *admissionregistrationv1.MutatingWebhookConfiguration:
+		typeSpecificResult = MutatingWebhookConfigurationNeedsUpdate(existingTyped, desired.(*admissionregistrationv1.MutatingWebhookConfiguration))
 	case *securityv1.SecurityContextConstraints:
 		typeSpecificResult = SecurityContextConstraintsNeedsUpdate(existingTyped, desired.(*securityv1.SecurityContextConstraints))
 	case *spiffev1alpha1.ClusterSPIFFEID:
@@ -289,6 +291,11 @@ func ValidatingWebhookConfigurationNeedsUpdate(existing, desired *admissionregis
 	return false
 }
 
+// MutatingWebhookConfigurationNeedsUpdate checks if a MutatingWebhookConfiguration needs updating
+func MutatingWebhookConfigurationNeedsUpdate(existing, desired *admissionregistrationv1.MutatingWebhookConfiguration) bool {
+	return !equality.Semantic.DeepEqual(existing.Webhooks, desired.Webhooks)
+}
+
 // SecurityContextConstraintsNeedsUpdate checks if a SecurityContextConstraints needs updating
 func SecurityContextConstraintsNeedsUpdate(existing, desired *securityv1.SecurityContextConstraints) bool {
 	// Compare Users
@@ -399,6 +406,12 @@ func ClusterSPIFFEIDNeedsUpdate(existing, desired *spiffev1alpha1.ClusterSPIFFEI
 	if !stringSlicesEqual(existing.Spec.DNSNameTemplates, desired.Spec.DNSNameTemplates) {
 		return true
 	}
+	// Compare TTLs and federated trust domains
+	if existing.Spec.TTL != desired.Spec.TTL ||
+		existing.Spec.JWTTTL != desired.Spec.JWTTTL ||
+		!stringSlicesEqual(existing.Spec.FederatesWith, desired.Spec.FederatesWith) {
+		return true
+	}
 	// Compare selectors using Semantic.DeepEqual for Kubernetes types
 	if !equality.Semantic.DeepEqual(existing.Spec.PodSelector, desired.Spec.PodSelector) ||
 		!equality.Semantic.DeepEqual(existing.Spec.NamespaceSelector, desired.Spec.NamespaceSelector) ||
@@ -825,9 +838,15 @@ func DaemonSetNeedsUpdate(fetched, desired *appsv1.DaemonSet) bool {
 	if !equality.Semantic.DeepEqual(ds.Selector, fs.Selector) {
 		return true
 	}
+	if !daemonSetUpdateStrategyEqual(ds.UpdateStrategy, fs.UpdateStrategy) {
+		return true
+	}
 	if !equality.Semantic.DeepEqual(ds.Template.Labels, fs.Template.Labels) {
 		return true
 	}
+	if desiredHash := ds.Template.Annotations[PodTemplateHashAnnotationKey]; desiredHash != "" && desiredHash != fs.Template.Annotations[PodTemplateHashAnnotationKey] {
+		return true
+	}
 	dPod := ds.Template.Spec
 	fPod := fs.Template.Spec
 	if dPod.ServiceAccountName != fPod.ServiceAccountName {
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"testing"
+	"time"
 
 	securityv1 "github.com/openshift/api/security/v1"
 	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
@@ -2012,6 +2013,26 @@ func TestClusterSPIFFEIDNeedsUpdate(t *testing.T) {
 			t.Error("Expected true when SPIFFEIDTemplate differs")
 		}
 	})
+
+	t.Run("different TTL or federatesWith needs update", func(t *testing.T) {
+		current := &spiffev1alpha1.ClusterSPIFFEID{
+			Spec: spiffev1alpha1.ClusterSPIFFEIDSpec{
+				SPIFFEIDTemplate: "spiffe://example.org/test",
+				FederatesWith:    []string{"partner.example.com"},
+			},
+		}
+		desired := current.DeepCopy()
+		desired.Spec.TTL = metav1.Duration{Duration: time.Hour}
+		if !ClusterSPIFFEIDNeedsUpdate(current, desired) {
+			t.Error("Expected true when TTL differs")
+		}
+
+		desired = current.DeepCopy()
+		desired.Spec.FederatesWith = []string{"other.example.com"}
+		if !ClusterSPIFFEIDNeedsUpdate(current, desired) {
+			t.Error("Expected true when FederatesWith differs")
+		}
+	})
 }
 
 // TestResourceNeedsUpdate_AllScenarios tests ResourceNeedsUpdate with table-driven tests
@@ -0,0 +1,270 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+const (
+	// PodTemplateHashAnnotationKey records the hash of the desired pod template on DaemonSet pods,
+	// so the operator can tell which pods already run the current template.
+	PodTemplateHashAnnotationKey = "ztwim.openshift.io/pod-template-hash"
+
+	// Rollout phases reported in DaemonSetRolloutStatus
+	RolloutPhaseCanaryInProgress = "CanaryInProgress"
+	RolloutPhaseRollingUpdate    = "RollingUpdate"
+	RolloutPhaseComplete         = "Complete"
+)
+
+// RolloutClient is the subset of the controller client used to drive DaemonSet rollouts
+type RolloutClient interface {
+	List(context.Context, client.ObjectList, ...client.ListOption) error
+	Delete(context.Context, client.Object, ...client.DeleteOption) error
+}
+
+// DaemonSetRolloutPlan is the outcome of PlanDaemonSetRollout
+type DaemonSetRolloutPlan struct {
+	// Status is the rollout progress to report in the operand status
+	Status *v1alpha1.DaemonSetRolloutStatus
+	// OutdatedCanaryPods are the pods on canary nodes that still run an old pod template
+	OutdatedCanaryPods []corev1.Pod
+	// CanaryNodesNotFound is set when no node matches the canary node selector. The rollout is
+	// then held rather than rolled out to every node without a canary.
+	CanaryNodesNotFound bool
+
+	// podSelector is the DaemonSet pod selector, only pods it matches may be deleted
+	podSelector labels.Selector
+}
+
+// DaemonSetUpdateStrategy returns the RollingUpdate strategy for the given rollout configuration.
+// Unset fields default to maxUnavailable=1 and maxSurge=0.
+func DaemonSetUpdateStrategy(strategy *v1alpha1.DaemonSetRolloutStrategy) appsv1.DaemonSetUpdateStrategy {
+	maxUnavailable := intstr.FromInt32(1)
+	maxSurge := intstr.FromInt32(0)
+	if strategy != nil {
+		if strategy.MaxUnavailable != nil {
+			maxUnavailable = *strategy.MaxUnavailable
+		}
+		if strategy.MaxSurge != nil {
+			maxSurge = *strategy.MaxSurge
+		}
+	}
+	return appsv1.DaemonSetUpdateStrategy{
+		Type: appsv1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+			MaxUnavailable: &maxUnavailable,
+			MaxSurge:       &maxSurge,
+		},
+	}
+}
+
+// ValidateDaemonSetRolloutStrategy checks that maxUnavailable and maxSurge are valid
+// and not both zero, mirroring the DaemonSet API validation.
+func ValidateDaemonSetRolloutStrategy(strategy *v1alpha1.DaemonSetRolloutStrategy) error {
+	if strategy == nil {
+		return nil
+	}
+	rollingUpdate := DaemonSetUpdateStrategy(strategy).RollingUpdate
+
+	maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(rollingUpdate.MaxUnavailable, 100, true)
+	if err != nil || maxUnavailable < 0 {
+		return fmt.Errorf("invalid maxUnavailable %q: must be a non-negative integer or percentage", rollingUpdate.MaxUnavailable.String())
+	}
+	maxSurge, err := intstr.GetScaledValueFromIntOrPercent(rollingUpdate.MaxSurge, 100, true)
+	if err != nil || maxSurge < 0 {
+		return fmt.Errorf("invalid maxSurge %q: must be a non-negative integer or percentage", rollingUpdate.MaxSurge.String())
+	}
+	if maxUnavailable == 0 && maxSurge == 0 {
+		return fmt.Errorf("maxUnavailable and maxSurge cannot both be 0")
+	}
+	return nil
+}
+
+// daemonSetUpdateStrategyEqual compares update strategies, treating unset rolling update
+// fields as their API defaults so that server-side defaulting does not trigger updates.
+func daemonSetUpdateStrategyEqual(a, b appsv1.DaemonSetUpdateStrategy) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	if a.Type != appsv1.RollingUpdateDaemonSetStrategyType {
+		return true
+	}
+	normalize := func(ru *appsv1.RollingUpdateDaemonSet) (string, string) {
+		maxUnavailable, maxSurge := intstr.FromInt32(1), intstr.FromInt32(0)
+		if ru != nil && ru.MaxUnavailable != nil {
+			maxUnavailable = *ru.MaxUnavailable
+		}
+		if ru != nil && ru.MaxSurge != nil {
+			maxSurge = *ru.MaxSurge
+		}
+		return maxUnavailable.String(), maxSurge.String()
+	}
+	aUnavailable, aSurge := normalize(a.RollingUpdate)
+	bUnavailable, bSurge := normalize(b.RollingUpdate)
+	return aUnavailable == bUnavailable && aSurge == bSurge
+}
+
+// SetPodTemplateHash computes a hash of the DaemonSet pod template and records it
+// in the PodTemplateHashAnnotationKey annotation of the template.
+func SetPodTemplateHash(ds *appsv1.DaemonSet) {
+	template := ds.Spec.Template.DeepCopy()
+	delete(template.Annotations, PodTemplateHashAnnotationKey)
+	data, err := json.Marshal(template)
+	if err != nil {
+		return
+	}
+	if ds.Spec.Template.Annotations == nil {
+		ds.Spec.Template.Annotations = map[string]string{}
+	}
+	ds.Spec.Template.Annotations[PodTemplateHashAnnotationKey] = GenerateConfigHash(data)
+}
+
+// PlanDaemonSetRollout decides how the desired DaemonSet should be rolled out.
+// When a canary is configured and the pod template changed, or a canary phase is already
+// in progress, the desired DaemonSet is switched to the OnDelete strategy so that only the
+// canary pods deleted by the operator are replaced. Once every canary pod runs the new
+// template and is ready, the configured RollingUpdate strategy is restored. When no node matches
+// the canary node selector, the rollout is held in the canary phase until one does.
+// existing may be nil when the DaemonSet does not exist yet.
+func PlanDaemonSetRollout(ctx context.Context, c RolloutClient, existing, desired *appsv1.DaemonSet, strategy *v1alpha1.DaemonSetRolloutStrategy) (*DaemonSetRolloutPlan, error) {
+	desiredHash := desired.Spec.Template.Annotations[PodTemplateHashAnnotationKey]
+	plan := &DaemonSetRolloutPlan{
+		Status: &v1alpha1.DaemonSetRolloutStatus{PodTemplateHash: desiredHash},
+	}
+	if existing == nil {
+		plan.Status.Phase = RolloutPhaseComplete
+		return plan, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(desired.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DaemonSet selector: %w", err)
+	}
+	plan.podSelector = selector
+	var pods corev1.PodList
+	if err := c.List(ctx, &pods, client.InNamespace(desired.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list pods of DaemonSet %s/%s: %w", desired.Namespace, desired.Name, err)
+	}
+
+	plan.Status.DesiredNodes = existing.Status.DesiredNumberScheduled
+	for i := range pods.Items {
+		if pods.Items[i].Annotations[PodTemplateHashAnnotationKey] == desiredHash {
+			plan.Status.UpdatedNodes++
+		}
+	}
+
+	templateChanged := existing.Spec.Template.Annotations[PodTemplateHashAnnotationKey] != desiredHash
+	canaryInProgress := existing.Spec.UpdateStrategy.Type == appsv1.OnDeleteDaemonSetStrategyType
+	if strategy == nil || strategy.Canary == nil || (!templateChanged && !canaryInProgress) {
+		plan.Status.Phase = rollingPhase(plan.Status)
+		return plan, nil
+	}
+
+	canaryNodes, err := listCanaryNodeNames(ctx, c, strategy.Canary)
+	if err != nil {
+		return nil, err
+	}
+	if len(canaryNodes) == 0 {
+		desired.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}
+		plan.Status.Phase = RolloutPhaseCanaryInProgress
+		plan.CanaryNodesNotFound = true
+		return plan, nil
+	}
+
+	for _, pod := range pods.Items {
+		if !canaryNodes[pod.Spec.NodeName] {
+			continue
+		}
+		plan.Status.CanaryNodes++
+		switch {
+		case pod.DeletionTimestamp != nil:
+			// Pod is being replaced, wait for its successor
+		case pod.Annotations[PodTemplateHashAnnotationKey] != desiredHash:
+			plan.OutdatedCanaryPods = append(plan.OutdatedCanaryPods, pod)
+		case IsPodReady(&pod):
+			plan.Status.ReadyCanaryNodes++
+		}
+	}
+
+	// A deleted canary pod may not have been recreated yet, so also wait for the
+	// DaemonSet controller to report a pod scheduled on every node.
+	allScheduled := existing.Status.CurrentNumberScheduled >= existing.Status.DesiredNumberScheduled
+	if !allScheduled || plan.Status.ReadyCanaryNodes < plan.Status.CanaryNodes || len(plan.OutdatedCanaryPods) > 0 {
+		desired.Spec.UpdateStrategy = appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}
+		plan.Status.Phase = RolloutPhaseCanaryInProgress
+		return plan, nil
+	}
+
+	plan.Status.Phase = rollingPhase(plan.Status)
+	return plan, nil
+}
+
+// DeleteOutdatedCanaryPods deletes the canary pods that still run an old pod template,
+// so that the DaemonSet controller recreates them from the current template.
+// It must only be called after the DaemonSet has been updated with the new template.
+// The operator may delete pods in any namespace, so pods outside the operator namespace or
+// not matching the DaemonSet pod selector are refused.
+func DeleteOutdatedCanaryPods(ctx context.Context, c RolloutClient, plan *DaemonSetRolloutPlan) error {
+	for i := range plan.OutdatedCanaryPods {
+		if !plan.isDaemonSetPod(&plan.OutdatedCanaryPods[i]) {
+			return fmt.Errorf("refusing to delete pod %s/%s, it is not a pod of the DaemonSet",
+				plan.OutdatedCanaryPods[i].Namespace, plan.OutdatedCanaryPods[i].Name)
+		}
+	}
+	for i := range plan.OutdatedCanaryPods {
+		if err := c.Delete(ctx, &plan.OutdatedCanaryPods[i]); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete canary pod %s: %w", plan.OutdatedCanaryPods[i].Name, err)
+		}
+	}
+	return nil
+}
+
+// isDaemonSetPod reports whether the pod is in the operator namespace and matches the DaemonSet pod selector
+func (p *DaemonSetRolloutPlan) isDaemonSetPod(pod *corev1.Pod) bool {
+	if p.podSelector == nil || p.podSelector.Empty() {
+		return false
+	}
+	return pod.Namespace == GetOperatorNamespace() && p.podSelector.Matches(labels.Set(pod.Labels))
+}
+
+// listCanaryNodeNames returns the names of the nodes matching the canary node selector
+func listCanaryNodeNames(ctx context.Context, c RolloutClient, canary *v1alpha1.CanaryRollout) (map[string]bool, error) {
+	var nodes corev1.NodeList
+	if err := c.List(ctx, &nodes, client.MatchingLabelsSelector{Selector: labels.SelectorFromSet(canary.NodeSelector)}); err != nil {
+		return nil, fmt.Errorf("failed to list canary nodes: %w", err)
+	}
+	names := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		names[node.Name] = true
+	}
+	return names, nil
+}
+
+// rollingPhase returns the phase of a rollout driven by the RollingUpdate strategy
+func rollingPhase(status *v1alpha1.DaemonSetRolloutStatus) string {
+	if status.UpdatedNodes < status.DesiredNodes {
+		return RolloutPhaseRollingUpdate
+	}
+	return RolloutPhaseComplete
+}
+
+// IsPodReady returns true if the pod has a Ready condition set to True
+func IsPodReady(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
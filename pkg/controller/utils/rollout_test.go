@@ -0,0 +1,367 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeRolloutClient serves pods and nodes from memory and records deletions
+type fakeRolloutClient struct {
+	pods    []corev1.Pod
+	nodes   []corev1.Node
+	deleted []string
+}
+
+func (f *fakeRolloutClient) List(_ context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	switch l := list.(type) {
+	case *corev1.PodList:
+		l.Items = f.pods
+	case *corev1.NodeList:
+		for _, node := range f.nodes {
+			if listOpts.LabelSelector == nil || listOpts.LabelSelector.Matches(labels.Set(node.Labels)) {
+				l.Items = append(l.Items, node)
+			}
+		}
+	}
+	return nil
+}
+
+func (f *fakeRolloutClient) Delete(_ context.Context, obj client.Object, _ ...client.DeleteOption) error {
+	f.deleted = append(f.deleted, obj.GetName())
+	return nil
+}
+
+func rolloutTestDaemonSet(hash string, strategy appsv1.DaemonSetUpdateStrategy) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "spire-agent", Namespace: "ns"},
+		Spec: appsv1.DaemonSetSpec{
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "spire-agent"}},
+			UpdateStrategy: strategy,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{PodTemplateHashAnnotationKey: hash}},
+			},
+		},
+		Status: appsv1.DaemonSetStatus{DesiredNumberScheduled: 3, CurrentNumberScheduled: 3},
+	}
+}
+
+func rolloutTestPod(name, node, hash string, ready bool) corev1.Pod {
+	readyStatus := corev1.ConditionFalse
+	if ready {
+		readyStatus = corev1.ConditionTrue
+	}
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "ns",
+			Labels:      map[string]string{"app": "spire-agent"},
+			Annotations: map[string]string{PodTemplateHashAnnotationKey: hash},
+		},
+		Spec:   corev1.PodSpec{NodeName: node},
+		Status: corev1.PodStatus{Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: readyStatus}}},
+	}
+}
+
+func rolloutTestNodes() []corev1.Node {
+	return []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-1", Labels: map[string]string{"canary": "true"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-3"}},
+	}
+}
+
+func TestDaemonSetUpdateStrategy(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		strategy := DaemonSetUpdateStrategy(nil)
+		assert.Equal(t, appsv1.RollingUpdateDaemonSetStrategyType, strategy.Type)
+		assert.Equal(t, intstr.FromInt32(1), *strategy.RollingUpdate.MaxUnavailable)
+		assert.Equal(t, intstr.FromInt32(0), *strategy.RollingUpdate.MaxSurge)
+	})
+
+	t.Run("custom values", func(t *testing.T) {
+		strategy := DaemonSetUpdateStrategy(&v1alpha1.DaemonSetRolloutStrategy{
+			MaxUnavailable: ptr.To(intstr.FromString("10%")),
+			MaxSurge:       ptr.To(intstr.FromInt32(2)),
+		})
+		assert.Equal(t, intstr.FromString("10%"), *strategy.RollingUpdate.MaxUnavailable)
+		assert.Equal(t, intstr.FromInt32(2), *strategy.RollingUpdate.MaxSurge)
+	})
+}
+
+func TestValidateDaemonSetRolloutStrategy(t *testing.T) {
+	tests := []struct {
+		name      string
+		strategy  *v1alpha1.DaemonSetRolloutStrategy
+		expectErr bool
+	}{
+		{name: "nil strategy", strategy: nil},
+		{name: "surge only", strategy: &v1alpha1.DaemonSetRolloutStrategy{MaxUnavailable: ptr.To(intstr.FromInt32(0)), MaxSurge: ptr.To(intstr.FromString("25%"))}},
+		{name: "percentage unavailable", strategy: &v1alpha1.DaemonSetRolloutStrategy{MaxUnavailable: ptr.To(intstr.FromString("20%"))}},
+		{name: "both zero", strategy: &v1alpha1.DaemonSetRolloutStrategy{MaxUnavailable: ptr.To(intstr.FromInt32(0))}, expectErr: true},
+		{name: "negative surge", strategy: &v1alpha1.DaemonSetRolloutStrategy{MaxSurge: ptr.To(intstr.FromInt32(-1))}, expectErr: true},
+		{name: "invalid percentage", strategy: &v1alpha1.DaemonSetRolloutStrategy{MaxUnavailable: ptr.To(intstr.FromString("abc"))}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateDaemonSetRolloutStrategy(tt.strategy)
+			if tt.expectErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestDaemonSetUpdateStrategyEqual(t *testing.T) {
+	defaulted := appsv1.DaemonSetUpdateStrategy{
+		Type: appsv1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+			MaxUnavailable: ptr.To(intstr.FromInt32(1)),
+			MaxSurge:       ptr.To(intstr.FromInt32(0)),
+		},
+	}
+	legacy := appsv1.DaemonSetUpdateStrategy{
+		Type:          appsv1.RollingUpdateDaemonSetStrategyType,
+		RollingUpdate: &appsv1.RollingUpdateDaemonSet{MaxUnavailable: &intstr.IntOrString{IntVal: 1}},
+	}
+
+	assert.True(t, daemonSetUpdateStrategyEqual(legacy, defaulted))
+	assert.True(t, daemonSetUpdateStrategyEqual(DaemonSetUpdateStrategy(nil), defaulted))
+	assert.False(t, daemonSetUpdateStrategyEqual(DaemonSetUpdateStrategy(&v1alpha1.DaemonSetRolloutStrategy{MaxSurge: ptr.To(intstr.FromInt32(1))}), defaulted))
+	assert.False(t, daemonSetUpdateStrategyEqual(appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}, defaulted))
+}
+
+func TestSetPodTemplateHash(t *testing.T) {
+	ds := rolloutTestDaemonSet("", DaemonSetUpdateStrategy(nil))
+	ds.Spec.Template.Spec.Containers = []corev1.Container{{Name: "spire-agent", Image: "image:v1"}}
+
+	SetPodTemplateHash(ds)
+	first := ds.Spec.Template.Annotations[PodTemplateHashAnnotationKey]
+	require.NotEmpty(t, first)
+
+	SetPodTemplateHash(ds)
+	assert.Equal(t, first, ds.Spec.Template.Annotations[PodTemplateHashAnnotationKey], "hash must not depend on the previous hash")
+
+	ds.Spec.Template.Spec.Containers[0].Image = "image:v2"
+	SetPodTemplateHash(ds)
+	assert.NotEqual(t, first, ds.Spec.Template.Annotations[PodTemplateHashAnnotationKey])
+}
+
+func TestPlanDaemonSetRollout(t *testing.T) {
+	t.Setenv("OPERATOR_NAMESPACE", "ns")
+	canary := &v1alpha1.DaemonSetRolloutStrategy{
+		Canary: &v1alpha1.CanaryRollout{NodeSelector: map[string]string{"canary": "true"}},
+	}
+	missingCanary := &v1alpha1.DaemonSetRolloutStrategy{
+		Canary: &v1alpha1.CanaryRollout{NodeSelector: map[string]string{"canary": "missing"}},
+	}
+	rolling := DaemonSetUpdateStrategy(nil)
+	onDelete := appsv1.DaemonSetUpdateStrategy{Type: appsv1.OnDeleteDaemonSetStrategyType}
+
+	tests := []struct {
+		name             string
+		existing         *appsv1.DaemonSet
+		desiredHash      string
+		strategy         *v1alpha1.DaemonSetRolloutStrategy
+		pods             []corev1.Pod
+		expectedPhase    string
+		expectedType     appsv1.DaemonSetUpdateStrategyType
+		expectedOutdated []string
+		expectedUpdated  int32
+		expectedNotFound bool
+	}{
+		{
+			name:          "new DaemonSet",
+			existing:      nil,
+			desiredHash:   "new",
+			strategy:      canary,
+			expectedPhase: RolloutPhaseComplete,
+			expectedType:  appsv1.RollingUpdateDaemonSetStrategyType,
+		},
+		{
+			name:        "template change without canary rolls out directly",
+			existing:    rolloutTestDaemonSet("old", rolling),
+			desiredHash: "new",
+			pods: []corev1.Pod{
+				rolloutTestPod("a", "node-1", "old", true),
+				rolloutTestPod("b", "node-2", "old", true),
+				rolloutTestPod("c", "node-3", "old", true),
+			},
+			expectedPhase: RolloutPhaseRollingUpdate,
+			expectedType:  appsv1.RollingUpdateDaemonSetStrategyType,
+		},
+		{
+			name:        "template change with canary starts canary phase",
+			existing:    rolloutTestDaemonSet("old", rolling),
+			desiredHash: "new",
+			strategy:    canary,
+			pods: []corev1.Pod{
+				rolloutTestPod("a", "node-1", "old", true),
+				rolloutTestPod("b", "node-2", "old", true),
+				rolloutTestPod("c", "node-3", "old", true),
+			},
+			expectedPhase:    RolloutPhaseCanaryInProgress,
+			expectedType:     appsv1.OnDeleteDaemonSetStrategyType,
+			expectedOutdated: []string{"a"},
+		},
+		{
+			name:        "canary pod updated but not ready keeps the rollout paused",
+			existing:    rolloutTestDaemonSet("new", onDelete),
+			desiredHash: "new",
+			strategy:    canary,
+			pods: []corev1.Pod{
+				rolloutTestPod("a2", "node-1", "new", false),
+				rolloutTestPod("b", "node-2", "old", true),
+				rolloutTestPod("c", "node-3", "old", true),
+			},
+			expectedPhase:   RolloutPhaseCanaryInProgress,
+			expectedType:    appsv1.OnDeleteDaemonSetStrategyType,
+			expectedUpdated: 1,
+		},
+		{
+			name:        "ready canary pods resume the rolling update",
+			existing:    rolloutTestDaemonSet("new", onDelete),
+			desiredHash: "new",
+			strategy:    canary,
+			pods: []corev1.Pod{
+				rolloutTestPod("a2", "node-1", "new", true),
+				rolloutTestPod("b", "node-2", "old", true),
+				rolloutTestPod("c", "node-3", "old", true),
+			},
+			expectedPhase:   RolloutPhaseRollingUpdate,
+			expectedType:    appsv1.RollingUpdateDaemonSetStrategyType,
+			expectedUpdated: 1,
+		},
+		{
+			name: "deleted canary pod not yet recreated keeps the rollout paused",
+			existing: func() *appsv1.DaemonSet {
+				ds := rolloutTestDaemonSet("new", onDelete)
+				ds.Status.CurrentNumberScheduled = 2
+				return ds
+			}(),
+			desiredHash: "new",
+			strategy:    canary,
+			pods: []corev1.Pod{
+				rolloutTestPod("b", "node-2", "old", true),
+				rolloutTestPod("c", "node-3", "old", true),
+			},
+			expectedPhase: RolloutPhaseCanaryInProgress,
+			expectedType:  appsv1.OnDeleteDaemonSetStrategyType,
+		},
+		{
+			name:        "canary selector matching no node holds the rollout",
+			existing:    rolloutTestDaemonSet("old", rolling),
+			desiredHash: "new",
+			strategy:    missingCanary,
+			pods: []corev1.Pod{
+				rolloutTestPod("a", "node-1", "old", true),
+				rolloutTestPod("b", "node-2", "old", true),
+				rolloutTestPod("c", "node-3", "old", true),
+			},
+			expectedPhase:    RolloutPhaseCanaryInProgress,
+			expectedType:     appsv1.OnDeleteDaemonSetStrategyType,
+			expectedNotFound: true,
+		},
+		{
+			name:        "canary selector matching no node keeps a started canary phase held",
+			existing:    rolloutTestDaemonSet("new", onDelete),
+			desiredHash: "new",
+			strategy:    missingCanary,
+			pods: []corev1.Pod{
+				rolloutTestPod("a2", "node-1", "new", true),
+				rolloutTestPod("b", "node-2", "old", true),
+				rolloutTestPod("c", "node-3", "old", true),
+			},
+			expectedPhase:    RolloutPhaseCanaryInProgress,
+			expectedType:     appsv1.OnDeleteDaemonSetStrategyType,
+			expectedUpdated:  1,
+			expectedNotFound: true,
+		},
+		{
+			name:        "completed rollout",
+			existing:    rolloutTestDaemonSet("new", rolling),
+			desiredHash: "new",
+			strategy:    canary,
+			pods: []corev1.Pod{
+				rolloutTestPod("a", "node-1", "new", true),
+				rolloutTestPod("b", "node-2", "new", true),
+				rolloutTestPod("c", "node-3", "new", true),
+			},
+			expectedPhase:   RolloutPhaseComplete,
+			expectedType:    appsv1.RollingUpdateDaemonSetStrategyType,
+			expectedUpdated: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &fakeRolloutClient{pods: tt.pods, nodes: rolloutTestNodes()}
+			desired := rolloutTestDaemonSet(tt.desiredHash, DaemonSetUpdateStrategy(tt.strategy))
+
+			plan, err := PlanDaemonSetRollout(context.Background(), c, tt.existing, desired, tt.strategy)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedPhase, plan.Status.Phase)
+			assert.Equal(t, tt.desiredHash, plan.Status.PodTemplateHash)
+			assert.Equal(t, tt.expectedType, desired.Spec.UpdateStrategy.Type)
+			assert.Equal(t, tt.expectedUpdated, plan.Status.UpdatedNodes)
+			assert.Equal(t, tt.expectedNotFound, plan.CanaryNodesNotFound)
+			var outdated []string
+			for _, pod := range plan.OutdatedCanaryPods {
+				outdated = append(outdated, pod.Name)
+			}
+			assert.Equal(t, tt.expectedOutdated, outdated)
+
+			require.NoError(t, DeleteOutdatedCanaryPods(context.Background(), c, plan))
+			assert.Equal(t, tt.expectedOutdated, c.deleted)
+		})
+	}
+}
+
+func TestDeleteOutdatedCanaryPods_OnlyDaemonSetPods(t *testing.T) {
+	t.Setenv("OPERATOR_NAMESPACE", "ns")
+	canary := &v1alpha1.DaemonSetRolloutStrategy{
+		Canary: &v1alpha1.CanaryRollout{NodeSelector: map[string]string{"canary": "true"}},
+	}
+
+	otherNamespace := rolloutTestPod("a", "node-1", "old", true)
+	otherNamespace.Namespace = "workloads"
+	otherLabels := rolloutTestPod("a", "node-1", "old", true)
+	otherLabels.Labels = map[string]string{"app": "workload"}
+
+	for name, pod := range map[string]corev1.Pod{"other namespace": otherNamespace, "other labels": otherLabels} {
+		t.Run(name, func(t *testing.T) {
+			c := &fakeRolloutClient{pods: []corev1.Pod{pod}, nodes: rolloutTestNodes()}
+			desired := rolloutTestDaemonSet("new", DaemonSetUpdateStrategy(canary))
+			plan, err := PlanDaemonSetRollout(context.Background(), c, rolloutTestDaemonSet("old", DaemonSetUpdateStrategy(nil)), desired, canary)
+			require.NoError(t, err)
+			require.Len(t, plan.OutdatedCanaryPods, 1)
+
+			err = DeleteOutdatedCanaryPods(context.Background(), c, plan)
+			require.ErrorContains(t, err, "refusing to delete pod")
+			assert.Empty(t, c.deleted)
+		})
+	}
+
+	t.Run("empty DaemonSet selector", func(t *testing.T) {
+		plan := &DaemonSetRolloutPlan{OutdatedCanaryPods: []corev1.Pod{rolloutTestPod("a", "node-1", "old", true)}, podSelector: labels.Everything()}
+		c := &fakeRolloutClient{}
+		require.ErrorContains(t, DeleteOutdatedCanaryPods(context.Background(), c, plan), "refusing to delete pod")
+		assert.Empty(t, c.deleted)
+	})
+}
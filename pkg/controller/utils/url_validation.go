@@ -20,6 +20,28 @@ func IsValidURL(issuerURL string) error {
 	return validateURLComponents(u)
 }
 
+// IsValidDomain validates a bare host name, such as an OIDC discovery domain.
+func IsValidDomain(domain string) error {
+	if domain == "" {
+		return fmt.Errorf("domain cannot be empty")
+	}
+
+	u, err := url.Parse("https://" + domain)
+	if err != nil {
+		return fmt.Errorf("invalid domain format: %w", err)
+	}
+
+	if err := validateURLComponents(u); err != nil {
+		return err
+	}
+
+	if u.Hostname() != domain {
+		return fmt.Errorf("domain must be a host name without scheme, port or path, got: %s", domain)
+	}
+
+	return nil
+}
+
 // NormalizeURL normalizes JWT issuer URL for consistent comparison
 func NormalizeURL(issuerURL string) (string, error) {
 	if err := IsValidURL(issuerURL); err != nil {
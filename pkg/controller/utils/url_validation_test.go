@@ -190,6 +190,66 @@ func TestIsValidJWTIssuerURL(t *testing.T) {
 	}
 }
 
+func TestIsValidDomain(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:  "host name",
+			input: "oidc.example.com",
+		},
+		{
+			name:  "single label",
+			input: "spire-spiffe-oidc-discovery-provider",
+		},
+		{
+			name:        "empty domain",
+			expectError: true,
+			errorMsg:    "domain cannot be empty",
+		},
+		{
+			name:        "domain with scheme",
+			input:       "https://oidc.example.com",
+			expectError: true,
+			errorMsg:    "without scheme, port or path",
+		},
+		{
+			name:        "domain with port",
+			input:       "oidc.example.com:8443",
+			expectError: true,
+			errorMsg:    "without scheme, port or path",
+		},
+		{
+			name:        "domain with path",
+			input:       "oidc.example.com/keys",
+			expectError: true,
+			errorMsg:    "without scheme, port or path",
+		},
+		{
+			name:        "domain with query",
+			input:       "oidc.example.com?a=b",
+			expectError: true,
+			errorMsg:    "query parameters are not allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := IsValidDomain(tt.input)
+
+			if tt.expectError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestNormalizeJWTIssuerURL(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -3,6 +3,7 @@ package utils
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"os"
 	"sort"
 	"strings"
@@ -23,6 +24,10 @@ import (
 
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
 )
 
 // logInvalidCreateOnlyModeOnce ensures we only log the warning once
@@ -188,6 +193,17 @@ func DerefResourceRequirements(r *corev1.ResourceRequirements) corev1.ResourceRe
 	return corev1.ResourceRequirements{}
 }
 
+// ContainerResourceRequirements returns the resources of the named container: its override when one is
+// configured, or the shared resources otherwise
+func ContainerResourceRequirements(name string, overrides []v1alpha1.ContainerResources, shared *corev1.ResourceRequirements) corev1.ResourceRequirements {
+	for _, override := range overrides {
+		if override.Name == name {
+			return override.Resources
+		}
+	}
+	return DerefResourceRequirements(shared)
+}
+
 func DerefAffinity(a *corev1.Affinity) corev1.Affinity {
 	if a != nil {
 		return *a
@@ -344,3 +360,16 @@ var GenerationOrOwnerReferenceChangedPredicate = predicate.Or(
 	predicate.GenerationChangedPredicate{},
 	OwnerReferenceChangedPredicate,
 )
+
+// ServerAPIDialer returns the DialFunc connecting the operator to the SPIRE server API through the proxy
+// sidecar of the spire-server pod
+func ServerAPIDialer(reader spireapi.ObjectGetter) spireapi.DialFunc {
+	return spireapi.NewProxyDialer(reader,
+		types.NamespacedName{Namespace: GetOperatorNamespace(), Name: SpireServerAPIClientSecretName},
+		ServerAPIProxyAddress())
+}
+
+// ServerAPIProxyAddress returns the address of the SPIRE server API proxy Service
+func ServerAPIProxyAddress() string {
+	return fmt.Sprintf("%s.%s.svc:%d", SpireServerAPIServiceName, GetOperatorNamespace(), SpireServerAPIProxyPort)
+}
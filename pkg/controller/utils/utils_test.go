@@ -8,6 +8,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
 )
 
 // Helper function to set environment variable and return cleanup function
@@ -397,6 +399,59 @@ func TestDerefResourceRequirements(t *testing.T) {
 	}
 }
 
+func TestContainerResourceRequirements(t *testing.T) {
+	shared := &corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("50m"),
+		},
+	}
+	override := corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("1Gi"),
+		},
+	}
+	overrides := []v1alpha1.ContainerResources{{Name: "spire-server", Resources: override}}
+
+	tests := []struct {
+		name      string
+		container string
+		overrides []v1alpha1.ContainerResources
+		shared    *corev1.ResourceRequirements
+		expected  corev1.ResourceRequirements
+	}{
+		{
+			name:      "override takes precedence over shared resources",
+			container: "spire-server",
+			overrides: overrides,
+			shared:    shared,
+			expected:  override,
+		},
+		{
+			name:      "container without override uses shared resources",
+			container: "spire-controller-manager",
+			overrides: overrides,
+			shared:    shared,
+			expected:  *shared,
+		},
+		{
+			name:      "no override and no shared resources returns empty value",
+			container: "spire-controller-manager",
+			overrides: overrides,
+			shared:    nil,
+			expected:  corev1.ResourceRequirements{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := ContainerResourceRequirements(tt.container, tt.overrides, tt.shared)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ContainerResourceRequirements() = %+v, want %+v", result, tt.expected)
+			}
+		})
+	}
+}
+
 func TestDerefAffinity(t *testing.T) {
 	tests := []struct {
 		name     string
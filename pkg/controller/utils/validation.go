@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"unsafe"
 
@@ -12,6 +13,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/kubernetes/pkg/apis/core"
 	corevalidation "k8s.io/kubernetes/pkg/apis/core/validation"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
 )
 
 // StatusManager is an interface that defines methods needed for status management
@@ -195,6 +198,25 @@ func ValidateCommonConfigResources(resources *corev1.ResourceRequirements) error
 	return nil
 }
 
+// ValidateContainerResources validates per-container resource overrides with ValidateCommonConfigResources
+// and checks that each names one of the given containers, at most once.
+func ValidateContainerResources(overrides []v1alpha1.ContainerResources, containerNames ...string) error {
+	seen := map[string]bool{}
+	for _, override := range overrides {
+		if !slices.Contains(containerNames, override.Name) {
+			return fmt.Errorf("unknown container %q, must be one of %s", override.Name, strings.Join(containerNames, ", "))
+		}
+		if seen[override.Name] {
+			return fmt.Errorf("container %q has more than one resources override", override.Name)
+		}
+		seen[override.Name] = true
+		if err := ValidateCommonConfigResources(&override.Resources); err != nil {
+			return fmt.Errorf("container %q: %w", override.Name, err)
+		}
+	}
+	return nil
+}
+
 // ValidateCommonConfigLabels validates labels configuration using Kubernetes validation functions.
 func ValidateCommonConfigLabels(labels map[string]string) error {
 	if len(labels) == 0 {
@@ -7,6 +7,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/klog/v2/textlogger"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
 )
 
 func TestValidateCommonConfigAffinity(t *testing.T) {
@@ -440,6 +442,73 @@ func TestValidateCommonConfigResources(t *testing.T) {
 	}
 }
 
+func TestValidateContainerResources(t *testing.T) {
+	validResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU: resource.MustParse("100m"),
+		},
+	}
+	containerNames := []string{"spire-server", "spire-controller-manager"}
+
+	tests := []struct {
+		name      string
+		overrides []v1alpha1.ContainerResources
+		wantError bool
+	}{
+		{
+			name:      "no overrides is valid",
+			overrides: nil,
+			wantError: false,
+		},
+		{
+			name: "valid overrides for known containers",
+			overrides: []v1alpha1.ContainerResources{
+				{Name: "spire-server", Resources: validResources},
+				{Name: "spire-controller-manager", Resources: validResources},
+			},
+			wantError: false,
+		},
+		{
+			name: "unknown container",
+			overrides: []v1alpha1.ContainerResources{
+				{Name: "spire-agent", Resources: validResources},
+			},
+			wantError: true,
+		},
+		{
+			name: "duplicate container",
+			overrides: []v1alpha1.ContainerResources{
+				{Name: "spire-server", Resources: validResources},
+				{Name: "spire-server", Resources: validResources},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid resources - limit less than request",
+			overrides: []v1alpha1.ContainerResources{
+				{Name: "spire-server", Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("500m"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU: resource.MustParse("100m"),
+					},
+				}},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateContainerResources(tt.overrides, containerNames...)
+			if (err != nil) != tt.wantError {
+				t.Errorf("ValidateContainerResources() error = %v, wantError %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
 func TestValidateCommonConfigLabels(t *testing.T) {
 	tests := []struct {
 		name      string
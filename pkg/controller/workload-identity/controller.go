@@ -0,0 +1,348 @@
+package workload_identity
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	customClient "github.com/openshift/zero-trust-workload-identity-manager/pkg/client"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/version"
+)
+
+const (
+	// Kubernetes-compliant condition names
+	PolicyCompliant          = "PolicyCompliant"
+	ClusterSPIFFEIDAvailable = "ClusterSPIFFEIDAvailable"
+
+	// workloadIdentityFinalizer removes the ClusterSPIFFEID, which cannot be owned by a namespaced resource
+	workloadIdentityFinalizer = "operator.openshift.io/workload-identity"
+
+	// Labels linking a ClusterSPIFFEID to its WorkloadIdentity. The name label is left out when the
+	// name does not fit in a label value, the annotations always link both.
+	WorkloadIdentityNamespaceLabel = "operator.openshift.io/workload-identity-namespace"
+	WorkloadIdentityNameLabel      = "operator.openshift.io/workload-identity-name"
+
+	WorkloadIdentityNamespaceAnnotation = "operator.openshift.io/workload-identity-namespace"
+	WorkloadIdentityNameAnnotation      = "operator.openshift.io/workload-identity-name"
+
+	clusterSPIFFEIDClassName = "zero-trust-workload-identity-manager-spire"
+	clusterSPIFFEIDPrefix    = "workloadidentity."
+	// clusterSPIFFEIDHashLength is the number of hex characters of the hash ending names too long to
+	// be used as they are
+	clusterSPIFFEIDHashLength = 16
+
+	defaultSPIFFEIDPath = "/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}"
+)
+
+// WorkloadIdentityReconciler reconciles a WorkloadIdentity object
+type WorkloadIdentityReconciler struct {
+	ctrlClient    customClient.CustomCtrlClient
+	ctx           context.Context
+	eventRecorder record.EventRecorder
+	log           logr.Logger
+	scheme        *runtime.Scheme
+}
+
+// New returns a new Reconciler instance.
+func New(mgr ctrl.Manager) (*WorkloadIdentityReconciler, error) {
+	c, err := customClient.NewCustomClient(mgr)
+	if err != nil {
+		return nil, err
+	}
+	return &WorkloadIdentityReconciler{
+		ctrlClient:    c,
+		ctx:           context.Background(),
+		eventRecorder: mgr.GetEventRecorderFor(utils.ZeroTrustWorkloadIdentityManagerWorkloadIdentityControllerName),
+		log:           ctrl.Log.WithName(utils.ZeroTrustWorkloadIdentityManagerWorkloadIdentityControllerName),
+		scheme:        mgr.GetScheme(),
+	}, nil
+}
+
+func (r *WorkloadIdentityReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	r.log.Info(fmt.Sprintf("reconciling %s", utils.ZeroTrustWorkloadIdentityManagerWorkloadIdentityControllerName), "namespace", req.Namespace, "name", req.Name)
+	var workloadIdentity v1alpha1.WorkloadIdentity
+	if err := r.ctrlClient.Get(ctx, req.NamespacedName, &workloadIdentity); err != nil {
+		if kerrors.IsNotFound(err) {
+			r.log.Info("WorkloadIdentity resource not found. Ignoring since object must be deleted or not been created.")
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	if !workloadIdentity.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, &workloadIdentity)
+	}
+	if controllerutil.AddFinalizer(&workloadIdentity, workloadIdentityFinalizer) {
+		if err := r.ctrlClient.Update(ctx, &workloadIdentity); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	statusMgr := status.NewManager(r.ctrlClient)
+	originalStatus := workloadIdentity.Status.DeepCopy()
+	defer func() {
+		if !equality.Semantic.DeepEqual(originalStatus, &workloadIdentity.Status) {
+			statusMgr.RequestStatusUpdate()
+		}
+		if err := statusMgr.ApplyStatus(ctx, &workloadIdentity, func() *v1alpha1.ConditionalStatus {
+			return &workloadIdentity.Status.ConditionalStatus
+		}); err != nil {
+			r.log.Error(err, "failed to update status")
+		}
+	}()
+
+	policy, err := r.namespacePolicy(ctx, workloadIdentity.Namespace)
+	if err != nil {
+		r.log.Error(err, "failed to evaluate WorkloadIdentityPolicy resources", "namespace", workloadIdentity.Namespace)
+		statusMgr.AddCondition(PolicyCompliant, "PolicyEvaluationFailed", err.Error(), metav1.ConditionFalse)
+		return ctrl.Result{}, err
+	}
+	if err := validateWorkloadIdentity(&workloadIdentity, policy); err != nil {
+		r.log.Info("WorkloadIdentity violates policy", "namespace", workloadIdentity.Namespace, "name", workloadIdentity.Name, "reason", err.Error())
+		statusMgr.AddCondition(PolicyCompliant, "PolicyViolation", err.Error(), metav1.ConditionFalse)
+		r.eventRecorder.Event(&workloadIdentity, corev1.EventTypeWarning, "PolicyViolation", err.Error())
+		// Pods must not keep an identity the policy no longer allows
+		if err := r.deleteClusterSPIFFEID(ctx, &workloadIdentity); err != nil {
+			return ctrl.Result{}, err
+		}
+		statusMgr.RemoveCondition(ClusterSPIFFEIDAvailable)
+		workloadIdentity.Status.ClusterSPIFFEIDName = ""
+		workloadIdentity.Status.SPIFFEIDTemplate = ""
+		return ctrl.Result{}, nil
+	}
+	statusMgr.AddCondition(PolicyCompliant, "PolicyAllowed",
+		"WorkloadIdentity is allowed by the policies of the namespace",
+		metav1.ConditionTrue)
+
+	desired := generateClusterSPIFFEID(&workloadIdentity)
+	if err := r.reconcileClusterSPIFFEID(ctx, desired, statusMgr, utils.IsInCreateOnlyMode()); err != nil {
+		return ctrl.Result{}, err
+	}
+	workloadIdentity.Status.ClusterSPIFFEIDName = desired.Name
+	workloadIdentity.Status.SPIFFEIDTemplate = desired.Spec.SPIFFEIDTemplate
+	return ctrl.Result{}, nil
+}
+
+// finalize deletes the ClusterSPIFFEID and releases the WorkloadIdentity
+func (r *WorkloadIdentityReconciler) finalize(ctx context.Context, workloadIdentity *v1alpha1.WorkloadIdentity) error {
+	if !controllerutil.ContainsFinalizer(workloadIdentity, workloadIdentityFinalizer) {
+		return nil
+	}
+	if err := r.deleteClusterSPIFFEID(ctx, workloadIdentity); err != nil {
+		return err
+	}
+	controllerutil.RemoveFinalizer(workloadIdentity, workloadIdentityFinalizer)
+	return r.ctrlClient.Update(ctx, workloadIdentity)
+}
+
+func (r *WorkloadIdentityReconciler) deleteClusterSPIFFEID(ctx context.Context, workloadIdentity *v1alpha1.WorkloadIdentity) error {
+	clusterSPIFFEID := &spiffev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{Name: clusterSPIFFEIDName(workloadIdentity)},
+	}
+	if err := r.ctrlClient.Delete(ctx, clusterSPIFFEID); err != nil && !kerrors.IsNotFound(err) {
+		r.log.Error(err, "failed to delete ClusterSPIFFEID", "name", clusterSPIFFEID.Name)
+		return err
+	}
+	return nil
+}
+
+// namespacePolicy returns what the namespace may request according to the WorkloadIdentityPolicy resources
+func (r *WorkloadIdentityReconciler) namespacePolicy(ctx context.Context, namespace string) (*namespacePolicy, error) {
+	ns := &corev1.Namespace{}
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return nil, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+	var policies v1alpha1.WorkloadIdentityPolicyList
+	if err := r.ctrlClient.List(ctx, &policies); err != nil {
+		return nil, fmt.Errorf("failed to list WorkloadIdentityPolicy resources: %w", err)
+	}
+	return policyForNamespace(namespace, ns.Labels, policies.Items)
+}
+
+// reconcileClusterSPIFFEID creates or updates the ClusterSPIFFEID of the WorkloadIdentity
+func (r *WorkloadIdentityReconciler) reconcileClusterSPIFFEID(ctx context.Context, desired *spiffev1alpha1.ClusterSPIFFEID, statusMgr *status.Manager, createOnlyMode bool) error {
+	existing := &spiffev1alpha1.ClusterSPIFFEID{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name}, existing)
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			r.log.Error(err, "failed to get ClusterSPIFFEID", "name", desired.Name)
+			statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "ClusterSPIFFEIDGetFailed",
+				fmt.Sprintf("Failed to get ClusterSPIFFEID: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, ClusterSPIFFEIDAvailable); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create ClusterSPIFFEID", "name", desired.Name)
+			statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "ClusterSPIFFEIDCreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.log.Info("Created ClusterSPIFFEID", "name", desired.Name)
+	} else if utils.ResourceNeedsUpdate(existing, desired) {
+		if createOnlyMode {
+			r.log.Info("Skipping ClusterSPIFFEID update due to create-only mode", "name", desired.Name)
+		} else {
+			desired.ResourceVersion = existing.ResourceVersion
+			if err := r.ctrlClient.Update(ctx, desired); err != nil {
+				r.log.Error(err, "failed to update ClusterSPIFFEID", "name", desired.Name)
+				statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "ClusterSPIFFEIDUpdateFailed",
+					fmt.Sprintf("Failed to update ClusterSPIFFEID: %v", err),
+					metav1.ConditionFalse)
+				return err
+			}
+			r.log.Info("Updated ClusterSPIFFEID", "name", desired.Name)
+		}
+	} else {
+		r.log.V(1).Info("ClusterSPIFFEID is up to date", "name", desired.Name)
+	}
+
+	statusMgr.AddCondition(ClusterSPIFFEIDAvailable, "ClusterSPIFFEIDReady",
+		fmt.Sprintf("ClusterSPIFFEID %s is ready", desired.Name),
+		metav1.ConditionTrue)
+	return nil
+}
+
+// generateClusterSPIFFEID returns the ClusterSPIFFEID for the WorkloadIdentity, confined to its namespace
+func generateClusterSPIFFEID(workloadIdentity *v1alpha1.WorkloadIdentity) *spiffev1alpha1.ClusterSPIFFEID {
+	labels := utils.StandardizedLabels("workload-identity", utils.ComponentControlPlane, version.SpireControllerManagerVersion, nil)
+	labels[WorkloadIdentityNamespaceLabel] = workloadIdentity.Namespace
+	if len(validation.IsValidLabelValue(workloadIdentity.Name)) == 0 {
+		labels[WorkloadIdentityNameLabel] = workloadIdentity.Name
+	}
+
+	spec := workloadIdentity.Spec
+	clusterSPIFFEID := &spiffev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   clusterSPIFFEIDName(workloadIdentity),
+			Labels: labels,
+			Annotations: map[string]string{
+				WorkloadIdentityNamespaceAnnotation: workloadIdentity.Namespace,
+				WorkloadIdentityNameAnnotation:      workloadIdentity.Name,
+			},
+		},
+		Spec: spiffev1alpha1.ClusterSPIFFEIDSpec{
+			ClassName:            clusterSPIFFEIDClassName,
+			SPIFFEIDTemplate:     "spiffe://{{ .TrustDomain }}" + spiffeIDPath(workloadIdentity),
+			Hint:                 spec.Hint,
+			DNSNameTemplates:     spec.DNSNameTemplates,
+			AutoPopulateDNSNames: utils.StringToBool(spec.AutoPopulateDNSNames),
+			FederatesWith:        spec.FederatesWith,
+			PodSelector:          spec.PodSelector,
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "kubernetes.io/metadata.name",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{workloadIdentity.Namespace},
+					},
+				},
+			},
+		},
+	}
+	if spec.TTL != nil {
+		clusterSPIFFEID.Spec.TTL = *spec.TTL
+	}
+	if spec.JWTTTL != nil {
+		clusterSPIFFEID.Spec.JWTTTL = *spec.JWTTTL
+	}
+	return clusterSPIFFEID
+}
+
+// clusterSPIFFEIDName is unique per WorkloadIdentity since namespace names cannot contain dots. Names
+// longer than an object name allows are truncated and end with a hash of the full name instead.
+func clusterSPIFFEIDName(workloadIdentity *v1alpha1.WorkloadIdentity) string {
+	name := clusterSPIFFEIDPrefix + workloadIdentity.Namespace + "." + workloadIdentity.Name
+	if len(name) <= validation.DNS1123SubdomainMaxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	truncated := strings.TrimRight(name[:validation.DNS1123SubdomainMaxLength-clusterSPIFFEIDHashLength-1], ".-")
+	return truncated + "-" + hex.EncodeToString(sum[:])[:clusterSPIFFEIDHashLength]
+}
+
+func spiffeIDPath(workloadIdentity *v1alpha1.WorkloadIdentity) string {
+	if workloadIdentity.Spec.SPIFFEIDPath == "" {
+		return defaultSPIFFEIDPath
+	}
+	return workloadIdentity.Spec.SPIFFEIDPath
+}
+
+func (r *WorkloadIdentityReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Enqueue the WorkloadIdentity a ClusterSPIFFEID was generated for
+	clusterSPIFFEIDMapFunc := func(ctx context.Context, obj client.Object) []reconcile.Request {
+		annotations, labels := obj.GetAnnotations(), obj.GetLabels()
+		namespace, name := annotations[WorkloadIdentityNamespaceAnnotation], annotations[WorkloadIdentityNameAnnotation]
+		if namespace == "" || name == "" {
+			// ClusterSPIFFEIDs created before the annotations were added
+			namespace, name = labels[WorkloadIdentityNamespaceLabel], labels[WorkloadIdentityNameLabel]
+		}
+		if namespace == "" || name == "" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+	}
+
+	// Policies can change what any namespace may request, so every WorkloadIdentity is checked again
+	policyMapFunc := func(ctx context.Context, _ client.Object) []reconcile.Request {
+		var workloadIdentities v1alpha1.WorkloadIdentityList
+		if err := r.ctrlClient.List(ctx, &workloadIdentities); err != nil {
+			r.log.Error(err, "failed to list WorkloadIdentity resources")
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(workloadIdentities.Items))
+		for _, workloadIdentity := range workloadIdentities.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&workloadIdentity)})
+		}
+		return requests
+	}
+
+	// Relabeling a namespace can change which policies select it
+	namespaceMapFunc := func(ctx context.Context, obj client.Object) []reconcile.Request {
+		var workloadIdentities v1alpha1.WorkloadIdentityList
+		if err := r.ctrlClient.List(ctx, &workloadIdentities, client.InNamespace(obj.GetName())); err != nil {
+			r.log.Error(err, "failed to list WorkloadIdentity resources", "namespace", obj.GetName())
+			return nil
+		}
+		requests := make([]reconcile.Request, 0, len(workloadIdentities.Items))
+		for _, workloadIdentity := range workloadIdentities.Items {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&workloadIdentity)})
+		}
+		return requests
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.WorkloadIdentity{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Named(utils.ZeroTrustWorkloadIdentityManagerWorkloadIdentityControllerName).
+		Watches(&spiffev1alpha1.ClusterSPIFFEID{}, handler.EnqueueRequestsFromMapFunc(clusterSPIFFEIDMapFunc)).
+		Watches(&v1alpha1.WorkloadIdentityPolicy{}, handler.EnqueueRequestsFromMapFunc(policyMapFunc), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Namespace{}, handler.EnqueueRequestsFromMapFunc(namespaceMapFunc), builder.WithPredicates(predicate.LabelChangedPredicate{})).
+		Complete(r)
+}
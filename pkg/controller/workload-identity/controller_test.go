@@ -0,0 +1,255 @@
+package workload_identity
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func newTestReconciler(fakeClient *fakes.FakeCustomCtrlClient) *WorkloadIdentityReconciler {
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	_ = spiffev1alpha1.AddToScheme(scheme)
+	return &WorkloadIdentityReconciler{
+		ctrlClient:    fakeClient,
+		ctx:           context.Background(),
+		eventRecorder: record.NewFakeRecorder(100),
+		log:           logr.Discard(),
+		scheme:        scheme,
+	}
+}
+
+func newTestWorkloadIdentity(spec v1alpha1.WorkloadIdentitySpec) *v1alpha1.WorkloadIdentity {
+	return &v1alpha1.WorkloadIdentity{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "api",
+			Namespace:  "payments",
+			Finalizers: []string{workloadIdentityFinalizer},
+		},
+		Spec: spec,
+	}
+}
+
+// stubClient serves the WorkloadIdentity, its namespace, the policies and optionally an existing ClusterSPIFFEID
+func stubClient(fakeClient *fakes.FakeCustomCtrlClient, workloadIdentity *v1alpha1.WorkloadIdentity, policies []v1alpha1.WorkloadIdentityPolicy, existing *spiffev1alpha1.ClusterSPIFFEID) {
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		switch o := obj.(type) {
+		case *v1alpha1.WorkloadIdentity:
+			workloadIdentity.DeepCopyInto(o)
+		case *spiffev1alpha1.ClusterSPIFFEID:
+			if existing == nil {
+				return kerrors.NewNotFound(schema.GroupResource{Resource: "clusterspiffeids"}, key.Name)
+			}
+			existing.DeepCopyInto(o)
+		case *corev1.Namespace:
+			o.Name = key.Name
+			o.Labels = map[string]string{"team": "payments"}
+		}
+		return nil
+	}
+	fakeClient.ListStub = func(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+		if l, ok := list.(*v1alpha1.WorkloadIdentityPolicyList); ok {
+			l.Items = policies
+		}
+		return nil
+	}
+}
+
+// lastStatus returns the WorkloadIdentity passed to the last status update
+func lastStatus(t *testing.T, fakeClient *fakes.FakeCustomCtrlClient) *v1alpha1.WorkloadIdentity {
+	t.Helper()
+	require.Positive(t, fakeClient.StatusUpdateWithRetryCallCount())
+	_, obj, _ := fakeClient.StatusUpdateWithRetryArgsForCall(fakeClient.StatusUpdateWithRetryCallCount() - 1)
+	workloadIdentity, ok := obj.(*v1alpha1.WorkloadIdentity)
+	require.True(t, ok)
+	return workloadIdentity
+}
+
+func reconcileRequest() ctrl.Request {
+	return ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "payments", Name: "api"}}
+}
+
+func TestReconcile_CreatesClusterSPIFFEID(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+	stubClient(fakeClient, newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{
+		PodSelector:          &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+		SPIFFEIDPath:         "/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		AutoPopulateDNSNames: "true",
+		TTL:                  &metav1.Duration{Duration: time.Hour},
+	}), []v1alpha1.WorkloadIdentityPolicy{
+		newPolicy("payments", map[string]string{"team": "payments"}, v1alpha1.WorkloadIdentityPolicySpec{
+			AllowAutoPopulateDNSNames: "true",
+			MaxTTL:                    duration(24 * time.Hour),
+		}),
+	}, nil)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcileRequest())
+
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.CreateCallCount())
+	_, obj, _ := fakeClient.CreateArgsForCall(0)
+	created, ok := obj.(*spiffev1alpha1.ClusterSPIFFEID)
+	require.True(t, ok)
+	assert.Equal(t, "workloadidentity.payments.api", created.Name)
+	assert.Equal(t, "payments", created.Labels[WorkloadIdentityNamespaceLabel])
+	assert.Equal(t, "api", created.Labels[WorkloadIdentityNameLabel])
+	assert.Equal(t, "payments", created.Annotations[WorkloadIdentityNamespaceAnnotation])
+	assert.Equal(t, "api", created.Annotations[WorkloadIdentityNameAnnotation])
+	assert.Equal(t, utils.AppManagedByLabelValue, created.Labels[utils.AppManagedByLabelKey])
+	assert.Equal(t, clusterSPIFFEIDClassName, created.Spec.ClassName)
+	assert.Equal(t, "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}", created.Spec.SPIFFEIDTemplate)
+	assert.Equal(t, []string{"payments"}, created.Spec.NamespaceSelector.MatchExpressions[0].Values)
+	assert.True(t, created.Spec.AutoPopulateDNSNames)
+	assert.Equal(t, time.Hour, created.Spec.TTL.Duration)
+
+	status := lastStatus(t, fakeClient)
+	assert.Equal(t, "workloadidentity.payments.api", status.Status.ClusterSPIFFEIDName)
+	assert.True(t, apimeta.IsStatusConditionTrue(status.Status.Conditions, PolicyCompliant))
+	assert.True(t, apimeta.IsStatusConditionTrue(status.Status.Conditions, ClusterSPIFFEIDAvailable))
+}
+
+func TestReconcile_UpdatesChangedClusterSPIFFEID(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+	workloadIdentity := newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{Hint: "api"})
+	existing := generateClusterSPIFFEID(workloadIdentity)
+	existing.ResourceVersion = "7"
+	existing.Spec.Hint = "old"
+	policies := []v1alpha1.WorkloadIdentityPolicy{
+		newPolicy("payments", nil, v1alpha1.WorkloadIdentityPolicySpec{AllowedHints: []string{"api"}}),
+	}
+	stubClient(fakeClient, workloadIdentity, policies, existing)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcileRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, fakeClient.CreateCallCount())
+	require.Equal(t, 1, fakeClient.UpdateCallCount())
+	_, obj, _ := fakeClient.UpdateArgsForCall(0)
+	updated := obj.(*spiffev1alpha1.ClusterSPIFFEID)
+	assert.Equal(t, "api", updated.Spec.Hint)
+	assert.Equal(t, "7", updated.ResourceVersion)
+}
+
+func TestReconcile_AddsFinalizer(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+	workloadIdentity := newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{})
+	workloadIdentity.Finalizers = nil
+	stubClient(fakeClient, workloadIdentity, nil, nil)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcileRequest())
+
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.UpdateCallCount())
+	_, obj, _ := fakeClient.UpdateArgsForCall(0)
+	assert.Contains(t, obj.GetFinalizers(), workloadIdentityFinalizer)
+}
+
+func TestReconcile_PolicyViolation(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+	workloadIdentity := newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{SPIFFEIDPath: "/ns/billing/sa/api"})
+	workloadIdentity.Status.ClusterSPIFFEIDName = "workloadidentity.payments.api"
+	stubClient(fakeClient, workloadIdentity, nil, nil)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcileRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, fakeClient.CreateCallCount())
+	require.Equal(t, 1, fakeClient.DeleteCallCount())
+	_, obj, _ := fakeClient.DeleteArgsForCall(0)
+	assert.Equal(t, "workloadidentity.payments.api", obj.GetName())
+
+	status := lastStatus(t, fakeClient)
+	assert.Empty(t, status.Status.ClusterSPIFFEIDName)
+	condition := apimeta.FindStatusCondition(status.Status.Conditions, PolicyCompliant)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "PolicyViolation", condition.Reason)
+}
+
+func TestReconcile_AllowedByPolicy(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+	policies := []v1alpha1.WorkloadIdentityPolicy{
+		newPolicy("payments", map[string]string{"team": "payments"}, v1alpha1.WorkloadIdentityPolicySpec{
+			AllowedSPIFFEIDPathPrefixes: []string{"/team/{namespace}/"},
+			AllowedFederatesWith:        []string{"partner.example.com"},
+		}),
+	}
+	stubClient(fakeClient, newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{
+		SPIFFEIDPath:  "/team/payments/api",
+		FederatesWith: []string{"partner.example.com"},
+	}), policies, nil)
+
+	_, err := reconciler.Reconcile(context.Background(), reconcileRequest())
+
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.CreateCallCount())
+	_, obj, _ := fakeClient.CreateArgsForCall(0)
+	created := obj.(*spiffev1alpha1.ClusterSPIFFEID)
+	assert.Equal(t, "spiffe://{{ .TrustDomain }}/team/payments/api", created.Spec.SPIFFEIDTemplate)
+	assert.Equal(t, []string{"partner.example.com"}, created.Spec.FederatesWith)
+}
+
+func TestClusterSPIFFEIDName(t *testing.T) {
+	short := newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{})
+	assert.Equal(t, "workloadidentity.payments.api", clusterSPIFFEIDName(short))
+
+	long := newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{})
+	long.Namespace = strings.Repeat("n", 63)
+	long.Name = strings.Repeat("a", 253)
+	name := clusterSPIFFEIDName(long)
+	assert.Len(t, name, validation.DNS1123SubdomainMaxLength)
+	assert.Empty(t, validation.IsDNS1123Subdomain(name))
+
+	other := long.DeepCopy()
+	other.Name = strings.Repeat("a", 252) + "b"
+	assert.NotEqual(t, name, clusterSPIFFEIDName(other))
+
+	generated := generateClusterSPIFFEID(long)
+	assert.NotContains(t, generated.Labels, WorkloadIdentityNameLabel)
+	assert.Equal(t, long.Name, generated.Annotations[WorkloadIdentityNameAnnotation])
+}
+
+func TestReconcile_Deletion(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newTestReconciler(fakeClient)
+	workloadIdentity := newTestWorkloadIdentity(v1alpha1.WorkloadIdentitySpec{})
+	now := metav1.Now()
+	workloadIdentity.DeletionTimestamp = &now
+	stubClient(fakeClient, workloadIdentity, nil, nil)
+	fakeClient.DeleteReturns(kerrors.NewNotFound(schema.GroupResource{Resource: "clusterspiffeids"}, "workloadidentity.payments.api"))
+
+	_, err := reconciler.Reconcile(context.Background(), reconcileRequest())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, fakeClient.DeleteCallCount())
+	require.Equal(t, 1, fakeClient.UpdateCallCount())
+	_, obj, _ := fakeClient.UpdateArgsForCall(0)
+	assert.NotContains(t, obj.GetFinalizers(), workloadIdentityFinalizer)
+	assert.Equal(t, 0, fakeClient.StatusUpdateWithRetryCallCount())
+}
@@ -0,0 +1,222 @@
+package workload_identity
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+const (
+	// namespacePlaceholder is replaced with the namespace name in allowed path prefixes
+	namespacePlaceholder = "{namespace}"
+
+	// podNamespaceTemplate renders to the namespace of the pod, which is locked to the WorkloadIdentity namespace
+	podNamespaceTemplate = "{{ .PodMeta.Namespace }}"
+)
+
+// namespacePolicy is what a namespace may request, merged from every policy selecting it
+type namespacePolicy struct {
+	// selected is set when at least one policy selects the namespace
+	selected     bool
+	pathPrefixes []string
+	// dnsNames are exact names, or domains whose names are allowed when prefixed with "*."
+	dnsNames      []string
+	federatesWith []string
+	hints         []string
+	// maxTTL and maxJWTTTL are nil when unlimited, which only applies once a policy selects the namespace
+	maxTTL    *time.Duration
+	maxJWTTTL *time.Duration
+	// allowSVIDSecrets is set when any policy lets the namespace write SVIDs into Secrets
+	allowSVIDSecrets bool
+	// allowAutoPopulateDNSNames is set when any policy lets the namespace add the names of its Services
+	allowAutoPopulateDNSNames bool
+}
+
+// policyForNamespace merges the policies selecting the namespace. The namespace's own
+// /ns/<namespace>/ prefix and the DNS names of its Services are always allowed, anything else
+// requires a policy.
+func policyForNamespace(namespace string, namespaceLabels map[string]string, policies []v1alpha1.WorkloadIdentityPolicy) (*namespacePolicy, error) {
+	merged := &namespacePolicy{
+		pathPrefixes: []string{fmt.Sprintf("/ns/%s/", namespace)},
+		dnsNames:     []string{fmt.Sprintf("*.%s.svc", namespace), fmt.Sprintf("*.%s.svc.cluster.local", namespace)},
+	}
+
+	ttlLimited, jwtTTLLimited := true, true
+	for _, policy := range policies {
+		matches, err := selectsNamespace(policy.Spec.NamespaceSelector, namespaceLabels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespaceSelector in WorkloadIdentityPolicy %s: %w", policy.Name, err)
+		}
+		if !matches {
+			continue
+		}
+		merged.selected = true
+
+		for _, prefix := range policy.Spec.AllowedSPIFFEIDPathPrefixes {
+			merged.pathPrefixes = appendUnique(merged.pathPrefixes, strings.ReplaceAll(prefix, namespacePlaceholder, namespace))
+		}
+		for _, name := range policy.Spec.AllowedDNSNames {
+			merged.dnsNames = appendUnique(merged.dnsNames, strings.ToLower(strings.ReplaceAll(name, namespacePlaceholder, namespace)))
+		}
+		for _, trustDomain := range policy.Spec.AllowedFederatesWith {
+			merged.federatesWith = appendUnique(merged.federatesWith, trustDomain)
+		}
+		for _, hint := range policy.Spec.AllowedHints {
+			merged.hints = appendUnique(merged.hints, strings.ReplaceAll(hint, namespacePlaceholder, namespace))
+		}
+		// The most permissive policy wins, and a policy without a limit lifts it
+		ttlLimited, merged.maxTTL = mergeMax(ttlLimited, merged.maxTTL, policy.Spec.MaxTTL)
+		jwtTTLLimited, merged.maxJWTTTL = mergeMax(jwtTTLLimited, merged.maxJWTTTL, policy.Spec.MaxJWTTTL)
+		merged.allowSVIDSecrets = merged.allowSVIDSecrets || utils.StringToBool(policy.Spec.AllowSVIDSecrets)
+		merged.allowAutoPopulateDNSNames = merged.allowAutoPopulateDNSNames || utils.StringToBool(policy.Spec.AllowAutoPopulateDNSNames)
+	}
+
+	return merged, nil
+}
+
+// mergeMax keeps the largest limit, or no limit once any policy has none
+func mergeMax(limited bool, current *time.Duration, limit *metav1.Duration) (bool, *time.Duration) {
+	if !limited {
+		return false, nil
+	}
+	if limit == nil {
+		return false, nil
+	}
+	if current == nil || limit.Duration > *current {
+		return true, &limit.Duration
+	}
+	return true, current
+}
+
+func selectsNamespace(selector *metav1.LabelSelector, namespaceLabels map[string]string) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	return s.Matches(labels.Set(namespaceLabels)), nil
+}
+
+// validateWorkloadIdentity checks the WorkloadIdentity against the namespace policy
+func validateWorkloadIdentity(workloadIdentity *v1alpha1.WorkloadIdentity, policy *namespacePolicy) error {
+	path := spiffeIDPath(workloadIdentity)
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("spiffeIDPath %q must start with /", path)
+	}
+
+	// Only the literal part of the template before the first action is known, so it must contain a whole allowed prefix
+	literal := strings.ReplaceAll(path, podNamespaceTemplate, workloadIdentity.Namespace)
+	if i := strings.Index(literal, "{{"); i >= 0 {
+		literal = literal[:i]
+	}
+	if !slices.ContainsFunc(policy.pathPrefixes, func(prefix string) bool { return strings.HasPrefix(literal, prefix) }) {
+		return fmt.Errorf("spiffeIDPath %q is not under an allowed prefix %v", path, policy.pathPrefixes)
+	}
+
+	for _, template := range workloadIdentity.Spec.DNSNameTemplates {
+		if !policy.allowsDNSNameTemplate(template, workloadIdentity.Namespace) {
+			return fmt.Errorf("dnsNameTemplate %q is not allowed, allowed names are %v", template, policy.dnsNames)
+		}
+	}
+	if utils.StringToBool(workloadIdentity.Spec.AutoPopulateDNSNames) && !policy.allowAutoPopulateDNSNames {
+		return fmt.Errorf("no WorkloadIdentityPolicy allows autoPopulateDNSNames in namespace %s", workloadIdentity.Namespace)
+	}
+	if hint := workloadIdentity.Spec.Hint; hint != "" && !slices.Contains(policy.hints, hint) {
+		return fmt.Errorf("hint %q is not allowed, allowed hints are %v", hint, policy.hints)
+	}
+
+	for _, trustDomain := range workloadIdentity.Spec.FederatesWith {
+		if !slices.Contains(policy.federatesWith, trustDomain) {
+			return fmt.Errorf("federation with trust domain %q is not allowed", trustDomain)
+		}
+	}
+
+	if err := policy.checkTTL("ttl", workloadIdentity.Spec.TTL, policy.maxTTL); err != nil {
+		return err
+	}
+	return policy.checkTTL("jwtTTL", workloadIdentity.Spec.JWTTTL, policy.maxJWTTTL)
+}
+
+// checkTTL refuses a TTL above the limit. A namespace no policy selects gets the default TTLs of the
+// SPIRE server, since an absent policy must not mean an unlimited TTL.
+func (p *namespacePolicy) checkTTL(field string, ttl *metav1.Duration, limit *time.Duration) error {
+	if ttl == nil {
+		return nil
+	}
+	if !p.selected {
+		return fmt.Errorf("%s is not allowed, no WorkloadIdentityPolicy selects the namespace", field)
+	}
+	if limit != nil && ttl.Duration > *limit {
+		return fmt.Errorf("%s %s exceeds the maximum of %s", field, ttl.Duration, *limit)
+	}
+	return nil
+}
+
+// ValidateSVIDSecret checks the SVIDSecret against the WorkloadIdentityPolicy resources selecting its namespace
+func ValidateSVIDSecret(svidSecret *v1alpha1.SVIDSecret, namespaceLabels map[string]string, policies []v1alpha1.WorkloadIdentityPolicy) error {
+	policy, err := policyForNamespace(svidSecret.Namespace, namespaceLabels, policies)
+	if err != nil {
+		return err
+	}
+	if !policy.allowSVIDSecrets {
+		return fmt.Errorf("no WorkloadIdentityPolicy allows SVIDSecrets in namespace %s", svidSecret.Namespace)
+	}
+
+	path := svidSecret.Spec.SPIFFEIDPath
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("spiffeIDPath %q must start with /", path)
+	}
+	if !slices.ContainsFunc(policy.pathPrefixes, func(prefix string) bool { return strings.HasPrefix(path, prefix) }) {
+		return fmt.Errorf("spiffeIDPath %q is not under an allowed prefix %v", path, policy.pathPrefixes)
+	}
+	for _, name := range svidSecret.Spec.DNSNames {
+		if !policy.allowsDNSName(name) {
+			return fmt.Errorf("dnsName %q is not allowed, allowed names are %v", name, policy.dnsNames)
+		}
+	}
+	return policy.checkTTL("ttl", svidSecret.Spec.TTL, policy.maxTTL)
+}
+
+// allowsDNSName reports whether the name matches an allowed name. A "*." pattern matches names
+// of any depth under its domain, but not the domain itself.
+func (p *namespacePolicy) allowsDNSName(name string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	return slices.ContainsFunc(p.dnsNames, func(pattern string) bool {
+		if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+			return strings.HasSuffix(name, "."+domain) && len(name) > len(domain)+1
+		}
+		return name == pattern
+	})
+}
+
+// allowsDNSNameTemplate reports whether every name the template can render is allowed. Only the
+// namespace is known before a pod is selected, so a template with other actions must end in a literal
+// domain covered by a "*." pattern.
+func (p *namespacePolicy) allowsDNSNameTemplate(template, namespace string) bool {
+	rendered := strings.ReplaceAll(template, podNamespaceTemplate, namespace)
+	i := strings.LastIndex(rendered, "}}")
+	if i < 0 {
+		return !strings.Contains(rendered, "{{") && p.allowsDNSName(rendered)
+	}
+	suffix := strings.ToLower(strings.TrimSuffix(rendered[i+2:], "."))
+	return slices.ContainsFunc(p.dnsNames, func(pattern string) bool {
+		domain, ok := strings.CutPrefix(pattern, "*.")
+		return ok && strings.HasSuffix(suffix, "."+domain)
+	})
+}
+
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,362 @@
+package workload_identity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+)
+
+func newPolicy(name string, selector map[string]string, spec v1alpha1.WorkloadIdentityPolicySpec) v1alpha1.WorkloadIdentityPolicy {
+	if selector != nil {
+		spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: selector}
+	}
+	return v1alpha1.WorkloadIdentityPolicy{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+}
+
+func duration(d time.Duration) *metav1.Duration {
+	return &metav1.Duration{Duration: d}
+}
+
+func TestPolicyForNamespace(t *testing.T) {
+	teamLabels := map[string]string{"team": "payments"}
+
+	tests := []struct {
+		name              string
+		policies          []v1alpha1.WorkloadIdentityPolicy
+		wantPrefixes      []string
+		wantFederatesWith []string
+		wantMaxTTL        *time.Duration
+		wantSelected      bool
+	}{
+		{
+			name:         "no policy allows only the namespace prefix",
+			wantPrefixes: []string{"/ns/payments/"},
+		},
+		{
+			name: "policy for another namespace is ignored",
+			policies: []v1alpha1.WorkloadIdentityPolicy{
+				newPolicy("other", map[string]string{"team": "billing"}, v1alpha1.WorkloadIdentityPolicySpec{
+					AllowedSPIFFEIDPathPrefixes: []string{"/billing/"},
+					MaxTTL:                      duration(time.Hour),
+				}),
+			},
+			wantPrefixes: []string{"/ns/payments/"},
+		},
+		{
+			name: "matching policies are merged",
+			policies: []v1alpha1.WorkloadIdentityPolicy{
+				newPolicy("team", teamLabels, v1alpha1.WorkloadIdentityPolicySpec{
+					AllowedSPIFFEIDPathPrefixes: []string{"/team/{namespace}/"},
+					AllowedFederatesWith:        []string{"partner.example.com"},
+					MaxTTL:                      duration(time.Hour),
+				}),
+				newPolicy("all", nil, v1alpha1.WorkloadIdentityPolicySpec{
+					AllowedFederatesWith: []string{"partner.example.com", "other.example.com"},
+					MaxTTL:               duration(2 * time.Hour),
+				}),
+			},
+			wantPrefixes:      []string{"/ns/payments/", "/team/payments/"},
+			wantFederatesWith: []string{"partner.example.com", "other.example.com"},
+			wantMaxTTL:        ptr.To(2 * time.Hour),
+			wantSelected:      true,
+		},
+		{
+			name: "policy without a limit lifts it",
+			policies: []v1alpha1.WorkloadIdentityPolicy{
+				newPolicy("limited", teamLabels, v1alpha1.WorkloadIdentityPolicySpec{MaxTTL: duration(time.Hour)}),
+				newPolicy("unlimited", nil, v1alpha1.WorkloadIdentityPolicySpec{}),
+			},
+			wantPrefixes: []string{"/ns/payments/"},
+			wantSelected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := policyForNamespace("payments", teamLabels, tt.policies)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPrefixes, policy.pathPrefixes)
+			assert.Equal(t, tt.wantFederatesWith, policy.federatesWith)
+			assert.Equal(t, tt.wantMaxTTL, policy.maxTTL)
+			assert.Equal(t, tt.wantSelected, policy.selected)
+		})
+	}
+}
+
+func TestPolicyForNamespace_InvalidSelector(t *testing.T) {
+	policy := v1alpha1.WorkloadIdentityPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "broken"},
+		Spec: v1alpha1.WorkloadIdentityPolicySpec{
+			NamespaceSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Bogus"}},
+			},
+		},
+	}
+
+	_, err := policyForNamespace("payments", nil, []v1alpha1.WorkloadIdentityPolicy{policy})
+
+	assert.ErrorContains(t, err, "broken")
+}
+
+func TestPolicyForNamespace_HintsAndAutoPopulateDNSNames(t *testing.T) {
+	policies := []v1alpha1.WorkloadIdentityPolicy{
+		newPolicy("team", nil, v1alpha1.WorkloadIdentityPolicySpec{
+			AllowedHints:              []string{"{namespace}-internal"},
+			AllowAutoPopulateDNSNames: "true",
+			AllowSVIDSecrets:          "true",
+		}),
+	}
+
+	policy, err := policyForNamespace("payments", nil, policies)
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"payments-internal"}, policy.hints)
+	assert.True(t, policy.allowAutoPopulateDNSNames)
+	assert.True(t, policy.allowSVIDSecrets)
+}
+
+func TestValidateWorkloadIdentity(t *testing.T) {
+	policy := &namespacePolicy{
+		selected:      true,
+		pathPrefixes:  []string{"/ns/payments/", "/team/payments/"},
+		dnsNames:      []string{"*.payments.svc", "api.example.com"},
+		federatesWith: []string{"partner.example.com"},
+		hints:         []string{"internal"},
+		maxTTL:        ptr.To(time.Hour),
+		maxJWTTTL:     ptr.To(5 * time.Minute),
+	}
+
+	tests := []struct {
+		name    string
+		spec    v1alpha1.WorkloadIdentitySpec
+		wantErr string
+	}{
+		{
+			name: "default path",
+		},
+		{
+			name: "namespace template in path",
+			spec: v1alpha1.WorkloadIdentitySpec{SPIFFEIDPath: "/ns/{{ .PodMeta.Namespace }}/app/{{ .PodMeta.Name }}"},
+		},
+		{
+			name: "allowed prefix",
+			spec: v1alpha1.WorkloadIdentitySpec{SPIFFEIDPath: "/team/payments/api"},
+		},
+		{
+			name:    "other namespace",
+			spec:    v1alpha1.WorkloadIdentitySpec{SPIFFEIDPath: "/ns/billing/sa/api"},
+			wantErr: "not under an allowed prefix",
+		},
+		{
+			name:    "template before the prefix ends",
+			spec:    v1alpha1.WorkloadIdentitySpec{SPIFFEIDPath: "/ns/{{ .PodSpec.ServiceAccountName }}/api"},
+			wantErr: "not under an allowed prefix",
+		},
+		{
+			name:    "prefix without trailing segment boundary",
+			spec:    v1alpha1.WorkloadIdentitySpec{SPIFFEIDPath: "/ns/payments-evil/api"},
+			wantErr: "not under an allowed prefix",
+		},
+		{
+			name: "allowed federation",
+			spec: v1alpha1.WorkloadIdentitySpec{FederatesWith: []string{"partner.example.com"}},
+		},
+		{
+			name:    "federation not allowed",
+			spec:    v1alpha1.WorkloadIdentitySpec{FederatesWith: []string{"evil.example.com"}},
+			wantErr: "evil.example.com",
+		},
+		{
+			name:    "ttl too long",
+			spec:    v1alpha1.WorkloadIdentitySpec{TTL: duration(2 * time.Hour)},
+			wantErr: "ttl 2h0m0s exceeds",
+		},
+		{
+			name:    "jwt ttl too long",
+			spec:    v1alpha1.WorkloadIdentitySpec{JWTTTL: duration(time.Hour)},
+			wantErr: "jwtTTL 1h0m0s exceeds",
+		},
+		{
+			name: "allowed DNS name templates",
+			spec: v1alpha1.WorkloadIdentitySpec{DNSNameTemplates: []string{
+				"api.example.com",
+				"api.{{ .PodMeta.Namespace }}.svc",
+				"{{ .PodMeta.Name }}.payments.svc",
+			}},
+		},
+		{
+			name:    "DNS name not allowed",
+			spec:    v1alpha1.WorkloadIdentitySpec{DNSNameTemplates: []string{"www.example.com"}},
+			wantErr: `dnsNameTemplate "www.example.com" is not allowed`,
+		},
+		{
+			name:    "template ending outside an allowed domain",
+			spec:    v1alpha1.WorkloadIdentitySpec{DNSNameTemplates: []string{"{{ .PodMeta.Name }}.payments.svc.{{ .PodMeta.Name }}"}},
+			wantErr: "is not allowed",
+		},
+		{
+			name:    "template rendering the domain",
+			spec:    v1alpha1.WorkloadIdentitySpec{DNSNameTemplates: []string{"api.{{ .PodMeta.Name }}.svc"}},
+			wantErr: "is not allowed",
+		},
+		{
+			name:    "auto-populated DNS names not allowed",
+			spec:    v1alpha1.WorkloadIdentitySpec{AutoPopulateDNSNames: "true"},
+			wantErr: "autoPopulateDNSNames",
+		},
+		{
+			name: "allowed hint",
+			spec: v1alpha1.WorkloadIdentitySpec{Hint: "internal"},
+		},
+		{
+			name:    "hint not allowed",
+			spec:    v1alpha1.WorkloadIdentitySpec{Hint: "external"},
+			wantErr: `hint "external" is not allowed`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloadIdentity := &v1alpha1.WorkloadIdentity{
+				ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "payments"},
+				Spec:       tt.spec,
+			}
+			err := validateWorkloadIdentity(workloadIdentity, policy)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestValidateWorkloadIdentity_NoPolicy(t *testing.T) {
+	policy, err := policyForNamespace("payments", nil, nil)
+	require.NoError(t, err)
+
+	workloadIdentity := &v1alpha1.WorkloadIdentity{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "payments"},
+		Spec:       v1alpha1.WorkloadIdentitySpec{DNSNameTemplates: []string{"api.payments.svc"}},
+	}
+	assert.NoError(t, validateWorkloadIdentity(workloadIdentity, policy))
+
+	workloadIdentity.Spec.TTL = duration(time.Minute)
+	assert.ErrorContains(t, validateWorkloadIdentity(workloadIdentity, policy), "no WorkloadIdentityPolicy selects the namespace")
+
+	workloadIdentity.Spec.TTL = nil
+	workloadIdentity.Spec.Hint = "api"
+	assert.ErrorContains(t, validateWorkloadIdentity(workloadIdentity, policy), `hint "api" is not allowed`)
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+func TestValidateSVIDSecret(t *testing.T) {
+	teamLabels := map[string]string{"team": "payments"}
+	allowing := newPolicy("team", teamLabels, v1alpha1.WorkloadIdentityPolicySpec{
+		AllowedSPIFFEIDPathPrefixes: []string{"/team/{namespace}/"},
+		MaxTTL:                      duration(24 * time.Hour),
+		AllowSVIDSecrets:            "true",
+	})
+
+	tests := []struct {
+		name     string
+		policies []v1alpha1.WorkloadIdentityPolicy
+		spec     v1alpha1.SVIDSecretSpec
+		wantErr  string
+	}{
+		{
+			name:     "allowed",
+			policies: []v1alpha1.WorkloadIdentityPolicy{allowing},
+			spec:     v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/team/payments/ingress", TTL: duration(time.Hour)},
+		},
+		{
+			name:    "no policy",
+			spec:    v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/ns/payments/ingress"},
+			wantErr: "no WorkloadIdentityPolicy allows SVIDSecrets",
+		},
+		{
+			name: "policy without allowSVIDSecrets",
+			policies: []v1alpha1.WorkloadIdentityPolicy{
+				newPolicy("team", teamLabels, v1alpha1.WorkloadIdentityPolicySpec{AllowSVIDSecrets: "false"}),
+			},
+			spec:    v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/ns/payments/ingress"},
+			wantErr: "no WorkloadIdentityPolicy allows SVIDSecrets",
+		},
+		{
+			name:     "path outside the allowed prefixes",
+			policies: []v1alpha1.WorkloadIdentityPolicy{allowing},
+			spec:     v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/ns/billing/ingress"},
+			wantErr:  "not under an allowed prefix",
+		},
+		{
+			name:     "service DNS names of the namespace",
+			policies: []v1alpha1.WorkloadIdentityPolicy{allowing},
+			spec: v1alpha1.SVIDSecretSpec{
+				SPIFFEIDPath: "/ns/payments/ingress",
+				DNSNames:     []string{"api.payments.svc", "api.payments.svc.cluster.local"},
+			},
+		},
+		{
+			name:     "DNS name of another namespace",
+			policies: []v1alpha1.WorkloadIdentityPolicy{allowing},
+			spec:     v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/ns/payments/ingress", DNSNames: []string{"api.billing.svc"}},
+			wantErr:  `dnsName "api.billing.svc" is not allowed`,
+		},
+		{
+			name:     "external DNS name without policy",
+			policies: []v1alpha1.WorkloadIdentityPolicy{allowing},
+			spec:     v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/ns/payments/ingress", DNSNames: []string{"www.example.com"}},
+			wantErr:  "is not allowed",
+		},
+		{
+			name: "external DNS name allowed by policy",
+			policies: []v1alpha1.WorkloadIdentityPolicy{
+				allowing,
+				newPolicy("dns", teamLabels, v1alpha1.WorkloadIdentityPolicySpec{AllowedDNSNames: []string{"*.{namespace}.example.com"}}),
+			},
+			spec: v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/ns/payments/ingress", DNSNames: []string{"WWW.payments.example.com"}},
+		},
+		{
+			name:     "ttl too long",
+			policies: []v1alpha1.WorkloadIdentityPolicy{allowing},
+			spec:     v1alpha1.SVIDSecretSpec{SPIFFEIDPath: "/ns/payments/ingress", TTL: duration(48 * time.Hour)},
+			wantErr:  "ttl 48h0m0s exceeds",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svidSecret := &v1alpha1.SVIDSecret{
+				ObjectMeta: metav1.ObjectMeta{Name: "ingress", Namespace: "payments"},
+				Spec:       tt.spec,
+			}
+			err := ValidateSVIDSecret(svidSecret, teamLabels, tt.policies)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNamespacePolicyAllowsDNSName(t *testing.T) {
+	policy := &namespacePolicy{dnsNames: []string{"*.payments.svc", "payments.example.com"}}
+
+	assert.True(t, policy.allowsDNSName("api.payments.svc"))
+	assert.True(t, policy.allowsDNSName("a.b.payments.svc."))
+	assert.True(t, policy.allowsDNSName("payments.example.com"))
+	assert.False(t, policy.allowsDNSName("payments.svc"))
+	assert.False(t, policy.allowsDNSName("apipayments.svc"))
+	assert.False(t, policy.allowsDNSName("www.payments.example.com"))
+	assert.False(t, policy.allowsDNSName("api.payments.svc.evil.com"))
+}
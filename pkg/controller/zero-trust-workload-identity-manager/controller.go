@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"os"
 	"reflect"
+	"slices"
 	"strings"
 
 	operatorv1 "github.com/operator-framework/api/pkg/operators/v1"
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	apierror "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
@@ -145,6 +147,19 @@ type ZeroTrustWorkloadIdentityManagerReconciler struct {
 // +kubebuilder:rbac:groups=operator.openshift.io,resources=spireservers,verbs=get;update;delete,resourceNames=cluster
 // +kubebuilder:rbac:groups=operator.openshift.io,resources=spireservers/status,verbs=update,resourceNames=cluster
 // +kubebuilder:rbac:groups=operator.openshift.io,resources=spireservers/finalizers,verbs=update,resourceNames=cluster
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=jointokens,verbs=get;list;watch
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=jointokens/status,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=jointokens/finalizers,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=spireauthorityoperations,verbs=get;list;watch
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=spireauthorityoperations/status,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=spireauthorityoperations/finalizers,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=workloadidentities,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=workloadidentities/status,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=workloadidentities/finalizers,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=svidsecrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=svidsecrets/status,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=svidsecrets/finalizers,verbs=update
+// +kubebuilder:rbac:groups=operator.openshift.io,resources=workloadidentitypolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=list;watch;create
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=get;update;delete,resourceNames=spire-server;spire-agent;spire-controller-manager
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=list;watch;create
@@ -155,15 +170,21 @@ type ZeroTrustWorkloadIdentityManagerReconciler struct {
 // +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;update;delete,resourceNames=spire-bundle;spire-controller-manager-leader-election;spire-server-external-cert-reader;spire-oidc-external-cert-reader
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=get;list;watch;create;patch
 // +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations,verbs=update;delete,resourceNames=spire-controller-manager-webhook
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=get;list;watch;create;patch
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=mutatingwebhookconfigurations,verbs=update;delete,resourceNames=zero-trust-workload-identity-manager-spiffe-csi-injection
 // +kubebuilder:rbac:groups="",resources=services,verbs=list;watch;create
-// +kubebuilder:rbac:groups="",resources=services,verbs=get;update;delete,resourceNames=spire-server;spire-controller-manager-webhook;spire-agent;spire-spiffe-oidc-discovery-provider
+// +kubebuilder:rbac:groups="",resources=services,verbs=get;update;delete,resourceNames=spire-server;spire-server-external;spire-controller-manager-webhook;spire-agent;spire-spiffe-oidc-discovery-provider
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=list;watch;create
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;update;delete,resourceNames=spire-server;spire-agent;spire-spiffe-csi-driver;spire-spiffe-oidc-discovery-provider
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;update;patch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
-// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// Canary rollouts replace the spire-agent and spiffe-csi-driver pods on canary nodes by deleting them.
+// The operator's permissions are a single ClusterRole and RBAC cannot match pod labels, so the grant
+// is cluster-wide; utils.DeleteOutdatedCanaryPods refuses any pod outside the operator namespace or
+// not matching the DaemonSet pod selector.
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;delete
 // +kubebuilder:rbac:groups="",resources=nodes/proxy,verbs=get
 // +kubebuilder:rbac:groups="",resources=endpoints,verbs=get;list;watch
 // +kubebuilder:rbac:groups=storage.k8s.io,resources=csidrivers,verbs=get;list;watch;create;update;delete
@@ -181,15 +202,29 @@ type ZeroTrustWorkloadIdentityManagerReconciler struct {
 // +kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;update;delete,resourceNames=spire-agent;spire-spiffe-csi-driver
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=list;watch;create
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;update;delete,resourceNames=spire-spiffe-oidc-discovery-provider
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=list;watch;create
+// +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;update;delete,resourceNames=spire-spiffe-oidc-discovery-provider
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=list;watch;create
+// +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;update;delete,resourceNames=spire-spiffe-oidc-discovery-provider
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=list;watch;create
+// +kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;delete,resourceNames=spire-spiffe-oidc-discovery-provider-acme
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=list;watch;create
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;update;delete,resourceNames=spire-server
 // +kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=list;watch;create
 // +kubebuilder:rbac:groups=security.openshift.io,resources=securitycontextconstraints,verbs=get;update;delete,resourceNames=spire-agent;spire-spiffe-csi-driver
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=list;watch;create
-// +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;update;delete,resourceNames=spire-server-federation;spire-oidc-discovery-provider
-// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=route.openshift.io,resources=routes,verbs=get;update;delete,resourceNames=spire-server-federation;spire-server-grpc;spire-oidc-discovery-provider;spire-oidc-discovery-provider-previous
+// SVIDSecrets write their Secret into the namespace of the SVIDSecret, so the Secret grant cannot be
+// limited by name or namespace. The cache only holds Secrets carrying the managed-by label, and the
+// operator only updates or deletes a Secret that also has a controller reference to the resource it
+// was created for (utils.IsManagedBy), so existing Secrets are never adopted, overwritten or deleted.
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=route.openshift.io,resources=routes/custom-host,verbs=create;update
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=list;watch;create
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;update;delete,resourceNames=spire-server-federation;spire-oidc-discovery-provider
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes;tlsroutes;backendtlspolicies,verbs=list;watch;create
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes;tlsroutes;backendtlspolicies,verbs=get;update;delete,resourceNames=spire-server-federation;spire-oidc-discovery-provider
 // +kubebuilder:rbac:groups=operators.coreos.com,resources=operatorconditions,verbs=get;list;watch
 // +kubebuilder:rbac:groups=operators.coreos.com,resources=operatorconditions/status,verbs=update
 // +kubebuilder:rbac:groups=cert-manager.io,resources=certificaterequests,verbs=create;get;list;delete
@@ -336,6 +371,12 @@ func (r *ZeroTrustWorkloadIdentityManagerReconciler) Reconcile(ctx context.Conte
 		r.log.Error(err, "failed to update OperatorCondition, continuing (operator may be running outside OLM)")
 	}
 
+	r.reconcileJWTIssuerConsistency(ctx, statusMgr)
+
+	if err := r.reconcileDefaultIdentity(ctx, &config, statusMgr, createOnlyModeEnabled); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -392,6 +433,11 @@ func (r *ZeroTrustWorkloadIdentityManagerReconciler) aggregateOperandStatus(ctx
 		r.getSpireOIDCDiscoveryProviderStatus(ctx),
 	}
 
+	// Agents attached to an external SPIRE server do not need a local server or OIDC discovery provider
+	if r.agentUsesExternalServer(ctx) {
+		operandStatuses = filterOptionalOperands(operandStatuses, "SpireServer", "SpireOIDCDiscoveryProvider")
+	}
+
 	// Process each operand status
 	for _, operand := range operandStatuses {
 		processOperandStatus(operand, state)
@@ -406,6 +452,47 @@ func (r *ZeroTrustWorkloadIdentityManagerReconciler) aggregateOperandStatus(ctx
 	}
 }
 
+// reconcileJWTIssuerConsistency reports in the JWTIssuerConsistent condition whether the SpireServer and the
+// SpireOIDCDiscoveryProvider agree on the JWT issuer. The condition is removed unless both exist.
+func (r *ZeroTrustWorkloadIdentityManagerReconciler) reconcileJWTIssuerConsistency(ctx context.Context, statusMgr *status.Manager) {
+	var server v1alpha1.SpireServer
+	var oidc v1alpha1.SpireOIDCDiscoveryProvider
+	for _, obj := range []client.Object{&server, &oidc} {
+		if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: "cluster"}, obj); err != nil {
+			if apierror.IsNotFound(err) {
+				statusMgr.RemoveCondition(utils.JWTIssuerConsistentConditionType)
+			} else {
+				r.log.Error(err, "failed to get operand to compare JWT issuers")
+			}
+			return
+		}
+	}
+
+	conditionStatus, reason, message := utils.CheckJWTIssuerConsistency(&server, &oidc)
+	statusMgr.AddCondition(utils.JWTIssuerConsistentConditionType, reason, message, conditionStatus)
+}
+
+// agentUsesExternalServer reports whether the SpireAgent is configured against an external SPIRE server
+func (r *ZeroTrustWorkloadIdentityManagerReconciler) agentUsesExternalServer(ctx context.Context) bool {
+	var agent v1alpha1.SpireAgent
+	if err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: "cluster"}, &agent); err != nil {
+		return false
+	}
+	return agent.Spec.ExternalServer != nil
+}
+
+// filterOptionalOperands drops the given operand kinds when their CR has not been created
+func filterOptionalOperands(operandStatuses []v1alpha1.OperandStatus, kinds ...string) []v1alpha1.OperandStatus {
+	filtered := make([]v1alpha1.OperandStatus, 0, len(operandStatuses))
+	for _, operand := range operandStatuses {
+		if operand.Message == OperandMessageCRNotFound && slices.Contains(kinds, operand.Kind) {
+			continue
+		}
+		filtered = append(filtered, operand)
+	}
+	return filtered
+}
+
 // operandStatusGetter defines the interface for types that have conditional status
 type operandStatusGetter interface {
 	client.Object
@@ -672,6 +759,11 @@ func (r *ZeroTrustWorkloadIdentityManagerReconciler) SetupWithManager(mgr ctrl.M
 		}
 	}
 
+	// Restore the default identity when it is modified or deleted
+	defaultIdentityPredicate := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == DefaultIdentityClusterSPIFFEIDName
+	})
+
 	// Watch ZTWIM CR and all operand CRs to aggregate their status
 	// Reconcile on operand creation and status changes
 	err := ctrl.NewControllerManagedBy(mgr).
@@ -682,6 +774,7 @@ func (r *ZeroTrustWorkloadIdentityManagerReconciler) SetupWithManager(mgr ctrl.M
 		Watches(&v1alpha1.SpireAgent{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(operandStatusChangedPredicate)).
 		Watches(&v1alpha1.SpiffeCSIDriver{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(operandStatusChangedPredicate)).
 		Watches(&v1alpha1.SpireOIDCDiscoveryProvider{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(operandStatusChangedPredicate)).
+		Watches(&spiffev1alpha1.ClusterSPIFFEID{}, handler.EnqueueRequestsFromMapFunc(mapFunc), builder.WithPredicates(defaultIdentityPredicate)).
 		Complete(r)
 	if err != nil {
 		return err
@@ -11,7 +11,9 @@ import (
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
 	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -1588,6 +1590,55 @@ func TestAggregateOperandStatus_AllNotFound(t *testing.T) {
 	}
 }
 
+// TestAggregateOperandStatus_ExternalServer tests that a missing local server and OIDC provider
+// do not block readiness when agents use an external SPIRE server
+func TestAggregateOperandStatus_ExternalServer(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+
+	reconciler := &ZeroTrustWorkloadIdentityManagerReconciler{
+		ctrlClient:            fakeClient,
+		ctx:                   context.Background(),
+		log:                   logr.Discard(),
+		scheme:                scheme,
+		eventRecorder:         record.NewFakeRecorder(100),
+		operatorConditionName: "test-operator-condition",
+	}
+
+	readyConditions := []metav1.Condition{
+		{Type: v1alpha1.Ready, Status: metav1.ConditionTrue, Reason: v1alpha1.ReasonReady},
+	}
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		switch cr := obj.(type) {
+		case *v1alpha1.SpireAgent:
+			cr.Name = "cluster"
+			cr.Spec.ExternalServer = &v1alpha1.ExternalSpireServer{Address: "spire.example.com"}
+			cr.Status.ConditionalStatus.Conditions = readyConditions
+		case *v1alpha1.SpiffeCSIDriver:
+			cr.Name = "cluster"
+			cr.Status.ConditionalStatus.Conditions = readyConditions
+		default:
+			return kerrors.NewNotFound(schema.GroupResource{}, "cluster")
+		}
+		return nil
+	}
+
+	result := reconciler.aggregateOperandStatus(context.Background())
+
+	if len(result.operandStatuses) != 2 {
+		t.Fatalf("Expected 2 operand statuses, got %d", len(result.operandStatuses))
+	}
+	for _, operand := range result.operandStatuses {
+		if operand.Kind == "SpireServer" || operand.Kind == "SpireOIDCDiscoveryProvider" {
+			t.Errorf("Expected %s to be omitted in external server mode", operand.Kind)
+		}
+	}
+	if !result.allReady {
+		t.Error("Expected allReady to be true with an external SPIRE server")
+	}
+}
+
 // TestAggregateOperandStatus_AllReady tests aggregateOperandStatus when all CRs are ready
 func TestAggregateOperandStatus_AllReady(t *testing.T) {
 	fakeClient := &fakes.FakeCustomCtrlClient{}
@@ -2869,6 +2920,8 @@ func TestReconcile_UpdateOperatorConditionError(t *testing.T) {
 							{Type: v1alpha1.Ready, Status: metav1.ConditionTrue, Reason: v1alpha1.ReasonReady},
 						}
 						return nil
+					case *spiffev1alpha1.ClusterSPIFFEID:
+						return kerrors.NewNotFound(schema.GroupResource{Resource: "clusterspiffeids"}, key.Name)
 					default:
 						// OperatorCondition Get returns error
 						return errors.New("OperatorCondition not found")
@@ -3640,3 +3693,80 @@ func TestFindOperatorCondition_GetSucceeds(t *testing.T) {
 		t.Errorf("Expected no error when Get succeeds, got: %v", err)
 	}
 }
+
+// TestReconcileJWTIssuerConsistency tests the JWTIssuerConsistent condition aggregated from the operand issuers
+func TestReconcileJWTIssuerConsistency(t *testing.T) {
+	operandsWithIssuers := func(serverIssuer, oidcIssuer string) func(context.Context, client.ObjectKey, client.Object) error {
+		return func(_ context.Context, _ client.ObjectKey, obj client.Object) error {
+			switch o := obj.(type) {
+			case *v1alpha1.SpireServer:
+				o.Spec.JwtIssuer = serverIssuer
+			case *v1alpha1.SpireOIDCDiscoveryProvider:
+				if oidcIssuer == "" {
+					return kerrors.NewNotFound(schema.GroupResource{}, "cluster")
+				}
+				o.Spec.JwtIssuer = oidcIssuer
+			}
+			return nil
+		}
+	}
+	newConfig := func() *v1alpha1.ZeroTrustWorkloadIdentityManager {
+		return &v1alpha1.ZeroTrustWorkloadIdentityManager{ObjectMeta: metav1.ObjectMeta{Name: "cluster"}}
+	}
+	applyStatus := func(t *testing.T, statusMgr *status.Manager, config *v1alpha1.ZeroTrustWorkloadIdentityManager) {
+		t.Helper()
+		if err := statusMgr.ApplyStatus(context.Background(), config, func() *v1alpha1.ConditionalStatus {
+			return &config.Status.ConditionalStatus
+		}); err != nil {
+			t.Fatalf("failed to apply status: %v", err)
+		}
+	}
+
+	t.Run("mismatch", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newTestReconciler(fakeClient)
+		fakeClient.GetStub = operandsWithIssuers("https://server.example.com", "https://oidc.example.com")
+		config := newConfig()
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), statusMgr)
+		applyStatus(t, statusMgr, config)
+
+		condition := apimeta.FindStatusCondition(config.Status.Conditions, utils.JWTIssuerConsistentConditionType)
+		if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != utils.JWTIssuerMismatch {
+			t.Errorf("Expected a False %s condition, got %+v", utils.JWTIssuerMismatch, condition)
+		}
+	})
+
+	t.Run("match", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newTestReconciler(fakeClient)
+		fakeClient.GetStub = operandsWithIssuers("https://oidc.example.com", "https://oidc.example.com/")
+		config := newConfig()
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), statusMgr)
+		applyStatus(t, statusMgr, config)
+
+		condition := apimeta.FindStatusCondition(config.Status.Conditions, utils.JWTIssuerConsistentConditionType)
+		if condition == nil || condition.Status != metav1.ConditionTrue {
+			t.Errorf("Expected a True condition, got %+v", condition)
+		}
+	})
+
+	t.Run("no discovery provider", func(t *testing.T) {
+		fakeClient := &fakes.FakeCustomCtrlClient{}
+		reconciler := newTestReconciler(fakeClient)
+		fakeClient.GetStub = operandsWithIssuers("https://oidc.example.com", "")
+		config := newConfig()
+		config.Status.Conditions = []metav1.Condition{{Type: utils.JWTIssuerConsistentConditionType, Status: metav1.ConditionFalse, Reason: utils.JWTIssuerMismatch}}
+		statusMgr := status.NewManager(fakeClient)
+
+		reconciler.reconcileJWTIssuerConsistency(context.Background(), statusMgr)
+		applyStatus(t, statusMgr, config)
+
+		if condition := apimeta.FindStatusCondition(config.Status.Conditions, utils.JWTIssuerConsistentConditionType); condition != nil {
+			t.Errorf("Expected the condition to be removed, got %+v", condition)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package zero_trust_workload_identity_manager
+
+import (
+	"context"
+	"fmt"
+
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/version"
+)
+
+const (
+	// DefaultIdentityAvailable reports whether the default workload identity is applied
+	DefaultIdentityAvailable = "DefaultIdentityAvailable"
+
+	// DefaultIdentityClusterSPIFFEIDName is the name of the fallback ClusterSPIFFEID
+	DefaultIdentityClusterSPIFFEIDName = "zero-trust-workload-identity-manager-spire-default"
+
+	defaultIdentitySPIFFEIDTemplate = "spiffe://{{ .TrustDomain }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}"
+)
+
+// reconcileDefaultIdentity creates, updates or removes the fallback ClusterSPIFFEID
+func (r *ZeroTrustWorkloadIdentityManagerReconciler) reconcileDefaultIdentity(ctx context.Context, config *v1alpha1.ZeroTrustWorkloadIdentityManager, statusMgr *status.Manager, createOnlyMode bool) error {
+	// Enabled defaults to "true" in the CRD, so an absent configuration keeps the default identity
+	if identity := config.Spec.DefaultIdentity; identity != nil && !utils.StringToBool(identity.Enabled) {
+		existing := &spiffev1alpha1.ClusterSPIFFEID{ObjectMeta: metav1.ObjectMeta{Name: DefaultIdentityClusterSPIFFEIDName}}
+		if err := r.ctrlClient.Delete(ctx, existing); err != nil && !apierror.IsNotFound(err) {
+			r.log.Error(err, "failed to delete default ClusterSPIFFEID")
+			statusMgr.AddCondition(DefaultIdentityAvailable, "DefaultIdentityDeletionFailed",
+				fmt.Sprintf("Failed to delete default ClusterSPIFFEID: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+		statusMgr.RemoveCondition(DefaultIdentityAvailable)
+		return nil
+	}
+
+	desired := generateDefaultIdentityClusterSPIFFEID(config.Spec.DefaultIdentity)
+	if err := validateDefaultIdentity(&desired.Spec, config.Spec.TrustDomain, config.Spec.ClusterName); err != nil {
+		// Keep the current default identity rather than breaking every workload relying on it
+		r.log.Error(err, "invalid default identity configuration")
+		statusMgr.AddCondition(DefaultIdentityAvailable, "InvalidDefaultIdentity",
+			fmt.Sprintf("Default identity configuration validation failed: %v", err),
+			metav1.ConditionFalse)
+		return nil
+	}
+	if err := controllerutil.SetControllerReference(config, desired, r.scheme); err != nil {
+		r.log.Error(err, "failed to set controller reference for default ClusterSPIFFEID")
+		statusMgr.AddCondition(DefaultIdentityAvailable, "DefaultIdentityGenerationFailed",
+			err.Error(),
+			metav1.ConditionFalse)
+		return err
+	}
+
+	existing := &spiffev1alpha1.ClusterSPIFFEID{}
+	err := r.ctrlClient.Get(ctx, types.NamespacedName{Name: desired.Name}, existing)
+	if err != nil {
+		if !apierror.IsNotFound(err) {
+			r.log.Error(err, "failed to get default ClusterSPIFFEID")
+			statusMgr.AddCondition(DefaultIdentityAvailable, "DefaultIdentityGetFailed",
+				fmt.Sprintf("Failed to get default ClusterSPIFFEID: %v", err),
+				metav1.ConditionFalse)
+			return err
+		}
+
+		if err := r.ctrlClient.Create(ctx, desired); err != nil {
+			if conflictErr := utils.HandleCreateConflict(err, desired, r.log, statusMgr, DefaultIdentityAvailable); conflictErr != nil {
+				return conflictErr
+			}
+			r.log.Error(err, "failed to create default ClusterSPIFFEID")
+			statusMgr.AddCondition(DefaultIdentityAvailable, "DefaultIdentityCreationFailed",
+				err.Error(),
+				metav1.ConditionFalse)
+			return err
+		}
+		r.log.Info("Created default ClusterSPIFFEID", "name", desired.Name)
+	} else if utils.ResourceNeedsUpdate(existing, desired) || utils.NeedsOwnerReferenceUpdate(existing, config) {
+		// Earlier releases created the default identity from the SpireOIDCDiscoveryProvider, the update takes it over
+		if createOnlyMode {
+			r.log.Info("Skipping default ClusterSPIFFEID update due to create-only mode", "name", desired.Name)
+		} else {
+			desired.ResourceVersion = existing.ResourceVersion
+			if err := r.ctrlClient.Update(ctx, desired); err != nil {
+				r.log.Error(err, "failed to update default ClusterSPIFFEID")
+				statusMgr.AddCondition(DefaultIdentityAvailable, "DefaultIdentityUpdateFailed",
+					fmt.Sprintf("Failed to update default ClusterSPIFFEID: %v", err),
+					metav1.ConditionFalse)
+				return err
+			}
+			r.log.Info("Updated default ClusterSPIFFEID", "name", desired.Name)
+		}
+	} else {
+		r.log.V(1).Info("Default ClusterSPIFFEID is up to date", "name", desired.Name)
+	}
+
+	statusMgr.AddCondition(DefaultIdentityAvailable, v1alpha1.ReasonReady,
+		fmt.Sprintf("Default identity %s is applied", desired.Spec.SPIFFEIDTemplate),
+		metav1.ConditionTrue)
+	return nil
+}
+
+// generateDefaultIdentityClusterSPIFFEID returns the fallback ClusterSPIFFEID for the configuration
+func generateDefaultIdentityClusterSPIFFEID(identity *v1alpha1.DefaultIdentityConfig) *spiffev1alpha1.ClusterSPIFFEID {
+	if identity == nil {
+		identity = &v1alpha1.DefaultIdentityConfig{}
+	}
+
+	// The operator namespace is excluded so that the operands keep their own identities
+	namespaceSelector := &metav1.LabelSelector{}
+	if identity.NamespaceSelector != nil {
+		namespaceSelector = identity.NamespaceSelector.DeepCopy()
+	}
+	namespaceSelector.MatchExpressions = append(namespaceSelector.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      "kubernetes.io/metadata.name",
+		Operator: metav1.LabelSelectorOpNotIn,
+		Values:   []string{utils.GetOperatorNamespace()},
+	})
+
+	clusterSpiffeID := &spiffev1alpha1.ClusterSPIFFEID{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   DefaultIdentityClusterSPIFFEIDName,
+			Labels: utils.StandardizedLabels("spire-default-identity", utils.ComponentControlPlane, version.SpireControllerManagerVersion, nil),
+		},
+		Spec: spiffev1alpha1.ClusterSPIFFEIDSpec{
+			ClassName:         "zero-trust-workload-identity-manager-spire",
+			Hint:              "default",
+			SPIFFEIDTemplate:  defaultIdentitySPIFFEIDTemplate,
+			Fallback:          true,
+			DNSNameTemplates:  identity.DNSNameTemplates,
+			PodSelector:       identity.PodSelector,
+			NamespaceSelector: namespaceSelector,
+		},
+	}
+	if identity.SPIFFEIDTemplate != "" {
+		clusterSpiffeID.Spec.SPIFFEIDTemplate = identity.SPIFFEIDTemplate
+	}
+	if identity.TTL != nil {
+		clusterSpiffeID.Spec.TTL = *identity.TTL
+	}
+	if identity.JWTTTL != nil {
+		clusterSpiffeID.Spec.JWTTTL = *identity.JWTTTL
+	}
+	return clusterSpiffeID
+}
+
+// validateDefaultIdentity parses the ClusterSPIFFEID templates the way spire-controller-manager does
+// and renders the SPIFFE ID for a sample pod to check it stays in the trust domain
+func validateDefaultIdentity(spec *spiffev1alpha1.ClusterSPIFFEIDSpec, trustDomain, clusterName string) error {
+	parsed, err := spiffev1alpha1.ParseClusterSPIFFEIDSpec(spec)
+	if err != nil {
+		return err
+	}
+
+	data := utils.SampleTemplateData(trustDomain, clusterName, "sample", "default")
+	if _, err := utils.RenderSampleSPIFFEID(parsed.SPIFFEIDTemplate, data); err != nil {
+		return fmt.Errorf("SPIFFE ID template %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package zero_trust_workload_identity_manager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	spiffev1alpha1 "github.com/spiffe/spire-controller-manager/api/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/api/v1alpha1"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/client/fakes"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/status"
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/controller/utils"
+)
+
+func newDefaultIdentityTestReconciler(fakeClient *fakes.FakeCustomCtrlClient) *ZeroTrustWorkloadIdentityManagerReconciler {
+	reconciler := newTestReconciler(fakeClient)
+	scheme := runtime.NewScheme()
+	_ = v1alpha1.AddToScheme(scheme)
+	reconciler.scheme = scheme
+	return reconciler
+}
+
+func newDefaultIdentityTestZTWIM(identity *v1alpha1.DefaultIdentityConfig) *v1alpha1.ZeroTrustWorkloadIdentityManager {
+	return &v1alpha1.ZeroTrustWorkloadIdentityManager{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster", UID: "ztwim-uid"},
+		Spec: v1alpha1.ZeroTrustWorkloadIdentityManagerSpec{
+			TrustDomain:     "example.com",
+			ClusterName:     "test-cluster",
+			DefaultIdentity: identity,
+		},
+	}
+}
+
+func conditionOf(t *testing.T, fakeClient *fakes.FakeCustomCtrlClient, statusMgr *status.Manager, config *v1alpha1.ZeroTrustWorkloadIdentityManager) *metav1.Condition {
+	t.Helper()
+	require.NoError(t, statusMgr.ApplyStatus(context.Background(), config, func() *v1alpha1.ConditionalStatus {
+		return &config.Status.ConditionalStatus
+	}))
+	return apimeta.FindStatusCondition(config.Status.Conditions, DefaultIdentityAvailable)
+}
+
+func TestGenerateDefaultIdentityClusterSPIFFEID(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		csid := generateDefaultIdentityClusterSPIFFEID(nil)
+
+		assert.Equal(t, DefaultIdentityClusterSPIFFEIDName, csid.Name)
+		assert.Equal(t, defaultIdentitySPIFFEIDTemplate, csid.Spec.SPIFFEIDTemplate)
+		assert.True(t, csid.Spec.Fallback)
+		assert.Nil(t, csid.Spec.PodSelector)
+		require.Len(t, csid.Spec.NamespaceSelector.MatchExpressions, 1)
+		assert.Equal(t, metav1.LabelSelectorOpNotIn, csid.Spec.NamespaceSelector.MatchExpressions[0].Operator)
+		assert.Equal(t, []string{utils.GetOperatorNamespace()}, csid.Spec.NamespaceSelector.MatchExpressions[0].Values)
+		assert.Equal(t, utils.AppManagedByLabelValue, csid.Labels[utils.AppManagedByLabelKey])
+	})
+
+	t.Run("customized", func(t *testing.T) {
+		identity := &v1alpha1.DefaultIdentityConfig{
+			SPIFFEIDTemplate:  "spiffe://{{ .TrustDomain }}/cluster/{{ .ClusterName }}/ns/{{ .PodMeta.Namespace }}",
+			NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"mesh": "enabled"}},
+			PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			TTL:               &metav1.Duration{Duration: time.Hour},
+			JWTTTL:            &metav1.Duration{Duration: 5 * time.Minute},
+			DNSNameTemplates:  []string{"{{ .PodMeta.Name }}.{{ .PodMeta.Namespace }}.svc"},
+		}
+
+		csid := generateDefaultIdentityClusterSPIFFEID(identity)
+
+		assert.Equal(t, identity.SPIFFEIDTemplate, csid.Spec.SPIFFEIDTemplate)
+		assert.Equal(t, map[string]string{"mesh": "enabled"}, csid.Spec.NamespaceSelector.MatchLabels)
+		assert.Len(t, csid.Spec.NamespaceSelector.MatchExpressions, 1)
+		assert.Empty(t, identity.NamespaceSelector.MatchExpressions, "the configured selector must not be modified")
+		assert.Equal(t, identity.PodSelector, csid.Spec.PodSelector)
+		assert.Equal(t, time.Hour, csid.Spec.TTL.Duration)
+		assert.Equal(t, 5*time.Minute, csid.Spec.JWTTTL.Duration)
+		assert.Equal(t, identity.DNSNameTemplates, csid.Spec.DNSNameTemplates)
+	})
+}
+
+func TestValidateDefaultIdentity(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		dnsNames []string
+		wantErr  string
+	}{
+		{
+			name:     "default template",
+			template: defaultIdentitySPIFFEIDTemplate,
+		},
+		{
+			name:     "cluster name in template",
+			template: "spiffe://{{ .TrustDomain }}/cluster/{{ .ClusterName }}/ns/{{ .PodMeta.Namespace }}/sa/{{ .PodSpec.ServiceAccountName }}",
+		},
+		{
+			name:     "literal trust domain",
+			template: "spiffe://example.com/ns/{{ .PodMeta.Namespace }}",
+		},
+		{
+			name:     "syntax error",
+			template: "spiffe://{{ .TrustDomain }/ns",
+			wantErr:  "invalid SPIFFEID template",
+		},
+		{
+			name:     "unknown field",
+			template: "spiffe://{{ .TrustDomain }}/{{ .Namespace }}",
+			wantErr:  "failed to render",
+		},
+		{
+			name:     "other trust domain",
+			template: "spiffe://other.example.com/ns/{{ .PodMeta.Namespace }}",
+			wantErr:  "must render a SPIFFE ID starting with",
+		},
+		{
+			name:     "invalid dns name template",
+			template: defaultIdentitySPIFFEIDTemplate,
+			dnsNames: []string{"{{ .PodMeta.Name"},
+			wantErr:  "invalid dnsNameTemplate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &spiffev1alpha1.ClusterSPIFFEIDSpec{SPIFFEIDTemplate: tt.template, DNSNameTemplates: tt.dnsNames}
+			err := validateDefaultIdentity(spec, "example.com", "test-cluster")
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestReconcileDefaultIdentity_Create(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newDefaultIdentityTestReconciler(fakeClient)
+	config := newDefaultIdentityTestZTWIM(nil)
+	statusMgr := status.NewManager(fakeClient)
+	fakeClient.GetReturns(kerrors.NewNotFound(schema.GroupResource{Resource: "clusterspiffeids"}, DefaultIdentityClusterSPIFFEIDName))
+
+	err := reconciler.reconcileDefaultIdentity(context.Background(), config, statusMgr, false)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.CreateCallCount())
+	_, obj, _ := fakeClient.CreateArgsForCall(0)
+	assert.True(t, metav1.IsControlledBy(obj, config))
+	condition := conditionOf(t, fakeClient, statusMgr, config)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+}
+
+func TestReconcileDefaultIdentity_TakesOverFromOIDC(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newDefaultIdentityTestReconciler(fakeClient)
+	config := newDefaultIdentityTestZTWIM(nil)
+	statusMgr := status.NewManager(fakeClient)
+
+	// Same spec as desired, but still owned by the SpireOIDCDiscoveryProvider
+	existing := generateDefaultIdentityClusterSPIFFEID(nil)
+	existing.ResourceVersion = "42"
+	controller := true
+	existing.OwnerReferences = []metav1.OwnerReference{{
+		APIVersion: v1alpha1.GroupVersion.String(),
+		Kind:       "SpireOIDCDiscoveryProvider",
+		Name:       "cluster",
+		UID:        "oidc-uid",
+		Controller: &controller,
+	}}
+	fakeClient.GetStub = func(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+		existing.DeepCopyInto(obj.(*spiffev1alpha1.ClusterSPIFFEID))
+		return nil
+	}
+
+	err := reconciler.reconcileDefaultIdentity(context.Background(), config, statusMgr, false)
+
+	require.NoError(t, err)
+	require.Equal(t, 1, fakeClient.UpdateCallCount())
+	_, obj, _ := fakeClient.UpdateArgsForCall(0)
+	assert.True(t, metav1.IsControlledBy(obj, config))
+	assert.Equal(t, "42", obj.GetResourceVersion())
+}
+
+func TestReconcileDefaultIdentity_InvalidTemplateKeepsExisting(t *testing.T) {
+	fakeClient := &fakes.FakeCustomCtrlClient{}
+	reconciler := newDefaultIdentityTestReconciler(fakeClient)
+	config := newDefaultIdentityTestZTWIM(&v1alpha1.DefaultIdentityConfig{
+		Enabled:          "true",
+		SPIFFEIDTemplate: "spiffe://other.example.com/{{ .PodMeta.Namespace }}",
+	})
+	statusMgr := status.NewManager(fakeClient)
+
+	err := reconciler.reconcileDefaultIdentity(context.Background(), config, statusMgr, false)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, fakeClient.GetCallCount())
+	assert.Equal(t, 0, fakeClient.CreateCallCount())
+	assert.Equal(t, 0, fakeClient.UpdateCallCount())
+	assert.Equal(t, 0, fakeClient.DeleteCallCount())
+	condition := conditionOf(t, fakeClient, statusMgr, config)
+	require.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, "InvalidDefaultIdentity", condition.Reason)
+}
+
+func TestReconcileDefaultIdentity_Disabled(t *testing.T) {
+	// Anything but "true" disables the default identity, like the other string booleans of the API
+	for _, enabled := range []string{"false", "False", ""} {
+		t.Run(fmt.Sprintf("enabled %q", enabled), func(t *testing.T) {
+			fakeClient := &fakes.FakeCustomCtrlClient{}
+			reconciler := newDefaultIdentityTestReconciler(fakeClient)
+			config := newDefaultIdentityTestZTWIM(&v1alpha1.DefaultIdentityConfig{Enabled: enabled})
+			statusMgr := status.NewManager(fakeClient)
+			fakeClient.DeleteReturns(kerrors.NewNotFound(schema.GroupResource{Resource: "clusterspiffeids"}, DefaultIdentityClusterSPIFFEIDName))
+
+			err := reconciler.reconcileDefaultIdentity(context.Background(), config, statusMgr, false)
+
+			require.NoError(t, err)
+			require.Equal(t, 1, fakeClient.DeleteCallCount())
+			_, obj, _ := fakeClient.DeleteArgsForCall(0)
+			assert.Equal(t, DefaultIdentityClusterSPIFFEIDName, obj.GetName())
+			assert.Equal(t, 0, fakeClient.CreateCallCount())
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package objectstorage provides a minimal client for uploading objects to S3-compatible storage.
+// Neither the AWS SDK nor minio-go is vendored, so the client implements the subset of Signature
+// Version 4 and STS that PutObject needs.
+package objectstorage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRegion is used for signing when the S3 configuration has no region
+	DefaultRegion = "us-east-1"
+
+	amzDateLayout   = "20060102T150405Z"
+	amzShortLayout  = "20060102"
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	requestTimeout  = 30 * time.Second
+	maxErrorBodyLen = 1024
+)
+
+// S3Config identifies an S3-compatible bucket and the credentials used to write to it. Either the
+// access keys or WebIdentity must be set.
+type S3Config struct {
+	// Endpoint is the base URL of the S3 API, for example https://s3.us-east-1.amazonaws.com
+	Endpoint string
+	// Region is the signing region, DefaultRegion when empty
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken accompanies temporary access keys
+	SessionToken string
+	// WebIdentity obtains temporary access keys from STS instead
+	WebIdentity *WebIdentity
+	// CABundle holds PEM certificates trusted in addition to the system roots
+	CABundle []byte
+}
+
+// S3Client uploads objects to a bucket with path-style requests signed with AWS Signature Version 4
+type S3Client struct {
+	config     S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+
+	// mu guards assumed, the cached credentials of the web identity role
+	mu      sync.Mutex
+	assumed *credentials
+}
+
+// NewS3Client validates the configuration and returns a client for its bucket
+func NewS3Client(config S3Config) (*S3Client, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if endpoint.Scheme != "https" && endpoint.Scheme != "http" {
+		return nil, fmt.Errorf("S3 endpoint scheme must be http or https, got: %q", endpoint.Scheme)
+	}
+	if endpoint.Host == "" {
+		return nil, fmt.Errorf("S3 endpoint host is required")
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	if config.WebIdentity != nil {
+		if config.WebIdentity.RoleARN == "" {
+			return nil, fmt.Errorf("role ARN is required for web identity credentials")
+		}
+		if config.AccessKeyID != "" || config.SecretAccessKey != "" {
+			return nil, fmt.Errorf("S3 access keys and web identity credentials are mutually exclusive")
+		}
+	} else if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("S3 access key ID and secret access key are required")
+	}
+	if config.Region == "" {
+		config.Region = DefaultRegion
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(config.CABundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(config.CABundle) {
+			return nil, fmt.Errorf("S3 CA bundle contains no PEM certificates")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	return &S3Client{
+		config:     config,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Transport: transport, Timeout: requestTimeout},
+		now:        time.Now,
+	}, nil
+}
+
+// PutObject writes body to the object key in the bucket
+func (c *S3Client) PutObject(ctx context.Context, key, contentType string, body []byte) error {
+	objectURL := *c.endpoint
+	objectURL.Path = strings.TrimSuffix(c.endpoint.Path, "/") + "/" + c.config.Bucket + "/" + strings.TrimPrefix(key, "/")
+	objectURL.RawPath = ""
+	objectURL.RawQuery = ""
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build S3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	creds, err := c.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	signRequest(req, sha256Hex(body), c.config.Region, creds, c.now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to upload %s to bucket %s: %w", key, c.config.Bucket, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen))
+		return fmt.Errorf("failed to upload %s to bucket %s: %s: %s", key, c.config.Bucket, resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// signRequest adds the AWS Signature Version 4 headers to req, signing the host and every header already set
+func signRequest(req *http.Request, payloadHash, region string, creds credentials, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format(amzDateLayout)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := now.Format(amzShortLayout) + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{signAlgorithm, amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+creds.secretAccessKey), now.Format(amzShortLayout))
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, creds.accessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalURI encodes every path segment as required by Signature Version 4 for S3
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode percent-encodes every byte except the RFC 3986 unreserved characters
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,133 @@
+package objectstorage
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWebIdentityTokenFile is where OpenShift projects the service account token of operators
+	// using short-lived cloud credentials
+	DefaultWebIdentityTokenFile = "/var/run/secrets/openshift/serviceaccount/token"
+
+	stsAPIVersion     = "2011-06-15"
+	stsSessionName    = "zero-trust-workload-identity-manager"
+	stsSessionSeconds = 3600
+	// credentialsRefreshMargin is how long before expiry temporary credentials are renewed
+	credentialsRefreshMargin = 5 * time.Minute
+)
+
+// WebIdentity exchanges a projected service account token for temporary credentials of an IAM role
+// through the STS AssumeRoleWithWebIdentity API
+type WebIdentity struct {
+	RoleARN string
+	// TokenFile holds the service account token, DefaultWebIdentityTokenFile when empty
+	TokenFile string
+	// STSEndpoint is the base URL of the STS API, https://sts.<region>.amazonaws.com when empty
+	STSEndpoint string
+}
+
+// credentials are the keys used to sign requests, with a session token when they are temporary
+type credentials struct {
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	expiration      time.Time
+}
+
+// assumeRoleWithWebIdentityResponse is the part of the STS response carrying the credentials
+type assumeRoleWithWebIdentityResponse struct {
+	Credentials struct {
+		AccessKeyID     string    `xml:"AccessKeyId"`
+		SecretAccessKey string    `xml:"SecretAccessKey"`
+		SessionToken    string    `xml:"SessionToken"`
+		Expiration      time.Time `xml:"Expiration"`
+	} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+}
+
+// credentials returns the static keys of the configuration, or temporary keys of the web identity
+// role that are cached until shortly before they expire
+func (c *S3Client) credentials(ctx context.Context) (credentials, error) {
+	if c.config.WebIdentity == nil {
+		return credentials{
+			accessKeyID:     c.config.AccessKeyID,
+			secretAccessKey: c.config.SecretAccessKey,
+			sessionToken:    c.config.SessionToken,
+		}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.assumed != nil && c.now().Add(credentialsRefreshMargin).Before(c.assumed.expiration) {
+		return *c.assumed, nil
+	}
+	assumed, err := c.assumeRoleWithWebIdentity(ctx)
+	if err != nil {
+		return credentials{}, err
+	}
+	c.assumed = &assumed
+	return assumed, nil
+}
+
+// assumeRoleWithWebIdentity calls STS with the service account token. The request is authenticated
+// by the token itself and is not signed.
+func (c *S3Client) assumeRoleWithWebIdentity(ctx context.Context) (credentials, error) {
+	identity := c.config.WebIdentity
+	tokenFile := identity.TokenFile
+	if tokenFile == "" {
+		tokenFile = DefaultWebIdentityTokenFile
+	}
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return credentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+
+	endpoint := identity.STSEndpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", c.config.Region)
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {stsAPIVersion},
+		"RoleArn":          {identity.RoleARN},
+		"RoleSessionName":  {stsSessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+		"DurationSeconds":  {fmt.Sprint(stsSessionSeconds)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return credentials{}, fmt.Errorf("failed to build STS request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return credentials{}, fmt.Errorf("failed to assume role %s: %w", identity.RoleARN, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyLen))
+		return credentials{}, fmt.Errorf("failed to assume role %s: %s: %s", identity.RoleARN, resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var result assumeRoleWithWebIdentityResponse
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return credentials{}, fmt.Errorf("failed to decode STS response: %w", err)
+	}
+	if result.Credentials.AccessKeyID == "" || result.Credentials.SecretAccessKey == "" {
+		return credentials{}, fmt.Errorf("STS response for role %s has no credentials", identity.RoleARN)
+	}
+	return credentials{
+		accessKeyID:     result.Credentials.AccessKeyID,
+		secretAccessKey: result.Credentials.SecretAccessKey,
+		sessionToken:    result.Credentials.SessionToken,
+		expiration:      result.Credentials.Expiration,
+	}, nil
+}
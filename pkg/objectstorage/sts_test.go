@@ -0,0 +1,125 @@
+package objectstorage
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeSTS serves AssumeRoleWithWebIdentity, issuing credentials that expire after the given duration
+func newFakeSTS(t *testing.T, expiresIn time.Duration) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if r.Form.Get("Action") != "AssumeRoleWithWebIdentity" || r.Form.Get("WebIdentityToken") != "sa-token" {
+			http.Error(w, "<ErrorResponse><Error><Code>InvalidIdentityToken</Code></Error></ErrorResponse>", http.StatusBadRequest)
+			return
+		}
+		n := calls.Add(1)
+		fmt.Fprintf(w, `<AssumeRoleWithWebIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithWebIdentityResult>
+    <Credentials>
+      <AccessKeyId>ASIA%d</AccessKeyId>
+      <SecretAccessKey>secret</SecretAccessKey>
+      <SessionToken>session-%d</SessionToken>
+      <Expiration>%s</Expiration>
+    </Credentials>
+  </AssumeRoleWithWebIdentityResult>
+</AssumeRoleWithWebIdentityResponse>`, n, n, time.Now().Add(expiresIn).UTC().Format(time.RFC3339))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func writeToken(t *testing.T, token string) string {
+	path := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(path, []byte(token+"\n"), 0o600))
+	return path
+}
+
+func TestPutObjectWithWebIdentity(t *testing.T) {
+	fake, s3Server := newFakeS3(t)
+	stsServer, calls := newFakeSTS(t, time.Hour)
+	client, err := NewS3Client(S3Config{
+		Endpoint: s3Server.URL,
+		Bucket:   "oidc",
+		WebIdentity: &WebIdentity{
+			RoleARN:     "arn:aws:iam::123456789012:role/oidc",
+			TokenFile:   writeToken(t, "sa-token"),
+			STSEndpoint: stsServer.URL,
+		},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, client.PutObject(context.Background(), "keys", "application/json", []byte(`{}`)))
+	require.NoError(t, client.PutObject(context.Background(), "openid-configuration", "application/json", []byte(`{}`)))
+
+	assert.Equal(t, int32(1), calls.Load(), "credentials must be reused until they expire")
+	headers := fake.headers["/oidc/keys"]
+	assert.Equal(t, "session-1", headers.Get("X-Amz-Security-Token"))
+	assert.Contains(t, headers.Get("Authorization"), "Credential=ASIA1/")
+}
+
+func TestWebIdentityCredentialsRefresh(t *testing.T) {
+	stsServer, calls := newFakeSTS(t, time.Minute)
+	client, err := NewS3Client(S3Config{
+		Endpoint: "https://s3.example.com",
+		Bucket:   "oidc",
+		WebIdentity: &WebIdentity{
+			RoleARN:     "arn:aws:iam::123456789012:role/oidc",
+			TokenFile:   writeToken(t, "sa-token"),
+			STSEndpoint: stsServer.URL,
+		},
+	})
+	require.NoError(t, err)
+
+	first, err := client.credentials(context.Background())
+	require.NoError(t, err)
+	second, err := client.credentials(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, int32(2), calls.Load(), "credentials expiring within the refresh margin must be renewed")
+	assert.NotEqual(t, first.accessKeyID, second.accessKeyID)
+}
+
+func TestWebIdentityErrors(t *testing.T) {
+	stsServer, _ := newFakeSTS(t, time.Hour)
+
+	t.Run("missing token file", func(t *testing.T) {
+		client, err := NewS3Client(S3Config{
+			Endpoint:    "https://s3.example.com",
+			Bucket:      "oidc",
+			WebIdentity: &WebIdentity{RoleARN: "arn:aws:iam::123456789012:role/oidc", TokenFile: filepath.Join(t.TempDir(), "missing")},
+		})
+		require.NoError(t, err)
+
+		_, err = client.credentials(context.Background())
+		assert.ErrorContains(t, err, "failed to read web identity token")
+	})
+
+	t.Run("rejected token", func(t *testing.T) {
+		client, err := NewS3Client(S3Config{
+			Endpoint: "https://s3.example.com",
+			Bucket:   "oidc",
+			WebIdentity: &WebIdentity{
+				RoleARN:     "arn:aws:iam::123456789012:role/oidc",
+				TokenFile:   writeToken(t, "other-token"),
+				STSEndpoint: stsServer.URL,
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = client.credentials(context.Background())
+		assert.ErrorContains(t, err, "400 Bad Request")
+		assert.ErrorContains(t, err, "InvalidIdentityToken")
+	})
+}
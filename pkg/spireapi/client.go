@@ -0,0 +1,396 @@
+// Package spireapi provides a client for the SPIRE server API. The API is served on a private Unix socket
+// in the spire-server pod, which a proxy sidecar exposes to the operator over mutual TLS.
+package spireapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	localauthorityv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/localauthority/v1"
+	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ServerAPISocketPathEnv overrides the path of the SPIRE server private API socket
+	ServerAPISocketPathEnv = "SPIRE_SERVER_API_SOCKET_PATH"
+
+	// DefaultServerAPISocketPath is the path of the private API socket in the spire-server pod
+	DefaultServerAPISocketPath = "/tmp/spire-server/private/api.sock"
+
+	// ExpiresBeforeLayout is the time layout the server expects for the agent expiry filter
+	ExpiresBeforeLayout = "2006-01-02 15:04:05 -0700 -07"
+
+	listAgentsPageSize = 1000
+)
+
+// Client is the subset of the SPIRE server API used by the operator
+//
+//go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
+//counterfeiter:generate -o fakes . Client
+type Client interface {
+	// CreateJoinToken creates a join token valid for ttl. When agentID is set, the server
+	// also maps the agent attested with the token to that SPIFFE ID.
+	CreateJoinToken(ctx context.Context, ttl time.Duration, agentID *types.SPIFFEID) (*types.JoinToken, error)
+
+	// GetAgent returns the attested agent with the given SPIFFE ID, or nil if it does not exist.
+	GetAgent(ctx context.Context, id *types.SPIFFEID) (*types.Agent, error)
+
+	// ListAgents returns all attested agents matching the filter.
+	ListAgents(ctx context.Context, filter *agentv1.ListAgentsRequest_Filter) ([]*types.Agent, error)
+
+	// DeleteAgent removes the agent from the datastore. The agent has to attest again to get an SVID.
+	DeleteAgent(ctx context.Context, id *types.SPIFFEID) error
+
+	// BanAgent removes the agent SVID and prevents it from attesting again with the same identity.
+	BanAgent(ctx context.Context, id *types.SPIFFEID) error
+
+	// GetAuthorityState returns the active, prepared and old local authorities of the given type.
+	GetAuthorityState(ctx context.Context, authority Authority) (*AuthorityStates, error)
+
+	// PrepareAuthority prepares a new local authority and returns it.
+	PrepareAuthority(ctx context.Context, authority Authority) (*localauthorityv1.AuthorityState, error)
+
+	// ActivateAuthority makes the prepared authority with the given ID the active one.
+	ActivateAuthority(ctx context.Context, authority Authority, authorityID string) (*localauthorityv1.AuthorityState, error)
+
+	// TaintAuthority taints the old authority with the given ID.
+	TaintAuthority(ctx context.Context, authority Authority, authorityID string) (*localauthorityv1.AuthorityState, error)
+
+	// RevokeAuthority revokes the tainted old authority with the given ID.
+	RevokeAuthority(ctx context.Context, authority Authority, authorityID string) (*localauthorityv1.AuthorityState, error)
+
+	// MintX509SVID signs the CSR and returns an X509-SVID for the SPIFFE ID in its URI SAN, valid for
+	// ttl or the server default when ttl is zero.
+	MintX509SVID(ctx context.Context, csr []byte, ttl time.Duration) (*types.X509SVID, error)
+
+	// GetBundle returns the trust bundle of the server's trust domain.
+	GetBundle(ctx context.Context) (*types.Bundle, error)
+
+	io.Closer
+}
+
+// Authority selects the X.509 or JWT local authority
+type Authority string
+
+const (
+	// X509Authority is the local authority signing X509-SVIDs
+	X509Authority Authority = "X509"
+	// JWTAuthority is the local authority signing JWT-SVIDs
+	JWTAuthority Authority = "JWT"
+)
+
+// AuthorityStates are the local authorities of one type, each nil when the server has none
+type AuthorityStates struct {
+	Active   *localauthorityv1.AuthorityState
+	Prepared *localauthorityv1.AuthorityState
+	Old      *localauthorityv1.AuthorityState
+	// OldTainted reports whether the old authority is tainted. SPIRE records the taint in the bundle
+	// only, the local authority API does not return it.
+	OldTainted bool
+}
+
+// DialFunc opens a connection to the SPIRE server API
+type DialFunc func() (Client, error)
+
+// ServerAPISocketPath returns the path of the SPIRE server private API socket
+func ServerAPISocketPath() string {
+	if path := os.Getenv(ServerAPISocketPathEnv); path != "" {
+		return path
+	}
+	return DefaultServerAPISocketPath
+}
+
+// IsUnavailable reports whether err means the SPIRE server API could not be reached, for instance while
+// the spire-server pod restarts
+func IsUnavailable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// DialTLS connects to the SPIRE server API proxy at address over mutual TLS
+func DialTLS(address string, tlsConfig *tls.Config) (Client, error) {
+	conn, err := grpc.NewClient("dns:///"+address, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial SPIRE server API proxy %s: %w", address, err)
+	}
+	return newClient(conn), nil
+}
+
+// DialSocket connects to the SPIRE server API on the given Unix socket
+func DialSocket(path string) (Client, error) {
+	conn, err := dialSocket(path)
+	if err != nil {
+		return nil, err
+	}
+	return newClient(conn), nil
+}
+
+// dialSocket returns a gRPC connection to the Unix socket at path
+func dialSocket(path string) (*grpc.ClientConn, error) {
+	target := "unix:" + path
+	if filepath.IsAbs(path) {
+		target = "unix://" + path
+	}
+	conn, err := grpc.NewClient(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial SPIRE server API socket %s: %w", path, err)
+	}
+	return conn, nil
+}
+
+func newClient(conn *grpc.ClientConn) *client {
+	return &client{
+		conn:           conn,
+		agent:          agentv1.NewAgentClient(conn),
+		localAuthority: localauthorityv1.NewLocalAuthorityClient(conn),
+		svid:           svidv1.NewSVIDClient(conn),
+		bundle:         bundlev1.NewBundleClient(conn),
+	}
+}
+
+type client struct {
+	conn           *grpc.ClientConn
+	agent          agentv1.AgentClient
+	localAuthority localauthorityv1.LocalAuthorityClient
+	svid           svidv1.SVIDClient
+	bundle         bundlev1.BundleClient
+}
+
+func (c *client) CreateJoinToken(ctx context.Context, ttl time.Duration, agentID *types.SPIFFEID) (*types.JoinToken, error) {
+	token, err := c.agent.CreateJoinToken(ctx, &agentv1.CreateJoinTokenRequest{
+		Ttl:     int32(ttl / time.Second),
+		AgentId: agentID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create join token: %w", err)
+	}
+	return token, nil
+}
+
+func (c *client) GetAgent(ctx context.Context, id *types.SPIFFEID) (*types.Agent, error) {
+	agent, err := c.agent.GetAgent(ctx, &agentv1.GetAgentRequest{Id: id})
+	if status.Code(err) == codes.NotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get agent %s: %w", id.GetPath(), err)
+	}
+	return agent, nil
+}
+
+func (c *client) ListAgents(ctx context.Context, filter *agentv1.ListAgentsRequest_Filter) ([]*types.Agent, error) {
+	var agents []*types.Agent
+	req := &agentv1.ListAgentsRequest{
+		Filter:   filter,
+		PageSize: listAgentsPageSize,
+		// Only the fields needed to identify agents and their nodes are returned
+		OutputMask: &types.AgentMask{AttestationType: true, Selectors: true, X509SvidExpiresAt: true, Banned: true},
+	}
+	for {
+		resp, err := c.agent.ListAgents(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list agents: %w", err)
+		}
+		agents = append(agents, resp.Agents...)
+		if resp.NextPageToken == "" {
+			return agents, nil
+		}
+		req.PageToken = resp.NextPageToken
+	}
+}
+
+func (c *client) DeleteAgent(ctx context.Context, id *types.SPIFFEID) error {
+	if _, err := c.agent.DeleteAgent(ctx, &agentv1.DeleteAgentRequest{Id: id}); err != nil && status.Code(err) != codes.NotFound {
+		return fmt.Errorf("failed to delete agent %s: %w", id.GetPath(), err)
+	}
+	return nil
+}
+
+func (c *client) BanAgent(ctx context.Context, id *types.SPIFFEID) error {
+	if _, err := c.agent.BanAgent(ctx, &agentv1.BanAgentRequest{Id: id}); err != nil {
+		return fmt.Errorf("failed to ban agent %s: %w", id.GetPath(), err)
+	}
+	return nil
+}
+
+func (c *client) GetAuthorityState(ctx context.Context, authority Authority) (*AuthorityStates, error) {
+	var states *AuthorityStates
+	var err error
+	switch authority {
+	case X509Authority:
+		var resp *localauthorityv1.GetX509AuthorityStateResponse
+		if resp, err = c.localAuthority.GetX509AuthorityState(ctx, &localauthorityv1.GetX509AuthorityStateRequest{}); err == nil {
+			states = &AuthorityStates{Active: resp.Active, Prepared: resp.Prepared, Old: resp.Old}
+		}
+	case JWTAuthority:
+		var resp *localauthorityv1.GetJWTAuthorityStateResponse
+		if resp, err = c.localAuthority.GetJWTAuthorityState(ctx, &localauthorityv1.GetJWTAuthorityStateRequest{}); err == nil {
+			states = &AuthorityStates{Active: resp.Active, Prepared: resp.Prepared, Old: resp.Old}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported authority %q", authority)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s authority state: %w", authority, err)
+	}
+	if states.Old != nil {
+		bundle, err := c.GetBundle(ctx)
+		if err != nil {
+			return nil, err
+		}
+		states.OldTainted = BundleAuthorityTainted(bundle, authority, states.Old.AuthorityId)
+	}
+	return states, nil
+}
+
+// BundleAuthorityTainted reports whether the bundle marks the authority as tainted. X.509 authorities are
+// identified by the hex encoded subject key ID of their certificate, JWT authorities by their key ID.
+func BundleAuthorityTainted(bundle *types.Bundle, authority Authority, authorityID string) bool {
+	switch authority {
+	case X509Authority:
+		for _, ca := range bundle.GetX509Authorities() {
+			cert, err := x509.ParseCertificate(ca.Asn1)
+			if err == nil && hex.EncodeToString(cert.SubjectKeyId) == authorityID {
+				return ca.Tainted
+			}
+		}
+	case JWTAuthority:
+		for _, key := range bundle.GetJwtAuthorities() {
+			if key.KeyId == authorityID {
+				return key.Tainted
+			}
+		}
+	}
+	return false
+}
+
+func (c *client) PrepareAuthority(ctx context.Context, authority Authority) (*localauthorityv1.AuthorityState, error) {
+	var state *localauthorityv1.AuthorityState
+	var err error
+	switch authority {
+	case X509Authority:
+		var resp *localauthorityv1.PrepareX509AuthorityResponse
+		if resp, err = c.localAuthority.PrepareX509Authority(ctx, &localauthorityv1.PrepareX509AuthorityRequest{}); err == nil {
+			state = resp.PreparedAuthority
+		}
+	case JWTAuthority:
+		var resp *localauthorityv1.PrepareJWTAuthorityResponse
+		if resp, err = c.localAuthority.PrepareJWTAuthority(ctx, &localauthorityv1.PrepareJWTAuthorityRequest{}); err == nil {
+			state = resp.PreparedAuthority
+		}
+	default:
+		return nil, fmt.Errorf("unsupported authority %q", authority)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare %s authority: %w", authority, err)
+	}
+	return state, nil
+}
+
+func (c *client) ActivateAuthority(ctx context.Context, authority Authority, authorityID string) (*localauthorityv1.AuthorityState, error) {
+	var state *localauthorityv1.AuthorityState
+	var err error
+	switch authority {
+	case X509Authority:
+		var resp *localauthorityv1.ActivateX509AuthorityResponse
+		if resp, err = c.localAuthority.ActivateX509Authority(ctx, &localauthorityv1.ActivateX509AuthorityRequest{AuthorityId: authorityID}); err == nil {
+			state = resp.ActivatedAuthority
+		}
+	case JWTAuthority:
+		var resp *localauthorityv1.ActivateJWTAuthorityResponse
+		if resp, err = c.localAuthority.ActivateJWTAuthority(ctx, &localauthorityv1.ActivateJWTAuthorityRequest{AuthorityId: authorityID}); err == nil {
+			state = resp.ActivatedAuthority
+		}
+	default:
+		return nil, fmt.Errorf("unsupported authority %q", authority)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate %s authority %s: %w", authority, authorityID, err)
+	}
+	return state, nil
+}
+
+func (c *client) TaintAuthority(ctx context.Context, authority Authority, authorityID string) (*localauthorityv1.AuthorityState, error) {
+	var state *localauthorityv1.AuthorityState
+	var err error
+	switch authority {
+	case X509Authority:
+		var resp *localauthorityv1.TaintX509AuthorityResponse
+		if resp, err = c.localAuthority.TaintX509Authority(ctx, &localauthorityv1.TaintX509AuthorityRequest{AuthorityId: authorityID}); err == nil {
+			state = resp.TaintedAuthority
+		}
+	case JWTAuthority:
+		var resp *localauthorityv1.TaintJWTAuthorityResponse
+		if resp, err = c.localAuthority.TaintJWTAuthority(ctx, &localauthorityv1.TaintJWTAuthorityRequest{AuthorityId: authorityID}); err == nil {
+			state = resp.TaintedAuthority
+		}
+	default:
+		return nil, fmt.Errorf("unsupported authority %q", authority)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to taint %s authority %s: %w", authority, authorityID, err)
+	}
+	return state, nil
+}
+
+func (c *client) RevokeAuthority(ctx context.Context, authority Authority, authorityID string) (*localauthorityv1.AuthorityState, error) {
+	var state *localauthorityv1.AuthorityState
+	var err error
+	switch authority {
+	case X509Authority:
+		var resp *localauthorityv1.RevokeX509AuthorityResponse
+		if resp, err = c.localAuthority.RevokeX509Authority(ctx, &localauthorityv1.RevokeX509AuthorityRequest{AuthorityId: authorityID}); err == nil {
+			state = resp.RevokedAuthority
+		}
+	case JWTAuthority:
+		var resp *localauthorityv1.RevokeJWTAuthorityResponse
+		if resp, err = c.localAuthority.RevokeJWTAuthority(ctx, &localauthorityv1.RevokeJWTAuthorityRequest{AuthorityId: authorityID}); err == nil {
+			state = resp.RevokedAuthority
+		}
+	default:
+		return nil, fmt.Errorf("unsupported authority %q", authority)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke %s authority %s: %w", authority, authorityID, err)
+	}
+	return state, nil
+}
+
+func (c *client) MintX509SVID(ctx context.Context, csr []byte, ttl time.Duration) (*types.X509SVID, error) {
+	resp, err := c.svid.MintX509SVID(ctx, &svidv1.MintX509SVIDRequest{
+		Csr: csr,
+		Ttl: int32(ttl / time.Second),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint X509-SVID: %w", err)
+	}
+	return resp.Svid, nil
+}
+
+func (c *client) GetBundle(ctx context.Context) (*types.Bundle, error) {
+	bundle, err := c.bundle.GetBundle(ctx, &bundlev1.GetBundleRequest{
+		OutputMask: &types.BundleMask{X509Authorities: true, JwtAuthorities: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bundle: %w", err)
+	}
+	return bundle, nil
+}
+
+func (c *client) Close() error {
+	return c.conn.Close()
+}
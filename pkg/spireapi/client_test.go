@@ -0,0 +1,352 @@
+package spireapi
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	localauthorityv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/localauthority/v1"
+	svidv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/svid/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeAgentServer serves the agent API from an in-memory set of agents
+type fakeAgentServer struct {
+	agentv1.UnimplementedAgentServer
+
+	agents     map[string]*types.Agent
+	lastCreate *agentv1.CreateJoinTokenRequest
+	lastList   *agentv1.ListAgentsRequest
+	deleted    []string
+	banned     []string
+}
+
+func (s *fakeAgentServer) CreateJoinToken(_ context.Context, req *agentv1.CreateJoinTokenRequest) (*types.JoinToken, error) {
+	s.lastCreate = req
+	return &types.JoinToken{Value: "token-value", ExpiresAt: time.Now().Add(time.Duration(req.Ttl) * time.Second).Unix()}, nil
+}
+
+func (s *fakeAgentServer) GetAgent(_ context.Context, req *agentv1.GetAgentRequest) (*types.Agent, error) {
+	agent, ok := s.agents[req.Id.GetPath()]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "agent not found")
+	}
+	return agent, nil
+}
+
+// ListAgents returns one agent per page, ordered by path
+func (s *fakeAgentServer) ListAgents(_ context.Context, req *agentv1.ListAgentsRequest) (*agentv1.ListAgentsResponse, error) {
+	s.lastList = req
+	paths := make([]string, 0, len(s.agents))
+	for path := range s.agents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	index := 0
+	if req.PageToken != "" {
+		index = sort.SearchStrings(paths, req.PageToken)
+	}
+	if index >= len(paths) {
+		return &agentv1.ListAgentsResponse{}, nil
+	}
+	resp := &agentv1.ListAgentsResponse{Agents: []*types.Agent{s.agents[paths[index]]}}
+	if index+1 < len(paths) {
+		resp.NextPageToken = paths[index+1]
+	}
+	return resp, nil
+}
+
+func (s *fakeAgentServer) DeleteAgent(_ context.Context, req *agentv1.DeleteAgentRequest) (*emptypb.Empty, error) {
+	if _, ok := s.agents[req.Id.GetPath()]; !ok {
+		return nil, status.Error(codes.NotFound, "agent not found")
+	}
+	s.deleted = append(s.deleted, req.Id.GetPath())
+	return &emptypb.Empty{}, nil
+}
+
+func (s *fakeAgentServer) BanAgent(_ context.Context, req *agentv1.BanAgentRequest) (*emptypb.Empty, error) {
+	if _, ok := s.agents[req.Id.GetPath()]; !ok {
+		return nil, status.Error(codes.NotFound, "agent not found")
+	}
+	s.banned = append(s.banned, req.Id.GetPath())
+	return &emptypb.Empty{}, nil
+}
+
+// fakeLocalAuthorityServer serves a fixed set of X.509 and JWT authorities
+type fakeLocalAuthorityServer struct {
+	localauthorityv1.UnimplementedLocalAuthorityServer
+
+	tainted string
+}
+
+func (s *fakeLocalAuthorityServer) GetJWTAuthorityState(context.Context, *localauthorityv1.GetJWTAuthorityStateRequest) (*localauthorityv1.GetJWTAuthorityStateResponse, error) {
+	return &localauthorityv1.GetJWTAuthorityStateResponse{
+		Active: &localauthorityv1.AuthorityState{AuthorityId: "jwt-active"},
+		Old:    &localauthorityv1.AuthorityState{AuthorityId: "jwt-old"},
+	}, nil
+}
+
+func (s *fakeLocalAuthorityServer) GetX509AuthorityState(context.Context, *localauthorityv1.GetX509AuthorityStateRequest) (*localauthorityv1.GetX509AuthorityStateResponse, error) {
+	return &localauthorityv1.GetX509AuthorityStateResponse{
+		Active: &localauthorityv1.AuthorityState{AuthorityId: "x509-active"},
+		Old:    &localauthorityv1.AuthorityState{AuthorityId: "x509-old"},
+	}, nil
+}
+
+func (s *fakeLocalAuthorityServer) PrepareX509Authority(context.Context, *localauthorityv1.PrepareX509AuthorityRequest) (*localauthorityv1.PrepareX509AuthorityResponse, error) {
+	return &localauthorityv1.PrepareX509AuthorityResponse{PreparedAuthority: &localauthorityv1.AuthorityState{AuthorityId: "x509-prepared"}}, nil
+}
+
+func (s *fakeLocalAuthorityServer) TaintX509Authority(_ context.Context, req *localauthorityv1.TaintX509AuthorityRequest) (*localauthorityv1.TaintX509AuthorityResponse, error) {
+	if req.AuthorityId != "x509-old" {
+		return nil, status.Error(codes.InvalidArgument, "only the old authority can be tainted")
+	}
+	s.tainted = req.AuthorityId
+	return &localauthorityv1.TaintX509AuthorityResponse{TaintedAuthority: &localauthorityv1.AuthorityState{AuthorityId: req.AuthorityId}}, nil
+}
+
+// fakeSVIDServer mints an SVID echoing the CSR as its certificate
+type fakeSVIDServer struct {
+	svidv1.UnimplementedSVIDServer
+
+	lastMint *svidv1.MintX509SVIDRequest
+}
+
+func (s *fakeSVIDServer) MintX509SVID(_ context.Context, req *svidv1.MintX509SVIDRequest) (*svidv1.MintX509SVIDResponse, error) {
+	s.lastMint = req
+	return &svidv1.MintX509SVIDResponse{Svid: &types.X509SVID{
+		CertChain: [][]byte{req.Csr},
+		ExpiresAt: time.Now().Add(time.Duration(req.Ttl) * time.Second).Unix(),
+	}}, nil
+}
+
+// fakeBundleServer serves a bundle with a single X.509 authority and a tainted JWT authority
+type fakeBundleServer struct {
+	bundlev1.UnimplementedBundleServer
+
+	lastGet *bundlev1.GetBundleRequest
+}
+
+func (s *fakeBundleServer) GetBundle(_ context.Context, req *bundlev1.GetBundleRequest) (*types.Bundle, error) {
+	s.lastGet = req
+	return &types.Bundle{
+		TrustDomain:     "example.com",
+		X509Authorities: []*types.X509Certificate{{Asn1: []byte("ca")}},
+		JwtAuthorities:  []*types.JWTKey{{KeyId: "jwt-old", Tainted: true}},
+	}, nil
+}
+
+// startFakeServer serves the fake agent API on a Unix socket and returns its path
+func startFakeServer(t *testing.T, server *fakeAgentServer) string {
+	return startFakeServers(t, server, &fakeLocalAuthorityServer{})
+}
+
+// startFakeServers serves the fake agent and local authority APIs on a Unix socket and returns its path
+func startFakeServers(t *testing.T, server *fakeAgentServer, localAuthority *fakeLocalAuthorityServer) string {
+	return serveFake(t, func(grpcServer *grpc.Server) {
+		agentv1.RegisterAgentServer(grpcServer, server)
+		localauthorityv1.RegisterLocalAuthorityServer(grpcServer, localAuthority)
+		bundlev1.RegisterBundleServer(grpcServer, &fakeBundleServer{})
+	})
+}
+
+// serveFake serves the APIs registered by register on a Unix socket and returns its path
+func serveFake(t *testing.T, register func(*grpc.Server)) string {
+	t.Helper()
+	// Unix socket paths are length limited, so avoid the long test temp directory
+	dir, err := os.MkdirTemp("", "spireapi")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "api.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	grpcServer := grpc.NewServer()
+	register(grpcServer)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+	return path
+}
+
+func TestServerAPISocketPath(t *testing.T) {
+	t.Setenv(ServerAPISocketPathEnv, "")
+	assert.Equal(t, DefaultServerAPISocketPath, ServerAPISocketPath())
+
+	t.Setenv(ServerAPISocketPathEnv, "/run/spire/api.sock")
+	assert.Equal(t, "/run/spire/api.sock", ServerAPISocketPath())
+}
+
+func TestCreateJoinToken(t *testing.T) {
+	server := &fakeAgentServer{}
+	c, err := DialSocket(startFakeServer(t, server))
+	require.NoError(t, err)
+	defer c.Close()
+
+	agentID := &types.SPIFFEID{TrustDomain: "example.com", Path: "/vm/build-runner"}
+	token, err := c.CreateJoinToken(context.Background(), 2*time.Hour, agentID)
+
+	require.NoError(t, err)
+	assert.Equal(t, "token-value", token.Value)
+	require.NotNil(t, server.lastCreate)
+	assert.Equal(t, int32(7200), server.lastCreate.Ttl)
+	assert.Equal(t, "/vm/build-runner", server.lastCreate.AgentId.GetPath())
+}
+
+func TestGetAgent(t *testing.T) {
+	server := &fakeAgentServer{agents: map[string]*types.Agent{
+		"/spire/agent/join_token/used": {Id: &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/join_token/used"}},
+	}}
+	c, err := DialSocket(startFakeServer(t, server))
+	require.NoError(t, err)
+	defer c.Close()
+
+	agent, err := c.GetAgent(context.Background(), &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/join_token/used"})
+	require.NoError(t, err)
+	require.NotNil(t, agent)
+	assert.Equal(t, "/spire/agent/join_token/used", agent.Id.GetPath())
+
+	agent, err = c.GetAgent(context.Background(), &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/join_token/unused"})
+	require.NoError(t, err)
+	assert.Nil(t, agent)
+}
+
+func TestListAgents(t *testing.T) {
+	server := &fakeAgentServer{agents: map[string]*types.Agent{
+		"/spire/agent/k8s_psat/a": {Id: &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/k8s_psat/a"}},
+		"/spire/agent/k8s_psat/b": {Id: &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/k8s_psat/b"}},
+		"/spire/agent/k8s_psat/c": {Id: &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/k8s_psat/c"}},
+	}}
+	c, err := DialSocket(startFakeServer(t, server))
+	require.NoError(t, err)
+	defer c.Close()
+
+	agents, err := c.ListAgents(context.Background(), &agentv1.ListAgentsRequest_Filter{ByAttestationType: "k8s_psat"})
+
+	require.NoError(t, err)
+	require.Len(t, agents, 3)
+	assert.Equal(t, "/spire/agent/k8s_psat/c", agents[2].Id.GetPath())
+	assert.Equal(t, "k8s_psat", server.lastList.Filter.ByAttestationType)
+	assert.True(t, server.lastList.OutputMask.Selectors)
+}
+
+func TestDeleteAndBanAgent(t *testing.T) {
+	server := &fakeAgentServer{agents: map[string]*types.Agent{
+		"/spire/agent/k8s_psat/a": {Id: &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/k8s_psat/a"}},
+	}}
+	c, err := DialSocket(startFakeServer(t, server))
+	require.NoError(t, err)
+	defer c.Close()
+
+	existing := &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/k8s_psat/a"}
+	missing := &types.SPIFFEID{TrustDomain: "example.com", Path: "/spire/agent/k8s_psat/gone"}
+
+	require.NoError(t, c.DeleteAgent(context.Background(), existing))
+	// Deleting an agent that is already gone is not an error
+	require.NoError(t, c.DeleteAgent(context.Background(), missing))
+	assert.Equal(t, []string{"/spire/agent/k8s_psat/a"}, server.deleted)
+
+	require.NoError(t, c.BanAgent(context.Background(), existing))
+	assert.Error(t, c.BanAgent(context.Background(), missing))
+	assert.Equal(t, []string{"/spire/agent/k8s_psat/a"}, server.banned)
+}
+
+func TestLocalAuthority(t *testing.T) {
+	localAuthority := &fakeLocalAuthorityServer{}
+	c, err := DialSocket(startFakeServers(t, &fakeAgentServer{}, localAuthority))
+	require.NoError(t, err)
+	defer c.Close()
+
+	states, err := c.GetAuthorityState(context.Background(), X509Authority)
+	require.NoError(t, err)
+	assert.Equal(t, "x509-active", states.Active.GetAuthorityId())
+	assert.Nil(t, states.Prepared)
+	assert.Equal(t, "x509-old", states.Old.GetAuthorityId())
+	assert.False(t, states.OldTainted)
+
+	states, err = c.GetAuthorityState(context.Background(), JWTAuthority)
+	require.NoError(t, err)
+	assert.Equal(t, "jwt-active", states.Active.GetAuthorityId())
+	assert.True(t, states.OldTainted)
+
+	prepared, err := c.PrepareAuthority(context.Background(), X509Authority)
+	require.NoError(t, err)
+	assert.Equal(t, "x509-prepared", prepared.AuthorityId)
+
+	tainted, err := c.TaintAuthority(context.Background(), X509Authority, "x509-old")
+	require.NoError(t, err)
+	assert.Equal(t, "x509-old", tainted.AuthorityId)
+	assert.Equal(t, "x509-old", localAuthority.tainted)
+
+	_, err = c.TaintAuthority(context.Background(), X509Authority, "x509-active")
+	assert.Error(t, err)
+
+	_, err = c.RevokeAuthority(context.Background(), Authority("WIT"), "id")
+	assert.ErrorContains(t, err, "unsupported authority")
+}
+
+func TestMintX509SVIDAndGetBundle(t *testing.T) {
+	svidServer := &fakeSVIDServer{}
+	bundleServer := &fakeBundleServer{}
+	c, err := DialSocket(serveFake(t, func(grpcServer *grpc.Server) {
+		svidv1.RegisterSVIDServer(grpcServer, svidServer)
+		bundlev1.RegisterBundleServer(grpcServer, bundleServer)
+	}))
+	require.NoError(t, err)
+	defer c.Close()
+
+	svid, err := c.MintX509SVID(context.Background(), []byte("csr"), 24*time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{[]byte("csr")}, svid.CertChain)
+	require.NotNil(t, svidServer.lastMint)
+	assert.Equal(t, int32(86400), svidServer.lastMint.Ttl)
+
+	bundle, err := c.GetBundle(context.Background())
+	require.NoError(t, err)
+	require.Len(t, bundle.X509Authorities, 1)
+	assert.Equal(t, []byte("ca"), bundle.X509Authorities[0].Asn1)
+	assert.True(t, bundleServer.lastGet.OutputMask.X509Authorities)
+	assert.True(t, bundleServer.lastGet.OutputMask.JwtAuthorities)
+}
+
+func TestBundleAuthorityTainted(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		SubjectKeyId:          []byte{0xab, 0xcd},
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	bundle := &types.Bundle{
+		X509Authorities: []*types.X509Certificate{{Asn1: []byte("not a certificate")}, {Asn1: der, Tainted: true}},
+		JwtAuthorities:  []*types.JWTKey{{KeyId: "jwt-old", Tainted: true}, {KeyId: "jwt-active"}},
+	}
+	assert.True(t, BundleAuthorityTainted(bundle, X509Authority, "abcd"))
+	assert.False(t, BundleAuthorityTainted(bundle, X509Authority, "ef01"))
+	assert.True(t, BundleAuthorityTainted(bundle, JWTAuthority, "jwt-old"))
+	assert.False(t, BundleAuthorityTainted(bundle, JWTAuthority, "jwt-active"))
+	assert.False(t, BundleAuthorityTainted(bundle, JWTAuthority, "abcd"))
+}
@@ -0,0 +1,139 @@
+package spireapi
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+const (
+	// proxyCACommonName is the subject of the CA issuing the proxy credentials
+	proxyCACommonName = "spire-server-api-proxy-ca"
+
+	// BundleReaderCommonName is the subject of the client certificate the proxy only allows to read the
+	// trust bundle
+	BundleReaderCommonName = "spire-server-api-bundle-reader"
+)
+
+// ProxyCredentials are the PEM encoded certificates and keys securing the connections to the server API
+// proxy. All certificates are issued by the same CA, whose key is discarded: the credentials are
+// generated again as a whole when they need to be rotated.
+type ProxyCredentials struct {
+	CACert           []byte
+	ServerCert       []byte
+	ServerKey        []byte
+	ClientCert       []byte
+	ClientKey        []byte
+	BundleReaderCert []byte
+	BundleReaderKey  []byte
+}
+
+// GenerateProxyCredentials issues a server certificate for serverName, a client certificate for the
+// operator and a bundle reader client certificate, all valid for validity
+func GenerateProxyCredentials(serverName, clientName string, validity time.Duration) (*ProxyCredentials, error) {
+	now := time.Now()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	caTemplate := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: proxyCACommonName},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	caCertPEM, caCert, err := signCertificate(caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue CA certificate: %w", err)
+	}
+
+	credentials := &ProxyCredentials{CACert: caCertPEM}
+	credentials.ServerCert, credentials.ServerKey, err = issueLeafCertificate(caCert, caKey, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: serverName},
+		DNSNames:    []string{serverName},
+		NotBefore:   now.Add(-time.Minute),
+		NotAfter:    now.Add(validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue server certificate: %w", err)
+	}
+	credentials.ClientCert, credentials.ClientKey, err = issueLeafCertificate(caCert, caKey, clientTemplate(clientName, now, validity))
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue client certificate: %w", err)
+	}
+	credentials.BundleReaderCert, credentials.BundleReaderKey, err = issueLeafCertificate(caCert, caKey, clientTemplate(BundleReaderCommonName, now, validity))
+	if err != nil {
+		return nil, fmt.Errorf("failed to issue bundle reader certificate: %w", err)
+	}
+	return credentials, nil
+}
+
+// clientTemplate returns the template of a client certificate for commonName
+func clientTemplate(commonName string, now time.Time, validity time.Duration) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:     pkix.Name{CommonName: commonName},
+		NotBefore:   now.Add(-time.Minute),
+		NotAfter:    now.Add(validity),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+}
+
+// CertificateNotAfter returns the expiry of the first certificate in certPEM
+func CertificateNotAfter(certPEM []byte) (time.Time, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return time.Time{}, fmt.Errorf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	return cert.NotAfter, nil
+}
+
+// issueLeafCertificate generates a key and a certificate signed by the CA, both PEM encoded
+func issueLeafCertificate(caCert *x509.Certificate, caKey *ecdsa.PrivateKey, template *x509.Certificate) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	certPEM, _, err := signCertificate(template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return certPEM, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+// signCertificate assigns a random serial number to template and signs it with the parent key
+func signCertificate(template, parent *x509.Certificate, publicKey *ecdsa.PublicKey, parentKey *ecdsa.PrivateKey) ([]byte, *x509.Certificate, error) {
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, nil, err
+	}
+	template.SerialNumber = serialNumber
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, publicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), cert, nil
+}
@@ -0,0 +1,63 @@
+package spireapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// credentialsReadTimeout bounds reading the client certificate Secret when dialing the proxy
+const credentialsReadTimeout = 10 * time.Second
+
+// ObjectGetter reads an object from the cluster
+type ObjectGetter interface {
+	Get(context.Context, ctrlclient.ObjectKey, ctrlclient.Object) error
+}
+
+// NewProxyDialer returns a DialFunc connecting to the server API proxy at address, authenticating with
+// the client certificate and trusting the CA stored in the given Secret
+func NewProxyDialer(reader ObjectGetter, secretKey k8stypes.NamespacedName, address string) DialFunc {
+	return func() (Client, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), credentialsReadTimeout)
+		defer cancel()
+
+		secret := &corev1.Secret{}
+		if err := reader.Get(ctx, secretKey, secret); err != nil {
+			return nil, fmt.Errorf("failed to get SPIRE server API client credentials %s: %w", secretKey.Name, err)
+		}
+		tlsConfig, err := clientTLSConfig(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey], secret.Data[ProxyCACertFileName], address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SPIRE server API client credentials %s: %w", secretKey.Name, err)
+		}
+		return DialTLS(address, tlsConfig)
+	}
+}
+
+// clientTLSConfig returns the TLS configuration for the PEM encoded client certificate, key and CA
+func clientTLSConfig(certPEM, keyPEM, caPEM []byte, address string) (*tls.Config, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	rootCAs := x509.NewCertPool()
+	if !rootCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no CA certificate found")
+	}
+	serverName, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      rootCAs,
+		ServerName:   serverName,
+	}, nil
+}
@@ -0,0 +1,1082 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/openshift/zero-trust-workload-identity-manager/pkg/spireapi"
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	localauthorityv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/localauthority/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+)
+
+type FakeClient struct {
+	ActivateAuthorityStub        func(context.Context, spireapi.Authority, string) (*localauthorityv1.AuthorityState, error)
+	activateAuthorityMutex       sync.RWMutex
+	activateAuthorityArgsForCall []struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+		arg3 string
+	}
+	activateAuthorityReturns struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	activateAuthorityReturnsOnCall map[int]struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	BanAgentStub        func(context.Context, *types.SPIFFEID) error
+	banAgentMutex       sync.RWMutex
+	banAgentArgsForCall []struct {
+		arg1 context.Context
+		arg2 *types.SPIFFEID
+	}
+	banAgentReturns struct {
+		result1 error
+	}
+	banAgentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CloseStub        func() error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+	}
+	closeReturns struct {
+		result1 error
+	}
+	closeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	CreateJoinTokenStub        func(context.Context, time.Duration, *types.SPIFFEID) (*types.JoinToken, error)
+	createJoinTokenMutex       sync.RWMutex
+	createJoinTokenArgsForCall []struct {
+		arg1 context.Context
+		arg2 time.Duration
+		arg3 *types.SPIFFEID
+	}
+	createJoinTokenReturns struct {
+		result1 *types.JoinToken
+		result2 error
+	}
+	createJoinTokenReturnsOnCall map[int]struct {
+		result1 *types.JoinToken
+		result2 error
+	}
+	DeleteAgentStub        func(context.Context, *types.SPIFFEID) error
+	deleteAgentMutex       sync.RWMutex
+	deleteAgentArgsForCall []struct {
+		arg1 context.Context
+		arg2 *types.SPIFFEID
+	}
+	deleteAgentReturns struct {
+		result1 error
+	}
+	deleteAgentReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GetAgentStub        func(context.Context, *types.SPIFFEID) (*types.Agent, error)
+	getAgentMutex       sync.RWMutex
+	getAgentArgsForCall []struct {
+		arg1 context.Context
+		arg2 *types.SPIFFEID
+	}
+	getAgentReturns struct {
+		result1 *types.Agent
+		result2 error
+	}
+	getAgentReturnsOnCall map[int]struct {
+		result1 *types.Agent
+		result2 error
+	}
+	GetAuthorityStateStub        func(context.Context, spireapi.Authority) (*spireapi.AuthorityStates, error)
+	getAuthorityStateMutex       sync.RWMutex
+	getAuthorityStateArgsForCall []struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+	}
+	getAuthorityStateReturns struct {
+		result1 *spireapi.AuthorityStates
+		result2 error
+	}
+	getAuthorityStateReturnsOnCall map[int]struct {
+		result1 *spireapi.AuthorityStates
+		result2 error
+	}
+	GetBundleStub        func(context.Context) (*types.Bundle, error)
+	getBundleMutex       sync.RWMutex
+	getBundleArgsForCall []struct {
+		arg1 context.Context
+	}
+	getBundleReturns struct {
+		result1 *types.Bundle
+		result2 error
+	}
+	getBundleReturnsOnCall map[int]struct {
+		result1 *types.Bundle
+		result2 error
+	}
+	ListAgentsStub        func(context.Context, *agentv1.ListAgentsRequest_Filter) ([]*types.Agent, error)
+	listAgentsMutex       sync.RWMutex
+	listAgentsArgsForCall []struct {
+		arg1 context.Context
+		arg2 *agentv1.ListAgentsRequest_Filter
+	}
+	listAgentsReturns struct {
+		result1 []*types.Agent
+		result2 error
+	}
+	listAgentsReturnsOnCall map[int]struct {
+		result1 []*types.Agent
+		result2 error
+	}
+	MintX509SVIDStub        func(context.Context, []byte, time.Duration) (*types.X509SVID, error)
+	mintX509SVIDMutex       sync.RWMutex
+	mintX509SVIDArgsForCall []struct {
+		arg1 context.Context
+		arg2 []byte
+		arg3 time.Duration
+	}
+	mintX509SVIDReturns struct {
+		result1 *types.X509SVID
+		result2 error
+	}
+	mintX509SVIDReturnsOnCall map[int]struct {
+		result1 *types.X509SVID
+		result2 error
+	}
+	PrepareAuthorityStub        func(context.Context, spireapi.Authority) (*localauthorityv1.AuthorityState, error)
+	prepareAuthorityMutex       sync.RWMutex
+	prepareAuthorityArgsForCall []struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+	}
+	prepareAuthorityReturns struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	prepareAuthorityReturnsOnCall map[int]struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	RevokeAuthorityStub        func(context.Context, spireapi.Authority, string) (*localauthorityv1.AuthorityState, error)
+	revokeAuthorityMutex       sync.RWMutex
+	revokeAuthorityArgsForCall []struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+		arg3 string
+	}
+	revokeAuthorityReturns struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	revokeAuthorityReturnsOnCall map[int]struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	TaintAuthorityStub        func(context.Context, spireapi.Authority, string) (*localauthorityv1.AuthorityState, error)
+	taintAuthorityMutex       sync.RWMutex
+	taintAuthorityArgsForCall []struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+		arg3 string
+	}
+	taintAuthorityReturns struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	taintAuthorityReturnsOnCall map[int]struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *FakeClient) ActivateAuthority(arg1 context.Context, arg2 spireapi.Authority, arg3 string) (*localauthorityv1.AuthorityState, error) {
+	fake.activateAuthorityMutex.Lock()
+	ret, specificReturn := fake.activateAuthorityReturnsOnCall[len(fake.activateAuthorityArgsForCall)]
+	fake.activateAuthorityArgsForCall = append(fake.activateAuthorityArgsForCall, struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.ActivateAuthorityStub
+	fakeReturns := fake.activateAuthorityReturns
+	fake.recordInvocation("ActivateAuthority", []interface{}{arg1, arg2, arg3})
+	fake.activateAuthorityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) ActivateAuthorityCallCount() int {
+	fake.activateAuthorityMutex.RLock()
+	defer fake.activateAuthorityMutex.RUnlock()
+	return len(fake.activateAuthorityArgsForCall)
+}
+
+func (fake *FakeClient) ActivateAuthorityCalls(stub func(context.Context, spireapi.Authority, string) (*localauthorityv1.AuthorityState, error)) {
+	fake.activateAuthorityMutex.Lock()
+	defer fake.activateAuthorityMutex.Unlock()
+	fake.ActivateAuthorityStub = stub
+}
+
+func (fake *FakeClient) ActivateAuthorityArgsForCall(i int) (context.Context, spireapi.Authority, string) {
+	fake.activateAuthorityMutex.RLock()
+	defer fake.activateAuthorityMutex.RUnlock()
+	argsForCall := fake.activateAuthorityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) ActivateAuthorityReturns(result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.activateAuthorityMutex.Lock()
+	defer fake.activateAuthorityMutex.Unlock()
+	fake.ActivateAuthorityStub = nil
+	fake.activateAuthorityReturns = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ActivateAuthorityReturnsOnCall(i int, result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.activateAuthorityMutex.Lock()
+	defer fake.activateAuthorityMutex.Unlock()
+	fake.ActivateAuthorityStub = nil
+	if fake.activateAuthorityReturnsOnCall == nil {
+		fake.activateAuthorityReturnsOnCall = make(map[int]struct {
+			result1 *localauthorityv1.AuthorityState
+			result2 error
+		})
+	}
+	fake.activateAuthorityReturnsOnCall[i] = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) BanAgent(arg1 context.Context, arg2 *types.SPIFFEID) error {
+	fake.banAgentMutex.Lock()
+	ret, specificReturn := fake.banAgentReturnsOnCall[len(fake.banAgentArgsForCall)]
+	fake.banAgentArgsForCall = append(fake.banAgentArgsForCall, struct {
+		arg1 context.Context
+		arg2 *types.SPIFFEID
+	}{arg1, arg2})
+	stub := fake.BanAgentStub
+	fakeReturns := fake.banAgentReturns
+	fake.recordInvocation("BanAgent", []interface{}{arg1, arg2})
+	fake.banAgentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) BanAgentCallCount() int {
+	fake.banAgentMutex.RLock()
+	defer fake.banAgentMutex.RUnlock()
+	return len(fake.banAgentArgsForCall)
+}
+
+func (fake *FakeClient) BanAgentCalls(stub func(context.Context, *types.SPIFFEID) error) {
+	fake.banAgentMutex.Lock()
+	defer fake.banAgentMutex.Unlock()
+	fake.BanAgentStub = stub
+}
+
+func (fake *FakeClient) BanAgentArgsForCall(i int) (context.Context, *types.SPIFFEID) {
+	fake.banAgentMutex.RLock()
+	defer fake.banAgentMutex.RUnlock()
+	argsForCall := fake.banAgentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) BanAgentReturns(result1 error) {
+	fake.banAgentMutex.Lock()
+	defer fake.banAgentMutex.Unlock()
+	fake.BanAgentStub = nil
+	fake.banAgentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) BanAgentReturnsOnCall(i int, result1 error) {
+	fake.banAgentMutex.Lock()
+	defer fake.banAgentMutex.Unlock()
+	fake.BanAgentStub = nil
+	if fake.banAgentReturnsOnCall == nil {
+		fake.banAgentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.banAgentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) Close() error {
+	fake.closeMutex.Lock()
+	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+	}{})
+	stub := fake.CloseStub
+	fakeReturns := fake.closeReturns
+	fake.recordInvocation("Close", []interface{}{})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *FakeClient) CloseCalls(stub func() error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
+func (fake *FakeClient) CloseReturns(result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) CloseReturnsOnCall(i int, result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	if fake.closeReturnsOnCall == nil {
+		fake.closeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.closeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) CreateJoinToken(arg1 context.Context, arg2 time.Duration, arg3 *types.SPIFFEID) (*types.JoinToken, error) {
+	fake.createJoinTokenMutex.Lock()
+	ret, specificReturn := fake.createJoinTokenReturnsOnCall[len(fake.createJoinTokenArgsForCall)]
+	fake.createJoinTokenArgsForCall = append(fake.createJoinTokenArgsForCall, struct {
+		arg1 context.Context
+		arg2 time.Duration
+		arg3 *types.SPIFFEID
+	}{arg1, arg2, arg3})
+	stub := fake.CreateJoinTokenStub
+	fakeReturns := fake.createJoinTokenReturns
+	fake.recordInvocation("CreateJoinToken", []interface{}{arg1, arg2, arg3})
+	fake.createJoinTokenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) CreateJoinTokenCallCount() int {
+	fake.createJoinTokenMutex.RLock()
+	defer fake.createJoinTokenMutex.RUnlock()
+	return len(fake.createJoinTokenArgsForCall)
+}
+
+func (fake *FakeClient) CreateJoinTokenCalls(stub func(context.Context, time.Duration, *types.SPIFFEID) (*types.JoinToken, error)) {
+	fake.createJoinTokenMutex.Lock()
+	defer fake.createJoinTokenMutex.Unlock()
+	fake.CreateJoinTokenStub = stub
+}
+
+func (fake *FakeClient) CreateJoinTokenArgsForCall(i int) (context.Context, time.Duration, *types.SPIFFEID) {
+	fake.createJoinTokenMutex.RLock()
+	defer fake.createJoinTokenMutex.RUnlock()
+	argsForCall := fake.createJoinTokenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) CreateJoinTokenReturns(result1 *types.JoinToken, result2 error) {
+	fake.createJoinTokenMutex.Lock()
+	defer fake.createJoinTokenMutex.Unlock()
+	fake.CreateJoinTokenStub = nil
+	fake.createJoinTokenReturns = struct {
+		result1 *types.JoinToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) CreateJoinTokenReturnsOnCall(i int, result1 *types.JoinToken, result2 error) {
+	fake.createJoinTokenMutex.Lock()
+	defer fake.createJoinTokenMutex.Unlock()
+	fake.CreateJoinTokenStub = nil
+	if fake.createJoinTokenReturnsOnCall == nil {
+		fake.createJoinTokenReturnsOnCall = make(map[int]struct {
+			result1 *types.JoinToken
+			result2 error
+		})
+	}
+	fake.createJoinTokenReturnsOnCall[i] = struct {
+		result1 *types.JoinToken
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) DeleteAgent(arg1 context.Context, arg2 *types.SPIFFEID) error {
+	fake.deleteAgentMutex.Lock()
+	ret, specificReturn := fake.deleteAgentReturnsOnCall[len(fake.deleteAgentArgsForCall)]
+	fake.deleteAgentArgsForCall = append(fake.deleteAgentArgsForCall, struct {
+		arg1 context.Context
+		arg2 *types.SPIFFEID
+	}{arg1, arg2})
+	stub := fake.DeleteAgentStub
+	fakeReturns := fake.deleteAgentReturns
+	fake.recordInvocation("DeleteAgent", []interface{}{arg1, arg2})
+	fake.deleteAgentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FakeClient) DeleteAgentCallCount() int {
+	fake.deleteAgentMutex.RLock()
+	defer fake.deleteAgentMutex.RUnlock()
+	return len(fake.deleteAgentArgsForCall)
+}
+
+func (fake *FakeClient) DeleteAgentCalls(stub func(context.Context, *types.SPIFFEID) error) {
+	fake.deleteAgentMutex.Lock()
+	defer fake.deleteAgentMutex.Unlock()
+	fake.DeleteAgentStub = stub
+}
+
+func (fake *FakeClient) DeleteAgentArgsForCall(i int) (context.Context, *types.SPIFFEID) {
+	fake.deleteAgentMutex.RLock()
+	defer fake.deleteAgentMutex.RUnlock()
+	argsForCall := fake.deleteAgentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) DeleteAgentReturns(result1 error) {
+	fake.deleteAgentMutex.Lock()
+	defer fake.deleteAgentMutex.Unlock()
+	fake.DeleteAgentStub = nil
+	fake.deleteAgentReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) DeleteAgentReturnsOnCall(i int, result1 error) {
+	fake.deleteAgentMutex.Lock()
+	defer fake.deleteAgentMutex.Unlock()
+	fake.DeleteAgentStub = nil
+	if fake.deleteAgentReturnsOnCall == nil {
+		fake.deleteAgentReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteAgentReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeClient) GetAgent(arg1 context.Context, arg2 *types.SPIFFEID) (*types.Agent, error) {
+	fake.getAgentMutex.Lock()
+	ret, specificReturn := fake.getAgentReturnsOnCall[len(fake.getAgentArgsForCall)]
+	fake.getAgentArgsForCall = append(fake.getAgentArgsForCall, struct {
+		arg1 context.Context
+		arg2 *types.SPIFFEID
+	}{arg1, arg2})
+	stub := fake.GetAgentStub
+	fakeReturns := fake.getAgentReturns
+	fake.recordInvocation("GetAgent", []interface{}{arg1, arg2})
+	fake.getAgentMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) GetAgentCallCount() int {
+	fake.getAgentMutex.RLock()
+	defer fake.getAgentMutex.RUnlock()
+	return len(fake.getAgentArgsForCall)
+}
+
+func (fake *FakeClient) GetAgentCalls(stub func(context.Context, *types.SPIFFEID) (*types.Agent, error)) {
+	fake.getAgentMutex.Lock()
+	defer fake.getAgentMutex.Unlock()
+	fake.GetAgentStub = stub
+}
+
+func (fake *FakeClient) GetAgentArgsForCall(i int) (context.Context, *types.SPIFFEID) {
+	fake.getAgentMutex.RLock()
+	defer fake.getAgentMutex.RUnlock()
+	argsForCall := fake.getAgentArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) GetAgentReturns(result1 *types.Agent, result2 error) {
+	fake.getAgentMutex.Lock()
+	defer fake.getAgentMutex.Unlock()
+	fake.GetAgentStub = nil
+	fake.getAgentReturns = struct {
+		result1 *types.Agent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetAgentReturnsOnCall(i int, result1 *types.Agent, result2 error) {
+	fake.getAgentMutex.Lock()
+	defer fake.getAgentMutex.Unlock()
+	fake.GetAgentStub = nil
+	if fake.getAgentReturnsOnCall == nil {
+		fake.getAgentReturnsOnCall = make(map[int]struct {
+			result1 *types.Agent
+			result2 error
+		})
+	}
+	fake.getAgentReturnsOnCall[i] = struct {
+		result1 *types.Agent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetAuthorityState(arg1 context.Context, arg2 spireapi.Authority) (*spireapi.AuthorityStates, error) {
+	fake.getAuthorityStateMutex.Lock()
+	ret, specificReturn := fake.getAuthorityStateReturnsOnCall[len(fake.getAuthorityStateArgsForCall)]
+	fake.getAuthorityStateArgsForCall = append(fake.getAuthorityStateArgsForCall, struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+	}{arg1, arg2})
+	stub := fake.GetAuthorityStateStub
+	fakeReturns := fake.getAuthorityStateReturns
+	fake.recordInvocation("GetAuthorityState", []interface{}{arg1, arg2})
+	fake.getAuthorityStateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) GetAuthorityStateCallCount() int {
+	fake.getAuthorityStateMutex.RLock()
+	defer fake.getAuthorityStateMutex.RUnlock()
+	return len(fake.getAuthorityStateArgsForCall)
+}
+
+func (fake *FakeClient) GetAuthorityStateCalls(stub func(context.Context, spireapi.Authority) (*spireapi.AuthorityStates, error)) {
+	fake.getAuthorityStateMutex.Lock()
+	defer fake.getAuthorityStateMutex.Unlock()
+	fake.GetAuthorityStateStub = stub
+}
+
+func (fake *FakeClient) GetAuthorityStateArgsForCall(i int) (context.Context, spireapi.Authority) {
+	fake.getAuthorityStateMutex.RLock()
+	defer fake.getAuthorityStateMutex.RUnlock()
+	argsForCall := fake.getAuthorityStateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) GetAuthorityStateReturns(result1 *spireapi.AuthorityStates, result2 error) {
+	fake.getAuthorityStateMutex.Lock()
+	defer fake.getAuthorityStateMutex.Unlock()
+	fake.GetAuthorityStateStub = nil
+	fake.getAuthorityStateReturns = struct {
+		result1 *spireapi.AuthorityStates
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetAuthorityStateReturnsOnCall(i int, result1 *spireapi.AuthorityStates, result2 error) {
+	fake.getAuthorityStateMutex.Lock()
+	defer fake.getAuthorityStateMutex.Unlock()
+	fake.GetAuthorityStateStub = nil
+	if fake.getAuthorityStateReturnsOnCall == nil {
+		fake.getAuthorityStateReturnsOnCall = make(map[int]struct {
+			result1 *spireapi.AuthorityStates
+			result2 error
+		})
+	}
+	fake.getAuthorityStateReturnsOnCall[i] = struct {
+		result1 *spireapi.AuthorityStates
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetBundle(arg1 context.Context) (*types.Bundle, error) {
+	fake.getBundleMutex.Lock()
+	ret, specificReturn := fake.getBundleReturnsOnCall[len(fake.getBundleArgsForCall)]
+	fake.getBundleArgsForCall = append(fake.getBundleArgsForCall, struct {
+		arg1 context.Context
+	}{arg1})
+	stub := fake.GetBundleStub
+	fakeReturns := fake.getBundleReturns
+	fake.recordInvocation("GetBundle", []interface{}{arg1})
+	fake.getBundleMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) GetBundleCallCount() int {
+	fake.getBundleMutex.RLock()
+	defer fake.getBundleMutex.RUnlock()
+	return len(fake.getBundleArgsForCall)
+}
+
+func (fake *FakeClient) GetBundleCalls(stub func(context.Context) (*types.Bundle, error)) {
+	fake.getBundleMutex.Lock()
+	defer fake.getBundleMutex.Unlock()
+	fake.GetBundleStub = stub
+}
+
+func (fake *FakeClient) GetBundleArgsForCall(i int) context.Context {
+	fake.getBundleMutex.RLock()
+	defer fake.getBundleMutex.RUnlock()
+	argsForCall := fake.getBundleArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *FakeClient) GetBundleReturns(result1 *types.Bundle, result2 error) {
+	fake.getBundleMutex.Lock()
+	defer fake.getBundleMutex.Unlock()
+	fake.GetBundleStub = nil
+	fake.getBundleReturns = struct {
+		result1 *types.Bundle
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) GetBundleReturnsOnCall(i int, result1 *types.Bundle, result2 error) {
+	fake.getBundleMutex.Lock()
+	defer fake.getBundleMutex.Unlock()
+	fake.GetBundleStub = nil
+	if fake.getBundleReturnsOnCall == nil {
+		fake.getBundleReturnsOnCall = make(map[int]struct {
+			result1 *types.Bundle
+			result2 error
+		})
+	}
+	fake.getBundleReturnsOnCall[i] = struct {
+		result1 *types.Bundle
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ListAgents(arg1 context.Context, arg2 *agentv1.ListAgentsRequest_Filter) ([]*types.Agent, error) {
+	fake.listAgentsMutex.Lock()
+	ret, specificReturn := fake.listAgentsReturnsOnCall[len(fake.listAgentsArgsForCall)]
+	fake.listAgentsArgsForCall = append(fake.listAgentsArgsForCall, struct {
+		arg1 context.Context
+		arg2 *agentv1.ListAgentsRequest_Filter
+	}{arg1, arg2})
+	stub := fake.ListAgentsStub
+	fakeReturns := fake.listAgentsReturns
+	fake.recordInvocation("ListAgents", []interface{}{arg1, arg2})
+	fake.listAgentsMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) ListAgentsCallCount() int {
+	fake.listAgentsMutex.RLock()
+	defer fake.listAgentsMutex.RUnlock()
+	return len(fake.listAgentsArgsForCall)
+}
+
+func (fake *FakeClient) ListAgentsCalls(stub func(context.Context, *agentv1.ListAgentsRequest_Filter) ([]*types.Agent, error)) {
+	fake.listAgentsMutex.Lock()
+	defer fake.listAgentsMutex.Unlock()
+	fake.ListAgentsStub = stub
+}
+
+func (fake *FakeClient) ListAgentsArgsForCall(i int) (context.Context, *agentv1.ListAgentsRequest_Filter) {
+	fake.listAgentsMutex.RLock()
+	defer fake.listAgentsMutex.RUnlock()
+	argsForCall := fake.listAgentsArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) ListAgentsReturns(result1 []*types.Agent, result2 error) {
+	fake.listAgentsMutex.Lock()
+	defer fake.listAgentsMutex.Unlock()
+	fake.ListAgentsStub = nil
+	fake.listAgentsReturns = struct {
+		result1 []*types.Agent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) ListAgentsReturnsOnCall(i int, result1 []*types.Agent, result2 error) {
+	fake.listAgentsMutex.Lock()
+	defer fake.listAgentsMutex.Unlock()
+	fake.ListAgentsStub = nil
+	if fake.listAgentsReturnsOnCall == nil {
+		fake.listAgentsReturnsOnCall = make(map[int]struct {
+			result1 []*types.Agent
+			result2 error
+		})
+	}
+	fake.listAgentsReturnsOnCall[i] = struct {
+		result1 []*types.Agent
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) MintX509SVID(arg1 context.Context, arg2 []byte, arg3 time.Duration) (*types.X509SVID, error) {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.mintX509SVIDMutex.Lock()
+	ret, specificReturn := fake.mintX509SVIDReturnsOnCall[len(fake.mintX509SVIDArgsForCall)]
+	fake.mintX509SVIDArgsForCall = append(fake.mintX509SVIDArgsForCall, struct {
+		arg1 context.Context
+		arg2 []byte
+		arg3 time.Duration
+	}{arg1, arg2Copy, arg3})
+	stub := fake.MintX509SVIDStub
+	fakeReturns := fake.mintX509SVIDReturns
+	fake.recordInvocation("MintX509SVID", []interface{}{arg1, arg2Copy, arg3})
+	fake.mintX509SVIDMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) MintX509SVIDCallCount() int {
+	fake.mintX509SVIDMutex.RLock()
+	defer fake.mintX509SVIDMutex.RUnlock()
+	return len(fake.mintX509SVIDArgsForCall)
+}
+
+func (fake *FakeClient) MintX509SVIDCalls(stub func(context.Context, []byte, time.Duration) (*types.X509SVID, error)) {
+	fake.mintX509SVIDMutex.Lock()
+	defer fake.mintX509SVIDMutex.Unlock()
+	fake.MintX509SVIDStub = stub
+}
+
+func (fake *FakeClient) MintX509SVIDArgsForCall(i int) (context.Context, []byte, time.Duration) {
+	fake.mintX509SVIDMutex.RLock()
+	defer fake.mintX509SVIDMutex.RUnlock()
+	argsForCall := fake.mintX509SVIDArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) MintX509SVIDReturns(result1 *types.X509SVID, result2 error) {
+	fake.mintX509SVIDMutex.Lock()
+	defer fake.mintX509SVIDMutex.Unlock()
+	fake.MintX509SVIDStub = nil
+	fake.mintX509SVIDReturns = struct {
+		result1 *types.X509SVID
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) MintX509SVIDReturnsOnCall(i int, result1 *types.X509SVID, result2 error) {
+	fake.mintX509SVIDMutex.Lock()
+	defer fake.mintX509SVIDMutex.Unlock()
+	fake.MintX509SVIDStub = nil
+	if fake.mintX509SVIDReturnsOnCall == nil {
+		fake.mintX509SVIDReturnsOnCall = make(map[int]struct {
+			result1 *types.X509SVID
+			result2 error
+		})
+	}
+	fake.mintX509SVIDReturnsOnCall[i] = struct {
+		result1 *types.X509SVID
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) PrepareAuthority(arg1 context.Context, arg2 spireapi.Authority) (*localauthorityv1.AuthorityState, error) {
+	fake.prepareAuthorityMutex.Lock()
+	ret, specificReturn := fake.prepareAuthorityReturnsOnCall[len(fake.prepareAuthorityArgsForCall)]
+	fake.prepareAuthorityArgsForCall = append(fake.prepareAuthorityArgsForCall, struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+	}{arg1, arg2})
+	stub := fake.PrepareAuthorityStub
+	fakeReturns := fake.prepareAuthorityReturns
+	fake.recordInvocation("PrepareAuthority", []interface{}{arg1, arg2})
+	fake.prepareAuthorityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) PrepareAuthorityCallCount() int {
+	fake.prepareAuthorityMutex.RLock()
+	defer fake.prepareAuthorityMutex.RUnlock()
+	return len(fake.prepareAuthorityArgsForCall)
+}
+
+func (fake *FakeClient) PrepareAuthorityCalls(stub func(context.Context, spireapi.Authority) (*localauthorityv1.AuthorityState, error)) {
+	fake.prepareAuthorityMutex.Lock()
+	defer fake.prepareAuthorityMutex.Unlock()
+	fake.PrepareAuthorityStub = stub
+}
+
+func (fake *FakeClient) PrepareAuthorityArgsForCall(i int) (context.Context, spireapi.Authority) {
+	fake.prepareAuthorityMutex.RLock()
+	defer fake.prepareAuthorityMutex.RUnlock()
+	argsForCall := fake.prepareAuthorityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *FakeClient) PrepareAuthorityReturns(result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.prepareAuthorityMutex.Lock()
+	defer fake.prepareAuthorityMutex.Unlock()
+	fake.PrepareAuthorityStub = nil
+	fake.prepareAuthorityReturns = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) PrepareAuthorityReturnsOnCall(i int, result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.prepareAuthorityMutex.Lock()
+	defer fake.prepareAuthorityMutex.Unlock()
+	fake.PrepareAuthorityStub = nil
+	if fake.prepareAuthorityReturnsOnCall == nil {
+		fake.prepareAuthorityReturnsOnCall = make(map[int]struct {
+			result1 *localauthorityv1.AuthorityState
+			result2 error
+		})
+	}
+	fake.prepareAuthorityReturnsOnCall[i] = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) RevokeAuthority(arg1 context.Context, arg2 spireapi.Authority, arg3 string) (*localauthorityv1.AuthorityState, error) {
+	fake.revokeAuthorityMutex.Lock()
+	ret, specificReturn := fake.revokeAuthorityReturnsOnCall[len(fake.revokeAuthorityArgsForCall)]
+	fake.revokeAuthorityArgsForCall = append(fake.revokeAuthorityArgsForCall, struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.RevokeAuthorityStub
+	fakeReturns := fake.revokeAuthorityReturns
+	fake.recordInvocation("RevokeAuthority", []interface{}{arg1, arg2, arg3})
+	fake.revokeAuthorityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) RevokeAuthorityCallCount() int {
+	fake.revokeAuthorityMutex.RLock()
+	defer fake.revokeAuthorityMutex.RUnlock()
+	return len(fake.revokeAuthorityArgsForCall)
+}
+
+func (fake *FakeClient) RevokeAuthorityCalls(stub func(context.Context, spireapi.Authority, string) (*localauthorityv1.AuthorityState, error)) {
+	fake.revokeAuthorityMutex.Lock()
+	defer fake.revokeAuthorityMutex.Unlock()
+	fake.RevokeAuthorityStub = stub
+}
+
+func (fake *FakeClient) RevokeAuthorityArgsForCall(i int) (context.Context, spireapi.Authority, string) {
+	fake.revokeAuthorityMutex.RLock()
+	defer fake.revokeAuthorityMutex.RUnlock()
+	argsForCall := fake.revokeAuthorityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) RevokeAuthorityReturns(result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.revokeAuthorityMutex.Lock()
+	defer fake.revokeAuthorityMutex.Unlock()
+	fake.RevokeAuthorityStub = nil
+	fake.revokeAuthorityReturns = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) RevokeAuthorityReturnsOnCall(i int, result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.revokeAuthorityMutex.Lock()
+	defer fake.revokeAuthorityMutex.Unlock()
+	fake.RevokeAuthorityStub = nil
+	if fake.revokeAuthorityReturnsOnCall == nil {
+		fake.revokeAuthorityReturnsOnCall = make(map[int]struct {
+			result1 *localauthorityv1.AuthorityState
+			result2 error
+		})
+	}
+	fake.revokeAuthorityReturnsOnCall[i] = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) TaintAuthority(arg1 context.Context, arg2 spireapi.Authority, arg3 string) (*localauthorityv1.AuthorityState, error) {
+	fake.taintAuthorityMutex.Lock()
+	ret, specificReturn := fake.taintAuthorityReturnsOnCall[len(fake.taintAuthorityArgsForCall)]
+	fake.taintAuthorityArgsForCall = append(fake.taintAuthorityArgsForCall, struct {
+		arg1 context.Context
+		arg2 spireapi.Authority
+		arg3 string
+	}{arg1, arg2, arg3})
+	stub := fake.TaintAuthorityStub
+	fakeReturns := fake.taintAuthorityReturns
+	fake.recordInvocation("TaintAuthority", []interface{}{arg1, arg2, arg3})
+	fake.taintAuthorityMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *FakeClient) TaintAuthorityCallCount() int {
+	fake.taintAuthorityMutex.RLock()
+	defer fake.taintAuthorityMutex.RUnlock()
+	return len(fake.taintAuthorityArgsForCall)
+}
+
+func (fake *FakeClient) TaintAuthorityCalls(stub func(context.Context, spireapi.Authority, string) (*localauthorityv1.AuthorityState, error)) {
+	fake.taintAuthorityMutex.Lock()
+	defer fake.taintAuthorityMutex.Unlock()
+	fake.TaintAuthorityStub = stub
+}
+
+func (fake *FakeClient) TaintAuthorityArgsForCall(i int) (context.Context, spireapi.Authority, string) {
+	fake.taintAuthorityMutex.RLock()
+	defer fake.taintAuthorityMutex.RUnlock()
+	argsForCall := fake.taintAuthorityArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *FakeClient) TaintAuthorityReturns(result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.taintAuthorityMutex.Lock()
+	defer fake.taintAuthorityMutex.Unlock()
+	fake.TaintAuthorityStub = nil
+	fake.taintAuthorityReturns = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) TaintAuthorityReturnsOnCall(i int, result1 *localauthorityv1.AuthorityState, result2 error) {
+	fake.taintAuthorityMutex.Lock()
+	defer fake.taintAuthorityMutex.Unlock()
+	fake.TaintAuthorityStub = nil
+	if fake.taintAuthorityReturnsOnCall == nil {
+		fake.taintAuthorityReturnsOnCall = make(map[int]struct {
+			result1 *localauthorityv1.AuthorityState
+			result2 error
+		})
+	}
+	fake.taintAuthorityReturnsOnCall[i] = struct {
+		result1 *localauthorityv1.AuthorityState
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeClient) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.activateAuthorityMutex.RLock()
+	defer fake.activateAuthorityMutex.RUnlock()
+	fake.banAgentMutex.RLock()
+	defer fake.banAgentMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	fake.createJoinTokenMutex.RLock()
+	defer fake.createJoinTokenMutex.RUnlock()
+	fake.deleteAgentMutex.RLock()
+	defer fake.deleteAgentMutex.RUnlock()
+	fake.getAgentMutex.RLock()
+	defer fake.getAgentMutex.RUnlock()
+	fake.getAuthorityStateMutex.RLock()
+	defer fake.getAuthorityStateMutex.RUnlock()
+	fake.getBundleMutex.RLock()
+	defer fake.getBundleMutex.RUnlock()
+	fake.listAgentsMutex.RLock()
+	defer fake.listAgentsMutex.RUnlock()
+	fake.mintX509SVIDMutex.RLock()
+	defer fake.mintX509SVIDMutex.RUnlock()
+	fake.prepareAuthorityMutex.RLock()
+	defer fake.prepareAuthorityMutex.RUnlock()
+	fake.revokeAuthorityMutex.RLock()
+	defer fake.revokeAuthorityMutex.RUnlock()
+	fake.taintAuthorityMutex.RLock()
+	defer fake.taintAuthorityMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *FakeClient) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
+
+var _ spireapi.Client = new(FakeClient)
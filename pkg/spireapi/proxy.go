@@ -0,0 +1,224 @@
+package spireapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc"
+)
+
+const (
+	// ProxyCACertFileName, ProxyCertFileName and ProxyKeyFileName are the files the proxy reads its
+	// credentials from, matching the keys of the Secret mounted in its container
+	ProxyCACertFileName = "ca.crt"
+	ProxyCertFileName   = "tls.crt"
+	ProxyKeyFileName    = "tls.key"
+
+	proxyHandshakeTimeout = 10 * time.Second
+)
+
+// ProxyTLSConfig returns the server TLS configuration of the proxy. The certificate and client CA are read
+// from certDir on every handshake so that rotated credentials are picked up without a restart.
+func ProxyTLSConfig(certDir string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, err := tls.LoadX509KeyPair(filepath.Join(certDir, ProxyCertFileName), filepath.Join(certDir, ProxyKeyFileName))
+			if err != nil {
+				return nil, fmt.Errorf("failed to load proxy certificate: %w", err)
+			}
+			caPEM, err := os.ReadFile(filepath.Join(certDir, ProxyCACertFileName))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read proxy client CA: %w", err)
+			}
+			clientCAs := x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no certificates found in %s", ProxyCACertFileName)
+			}
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				Certificates: []tls.Certificate{cert},
+				ClientCAs:    clientCAs,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+				// gRPC clients require HTTP/2 to be negotiated
+				NextProtos: []string{"h2"},
+			}, nil
+		},
+	}
+}
+
+// ServeProxy accepts TLS connections on listener and forwards each one to the SPIRE server API on the
+// Unix socket at socketPath. SPIRE Server grants administrative access to callers on its private socket,
+// so tlsConfig must require a client certificate. Clients presenting the bundle reader certificate are
+// not forwarded: they are served a bundle API that only answers GetBundle. It returns when ctx is done
+// or the listener fails.
+func ServeProxy(ctx context.Context, listener net.Listener, socketPath string, tlsConfig *tls.Config) error {
+	upstream, err := dialSocket(socketPath)
+	if err != nil {
+		return err
+	}
+	defer upstream.Close()
+
+	bundleReaders := newConnListener(listener.Addr())
+	bundleServer := grpc.NewServer()
+	bundlev1.RegisterBundleServer(bundleServer, &bundleReaderServer{upstream: bundlev1.NewBundleClient(upstream)})
+	go func() { _ = bundleServer.Serve(bundleReaders) }()
+	defer bundleServer.Stop()
+
+	return acceptConnections(ctx, tls.NewListener(listener, tlsConfig), func(conn net.Conn) {
+		handleConnection(ctx, conn.(*tls.Conn), socketPath, bundleReaders)
+	})
+}
+
+// acceptConnections calls handle in a goroutine for every connection accepted on listener until ctx is
+// done, then waits for the handlers to return
+func acceptConnections(ctx context.Context, listener net.Listener, handle func(net.Conn)) error {
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			handle(conn)
+		}()
+	}
+}
+
+// handleConnection completes the handshake with the client, then hands bundle readers to the bundle API
+// and forwards the connections of other clients to the socket
+func handleConnection(ctx context.Context, conn *tls.Conn, socketPath string, bundleReaders *connListener) {
+	handshakeCtx, cancel := context.WithTimeout(ctx, proxyHandshakeTimeout)
+	defer cancel()
+	if err := conn.HandshakeContext(handshakeCtx); err != nil {
+		conn.Close()
+		return
+	}
+
+	if isBundleReader(conn.ConnectionState()) {
+		bundleReaders.deliver(ctx, conn)
+		return
+	}
+	forwardConnection(ctx, conn, socketPath)
+}
+
+// isBundleReader reports whether the client authenticated with the bundle reader certificate
+func isBundleReader(state tls.ConnectionState) bool {
+	return len(state.PeerCertificates) > 0 && state.PeerCertificates[0].Subject.CommonName == BundleReaderCommonName
+}
+
+// forwardConnection copies data between the client and the socket
+func forwardConnection(ctx context.Context, conn net.Conn, socketPath string) {
+	defer conn.Close()
+
+	upstream, err := (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+	pipe(ctx, conn, upstream)
+}
+
+// pipe copies data in both directions between conn and upstream until both are done or ctx is done
+func pipe(ctx context.Context, conn, upstream net.Conn) {
+	// Closing both connections when ctx is done unblocks the copies
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+		upstream.Close()
+	})
+	defer stop()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(upstream, conn)
+		closeWrite(upstream)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(conn, upstream)
+		closeWrite(conn)
+	}()
+	wg.Wait()
+}
+
+// closeWrite signals the end of the data to the peer when the connection supports half-closing it
+func closeWrite(conn net.Conn) {
+	if halfCloser, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = halfCloser.CloseWrite()
+	}
+}
+
+// bundleReaderServer is the bundle API served to bundle readers. It forwards GetBundle, which SPIRE Server
+// answers without authentication, and leaves every other method unimplemented.
+type bundleReaderServer struct {
+	bundlev1.UnimplementedBundleServer
+	upstream bundlev1.BundleClient
+}
+
+func (s *bundleReaderServer) GetBundle(ctx context.Context, req *bundlev1.GetBundleRequest) (*types.Bundle, error) {
+	return s.upstream.GetBundle(ctx, req)
+}
+
+// connListener is a net.Listener accepting the connections handed over by the proxy
+type connListener struct {
+	addr      net.Addr
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newConnListener(addr net.Addr) *connListener {
+	return &connListener{addr: addr, conns: make(chan net.Conn), closed: make(chan struct{})}
+}
+
+// deliver hands conn to the next Accept, or closes it when the listener or ctx is done first
+func (l *connListener) deliver(ctx context.Context, conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.closed:
+		conn.Close()
+	case <-ctx.Done():
+		conn.Close()
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
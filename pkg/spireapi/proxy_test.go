@@ -0,0 +1,176 @@
+package spireapi
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	agentv1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	bundlev1 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fakeSecretGetter returns a copy of secret for every Get
+type fakeSecretGetter struct {
+	secret *corev1.Secret
+}
+
+func (g *fakeSecretGetter) Get(_ context.Context, _ ctrlclient.ObjectKey, obj ctrlclient.Object) error {
+	g.secret.DeepCopyInto(obj.(*corev1.Secret))
+	return nil
+}
+
+// startProxy serves the fake agent and bundle APIs through the proxy and returns its address and
+// credentials
+func startProxy(t *testing.T, server *fakeAgentServer) (string, *ProxyCredentials) {
+	t.Helper()
+	socketPath := serveFake(t, func(grpcServer *grpc.Server) {
+		agentv1.RegisterAgentServer(grpcServer, server)
+		bundlev1.RegisterBundleServer(grpcServer, &fakeBundleServer{})
+	})
+
+	credentials, err := GenerateProxyCredentials("localhost", "operator", time.Hour)
+	require.NoError(t, err)
+	certDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(certDir, ProxyCertFileName), credentials.ServerCert, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(certDir, ProxyKeyFileName), credentials.ServerKey, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(certDir, ProxyCACertFileName), credentials.CACert, 0o600))
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ServeProxy(ctx, listener, socketPath, ProxyTLSConfig(certDir)) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+	return net.JoinHostPort("localhost", port), credentials
+}
+
+func clientSecret(credentials *ProxyCredentials) *corev1.Secret {
+	return &corev1.Secret{Data: map[string][]byte{
+		corev1.TLSCertKey:       credentials.ClientCert,
+		corev1.TLSPrivateKeyKey: credentials.ClientKey,
+		ProxyCACertFileName:     credentials.CACert,
+	}}
+}
+
+func TestProxyDialer(t *testing.T) {
+	server := &fakeAgentServer{}
+	address, credentials := startProxy(t, server)
+
+	dial := NewProxyDialer(&fakeSecretGetter{secret: clientSecret(credentials)}, k8stypes.NamespacedName{Name: "client"}, address)
+	c, err := dial()
+	require.NoError(t, err)
+	defer c.Close()
+
+	token, err := c.CreateJoinToken(context.Background(), time.Hour, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "token-value", token.Value)
+}
+
+func TestProxyBundleReader(t *testing.T) {
+	server := &fakeAgentServer{}
+	address, credentials := startProxy(t, server)
+
+	secret := clientSecret(credentials)
+	secret.Data[corev1.TLSCertKey] = credentials.BundleReaderCert
+	secret.Data[corev1.TLSPrivateKeyKey] = credentials.BundleReaderKey
+	c, err := NewProxyDialer(&fakeSecretGetter{secret: secret}, k8stypes.NamespacedName{Name: "bundle-reader"}, address)()
+	require.NoError(t, err)
+	defer c.Close()
+
+	bundle, err := c.GetBundle(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", bundle.TrustDomain)
+
+	_, err = c.CreateJoinToken(context.Background(), time.Hour, nil)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Nil(t, server.lastCreate)
+}
+
+func TestProxyRejectsUnauthenticatedClients(t *testing.T) {
+	address, credentials := startProxy(t, &fakeAgentServer{})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		tlsConfig, err := clientTLSConfig(credentials.ClientCert, credentials.ClientKey, credentials.CACert, address)
+		require.NoError(t, err)
+		tlsConfig.Certificates = nil
+
+		c, err := DialTLS(address, tlsConfig)
+		require.NoError(t, err)
+		defer c.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = c.CreateJoinToken(ctx, time.Hour, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("client certificate from another CA", func(t *testing.T) {
+		other, err := GenerateProxyCredentials("localhost", "operator", time.Hour)
+		require.NoError(t, err)
+		secret := clientSecret(other)
+		// Trust the proxy, but authenticate with a certificate it did not issue
+		secret.Data[ProxyCACertFileName] = credentials.CACert
+
+		c, err := NewProxyDialer(&fakeSecretGetter{secret: secret}, k8stypes.NamespacedName{Name: "client"}, address)()
+		require.NoError(t, err)
+		defer c.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err = c.CreateJoinToken(ctx, time.Hour, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("plain TLS handshake without client certificate fails", func(t *testing.T) {
+		conn, err := tls.Dial("tcp", address, &tls.Config{InsecureSkipVerify: true}) //nolint:gosec
+		if err == nil {
+			// TLS 1.3 reports the missing client certificate on the first read
+			_, err = conn.Read(make([]byte, 1))
+			conn.Close()
+		}
+		assert.Error(t, err)
+	})
+}
+
+func TestProxyDialerInvalidCredentials(t *testing.T) {
+	dial := NewProxyDialer(&fakeSecretGetter{secret: &corev1.Secret{}}, k8stypes.NamespacedName{Name: "client"}, "localhost:8444")
+	_, err := dial()
+	assert.ErrorContains(t, err, "invalid SPIRE server API client credentials client")
+}
+
+func TestGenerateProxyCredentials(t *testing.T) {
+	credentials, err := GenerateProxyCredentials("spire-server-api.ns.svc", "operator", 24*time.Hour)
+	require.NoError(t, err)
+
+	for _, certPEM := range [][]byte{credentials.CACert, credentials.ServerCert, credentials.ClientCert, credentials.BundleReaderCert} {
+		notAfter, err := CertificateNotAfter(certPEM)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(24*time.Hour), notAfter, time.Minute)
+	}
+	_, err = tls.X509KeyPair(credentials.ServerCert, credentials.ServerKey)
+	assert.NoError(t, err)
+	_, err = tls.X509KeyPair(credentials.ClientCert, credentials.ClientKey)
+	assert.NoError(t, err)
+	bundleReader, err := tls.X509KeyPair(credentials.BundleReaderCert, credentials.BundleReaderKey)
+	require.NoError(t, err)
+	assert.Equal(t, BundleReaderCommonName, bundleReader.Leaf.Subject.CommonName)
+
+	_, err = CertificateNotAfter([]byte("not a certificate"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+package spireapi
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+)
+
+// ServeRelay accepts connections on listener, a Unix socket shared with the other containers of the pod,
+// and forwards each one over mutual TLS to the server API proxy at address. The relay grants its clients
+// whatever the proxy allows the client certificate in certDir, so it is meant for the bundle reader
+// credentials. It returns when ctx is done or the listener fails.
+func ServeRelay(ctx context.Context, listener net.Listener, address, certDir string) error {
+	return acceptConnections(ctx, listener, func(conn net.Conn) {
+		relayConnection(ctx, conn, address, certDir)
+	})
+}
+
+// relayConnection dials the proxy and copies data between it and the client
+func relayConnection(ctx context.Context, conn net.Conn, address, certDir string) {
+	defer conn.Close()
+
+	// The credentials are read for every connection so that rotated ones are picked up without a restart
+	tlsConfig, err := relayTLSConfig(certDir, address)
+	if err != nil {
+		return
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, proxyHandshakeTimeout)
+	defer cancel()
+	upstream, err := (&tls.Dialer{Config: tlsConfig}).DialContext(dialCtx, "tcp", address)
+	if err != nil {
+		return
+	}
+	defer upstream.Close()
+	pipe(ctx, conn, upstream)
+}
+
+// relayTLSConfig returns the TLS configuration for the client certificate and CA in certDir
+func relayTLSConfig(certDir, address string) (*tls.Config, error) {
+	certPEM, err := os.ReadFile(filepath.Join(certDir, ProxyCertFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read relay certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(filepath.Join(certDir, ProxyKeyFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read relay key: %w", err)
+	}
+	caPEM, err := os.ReadFile(filepath.Join(certDir, ProxyCACertFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read relay CA: %w", err)
+	}
+	return clientTLSConfig(certPEM, keyPEM, caPEM, address)
+}
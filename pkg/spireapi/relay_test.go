@@ -0,0 +1,59 @@
+package spireapi
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRelay(t *testing.T) {
+	server := &fakeAgentServer{}
+	address, credentials := startProxy(t, server)
+
+	certDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(certDir, ProxyCertFileName), credentials.BundleReaderCert, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(certDir, ProxyKeyFileName), credentials.BundleReaderKey, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(certDir, ProxyCACertFileName), credentials.CACert, 0o600))
+
+	// Unix socket paths are length limited, so avoid the long test temp directory
+	dir, err := os.MkdirTemp("", "spireapi")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "relay.sock")
+	listener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- ServeRelay(ctx, listener, address, certDir) }()
+	t.Cleanup(func() {
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	c, err := DialSocket(socketPath)
+	require.NoError(t, err)
+	defer c.Close()
+
+	bundle, err := c.GetBundle(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "example.com", bundle.TrustDomain)
+
+	// The bundle reader credentials do not reach the rest of the server API
+	_, err = c.CreateJoinToken(context.Background(), time.Hour, nil)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Nil(t, server.lastCreate)
+}
+
+func TestRelayWithoutCredentials(t *testing.T) {
+	_, err := relayTLSConfig(t.TempDir(), "localhost:8444")
+	assert.ErrorContains(t, err, "failed to read relay certificate")
+}
@@ -209,6 +209,7 @@ var _ = Describe("Zero Trust Workload Identity Manager", Ordered, func() {
 				"BundleConfigAvailable":            metav1.ConditionTrue,
 				"StatefulSetAvailable":             metav1.ConditionTrue,
 				"TTLConfigurationValid":            metav1.ConditionTrue,
+				"ServerAPICredentialsAvailable":    metav1.ConditionTrue,
 				"Ready":                            metav1.ConditionTrue,
 			}, utils.DefaultTimeout)
 
@@ -336,6 +337,43 @@ var _ = Describe("Zero Trust Workload Identity Manager", Ordered, func() {
 		})
 	})
 
+	Context("SPIRE server API", func() {
+		It("Operator should reach the SPIRE server API through the proxy sidecar", func() {
+			By("Verifying the SPIRE Server pod runs the server API proxy")
+			sts, err := clientset.AppsV1().StatefulSets(utils.OperatorNamespace).Get(testCtx, utils.SpireServerStatefulSetName, metav1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred(), "failed to get SPIRE Server StatefulSet")
+			containerNames := []string{}
+			for _, container := range sts.Spec.Template.Spec.Containers {
+				containerNames = append(containerNames, container.Name)
+			}
+			Expect(containerNames).To(ContainElement("spire-server-api-proxy"), "SPIRE Server pod should run the server API proxy")
+
+			By("Creating a JoinToken, which is issued through the server API")
+			joinToken := &operatorv1alpha1.JoinToken{
+				ObjectMeta: metav1.ObjectMeta{Name: "e2e-server-api"},
+				Spec:       operatorv1alpha1.JoinTokenSpec{TTL: metav1.Duration{Duration: 10 * time.Minute}},
+			}
+			Expect(k8sClient.Create(testCtx, joinToken)).To(Succeed(), "failed to create JoinToken")
+			DeferCleanup(func(ctx context.Context) {
+				_ = client.IgnoreNotFound(k8sClient.Delete(ctx, joinToken))
+			})
+
+			By("Waiting for the JoinToken to become Active")
+			Eventually(func(g Gomega) {
+				current := &operatorv1alpha1.JoinToken{}
+				g.Expect(k8sClient.Get(testCtx, client.ObjectKey{Name: joinToken.Name}, current)).To(Succeed())
+				g.Expect(current.Status.State).To(Equal(operatorv1alpha1.JoinTokenStateActive))
+				g.Expect(current.Status.SecretName).NotTo(BeEmpty())
+
+				secret, err := clientset.CoreV1().Secrets(utils.OperatorNamespace).Get(testCtx, current.Status.SecretName, metav1.GetOptions{})
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(secret.Data).To(HaveKey("token"))
+				g.Expect(secret.Data["token"]).NotTo(BeEmpty())
+			}).WithTimeout(utils.ShortTimeout).WithPolling(utils.ShortInterval).Should(Succeed(),
+				"JoinToken should be issued through the SPIRE server API within %v", utils.ShortTimeout)
+		})
+	})
+
 	Context("OperatorCondition", func() {
 		It("Upgradeable should be True when all operands are ready", func() {
 			By("Verifying Upgradeable condition details")